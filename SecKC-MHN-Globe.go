@@ -16,6 +16,8 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+
+	"SecKC-MHN-Globe/pkg/mhn"
 )
 
 type Connection struct {
@@ -33,55 +35,17 @@ type APIConfig struct {
 
 type APIClient struct {
 	config      *APIConfig
-	httpClient  *http.Client
+	client      *mhn.Client
 	lastEventTS float64
 }
 
-type APIEvent struct {
-	Event     map[string]interface{} `json:"event"`
-	Timestamp float64                `json:"timestamp"`
-	CachedAt  string                 `json:"cached_at"`
-}
-
-type APIResponse struct {
-	Events        []APIEvent `json:"events"`
-	Count         int        `json:"count"`
-	Authenticated bool       `json:"authenticated"`
-	ServerTime    float64    `json:"server_time"`
-}
-
-type GeocodeResponse struct {
-	City struct {
-		Names map[string]string `json:"names"`
-	} `json:"city"`
-	Country struct {
-		ISOCode string            `json:"iso_code"`
-		Names   map[string]string `json:"names"`
-	} `json:"country"`
-	Location struct {
-		Latitude  float64 `json:"latitude"`
-		Longitude float64 `json:"longitude"`
-	} `json:"location"`
-}
-
-type CowrieSession struct {
-	Session         string   `json:"session"`
-	StartTime       string   `json:"startTime"`
-	EndTime         string   `json:"endTime"`
-	PeerIP          string   `json:"peerIP"`
-	PeerPort        int      `json:"peerPort"`
-	HostIP          string   `json:"hostIP"`
-	HostPort        int      `json:"hostPort"`
-	LoggedIn        []string `json:"loggedin"`
-	Credentials     []string `json:"credentials"`
-	Commands        []string `json:"commands"`
-	UnknownCommands []string `json:"unknownCommands"`
-	URLs            []string `json:"urls"`
-	Version         *string  `json:"version"`
-	TTYLog          *string  `json:"ttylog"`
-	Hashes          []string `json:"hashes"`
-	Protocol        string   `json:"protocol"`
-}
+// The API's wire formats and event parsing live in pkg/mhn
+type (
+	APIEvent        = mhn.Event
+	GeocodeResponse = mhn.GeocodeResponse
+	HourlyStats     = mhn.HourlyStats
+	StatsResponse   = mhn.StatsResponse
+)
 
 type Dashboard struct {
 	Connections []Connection
@@ -153,14 +117,6 @@ type LocationInfo struct {
 	Valid     bool
 }
 
-type HourlyStats struct {
-	Date    string         `json:"date"`
-	Hourly  map[string]int `json:"hourly"`
-	Channel string         `json:"channel"`
-}
-
-type StatsResponse []HourlyStats
-
 type StatsManager struct {
 	todayData     StatsResponse
 	yesterdayData StatsResponse
@@ -172,10 +128,8 @@ type StatsManager struct {
 
 func NewAPIClient(config *APIConfig) *APIClient {
 	return &APIClient{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		config:      config,
+		client:      mhn.NewClient(config.BaseURL),
 		lastEventTS: 0,
 	}
 }
@@ -219,22 +173,9 @@ func (g *GeoIPManager) fetchFromAPI(ipStr string) LocationInfo {
 	}
 
 	// Make API call to geocode endpoint
-	url := fmt.Sprintf("%s/geocode/%s", strings.TrimSuffix(g.apiClient.config.BaseURL, "/"), ipStr)
-	resp, err := g.apiClient.httpClient.Get(url)
+	geocodeResp, err := g.apiClient.client.Geocode(ipStr)
 	if err != nil {
-		debugLog("Geocode API: Failed to request %s: %v", ipStr, err)
-		return LocationInfo{Valid: false}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		debugLog("Geocode API: Request failed for %s: status %d", ipStr, resp.StatusCode)
-		return LocationInfo{Valid: false}
-	}
-
-	var geocodeResp GeocodeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&geocodeResp); err != nil {
-		debugLog("Geocode API: Failed to decode response for %s: %v", ipStr, err)
+		debugLog("Geocode API: Request failed for %s: %v", ipStr, err)
 		return LocationInfo{Valid: false}
 	}
 
@@ -312,36 +253,17 @@ func (g *GeoIPManager) GetCacheStats() (int, int) {
 }
 
 func (api *APIClient) GetRecentEvents() ([]APIEvent, error) {
-	url := fmt.Sprintf("%s/feeds/events/recent", strings.TrimSuffix(api.config.BaseURL, "/"))
-	
-	// Add query parameters
-	if api.lastEventTS > 0 {
-		url = fmt.Sprintf("%s?since=%.1f&limit=%d", url, api.lastEventTS, api.config.MaxEvents)
-	} else {
-		url = fmt.Sprintf("%s?limit=%d", url, api.config.MaxEvents)
-	}
-
-	resp, err := api.httpClient.Get(url)
+	events, err := api.client.Events(api.lastEventTS, api.config.MaxEvents)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get events: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API request failed: status %d", resp.StatusCode)
-	}
-
-	var apiResp APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+		return nil, err
 	}
 
 	// Update last event timestamp
-	if len(apiResp.Events) > 0 {
-		api.lastEventTS = apiResp.Events[len(apiResp.Events)-1].Timestamp
+	if len(events) > 0 {
+		api.lastEventTS = events[len(events)-1].Timestamp
 	}
 
-	return apiResp.Events, nil
+	return events, nil
 }
 
 func NewStatsManager() *StatsManager {
@@ -1086,6 +1008,10 @@ func startAPIClient(apiClient *APIClient, dashboard *Dashboard) error {
 		ticker := time.NewTicker(apiClient.config.PollInterval)
 		defer ticker.Stop()
 
+		// Keys of the events at lastProcessedEventTime, so others from the
+		// same second are not mistaken for ones already shown
+		seenAtLast := make(map[string]bool)
+
 		for {
 			select {
 			case <-ticker.C:
@@ -1101,68 +1027,28 @@ func startAPIClient(apiClient *APIClient, dashboard *Dashboard) error {
 
 				for _, apiEvent := range events {
 					// Skip events we've already processed based on timestamp
-					if apiEvent.Timestamp <= lastProcessedEventTime {
+					if apiEvent.Timestamp < lastProcessedEventTime {
 						continue
 					}
 					
 					// Update last processed timestamp
 					if apiEvent.Timestamp > lastProcessedEventTime {
 						lastProcessedEventTime = apiEvent.Timestamp
+						clear(seenAtLast)
+					}
+					key := apiEvent.Key()
+					if seenAtLast[key] {
+						continue
 					}
+					seenAtLast[key] = true
 					
 					// Process each event
-					eventData := apiEvent.Event
-
-					// Extract IP address from the event
-					var ipAddress string
-					if srcIP, ok := eventData["src_ip"].(string); ok {
-						ipAddress = srcIP
-					} else if peerIP, ok := eventData["peerIP"].(string); ok {
-						ipAddress = peerIP
-					}
-
-					if ipAddress == "" {
+					event, ok := mhn.Parse(apiEvent.Event)
+					if !ok {
 						debugLog("API Client: Event has no IP address, skipping")
 						continue
 					}
-
-					// Extract credentials if available
-					var username, password string
-					if loggedin, ok := eventData["loggedin"].([]interface{}); ok && len(loggedin) >= 2 {
-						if user, ok := loggedin[0].(string); ok {
-							username = user
-						}
-						if pass, ok := loggedin[1].(string); ok {
-							password = pass
-						}
-					}
-
-					// Fallback to other credential fields
-					if username == "" {
-						if user, ok := eventData["username"].(string); ok {
-							username = user
-						}
-					}
-					if password == "" {
-						if pass, ok := eventData["password"].(string); ok {
-							password = pass
-						}
-					}
-
-					// Use protocol if no credentials
-					if username == "" && password == "" {
-						if protocol, ok := eventData["protocol"].(string); ok {
-							username = "connection"
-							password = protocol
-						}
-					}
-
-					if username == "" {
-						username = "unknown"
-					}
-					if password == "" {
-						password = "unknown"
-					}
+					ipAddress, username, password := event.SrcIP, event.Username, event.Password
 
 					debugLog("API Client: Adding connection %s:%s@%s", username, password, ipAddress)
 					dashboard.AddConnection(ipAddress, username, password)
@@ -0,0 +1,1346 @@
+// Command SecKC-MHN-Globe-Enhanced shows live honeypot attacks on a rotating
+// globe in the terminal. It parses the flags and config file and wires the
+// api sources and sinks to the tui.
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/config"
+	"SecKC-MHN-Globe/pkg/geoip"
+	"SecKC-MHN-Globe/pkg/globerender"
+	"SecKC-MHN-Globe/pkg/stats"
+	"SecKC-MHN-Globe/pkg/tui"
+)
+
+// loopbackAddr reports whether a host:port listens only on the loopback
+// interface. An empty host listens on every interface.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// configRegistry returns the registry as the config package takes it
+func configRegistry() []config.Option {
+	options := make([]config.Option, len(tui.ConfigOptions))
+	for i, opt := range tui.ConfigOptions {
+		options[i] = config.Option(opt)
+	}
+	return options
+}
+
+var globalGeoIPAvailable bool
+
+var globalDemoStorm *tui.DemoStorm
+
+var debugLogger *log.Logger
+
+func debugLog(format string, v ...interface{}) {
+	if debugLogger != nil {
+		debugLogger.Printf(format, v...)
+	}
+}
+
+// validGeoLang accepts locale tags like "de", "pt-BR" or "zh-CN"
+func validGeoLang(lang string) bool {
+	base, region, _ := strings.Cut(lang, "-")
+	if len(base) < 2 || len(base) > 3 || len(region) > 4 {
+		return false
+	}
+	for _, r := range base + region {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseAPIEndpoints splits a comma separated list of API base URLs, each
+// optionally prefixed with "label=". Unlabeled endpoints are named after
+// their host.
+func ParseAPIEndpoints(spec string) ([]tui.APIEndpoint, error) {
+	var endpoints []tui.APIEndpoint
+	labels := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		label, baseURL, named := strings.Cut(item, "=")
+		if !named || api.IsHTTPURL(item) {
+			label, baseURL = "", item
+		}
+		parsed, err := url.Parse(baseURL)
+		if err != nil || !api.IsHTTPURL(baseURL) || parsed.Host == "" {
+			return nil, fmt.Errorf("%q is not an http:// or https:// URL", baseURL)
+		}
+		if label == "" {
+			label = parsed.Hostname()
+		}
+		if labels[label] {
+			return nil, fmt.Errorf("endpoint %q listed twice (name them with label=URL)", label)
+		}
+		labels[label] = true
+		endpoints = append(endpoints, tui.APIEndpoint{Label: label, URL: baseURL})
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no API URL given")
+	}
+	return endpoints, nil
+}
+
+func createAPIConfig(endpoint tui.APIEndpoint, pollInterval time.Duration, maxEvents int, key, keyHeader, stream string) *api.APIConfig {
+	return &api.APIConfig{
+		Label:        endpoint.Label,
+		BaseURL:      endpoint.URL,
+		PollInterval: pollInterval,
+		MaxEvents:    maxEvents,
+		Key:          key,
+		KeyHeader:    keyHeader,
+		Stream:       stream,
+	}
+}
+
+// quitOnSignal shuts down on SIGINT or SIGTERM the way q does, so a
+// recording or GIF in progress is still written out. A second signal kills
+// the process as usual.
+func quitOnSignal(quit chan bool) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		signal.Stop(sigs)
+		debugLog("Received %v, shutting down", sig)
+		select {
+		case quit <- true:
+		default:
+		}
+	}()
+}
+
+func showHelp() {
+	fmt.Printf(`SecKC-MHN-Globe Enhanced - TUI Earth visualization with honeypot monitoring
+
+DESCRIPTION:
+    Terminal-based application displaying a rotating 3D ASCII globe with a live
+    dashboard of incoming connection attempts. NOW WITH ENHANCED FEATURES!
+
+USAGE:
+    SecKC-MHN-Globe-Enhanced [OPTIONS]
+
+OPTIONS:
+    -h                Show this help message
+    --version         Print the version (and commit, when known) and exit
+    -d <filename>     Enable debug logging to specified file
+    --pprof-addr <addr>
+                      Serve CPU, heap and goroutine profiles from
+                      net/http/pprof on this address (e.g. localhost:6060).
+                      Other addresses need --web-user or --web-token, whose
+                      access policy the profiles then share
+    --bench-render    Time globe rendering at several terminal sizes in each
+                      charset, print the results and exit
+    -s <seconds>      Globe rotation period in seconds (10-300, default: 30)
+    -r <milliseconds> Globe refresh rate in milliseconds (50-1000, default: 100)
+    -m                Enable monochrome mode
+    -a <ratio>        Character aspect ratio (height/width, 1.0-4.0, default: 2.0)
+    -u <url>          Base URL for SecKC API. Several comma separated endpoints,
+                      each optionally label=URL, are polled together, merged,
+                      de-duplicated and tagged by label (column: origin)
+    -e <count>        Maximum events to fetch per API call (1-500, default: 50)
+    -p <duration>     API polling interval (1s-300s, default: 2s)
+    --backfill <dur>  Load recent history (e.g. 1h, up to 24h) at startup so
+                      markers, panels and the timeline start populated
+    --stream <mode>   Receive events over the API's stream endpoint when it has
+                      one: auto|websocket|sse|off (default: auto, falling back
+                      to polling)
+    --api-key <key>   API key or bearer token, sent as Authorization: Bearer
+                      with events and geocode requests
+    --api-key-header <name>
+                      Send the raw key in this header instead, e.g. X-API-Key
+
+NETWORK:
+    --proxy <url>         Proxy for all outbound HTTP requests (API, geocoding,
+                          ipinfo.io, webhooks and sinks): http://, https:// or
+                          socks5:// (default: HTTPS_PROXY / HTTP_PROXY)
+    --ca-cert <file>      PEM CA bundle trusted in addition to the system roots,
+                          e.g. for a private MHN server with an internal CA
+    --tls-skip-verify     Do not verify HTTPS certificates (insecure)
+
+ENHANCED OPTIONS:
+    --charset <type>      Character set: ascii|blocks|braille (default: ascii)
+    --projection <name>   orthographic (the turning globe, default), flat (an
+                          equirectangular world map), mollweide (an
+                          equal-area ellipse) or hemispheres (the near and far
+                          sides as two globes); the flat maps show every attack
+                          at once and stay still, the hemispheres still turn.
+                          J cycles them
+    --color-mode <mode>   Terminal colors: auto|mono|16|256|truecolor; themes are
+                          mapped to the nearest palette colors (default: auto)
+    --unicode <mode>      Unicode support: auto|on|off; off draws the globe and
+                          panels in plain ASCII (default: auto)
+    --theme <name>        Theme: default|matrix|amber|solarized|nord|dracula|mono
+                          rainbow|skittles|deuteranopia|protanopia|tritanopia
+                          high-contrast
+    --arcs <style>        Attack arcs: curved|straight|off (default: off)
+    --trail-ms <ms>       Arc trail persistence in milliseconds (default: 1200)
+    --footprints <dur>    Leave a dim dot where attacks came from, fading out
+                          this long after the last one, so the session's
+                          geography builds up on the globe (default: 10m,
+                          0s disables)
+    --lighting            Enable globe lighting/shading
+    --light-lon <deg>     Light source longitude (-180 to 180)
+    --light-lat <deg>     Light source latitude (-90 to 90)
+    --light-follow        Light rotates opposite to globe
+    --crt                 Enable CRT effects: alternate rows dimmed by the
+                          theme's scanline shade, plus --glow and --crt-curve
+    --glow <level>        Phosphor glow radius 0-3: bright characters bloom
+                          into nearby cells and fade slowly (default: 0)
+    --crt-curve           Bow the picture like a curved tube
+    --rain                Enable Matrix rain effect
+    --rain-density <n>    Rain density 0-10 (default: 5)
+    --rain-mask           Only let rain fall over the ocean and background;
+                          --rain-mask=false lets it fall over land (default: true)
+    --protocol-glyphs     Show protocol-specific glyphs instead of asterisks
+    --wrap                Wrap long dashboard rows onto indented continuation
+                          lines instead of scrolling (toggle with W)
+    --collapse <mode>     Fold each run of consecutive rows from one IP into
+                          one row with its attempt count, e.g. root:admin
+                          (12): off (default), on, or expanded to also list
+                          the credentials the run tried (* cycles them)
+    --columns <spec>      Dashboard columns: compact, normal (default), wide, or
+                          a comma separated list of ip, country, city, proto,
+                          port, creds, time, datetime, age, tag, origin and
+                          org; name:width fixes a column's width, e.g.
+                          ip,country,city:10,creds:16,org
+    --repeat-threshold <n>  Session hits before an IP is a repeat offender: its
+                          marker grows to ✸ (and █ at 4x), and its dashboard
+                          row gets a ×N badge (default: 5)
+    --timeline=false      Hide the session timeline bar under the globe
+    --ticker <mode>       Attack ticker scrolling "IP [CC] user:pass proto"
+                          along the bottom row: auto (default) runs it in the
+                          compact layout of small terminals, on adds it to
+                          the full layout in place of the command guide, off
+                          hides it (~ toggles it)
+    --time-format <fmt>   Dashboard time column: local (default, HH:MM:SS),
+                          relative (age such as 34s or 5m, kept current) or
+                          utc (ISO 8601, e.g. 2026-10-16T15:04:05Z); z cycles
+                          them
+    --legend              Start with the symbol legend open (B toggles it)
+    --honeypots <spec>    Honeypots marked on the globe with ◉ and their name,
+                          as "name,lat,lon", ";" separated. Arcs run to the
+                          one named like the API endpoint label that reported
+                          the attack, or the first (default:
+                          "SecKC,39.0997,-94.5786")
+    --kiosk               Attract mode for wall displays: cycle themes, open and
+                          close panels, and zoom into the busiest region
+    --kiosk-interval <s>  Seconds between kiosk panel changes; themes change
+                          every 2x and zooms happen every 3x (default: 30)
+    --preset-<n> <spec>   Region framed by key 1-9 as "name,lat,lon,zoom",
+                          e.g. --preset-3 "Europe,50,15,2.6"
+    --subcell=false       Snap markers and arcs to whole cells with the braille
+                          charset (default: place them on individual dots)
+    --jitter <deg>        Spread markers over this many degrees, by an amount
+                          seeded by each address, so attackers geolocated to
+                          one city centroid do not all share a cell; cells
+                          shared by 3+ attackers show ✱, 10+ show ● (default:
+                          0.8, 0 plots exact locations)
+    --layers <spec>       Show, hide or dim frame layers: name=on, name=off or
+                          name=<dim 0-1>, comma separated, e.g.
+                          graticule=on,rain=off,dashboard=0.3. Layers, bottom
+                          to top: earth, graticule, rain, heatmap,
+                          footprints, arcs, markers, honeypots, dashboard,
+                          panels, status, toasts, help, palette
+    --quality <level>     Land anti-aliasing: normal bleeds light into
+                          neighbouring cells, high averages 2x2 samples per
+                          cell for smoother coastlines (default: normal)
+    --mouse               Zoom with the mouse wheel about the pointer; hold
+                          Shift to select text while it is on (default: true)
+    --demo-storm          Enable demo storm generator: attackers from the
+                          networks honeypots see most, each trying a run of
+                          credentials typical of its service
+    --demo-rate <n>       Demo attack rate per second (default: 10)
+    --demo-scenario <f>   Script the storm in timed phases, one per line or
+                          comma separated, e.g. "quiet 60s, then 200/s from
+                          CN for 30s"; ramps as 10-300/s, limits as from
+                          <CC|ASN> and proto <name>, plus seed <n> and repeat
+    --demo-replay <src>   Replay geolocated events instead of polling the API,
+                          with no network access: "builtin" plays the sample
+                          capture in the binary, or give an events file (one
+                          enriched event per line, as --hpfeeds-host publishes)
+    --offline             Make no lookups or API requests: no event polling,
+                          stats, geocoding, ipinfo.io or rDNS. Locations come
+                          from the embedded table of attacker networks that
+                          --demo-storm also uses
+    --cowrie-log <file>   Follow a local Cowrie json.log instead of polling the
+                          API, for a honeypot host without an MHN server;
+                          --backfill loads that much of the existing log
+    --zeek <conn.log>     Show connections from a Zeek conn.log (TSV or JSON):
+                          originator as the source, service or destination
+                          port as the protocol; private addresses are skipped
+    --zeek-follow         Keep reading as Zeek appends to the log, following
+                          rotation (the file may not exist yet)
+    --record <file>       Record session to asciinema file
+    --record-format <fmt> cast, events (every live event, timed from the cast
+                          start, in <file>.events.ndjson) or both (default)
+    --export-gif <file>   Export session as an animated GIF, written once the
+                          duration has passed or on exit
+    --gif-duration <dur>  Length of session to capture (default: 20s)
+    --gif-frame-skip <n>  Capture every Nth rendered frame (default: 4)
+    --config <file>       Load settings from TOML config file
+    --generate-config <file>  Write a commented example config (- for stdout)
+    --active-fps <n>      Render rate while active (1-60, default: 20)
+    --idle-fps <n>        Render rate when idle, 0 disables (default: 0)
+    --idle-after <sec>    Seconds without events/keys before idling (default: 30)
+
+EMBEDDED SERVER:
+    --web-addr <addr>     Serve panel data as JSON on this address (e.g. :8080)
+    --web-user <name>     Require HTTP basic auth with this username
+    --web-pass <pass>     Password for --web-user
+    --web-token <token>   Require 'Authorization: Bearer <token>'
+    --web-allow <list>    Comma separated IPs/CIDRs allowed to connect
+    --web-tls-cert <file> Serve over HTTPS with this certificate
+    --web-tls-key <file>  Private key for --web-tls-cert
+    --web-tls-self-signed Serve over HTTPS with a generated self-signed certificate
+
+ASN ENRICHMENT:
+    --asn-db <file>       Local ASN database for offline lookups: MaxMind
+                          GeoLite2-ASN (.mmdb) or iptoasn.com TSV (.tsv/.tsv.gz)
+    --asn-fallback=false  Never query ipinfo.io, even when the database has no match
+    --geo-lang <code>     Language of city and country names, e.g. de, es, fr,
+                          ja, pt-BR, ru, zh-CN (default: en; English is used
+                          when a name has no translation)
+
+ALERTING:
+    --alert-rules <file>  TOML file of [[rule]] alert rules matching country,
+                          ASN, protocol, username, IP/CIDR or per-IP rate;
+                          rules highlight rows, flash markers and POST to
+                          Slack/Discord/generic webhooks (see alerts.example.toml)
+    --sensors <list>      Sensors and services for the coverage panel (F), e.g.
+                          "cowrie-kc:ssh/22 telnet/23,dionaea-kc:smb/445 http/80"
+    --coverage-window <d> Mark a configured service silent after this long
+                          without events (default: 1h)
+    --tags-file <file>    Keep triage tags (Y) in this JSON file across restarts
+    --spectator           Start locked: only panels, help, the command guide and
+                          dashboard scrolling and search respond; quitting,
+                          pausing, camera, settings and screenshots need the
+                          operator
+    --operator-pass <p>   Passphrase that unlocks operator keys after Ctrl+O
+    --banner              Reserve a row above the dashboard for critical alerts
+                          and incidents until acknowledged with Backspace
+    --storm-rate <n>      Events per minute that raise the storm alarm: the
+                          dashboard header flashes and shows where the last
+                          minute's events came from (default: 300, 0 disables)
+    --storm-clear <n>     Events per minute a storm must fall to before it
+                          clears (default: half of --storm-rate)
+    --storm-hold <dur>    How long the rate must stay that low before the
+                          storm clears (default: 30s)
+    --storm-heatmap       Shade countries by attacks while a storm lasts
+
+CREDENTIAL FINDINGS:
+    --findings-window <dur>
+                          Window credential patterns are counted over; usernames
+                          seen in the first one are the baseline (default: 10m)
+    --findings-usernames <n>
+                          Flag an address trying this many distinct usernames
+                          within the window (default: 10, 0 disables)
+    --findings-new-user-rate <n>
+                          Flag a never seen username tried this many times
+                          within the window (default: 20, 0 disables)
+    --findings-hostnames <list>
+                          Honeypot hostnames to flag in passwords, besides the
+                          sensor names events carry and the --honeypots names
+
+MALWARE HASH LOOKUPS:
+    --vt-key <key>        VirusTotal API key: look up the hashes of files
+                          Cowrie saw downloaded and binaries dionaea captured,
+                          shown in the detail panels and the Z panel
+    --bazaar-key <key>    MalwareBazaar Auth-Key, asked about hashes VirusTotal
+                          does not know (or all of them without --vt-key)
+    --hash-lookup-rate <n>
+                          Lookups per minute (default: 4, what VirusTotal's
+                          public API allows); a 429 pauses lookups for 5m
+
+REVERSE DNS:
+    --dns-server <host>   Send reverse lookups to this server (host[:port])
+                          instead of the system resolver
+    --dns-timeout <dur>   Reverse lookup timeout (default: 1s)
+    --dns-workers <n>     Concurrent reverse lookups (default: 4)
+    --dns-negative-ttl <dur>  Remember addresses with no PTR record (default: 30m)
+
+HPFEEDS PUBLISHING:
+    --hpfeeds-host <host>     Re-publish enriched events to this hpfeeds broker
+    --hpfeeds-port <port>     Broker port (default: 10000)
+    --hpfeeds-ident <ident>   Publisher ident
+    --hpfeeds-secret <secret> Publisher secret
+    --hpfeeds-channel <name>  Channel for enriched events (default: seckc.enriched)
+
+SYSLOG FORWARDING:
+    --syslog-forward <url>    Send every live event to a syslog collector:
+                              udp://host[:514], tcp://host[:601] or
+                              tls://host[:6514]
+    --syslog-format <fmt>     rfc5424 (structured data) or cef (default: rfc5424)
+    --syslog-ca <file>        PEM CA bundle for a tls:// collector (default:
+                              system roots)
+
+ELASTICSEARCH / OPENSEARCH:
+    --es-url <url>            Bulk-index every event into this cluster
+    --es-index <name>         Index name with %%Y %%m %%d %%H from the event time
+                              (default: seckc-events-%%Y.%%m.%%d)
+    --es-user <name>          Basic auth username
+    --es-pass <pass>          Basic auth password
+    --es-api-key <key>        API key (base64 id:key) instead of basic auth
+    --es-batch <n>            Events per bulk request (default: 500)
+    --es-flush <dur>          Send a partial batch after this long (default: 5s)
+
+EVENT BUS:
+    --kafka-brokers <list>    Publish enriched events as JSON to Kafka, e.g.
+                              "kafka1:9092,kafka2:9092"
+    --kafka-topic <name>      Topic; records are keyed by source IP
+                              (default: seckc.enriched)
+    --kafka-user <name>       SASL/PLAIN username
+    --kafka-pass <pass>       SASL/PLAIN password
+    --kafka-tls               Connect to the brokers over TLS
+    --nats-url <url>          Publish enriched events to a NATS server:
+                              nats://host[:4222] or tls://host[:4222]
+    --nats-subject <subject>  Subject (default: seckc.enriched)
+    --nats-user <name>        Username
+    --nats-pass <pass>        Password
+    --nats-token <token>      Auth token, instead of a username
+
+MQTT:
+    --mqtt-broker <url>       mqtt://host[:1883] or mqtts://host[:8883]
+    --mqtt-subscribe <filter> Read JSON honeypot events from this topic filter,
+                              e.g. "honeypot/+/events", alongside the API
+    --mqtt-publish <topic>    Publish enriched events to this topic
+    --mqtt-user <name>        Username
+    --mqtt-pass <pass>        Password
+    --mqtt-ca <file>          PEM CA bundle for an mqtts:// broker (default:
+                              system roots)
+    --mqtt-client-id <id>     Client id prefix (default: seckc-globe)
+    --mqtt-qos <n>            0 or 1 for both directions (default: 0)
+
+THREAT INTEL EXPORT:
+    --intel-file <file>       Write attacking addresses as a STIX 2.1 bundle of
+                              indicators and sightings every interval
+    --intel-interval <dur>    Export interval (default: 5m)
+    --intel-window <dur>      Export addresses seen this recently (default: 24h)
+    --intel-min-sightings <n> Skip addresses seen fewer times (default: 1)
+    --taxii-url <url>         Push new indicators to this TAXII 2.1 collection
+                              objects endpoint
+    --taxii-user <name>       TAXII basic auth username
+    --taxii-pass <pass>       TAXII basic auth password
+    --misp-url <url>          Create a MISP event of ip-src attributes for new
+                              addresses every interval
+    --misp-key <key>          MISP automation key
+
+LIMITS (for long-running kiosks):
+    --max-arcs <n>            Maximum live attack arcs (default: 2000)
+    --geo-cache-size <n>      Maximum cached geolocation lookups (default: 2000)
+    --max-cred-attempts <n>   Credential attempts kept for the histogram (default: 50000)
+    --history-size <n>        Events kept for the timeline and scrub mode (default: 20000)
+    --mem-limit <mb>          Shed caches when RSS exceeds this many MB (default: 0, off)
+
+CONFIGURATION PRECEDENCE:
+    defaults < config file < environment < flags
+    Every option has a config key and an environment variable named
+    SECKC_GLOBE_<SECTION>_<KEY>, e.g. SECKC_GLOBE_DISPLAY_THEME=matrix.
+    See --generate-config for the full list.
+
+INTERACTIVE CONTROLS:
+    Space    - Pause/Resume rotation
+    [/]      - Decrease/Increase spin speed
+    </>      - Turn the globe 5° back/forward (handy while paused)
+    +/-      - Zoom in/out, keeping the middle of the view in place
+    Wheel    - Zoom in/out about the mouse pointer (--mouse)
+    Arrows   - Nudge the view, the same stretch of globe at any zoom
+    T        - Cycle through themes
+    C        - Toggle coastlines/grid
+    G        - Toggle great-circle arcs
+    L        - Toggle lighting
+    R        - Toggle Matrix rain
+    K        - Toggle credential pair histogram
+    @        - Toggle top destination ports panel (service names, bar chart)
+    D        - Toggle diagnostics panel (background workers, memory)
+    B        - Toggle symbol legend (markers, arcs, glyphs, land density)
+    W        - Toggle dashboard row wrap (instead of , / . scrolling)
+    U        - Cycle dashboard columns: compact, normal, wide and any
+               --columns layout
+    A        - Toggle alerts log panel
+    F        - Toggle protocol coverage matrix (sensors x protocols; silent
+               configured services are highlighted)
+    #        - Cycle the stats chart: last 24 hours, one day by the hour, or
+               7 or 30 days by the day; ←/→ step the day shown
+    %%        - Choropleth: shade each country's land by its attack count
+    Y        - Triage mode: ↑/↓ select a dashboard row, then 1 investigated,
+               2 false positive, 3 escalated or 0 to clear; tags apply to the
+               row's source IP and show in the Tag column. Y pins the row to
+               the top of the dashboard (up to 5) or unpins it. Esc leaves
+    PgUp/Dn  - Scroll the dashboard back through session history; the
+               separator shows the position and End returns to live
+    /        - Search the session history for a substring or regex (case
+               insensitive) in IP, credentials, city, country, org or rDNS;
+               the dashboard shows only matches, with the matched text
+               highlighted. n/N select the previous/next match (PgUp/PgDn a
+               page), Enter on an empty prompt searches again, Esc clears
+    Tab      - Cycle the dashboard filter: all, tagged, untagged, or one tag
+    Home     - Scrub mode: freeze the view and move a cursor along the session
+               timeline (←/→, PgUp/PgDn) to see globe and dashboard as they
+               were; End or Esc returns to live
+    Bksp/Del - Acknowledge and clear the alert banner lane
+    V        - Follow-attack camera: turn the globe to face each new
+               attack's source before spinning on
+    J        - Cycle the projection: globe, flat world map, Mollweide, hemispheres
+    1-9      - Frame a region preset (North America, South America, Europe,
+               Africa, Middle East, Russia, East Asia, South Asia, Oceania)
+               and hold it there
+    0        - Reset zoom and nudge and let the globe spin again
+    Enter    - Session detail panel: commands, URLs and file hashes of
+               sessions with shell interaction (↑/↓ scroll, ←/→ session)
+    O / F12  - Save screenshot (text + SVG)
+    E        - Export session statistics (top IPs, countries, ASNs,
+               credentials, ports, hourly counts) as JSON, CSV and Markdown
+    M        - Settings menu (theme, charset, arcs, trail, lighting, rain, polling)
+    :        - Command palette (Tab completes, ↑/↓ recalls, Enter runs):
+                 :theme <name>           :charset <name>     :arcs <style>
+                 :layer <name> on|off    :zoom <0.5-3.0>
+                 :projection orthographic|flat|mollweide|hemispheres
+                 :filter <field> <value> (country, proto, port, ip, asn, org,
+                                          city, origin) or :filter off
+                 :goto <lat>,<lon> [zoom] or :goto <region>, e.g. :goto europe
+                 :record <file.cast> or :record stop
+                 :export [last <dur>] ndjson|csv|cef [file]
+                 :export stats [base]    (writes base.json, .csv and .md)
+                 :wordlist [all|<protocol>] [base] (credentials for hydra and
+                                          hashcat: base-users.txt, ...)
+    ?        - Toggle help panel
+    Ctrl+O   - With --spectator: type the operator passphrase and Enter to
+               unlock every key; Ctrl+O again hands the display back
+    Q/X/Esc  - Exit
+
+KEY BINDINGS:
+    --key-<action> <keys>     Remap an action to a comma-separated key list,
+                              e.g. --key-pause=p or --key-theme=t,T; an empty
+                              list unbinds it. Names: a single character,
+                              space, comma, tab, enter, esc, backspace, up,
+                              down, left, right, pgup, pgdn, home, end, f1-f12
+                              or ctrl+<letter>. Conflicts are startup errors;
+                              1-9, Ctrl+C and Ctrl+O cannot be rebound. The
+                              help panel and command guide show live bindings.
+
+EXAMPLES:
+    # Default enhanced mode
+    ./SecKC-MHN-Globe-Enhanced
+
+    # Matrix theme with rain and Braille characters
+    ./SecKC-MHN-Globe-Enhanced --theme matrix --rain --charset braille
+
+    # Attack arcs with lighting
+    ./SecKC-MHN-Globe-Enhanced --arcs curved --lighting --light-follow
+
+    # Fully populated display with no network access or honeypot
+    ./SecKC-MHN-Globe-Enhanced --demo-replay builtin
+
+    # Demo mode with recording
+    ./SecKC-MHN-Globe-Enhanced --demo-storm --demo-rate 50 --record demo.cast
+
+    # Full experience
+    ./SecKC-MHN-Globe-Enhanced --theme matrix --charset braille --arcs curved --lighting --light-follow --rain --protocol-glyphs --crt
+
+`)
+}
+
+func main() {
+	// Basic flags
+	var debugFile = flag.String("d", "", "Debug log filename")
+	var pprofAddr = flag.String("pprof-addr", "", "Serve net/http/pprof profiles on this address")
+	var benchRender = flag.Bool("bench-render", false, "Time globe rendering across terminal sizes and charsets, then exit")
+	var showHelpFlag = flag.Bool("h", false, "Show help")
+	var showVersion = flag.Bool("version", false, "Print the version and exit")
+	var rotationPeriod = flag.Int("s", 30, "Globe rotation period in seconds")
+	var refreshRate = flag.Int("r", 100, "Globe refresh rate in milliseconds")
+	var monochrome = flag.Bool("m", false, "Enable monochrome mode")
+	var aspectRatio = flag.Float64("a", 2.0, "Character aspect ratio")
+	var baseURL = flag.String("u", "https://mhn.h-i-r.net/seckcapi", "Base URL for SecKC API; comma separated for several, each optionally label=URL")
+	var maxEvents = flag.Int("e", 50, "Maximum events to fetch per API call")
+	var backfill = flag.Duration("backfill", 0, "Load this much recent history from the API at startup")
+	var pollInterval = flag.Duration("p", 2*time.Second, "API polling interval")
+	var stream = flag.String("stream", "auto", "Event stream transport: auto|websocket|sse|off")
+	var apiKey = flag.String("api-key", "", "API key or bearer token for the SecKC API")
+	var apiKeyHeader = flag.String("api-key-header", "", "Send the API key in this header instead of Authorization: Bearer")
+	var proxy = flag.String("proxy", "", "Proxy URL for all outbound HTTP requests")
+	var caCert = flag.String("ca-cert", "", "PEM CA bundle to trust for outbound HTTPS in addition to the system roots")
+	var tlsSkipVerify = flag.Bool("tls-skip-verify", false, "Do not verify HTTPS certificates")
+
+	// Enhanced flags
+	var charset = flag.String("charset", "ascii", "Character set: ascii|blocks|braille")
+	var projectionName = flag.String("projection", "orthographic", "Map projection: orthographic|flat|mollweide|hemispheres")
+	var colorMode = flag.String("color-mode", "auto", "Terminal colors: auto|mono|16|256|truecolor")
+	var unicodeMode = flag.String("unicode", "auto", "Terminal Unicode support: auto|on|off")
+	var themeName = flag.String("theme", "default", "Theme name")
+	var arcStyle = flag.String("arcs", "off", "Attack arcs: curved|straight|off")
+	var trailMS = flag.Int("trail-ms", 1200, "Arc trail persistence in milliseconds")
+	var footprints = flag.Duration("footprints", tui.DefaultFootprintTime, "Leave a fading dot where attacks came from for this long (0 disables)")
+	var lighting = flag.Bool("lighting", false, "Enable globe lighting/shading")
+	var lightLon = flag.Float64("light-lon", 0, "Light source longitude")
+	var lightLat = flag.Float64("light-lat", 0, "Light source latitude")
+	var lightFollow = flag.Bool("light-follow", false, "Light follows rotation")
+	var crtEffect = flag.Bool("crt", false, "Enable CRT scanline effect")
+	var glowLevel = flag.Int("glow", 0, "Phosphor glow level 0-3")
+	var crtCurve = flag.Bool("crt-curve", false, "Bow the picture like a curved CRT tube")
+	var rainEffect = flag.Bool("rain", false, "Enable Matrix rain effect")
+	var rainDensity = flag.Int("rain-density", 5, "Rain density 0-10")
+	var rainMask = flag.Bool("rain-mask", true, "Only let rain fall over the ocean and background")
+	var protocolGlyphs = flag.Bool("protocol-glyphs", false, "Show protocol glyphs")
+	var dashboardWrap = flag.Bool("wrap", false, "Wrap long dashboard rows instead of scrolling")
+	var collapse = flag.String("collapse", "off", "Fold consecutive rows from one IP into one: off, on or expanded (listing the credentials tried)")
+	var columns = flag.String("columns", "normal", "Dashboard columns: compact, normal, wide or a list such as ip,country,city:16,creds,org")
+	var repeatThreshold = flag.Int("repeat-threshold", tui.DefaultRepeatThreshold, "Session hits from one IP before it is marked as a repeat offender")
+	var showTimeline = flag.Bool("timeline", true, "Show the session timeline bar under the globe")
+	var tickerMode = flag.String("ticker", "auto", "Attack ticker along the bottom row: auto (compact layout only), on or off")
+	var timeFormat = flag.String("time-format", "local", "Dashboard time column: local (HH:MM:SS), relative (age) or utc (ISO 8601)")
+	var showLegend = flag.Bool("legend", false, "Start with the symbol legend open")
+	var honeypotSpec = flag.String("honeypots", tui.DefaultHoneypots, "Honeypots marked on the globe as name,lat,lon[;name,lat,lon...]")
+	var historySize = flag.Int("history-size", tui.DefaultHistorySize, "Events kept for the timeline and scrub mode")
+	var kioskMode = flag.Bool("kiosk", false, "Attract mode: cycle themes and panels and zoom into the busiest region")
+	var kioskInterval = flag.Int("kiosk-interval", tui.DefaultKioskInterval, "Seconds between kiosk panel changes")
+	var presetSpecs [9]*string
+	for i := range presetSpecs {
+		presetSpecs[i] = flag.String(fmt.Sprintf("preset-%d", i+1), tui.DefaultViewPresets[i], fmt.Sprintf("View preset for key %d as name,lat,lon,zoom", i+1))
+	}
+	keySpecs := make(map[string]*string)
+	for _, action := range tui.KeyActions {
+		keySpecs[action.Name] = flag.String("key-"+strings.ReplaceAll(action.Name, "_", "-"), action.Defaults, "Keys for: "+action.Help)
+	}
+	var subCell = flag.Bool("subcell", true, "Place markers and arcs on individual Braille dots (braille charset only)")
+	var jitter = flag.Float64("jitter", api.DefaultJitter, "Degrees markers are spread over around their location")
+	var layerSpec = flag.String("layers", "", "Layers to show, hide or dim, e.g. graticule=on,rain=off,dashboard=0.3")
+	var quality = flag.String("quality", "normal", "Land anti-aliasing: normal|high (2x2 supersampling)")
+	var mouse = flag.Bool("mouse", true, "Zoom with the mouse wheel about the pointer")
+	var demoStorm = flag.Bool("demo-storm", false, "Enable demo storm generator")
+	var demoRate = flag.Int("demo-rate", 10, "Demo attack rate per second")
+	var demoScenario = flag.String("demo-scenario", "", "Drive the demo storm from a scenario file of timed phases")
+	var demoReplay = flag.String("demo-replay", "", "Replay enriched events from a file, or the built-in sample with \"builtin\"")
+	var offline = flag.Bool("offline", false, "Make no API requests or network lookups; locate addresses from the embedded network table")
+	var recordFile = flag.String("record", "", "Record to asciinema file")
+	var recordFormat = flag.String("record-format", "both", "What --record writes: cast|events|both")
+	var configFile = flag.String("config", "", "Load from TOML config file")
+	var webAddr = flag.String("web-addr", "", "Serve the panel data API on this address (e.g. :8080)")
+	var webUser = flag.String("web-user", "", "Require HTTP basic auth username for the web server")
+	var webPass = flag.String("web-pass", "", "HTTP basic auth password for the web server")
+	var webToken = flag.String("web-token", "", "Require this bearer token for the web server")
+	var webAllow = flag.String("web-allow", "", "Comma separated IPs/CIDRs allowed to use the web server")
+	var webTLSCert = flag.String("web-tls-cert", "", "TLS certificate file for the web server")
+	var webTLSKey = flag.String("web-tls-key", "", "TLS private key file for the web server")
+	var webTLSSelfSigned = flag.Bool("web-tls-self-signed", false, "Serve the web server over TLS with a generated self-signed certificate")
+	var exportGIF = flag.String("export-gif", "", "Export the session as an animated GIF")
+	var gifDuration = flag.Duration("gif-duration", 20*time.Second, "Length of session to capture for GIF export")
+	var gifFrameSkip = flag.Int("gif-frame-skip", 4, "Capture every Nth rendered frame for GIF export")
+	var generateConfig = flag.String("generate-config", "", "Write a commented example TOML config to file (- for stdout)")
+	var activeFPS = flag.Int("active-fps", 20, "Render rate while events or keys are arriving")
+	var idleFPS = flag.Int("idle-fps", 0, "Render rate when idle (0 disables idle throttling)")
+	var idleAfter = flag.Int("idle-after", 30, "Seconds without events or keys before idling")
+	var hpfeedsHost = flag.String("hpfeeds-host", "", "hpfeeds broker host for publishing enriched events")
+	var hpfeedsPort = flag.Int("hpfeeds-port", 10000, "hpfeeds broker port")
+	var hpfeedsIdent = flag.String("hpfeeds-ident", "", "hpfeeds publisher ident")
+	var hpfeedsSecret = flag.String("hpfeeds-secret", "", "hpfeeds publisher secret")
+	var hpfeedsChannel = flag.String("hpfeeds-channel", "seckc.enriched", "hpfeeds channel for enriched events")
+	var syslogForward = flag.String("syslog-forward", "", "Forward events to this syslog collector (udp://, tcp:// or tls://host[:port])")
+	var syslogFormat = flag.String("syslog-format", "rfc5424", "Syslog message format: rfc5424 or cef")
+	var syslogCA = flag.String("syslog-ca", "", "PEM CA bundle to verify a tls:// syslog collector")
+	var esURL = flag.String("es-url", "", "Elasticsearch/OpenSearch URL to bulk-index events into")
+	var esIndex = flag.String("es-index", api.DefaultESIndex, "Index name; %Y, %m, %d and %H come from the event time")
+	var esUser = flag.String("es-user", "", "Elasticsearch basic auth username")
+	var esPass = flag.String("es-pass", "", "Elasticsearch basic auth password")
+	var esAPIKey = flag.String("es-api-key", "", "Elasticsearch API key (base64 id:key)")
+	var esBatch = flag.Int("es-batch", 500, "Events per bulk request")
+	var esFlush = flag.Duration("es-flush", 5*time.Second, "Send a partial batch after this long")
+	var kafkaBrokers = flag.String("kafka-brokers", "", "Comma separated Kafka bootstrap brokers (host:port)")
+	var kafkaTopic = flag.String("kafka-topic", "seckc.enriched", "Kafka topic for enriched events")
+	var kafkaUser = flag.String("kafka-user", "", "Kafka SASL/PLAIN username")
+	var kafkaPass = flag.String("kafka-pass", "", "Kafka SASL/PLAIN password")
+	var kafkaTLS = flag.Bool("kafka-tls", false, "Connect to the Kafka brokers over TLS")
+	var natsURL = flag.String("nats-url", "", "NATS server for enriched events (nats://host:4222 or tls://host:4222)")
+	var natsSubject = flag.String("nats-subject", "seckc.enriched", "NATS subject for enriched events")
+	var natsUser = flag.String("nats-user", "", "NATS username")
+	var natsPass = flag.String("nats-pass", "", "NATS password")
+	var natsToken = flag.String("nats-token", "", "NATS auth token")
+	var mqttBroker = flag.String("mqtt-broker", "", "MQTT broker (mqtt://host:1883 or mqtts://host:8883)")
+	var mqttUser = flag.String("mqtt-user", "", "MQTT username")
+	var mqttPass = flag.String("mqtt-pass", "", "MQTT password")
+	var mqttCA = flag.String("mqtt-ca", "", "PEM CA bundle to verify an mqtts:// broker")
+	var mqttClientID = flag.String("mqtt-client-id", "seckc-globe", "MQTT client id prefix")
+	var mqttQoS = flag.Int("mqtt-qos", 0, "MQTT QoS for subscribing and publishing (0 or 1)")
+	var mqttSubscribe = flag.String("mqtt-subscribe", "", "Read JSON honeypot events from this MQTT topic filter")
+	var mqttPublish = flag.String("mqtt-publish", "", "Publish enriched events to this MQTT topic")
+	var zeekLog = flag.String("zeek", "", "Zeek conn.log (TSV or JSON) to show connections from")
+	var zeekFollow = flag.Bool("zeek-follow", false, "Keep reading the Zeek log as it grows, across rotation")
+	var cowrieLog = flag.String("cowrie-log", "", "Follow a local Cowrie json.log instead of polling the honeypot API")
+	var intelInterval = flag.Duration("intel-interval", api.DefaultIntelInterval, "How often to write the STIX feed and push to TAXII/MISP")
+	var intelWindow = flag.Duration("intel-window", api.DefaultIntelWindow, "Export addresses seen within this window")
+	var intelMinSightings = flag.Int("intel-min-sightings", 1, "Only export addresses seen at least this many times")
+	var intelFile = flag.String("intel-file", "", "Write the STIX 2.1 bundle to this file every interval")
+	var taxiiURL = flag.String("taxii-url", "", "TAXII 2.1 collection objects endpoint to push indicators to")
+	var taxiiUser = flag.String("taxii-user", "", "TAXII basic auth username")
+	var taxiiPass = flag.String("taxii-pass", "", "TAXII basic auth password")
+	var mispURL = flag.String("misp-url", "", "MISP instance to create events in")
+	var mispKey = flag.String("misp-key", "", "MISP automation key")
+	var asnDBPath = flag.String("asn-db", "", "Local ASN database (GeoLite2-ASN .mmdb or iptoasn .tsv/.tsv.gz)")
+	var asnFallback = flag.Bool("asn-fallback", true, "Query ipinfo.io when the local ASN database has no match")
+	var geoLang = flag.String("geo-lang", "en", "Language of city and country names (e.g. de, ja, zh-CN)")
+	var alertRulesPath = flag.String("alert-rules", "", "TOML file of alert rules")
+	var sensorList = flag.String("sensors", "", "Comma separated sensors as name:service/port ... for the coverage panel")
+	var spectator = flag.Bool("spectator", false, "Start locked so only view toggles work until the operator unlocks")
+	var operatorPass = flag.String("operator-pass", "", "Passphrase that unlocks operator keys after Ctrl+O")
+	var tagsFile = flag.String("tags-file", "", "JSON file to keep triage tags in across restarts")
+	var coverageWindow = flag.Duration("coverage-window", tui.DefaultCoverageWindow, "Mark configured services silent after this long without events")
+	var showBanner = flag.Bool("banner", false, "Reserve a row above the dashboard for alerts and incidents")
+	var stormRate = flag.Int("storm-rate", tui.DefaultStormRate, "Events per minute that raise the storm alarm (0 disables)")
+	var stormClear = flag.Int("storm-clear", 0, "Events per minute a storm must fall to before it clears (0 for half of --storm-rate)")
+	var stormHold = flag.Duration("storm-hold", tui.DefaultStormHold, "How long the rate must stay down before a storm clears")
+	var stormHeatmap = flag.Bool("storm-heatmap", false, "Shade countries by attacks while a storm lasts")
+	var findingsWindow = flag.Duration("findings-window", tui.DefaultFindingsWindow, "Window credential patterns are counted over")
+	var findingsUsernames = flag.Int("findings-usernames", tui.DefaultFindingsUsernames, "Distinct usernames from one address within the window that make a spray (0 disables)")
+	var findingsNewUserRate = flag.Int("findings-new-user-rate", tui.DefaultFindingsNewUserRate, "Tries of a never seen username within the window that make a finding (0 disables)")
+	var findingsHostnames = flag.String("findings-hostnames", "", "Comma separated honeypot hostnames to flag in passwords")
+	var virusTotalKey = flag.String("vt-key", "", "VirusTotal API key for malware hash lookups")
+	var bazaarKey = flag.String("bazaar-key", "", "MalwareBazaar Auth-Key for malware hash lookups")
+	var hashLookupRate = flag.Int("hash-lookup-rate", tui.DefaultHashLookupRate, "Malware hash lookups per minute")
+	var dnsServer = flag.String("dns-server", "", "DNS server for reverse lookups (host[:port])")
+	var dnsTimeout = flag.Duration("dns-timeout", time.Second, "Reverse lookup timeout")
+	var dnsWorkers = flag.Int("dns-workers", 4, "Concurrent reverse lookups")
+	var dnsNegativeTTL = flag.Duration("dns-negative-ttl", 30*time.Minute, "How long to remember addresses with no PTR record")
+	var maxArcs = flag.Int("max-arcs", tui.DefaultMaxArcs, "Maximum live attack arcs")
+	var geoCacheSize = flag.Int("geo-cache-size", api.DefaultGeoCacheSize, "Maximum cached geolocation lookups")
+	var maxCredAttempts = flag.Int("max-cred-attempts", tui.DefaultMaxCredAttempts, "Maximum credential attempts kept for the histogram")
+	var memLimit = flag.Int("mem-limit", 0, "Shed cached data when RSS exceeds this many MB (0 disables)")
+
+	flag.Parse()
+
+	if *showHelpFlag {
+		showHelp()
+		os.Exit(0)
+	}
+
+	if *showVersion {
+		fmt.Printf("SecKC-MHN-Globe Enhanced %s\n", api.BuildVersion())
+		os.Exit(0)
+	}
+
+	if *benchRender {
+		tui.RunRenderBenchmark(os.Stdout, *aspectRatio)
+		return
+	}
+
+	if *generateConfig != "" {
+		out := os.Stdout
+		if *generateConfig != "-" {
+			file, err := os.Create(*generateConfig)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating config file: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := config.Generate(out, configRegistry(), flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating config: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Merge defaults < config file < environment < flags
+	sources, err := config.Merge(flag.CommandLine, configRegistry(), *configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Validate parameters, naming the key and source of every bad value
+	var problems []string
+	check := func(flagName string, ok bool, msg string) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s", sources.Describe(flagName), msg))
+		}
+	}
+	check("s", *rotationPeriod >= 10 && *rotationPeriod <= 300, "rotation period must be between 10 and 300 seconds")
+	check("r", *refreshRate >= 50 && *refreshRate <= 1000, "refresh rate must be between 50 and 1000 milliseconds")
+	check("repeat-threshold", *repeatThreshold >= 2, "threshold must be at least 2")
+	columnLayout, columnsErr := tui.ParseColumnLayout(*columns)
+	check("columns", columnsErr == nil, fmt.Sprint(columnsErr))
+	check("kiosk-interval", *kioskInterval >= 5 && *kioskInterval <= 600, "must be between 5 and 600 seconds")
+	honeypots, honeypotsErr := tui.ParseHoneypots(*honeypotSpec)
+	check("honeypots", honeypotsErr == nil, fmt.Sprint(honeypotsErr))
+	var viewPresets [9]tui.ViewPreset
+	for i, spec := range presetSpecs {
+		preset, err := tui.ParseViewPreset(*spec)
+		if err != nil {
+			check(fmt.Sprintf("preset-%d", i+1), false, err.Error())
+		}
+		viewPresets[i] = preset
+	}
+	check("a", *aspectRatio >= 1.0 && *aspectRatio <= 4.0, "aspect ratio must be between 1.0 and 4.0")
+	check("e", *maxEvents >= 1 && *maxEvents <= 500, "max events must be between 1 and 500")
+	check("p", *pollInterval >= time.Second && *pollInterval <= 300*time.Second, "poll interval must be between 1s and 300s")
+	check("backfill", *backfill >= 0 && *backfill <= 24*time.Hour, "must be between 0s and 24h")
+	apiEndpoints, endpointsErr := ParseAPIEndpoints(*baseURL)
+	check("u", endpointsErr == nil, fmt.Sprint(endpointsErr))
+	check("stream", tui.IndexOf(api.StreamModes, *stream) >= 0, fmt.Sprintf("unknown transport %q (use auto, websocket, sse or off)", *stream))
+	check("api-key-header", *apiKeyHeader == "" || *apiKey != "", "api.key is required when api.key_header is set")
+	check("api-key-header", *apiKeyHeader == "" || api.ValidHeaderName(*apiKeyHeader), "not a valid HTTP header name")
+	var proxyURL *url.URL
+	if *proxy != "" {
+		proxyURL, err = api.ParseProxyURL(*proxy)
+		check("proxy", err == nil, fmt.Sprint(err))
+	}
+	var caPool *x509.CertPool
+	if *caCert != "" {
+		caPool, err = api.LoadCABundle(*caCert)
+		check("ca-cert", err == nil, fmt.Sprint(err))
+	}
+	if proxyURL != nil || caPool != nil || *tlsSkipVerify {
+		api.HTTPTransport = api.NewHTTPTransport(proxyURL, caPool, *tlsSkipVerify)
+	}
+	check("theme", tui.Themes[*themeName] != nil, fmt.Sprintf("unknown theme %q", *themeName))
+	check("charset", tui.IndexOf(tui.CharsetNames, *charset) >= 0, fmt.Sprintf("unknown charset %q", *charset))
+	layersErr := tui.NewCompositor().ApplyLayerSpec(*layerSpec)
+	check("layers", layersErr == nil, fmt.Sprint(layersErr))
+	keyBindings := make(map[string]string)
+	for name, spec := range keySpecs {
+		keyBindings[name] = *spec
+	}
+	keyMap, keysErr := tui.NewKeyMap(keyBindings)
+	if bindErr, ok := keysErr.(*tui.KeyBindingError); ok {
+		check("key-"+strings.ReplaceAll(bindErr.Action, "_", "-"), false, bindErr.Err.Error())
+	}
+	check("quality", tui.IndexOf(tui.QualityNames, *quality) >= 0, fmt.Sprintf("unknown quality %q (use normal or high)", *quality))
+	check("ticker", slices.Contains(tui.TickerModes, *tickerMode), fmt.Sprintf("unknown ticker mode %q (use %s)", *tickerMode, strings.Join(tui.TickerModes, ", ")))
+	check("collapse", slices.Contains(tui.CollapseModes, *collapse), fmt.Sprintf("unknown collapse mode %q (use %s)", *collapse, strings.Join(tui.CollapseModes, ", ")))
+	check("time-format", slices.Contains(tui.TimeFormats, *timeFormat), fmt.Sprintf("unknown time format %q (use %s)", *timeFormat, strings.Join(tui.TimeFormats, ", ")))
+	check("jitter", *jitter >= 0 && *jitter <= 2, "must be between 0 and 2 degrees")
+	projection, projectionOK := globerender.ParseProjection(*projectionName)
+	check("projection", projectionOK, fmt.Sprintf("unknown projection %q (use %s)", *projectionName, strings.Join(globerender.ProjectionNames, ", ")))
+	check("color-mode", *colorMode == "auto" || tui.IndexOf(tui.ColorModeNames, *colorMode) >= 0, fmt.Sprintf("unknown color mode %q", *colorMode))
+	check("unicode", tui.IndexOf(tui.UnicodeModes, *unicodeMode) >= 0, fmt.Sprintf("unknown unicode mode %q", *unicodeMode))
+	check("active-fps", *activeFPS >= 1 && *activeFPS <= 60, "active FPS must be between 1 and 60")
+	check("idle-fps", *idleFPS >= 0 && *idleFPS <= *activeFPS, "idle FPS must be between 0 and the active FPS")
+	check("idle-after", *idleAfter >= 1, "idle timeout must be at least 1 second")
+	check("arcs", tui.IndexOf(tui.ArcStyles, *arcStyle) >= 0, fmt.Sprintf("unknown arc style %q", *arcStyle))
+	check("trail-ms", *trailMS >= 100 && *trailMS <= 10000, "trail must be between 100 and 10000 milliseconds")
+	check("footprints", *footprints >= 0 && *footprints <= 24*time.Hour, "must be between 0s and 24h")
+	check("glow", *glowLevel >= 0 && *glowLevel <= 3, "glow level must be between 0 and 3")
+	check("rain-density", *rainDensity >= 0 && *rainDensity <= 10, "rain density must be between 0 and 10")
+	check("light-lon", *lightLon >= -180 && *lightLon <= 180, "longitude must be between -180 and 180")
+	check("light-lat", *lightLat >= -90 && *lightLat <= 90, "latitude must be between -90 and 90")
+	check("demo-rate", *demoRate >= 1 && *demoRate <= tui.MaxDemoRate, "demo rate must be between 1 and 1000 per second")
+	var scenario *tui.DemoScenario
+	if *demoScenario != "" {
+		var err error
+		scenario, err = tui.LoadDemoScenario(*demoScenario)
+		if err != nil {
+			check("demo-scenario", false, err.Error())
+		}
+	}
+	if *demoReplay != "" && *demoReplay != "builtin" {
+		_, err := os.Stat(*demoReplay)
+		check("demo-replay", err == nil, "must be \"builtin\" or a readable events file")
+	}
+	check("record-format", slices.Contains(tui.RecordFormats, *recordFormat), "record format must be one of "+strings.Join(tui.RecordFormats, ", "))
+	check("gif-duration", *gifDuration > 0, "GIF duration must be positive")
+	check("gif-frame-skip", *gifFrameSkip >= 1, "GIF frame skip must be at least 1")
+	check("web-pass", *webUser == "" || *webPass != "", "a password is required when web.user is set")
+	check("pprof-addr", *pprofAddr == "" || loopbackAddr(*pprofAddr) || *webUser != "" || *webToken != "",
+		"must be a loopback address such as localhost:6060 unless web.user or web.token is set")
+	check("dns-timeout", *dnsTimeout >= 100*time.Millisecond && *dnsTimeout <= 10*time.Second, "timeout must be between 100ms and 10s")
+	check("dns-workers", *dnsWorkers >= 1 && *dnsWorkers <= 64, "workers must be between 1 and 64")
+	check("dns-negative-ttl", *dnsNegativeTTL >= 0, "must not be negative")
+	check("coverage-window", *coverageWindow >= time.Minute, "must be at least 1m")
+	check("storm-rate", *stormRate >= 0, "must not be negative")
+	check("storm-clear", *stormClear >= 0 && (*stormRate == 0 || *stormClear < *stormRate), "must be below storm-rate")
+	check("storm-hold", *stormHold >= 0, "must not be negative")
+	check("findings-window", *findingsWindow >= time.Minute, "must be at least 1m")
+	check("findings-usernames", *findingsUsernames >= 0, "must not be negative")
+	check("findings-new-user-rate", *findingsNewUserRate >= 0, "must not be negative")
+	check("hash-lookup-rate", *hashLookupRate >= 1, "must be at least 1")
+	sensors, err := tui.ParseSensorSpecs(*sensorList)
+	if err != nil {
+		check("sensors", false, err.Error())
+	}
+	tagStore, err := tui.NewTagStore(*tagsFile)
+	if err != nil {
+		check("tags-file", false, err.Error())
+	}
+	check("geo-lang", validGeoLang(*geoLang), fmt.Sprintf("invalid language code %q", *geoLang))
+	check("max-arcs", *maxArcs >= 1, "must be at least 1")
+	check("geo-cache-size", *geoCacheSize >= 1, "must be at least 1")
+	check("max-cred-attempts", *maxCredAttempts >= 1, "must be at least 1")
+	check("history-size", *historySize >= 100, "must be at least 100")
+	check("mem-limit", *memLimit >= 0, "must be 0 (disabled) or a size in MB")
+	check("hpfeeds-port", *hpfeedsPort >= 1 && *hpfeedsPort <= 65535, "port must be between 1 and 65535")
+	check("hpfeeds-ident", *hpfeedsHost == "" || *hpfeedsIdent != "", "an ident is required when hpfeeds.host is set")
+	check("hpfeeds-secret", *hpfeedsHost == "" || *hpfeedsSecret != "", "a secret is required when hpfeeds.host is set")
+	check("syslog-format", tui.IndexOf(api.SyslogFormats, *syslogFormat) >= 0, fmt.Sprintf("unknown format %q (use rfc5424 or cef)", *syslogFormat))
+	var syslogForwarder *api.SyslogForwarder
+	if *syslogForward != "" {
+		syslogForwarder, err = api.NewSyslogForwarder(*syslogForward, *syslogFormat, *syslogCA)
+		if err != nil {
+			check("syslog-forward", false, err.Error())
+		}
+	}
+	check("es-url", *esURL == "" || api.IsHTTPURL(*esURL), "must be an http:// or https:// URL")
+	esIndexSample := api.ESIndexName(*esIndex, time.Now())
+	check("es-index", esIndexSample != "" && esIndexSample == strings.ToLower(esIndexSample) && !strings.ContainsAny(esIndexSample, `\/*?"<>| ,#`),
+		"index names must be lowercase without spaces or \\ / * ? \" < > | , #")
+	check("es-pass", *esUser == "" || *esPass != "", "a password is required when elasticsearch.user is set")
+	check("es-batch", *esBatch >= 1 && *esBatch <= 10000, "must be between 1 and 10000")
+	check("es-flush", *esFlush >= time.Second && *esFlush <= 5*time.Minute, "must be between 1s and 5m")
+	var kafkaBrokerList []string
+	for _, broker := range strings.Split(*kafkaBrokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			_, _, err := net.SplitHostPort(broker)
+			check("kafka-brokers", err == nil, fmt.Sprintf("broker %q must be host:port", broker))
+			kafkaBrokerList = append(kafkaBrokerList, broker)
+		}
+	}
+	check("kafka-topic", len(kafkaBrokerList) == 0 || *kafkaTopic != "", "a topic is required when kafka.brokers is set")
+	check("kafka-pass", *kafkaUser == "" || *kafkaPass != "", "a password is required when kafka.user is set")
+	var natsTransport *api.NATSTransport
+	if *natsURL != "" {
+		natsTransport, err = api.NewNATSTransport(*natsURL, *natsSubject, *natsUser, *natsPass, *natsToken)
+		if err != nil {
+			check("nats-url", false, err.Error())
+		}
+		check("nats-subject", *natsSubject != "" && !strings.ContainsAny(*natsSubject, " \t\r\n"), "subject must be non-empty without whitespace")
+	}
+	check("nats-pass", *natsUser == "" || *natsPass != "", "a password is required when nats.user is set")
+	check("mqtt-qos", *mqttQoS == 0 || *mqttQoS == 1, "QoS must be 0 or 1")
+	check("mqtt-broker", *mqttBroker != "" || (*mqttSubscribe == "" && *mqttPublish == ""), "a broker is required for mqtt.subscribe and mqtt.publish")
+	check("mqtt-pass", *mqttUser == "" || *mqttPass != "", "a password is required when mqtt.user is set")
+	check("mqtt-publish", !strings.ContainsAny(*mqttPublish, "+#"), "a publish topic cannot contain wildcards")
+	var mqttSource, mqttSink *api.MQTTClient
+	if *mqttBroker != "" {
+		// Source and sink get their own sessions; a broker drops the older of
+		// two connections with the same client id
+		var mqttErr error
+		if *mqttSubscribe != "" {
+			mqttSource, mqttErr = api.NewMQTTClient(*mqttBroker, *mqttClientID+"-src", *mqttUser, *mqttPass, *mqttCA, *mqttQoS)
+		}
+		if mqttErr == nil && *mqttPublish != "" {
+			mqttSink, mqttErr = api.NewMQTTClient(*mqttBroker, *mqttClientID+"-pub", *mqttUser, *mqttPass, *mqttCA, *mqttQoS)
+		}
+		if mqttErr != nil {
+			check("mqtt-broker", false, mqttErr.Error())
+		}
+	}
+	if *cowrieLog != "" {
+		_, err := os.Stat(*cowrieLog)
+		check("cowrie-log", err == nil, fmt.Sprint(err))
+		check("cowrie-log", *demoReplay == "", "cannot be combined with demo.replay")
+	}
+	if *zeekLog != "" && !*zeekFollow {
+		_, err := os.Stat(*zeekLog)
+		check("zeek", err == nil, fmt.Sprint(err))
+	}
+	check("intel-interval", *intelInterval >= 10*time.Second, "must be at least 10s")
+	check("intel-window", *intelWindow >= time.Minute, "must be at least 1m")
+	check("intel-min-sightings", *intelMinSightings >= 1, "must be at least 1")
+	check("taxii-url", *taxiiURL == "" || api.IsHTTPURL(*taxiiURL), "must be an http:// or https:// URL")
+	check("taxii-pass", *taxiiUser == "" || *taxiiPass != "", "a password is required when taxii.user is set")
+	check("misp-url", *mispURL == "" || api.IsHTTPURL(*mispURL), "must be an http:// or https:// URL")
+	check("misp-key", *mispURL == "" || *mispKey != "", "a key is required when misp.url is set")
+	webAllowNets, err := api.ParseAllowList(*webAllow)
+	if err != nil {
+		check("web-allow", false, err.Error())
+	}
+	var asnDB geoip.ASNDatabase
+	if *asnDBPath != "" {
+		asnDB, err = geoip.OpenASN(*asnDBPath)
+		if err != nil {
+			check("asn-db", false, err.Error())
+		}
+	}
+	var alertRules []tui.AlertRule
+	if *alertRulesPath != "" {
+		alertRules, err = tui.LoadAlertRules(*alertRulesPath)
+		if err != nil {
+			check("alert-rules", false, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", problem)
+		}
+		os.Exit(1)
+	}
+	webPolicy := &api.AccessPolicy{Username: *webUser, Password: *webPass, Token: *webToken, Allow: webAllowNets}
+
+	// Debug logging
+	if *debugFile != "" {
+		file, err := os.OpenFile(*debugFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening debug log: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		debugLogger = log.New(file, "", log.LstdFlags|log.Lmicroseconds)
+		api.SetDebugLogger(debugLogger)
+		tui.SetDebugLogger(debugLogger)
+		debugLog("SecKC-MHN-Globe Enhanced %s starting", api.BuildVersion())
+	}
+	api.Workers.OnFailure(func(name string, err error) {
+		tui.PostBanner("critical", "Worker %s failed: %v", name, err)
+	})
+
+	// Initialize theme
+	if *monochrome {
+		*themeName = "mono"
+	}
+	tui.SetActiveTheme(tui.Themes[*themeName])
+	debugLog("Theme: %s", tui.ActiveTheme().Name)
+	for _, opt := range configRegistry() {
+		value := flag.Lookup(opt.Flag).Value.String()
+		if opt.Key == "pass" || opt.Key == "token" || opt.Key == "secret" || opt.Key == "key" || opt.Key == "api_key" ||
+			opt.Key == "virustotal_key" || opt.Key == "bazaar_key" {
+			value = "(hidden)"
+		} else if opt.Flag == "proxy" && proxyURL != nil {
+			value = proxyURL.Redacted()
+		}
+		debugLog("Config: %s = %s (%s)", opt.Name(), value, sources.From(opt.Flag))
+	}
+
+	// Parse charset
+	charsetType := tui.ParseCharset(*charset)
+	debugLog("Charset: %s", *charset)
+
+	rand.Seed(time.Now().UnixNano())
+
+	// Initialize API, one client per endpoint
+	for _, endpoint := range apiEndpoints {
+		apiConfig := createAPIConfig(endpoint, *pollInterval, *maxEvents, *apiKey, *apiKeyHeader, *stream)
+		api.Clients = append(api.Clients, api.NewAPIClient(apiConfig))
+	}
+	for _, client := range api.Clients {
+		client.OnStatusChange(tui.ToastAPIStatus(client))
+	}
+	apiClient := api.Clients[0]
+	api.PrimaryClient = apiClient
+
+	// Initialize GeoIP; a replay brings its own locations and stays offline
+	geoAPI := apiClient
+	if *demoReplay != "" || *offline {
+		geoAPI = nil
+	}
+	geoIPManager := api.NewGeoIPManager(geoAPI)
+	geoIPManager.SetMaxCache(*geoCacheSize)
+	geoIPManager.SetLanguage(*geoLang)
+	geoIPManager.SetJitter(*jitter)
+	if *demoStorm || scenario != nil || *offline {
+		geoIPManager.SetFixtures(tui.NewGeoFixtures())
+		debugLog("GeoIP: Locating addresses in the embedded network table first")
+	}
+	rdnsResolver := api.NewReverseResolver(*dnsServer, *dnsWorkers, *dnsTimeout, *dnsNegativeTTL)
+	rdnsResolver.Start()
+	geoIPManager.SetReverseResolver(rdnsResolver)
+	if *dnsServer != "" {
+		debugLog("rDNS: Using DNS server %s", rdnsResolver.Server())
+	}
+	if asnDB != nil {
+		geoIPManager.SetASNDatabase(asnDB, *asnFallback)
+		defer asnDB.Close()
+		debugLog("ASN: Using local database %s (ipinfo.io fallback: %v)", *asnDBPath, *asnFallback)
+	}
+	tui.GlobalGeoIP = geoIPManager
+	globalGeoIPAvailable = true
+
+	// Initialize Arc Manager
+	tui.GlobalArcManager = tui.NewArcManager(*arcStyle, *trailMS)
+	tui.GlobalArcManager.SetMaxArcs(*maxArcs)
+	tui.GlobalArcManager.SetHoneypots(honeypots)
+	tui.GlobalFootprints = tui.NewFootprintTracker(*footprints)
+
+	// Initialize hpfeeds publisher for enriched events
+	if *hpfeedsHost != "" {
+		tui.GlobalHPFeedsPublisher = api.NewHPFeedsPublisher(*hpfeedsHost, *hpfeedsPort, *hpfeedsIdent, *hpfeedsSecret, *hpfeedsChannel)
+		tui.GlobalHPFeedsPublisher.Start()
+		debugLog("hpfeeds: Publishing enriched events to %s on channel %s", tui.GlobalHPFeedsPublisher.Addr(), *hpfeedsChannel)
+	}
+
+	// Forward live events to the SIEM's syslog collector
+	if syslogForwarder != nil {
+		tui.GlobalSyslog = syslogForwarder
+		tui.GlobalSyslog.Start()
+		debugLog("Syslog: Forwarding %s events to %s", *syslogFormat, *syslogForward)
+	}
+
+	// Publish enriched events to Kafka, NATS and MQTT for downstream pipelines
+	if len(kafkaBrokerList) > 0 {
+		bus := api.NewBusPublisher("kafka", api.NewKafkaTransport(kafkaBrokerList, *kafkaTopic, *kafkaUser, *kafkaPass, *kafkaTLS))
+		bus.Start()
+		tui.GlobalBuses = append(tui.GlobalBuses, bus)
+		debugLog("kafka: Publishing enriched events to topic %s via %s", *kafkaTopic, *kafkaBrokers)
+	}
+	if natsTransport != nil {
+		bus := api.NewBusPublisher("nats", natsTransport)
+		bus.Start()
+		tui.GlobalBuses = append(tui.GlobalBuses, bus)
+		debugLog("nats: Publishing enriched events to %s on subject %s", *natsURL, *natsSubject)
+	}
+
+	if mqttSink != nil {
+		mqttSink.SetTopic(*mqttPublish)
+		bus := api.NewBusPublisher("mqtt", mqttSink)
+		bus.Start()
+		tui.GlobalBuses = append(tui.GlobalBuses, bus)
+		debugLog("mqtt: Publishing enriched events to %s on %s", *mqttBroker, *mqttPublish)
+	}
+
+	// Bulk-index events into Elasticsearch/OpenSearch for Kibana
+	if *esURL != "" {
+		tui.GlobalElastic = api.NewElasticSink(*esURL, *esIndex, *esBatch, *esFlush)
+		tui.GlobalElastic.SetAuth(*esUser, *esPass, *esAPIKey)
+		tui.GlobalElastic.Start()
+		debugLog("Elasticsearch: Indexing events into %s/%s", *esURL, *esIndex)
+	}
+
+	// Collect attacking addresses for the STIX feed and TAXII/MISP pushes
+	tui.GlobalIntel = api.NewIntelExporter(*intelWindow, *intelMinSightings)
+	tui.GlobalIntel.SetFile(*intelFile)
+	tui.GlobalIntel.SetTAXII(*taxiiURL, *taxiiUser, *taxiiPass)
+	tui.GlobalIntel.SetMISP(*mispURL, *mispKey)
+	if *intelFile != "" || *taxiiURL != "" || *mispURL != "" {
+		tui.GlobalIntel.Start(*intelInterval)
+		debugLog("Intel: Exporting every %v (file %q, TAXII %q, MISP %q)", *intelInterval, *intelFile, *taxiiURL, *mispURL)
+	}
+
+	// Keep the operator's triage tags, on disk when --tags-file is set
+	tui.GlobalTags = tagStore
+	debugLog("Tags: %d tagged addresses", tui.GlobalTags.Len())
+
+	// Compare the events each sensor reports against what it should run
+	tui.GlobalCoverage = tui.NewCoverageTracker(sensors, *coverageWindow)
+
+	// Pin alerts and incidents above the dashboard until acknowledged
+	if *showBanner {
+		tui.GlobalBanner = tui.NewBannerLane()
+	}
+
+	// Sound the storm alarm when the event rate spikes
+	tui.GlobalStorm = tui.NewStormDetector(*stormRate, *stormClear, *stormHold, *stormHeatmap)
+
+	// Look for unusual credential patterns, and passwords that give away a
+	// honeypot's name
+	hostnames := strings.Split(*findingsHostnames, ",")
+	for _, honeypot := range honeypots {
+		hostnames = append(hostnames, honeypot.Name)
+	}
+	tui.GlobalFindings = tui.NewCredentialAnalyzer(*findingsWindow, *findingsUsernames, *findingsNewUserRate, hostnames)
+
+	// Keep the hashes of downloaded and captured files, looked up when a
+	// service is configured
+	tui.GlobalHashes = tui.NewHashLookup(*virusTotalKey, *bazaarKey, *hashLookupRate, *offline)
+	tui.GlobalHashes.Start()
+
+	// Initialize alerting rules. The engine exists even without them, so a
+	// config reload can turn alerting on.
+	tui.GlobalAlertEngine = tui.NewAlertEngine(*alertRulesPath, alertRules)
+	if *alertRulesPath != "" {
+		tui.GlobalAlertEngine.Start()
+		debugLog("Alerts: Loaded %d rules from %s", len(alertRules), *alertRulesPath)
+	}
+
+	// Initialize credential pair tracking
+	tui.GlobalCredStats = stats.NewCredentialStats(15 * time.Minute)
+	tui.GlobalCredStats.SetMaxAttempts(*maxCredAttempts)
+
+	// Count hits per IP across the session for repeat offender markers
+	tui.GlobalOffenders = tui.NewOffenderTracker(*repeatThreshold)
+
+	// Remember the session's events for the timeline and scrub mode
+	tui.GlobalHistory = tui.NewEventHistory(*historySize)
+
+	// Profiling endpoint for reports of high CPU on large displays
+	if *pprofAddr != "" {
+		tui.StartPprof(*pprofAddr, webPolicy)
+	}
+
+	// Watch memory so long-running kiosks shed caches instead of growing
+	tui.GlobalMemWatchdog = tui.NewMemoryWatchdog(*memLimit)
+	tui.GlobalMemWatchdog.Start()
+
+	// Initialize Demo Storm
+	globalDemoStorm = tui.NewDemoStorm()
+	if *demoStorm || scenario != nil {
+		globalDemoStorm.Enable(*demoRate, scenario)
+		geoIPManager.SetDemo(true)
+	}
+
+	// Load the replay before the screen is taken over so errors show plainly
+	var replay *tui.DemoReplay
+	if *demoReplay != "" {
+		replay, err = tui.LoadDemoReplay(*demoReplay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --demo-replay: %v\n", err)
+			os.Exit(1)
+		}
+		debugLog("Replay: Loaded %d events from %s", replay.Len(), *demoReplay)
+	}
+
+	// Initialize TUI
+	ui, err := tui.NewTUI(*aspectRatio, charsetType, *recordFile, *recordFormat, *colorMode, *unicodeMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing TUI: %v\n", err)
+		os.Exit(1)
+	}
+	defer ui.Close()
+
+	tui.GlobalTUI = ui
+
+	// Lock the keyboard for passers-by until the operator unlocks it
+	var roles *tui.RoleLock
+	if *spectator {
+		roles = tui.NewRoleLock(*operatorPass)
+		if *operatorPass == "" {
+			debugLog("Spectator: no --operator-pass, the display stays locked until restart")
+		}
+	}
+
+	ui.Configure(tui.Options{
+		// Keep replays offline; a lone Cowrie host has no stats API
+		Offline:       replay != nil || *cowrieLog != "" || *offline,
+		GIF:           tui.NewGIFExporter(*exportGIF, *gifDuration, *gifFrameSkip),
+		Presets:       tui.NewViewPresets(viewPresets),
+		Keys:          keyMap,
+		FrameRate:     tui.NewFrameRateController(*activeFPS, *idleFPS, time.Duration(*idleAfter)*time.Second),
+		Roles:         roles,
+		SubCell:       *subCell,
+		Supersample:   *quality == "high",
+		Projection:    projection,
+		Lighting:      *lighting,
+		LightLon:      *lightLon,
+		LightLat:      *lightLat,
+		LightFollow:   *lightFollow,
+		CRT:           *crtEffect,
+		Glow:          *glowLevel,
+		CRTCurve:      *crtCurve,
+		RainMask:      *rainMask,
+		DashboardWrap: *dashboardWrap,
+		Collapse:      *collapse,
+		ShowTimeline:  *showTimeline,
+		TickerMode:    *tickerMode,
+		TimeFormat:    *timeFormat,
+		ShowLegend:    *showLegend,
+		ShowBanner:    *showBanner,
+	})
+	ui.SetMouse(*mouse)
+	if *layerSpec != "" {
+		ui.SetLayers(*layerSpec) // Validated above
+	}
+	ui.SetColumnLayout(columnLayout)
+	if *rainEffect {
+		ui.SetRainDensity(*rainDensity)
+	}
+
+	quit := ui.PollEvents(*aspectRatio)
+	quitOnSignal(quit)
+
+	// Reload the config file on SIGHUP or when it changes on disk
+	if *configFile != "" {
+		ui.WatchConfig(*configFile)
+	}
+
+	// Start embedded web server
+	if *webAddr != "" {
+		webServer := api.NewWebServer(*webAddr, webPolicy)
+		tui.RegisterPanelAPI(webServer)
+		if *webTLSCert != "" || *webTLSKey != "" || *webTLSSelfSigned {
+			if err := webServer.EnableTLS(*webTLSCert, *webTLSKey); err != nil {
+				ui.Close()
+				fmt.Fprintf(os.Stderr, "Error: Web server TLS: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		webServer.Start()
+		defer webServer.Close()
+	}
+
+	sharedDashboard := ui.Dashboard()
+
+	// Start API client, unless a replay or a local Cowrie log stands in for
+	// the honeypot feed or --offline rules it out
+	useLiveData := false
+	if replay != nil {
+		replay.Start(sharedDashboard)
+		useLiveData = true
+	} else if *cowrieLog != "" {
+		if err := tui.NewCowrieSource(sharedDashboard).Start(*cowrieLog, *backfill); err != nil {
+			debugLog("Cowrie: %v", err)
+		}
+		useLiveData = true
+	} else if *offline {
+		debugLog("Offline: Not polling the API")
+	} else {
+		for _, client := range api.Clients {
+			client.Start(*backfill, sharedDashboard.AddAPIRows)
+		}
+		useLiveData = true
+	}
+
+	// Show connections from a Zeek sensor's conn.log
+	if *zeekLog != "" {
+		if err := tui.StartZeekInput(*zeekLog, *zeekFollow, sharedDashboard); err != nil {
+			debugLog("Zeek: %v", err)
+		}
+	}
+
+	// Take events from honeypots that publish over MQTT as well
+	if mqttSource != nil {
+		api.StartMQTTSource(mqttSource, *mqttSubscribe, tui.GlobalGeoIP, sharedDashboard.AddAPIRows)
+		debugLog("mqtt: Reading events from %s on %s", *mqttBroker, *mqttSubscribe)
+	}
+
+	// Start demo storm if enabled
+	if globalDemoStorm.Enabled() {
+		globalDemoStorm.Start(sharedDashboard)
+		useLiveData = true // Don't generate random data if demo storm is active
+	}
+
+	var kioskEvery time.Duration
+	if *kioskMode {
+		kioskEvery = time.Duration(*kioskInterval) * time.Second
+	}
+
+	ui.Run(quit, tui.LoopOptions{
+		Refresh:        time.Duration(*refreshRate) * time.Millisecond,
+		RotationPeriod: time.Duration(*rotationPeriod) * time.Second,
+		Kiosk:          kioskEvery,
+		ProtocolGlyphs: *protocolGlyphs,
+		Mock:           !useLiveData,
+	})
+
+	debugLog("Shutting down")
+	globalDemoStorm.Stop()
+	api.Workers.Stop(2 * time.Second)
+	ui.Close()
+	fmt.Println("Exiting...")
+	os.Exit(0)
+}
@@ -0,0 +1,554 @@
+package api
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// busTransport is a connection to a message bus; the publish loop calls it
+// from a single goroutine
+type busTransport interface {
+	Connect() error
+	Publish(key, value []byte) error
+	Close()
+}
+
+type busMessage struct {
+	key   []byte
+	value []byte
+}
+
+// BusPublisher forwards enriched events as JSON to a Kafka topic or NATS
+// subject, reconnecting with backoff like the hpfeeds publisher
+type BusPublisher struct {
+	name      string
+	transport busTransport
+	queue     chan busMessage
+	dropped   int
+	mutex     sync.Mutex
+}
+
+func NewBusPublisher(name string, transport busTransport) *BusPublisher {
+	return &BusPublisher{name: name, transport: transport, queue: make(chan busMessage, 1000)}
+}
+
+// Start runs the publish loop until shutdown
+func (bp *BusPublisher) Start() {
+	Workers.Go(bp.name, func(stop <-chan struct{}) error {
+		connected := false
+		defer func() {
+			if connected {
+				bp.transport.Close()
+			}
+		}()
+		backoff := time.Second
+		for {
+			var msg busMessage
+			select {
+			case <-stop:
+				return nil
+			case msg = <-bp.queue:
+			}
+
+			for {
+				if !connected {
+					if err := bp.transport.Connect(); err != nil {
+						debugLog("%s: Connect failed: %v (retry in %v)", bp.name, err, backoff)
+						select {
+						case <-stop:
+							return nil
+						case <-time.After(backoff):
+						}
+						backoff = time.Duration(math.Min(float64(backoff*2), float64(60*time.Second)))
+						continue
+					}
+					connected = true
+					backoff = time.Second
+				}
+
+				if err := bp.transport.Publish(msg.key, msg.value); err != nil {
+					debugLog("%s: Publish failed: %v", bp.name, err)
+					bp.transport.Close()
+					connected = false
+					continue
+				}
+				break
+			}
+		}
+	})
+}
+
+// Publish queues an enriched event keyed by its source address, dropping
+// it if the bus is backed up
+func (bp *BusPublisher) Publish(event EnrichedEvent) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	select {
+	case bp.queue <- busMessage{key: []byte(event.SrcIP), value: value}:
+	default:
+		bp.mutex.Lock()
+		bp.dropped++
+		bp.mutex.Unlock()
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Kafka: a minimal producer speaking the broker protocol directly (Metadata
+// v1, Produce v3 with v2 record batches, SASL/PLAIN), enough to append
+// uncompressed records with acks from the partition leader
+// ----------------------------------------------------------------------------
+
+const (
+	kafkaAPIProduce          = 0
+	kafkaAPIMetadata         = 3
+	kafkaAPISaslHandshake    = 17
+	kafkaAPISaslAuthenticate = 36
+	kafkaClientID            = "seckc-globe"
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+type KafkaTransport struct {
+	brokers   []string
+	topic     string
+	user      string
+	pass      string
+	tlsConfig *tls.Config
+
+	addrs       map[int32]string   // Broker id to host:port, from metadata
+	leaders     []int32            // Leader broker of each partition
+	conns       map[int32]net.Conn // Open connections by broker id
+	correlation int32
+}
+
+func NewKafkaTransport(brokers []string, topic, user, pass string, useTLS bool) *KafkaTransport {
+	kt := &KafkaTransport{brokers: brokers, topic: topic, user: user, pass: pass}
+	if useTLS {
+		kt.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return kt
+}
+
+// Connect asks the first reachable bootstrap broker which brokers lead the
+// topic's partitions
+func (kt *KafkaTransport) Connect() error {
+	kt.conns = make(map[int32]net.Conn)
+	var lastErr error
+	for _, broker := range kt.brokers {
+		conn, err := kt.dial(broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		err = kt.metadata(conn)
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+func (kt *KafkaTransport) Close() {
+	for _, conn := range kt.conns {
+		conn.Close()
+	}
+	kt.conns = nil
+}
+
+// Publish appends a record to the partition picked by hashing its key, so
+// one attacker's events stay in order
+func (kt *KafkaTransport) Publish(key, value []byte) error {
+	h := fnv.New32a()
+	h.Write(key)
+	partition := int32(h.Sum32() % uint32(len(kt.leaders)))
+	leader := kt.leaders[partition]
+
+	conn, ok := kt.conns[leader]
+	if !ok {
+		addr, known := kt.addrs[leader]
+		if !known {
+			return fmt.Errorf("partition %d has no leader", partition)
+		}
+		var err error
+		if conn, err = kt.dial(addr); err != nil {
+			return err
+		}
+		kt.conns[leader] = conn
+	}
+
+	var req []byte
+	req = binary.BigEndian.AppendUint16(req, 0xffff) // No transactional id
+	req = binary.BigEndian.AppendUint16(req, 1)      // acks: leader only
+	req = binary.BigEndian.AppendUint32(req, 10000)  // Timeout ms
+	req = binary.BigEndian.AppendUint32(req, 1)
+	req = kafkaString(req, kt.topic)
+	req = binary.BigEndian.AppendUint32(req, 1)
+	req = binary.BigEndian.AppendUint32(req, uint32(partition))
+	batch := kafkaRecordBatch(key, value, time.Now())
+	req = binary.BigEndian.AppendUint32(req, uint32(len(batch)))
+	req = append(req, batch...)
+
+	resp, err := kt.roundTrip(conn, kafkaAPIProduce, 3, req)
+	if err != nil {
+		return err
+	}
+	r := kafkaReader{buf: resp}
+	r.int32() // Topics
+	r.string()
+	r.int32() // Partitions
+	r.int32()
+	if code := r.int16(); code != 0 {
+		return fmt.Errorf("produce to %s/%d: error code %d", kt.topic, partition, code)
+	}
+	return r.err
+}
+
+func (kt *KafkaTransport) dial(addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if kt.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, kt.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kt.user != "" {
+		if err := kt.authenticate(conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("SASL/PLAIN: %v", err)
+		}
+	}
+	return conn, nil
+}
+
+func (kt *KafkaTransport) authenticate(conn net.Conn) error {
+	resp, err := kt.roundTrip(conn, kafkaAPISaslHandshake, 1, kafkaString(nil, "PLAIN"))
+	if err != nil {
+		return err
+	}
+	r := kafkaReader{buf: resp}
+	if code := r.int16(); code != 0 {
+		return fmt.Errorf("handshake error code %d", code)
+	}
+
+	token := []byte("\x00" + kt.user + "\x00" + kt.pass)
+	req := binary.BigEndian.AppendUint32(nil, uint32(len(token)))
+	resp, err = kt.roundTrip(conn, kafkaAPISaslAuthenticate, 0, append(req, token...))
+	if err != nil {
+		return err
+	}
+	r = kafkaReader{buf: resp}
+	if code := r.int16(); code != 0 {
+		return fmt.Errorf("%s (error code %d)", r.string(), code)
+	}
+	return nil
+}
+
+func (kt *KafkaTransport) metadata(conn net.Conn) error {
+	req := binary.BigEndian.AppendUint32(nil, 1)
+	resp, err := kt.roundTrip(conn, kafkaAPIMetadata, 1, kafkaString(req, kt.topic))
+	if err != nil {
+		return err
+	}
+
+	r := kafkaReader{buf: resp}
+	kt.addrs = make(map[int32]string)
+	for n := r.int32(); n > 0 && r.err == nil; n-- {
+		id := r.int32()
+		host := r.string()
+		port := r.int32()
+		r.string() // Rack
+		kt.addrs[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	r.int32() // Controller
+	if r.int32() != 1 {
+		return fmt.Errorf("metadata for %s: unexpected topic count", kt.topic)
+	}
+	if code := r.int16(); code != 0 {
+		return fmt.Errorf("metadata for %s: error code %d", kt.topic, code)
+	}
+	r.string()
+	r.int8() // Internal
+	partitions := r.int32()
+	if partitions <= 0 {
+		return fmt.Errorf("topic %s has no partitions", kt.topic)
+	}
+	kt.leaders = make([]int32, partitions)
+	for i := int32(0); i < partitions && r.err == nil; i++ {
+		r.int16() // Error
+		index := r.int32()
+		leader := r.int32()
+		for n := r.int32(); n > 0 && r.err == nil; n-- { // Replicas
+			r.int32()
+		}
+		for n := r.int32(); n > 0 && r.err == nil; n-- { // In-sync replicas
+			r.int32()
+		}
+		if index >= 0 && index < partitions {
+			kt.leaders[index] = leader
+		}
+	}
+	return r.err
+}
+
+// roundTrip sends one request and returns the response body after the
+// correlation id
+func (kt *KafkaTransport) roundTrip(conn net.Conn, apiKey, version int16, body []byte) ([]byte, error) {
+	kt.correlation++
+	msg := make([]byte, 4, 4+10+len(kafkaClientID)+len(body))
+	msg = binary.BigEndian.AppendUint16(msg, uint16(apiKey))
+	msg = binary.BigEndian.AppendUint16(msg, uint16(version))
+	msg = binary.BigEndian.AppendUint32(msg, uint32(kt.correlation))
+	msg = kafkaString(msg, kafkaClientID)
+	msg = append(msg, body...)
+	binary.BigEndian.PutUint32(msg, uint32(len(msg)-4))
+
+	conn.SetDeadline(time.Now().Add(15 * time.Second))
+	defer conn.SetDeadline(time.Time{})
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length < 4 || length > 64<<20 {
+		return nil, fmt.Errorf("invalid response length %d", length)
+	}
+	if id := int32(binary.BigEndian.Uint32(header[4:])); id != kt.correlation {
+		return nil, fmt.Errorf("response %d does not match request %d", id, kt.correlation)
+	}
+	resp := make([]byte, length-4)
+	_, err := io.ReadFull(conn, resp)
+	return resp, err
+}
+
+// kafkaRecordBatch encodes a v2 record batch holding one record
+func kafkaRecordBatch(key, value []byte, t time.Time) []byte {
+	var record []byte
+	record = append(record, 0)              // Attributes
+	record = binary.AppendVarint(record, 0) // Timestamp delta
+	record = binary.AppendVarint(record, 0) // Offset delta
+	record = binary.AppendVarint(record, int64(len(key)))
+	record = append(record, key...)
+	record = binary.AppendVarint(record, int64(len(value)))
+	record = append(record, value...)
+	record = binary.AppendVarint(record, 0) // Headers
+
+	// Everything after the CRC, which the CRC covers
+	var tail []byte
+	tail = binary.BigEndian.AppendUint16(tail, 0)                     // Attributes: no compression
+	tail = binary.BigEndian.AppendUint32(tail, 0)                     // Last offset delta
+	tail = binary.BigEndian.AppendUint64(tail, uint64(t.UnixMilli())) // First timestamp
+	tail = binary.BigEndian.AppendUint64(tail, uint64(t.UnixMilli())) // Max timestamp
+	tail = binary.BigEndian.AppendUint64(tail, math.MaxUint64)        // Producer id: none
+	tail = binary.BigEndian.AppendUint16(tail, 0xffff)                // Producer epoch
+	tail = binary.BigEndian.AppendUint32(tail, 0xffffffff)            // Base sequence
+	tail = binary.BigEndian.AppendUint32(tail, 1)                     // Records
+	tail = binary.AppendVarint(tail, int64(len(record)))
+	tail = append(tail, record...)
+
+	var batch []byte
+	batch = binary.BigEndian.AppendUint64(batch, 0) // Base offset
+	batch = binary.BigEndian.AppendUint32(batch, uint32(4+1+4+len(tail)))
+	batch = binary.BigEndian.AppendUint32(batch, 0xffffffff) // Partition leader epoch
+	batch = append(batch, 2)                                 // Magic
+	batch = binary.BigEndian.AppendUint32(batch, crc32.Checksum(tail, crc32c))
+	return append(batch, tail...)
+}
+
+func kafkaString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// kafkaReader decodes big endian response fields, remembering the first
+// short read
+type kafkaReader struct {
+	buf []byte
+	err error
+}
+
+func (r *kafkaReader) take(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.buf) {
+		if r.err == nil {
+			r.err = fmt.Errorf("truncated response")
+		}
+		return make([]byte, max(n, 0))
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *kafkaReader) int8() int8 { return int8(r.take(1)[0]) }
+
+func (r *kafkaReader) int16() int16 { return int16(binary.BigEndian.Uint16(r.take(2))) }
+
+func (r *kafkaReader) int32() int32 { return int32(binary.BigEndian.Uint32(r.take(4))) }
+
+// string reads a nullable string, returning "" for null
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+// ----------------------------------------------------------------------------
+// NATS: the text protocol's INFO/CONNECT handshake and PUB, with user/pass
+// or token auth and TLS when the server or the URL asks for it
+// ----------------------------------------------------------------------------
+
+type NATSTransport struct {
+	addr    string
+	useTLS  bool
+	subject string
+	user    string
+	pass    string
+	token   string
+
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// NewNATSTransport takes a nats://host[:4222] or tls://host[:4222] URL
+func NewNATSTransport(url, subject, user, pass, token string) (*NATSTransport, error) {
+	scheme, host, ok := strings.Cut(url, "://")
+	if !ok || host == "" || (scheme != "nats" && scheme != "tls") {
+		return nil, fmt.Errorf("server %q must look like nats://host:4222 or tls://host:4222", url)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "4222")
+	}
+	return &NATSTransport{addr: host, useTLS: scheme == "tls", subject: subject, user: user, pass: pass, token: token}, nil
+}
+
+func (nt *NATSTransport) Connect() error {
+	conn, err := net.DialTimeout("tcp", nt.addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	infoJSON, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok || json.Unmarshal([]byte(infoJSON), &info) != nil {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if nt.useTLS || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(nt.addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	options, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"name":       "seckc-globe",
+		"lang":       "go",
+		"version":    BuildVersion(),
+		"protocol":   1,
+		"user":       nt.user,
+		"pass":       nt.pass,
+		"auth_token": nt.token,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		conn.Close()
+		return err
+	}
+	// The server answers the PING once it has accepted CONNECT
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("server: %s", strings.TrimPrefix(line, "-ERR "))
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	nt.conn, nt.reader = conn, reader
+	debugLog("nats: Connected to %s", nt.addr)
+
+	// Answer keepalive pings and surface server errors
+	go func(c net.Conn, r *bufio.Reader) {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "PING":
+				nt.writeMu.Lock()
+				c.Write([]byte("PONG\r\n"))
+				nt.writeMu.Unlock()
+			case strings.HasPrefix(line, "-ERR"):
+				debugLog("nats: Server error: %s", line)
+			}
+		}
+	}(conn, reader)
+	return nil
+}
+
+func (nt *NATSTransport) Publish(key, value []byte) error {
+	nt.writeMu.Lock()
+	defer nt.writeMu.Unlock()
+	nt.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	msg := fmt.Appendf(nil, "PUB %s %d\r\n", nt.subject, len(value))
+	msg = append(msg, value...)
+	_, err := nt.conn.Write(append(msg, '\r', '\n'))
+	return err
+}
+
+func (nt *NATSTransport) Close() {
+	if nt.conn != nil {
+		nt.conn.Close()
+		nt.conn = nil
+	}
+}
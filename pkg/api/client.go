@@ -0,0 +1,440 @@
+// Package api moves attack events in and out of the globe. It polls and
+// streams the MHN API, geolocates attackers, forwards enriched events to
+// hpfeeds, syslog, Elasticsearch, Kafka, NATS and MQTT, exports threat intel
+// and serves the embedded web API. It knows nothing about the terminal.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+type APIConfig struct {
+	Label        string // Endpoint name for origin tags and status badges
+	BaseURL      string
+	PollInterval time.Duration
+	MaxEvents    int
+	Key          string // API key or bearer token, empty for anonymous access
+	KeyHeader    string // Header carrying the raw key; empty sends Authorization: Bearer
+	Stream       string // Event stream transport: auto, websocket, sse or off
+}
+
+type APIClient struct {
+	config       *APIConfig
+	httpClient   *http.Client
+	pollClient   *http.Client // Events polling, with gzip and conditional requests
+	streamClient *http.Client // Event stream, without a timeout
+	lastEventTS  float64
+	streaming    string        // Connected stream transport, empty while polling
+	skew         time.Duration // Server clock minus ours, from the latest server_time
+	skewKnown    bool          // A response has carried server_time
+	mutex        sync.RWMutex
+
+	authMutex     sync.RWMutex
+	authenticated bool // Server reported the last events request as authenticated
+	denied        int  // 401 or 403 from the latest request, 0 once one succeeds
+	failed        bool // The latest events request failed
+	onStatus      func(failed bool)
+
+	processedTS float64 // Newest event handed to the dashboard
+}
+
+var PrimaryClient *APIClient // The first endpoint, which also geocodes
+
+var Clients []*APIClient
+
+var globalEventMerger = mhn.NewMerger()
+
+func NewAPIClient(config *APIConfig) *APIClient {
+	return &APIClient{
+		config:     config,
+		httpClient: NewHTTPClient(10 * time.Second),
+		pollClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: NewPollingTransport(HTTPTransport, EventsTransfer),
+		},
+		streamClient: &http.Client{Transport: HTTPTransport},
+		lastEventTS:  0,
+	}
+}
+
+// Backfill fetches pages of the largest size the API allows, up to a cap
+const (
+	backfillPageSize  = 500
+	MaxBackfillEvents = 20000
+)
+
+func (api *APIClient) PollInterval() time.Duration {
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	return api.config.PollInterval
+}
+
+func (api *APIClient) SetPollInterval(interval time.Duration) {
+	api.mutex.Lock()
+	api.config.PollInterval = interval
+	api.mutex.Unlock()
+}
+
+func (api *APIClient) GetRecentEvents() ([]APIEvent, error) {
+	events, err := api.fetchEvents(api.lastEventTS, api.config.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) > 0 {
+		api.lastEventTS = events[len(events)-1].Timestamp
+	}
+
+	return events, nil
+}
+
+// Backfill fetches the events of the last window, oldest first, paging
+// through the API until it catches up or has limit events. Polling resumes
+// after the newest event returned.
+func (api *APIClient) Backfill(window time.Duration, limit int) ([]APIEvent, error) {
+	since := api.serverTime(time.Now().Add(-window))
+	var events []APIEvent
+	for len(events) < limit {
+		page, err := api.fetchEvents(since, min(backfillPageSize, limit-len(events)))
+		if err != nil {
+			return events, err
+		}
+		if len(page) == 0 || page[len(page)-1].Timestamp <= since {
+			break
+		}
+		events = append(events, page...)
+		since = page[len(page)-1].Timestamp
+		api.lastEventTS = since
+		if len(page) < backfillPageSize {
+			break
+		}
+	}
+	return events, nil
+}
+
+// fetchEvents requests up to limit events newer than since (0 for the latest)
+func (api *APIClient) fetchEvents(since float64, limit int) ([]APIEvent, error) {
+	url := fmt.Sprintf("%s/feeds/events/recent", strings.TrimSuffix(api.config.BaseURL, "/"))
+
+	if since > 0 {
+		url = fmt.Sprintf("%s?since=%.1f&limit=%d", url, since, limit)
+	} else {
+		url = fmt.Sprintf("%s?limit=%d", url, limit)
+	}
+
+	sent := time.Now()
+	resp, err := api.get(api.pollClient, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("API request denied: status %d, check --api-key", resp.StatusCode)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("API request failed: status %d", resp.StatusCode)
+	}
+
+	var apiResp APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	api.authMutex.Lock()
+	if apiResp.Authenticated != api.authenticated {
+		debugLog("API: authenticated=%v", apiResp.Authenticated)
+	}
+	api.authenticated = apiResp.Authenticated
+	api.authMutex.Unlock()
+
+	// A replayed body's server_time is from the request that first fetched it
+	if apiResp.ServerTime > 0 && resp.Header.Get(replayedHeader) == "" {
+		// The server read its clock about halfway through the round trip
+		received := time.Now()
+		local := sent.Add(received.Sub(sent) / 2)
+		api.setSkew(time.Duration((apiResp.ServerTime - float64(local.UnixNano())/1e9) * 1e9))
+	}
+
+	return apiResp.Events, nil
+}
+
+// ClockSkewLogged is how far the measured skew moves before it is logged again
+const ClockSkewLogged = time.Second
+
+// Skew returns how far the server's clock is ahead of ours and whether a
+// response has told us. Event timestamps and since cursors are in server
+// time; rows are shown in ours.
+func (api *APIClient) Skew() (time.Duration, bool) {
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	return api.skew, api.skewKnown
+}
+
+func (api *APIClient) setSkew(skew time.Duration) {
+	api.mutex.Lock()
+	if !api.skewKnown || (skew-api.skew).Abs() >= ClockSkewLogged {
+		debugLog("API: %s clock is %s of ours", api.config.Label, FormatSkew(skew))
+	}
+	api.skew, api.skewKnown = skew, true
+	api.mutex.Unlock()
+}
+
+// measureSkew asks for the latest event just to learn the server's time,
+// unless a response has already carried it. Streams do not carry it, and
+// backfill needs it before its first request.
+func (api *APIClient) measureSkew() {
+	if _, known := api.Skew(); known {
+		return
+	}
+	if _, err := api.fetchEvents(0, 1); err != nil {
+		debugLog("API: %s clock skew not measured: %v", api.config.Label, err)
+	}
+}
+
+// FormatSkew describes a clock skew, e.g. "3.2s ahead"
+func FormatSkew(skew time.Duration) string {
+	if skew < 0 {
+		return fmt.Sprintf("%.1fs behind", -skew.Seconds())
+	}
+	return fmt.Sprintf("%.1fs ahead", skew.Seconds())
+}
+
+// serverTime converts a time on our clock to a server timestamp
+func (api *APIClient) serverTime(t time.Time) float64 {
+	skew, _ := api.Skew()
+	return float64(t.Add(skew).UnixNano()) / 1e9
+}
+
+// get sends a GET request to the API through client with the configured key
+// attached and records whether the server turned the key away
+func (api *APIClient) get(client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	api.authorize(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	api.recordStatus(url, resp.StatusCode)
+	return resp, nil
+}
+
+// authorize attaches the configured key to a request
+func (api *APIClient) authorize(req *http.Request) {
+	if api.config.Key == "" {
+		return
+	}
+	if api.config.KeyHeader != "" {
+		req.Header.Set(api.config.KeyHeader, api.config.Key)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+api.config.Key)
+	}
+}
+
+// recordStatus notes whether the API turned the key away
+func (api *APIClient) recordStatus(url string, status int) {
+	denied := 0
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		denied = status
+	}
+	api.authMutex.Lock()
+	if denied != api.denied {
+		if denied != 0 {
+			debugLog("API: %s returned %d, the API key is missing or not accepted", url, denied)
+		} else {
+			debugLog("API: access restored")
+		}
+	}
+	api.denied = denied
+	api.authMutex.Unlock()
+}
+
+// StatusBadge returns the status line indicator for the API and whether it
+// reports a problem. A lone endpoint shows whether it answers and its
+// authentication state; with labeled set, as when several endpoints are polled, every endpoint
+// gets a badge naming it, so one that stops answering stands out.
+func (api *APIClient) StatusBadge(labeled bool) (string, bool) {
+	api.authMutex.RLock()
+	defer api.authMutex.RUnlock()
+
+	if !labeled {
+		switch api.denied {
+		case http.StatusUnauthorized:
+			return "[API 401 UNAUTHORIZED]", true
+		case http.StatusForbidden:
+			return "[API 403 FORBIDDEN]", true
+		}
+		if api.failed {
+			return "[API DOWN]", true
+		}
+		if api.authenticated {
+			return "[API AUTH]", false
+		}
+		return "[API]", false
+	}
+
+	label := api.config.Label
+	switch {
+	case api.denied != 0:
+		return fmt.Sprintf("[%s %d]", label, api.denied), true
+	case api.failed:
+		return "[" + label + " DOWN]", true
+	case api.authenticated:
+		return "[" + label + " AUTH]", false
+	}
+	return "[" + label + "]", false
+}
+
+// setFailed records whether the latest events request failed
+func (api *APIClient) setFailed(failed bool) {
+	api.authMutex.Lock()
+	changed := failed != api.failed
+	if changed {
+		debugLog("API: %s %s", api.config.Label, map[bool]string{true: "unreachable", false: "reachable"}[failed])
+	}
+	api.failed = failed
+	onStatus := api.onStatus
+	api.authMutex.Unlock()
+	if changed && onStatus != nil {
+		onStatus(failed)
+	}
+}
+
+// OnStatusChange calls fn whenever the events requests start or stop failing
+func (api *APIClient) OnStatusChange(fn func(failed bool)) {
+	api.authMutex.Lock()
+	defer api.authMutex.Unlock()
+	api.onStatus = fn
+}
+
+// Label names the endpoint, for telling several apart
+func (api *APIClient) Label() string {
+	return api.config.Label
+}
+
+// ValidHeaderName reports whether name is a usable HTTP header field name
+func ValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("!#$%&'*+-.^_`|~", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Start polls the endpoint under the supervisor, following its event stream
+// instead whenever it has one. The rows of new events go to deliver, first
+// those of the last backfill of history, which are not live.
+func (api *APIClient) Start(backfill time.Duration, deliver func(rows []Connection, live bool)) {
+	backfilled := false
+	name := "api-poller"
+	if len(Clients) > 1 {
+		name = "api:" + api.config.Label
+	}
+	Workers.Go(name, func(stop <-chan struct{}) error {
+		api.measureSkew()
+
+		// Seed the display with recent history once, not on every restart
+		if backfill > 0 && !backfilled {
+			backfilled = true
+			deliver(api.backfillRows(backfill), false)
+		}
+
+		interval := api.PollInterval()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// Prefer the event stream; polling fills in until it (re)connects
+		var nextStream time.Time
+		for {
+			if api.config.Stream != "off" && !time.Now().Before(nextStream) {
+				err := api.Stream(api.config.Stream, stop, func(events []APIEvent) {
+					api.setFailed(false)
+					deliver(api.rows(events), true)
+				})
+				select {
+				case <-stop:
+					return nil
+				default:
+				}
+				if errors.Is(err, errStreamUnsupported) {
+					debugLog("Stream: %v, polling every %v", err, interval)
+					nextStream = time.Now().Add(streamProbeRetry)
+				} else {
+					debugLog("Stream: %v, polling until it reconnects", err)
+					nextStream = time.Now().Add(streamRetry)
+				}
+			}
+
+			select {
+			case <-stop:
+				return nil
+			case <-ticker.C:
+			}
+
+			// Pick up poll interval changes from the settings menu or config reload
+			if current := api.PollInterval(); current != interval {
+				interval = current
+				ticker.Reset(interval)
+			}
+
+			events, err := api.GetRecentEvents()
+			api.setFailed(err != nil)
+			if err != nil {
+				continue
+			}
+			deliver(api.rows(events), true)
+		}
+	})
+}
+
+// ParseAPIEvents decodes polled, streamed or backfilled events into
+// dashboard rows tagged with the endpoint label origin; see mhn.ParseEvents
+// for the events it skips. Timestamps are on the server's clock, skew ahead
+// of ours; rows get times on ours. It also returns the newest timestamp
+// among events, the next call's after.
+func ParseAPIEvents(events []APIEvent, after float64, skew time.Duration, origin string, merger *mhn.Merger) ([]Connection, float64) {
+	parsed, newest := mhn.ParseEvents(events, after, merger)
+	rows := make([]Connection, 0, len(parsed))
+	for _, event := range parsed {
+		rows = append(rows, eventConnection(event.EventFields, event.Time.Add(-skew), origin))
+	}
+	return rows, newest
+}
+
+// rows turns events from the endpoint into dashboard rows tagged with it.
+// Events another endpoint, or an earlier poll, already delivered are dropped.
+func (api *APIClient) rows(events []APIEvent) []Connection {
+	skew, _ := api.Skew()
+	rows, newest := ParseAPIEvents(events, api.processedTS, skew, api.config.Label, globalEventMerger)
+	api.processedTS = newest
+	return rows
+}
+
+// backfillRows loads the events of the last window so the globe, panels
+// and timeline start out populated instead of empty
+func (api *APIClient) backfillRows(window time.Duration) []Connection {
+	events, err := api.Backfill(window, MaxBackfillEvents)
+	if err != nil {
+		debugLog("Backfill: %v (keeping %d events fetched so far)", err, len(events))
+	}
+	rows := api.rows(events)
+	debugLog("Backfill: Loaded %d events from the last %v from %s", len(rows), window, api.config.Label)
+	return rows
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultESIndex     = "seckc-events-%Y.%m.%d"
+	esTemplateName     = "seckc-events"
+	esBufferMultiplier = 10 // Batches kept while the cluster is unreachable
+)
+
+// esMappings types the document fields so Kibana can map, aggregate and
+// filter them: location is a geo_point and the ids are keywords
+const esMappings = `{
+  "properties": {
+    "@timestamp": {"type": "date"},
+    "src_ip":     {"type": "ip"},
+    "username":   {"type": "keyword"},
+    "password":   {"type": "keyword"},
+    "protocol":   {"type": "keyword"},
+    "city":       {"type": "keyword"},
+    "country":    {"type": "keyword"},
+    "location":   {"type": "geo_point"},
+    "asn":        {"type": "keyword"},
+    "org":        {"type": "keyword"},
+    "rdns":       {"type": "keyword"}
+  }
+}`
+
+// esDocument is the indexed form of an enriched event
+type esDocument struct {
+	Timestamp string      `json:"@timestamp"`
+	SrcIP     string      `json:"src_ip"`
+	Username  string      `json:"username"`
+	Password  string      `json:"password"`
+	Protocol  string      `json:"protocol"`
+	City      string      `json:"city,omitempty"`
+	Country   string      `json:"country,omitempty"`
+	Location  *esGeoPoint `json:"location,omitempty"`
+	ASN       string      `json:"asn,omitempty"`
+	Org       string      `json:"org,omitempty"`
+	RDNS      string      `json:"rdns,omitempty"`
+}
+
+type esGeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// ElasticSink bulk-indexes events into Elasticsearch or OpenSearch. Events
+// are batched and sent when a batch fills or the flush interval passes;
+// batches that fail stay buffered for the next attempt. Document ids are
+// derived from the event, so backfilled events indexed again after a
+// restart overwrite themselves instead of doubling up.
+type ElasticSink struct {
+	url        string
+	index      string // Index name with %Y, %m, %d and %H replaced from the event time
+	user       string
+	pass       string
+	apiKey     string
+	batchSize  int
+	flushEvery time.Duration
+
+	pending    []byte // Bulk request body, an action and a document per event
+	count      int
+	indexed    int
+	dropped    int
+	templateOK bool
+	flushNow   chan struct{}
+	httpClient *http.Client
+	mutex      sync.Mutex
+}
+
+func NewElasticSink(url, index string, batchSize int, flushEvery time.Duration) *ElasticSink {
+	return &ElasticSink{
+		url:        strings.TrimSuffix(url, "/"),
+		index:      index,
+		batchSize:  batchSize,
+		flushEvery: flushEvery,
+		flushNow:   make(chan struct{}, 1),
+		httpClient: NewHTTPClient(30 * time.Second),
+	}
+}
+
+// SetAuth sends basic auth, or an API key when apiKey is set, with each bulk
+// request
+func (es *ElasticSink) SetAuth(user, pass, apiKey string) {
+	es.user, es.pass, es.apiKey = user, pass, apiKey
+}
+
+// ESIndexName expands the strftime style date tokens of an index pattern
+func ESIndexName(pattern string, t time.Time) string {
+	t = t.UTC()
+	return strings.NewReplacer(
+		"%Y", t.Format("2006"),
+		"%m", t.Format("01"),
+		"%d", t.Format("02"),
+		"%H", t.Format("15"),
+	).Replace(pattern)
+}
+
+// esIndexWildcard matches every index the pattern can expand to
+func esIndexWildcard(pattern string) string {
+	return strings.NewReplacer("%Y", "*", "%m", "*", "%d", "*", "%H", "*").Replace(pattern)
+}
+
+// Index queues an event and sends the batch once it is full
+func (es *ElasticSink) Index(event EnrichedEvent, t time.Time) {
+	doc := esDocument{
+		Timestamp: t.UTC().Format("2006-01-02T15:04:05.000Z"),
+		SrcIP:     event.SrcIP,
+		Username:  event.Username,
+		Password:  event.Password,
+		Protocol:  event.Protocol,
+		City:      event.City,
+		Country:   event.Country,
+		ASN:       event.ASN,
+		Org:       event.Org,
+		RDNS:      event.RDNS,
+	}
+	if event.Latitude != 0 || event.Longitude != 0 {
+		doc.Location = &esGeoPoint{Lat: event.Latitude, Lon: event.Longitude}
+	}
+	source, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	id := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%s|%s|%s", t.UnixNano(), event.SrcIP, event.Protocol, event.Username, event.Password)))
+	action, _ := json.Marshal(map[string]interface{}{
+		"index": map[string]string{"_index": ESIndexName(es.index, t), "_id": hex.EncodeToString(id[:])},
+	})
+
+	es.mutex.Lock()
+	if es.count >= es.batchSize*esBufferMultiplier {
+		es.dropped++
+		es.mutex.Unlock()
+		return
+	}
+	es.pending = append(es.pending, action...)
+	es.pending = append(es.pending, '\n')
+	es.pending = append(es.pending, source...)
+	es.pending = append(es.pending, '\n')
+	es.count++
+	full := es.count%es.batchSize == 0
+	es.mutex.Unlock()
+
+	if full {
+		es.kick()
+	}
+}
+
+// kick asks the flush loop to send now, if it is not already busy
+func (es *ElasticSink) kick() {
+	select {
+	case es.flushNow <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the flush loop until shutdown, sending what is left on the way out
+func (es *ElasticSink) Start() {
+	Workers.Go("elasticsearch", func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(es.flushEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				es.flush()
+				return nil
+			case <-ticker.C:
+			case <-es.flushNow:
+			}
+			es.flush()
+		}
+	})
+}
+
+// flush sends the buffered events, a batch per bulk request
+func (es *ElasticSink) flush() {
+	if !es.templateOK {
+		if err := es.putTemplate(); err != nil {
+			debugLog("Elasticsearch: Index template failed: %v", err)
+		} else {
+			es.templateOK = true
+		}
+	}
+	for {
+		es.mutex.Lock()
+		body, n := es.takeBatch()
+		es.mutex.Unlock()
+		if n == 0 {
+			return
+		}
+		if err := es.bulk(body); err != nil {
+			debugLog("Elasticsearch: Bulk request of %d events failed: %v", n, err)
+			es.mutex.Lock()
+			es.pending = append(body, es.pending...)
+			es.count += n
+			es.mutex.Unlock()
+			return
+		}
+		es.mutex.Lock()
+		es.indexed += n
+		es.mutex.Unlock()
+	}
+}
+
+// takeBatch removes up to a batch of events from the buffer; the caller
+// holds es.mutex
+func (es *ElasticSink) takeBatch() ([]byte, int) {
+	end, n := 0, 0
+	for n < es.batchSize && end < len(es.pending) {
+		// Two lines per event
+		for lines := 0; lines < 2; lines++ {
+			end += bytes.IndexByte(es.pending[end:], '\n') + 1
+		}
+		n++
+	}
+	body := es.pending[:end:end]
+	es.pending = es.pending[end:]
+	es.count -= n
+	return body, n
+}
+
+// putTemplate maps every index the pattern produces before the first
+// document lands in one
+func (es *ElasticSink) putTemplate() error {
+	template, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{esIndexWildcard(es.index)},
+		"template":       map[string]json.RawMessage{"mappings": json.RawMessage(esMappings)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = es.request(http.MethodPut, "/_index_template/"+esTemplateName, "application/json", template)
+	return err
+}
+
+// bulk sends one bulk request and reports the first item the cluster rejected
+func (es *ElasticSink) bulk(body []byte) error {
+	data, err := es.request(http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("unreadable bulk response: %v", err)
+	}
+	if result.Errors {
+		rejected := 0
+		var first json.RawMessage
+		for _, item := range result.Items {
+			for _, op := range item {
+				if op.Status > 299 {
+					rejected++
+					if first == nil {
+						first = op.Error
+					}
+				}
+			}
+		}
+		// Rejected documents are malformed, not in flight; retrying them would
+		// block the buffer forever
+		debugLog("Elasticsearch: %d of %d documents rejected: %s", rejected, len(result.Items), first)
+	}
+	return nil
+}
+
+func (es *ElasticSink) request(method, path, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, es.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if es.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+es.apiKey)
+	} else if es.user != "" {
+		req.SetBasicAuth(es.user, es.pass)
+	}
+	resp, err := es.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(data) > 200 {
+			data = data[:200]
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"time"
+
+	"SecKC-MHN-Globe/pkg/geoip"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+type Connection struct {
+	IP       string
+	Username string
+	Password string
+	Protocol string
+	Time     time.Time
+	City     string // City name
+	Country  string // Country code
+	ASN      string // Autonomous System Number
+	Org      string // Organization/ISP
+	RDNS     string // Reverse DNS
+	Alert    string // Name of the alert rule highlighting this row
+	Hits     int    // Session hit count for this IP, filled in per frame
+	Session  *SessionDetail
+	Feed     *FeedDetail
+	Key      string   // Row identity for in-place updates (the Cowrie session ID), empty for one-off events
+	Tag      string   // Operator's triage tag for this IP, filled in per frame
+	Origin   string   // Label of the API endpoint that reported it, empty for other sources
+	Port     int      // Destination port on the honeypot, 0 when the sensor did not report it
+	Inferred bool     // Protocol was guessed from Port, the sensor did not report one
+	Sensor   string   // Reporting sensor, empty when the feed does not say
+	Attempts int      // Rows a collapsed row stands for, 0 when not collapsed
+	Tried    []string // Credentials of a collapsed row's rows, each once, oldest first
+}
+
+// Service is the protocol the row names or, when it names none, the one
+// usually found on its destination port
+func (c Connection) Service() string {
+	if c.Protocol != "" {
+		return c.Protocol
+	}
+	service, _ := mhn.InferProtocol(c.Port)
+	return service
+}
+
+// eventConnection makes a dashboard row of an event parsed from a honeypot
+// feed, reported by the API endpoint labelled origin
+func eventConnection(event mhn.EventFields, t time.Time, origin string) Connection {
+	return Connection{
+		IP:       event.SrcIP,
+		Username: event.Username,
+		Password: event.Password,
+		Protocol: event.Protocol,
+		Time:     t,
+		Session:  event.Session,
+		Feed:     event.Feed,
+		Origin:   origin,
+		Port:     event.DestPort,
+		Sensor:   event.Sensor,
+	}
+}
+
+// The MHN wire formats and event schema are shared with the original
+// binary and other programs in pkg/mhn
+type (
+	APIEvent        = mhn.Event
+	APIResponse     = mhn.EventsResponse
+	GeocodeResponse = mhn.GeocodeResponse
+	HourlyStats     = mhn.HourlyStats
+	StatsResponse   = mhn.StatsResponse
+	SessionDetail   = mhn.SessionDetail
+	FeedDetail      = mhn.FeedDetail
+)
+
+// Locations and their least recently used cache live in pkg/geoip
+type LocationInfo = geoip.Location
+
+// Enriched returns the row as the event published to hpfeeds and SIEMs,
+// less the coordinates, which rows do not keep
+func (conn Connection) Enriched() EnrichedEvent {
+	return EnrichedEvent{
+		SrcIP:     conn.IP,
+		Username:  conn.Username,
+		Password:  conn.Password,
+		Protocol:  conn.Protocol,
+		Timestamp: conn.Time.UTC().Format(time.RFC3339),
+		City:      conn.City,
+		Country:   conn.Country,
+		ASN:       conn.ASN,
+		Org:       conn.Org,
+		RDNS:      conn.RDNS,
+		Origin:    conn.Origin,
+		DestPort:  conn.Port,
+		Inferred:  conn.Inferred,
+		Feed:      conn.Feed,
+	}
+}
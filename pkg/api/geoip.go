@@ -0,0 +1,323 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/geoip"
+)
+
+type GeoIPManager struct {
+	apiClient   *APIClient
+	cache       *geoip.Cache
+	asnDB       geoip.ASNDatabase // Local ASN database, nil when not configured
+	asnFallback bool              // Fall back to ipinfo.io when the local database has no match
+	rdns        *ReverseResolver
+	fixtures    Locator // Embedded locations tried before the API, nil unless demoing or offline
+	demo        bool    // Skip the ASN and reverse DNS network lookups
+	lang        string  // Locale of city/country names, falls back to "en"
+	jitter      float64 // Degrees markers are spread over around their location
+	hits        int     // Lookups answered from the cache
+	misses      int     // Lookups that went to the API
+	mutex       sync.RWMutex
+}
+
+// Locator places addresses without going to the API
+type Locator interface {
+	Lookup(ipStr string) (LocationInfo, bool)
+}
+
+// DefaultGeoCacheSize is how many locations the geolocation cache holds
+const DefaultGeoCacheSize = 2000
+
+func NewGeoIPManager(apiClient *APIClient) *GeoIPManager {
+	return &GeoIPManager{
+		apiClient:   apiClient,
+		cache:       geoip.NewCache(DefaultGeoCacheSize),
+		asnFallback: true,
+		lang:        "en",
+	}
+}
+
+// SetLanguage picks the locale used for city and country names
+func (g *GeoIPManager) SetLanguage(lang string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.lang = lang
+}
+
+// localizedName returns the name in lang, matching a regional variant
+// ("zh" finds "zh-CN") and falling back to English
+func localizedName(names map[string]string, lang string) string {
+	if name := names[lang]; name != "" {
+		return name
+	}
+	for key, name := range names {
+		if name != "" && strings.HasPrefix(key, lang+"-") {
+			return name
+		}
+	}
+	return names["en"]
+}
+
+// SetASNDatabase enables offline ASN/Org enrichment. With fallback set,
+// addresses missing from the database are still looked up on ipinfo.io.
+func (g *GeoIPManager) SetASNDatabase(db geoip.ASNDatabase, fallback bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.asnDB = db
+	g.asnFallback = fallback
+}
+
+func (g *GeoIPManager) LookupIP(ipStr string) LocationInfo {
+	if cached, exists := g.cache.Get(ipStr); exists {
+		debugLog("Geocode Cache: Hit for %s", ipStr)
+		g.count(true)
+		return cached
+	}
+	g.mutex.RLock()
+	fixtures := g.fixtures
+	g.mutex.RUnlock()
+
+	debugLog("Geocode Cache: Miss for %s", ipStr)
+	g.count(false)
+	var location LocationInfo
+	ok := false
+	if fixtures != nil {
+		location, ok = fixtures.Lookup(ipStr)
+	}
+	if !ok {
+		location = g.fetchFromAPI(ipStr)
+	}
+
+	if location.Valid {
+		g.cache.Add(ipStr, location)
+	}
+
+	return location
+}
+
+func (g *GeoIPManager) fetchFromAPI(ipStr string) LocationInfo {
+	if g.apiClient == nil {
+		return LocationInfo{Valid: false}
+	}
+
+	url := fmt.Sprintf("%s/geocode/%s", strings.TrimSuffix(g.apiClient.config.BaseURL, "/"), ipStr)
+	resp, err := g.apiClient.get(g.apiClient.httpClient, url)
+	if err != nil {
+		debugLog("Geocode API: Failed %s: %v", ipStr, err)
+		return LocationInfo{Valid: false}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		debugLog("Geocode API: Status %d for %s", resp.StatusCode, ipStr)
+		return LocationInfo{Valid: false}
+	}
+
+	var geocodeResp GeocodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&geocodeResp); err != nil {
+		debugLog("Geocode API: Decode failed for %s: %v", ipStr, err)
+		return LocationInfo{Valid: false}
+	}
+
+	g.mutex.RLock()
+	lang, asnDB, demo := g.lang, g.asnDB, g.demo
+	g.mutex.RUnlock()
+
+	// Skip network ASN/rDNS lookups in demo mode for performance
+	var asn, org, rdns string
+	if !demo {
+		// Only fetch ASN/rDNS for real (non-demo) traffic
+		asn, org = g.lookupASN(ipStr)
+		rdns = g.lookupReverseDNS(ipStr)
+	} else if asnDB != nil {
+		// A local database is cheap enough to enrich demo traffic too
+		asn, org, _ = asnDB.Lookup(net.ParseIP(ipStr))
+	}
+
+	location := LocationInfo{
+		City:      localizedName(geocodeResp.City.Names, lang),
+		Country:   localizedName(geocodeResp.Country.Names, lang),
+		Latitude:  geocodeResp.Location.Latitude,
+		Longitude: geocodeResp.Location.Longitude,
+		ASN:       asn,
+		Org:       org,
+		RDNS:      rdns,
+		Valid:     true,
+	}
+
+	// Records without coordinates fall back to their country's centroid,
+	// or are left off the globe when the country is not known either
+	if location.Latitude == 0 && location.Longitude == 0 {
+		lat, lon, ok := geoip.CountryCentroid(geocodeResp.Country.ISOCode)
+		if !ok {
+			debugLog("Geocode API: No coordinates or country for %s", ipStr)
+			return LocationInfo{Valid: false}
+		}
+		debugLog("Geocode API: No coordinates for %s, placing it in %s", ipStr, geocodeResp.Country.ISOCode)
+		location.Latitude, location.Longitude, location.Approx = lat, lon, true
+	}
+	return location
+}
+
+func (g *GeoIPManager) lookupASN(ipStr string) (string, string) {
+	g.mutex.RLock()
+	asnDB, fallback := g.asnDB, g.asnFallback
+	g.mutex.RUnlock()
+
+	// Prefer the local database: no network round trip and no rate limits
+	if asnDB != nil {
+		if asn, org, ok := asnDB.Lookup(net.ParseIP(ipStr)); ok {
+			return asn, org
+		}
+		if !fallback {
+			return "", ""
+		}
+	}
+
+	// Try to fetch ASN info from ipinfo.io API (free tier allows limited requests)
+	url := fmt.Sprintf("https://ipinfo.io/%s/json", ipStr)
+
+	client := NewHTTPClient(2 * time.Second) // Increased timeout
+	resp, err := client.Get(url)
+	if err != nil {
+		debugLog("ASN Lookup: Failed for %s: %v", ipStr, err)
+		return "", ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		debugLog("ASN Lookup: HTTP %d for %s", resp.StatusCode, ipStr)
+		return "", ""
+	}
+
+	var result struct {
+		Org string `json:"org"` // Format: "AS15169 Google LLC"
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		debugLog("ASN Lookup: Decode error for %s: %v", ipStr, err)
+		return "", ""
+	}
+
+	// Parse "AS15169 Google LLC" into ASN and Org
+	if result.Org != "" {
+		debugLog("ASN Lookup: Success for %s: %s", ipStr, result.Org)
+		parts := strings.SplitN(result.Org, " ", 2)
+		if len(parts) == 2 {
+			return parts[0], parts[1] // "AS15169", "Google LLC"
+		}
+		return "", result.Org
+	}
+
+	return "", ""
+}
+
+func (g *GeoIPManager) lookupReverseDNS(ipStr string) string {
+	if g.rdns == nil {
+		return ""
+	}
+	return g.rdns.Lookup(ipStr)
+}
+
+// SetFixtures makes lookups try the embedded network table before the API
+func (g *GeoIPManager) SetFixtures(f Locator) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.fixtures = f
+}
+
+// SetDemo skips the ASN and reverse DNS lookups, which would only slow down
+// demo traffic. A local ASN database is still used.
+func (g *GeoIPManager) SetDemo(demo bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.demo = demo
+}
+
+// DefaultJitter is how many degrees (about 90 km at the equator) plotted
+// positions are spread over, so addresses geolocated to the same city
+// centroid do not all land on one spot
+const DefaultJitter = 0.8
+
+func (g *GeoIPManager) SetJitter(degrees float64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.jitter = degrees
+}
+
+// Plot is where an address's marker, arcs and footprint go on the globe:
+// its location moved by up to half the jitter each way, by an amount
+// seeded by the address so it stays put from frame to frame. Only the
+// globe uses it; the dashboard, exports and lookups keep the real location.
+func (g *GeoIPManager) Plot(ipStr string, loc LocationInfo) (float64, float64) {
+	g.mutex.RLock()
+	jitter := g.jitter
+	g.mutex.RUnlock()
+	if jitter <= 0 {
+		return loc.Latitude, loc.Longitude
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ipStr))
+	sum := h.Sum32()
+	lat := loc.Latitude + (float64(sum&0xffff)/0xffff-0.5)*jitter
+	lon := loc.Longitude + (float64(sum>>16)/0xffff-0.5)*jitter
+	return max(-90, min(90, lat)), lon
+}
+
+// SetReverseResolver sets the resolver used for rDNS enrichment
+func (g *GeoIPManager) SetReverseResolver(rr *ReverseResolver) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.rdns = rr
+}
+
+// Seed caches a known location for ipStr unless one is already cached, so
+// lookups for it never reach the network
+func (g *GeoIPManager) Seed(ipStr string, location LocationInfo) {
+	if !g.cache.Contains(ipStr) {
+		g.cache.Add(ipStr, location)
+	}
+}
+
+// SetMaxCache changes the cache capacity, evicting least recently used entries
+func (g *GeoIPManager) SetMaxCache(n int) {
+	g.cache.Resize(n)
+}
+
+// Shed evicts the least recently used half of the cache
+func (g *GeoIPManager) Shed() {
+	g.cache.Shed()
+}
+
+func (g *GeoIPManager) GetCacheStats() (int, int) {
+	return g.cache.Len(), g.cache.Capacity()
+}
+
+// count tallies a lookup for the hit ratio
+func (g *GeoIPManager) count(hit bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if hit {
+		g.hits++
+	} else {
+		g.misses++
+	}
+}
+
+// HitRatio is the share of lookups answered from the cache, or -1 before
+// the first lookup
+func (g *GeoIPManager) HitRatio() float64 {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	if g.hits+g.misses == 0 {
+		return -1
+	}
+	return float64(g.hits) / float64(g.hits+g.misses)
+}
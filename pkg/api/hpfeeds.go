@@ -0,0 +1,226 @@
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	hpfeedsOpError   = 0
+	hpfeedsOpInfo    = 1
+	hpfeedsOpAuth    = 2
+	hpfeedsOpPublish = 3
+)
+
+// EnrichedEvent is the JSON payload re-published to hpfeeds with geo/ASN data added
+type EnrichedEvent struct {
+	SrcIP     string      `json:"src_ip"`
+	Username  string      `json:"username"`
+	Password  string      `json:"password"`
+	Protocol  string      `json:"protocol"`
+	Timestamp string      `json:"timestamp"`
+	City      string      `json:"city,omitempty"`
+	Country   string      `json:"country,omitempty"`
+	Latitude  float64     `json:"latitude,omitempty"`
+	Longitude float64     `json:"longitude,omitempty"`
+	ASN       string      `json:"asn,omitempty"`
+	Org       string      `json:"org,omitempty"`
+	RDNS      string      `json:"rdns,omitempty"`
+	Origin    string      `json:"origin,omitempty"` // Label of the API endpoint that reported it
+	DestPort  int         `json:"dest_port,omitempty"`
+	Inferred  bool        `json:"protocol_inferred,omitempty"` // Protocol was guessed from DestPort
+	Feed      *FeedDetail `json:"honeypot_detail,omitempty"`   // What dionaea, p0f or suricata added
+}
+
+type HPFeedsPublisher struct {
+	addr      string
+	ident     string
+	secret    string
+	channel   string
+	queue     chan []byte
+	conn      net.Conn
+	connected bool
+	dropped   int
+	mutex     sync.RWMutex
+}
+
+func NewHPFeedsPublisher(host string, port int, ident, secret, channel string) *HPFeedsPublisher {
+	return &HPFeedsPublisher{
+		addr:    net.JoinHostPort(host, fmt.Sprintf("%d", port)),
+		ident:   ident,
+		secret:  secret,
+		channel: channel,
+		queue:   make(chan []byte, 1000),
+	}
+}
+
+// Addr is the broker's host:port
+func (hp *HPFeedsPublisher) Addr() string {
+	return hp.addr
+}
+
+// Start runs the publish loop, reconnecting with backoff when the broker drops
+func (hp *HPFeedsPublisher) Start() {
+	Workers.Go("hpfeeds", func(stop <-chan struct{}) error {
+		defer hp.disconnect()
+		backoff := time.Second
+		for {
+			var payload []byte
+			select {
+			case <-stop:
+				return nil
+			case payload = <-hp.queue:
+			}
+
+			for {
+				if hp.conn == nil {
+					if err := hp.connect(); err != nil {
+						debugLog("hpfeeds: Connect to %s failed: %v (retry in %v)", hp.addr, err, backoff)
+						select {
+						case <-stop:
+							return nil
+						case <-time.After(backoff):
+						}
+						backoff = time.Duration(math.Min(float64(backoff*2), float64(60*time.Second)))
+						continue
+					}
+					backoff = time.Second
+				}
+
+				if err := hp.writeMessage(hpfeedsOpPublish, hp.publishPayload(payload)); err != nil {
+					debugLog("hpfeeds: Publish failed: %v", err)
+					hp.disconnect()
+					continue
+				}
+				break
+			}
+		}
+	})
+}
+
+// Publish queues an enriched event, dropping it if the broker is backed up
+func (hp *HPFeedsPublisher) Publish(event EnrichedEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	select {
+	case hp.queue <- payload:
+	default:
+		hp.mutex.Lock()
+		hp.dropped++
+		hp.mutex.Unlock()
+	}
+}
+
+func (hp *HPFeedsPublisher) IsConnected() bool {
+	hp.mutex.RLock()
+	defer hp.mutex.RUnlock()
+	return hp.connected
+}
+
+func (hp *HPFeedsPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", hp.addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	// Broker greets with OP_INFO: broker name followed by a 4 byte nonce
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	opcode, payload, err := readHPFeedsMessage(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if opcode != hpfeedsOpInfo || len(payload) < 1 || len(payload) < int(payload[0])+1+4 {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting opcode %d", opcode)
+	}
+	nonce := payload[int(payload[0])+1:]
+
+	hp.conn = conn
+	hash := sha1.Sum(append(append([]byte{}, nonce...), []byte(hp.secret)...))
+	auth := append(hpfeedsString(hp.ident), hash[:]...)
+	if err := hp.writeMessage(hpfeedsOpAuth, auth); err != nil {
+		hp.disconnect()
+		return err
+	}
+
+	hp.mutex.Lock()
+	hp.connected = true
+	hp.mutex.Unlock()
+	debugLog("hpfeeds: Connected to %s as %s", hp.addr, hp.ident)
+
+	// Drain broker messages so auth errors surface in the debug log
+	go func(c net.Conn) {
+		for {
+			op, msg, err := readHPFeedsMessage(c)
+			if err != nil {
+				return
+			}
+			if op == hpfeedsOpError {
+				debugLog("hpfeeds: Broker error: %s", string(msg))
+			}
+		}
+	}(conn)
+
+	return nil
+}
+
+func (hp *HPFeedsPublisher) disconnect() {
+	if hp.conn != nil {
+		hp.conn.Close()
+		hp.conn = nil
+	}
+	hp.mutex.Lock()
+	hp.connected = false
+	hp.mutex.Unlock()
+}
+
+func (hp *HPFeedsPublisher) publishPayload(data []byte) []byte {
+	msg := hpfeedsString(hp.ident)
+	msg = append(msg, hpfeedsString(hp.channel)...)
+	return append(msg, data...)
+}
+
+func (hp *HPFeedsPublisher) writeMessage(opcode byte, payload []byte) error {
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header, uint32(5+len(payload)))
+	header[4] = opcode
+	hp.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := hp.conn.Write(append(header, payload...))
+	return err
+}
+
+// hpfeedsString encodes a string with the single byte length prefix used by hpfeeds
+func hpfeedsString(s string) []byte {
+	if len(s) > 255 {
+		s = s[:255]
+	}
+	return append([]byte{byte(len(s))}, []byte(s)...)
+}
+
+func readHPFeedsMessage(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length < 5 || length > 1024*1024 {
+		return 0, nil, fmt.Errorf("invalid message length %d", length)
+	}
+	payload := make([]byte, length-5)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[4], payload, nil
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPTransport carries every outbound HTTP request (API, geocoding,
+// ipinfo.io, stats, webhooks and sinks) so --proxy, --ca-cert and
+// --tls-skip-verify apply to all of them. Without those options it is the
+// default transport, which still honors HTTP_PROXY / HTTPS_PROXY / NO_PROXY.
+var HTTPTransport http.RoundTripper = http.DefaultTransport
+
+// NewHTTPClient returns a client with the given timeout on the shared transport
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: HTTPTransport}
+}
+
+// NewHTTPTransport builds the shared transport. A nil proxyURL keeps the
+// proxy environment variables and nil roots keep the system roots.
+func NewHTTPTransport(proxyURL *url.URL, roots *x509.CertPool, skipVerify bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if roots != nil || skipVerify {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			RootCAs:            roots,
+			InsecureSkipVerify: skipVerify,
+		}
+	}
+	return transport
+}
+
+// ParseProxyURL validates an http://, https:// or socks5:// proxy URL
+func ParseProxyURL(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q (use http://, https:// or socks5://)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("%q has no host", proxy)
+	}
+	return proxyURL, nil
+}
+
+// LoadCABundle adds a PEM bundle to the system roots, so private MHN servers
+// and public services such as ipinfo.io verify alike
+func LoadCABundle(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", caFile)
+	}
+	return pool, nil
+}
+
+// pollingCacheSize bounds the responses a PollingTransport remembers: the
+// latest events page plus today's and yesterday's stats
+const pollingCacheSize = 4
+
+// TransferStats counts the response bodies of a polled resource as they
+// arrived on the wire and after decompression, plus the requests the server
+// answered 304 Not Modified
+type TransferStats struct {
+	Name string
+
+	mutex       sync.Mutex
+	requests    int
+	notModified int
+	wireBytes   int64
+	bodyBytes   int64
+}
+
+// TransferCounts is a copy of a TransferStats' counters
+type TransferCounts struct {
+	Name        string
+	Requests    int
+	NotModified int
+	WireBytes   int64
+	BodyBytes   int64
+}
+
+func (ts *TransferStats) record(wire, body int, notModified bool) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.requests++
+	if notModified {
+		ts.notModified++
+	}
+	ts.wireBytes += int64(wire)
+	ts.bodyBytes += int64(body)
+}
+
+// Counts returns a copy of the counters
+func (ts *TransferStats) Counts() TransferCounts {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return TransferCounts{ts.Name, ts.requests, ts.notModified, ts.wireBytes, ts.bodyBytes}
+}
+
+// polledResponse is the remembered body and validators of a response
+type polledResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// PollingTransport asks for gzip and revalidates with the ETag or
+// Last-Modified of the previous response for the same URL, so polling an
+// unchanged resource transfers only headers. A 304 is answered with the
+// remembered body, so callers always see the full 200 response, marked with
+// the replayedHeader.
+type PollingTransport struct {
+	next  http.RoundTripper
+	stats *TransferStats
+
+	mutex sync.Mutex
+	cache map[string]*polledResponse
+	order []string // Cached URLs, oldest first
+}
+
+// replayedHeader marks a response whose body was remembered from an earlier one
+const replayedHeader = "X-From-Cache"
+
+func NewPollingTransport(next http.RoundTripper, stats *TransferStats) *PollingTransport {
+	return &PollingTransport{
+		next:  next,
+		stats: stats,
+		cache: make(map[string]*polledResponse),
+	}
+}
+
+func (pt *PollingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	pt.mutex.Lock()
+	cached := pt.cache[key]
+	pt.mutex.Unlock()
+
+	// Setting Accept-Encoding ourselves turns off the transport's transparent
+	// decompression, which is what lets the compressed size be counted
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := pt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	wire, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	body := wire
+	notModified := resp.StatusCode == http.StatusNotModified && cached != nil
+	if notModified {
+		resp.StatusCode, resp.Status = http.StatusOK, "200 OK"
+		resp.Header.Set(replayedHeader, "1")
+		body = cached.body
+	} else if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(bytes.NewReader(wire))
+		if err != nil {
+			return nil, err
+		}
+		if body, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.Uncompressed = true
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	pt.stats.record(len(wire), len(body), notModified)
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if !notModified && resp.StatusCode == http.StatusOK && (etag != "" || lastModified != "") {
+		pt.remember(key, &polledResponse{etag: etag, lastModified: lastModified, body: body})
+	}
+	return resp, nil
+}
+
+// remember caches a response, dropping the oldest URL beyond pollingCacheSize
+func (pt *PollingTransport) remember(key string, entry *polledResponse) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+	if _, ok := pt.cache[key]; ok {
+		pt.order = slices.DeleteFunc(pt.order, func(k string) bool { return k == key })
+	}
+	pt.cache[key] = entry
+	pt.order = append(pt.order, key)
+	for len(pt.order) > pollingCacheSize {
+		delete(pt.cache, pt.order[0])
+		pt.order = pt.order[1:]
+	}
+}
+
+// FormatBytes renders a byte count compactly, e.g. 812B, 40.2K or 3.1M
+func FormatBytes(n int64) string {
+	switch {
+	case n < 1<<10:
+		return fmt.Sprintf("%dB", n)
+	case n < 1<<20:
+		return fmt.Sprintf("%.1fK", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%.1fM", float64(n)/(1<<20))
+	}
+}
+
+var EventsTransfer = &TransferStats{Name: "Events"}
+
+var StatsTransfer = &TransferStats{Name: "Stats"}
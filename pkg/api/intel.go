@@ -0,0 +1,454 @@
+package api
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultIntelInterval = 5 * time.Minute
+	DefaultIntelWindow   = 24 * time.Hour
+	maxIntelSources      = 50000
+	intelBatchSize       = 500 // Objects or attributes per TAXII/MISP request
+)
+
+// stixNamespace seeds the UUIDv5 ids of exported objects, so an address
+// keeps the same indicator id across exports and restarts and TAXII servers
+// store re-exports as new versions of it
+var stixNamespace = [16]byte{0x8c, 0xf2, 0x3e, 0xec, 0x31, 0x7a, 0x4a, 0x96, 0x88, 0x44, 0x42, 0xb1, 0xdf, 0x5b, 0xa3, 0x32}
+
+// IntelSource sums up what one attacking address has done
+type IntelSource struct {
+	IP        string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Count     int
+	Protocols map[string]int
+	Country   string
+	ASN       string
+	Org       string
+	updated   time.Time // When the last sighting arrived, which lags LastSeen by the poll delay
+}
+
+// IntelExporter turns the addresses seen in the last window into STIX 2.1
+// indicators with sightings. Every interval it writes the whole feed to a
+// file and pushes the addresses active since the last successful push to a
+// TAXII 2.1 collection and a MISP instance, whichever are configured.
+type IntelExporter struct {
+	window       time.Duration
+	minSightings int
+	sources      map[string]*IntelSource
+	dropped      int
+
+	file      string
+	taxiiURL  string // Collection objects endpoint, .../collections/<id>/objects/
+	taxiiUser string
+	taxiiPass string
+	mispURL   string
+	mispKey   string
+
+	lastTAXII  time.Time
+	lastMISP   time.Time
+	httpClient *http.Client
+	mutex      sync.Mutex
+}
+
+func NewIntelExporter(window time.Duration, minSightings int) *IntelExporter {
+	return &IntelExporter{
+		window:       window,
+		minSightings: minSightings,
+		sources:      make(map[string]*IntelSource),
+		httpClient:   NewHTTPClient(30 * time.Second),
+	}
+}
+
+// Window is how long a source stays in the feed after its last sighting
+func (ie *IntelExporter) Window() time.Duration {
+	return ie.window
+}
+
+// SetFile writes the STIX bundle to path on every export
+func (ie *IntelExporter) SetFile(path string) {
+	ie.file = path
+}
+
+// SetTAXII pushes the feed to a TAXII 2.1 collection's objects endpoint
+func (ie *IntelExporter) SetTAXII(url, user, pass string) {
+	ie.taxiiURL, ie.taxiiUser, ie.taxiiPass = url, user, pass
+}
+
+// SetMISP pushes the feed to a MISP instance
+func (ie *IntelExporter) SetMISP(url, key string) {
+	ie.mispURL, ie.mispKey = url, key
+}
+
+// Record counts a sighting of the connection's source address
+func (ie *IntelExporter) Record(conn Connection) {
+	if net.ParseIP(conn.IP) == nil {
+		return
+	}
+	ie.mutex.Lock()
+	defer ie.mutex.Unlock()
+
+	src, ok := ie.sources[conn.IP]
+	if !ok {
+		if len(ie.sources) >= maxIntelSources {
+			ie.expire(time.Now())
+		}
+		if len(ie.sources) >= maxIntelSources {
+			ie.dropped++
+			return
+		}
+		src = &IntelSource{IP: conn.IP, FirstSeen: conn.Time, Protocols: make(map[string]int)}
+		ie.sources[conn.IP] = src
+	}
+	src.Count++
+	src.updated = time.Now()
+	src.Protocols[conn.Protocol]++
+	if conn.Time.Before(src.FirstSeen) {
+		src.FirstSeen = conn.Time
+	}
+	if conn.Time.After(src.LastSeen) {
+		src.LastSeen = conn.Time
+	}
+	if conn.Country != "" {
+		src.Country, src.ASN, src.Org = conn.Country, conn.ASN, conn.Org
+	}
+}
+
+// expire forgets addresses not seen within the window; the caller holds
+// ie.mutex
+func (ie *IntelExporter) expire(now time.Time) {
+	for ip, src := range ie.sources {
+		if now.Sub(src.LastSeen) > ie.window {
+			delete(ie.sources, ip)
+		}
+	}
+}
+
+// Sources lists the addresses with enough sightings that had one recorded
+// after since, sorted by address
+func (ie *IntelExporter) Sources(since time.Time) []IntelSource {
+	ie.mutex.Lock()
+	defer ie.mutex.Unlock()
+	ie.expire(time.Now())
+	var list []IntelSource
+	for _, src := range ie.sources {
+		if src.Count >= ie.minSightings && src.updated.After(since) {
+			copied := *src
+			copied.Protocols = maps.Clone(src.Protocols)
+			list = append(list, copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].IP < list[j].IP })
+	return list
+}
+
+// Start exports every interval until shutdown
+func (ie *IntelExporter) Start(interval time.Duration) {
+	Workers.Go("intel-export", func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return nil
+			case now := <-ticker.C:
+				ie.export(now)
+			}
+		}
+	})
+}
+
+func (ie *IntelExporter) export(now time.Time) {
+	if ie.file != "" {
+		if err := ie.writeFile(now); err != nil {
+			debugLog("Intel: Writing %s failed: %v", ie.file, err)
+		}
+	}
+	if ie.taxiiURL != "" {
+		fresh := ie.Sources(ie.lastTAXII)
+		if err := ie.pushTAXII(fresh, now); err != nil {
+			debugLog("Intel: TAXII push of %d indicators failed: %v", len(fresh), err)
+		} else {
+			ie.lastTAXII = now
+			debugLog("Intel: Pushed %d indicators to TAXII", len(fresh))
+		}
+	}
+	if ie.mispURL != "" {
+		fresh := ie.Sources(ie.lastMISP)
+		if err := ie.pushMISP(fresh, now); err != nil {
+			debugLog("Intel: MISP push of %d attributes failed: %v", len(fresh), err)
+		} else {
+			ie.lastMISP = now
+			debugLog("Intel: Pushed %d attributes to MISP", len(fresh))
+		}
+	}
+}
+
+// writeFile replaces the feed file with a bundle of every address in the
+// window, through a temporary file so readers never see half a bundle
+func (ie *IntelExporter) writeFile(now time.Time) error {
+	data, err := json.MarshalIndent(StixBundle(ie.Sources(time.Time{}), ie.window, now), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ie.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ie.file)
+}
+
+// pushTAXII adds the indicators and sightings to the collection, in batches
+func (ie *IntelExporter) pushTAXII(sources []IntelSource, now time.Time) error {
+	for start := 0; start < len(sources); start += intelBatchSize {
+		batch := sources[start:min(start+intelBatchSize, len(sources))]
+		envelope := map[string]interface{}{"objects": StixBundle(batch, ie.window, now).Objects}
+		header := http.Header{
+			"Content-Type": {"application/taxii+json;version=2.1"},
+			"Accept":       {"application/taxii+json;version=2.1"},
+		}
+		if err := ie.post(ie.taxiiURL, header, envelope, func(req *http.Request) {
+			if ie.taxiiUser != "" {
+				req.SetBasicAuth(ie.taxiiUser, ie.taxiiPass)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushMISP creates one MISP event per batch holding an ip-src attribute
+// for each address
+func (ie *IntelExporter) pushMISP(sources []IntelSource, now time.Time) error {
+	for start := 0; start < len(sources); start += intelBatchSize {
+		batch := sources[start:min(start+intelBatchSize, len(sources))]
+		attributes := make([]map[string]interface{}, len(batch))
+		for i, src := range batch {
+			attributes[i] = map[string]interface{}{
+				"type":       "ip-src",
+				"category":   "Network activity",
+				"value":      src.IP,
+				"to_ids":     true,
+				"comment":    src.Description(),
+				"first_seen": stixTime(src.FirstSeen),
+				"last_seen":  stixTime(src.LastSeen),
+			}
+		}
+		event := map[string]interface{}{"Event": map[string]interface{}{
+			"info":            fmt.Sprintf("SecKC-MHN-Globe honeypot attackers, %s", now.UTC().Format("2006-01-02 15:04 UTC")),
+			"date":            now.UTC().Format("2006-01-02"),
+			"distribution":    "0", // This organization only
+			"threat_level_id": "3", // Low
+			"analysis":        "2", // Completed
+			"Attribute":       attributes,
+		}}
+		header := http.Header{"Content-Type": {"application/json"}, "Accept": {"application/json"}}
+		if err := ie.post(strings.TrimSuffix(ie.mispURL, "/")+"/events/add", header, event, func(req *http.Request) {
+			req.Header.Set("Authorization", ie.mispKey)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ie *IntelExporter) post(url string, header http.Header, payload interface{}, auth func(*http.Request)) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	auth(req)
+	resp, err := ie.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Description is the human readable summary used in STIX and MISP
+func (src IntelSource) Description() string {
+	protocols := make([]string, 0, len(src.Protocols))
+	for protocol := range src.Protocols {
+		protocols = append(protocols, protocol)
+	}
+	sort.Slice(protocols, func(i, j int) bool {
+		return src.Protocols[protocols[i]] > src.Protocols[protocols[j]] ||
+			(src.Protocols[protocols[i]] == src.Protocols[protocols[j]] && protocols[i] < protocols[j])
+	})
+	for i, protocol := range protocols {
+		protocols[i] = fmt.Sprintf("%s (%d)", protocol, src.Protocols[protocol])
+	}
+	text := fmt.Sprintf("Seen %d times by SecKC honeypots over %s", src.Count, strings.Join(protocols, ", "))
+	if src.Country != "" {
+		text += fmt.Sprintf("; %s %s %s", src.Country, src.ASN, src.Org)
+	}
+	return strings.TrimSpace(text)
+}
+
+// stixBundle is a STIX 2.1 bundle with an indicator and a sighting for
+// every source, created by an identity for this program
+type stixBundle struct {
+	Type    string        `json:"type"`
+	ID      string        `json:"id"`
+	Objects []interface{} `json:"objects"`
+}
+
+type stixIdentity struct {
+	Type          string `json:"type"`
+	SpecVersion   string `json:"spec_version"`
+	ID            string `json:"id"`
+	Created       string `json:"created"`
+	Modified      string `json:"modified"`
+	Name          string `json:"name"`
+	IdentityClass string `json:"identity_class"`
+}
+
+type stixIndicator struct {
+	Type           string   `json:"type"`
+	SpecVersion    string   `json:"spec_version"`
+	ID             string   `json:"id"`
+	Created        string   `json:"created"`
+	Modified       string   `json:"modified"`
+	CreatedByRef   string   `json:"created_by_ref"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	IndicatorTypes []string `json:"indicator_types"`
+	Pattern        string   `json:"pattern"`
+	PatternType    string   `json:"pattern_type"`
+	ValidFrom      string   `json:"valid_from"`
+	ValidUntil     string   `json:"valid_until"`
+	Labels         []string `json:"labels,omitempty"`
+}
+
+type stixSighting struct {
+	Type          string `json:"type"`
+	SpecVersion   string `json:"spec_version"`
+	ID            string `json:"id"`
+	Created       string `json:"created"`
+	Modified      string `json:"modified"`
+	CreatedByRef  string `json:"created_by_ref"`
+	SightingOfRef string `json:"sighting_of_ref"`
+	Count         int    `json:"count"`
+	FirstSeen     string `json:"first_seen"`
+	LastSeen      string `json:"last_seen"`
+}
+
+// StixBundle builds the bundle for sources. Indicators stay valid for a
+// window after an address was last seen.
+func StixBundle(sources []IntelSource, window time.Duration, now time.Time) stixBundle {
+	identity := stixIdentity{
+		Type:          "identity",
+		SpecVersion:   "2.1",
+		ID:            "identity--" + uuidV5(stixNamespace, "SecKC-MHN-Globe"),
+		Created:       "2025-01-01T00:00:00.000Z",
+		Modified:      "2025-01-01T00:00:00.000Z",
+		Name:          "SecKC-MHN-Globe",
+		IdentityClass: "system",
+	}
+	bundle := stixBundle{Type: "bundle", ID: "bundle--" + uuidV4(), Objects: []interface{}{identity}}
+
+	for _, src := range sources {
+		addrType := "ipv4-addr"
+		if !strings.Contains(src.IP, ".") {
+			addrType = "ipv6-addr"
+		}
+		protocols := make([]string, 0, len(src.Protocols))
+		for protocol := range src.Protocols {
+			protocols = append(protocols, protocol)
+		}
+		sort.Strings(protocols)
+
+		// modified must not be earlier than created, even for backfilled events
+		modified := stixTime(maxTime(now, src.FirstSeen))
+		indicator := stixIndicator{
+			Type:           "indicator",
+			SpecVersion:    "2.1",
+			ID:             "indicator--" + uuidV5(stixNamespace, src.IP),
+			Created:        stixTime(src.FirstSeen),
+			Modified:       modified,
+			CreatedByRef:   identity.ID,
+			Name:           "Honeypot attacker " + src.IP,
+			Description:    src.Description(),
+			IndicatorTypes: []string{"malicious-activity"},
+			Pattern:        fmt.Sprintf("[%s:value = '%s']", addrType, src.IP),
+			PatternType:    "stix",
+			ValidFrom:      stixTime(src.FirstSeen),
+			ValidUntil:     stixTime(src.LastSeen.Add(window)),
+			Labels:         protocols,
+		}
+		sighting := stixSighting{
+			Type:          "sighting",
+			SpecVersion:   "2.1",
+			ID:            "sighting--" + uuidV5(stixNamespace, "sighting|"+src.IP),
+			Created:       stixTime(src.FirstSeen),
+			Modified:      modified,
+			CreatedByRef:  identity.ID,
+			SightingOfRef: indicator.ID,
+			Count:         src.Count,
+			FirstSeen:     stixTime(src.FirstSeen),
+			LastSeen:      stixTime(src.LastSeen),
+		}
+		bundle.Objects = append(bundle.Objects, indicator, sighting)
+	}
+	return bundle
+}
+
+func stixTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// uuidV5 is the RFC 4122 name based UUID of name in namespace
+func uuidV5(namespace [16]byte, name string) string {
+	sum := sha1.Sum(append(namespace[:], name...))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return formatUUID(sum[:16])
+}
+
+func uuidV4() string {
+	var b [16]byte
+	crand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b[:])
+}
+
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func IsHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
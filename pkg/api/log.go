@@ -0,0 +1,17 @@
+package api
+
+import "log"
+
+var debugLogger *log.Logger
+
+// SetDebugLogger sends the package's debug messages to logger; they are
+// dropped while it is nil
+func SetDebugLogger(logger *log.Logger) {
+	debugLogger = logger
+}
+
+func debugLog(format string, v ...interface{}) {
+	if debugLogger != nil {
+		debugLogger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+// MQTT 3.1.1 control packet types (high nibble of the first byte)
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttSubscribe  = 8
+	mqttSuback     = 9
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+	mqttKeepAlive  = 60 * time.Second
+	mqttMaxPacket  = 1 << 20
+	mqttAckTimeout = 10 * time.Second
+)
+
+// MQTTClient is a small MQTT 3.1.1 client: QoS 0 and 1 publish and
+// subscribe, username/password auth and TLS. It is a busTransport, so the
+// sink runs it under a BusPublisher.
+type MQTTClient struct {
+	addr      string
+	tlsConfig *tls.Config // Non-nil for mqtts:// brokers
+	clientID  string
+	user      string
+	pass      string
+	topic     string // Publish topic
+	qos       byte
+
+	onMessage func(topic string, payload []byte)
+
+	conn    net.Conn
+	writeMu sync.Mutex
+	nextID  uint16
+	acks    chan uint16   // Packet ids of PUBACK and SUBACK packets
+	done    chan struct{} // Closed when the connection is lost
+}
+
+// NewMQTTClient takes a mqtt://host[:1883] or mqtts://host[:8883] broker
+// URL and an optional PEM CA bundle for mqtts
+func NewMQTTClient(broker, clientID, user, pass, caFile string, qos int) (*MQTTClient, error) {
+	scheme, host, ok := strings.Cut(broker, "://")
+	ports := map[string]string{"mqtt": "1883", "tcp": "1883", "mqtts": "8883", "ssl": "8883"}
+	port, known := ports[scheme]
+	if !ok || !known || host == "" {
+		return nil, fmt.Errorf("broker %q must look like mqtt://host:1883 or mqtts://host:8883", broker)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	mc := &MQTTClient{addr: host, clientID: clientID, user: user, pass: pass, qos: byte(qos)}
+	if port == "8883" {
+		mc.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("%s: no PEM certificates found", caFile)
+			}
+			mc.tlsConfig.RootCAs = pool
+		}
+	}
+	return mc, nil
+}
+
+// SetTopic sets the topic events are published to
+func (mc *MQTTClient) SetTopic(topic string) {
+	mc.topic = topic
+}
+
+// Connect opens a clean session and starts the reader and keepalive loops
+func (mc *MQTTClient) Connect() error {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	var err error
+	if mc.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", mc.addr, mc.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", mc.addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	flags := byte(0x02) // Clean session
+	payload := mqttString(nil, mc.clientID)
+	if mc.user != "" {
+		flags |= 0x80
+		payload = mqttString(payload, mc.user)
+		if mc.pass != "" {
+			flags |= 0x40
+			payload = mqttString(payload, mc.pass)
+		}
+	}
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, flags) // Protocol level 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(mqttKeepAlive/time.Second))
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write(mqttPacket(mqttConnect<<4, append(body, payload...))); err != nil {
+		conn.Close()
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+	kind, ack, err := readMQTTPacket(reader)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if kind>>4 != mqttConnack || len(ack) != 2 {
+		conn.Close()
+		return fmt.Errorf("unexpected reply to CONNECT (packet type %d)", kind>>4)
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		reasons := []string{"", "unacceptable protocol version", "client id rejected", "server unavailable", "bad username or password", "not authorized"}
+		if int(ack[1]) < len(reasons) {
+			return fmt.Errorf("connection refused: %s", reasons[ack[1]])
+		}
+		return fmt.Errorf("connection refused: code %d", ack[1])
+	}
+	conn.SetDeadline(time.Time{})
+
+	mc.conn = conn
+	mc.acks = make(chan uint16, 16)
+	mc.done = make(chan struct{})
+	go mc.readLoop(conn, reader, mc.done)
+	go mc.pingLoop(mc.done)
+	debugLog("mqtt: Connected to %s as %s", mc.addr, mc.clientID)
+	return nil
+}
+
+func (mc *MQTTClient) readLoop(conn net.Conn, reader *bufio.Reader, done chan struct{}) {
+	defer close(done)
+	for {
+		conn.SetReadDeadline(time.Now().Add(mqttKeepAlive * 3 / 2))
+		kind, body, err := readMQTTPacket(reader)
+		if err != nil {
+			debugLog("mqtt: Connection to %s lost: %v", mc.addr, err)
+			conn.Close()
+			return
+		}
+		switch kind >> 4 {
+		case mqttPublish:
+			qos := kind >> 1 & 0x03
+			if len(body) < 2 {
+				continue
+			}
+			topicEnd := 2 + int(binary.BigEndian.Uint16(body))
+			payloadStart := topicEnd
+			if qos > 0 {
+				payloadStart += 2 // Packet id
+			}
+			if payloadStart > len(body) {
+				continue
+			}
+			if mc.onMessage != nil {
+				mc.onMessage(string(body[2:topicEnd]), body[payloadStart:])
+			}
+			if qos == 1 {
+				mc.write(mqttPacket(mqttPuback<<4, body[topicEnd:payloadStart]))
+			}
+		case mqttPuback, mqttSuback:
+			if len(body) >= 2 {
+				if kind>>4 == mqttSuback && len(body) > 2 && body[2] == 0x80 {
+					debugLog("mqtt: Broker refused the subscription")
+					conn.Close()
+					continue
+				}
+				select {
+				case mc.acks <- binary.BigEndian.Uint16(body):
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (mc *MQTTClient) pingLoop(done chan struct{}) {
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			mc.write([]byte{mqttPingreq << 4, 0})
+		}
+	}
+}
+
+// Subscribe asks for messages matching filter and waits for the broker to agree
+func (mc *MQTTClient) Subscribe(filter string) error {
+	id := mc.packetID()
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = append(mqttString(body, filter), mc.qos)
+	if err := mc.write(mqttPacket(mqttSubscribe<<4|0x02, body)); err != nil {
+		return err
+	}
+	return mc.awaitAck(id)
+}
+
+// Publish sends value to the client's topic; key is unused, MQTT has none
+func (mc *MQTTClient) Publish(key, value []byte) error {
+	body := mqttString(nil, mc.topic)
+	var id uint16
+	if mc.qos > 0 {
+		id = mc.packetID()
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	if err := mc.write(mqttPacket(mqttPublish<<4|mc.qos<<1, append(body, value...))); err != nil {
+		return err
+	}
+	if mc.qos > 0 {
+		return mc.awaitAck(id)
+	}
+	return nil
+}
+
+func (mc *MQTTClient) Close() {
+	mc.writeMu.Lock()
+	defer mc.writeMu.Unlock()
+	if mc.conn != nil {
+		mc.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		mc.conn.Write([]byte{mqttDisconnect << 4, 0})
+		mc.conn.Close()
+		mc.conn = nil
+	}
+}
+
+func (mc *MQTTClient) awaitAck(id uint16) error {
+	timeout := time.After(mqttAckTimeout)
+	for {
+		select {
+		case acked := <-mc.acks:
+			if acked == id {
+				return nil
+			}
+		case <-mc.done:
+			return fmt.Errorf("connection lost")
+		case <-timeout:
+			return fmt.Errorf("no acknowledgement from broker")
+		}
+	}
+}
+
+func (mc *MQTTClient) packetID() uint16 {
+	mc.nextID++
+	if mc.nextID == 0 {
+		mc.nextID = 1
+	}
+	return mc.nextID
+}
+
+func (mc *MQTTClient) write(packet []byte) error {
+	mc.writeMu.Lock()
+	defer mc.writeMu.Unlock()
+	if mc.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	mc.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := mc.conn.Write(packet)
+	return err
+}
+
+// mqttPacket prefixes a packet body with its fixed header
+func mqttPacket(first byte, body []byte) []byte {
+	packet := []byte{first}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, fmt.Errorf("invalid remaining length")
+		}
+	}
+	if length > mqttMaxPacket {
+		return 0, nil, fmt.Errorf("packet of %d bytes is too large", length)
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return first, body, err
+}
+
+func mqttString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// StartMQTTSource subscribes to filter and hands every JSON event that
+// arrives to deliver as a live row. Payloads are honeypot events as the API
+// returns them (src_ip or peerIP, loggedin or username/password, protocol,
+// sensor) or enriched events, whose locations seed geo.
+func StartMQTTSource(mc *MQTTClient, filter string, geo *GeoIPManager, deliver func(rows []Connection, live bool)) {
+	mc.onMessage = func(topic string, payload []byte) {
+		if !json.Valid(payload) {
+			debugLog("mqtt: Ignoring message on %s: not JSON", topic)
+			return
+		}
+		event, ok := mhn.Parse(payload)
+		if !ok {
+			return
+		}
+		// Only enriched events carry a location; other fields may not fit its types
+		var enriched EnrichedEvent
+		json.Unmarshal(payload, &enriched)
+		if geo != nil && (enriched.Latitude != 0 || enriched.Longitude != 0) {
+			geo.Seed(event.SrcIP, LocationInfo{
+				City:      enriched.City,
+				Country:   enriched.Country,
+				Latitude:  enriched.Latitude,
+				Longitude: enriched.Longitude,
+				ASN:       enriched.ASN,
+				Org:       enriched.Org,
+				RDNS:      enriched.RDNS,
+				Valid:     true,
+			})
+		}
+		// Another globe's guess is left to this one to make again
+		if enriched.Inferred {
+			event.Protocol = ""
+		}
+		deliver([]Connection{eventConnection(event, time.Now(), "")}, true)
+	}
+
+	Workers.Go("mqtt-source", func(stop <-chan struct{}) error {
+		defer mc.Close()
+		backoff := time.Second
+		for {
+			err := mc.Connect()
+			if err == nil {
+				if err = mc.Subscribe(filter); err == nil {
+					debugLog("mqtt: Subscribed to %s", filter)
+					backoff = time.Second
+					select {
+					case <-stop:
+						return nil
+					case <-mc.done:
+					}
+				}
+				mc.Close()
+			}
+			if err != nil {
+				debugLog("mqtt: Source on %s failed: %v (retry in %v)", mc.addr, err, backoff)
+			}
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(backoff):
+			}
+			backoff = time.Duration(math.Min(float64(backoff*2), float64(60*time.Second)))
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxNegativeRDNS = 10000
+
+type rdnsJob struct {
+	ip     string
+	result chan string
+}
+
+// ReverseResolver performs PTR lookups on a fixed pool of workers so a burst
+// of new attackers cannot spawn unbounded goroutines, and remembers addresses
+// with no PTR record so they are not re-resolved on every sighting
+type ReverseResolver struct {
+	resolver    *net.Resolver
+	server      string
+	workers     int
+	timeout     time.Duration
+	negativeTTL time.Duration
+	jobs        chan rdnsJob
+	negative    map[string]time.Time // IP -> negative cache expiry
+	mutex       sync.Mutex
+}
+
+// NewReverseResolver uses the system resolver unless server (host or
+// host:port) is given, in which case queries go straight to that server
+func NewReverseResolver(server string, workers int, timeout, negativeTTL time.Duration) *ReverseResolver {
+	rr := &ReverseResolver{
+		resolver:    net.DefaultResolver,
+		workers:     workers,
+		timeout:     timeout,
+		negativeTTL: negativeTTL,
+		jobs:        make(chan rdnsJob, workers*4),
+		negative:    make(map[string]time.Time),
+	}
+	if server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		rr.server = server
+		rr.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dialer := net.Dialer{Timeout: timeout}
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return rr
+}
+
+// Server is the DNS server queried, empty for the system resolver
+func (rr *ReverseResolver) Server() string {
+	return rr.server
+}
+
+// Start launches the supervised worker pool
+func (rr *ReverseResolver) Start() {
+	for i := 1; i <= rr.workers; i++ {
+		Workers.Go(fmt.Sprintf("rdns-%d", i), func(stop <-chan struct{}) error {
+			for {
+				select {
+				case <-stop:
+					return nil
+				case job := <-rr.jobs:
+					job.result <- rr.resolve(job.ip)
+				}
+			}
+		})
+	}
+}
+
+// Lookup returns the PTR name for ip, or "" when there is none, the lookup
+// times out, or every worker is busy
+func (rr *ReverseResolver) Lookup(ip string) string {
+	rr.mutex.Lock()
+	expiry, cached := rr.negative[ip]
+	rr.mutex.Unlock()
+	if cached && time.Now().Before(expiry) {
+		debugLog("rDNS Lookup: Negative cache hit for %s", ip)
+		return ""
+	}
+
+	job := rdnsJob{ip: ip, result: make(chan string, 1)}
+	select {
+	case rr.jobs <- job:
+	default:
+		debugLog("rDNS Lookup: Queue full, skipping %s", ip)
+		return ""
+	}
+
+	select {
+	case name := <-job.result:
+		return name
+	case <-time.After(2 * rr.timeout):
+		debugLog("rDNS Lookup: Timeout waiting for worker for %s", ip)
+		return ""
+	}
+}
+
+func (rr *ReverseResolver) resolve(ip string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), rr.timeout)
+	defer cancel()
+
+	names, err := rr.resolver.LookupAddr(ctx, ip)
+	if err == nil && len(names) > 0 {
+		rdns := strings.TrimSuffix(names[0], ".")
+		debugLog("rDNS Lookup: Success for %s: %s", ip, rdns)
+		return rdns
+	}
+
+	debugLog("rDNS Lookup: Failed for %s: %v", ip, err)
+	// Only an authoritative "no such record" is cached; timeouts and server
+	// failures are retried the next time the address shows up
+	if dnsErr, ok := err.(*net.DNSError); (ok && dnsErr.IsNotFound) || (err == nil && len(names) == 0) {
+		rr.addNegative(ip)
+	}
+	return ""
+}
+
+func (rr *ReverseResolver) addNegative(ip string) {
+	rr.mutex.Lock()
+	defer rr.mutex.Unlock()
+
+	now := time.Now()
+	if len(rr.negative) >= maxNegativeRDNS {
+		for cachedIP, expiry := range rr.negative {
+			if now.After(expiry) {
+				delete(rr.negative, cachedIP)
+			}
+		}
+		// Still full of live entries: drop arbitrary ones rather than grow
+		for cachedIP := range rr.negative {
+			if len(rr.negative) < maxNegativeRDNS {
+				break
+			}
+			delete(rr.negative, cachedIP)
+		}
+	}
+	rr.negative[ip] = now.Add(rr.negativeTTL)
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamPath is where the API would offer live events as a WebSocket or as
+// server-sent events. Until something answers there, the client polls.
+const streamPath = "/feeds/events/stream"
+
+const (
+	streamRetry       = 15 * time.Second // Reconnect delay after a stream drops
+	streamProbeRetry  = 10 * time.Minute // Re-check an API that had no stream endpoint
+	streamIdleTimeout = 90 * time.Second // Reconnect when the stream stays silent this long
+	streamDialTimeout = 10 * time.Second // Give up on a stream request that gets no answer
+	maxStreamMessage  = 1 << 20
+	wsAcceptGUID      = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// StreamModes are the accepted --stream values
+var StreamModes = []string{"auto", "websocket", "sse", "off"}
+
+// errStreamUnsupported means the API answered without a stream
+var errStreamUnsupported = errors.New("no event stream")
+
+// Stream connects to the API's event stream and passes each batch of events
+// to deliver until stop closes or the stream drops. In auto mode a single
+// request offers both a WebSocket upgrade and server-sent events and the
+// server picks one. It returns errStreamUnsupported when the API answers
+// with anything else, such as a 404.
+func (api *APIClient) Stream(mode string, stop <-chan struct{}, deliver func([]APIEvent)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The idle timer first bounds the handshake, then the silence between messages
+	idle := time.NewTimer(streamDialTimeout)
+	defer idle.Stop()
+	go func() {
+		select {
+		case <-stop:
+		case <-idle.C:
+			debugLog("Stream: Nothing received for %v", streamIdleTimeout)
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	touch := func() { idle.Reset(streamIdleTimeout) }
+
+	url := strings.TrimSuffix(api.config.BaseURL, "/") + streamPath
+	if api.lastEventTS > 0 {
+		url = fmt.Sprintf("%s?since=%.1f", url, api.lastEventTS)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	api.authorize(req)
+	wsKey := ""
+	if mode != "sse" {
+		nonce := make([]byte, 16)
+		crand.Read(nonce)
+		wsKey = base64.StdEncoding.EncodeToString(nonce)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", wsKey)
+	}
+	if mode != "websocket" {
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Cache-Control", "no-cache")
+	}
+
+	resp, err := api.streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	touch()
+	api.recordStatus(url, resp.StatusCode)
+
+	// Events delivered here move the polling cursor too, so polling picks up
+	// where the stream left off
+	forward := func(events []APIEvent) {
+		touch()
+		for _, ev := range events {
+			api.lastEventTS = max(api.lastEventTS, ev.Timestamp)
+		}
+		deliver(events)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case resp.StatusCode == http.StatusSwitchingProtocols && wsKey != "":
+		conn, ok := resp.Body.(io.ReadWriteCloser)
+		if !ok {
+			resp.Body.Close()
+			return fmt.Errorf("upgraded connection is not writable")
+		}
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+		if resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(wsKey) {
+			return fmt.Errorf("server sent a bad Sec-WebSocket-Accept")
+		}
+		api.setStreaming("websocket")
+		defer api.setStreaming("")
+		debugLog("Stream: WebSocket connected to %s", url)
+		return readWebSocket(conn, touch, forward)
+	case resp.StatusCode == http.StatusOK && strings.HasPrefix(contentType, "text/event-stream") && mode != "websocket":
+		defer resp.Body.Close()
+		api.setStreaming("sse")
+		defer api.setStreaming("")
+		debugLog("Stream: Server-sent events connected to %s", url)
+		return readSSE(resp.Body, touch, forward)
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("%w (status %d, %s)", errStreamUnsupported, resp.StatusCode, contentType)
+	}
+}
+
+// Streaming names the connected stream transport, empty while polling
+func (api *APIClient) Streaming() string {
+	api.mutex.RLock()
+	defer api.mutex.RUnlock()
+	return api.streaming
+}
+
+func (api *APIClient) setStreaming(transport string) {
+	api.mutex.Lock()
+	api.streaming = transport
+	api.mutex.Unlock()
+}
+
+// wsAccept is the Sec-WebSocket-Accept value expected for a handshake key
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// decodeStreamMessage accepts a single event ({"event": ..., "timestamp":
+// ...}) or a batch in the polling response format ({"events": [...]}).
+// Anything else, such as a heartbeat, yields no events.
+func decodeStreamMessage(data []byte) ([]APIEvent, error) {
+	var msg struct {
+		APIEvent
+		Events []APIEvent `json:"events"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	if msg.Events != nil {
+		return msg.Events, nil
+	}
+	if len(msg.Event) == 0 || string(msg.Event) == "null" {
+		return nil, nil
+	}
+	return []APIEvent{msg.APIEvent}, nil
+}
+
+// readSSE reads server-sent events, delivering the data of each message
+// event. Comments such as ": keepalive" only count as activity.
+func readSSE(r io.Reader, touch func(), deliver func([]APIEvent)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamMessage)
+	var data []string
+	eventType := ""
+	for scanner.Scan() {
+		touch()
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				data = append(data, value)
+			case "event":
+				eventType = value
+			}
+			continue
+		}
+
+		// A blank line dispatches the event
+		if len(data) > 0 && (eventType == "" || eventType == "message" || eventType == "events") {
+			if events, err := decodeStreamMessage([]byte(strings.Join(data, "\n"))); err != nil {
+				debugLog("Stream: Skipping bad message: %v", err)
+			} else if len(events) > 0 {
+				deliver(events)
+			}
+		}
+		data, eventType = nil, ""
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// readWebSocket reads messages from a client WebSocket connection, answering
+// pings and the server's close frame
+func readWebSocket(conn io.ReadWriteCloser, touch func(), deliver func([]APIEvent)) error {
+	r := bufio.NewReader(conn)
+	var message []byte
+	for {
+		fin, opcode, payload, err := readWSFrame(r)
+		if err != nil {
+			return err
+		}
+		touch()
+		switch opcode {
+		case 0x0, 0x1, 0x2: // Continuation, text, binary
+			if len(message)+len(payload) > maxStreamMessage {
+				return fmt.Errorf("message larger than %d bytes", maxStreamMessage)
+			}
+			message = append(message, payload...)
+			if !fin {
+				continue
+			}
+			if events, err := decodeStreamMessage(message); err != nil {
+				debugLog("Stream: Skipping bad message: %v", err)
+			} else if len(events) > 0 {
+				deliver(events)
+			}
+			message = nil
+		case 0x8: // Close: echo the status code back
+			writeWSFrame(conn, 0x8, payload[:min(len(payload), 2)])
+			return fmt.Errorf("closed by server")
+		case 0x9: // Ping
+			if err := writeWSFrame(conn, 0xA, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readWSFrame reads one frame, unmasking it if the server masked it
+func readWSFrame(r *bufio.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0f
+	size := uint64(header[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > maxStreamMessage {
+		err = fmt.Errorf("frame of %d bytes is too large", size)
+		return
+	}
+	var mask [4]byte
+	masked := header[1]&0x80 != 0
+	if masked {
+		if _, err = io.ReadFull(r, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// writeWSFrame writes a single masked frame, as clients must
+func writeWSFrame(w io.Writer, opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	crand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// WorkerFunc is a supervised background task. It should return nil once stop
+// is closed; returning an error or panicking gets it restarted with backoff.
+type WorkerFunc func(stop <-chan struct{}) error
+
+// WorkerStatus describes one supervised worker for the diagnostics panel
+type WorkerStatus struct {
+	Name      string    `json:"name"`
+	State     string    `json:"state"` // running, backoff, done, stopped
+	Restarts  int       `json:"restarts"`
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+type Supervisor struct {
+	workers   map[string]*WorkerStatus
+	order     []string
+	stop      chan struct{}
+	stopped   bool
+	onFailure func(name string, err error)
+	wg        sync.WaitGroup
+	mutex     sync.RWMutex
+}
+
+// OnFailure calls fn each time a worker fails, before it is restarted
+func (s *Supervisor) OnFailure(fn func(name string, err error)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onFailure = fn
+}
+
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		workers: make(map[string]*WorkerStatus),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Go starts a supervised worker. Starting a worker under a name that already
+// exists (periodic one-shot jobs) reuses its diagnostics entry.
+func (s *Supervisor) Go(name string, run WorkerFunc) {
+	s.mutex.Lock()
+	if s.stopped {
+		s.mutex.Unlock()
+		return
+	}
+	status, exists := s.workers[name]
+	if !exists {
+		status = &WorkerStatus{Name: name}
+		s.workers[name] = status
+		s.order = append(s.order, name)
+	}
+	s.wg.Add(1)
+	s.mutex.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		backoff := time.Second
+		for {
+			s.setState(status, "running")
+			started := time.Now()
+			err := s.runOnce(name, run)
+
+			select {
+			case <-s.stop:
+				s.setState(status, "stopped")
+				return
+			default:
+			}
+			if err == nil {
+				s.setState(status, "done")
+				return
+			}
+
+			// A worker that ran for a while before failing starts over with a short backoff
+			if time.Since(started) > time.Minute {
+				backoff = time.Second
+			}
+			debugLog("Supervisor: %s failed: %v (restart in %v)", name, err, backoff)
+			s.mutex.Lock()
+			status.Restarts++
+			status.LastError = err.Error()
+			onFailure := s.onFailure
+			s.mutex.Unlock()
+			if onFailure != nil {
+				onFailure(name, err)
+			}
+			s.setState(status, "backoff")
+
+			select {
+			case <-s.stop:
+				s.setState(status, "stopped")
+				return
+			case <-time.After(backoff):
+			}
+			backoff = time.Duration(math.Min(float64(backoff*2), float64(60*time.Second)))
+		}
+	}()
+}
+
+// runOnce calls the worker, converting a panic into an error
+func (s *Supervisor) runOnce(name string, run WorkerFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			debugLog("Supervisor: %s panicked: %v\n%s", name, r, debug.Stack())
+		}
+	}()
+	return run(s.stop)
+}
+
+func (s *Supervisor) setState(status *WorkerStatus, state string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status.State = state
+	status.Since = time.Now()
+}
+
+// Stop signals every worker to exit and waits up to timeout for them
+func (s *Supervisor) Stop(timeout time.Duration) {
+	s.mutex.Lock()
+	if s.stopped {
+		s.mutex.Unlock()
+		return
+	}
+	s.stopped = true
+	close(s.stop)
+	s.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		debugLog("Supervisor: Workers still running after %v", timeout)
+	}
+}
+
+// Status returns every worker in the order it was first started
+func (s *Supervisor) Status() []WorkerStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	statuses := make([]WorkerStatus, 0, len(s.order))
+	for _, name := range s.order {
+		statuses = append(statuses, *s.workers[name])
+	}
+	return statuses
+}
+
+// ShortDuration formats an age compactly, e.g. 45s, 12m, 5h, 3d
+func ShortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+var Workers = NewSupervisor()
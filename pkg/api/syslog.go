@@ -0,0 +1,273 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	syslogFacilityLocal0 = 16
+	syslogSeverityNotice = 5
+	syslogAppName        = "seckc-globe"
+	syslogEnterpriseID   = "32473" // Documentation enterprise number (RFC 5612)
+)
+
+var SyslogFormats = []string{"rfc5424", "cef"}
+
+// SyslogForwarder sends every live event to a syslog collector as an
+// RFC 5424 message, or as a CEF record in an RFC 5424 envelope, so a SIEM
+// ingests the same stream the globe shows. UDP sends one datagram per
+// event; TCP and TLS use octet counted framing (RFC 6587/5425).
+type SyslogForwarder struct {
+	network   string // udp, tcp or tls
+	addr      string
+	format    string
+	hostname  string
+	tlsConfig *tls.Config
+	queue     chan []byte
+	conn      net.Conn
+	connected bool
+	dropped   int
+	mutex     sync.RWMutex
+}
+
+// ParseSyslogTarget splits a collector URL such as udp://siem:514,
+// tcp://siem or tls://siem:6514 into a network and address, filling in
+// the standard port for the transport
+func ParseSyslogTarget(target string) (network, addr string, err error) {
+	network, host, ok := strings.Cut(target, "://")
+	if !ok || host == "" {
+		return "", "", fmt.Errorf("collector %q must look like udp://host:514, tcp://host:601 or tls://host:6514", target)
+	}
+	ports := map[string]string{"udp": "514", "tcp": "601", "tls": "6514"}
+	port, ok := ports[network]
+	if !ok {
+		return "", "", fmt.Errorf("unknown transport %q (use udp, tcp or tls)", network)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+	return network, host, nil
+}
+
+func NewSyslogForwarder(target, format, caFile string) (*SyslogForwarder, error) {
+	network, addr, err := ParseSyslogTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	sf := &SyslogForwarder{
+		network:  network,
+		addr:     addr,
+		format:   format,
+		hostname: hostname,
+		queue:    make(chan []byte, 1000),
+	}
+	if network == "tls" {
+		host, _, _ := net.SplitHostPort(addr)
+		sf.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("%s: no PEM certificates found", caFile)
+			}
+			sf.tlsConfig.RootCAs = pool
+		}
+	}
+	return sf, nil
+}
+
+// Start runs the send loop, reconnecting with backoff when the collector drops
+func (sf *SyslogForwarder) Start() {
+	Workers.Go("syslog", func(stop <-chan struct{}) error {
+		defer sf.disconnect()
+		backoff := time.Second
+		for {
+			var msg []byte
+			select {
+			case <-stop:
+				return nil
+			case msg = <-sf.queue:
+			}
+			if sf.network != "udp" {
+				msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+			}
+
+			for {
+				if sf.conn == nil {
+					if err := sf.connect(); err != nil {
+						debugLog("Syslog: Connect to %s failed: %v (retry in %v)", sf.addr, err, backoff)
+						select {
+						case <-stop:
+							return nil
+						case <-time.After(backoff):
+						}
+						backoff = time.Duration(math.Min(float64(backoff*2), float64(60*time.Second)))
+						continue
+					}
+					backoff = time.Second
+				}
+
+				sf.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if _, err := sf.conn.Write(msg); err != nil {
+					debugLog("Syslog: Send failed: %v", err)
+					sf.disconnect()
+					continue
+				}
+				break
+			}
+		}
+	})
+}
+
+// Forward queues an event, dropping it if the collector is backed up
+func (sf *SyslogForwarder) Forward(event EnrichedEvent, t time.Time) {
+	var msg []byte
+	if sf.format == "cef" {
+		msg = sf.envelope(t, "-", FormatCEF(event))
+	} else {
+		msg = sf.envelope(t, syslogStructuredData(event), fmt.Sprintf("%s connection from %s", event.Protocol, event.SrcIP))
+	}
+
+	select {
+	case sf.queue <- msg:
+	default:
+		sf.mutex.Lock()
+		sf.dropped++
+		sf.mutex.Unlock()
+	}
+}
+
+func (sf *SyslogForwarder) IsConnected() bool {
+	sf.mutex.RLock()
+	defer sf.mutex.RUnlock()
+	return sf.connected
+}
+
+func (sf *SyslogForwarder) connect() error {
+	var conn net.Conn
+	var err error
+	if sf.network == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", sf.addr, sf.tlsConfig)
+	} else {
+		conn, err = net.DialTimeout(sf.network, sf.addr, 10*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+	sf.conn = conn
+	sf.mutex.Lock()
+	sf.connected = true
+	sf.mutex.Unlock()
+	debugLog("Syslog: Connected to %s://%s", sf.network, sf.addr)
+	return nil
+}
+
+func (sf *SyslogForwarder) disconnect() {
+	if sf.conn != nil {
+		sf.conn.Close()
+		sf.conn = nil
+	}
+	sf.mutex.Lock()
+	sf.connected = false
+	sf.mutex.Unlock()
+}
+
+// envelope wraps a message in an RFC 5424 header
+func (sf *SyslogForwarder) envelope(t time.Time, structured, msg string) []byte {
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d connection %s %s",
+		syslogFacilityLocal0*8+syslogSeverityNotice, t.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		sf.hostname, syslogAppName, os.Getpid(), structured, msg))
+}
+
+// syslogStructuredData carries the event's fields as an RFC 5424
+// structured data element
+func syslogStructuredData(event EnrichedEvent) string {
+	var sb strings.Builder
+	sb.WriteString("[honeypot@" + syslogEnterpriseID)
+	param := func(name, value string) {
+		if value == "" {
+			return
+		}
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+		fmt.Fprintf(&sb, ` %s="%s"`, name, value)
+	}
+	param("src", event.SrcIP)
+	param("proto", event.Protocol)
+	param("user", event.Username)
+	param("pass", event.Password)
+	param("city", event.City)
+	param("country", event.Country)
+	if event.Latitude != 0 || event.Longitude != 0 {
+		param("lat", strconv.FormatFloat(event.Latitude, 'f', 4, 64))
+		param("lon", strconv.FormatFloat(event.Longitude, 'f', 4, 64))
+	}
+	param("asn", event.ASN)
+	param("org", event.Org)
+	param("rdns", event.RDNS)
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// FormatCEF renders an event as an ArcSight Common Event Format record
+func FormatCEF(event EnrichedEvent) string {
+	header := strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	value := strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CEF:0|SecKC|MHN-Globe|%s|%s|Honeypot connection|5|",
+		header.Replace(BuildVersion()), header.Replace(strings.ToLower(event.Protocol)))
+	first := true
+	field := func(key, v string) {
+		if v == "" {
+			return
+		}
+		if !first {
+			sb.WriteByte(' ')
+		}
+		first = false
+		sb.WriteString(key + "=" + value.Replace(v))
+	}
+	if t, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+		field("rt", strconv.FormatInt(t.UnixMilli(), 10))
+	}
+	field("src", event.SrcIP)
+	field("app", event.Protocol)
+	field("suser", event.Username)
+	if event.Password != "" {
+		field("cs1Label", "Password")
+		field("cs1", event.Password)
+	}
+	field("shost", event.RDNS)
+	if event.Latitude != 0 || event.Longitude != 0 {
+		field("slat", strconv.FormatFloat(event.Latitude, 'f', 4, 64))
+		field("slong", strconv.FormatFloat(event.Longitude, 'f', 4, 64))
+	}
+	if event.Country != "" {
+		field("cs2Label", "Country")
+		field("cs2", event.Country)
+	}
+	if event.City != "" {
+		field("cs3Label", "City")
+		field("cs3", event.City)
+	}
+	if event.ASN != "" || event.Org != "" {
+		field("cs4Label", "ASN")
+		field("cs4", strings.TrimSpace(event.ASN+" "+event.Org))
+	}
+	return sb.String()
+}
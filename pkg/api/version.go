@@ -0,0 +1,43 @@
+package api
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// version and commit are stamped into release builds:
+//
+//	go build -ldflags "-X SecKC-MHN-Globe/pkg/api.version=v1.2.0 -X SecKC-MHN-Globe/pkg/api.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// BuildVersion describes the running binary. Without ldflags it falls back
+// to the module version from go install and the VCS revision go build
+// records in a git checkout.
+var BuildVersion = sync.OnceValue(func() string {
+	ver, rev, dirty := version, commit, false
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if ver == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			ver = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if rev == "" {
+					rev = setting.Value[:min(len(setting.Value), 7)]
+				}
+			case "vcs.modified":
+				dirty = setting.Value == "true" && commit == ""
+			}
+		}
+	}
+	if rev == "" {
+		return ver
+	}
+	if dirty {
+		rev += "-dirty"
+	}
+	return ver + " (" + rev + ")"
+})
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessPolicy restricts who may use an embedded server. Empty fields disable
+// the corresponding check; when both basic auth and a token are set either is accepted.
+type AccessPolicy struct {
+	Username string
+	Password string
+	Token    string
+	Allow    []*net.IPNet
+}
+
+// ParseAllowList parses a comma separated list of IPs and CIDRs
+func ParseAllowList(list string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", entry)
+			}
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", entry)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func (ap *AccessPolicy) allowed(remoteAddr string) bool {
+	if len(ap.Allow) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range ap.Allow {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ap *AccessPolicy) authenticated(r *http.Request) bool {
+	if ap.Username == "" && ap.Token == "" {
+		return true
+	}
+	if ap.Token != "" {
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(ap.Token)) == 1 {
+				return true
+			}
+		}
+	}
+	if ap.Username != "" {
+		if user, pass, ok := r.BasicAuth(); ok {
+			userOK := subtle.ConstantTimeCompare([]byte(user), []byte(ap.Username)) == 1
+			passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(ap.Password)) == 1
+			if userOK && passOK {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Wrap enforces the allowlist and credentials before calling next
+func (ap *AccessPolicy) Wrap(name string, next http.Handler) http.Handler {
+	if ap == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ap.allowed(r.RemoteAddr) {
+			debugLog("%s: Rejected %s (not in allowlist)", name, r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if !ap.authenticated(r) {
+			debugLog("%s: Rejected %s (bad credentials)", name, r.RemoteAddr)
+			if ap.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="SecKC-MHN-Globe"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type WebServer struct {
+	addr     string
+	mux      *http.ServeMux
+	server   *http.Server
+	certFile string
+	keyFile  string
+}
+
+// EnableTLS serves over HTTPS using the given certificate and key files, or a
+// generated self-signed certificate when both are empty
+func (ws *WebServer) EnableTLS(certFile, keyFile string) error {
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("both a certificate and a key are required")
+		}
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return err
+		}
+		ws.certFile = certFile
+		ws.keyFile = keyFile
+		ws.server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		return nil
+	}
+
+	cert, err := generateSelfSignedCert()
+	if err != nil {
+		return err
+	}
+	ws.server.TLSConfig = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	return nil
+}
+
+// generateSelfSignedCert creates an in-memory ECDSA certificate for localhost
+// and this machine's hostname, valid for one year
+func generateSelfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := crand.Int(crand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	dnsNames := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		dnsNames = append(dnsNames, hostname)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"SecKC-MHN-Globe"}, CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+
+	der, err := x509.CreateCertificate(crand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+func NewWebServer(addr string, policy *AccessPolicy) *WebServer {
+	ws := &WebServer{
+		addr: addr,
+		mux:  http.NewServeMux(),
+	}
+	ws.server = &http.Server{
+		Addr:              addr,
+		Handler:           policy.Wrap("Web server", ws.mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ws.HandleFunc("GET /api/diagnostics", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, Workers.Status())
+	})
+	ws.HandleFunc("GET /api/version", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, map[string]string{"version": BuildVersion()})
+	})
+	return ws
+}
+
+// HandleFunc serves pattern with handler, behind the server's access policy
+func (ws *WebServer) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	ws.mux.HandleFunc(pattern, handler)
+}
+
+func (ws *WebServer) Start() {
+	Workers.Go("web-server", func(stop <-chan struct{}) error {
+		// ListenAndServe only returns once the server is shut down, so the
+		// worker would otherwise hold up Stop for its full timeout
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-stop:
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				ws.server.Shutdown(ctx)
+			case <-done:
+			}
+		}()
+
+		var err error
+		if ws.server.TLSConfig != nil {
+			debugLog("Web server: Listening on %s (TLS)", ws.addr)
+			err = ws.server.ListenAndServeTLS(ws.certFile, ws.keyFile)
+		} else {
+			debugLog("Web server: Listening on %s", ws.addr)
+			err = ws.server.ListenAndServe()
+		}
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	})
+}
+
+func (ws *WebServer) Close() {
+	ws.server.Close()
+}
+
+// QueryLimit reads the optional ?limit= parameter (1-100)
+func QueryLimit(r *http.Request, def int) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 || limit > 100 {
+		return def
+	}
+	return limit
+}
+
+func WriteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		debugLog("Web server: Encode failed: %v", err)
+	}
+}
@@ -0,0 +1,343 @@
+// Package config holds the TOML configuration of the enhanced globe and the
+// machinery that layers defaults, the config file, environment variables and
+// command line flags onto one flag set.
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// Config mirrors the config file, one struct per TOML table
+type Config struct {
+	API struct {
+		BaseURL      string `toml:"base_url"`
+		PollInterval string `toml:"poll_interval"`
+		MaxEvents    int    `toml:"max_events"`
+		Backfill     string `toml:"backfill"`
+		Stream       string `toml:"stream"`
+		Key          string `toml:"key"`
+		KeyHeader    string `toml:"key_header"`
+	} `toml:"api"`
+
+	HTTP struct {
+		Proxy         string `toml:"proxy"`
+		CACert        string `toml:"ca_cert"`
+		TLSSkipVerify bool   `toml:"tls_skip_verify"`
+	} `toml:"http"`
+
+	Display struct {
+		Theme           string     `toml:"theme"`
+		Charset         string     `toml:"charset"`
+		Projection      string     `toml:"projection"`
+		ColorMode       string     `toml:"color_mode"`
+		Unicode         string     `toml:"unicode"`
+		RotationPeriod  int        `toml:"rotation_period"`
+		RefreshRate     int        `toml:"refresh_rate"`
+		AspectRatio     float64    `toml:"aspect_ratio"`
+		Monochrome      bool       `toml:"monochrome"`
+		ProtocolGlyphs  bool       `toml:"protocol_glyphs"`
+		ActiveFPS       int        `toml:"active_fps"`
+		IdleFPS         int        `toml:"idle_fps"`
+		IdleAfter       int        `toml:"idle_after"`
+		SubCell         bool       `toml:"subcell"`
+		Jitter          float64    `toml:"jitter"`
+		Quality         string     `toml:"quality"`
+		Mouse           bool       `toml:"mouse"`
+		Layers          string     `toml:"layers"`
+		DashboardWrap   bool       `toml:"dashboard_wrap"`
+		Collapse        string     `toml:"collapse"`
+		Columns         ColumnSpec `toml:"columns"`
+		RepeatThreshold int        `toml:"repeat_threshold"`
+		Timeline        bool       `toml:"timeline"`
+		Ticker          string     `toml:"ticker"`
+		TimeFormat      string     `toml:"time_format"`
+		Legend          bool       `toml:"legend"`
+		Honeypots       string     `toml:"honeypots"`
+		Kiosk           bool       `toml:"kiosk"`
+		KioskInterval   int        `toml:"kiosk_interval"`
+	} `toml:"display"`
+
+	Effects struct {
+		ArcStyle    string `toml:"arc_style"`
+		TrailMS     int    `toml:"trail_ms"`
+		Footprints  string `toml:"footprints"`
+		CRTEnabled  bool   `toml:"crt_enabled"`
+		GlowLevel   int    `toml:"glow_level"`
+		CRTCurve    bool   `toml:"crt_curve"`
+		RainEnabled bool   `toml:"rain_enabled"`
+		RainDensity int    `toml:"rain_density"`
+		RainMask    bool   `toml:"rain_mask"`
+	} `toml:"effects"`
+
+	Lighting struct {
+		Enabled bool    `toml:"enabled"`
+		Lon     float64 `toml:"lon"`
+		Lat     float64 `toml:"lat"`
+		Follow  bool    `toml:"follow"`
+	} `toml:"lighting"`
+
+	Demo struct {
+		Enabled  bool   `toml:"enabled"`
+		Rate     int    `toml:"rate"`
+		Scenario string `toml:"scenario"`
+		Replay   string `toml:"replay"`
+		Offline  bool   `toml:"offline"`
+	} `toml:"demo"`
+
+	Recording struct {
+		File         string `toml:"file"`
+		Format       string `toml:"format"`
+		GIFFile      string `toml:"gif_file"`
+		GIFDuration  string `toml:"gif_duration"`
+		GIFFrameSkip int    `toml:"gif_frame_skip"`
+	} `toml:"recording"`
+
+	Web struct {
+		Addr          string `toml:"addr"`
+		User          string `toml:"user"`
+		Pass          string `toml:"pass"`
+		Token         string `toml:"token"`
+		Allow         string `toml:"allow"`
+		TLSCert       string `toml:"tls_cert"`
+		TLSKey        string `toml:"tls_key"`
+		TLSSelfSigned bool   `toml:"tls_self_signed"`
+	} `toml:"web"`
+
+	GeoIP struct {
+		ASNDB       string `toml:"asn_db"`
+		ASNFallback bool   `toml:"asn_fallback"`
+		Lang        string `toml:"lang"`
+	} `toml:"geoip"`
+
+	Alerts struct {
+		Rules        string `toml:"rules"`
+		Banner       bool   `toml:"banner"`
+		StormRate    int    `toml:"storm_rate"`
+		StormClear   int    `toml:"storm_clear"`
+		StormHold    string `toml:"storm_hold"`
+		StormHeatmap bool   `toml:"storm_heatmap"`
+	} `toml:"alerts"`
+
+	Findings struct {
+		Window      string `toml:"window"`
+		Usernames   int    `toml:"usernames"`
+		NewUserRate int    `toml:"new_user_rate"`
+		Hostnames   string `toml:"hostnames"`
+	} `toml:"findings"`
+
+	Malware struct {
+		VirusTotalKey string `toml:"virustotal_key"`
+		BazaarKey     string `toml:"bazaar_key"`
+		LookupRate    int    `toml:"lookup_rate"`
+	} `toml:"malware"`
+
+	Coverage struct {
+		Sensors string `toml:"sensors"`
+		Window  string `toml:"window"`
+	} `toml:"coverage"`
+
+	Triage struct {
+		TagsFile string `toml:"tags_file"`
+	} `toml:"triage"`
+
+	Roles struct {
+		Spectator bool   `toml:"spectator"`
+		Pass      string `toml:"pass"`
+	} `toml:"roles"`
+
+	DNS struct {
+		Server      string `toml:"server"`
+		Timeout     string `toml:"timeout"`
+		Workers     int    `toml:"workers"`
+		NegativeTTL string `toml:"negative_ttl"`
+	} `toml:"dns"`
+
+	HPFeeds struct {
+		Host    string `toml:"host"`
+		Port    int    `toml:"port"`
+		Ident   string `toml:"ident"`
+		Secret  string `toml:"secret"`
+		Channel string `toml:"channel"`
+	} `toml:"hpfeeds"`
+
+	Syslog struct {
+		Forward string `toml:"forward"`
+		Format  string `toml:"format"`
+		CA      string `toml:"ca"`
+	} `toml:"syslog"`
+
+	Elasticsearch struct {
+		URL    string `toml:"url"`
+		Index  string `toml:"index"`
+		User   string `toml:"user"`
+		Pass   string `toml:"pass"`
+		APIKey string `toml:"api_key"`
+		Batch  int    `toml:"batch"`
+		Flush  string `toml:"flush"`
+	} `toml:"elasticsearch"`
+
+	Kafka struct {
+		Brokers string `toml:"brokers"`
+		Topic   string `toml:"topic"`
+		User    string `toml:"user"`
+		Pass    string `toml:"pass"`
+		TLS     bool   `toml:"tls"`
+	} `toml:"kafka"`
+
+	NATS struct {
+		URL     string `toml:"url"`
+		Subject string `toml:"subject"`
+		User    string `toml:"user"`
+		Pass    string `toml:"pass"`
+		Token   string `toml:"token"`
+	} `toml:"nats"`
+
+	MQTT struct {
+		Broker    string `toml:"broker"`
+		User      string `toml:"user"`
+		Pass      string `toml:"pass"`
+		CA        string `toml:"ca"`
+		ClientID  string `toml:"client_id"`
+		QoS       int    `toml:"qos"`
+		Subscribe string `toml:"subscribe"`
+		Publish   string `toml:"publish"`
+	} `toml:"mqtt"`
+
+	Zeek struct {
+		Log    string `toml:"log"`
+		Follow bool   `toml:"follow"`
+	} `toml:"zeek"`
+
+	Cowrie struct {
+		Log string `toml:"log"`
+	} `toml:"cowrie"`
+
+	Intel struct {
+		Interval     string `toml:"interval"`
+		Window       string `toml:"window"`
+		MinSightings int    `toml:"min_sightings"`
+		File         string `toml:"file"`
+	} `toml:"intel"`
+
+	TAXII struct {
+		URL  string `toml:"url"`
+		User string `toml:"user"`
+		Pass string `toml:"pass"`
+	} `toml:"taxii"`
+
+	MISP struct {
+		URL string `toml:"url"`
+		Key string `toml:"key"`
+	} `toml:"misp"`
+
+	Limits struct {
+		MaxArcs         int `toml:"max_arcs"`
+		GeoCacheSize    int `toml:"geo_cache_size"`
+		MaxCredAttempts int `toml:"max_cred_attempts"`
+		MemLimitMB      int `toml:"mem_limit_mb"`
+		HistorySize     int `toml:"history_size"`
+	} `toml:"limits"`
+
+	Presets struct {
+		P1 string `toml:"1"`
+		P2 string `toml:"2"`
+		P3 string `toml:"3"`
+		P4 string `toml:"4"`
+		P5 string `toml:"5"`
+		P6 string `toml:"6"`
+		P7 string `toml:"7"`
+		P8 string `toml:"8"`
+		P9 string `toml:"9"`
+	} `toml:"presets"`
+
+	Keys struct {
+		Pause       string `toml:"pause"`
+		Freeze      string `toml:"freeze"`
+		SpeedDown   string `toml:"speed_down"`
+		SpeedUp     string `toml:"speed_up"`
+		ZoomIn      string `toml:"zoom_in"`
+		ZoomOut     string `toml:"zoom_out"`
+		NudgeUp     string `toml:"nudge_up"`
+		NudgeDown   string `toml:"nudge_down"`
+		NudgeLeft   string `toml:"nudge_left"`
+		NudgeRight  string `toml:"nudge_right"`
+		Theme       string `toml:"theme"`
+		Arcs        string `toml:"arcs"`
+		Lighting    string `toml:"lighting"`
+		Rain        string `toml:"rain"`
+		Info        string `toml:"info"`
+		Stats       string `toml:"stats"`
+		TopIps      string `toml:"top_ips"`
+		Ports       string `toml:"ports"`
+		Creds       string `toml:"creds"`
+		Diagnostics string `toml:"diagnostics"`
+		Legend      string `toml:"legend"`
+		Alerts      string `toml:"alerts"`
+		Findings    string `toml:"findings"`
+		Hashes      string `toml:"hashes"`
+		Urls        string `toml:"urls"`
+		Ticker      string `toml:"ticker"`
+		Coverage    string `toml:"coverage"`
+		Triage      string `toml:"triage"`
+		TagFilter   string `toml:"tag_filter"`
+		Session     string `toml:"session"`
+		Scrub       string `toml:"scrub"`
+		Live        string `toml:"live"`
+		StatsView   string `toml:"stats_view"`
+		Countries   string `toml:"countries"`
+		Follow      string `toml:"follow"`
+		ResetView   string `toml:"reset_view"`
+		Acknowledge string `toml:"acknowledge"`
+		ScrollLeft  string `toml:"scroll_left"`
+		ScrollRight string `toml:"scroll_right"`
+		ScrollHome  string `toml:"scroll_home"`
+		RotateLeft  string `toml:"rotate_left"`
+		RotateRight string `toml:"rotate_right"`
+		PageUp      string `toml:"page_up"`
+		PageDown    string `toml:"page_down"`
+		Search      string `toml:"search"`
+		SearchPrev  string `toml:"search_prev"`
+		SearchNext  string `toml:"search_next"`
+		Wrap        string `toml:"wrap"`
+		Collapse    string `toml:"collapse"`
+		Columns     string `toml:"columns"`
+		TimeFormat  string `toml:"time_format"`
+		Screenshot  string `toml:"screenshot"`
+		Report      string `toml:"report"`
+		Settings    string `toml:"settings"`
+		Palette     string `toml:"palette"`
+		Commands    string `toml:"commands"`
+		Help        string `toml:"help"`
+		Quit        string `toml:"quit"`
+	} `toml:"keys"`
+
+	Debug struct {
+		LogFile   string `toml:"log_file"`
+		PprofAddr string `toml:"pprof_addr"`
+	} `toml:"debug"`
+}
+
+// ColumnSpec lets the config file list columns as a TOML array or give a
+// single string
+type ColumnSpec string
+
+func (cs *ColumnSpec) UnmarshalTOML(v interface{}) error {
+	*cs = ColumnSpec(valueString(v))
+	return nil
+}
+
+// Load reads the config file at path; an empty path gives the zero Config
+func Load(path string) (*Config, error) {
+	var config Config
+
+	if path == "" {
+		return &config, nil
+	}
+
+	_, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Option describes one TOML setting and the command line flag it backs.
+// Defaults come from the flag definition so the two can never drift apart.
+type Option struct {
+	Section     string
+	Key         string
+	Flag        string
+	Range       string
+	Description string
+}
+
+// Name returns the dotted "section.key" name of the option
+func (opt Option) Name() string {
+	return opt.Section + "." + opt.Key
+}
+
+// EnvVar returns the environment variable that overrides the option
+func (opt Option) EnvVar() string {
+	return "SECKC_GLOBE_" + strings.ToUpper(opt.Section) + "_" + strings.ToUpper(opt.Key)
+}
+
+// Default returns the option's default in fs as a typed value matching its
+// Config field
+func (opt Option) Default(fs *flag.FlagSet) (interface{}, error) {
+	f := fs.Lookup(opt.Flag)
+	if f == nil {
+		return nil, fmt.Errorf("config option %s refers to unknown flag -%s", opt.Name(), opt.Flag)
+	}
+	kind, ok := fieldKinds()[opt.Name()]
+	if !ok {
+		return nil, fmt.Errorf("config option %s has no Config field", opt.Name())
+	}
+	switch kind {
+	case reflect.Bool:
+		return strconv.ParseBool(f.DefValue)
+	case reflect.Int:
+		return strconv.Atoi(f.DefValue)
+	case reflect.Float64:
+		return strconv.ParseFloat(f.DefValue, 64)
+	default:
+		return f.DefValue, nil
+	}
+}
+
+// formatTOMLValue renders a registry default as a TOML literal
+func formatTOMLValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		s := strconv.FormatFloat(val, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s
+	case []string:
+		quoted := make([]string, len(val))
+		for i, item := range val {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// fieldKinds maps "section.key" to the kind of every toml-tagged field in Config
+func fieldKinds() map[string]reflect.Kind {
+	kinds := make(map[string]reflect.Kind)
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		section := configType.Field(i)
+		sectionName := section.Tag.Get("toml")
+		for j := 0; j < section.Type.NumField(); j++ {
+			field := section.Type.Field(j)
+			kinds[sectionName+"."+field.Tag.Get("toml")] = field.Type.Kind()
+		}
+	}
+	return kinds
+}
+
+// Generate writes a fully commented example config built from the option
+// registry and the defaults in fs, then verifies it round-trips through the
+// Config struct. options must list every field of Config.
+func Generate(w io.Writer, options []Option, fs *flag.FlagSet) error {
+	registered := make(map[string]bool)
+	for _, opt := range options {
+		registered[opt.Name()] = true
+	}
+	for key := range fieldKinds() {
+		if !registered[key] {
+			return fmt.Errorf("config option %s is missing from the option registry", key)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# SecKC-MHN-Globe Enhanced configuration\n")
+	sb.WriteString("# Generated by --generate-config. Every supported option is listed with its\n")
+	sb.WriteString("# default value. Precedence: defaults < this file < environment < flags.\n")
+
+	section := ""
+	for _, opt := range options {
+		if opt.Section != section {
+			section = opt.Section
+			fmt.Fprintf(&sb, "\n[%s]\n", section)
+		}
+		def, err := opt.Default(fs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "\n# %s\n# Valid: %s  Flag: -%s  Env: %s\n%s = %s\n",
+			opt.Description, opt.Range, opt.Flag, opt.EnvVar(), opt.Key, formatTOMLValue(def))
+	}
+
+	var check Config
+	meta, err := toml.Decode(sb.String(), &check)
+	if err != nil {
+		return fmt.Errorf("generated config does not parse: %v", err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("generated config has unknown keys: %v", undecoded)
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// Sources records where each flag's final value came from
+type Sources struct {
+	options []Option
+	from    map[string]string
+}
+
+// From returns where the flag's value came from, e.g. "env SECKC_GLOBE_API_KEY"
+func (s *Sources) From(flagName string) string {
+	return s.from[flagName]
+}
+
+// Describe names an option for error messages, e.g.
+// "display.refresh_rate (config key display.refresh_rate)"
+func (s *Sources) Describe(flagName string) string {
+	name := "-" + flagName
+	for _, opt := range s.options {
+		if opt.Flag == flagName {
+			name = opt.Name()
+			break
+		}
+	}
+	source := s.from[flagName]
+	if source == "" {
+		source = "flag -" + flagName
+	}
+	return fmt.Sprintf("%s (%s)", name, source)
+}
+
+// valueString converts a decoded TOML value into flag syntax. Arrays are
+// joined with commas so lists such as web.allow can be written either way.
+func valueString(v interface{}) string {
+	switch val := v.(type) {
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = valueString(item)
+		}
+		return strings.Join(parts, ",")
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// Merge layers defaults < config file < environment < flags onto the parsed
+// flag set, so the rest of main only has to read flag values. Must be called
+// after fs.Parse.
+func Merge(fs *flag.FlagSet, options []Option, configPath string) (*Sources, error) {
+	sources := &Sources{options: options, from: make(map[string]string)}
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	var fileValues map[string]interface{}
+	if configPath != "" {
+		if _, err := toml.DecodeFile(configPath, &fileValues); err != nil {
+			return nil, fmt.Errorf("config %s: %v", configPath, err)
+		}
+		known := make(map[string]bool)
+		for _, opt := range options {
+			known[opt.Name()] = true
+		}
+		for section, value := range fileValues {
+			table, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("config key %s: expected a [%s] table", section, section)
+			}
+			for key := range table {
+				if !known[section+"."+key] {
+					return nil, fmt.Errorf("config key %s.%s: unknown option", section, key)
+				}
+			}
+		}
+	}
+
+	for _, opt := range options {
+		if fs.Lookup(opt.Flag) == nil {
+			return nil, fmt.Errorf("config option %s refers to unknown flag -%s", opt.Name(), opt.Flag)
+		}
+		if explicit[opt.Flag] {
+			sources.from[opt.Flag] = "flag -" + opt.Flag
+			continue
+		}
+		if value, ok := os.LookupEnv(opt.EnvVar()); ok {
+			if err := fs.Set(opt.Flag, value); err != nil {
+				return nil, fmt.Errorf("env %s: invalid value %q for %s", opt.EnvVar(), value, opt.Name())
+			}
+			sources.from[opt.Flag] = "env " + opt.EnvVar()
+			continue
+		}
+		if table, ok := fileValues[opt.Section].(map[string]interface{}); ok {
+			if raw, ok := table[opt.Key]; ok {
+				value := valueString(raw)
+				if err := fs.Set(opt.Flag, value); err != nil {
+					return nil, fmt.Errorf("config key %s: invalid value %q", opt.Name(), value)
+				}
+				sources.from[opt.Flag] = "config key " + opt.Name()
+				continue
+			}
+		}
+		sources.from[opt.Flag] = "default"
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,172 @@
+// Package effects animates the decorative overlays drawn over the globe.
+// It keeps their state only; the terminal front end decides how to draw it.
+package effects
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// RainColumn is one falling trail. Y is the head's row and moves by Speed
+// rows per update, so slow columns creep instead of stalling; Glyphs holds
+// a glyph index for every row the trail can pass over
+type RainColumn struct {
+	X         int
+	Y         float64
+	Speed     float64
+	Length    int
+	Intensity float64
+	Glyphs    []int
+}
+
+// Rain is the Matrix digital rain: columns of glyphs falling down the screen
+// at their own speeds
+type Rain struct {
+	columns  []RainColumn
+	width    int
+	height   int
+	enabled  bool
+	density  int
+	masked   bool // Only fall over ocean and background cells
+	maxSpeed float64
+	mutex    sync.RWMutex
+}
+
+// RainGlyphs are the characters trails are made of: half-width katakana
+// and digits as in the film, or plain ASCII when the terminal lacks Unicode.
+// A column's glyph indexes are taken modulo the length of either table.
+var (
+	RainGlyphs      = []rune("ｱｲｳｴｵｶｷｸｹｺｻｼｽｾｿﾀﾁﾂﾃﾄﾅﾆﾇﾈﾉﾊﾋﾌﾍﾎﾏﾐﾑﾒﾓﾔﾕﾖﾗﾘﾙﾚﾛﾜ0123456789")
+	RainASCIIGlyphs = []rune("0123456789ABCDEFZ:.=*+-<>|")
+)
+
+const rainChurn = 0.05 // Chance per update that each trail character changes
+
+// NewRain returns disabled, masked rain for a width x height screen with
+// density (0-10) columns for every 10 columns of the screen
+func NewRain(width, height, density int) *Rain {
+	mr := &Rain{
+		enabled:  false,
+		masked:   true,
+		maxSpeed: 1.5,
+	}
+	mr.reset(width, height, density)
+	return mr
+}
+
+// Resize starts the rain over on a width x height screen
+func (mr *Rain) Resize(width, height int) {
+	mr.mutex.Lock()
+	mr.reset(width, height, mr.density)
+	mr.mutex.Unlock()
+}
+
+// SetDensity starts the rain over with density columns for every 10 columns
+// of the screen
+func (mr *Rain) SetDensity(density int) {
+	mr.mutex.Lock()
+	mr.reset(mr.width, mr.height, density)
+	mr.mutex.Unlock()
+}
+
+// reset must be called with mr.mutex held, except from NewRain
+func (mr *Rain) reset(width, height, density int) {
+	mr.width, mr.height, mr.density = width, height, density
+	mr.columns = make([]RainColumn, 0)
+
+	// Initialize rain columns based on density, spread over the screen so
+	// the first frames are not empty
+	numColumns := (width * density) / 10
+	for i := 0; i < numColumns; i++ {
+		col := RainColumn{Glyphs: make([]int, max(height, 1))}
+		for y := range col.Glyphs {
+			col.Glyphs[y] = rainGlyph()
+		}
+		mr.respawn(&col)
+		col.Y = float64(rand.Intn(max(height, 1)*2) - height/2)
+		mr.columns = append(mr.columns, col)
+	}
+}
+
+// rainGlyph picks a random index into the glyph tables
+func rainGlyph() int {
+	return rand.Intn(len(RainGlyphs))
+}
+
+// respawn sends a column back above the top edge with a fresh position,
+// speed and length
+func (mr *Rain) respawn(col *RainColumn) {
+	col.X = rand.Intn(max(mr.width, 1))
+	col.Length = 5 + rand.Intn(15)
+	col.Y = -float64(rand.Intn(max(mr.height/2, 1)))
+	col.Speed = 0.3 + rand.Float64()*mr.maxSpeed
+	col.Intensity = 0.3 + rand.Float64()*0.7
+}
+
+// Update moves every column down by its speed and flickers its glyphs
+func (mr *Rain) Update() {
+	mr.mutex.Lock()
+	defer mr.mutex.Unlock()
+
+	for i := range mr.columns {
+		col := &mr.columns[i]
+		col.Y += col.Speed
+		if int(col.Y)-col.Length >= mr.height {
+			mr.respawn(col)
+		}
+
+		// Characters flicker as the trail passes
+		for y := range col.Glyphs {
+			if rand.Float64() < rainChurn {
+				col.Glyphs[y] = rainGlyph()
+			}
+		}
+		if head := int(col.Y); head >= 0 && head < len(col.Glyphs) {
+			col.Glyphs[head] = rainGlyph()
+		}
+	}
+}
+
+// Each calls fn with every column; Update waits until it returns
+func (mr *Rain) Each(fn func(col RainColumn)) {
+	mr.mutex.RLock()
+	defer mr.mutex.RUnlock()
+	for _, col := range mr.columns {
+		fn(col)
+	}
+}
+
+// Enabled reports whether the rain is falling
+func (mr *Rain) Enabled() bool {
+	mr.mutex.RLock()
+	defer mr.mutex.RUnlock()
+	return mr.enabled
+}
+
+func (mr *Rain) SetEnabled(enabled bool) {
+	mr.mutex.Lock()
+	mr.enabled = enabled
+	mr.mutex.Unlock()
+}
+
+// Density returns the columns per 10 screen columns the rain was made with
+func (mr *Rain) Density() int {
+	mr.mutex.RLock()
+	defer mr.mutex.RUnlock()
+	return mr.density
+}
+
+// Masked reports whether the rain only falls over the ocean and background
+func (mr *Rain) Masked() bool {
+	mr.mutex.RLock()
+	defer mr.mutex.RUnlock()
+	return mr.masked
+}
+
+// SetMasked chooses between rain that only falls over the ocean and the
+// background, and rain that falls over the land too
+func (mr *Rain) SetMasked(masked bool) {
+	mr.mutex.Lock()
+	mr.masked = masked
+	mr.mutex.Unlock()
+}
@@ -0,0 +1,140 @@
+// Package geoip resolves attacking addresses to their network owner from
+// local databases, and places countries by their centroids, so enrichment
+// works without calling a web API.
+package geoip
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ASNDatabase resolves an IP to its autonomous system without network calls
+type ASNDatabase interface {
+	Lookup(ip net.IP) (asn string, org string, ok bool)
+	Close() error
+}
+
+// OpenASN loads a MaxMind GeoLite2-ASN database (.mmdb) or an
+// iptoasn.com TSV file (optionally gzipped) based on the file extension
+func OpenASN(path string) (ASNDatabase, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".mmdb") {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return &mmdbASNDatabase{reader: reader}, nil
+	}
+	return loadTSVASNDatabase(path)
+}
+
+type mmdbASNDatabase struct {
+	reader *maxminddb.Reader
+}
+
+func (db *mmdbASNDatabase) Lookup(ip net.IP) (string, string, bool) {
+	var record struct {
+		Number uint   `maxminddb:"autonomous_system_number"`
+		Org    string `maxminddb:"autonomous_system_organization"`
+	}
+	if err := db.reader.Lookup(ip, &record); err != nil || record.Number == 0 {
+		return "", "", false
+	}
+	return fmt.Sprintf("AS%d", record.Number), record.Org, true
+}
+
+func (db *mmdbASNDatabase) Close() error {
+	return db.reader.Close()
+}
+
+type asnRange struct {
+	start net.IP // 16-byte form so IPv4 and IPv6 compare uniformly
+	end   net.IP
+	asn   uint32
+	org   string
+}
+
+// tsvASNDatabase holds iptoasn.com ranges sorted by start address:
+// range_start, range_end, AS_number, country_code, AS_description
+type tsvASNDatabase struct {
+	ranges []asnRange
+}
+
+func loadTSVASNDatabase(path string) (*tsvASNDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	db := &tsvASNDatabase{}
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 5 {
+			continue
+		}
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if start == nil || end == nil || err != nil {
+			return nil, fmt.Errorf("%s:%d: malformed range", path, lineNum)
+		}
+		// AS0 marks unrouted space
+		if asn == 0 {
+			continue
+		}
+		db.ranges = append(db.ranges, asnRange{start: start.To16(), end: end.To16(), asn: uint32(asn), org: fields[4]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(db.ranges) == 0 {
+		return nil, fmt.Errorf("%s: no ASN ranges found", path)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+	return db, nil
+}
+
+func (db *tsvASNDatabase) Lookup(ip net.IP) (string, string, bool) {
+	ip = ip.To16()
+	if ip == nil {
+		return "", "", false
+	}
+	// Last range starting at or before ip
+	idx := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	}) - 1
+	if idx < 0 || bytes.Compare(ip, db.ranges[idx].end) > 0 {
+		return "", "", false
+	}
+	r := db.ranges[idx]
+	return fmt.Sprintf("AS%d", r.asn), r.org, true
+}
+
+func (db *tsvASNDatabase) Close() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package geoip
+
+import (
+	"slices"
+	"sync"
+)
+
+// Location is where an address geolocates to, with what is known of its
+// network
+type Location struct {
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	ASN       string // Autonomous System Number
+	Org       string // Organization/ISP name
+	RDNS      string // Reverse DNS
+	Valid     bool
+	Approx    bool // Placed at the country's centroid for want of city coordinates
+}
+
+// Cache keeps the locations of the most recently looked up addresses,
+// evicting the least recently used beyond its capacity
+type Cache struct {
+	entries  map[string]Location
+	order    []string // Addresses, most recently used first
+	capacity int
+	mutex    sync.Mutex
+}
+
+func NewCache(capacity int) *Cache {
+	return &Cache{entries: make(map[string]Location), capacity: capacity}
+}
+
+// Get returns the cached location of ip and marks it most recently used
+func (c *Cache) Get(ip string) (Location, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	location, ok := c.entries[ip]
+	if ok {
+		c.touch(ip)
+	}
+	return location, ok
+}
+
+// Contains reports whether ip is cached, without marking it used
+func (c *Cache) Contains(ip string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok := c.entries[ip]
+	return ok
+}
+
+// Add caches the location of ip as the most recently used, evicting the
+// least recently used address when the cache is full
+func (c *Cache) Add(ip string, location Location) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[ip]; ok {
+		c.entries[ip] = location
+		c.touch(ip)
+		return
+	}
+	for len(c.entries) >= c.capacity && len(c.order) > 0 {
+		c.evictOldest()
+	}
+	c.entries[ip] = location
+	c.order = slices.Insert(c.order, 0, ip)
+}
+
+// Resize changes the capacity, evicting least recently used entries
+func (c *Cache) Resize(capacity int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.capacity = capacity
+	for len(c.entries) > c.capacity {
+		c.evictOldest()
+	}
+}
+
+// Shed evicts the least recently used half of the cache
+func (c *Cache) Shed() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for n := len(c.entries) / 2; n > 0; n-- {
+		c.evictOldest()
+	}
+}
+
+// Len is the number of cached addresses
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// Capacity is how many addresses the cache holds before evicting
+func (c *Cache) Capacity() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.capacity
+}
+
+// touch moves ip to the front of the order; c.mutex must be held
+func (c *Cache) touch(ip string) {
+	if i := slices.Index(c.order, ip); i > 0 {
+		copy(c.order[1:i+1], c.order[:i])
+		c.order[0] = ip
+	}
+}
+
+// evictOldest drops the least recently used address; c.mutex must be held
+func (c *Cache) evictOldest() {
+	if len(c.order) == 0 {
+		return
+	}
+	oldest := c.order[len(c.order)-1]
+	delete(c.entries, oldest)
+	c.order = c.order[:len(c.order)-1]
+}
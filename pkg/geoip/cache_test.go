@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache(3)
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		c.Add(ip, Location{City: ip, Valid: true})
+	}
+
+	// Get marks an address used, Contains does not
+	if _, ok := c.Get("192.0.2.1"); !ok {
+		t.Fatal("192.0.2.1 missing before the cache filled up")
+	}
+	if !c.Contains("192.0.2.2") {
+		t.Fatal("192.0.2.2 missing before the cache filled up")
+	}
+	c.Add("192.0.2.4", Location{City: "192.0.2.4"})
+
+	if c.Contains("192.0.2.2") {
+		t.Error("192.0.2.2 survived though it was the least recently used")
+	}
+	for _, ip := range []string{"192.0.2.1", "192.0.2.3", "192.0.2.4"} {
+		if !c.Contains(ip) {
+			t.Errorf("%s was evicted", ip)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", c.Len())
+	}
+}
+
+func TestCacheAddExisting(t *testing.T) {
+	c := NewCache(2)
+	c.Add("192.0.2.1", Location{City: "Old"})
+	c.Add("192.0.2.2", Location{City: "Other"})
+	c.Add("192.0.2.1", Location{City: "New"})
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d after updating an address, want 2", c.Len())
+	}
+	if location, _ := c.Get("192.0.2.1"); location.City != "New" {
+		t.Errorf("City = %q, want New", location.City)
+	}
+
+	// The update made 192.0.2.2 the oldest
+	c.Add("192.0.2.3", Location{})
+	if c.Contains("192.0.2.2") || !c.Contains("192.0.2.1") {
+		t.Error("the update did not mark 192.0.2.1 used")
+	}
+}
+
+func TestCacheResizeAndShed(t *testing.T) {
+	c := NewCache(10)
+	for i := range 10 {
+		c.Add(fmt.Sprintf("192.0.2.%d", i), Location{})
+	}
+
+	c.Resize(6)
+	if c.Len() != 6 || c.Capacity() != 6 {
+		t.Fatalf("Len() = %d, Capacity() = %d after Resize(6)", c.Len(), c.Capacity())
+	}
+	for i := range 4 {
+		if c.Contains(fmt.Sprintf("192.0.2.%d", i)) {
+			t.Errorf("192.0.2.%d survived the resize though it was among the oldest", i)
+		}
+	}
+
+	c.Shed()
+	if c.Len() != 3 {
+		t.Fatalf("Len() = %d after Shed, want 3", c.Len())
+	}
+	for i := 7; i < 10; i++ {
+		if !c.Contains(fmt.Sprintf("192.0.2.%d", i)) {
+			t.Errorf("Shed evicted 192.0.2.%d, one of the newest", i)
+		}
+	}
+
+	// Growing keeps what is there
+	c.Resize(20)
+	if c.Len() != 3 {
+		t.Errorf("Len() = %d after growing, want 3", c.Len())
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	c := NewCache(50)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				ip := fmt.Sprintf("198.51.100.%d", (g*31+i)%120)
+				c.Add(ip, Location{})
+				c.Get(ip)
+				if i%50 == 0 {
+					c.Shed()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Len(); n > 50 {
+		t.Errorf("Len() = %d, over the capacity of 50", n)
+	}
+}
@@ -0,0 +1,38 @@
+package globerender
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// BenchmarkRender times whole frames with the workload of --bench-render:
+// lighting, 50 curved arcs and 200 markers, at sizes from a laptop terminal to
+// a wall display. The names match its output so the two can be compared.
+func BenchmarkRender(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	markers := make([]Marker, 200)
+	for i := range markers {
+		markers[i] = Marker{Lat: rng.Float64()*140 - 70, Lon: rng.Float64()*360 - 180, Glyph: '*'}
+	}
+	arcs := make([]Arc, 50)
+	for i := range arcs {
+		arcs[i] = Arc{SrcLat: markers[i].Lat, SrcLon: markers[i].Lon, DstLat: 39.1, DstLon: -94.6}
+	}
+
+	charsets := []struct {
+		name    string
+		charset Charset
+	}{{"ascii", CharsetASCII}, {"blocks", CharsetBlocks}, {"braille", CharsetBraille}}
+	for _, size := range [][2]int{{80, 24}, {120, 40}, {200, 60}, {320, 90}} {
+		for _, c := range charsets {
+			b.Run(fmt.Sprintf("%dx%d/%s", size[0], size[1], c.name), func(b *testing.B) {
+				opts := Options{Charset: c.charset, Lighting: true, ArcStyle: "curved", Arcs: arcs}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					Render(size[0], size[1], float64(i)*0.01, markers, opts)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package globerender
+
+// EarthBitmap is an equirectangular land map with north at the top: '#' is
+// land and ' ' water. Generated by utils/convert_png.go.
+func EarthBitmap() []string {
+	return []string{
+		"                                                                                                                        ",
+		"                                                                                                                        ",
+		"                                                                                                                        ",
+		"                             # ####### #################                                    #                           ",
+		"                       #    #   ### #################            ###                                                    ",
+		"                      ###  ## ####       ############ #                        ##         ########        #####         ",
+		"                  ## ###   #  ### ##      ###########                         #    #### ################   ###          ",
+		"      ######## ###### #### # #  #  ###     #########              #######        # ## ##################################",
+		" ### ###########################    ####   #####      #          ####### ###############################################",
+		"      ########################       ##    ####                #### ####################################################",
+		"      ### # #################      ##        #                ##### # ##########################################  ##    ",
+		"                ##############     #####                   #     #  #######################################      ##     ",
+		"                 ################ #######                # #   ###########################################      ##      ",
+		"                  ########################                 ################################################             ",
+		"                    ###################  ##                ################################################             ",
+		"                   ################### #                    ##########  ####  ############################              ",
+		"                   ##################                    ##### ##  ###    ### ##########################                ",
+		"                   #################                     ###       # ######## ######################  #    #            ",
+		"                    ###############                       #  ###       ##############################  #  #             ",
+		"                     #############                        ######        #############################                   ",
+		"                       ######## #                        ############################################                   ",
+		"                      # ####     #                      ##################### #######################                   ",
+		"                       # ###      #                    ################# ######    #################                    ",
+		"                         ###  #   #                    ################## ######     ####  #####                        ",
+		"                          #####   # #                  ################## #####      ###    ####                        ",
+		"                             ####                      ################### ###       ##      ####   #                   ",
+		"                               #    #                  ####################           #      # ##                       ",
+		"                                #  #####                #####################         #      # #     ##                 ",
+		"                                   ######                #### ###############          #      #    #                    ",
+		"                                   ########                     ############                 ##   ##                    ",
+		"                                  #########                     ###########                   #  ####                   ",
+		"                                  #############                 ##########                    ##### #     ##            ",
+		"                                 ################                ########                                  ## #         ",
+		"                                  ###############                #########                         ## #    # #          ",
+		"                                   #############                 #########                                              ",
+		"                                   ############                  #########  #                         # ##  #           ",
+		"                                     ##########                 #########  ##                        ########           ",
+		"                                     ##########                  #######   ##                      ###########     #    ",
+		"                                     ########                    #######   #                      #############         ",
+		"                                     #######                     ######                           ##############        ",
+		"                                     #######                      #####                            #############        ",
+		"                                     ######                       ####                             ###   ######         ",
+		"                                    #####                                                                  ####       # ",
+		"                                    #####                                                                              #",
+		"                                    ###                                                                      #        # ",
+		"                                    ###                                                                             ##  ",
+		"                                    ##                                                                                  ",
+		"                                   ##                                                                                   ",
+		"                                    ##                                                                                  ",
+		"                                                                                                                        ",
+		"                                                                                                                        ",
+		"                                                                                                                        ",
+		"                                       #                                                                                ",
+		"                                      #                                #  ##########   ########################         ",
+		"                                   #####                 ########################## #################################   ",
+		"                  # ## #   #############              #############################################################     ",
+		"        ## #########################             ##################################################################     ",
+		"           ######################## #  #  ##     #################################################################      ",
+		"    ##################################################################################################################  ",
+		"########################################################################################################################",
+	}
+}
+
+// CountryBitmap is the country code of every cell of EarthBitmap, two
+// characters per cell. Generated by utils/convert_png.go -countries.
+func CountryBitmap() []string {
+	return []string{
+		"                                                                                                                                                                                                                                                ",
+		"                                                                                                                                                                                                                                                ",
+		"                                                        CACACA  CACACACACACACAGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGL                                                                                                                              ",
+		"                                                        CACACACACACACACACAGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGL                NONONO                                                                                                        ",
+		"                                            CACACA    CACACACACACACACACACAGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLGLNO                NONONO                    RURURURU              RURURURURURURURURURU            RURURURU                      ",
+		"                                    CACACACACACACA  CACACACACACACACACACACACACAGLGLGLGLGLGLGLGLGLGLGLGLGLGLGL                      NONONO                  RURURURURURURURURU  RURURURURURURURURURURURURURURURURU  RURURURURU                    ",
+		"RU        USUSUSUSUSUSUSUSUSUSUSCACACACACACACACACACACACACACACACACACACACACACA    CAGLGLGLGLGLGLGLGLGLGLGLISIS                        NONONONONONO          RURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURU",
+		"RURUUSUSUSUSUSUSUSUSUSUSUSUSCACACACACACACACACACACACACACACACACACACACA  CACACACACACA  GLGLGLGLGLGLGLGLISISIS                      NONONOFIFIFIFIFIRURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURU",
+		"RUUSUSUSUSUSUSUSUSUSUSUSCACACACACACACACACACACACACACACACACACACACACACA  CACACACACACA  GLGLGLGLGLGLGLISISISIS                  NOSESESESESEFIFIFIFIRURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURU",
+		"USUSUSUSUSUSUSUSUSUSUSUSCACACACACACACACACACACACACACACACACACACACACA  CACACACACACAGL  GLGLGLGLGLGLGL                          NONONOSESEFIFIFIFIFIRURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURU",
+		"US        USUSUSUSUSUSUSCACACACACACACACACACACACACACACACACACACA      CACACACACAGLGL    GLGLGLGL                      GBGBGB  NONOSESESEEEEEEEEERURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURURU",
+		"          USUSUSUSUS    CACACACACACACACACACACACACACACACACACACACACACACACACACACACACACA                                GBGBGB  DKDKSESERULTLTBYBYRURURURURURURURURURURURURUKZKZRURURURURURURURURURURURURURURURURURURURURURURURURURURURURURU        ",
+		"                              CACACACACACACACACACACACACACAUSUSCACACACACACACACACACACA                                GBGBGBNLNLDEDEPLPLPLBYBYBYRURURURUKZKZRURURURURUKZKZKZKZKZKZKZRURURURURURURURURURURUCNCNRURURURURURU        JPRURURU        ",
+		"                                CACACACAUSUSUSUSCACACAUSUSUSUSUSCACACACACACACACACACACA                              GBGBBEBELUDECZCZSKSKMDUAUAUAUAUAKZKZKZKZKZKZKZKZKZKZKZKZKZKZKZKZMNMNMNMNMNMNRURURUCNCNCNCNRURURURURU        JPJPJP          ",
+		"                                  CACAUSUSUSUSUSUSUSUSUSUSUSUSUSUSCACACACACACACACACACA                              FRFRFRFRCHITSISIHUROROMDUAUAUAGEGEGEKZKZKZKZKZKZKZKZKZKZKZKZKZCNCNMNMNMNMNMNMNMNCNCNCNCNCNRURURURUJP                        ",
+		"                                    USUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSCACACACACACA                            ESESESFRFRITITITBAMERSBGBGBGTRGEGEGEGEAZAZTMTMUZUZUZKGKGKGKGCNCNCNCNMNMNMNMNMNMNMNCNCNCNCNCNCNRUJPJPJP                        ",
+		"                                    USUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSCACACA                              PTPTESESESITITITITITALMKBGBGTRTRTRGEAMAZAZAZTMTMTMUZUZTJKGKGKGCNCNCNCNCNCNCNCNCNCNCNCNKPKPKPKPKPKPJPJPJPJP                      ",
+		"                                    USUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUSUS                                    PTPTESESDZDZITITITITGRGRGRCYCYTRTRSYAMAZAZAZTMTMTMUZTJTJTJKGKGCNCNCNCNCNCNCNCNCNCNCNCNCNKRKRKRKRJPJPJPJPJP                      ",
+		"                                    USUSUSUSMXMXUSUSUSUSUSUSUSUSUSUSUSUS                                        MAMAMADZDZDZTNTNIT      GRCYCYCYSYSYIQIQIRIRIRIRTMAFAFAFTJTJINCNCNCNCNCNCNCNCNCNCNCNCNCNKRKRKRJPJPJPJPJPJP                      ",
+		"                                      USUSMXMXMXMXUSUSUSUSUSUSUSUSUSBSBS                                        MAMAMADZDZDZTNTNTNLYLYLYEGEGILILJOIQIQKWKWIRIRIRAFAFPKPKPKININNPCNCNCNBTCNCNCNCNCNCNCNCNCNKRJPJPJPJPJPJP                        ",
+		"                                        MXMXMXMXMXUSUSUSUSUSUSUSUSBSBS                                        MAMAMAMADZDZDZDZTNLYLYLYLYEGEGEGILJOJOKWKWKWQAIRIRPKPKPKPKINININNPNPBTBTBTBTMMCNCNCNCNCNCNCNTW                                    ",
+		"                                          MXMXMXMXMXMXMXUSMXUSUSCUBSBS                                      MAMAMAMADZDZDZDZDZLYLYLYLYLYEGEGEGEGJOSASASAQAQAAEOMOMPKPKPKINININNPININBDMMMMMMVNVNCNCNCNTWTWTW                                    ",
+		"                                          MXMXMXMXMXMXMXMX      CUCUCUBS                                    MAMRMRMRMLMLDZNENENELYLYLYLYEGEGEGEGSASASASAQAAEOMOMOMOMPKPKINININININBDBDMMMMLAVNVNVNCNCNTWTWTW                                    ",
+		"                                                MXMXMXMXMXMXBZBZ  CUJMHT                                    SNMRMRMRMLMLMLNENENENETDTDTDSDSDSDSDERERSAYEYEOMOMOMOM  INININININININBDBDMMMMLALAVNVNCNCNPHPH                                      ",
+		"                                                MXMXMXMXMXGTBZHNHNJMJMHT                                    SNSNSNMLMLMLBFNENENETDTDTDTDSDSDSDSDERERERYEYEYEOMOMOM      INININININBDMMMMTHTHTHVNVNVN                                            ",
+		"                                                  MXMXMXGTSVSVNINIJMJMHTDOPR                                GMSNGNGNBFBFBFBJNENETDTDTDTDSDSDSDSDERERDJYEYEYEYEOM        ININININLK    THTHTHKHKHVNVN                                            ",
+		"                                                          SVSVCRCR  PACOVEVETTTTTT                          GWGWGNGNCIBFBJBJNGNGCMCMCFCFSDSDSDSSETETDJDJYEYE            INININLK        THKHVNVNVNVN                                            ",
+		"                                                                    COCOVEVEVETTGYSR                        GWSLLRCICIGHTGBJNGCMCMCMCFCFCFSSSSSSETETETSOSOSO              LKLKLK        MYMYMYVNVNBNMYMYPH                                      ",
+		"                                                                    COCOCOVEVEGYGYSRGFGF                      SLLRLRCIGHTGBJGQGQCMCGCFCFCFSSSSUGKEETSOSOSOSO              LKLKLK        MYMYMYSGMYMYBNMYMY                                      ",
+		"                                                                  ECECCOCOVEVEGYGYSRGFGF                        LRLRCIGHGHGQGQGQCGCGCGCFRWRWUGUGKEKESOSOSOSO                            IDIDSGSGIDIDIDMYID                                      ",
+		"                                                                  ECECECCOBRBRBRBRSRGFGFGFBR                                  GAGACGCDCDRWRWRWUGKEKEKESOSO                              IDIDIDSGIDIDIDIDIDID      IDIDPGPG                      ",
+		"                                                                ECECECPEPEBRBRBRBRBRGFGFBRBRBRBRBRBR                          GAGACGCDCDCDBIBITZTZKEKEKE                                  IDIDIDIDIDIDIDIDID      IDIDPGPGPGPGPG                ",
+		"                                                                ECPEPEPEPEBRBRBRBRBRBRBRBRBRBRBRBRBR                          GAAOAOAOCDCDCDTZTZTZTZTZ                                    IDIDIDIDIDIDIDIDTLTLTL  IDIDPGPGPGPGPG                ",
+		"                                                                PEPEPEPEPEBOBRBRBRBRBRBRBRBRBRBRBRBR                            AOAOAOCDCDZMMWMWMWMWMG                                              IDIDIDTLTLTL    PGPGPGPGPGPG                ",
+		"                                                                  PEPEPEPEBOBOBOBOBRBRBRBRBRBRBRBRBR                            AOAOAOAOZMZMMWMWMWMWMGMGMGMG                                        IDIDIDTLTLTLTLIDAUAUAUAUAU                  ",
+		"                                                                    PEPEBOBOBOBOBOBRBRBRBRBRBRBRBR                            AOAOAOAOZMZMZWZWMZMZMZMGMGMGMG                                            IDTLTLTLAUAUAUAUAUAU                    ",
+		"                                                                    CLCLCLCLBOBOPYPYBRBRBRBRBRBR                              NANANANABWBWZWZWMZMZMZMGMGMGMG                                          AUIDAUAUAUAUAUAUAUAUAUAU                  ",
+		"                                                                        CLCLARPYPYPYBRBRBRBRBRBR                              NANANANABWBWBWSZMZMZMZMGMGMGMG                                      AUAUAUAUAUAUAUAUAUAUAUAUAUAUAU                ",
+		"                                                                        CLCLARARPYBRBRBRBRBRBRBR                                NANANABWBWSZSZSZSZ  MGMGMGMG                                      AUAUAUAUAUAUAUAUAUAUAUAUAUAUAUNC              ",
+		"                                                                        CLCLARARARUYBRBRBRBR                                    NANAZAZALSLSSZSZSZ  MGMGMG                                        AUAUAUAUAUAUAUAUAUAUAUAUAUAUAUNC              ",
+		"                                                                        CLARARARUYUYUYUYUY                                        ZAZAZAZALSLSSZ                                                  AUAUAUAUAUAUAUAUAUAUAUAUAUAUAUAU              ",
+		"                                                                      CLCLARARARUYUYUYUYUY                                        ZAZAZAZALSLSLS                                                    AUAUAUAUAUAUAUAUAUAUAUAUAUAUAU        NZNZNZ",
+		"NZ                                                                    CLCLCLARARUYUYUYUY                                            ZAZAZALSLS                                                      AUAUAUAUAU  AUAUAUAUAUAUAUAU          NZNZNZ",
+		"NZ                                                                    CLARARARARARUY                                                                                                                                AUAUAUAUAUAU          NZNZNZ",
+		"NZ                                                                    CLARARARARAR                                                                                                                                      AUAUAU        NZNZNZNZNZ",
+		"                                                                      CLCLARARAR                                                                                                                                        AUAUAU        NZNZNZNZNZ",
+		"                                                                      CLCLCLAR                                                                                                                                                        NZNZNZNZ  ",
+		"                                                                      CLCLCLCL                                                                                                                                                                  ",
+		"                                                                      CLCLCLCL                                                                                                                                                                  ",
+		"                                                                        CLCLCL                                                                                                                                                                  ",
+		"                                                                                                                                                                                                                                                ",
+		"                                                                            AQAQAQ                                                                                                                                                              ",
+		"                                                                          AQAQAQAQ                                                                AQAQAQAQAQAQAQAQAQAQAQAQ  AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ                ",
+		"                                                                        AQAQAQAQAQ                                AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ    ",
+		"                                        AQAQAQ      AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ                          AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ    ",
+		"              AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ              AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ    ",
+		"              AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ  AQAQAQ        AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ        ",
+		"        AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ  ",
+		"AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ",
+		"AQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQAQ",
+	}
+}
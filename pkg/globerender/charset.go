@@ -0,0 +1,111 @@
+package globerender
+
+// Charset is the set of characters land is drawn with
+type Charset int
+
+const (
+	CharsetASCII Charset = iota
+	CharsetBlocks
+	CharsetBraille
+)
+
+// DensityToChar picks the character for a cell's land density, 0 for empty
+// up to above 1 for fully lit land
+func DensityToChar(density float64, charset Charset) rune {
+	switch charset {
+	case CharsetBraille:
+		return densityToBraille(density)
+	case CharsetBlocks:
+		return densityToBlock(density)
+	default: // CharsetASCII
+		return densityToASCII(density)
+	}
+}
+
+func densityToBraille(density float64) rune {
+	// Unicode Braille patterns: U+2800 to U+28FF (256 patterns)
+	// Map density 0.0-1.0 to braille dot patterns for visual density
+	if density > 1.0 {
+		return '⣿' // Full 8-dot pattern
+	} else if density > 0.9 {
+		return '⣾'
+	} else if density > 0.8 {
+		return '⣶'
+	} else if density > 0.7 {
+		return '⣦'
+	} else if density > 0.6 {
+		return '⣤'
+	} else if density > 0.5 {
+		return '⣀'
+	} else if density > 0.4 {
+		return '⡀'
+	} else if density > 0.3 {
+		return '⠄'
+	} else if density > 0.2 {
+		return '⠂'
+	} else if density > 0.15 {
+		return '⠁'
+	} else if density > 0.1 {
+		return '⠀'
+	}
+	return ' '
+}
+
+func densityToBlock(density float64) rune {
+	// Unicode block elements
+	if density > 1.0 {
+		return '█' // Full block
+	} else if density > 0.875 {
+		return '▓' // Dark shade
+	} else if density > 0.75 {
+		return '▒' // Medium shade
+	} else if density > 0.625 {
+		return '░' // Light shade
+	} else if density > 0.5 {
+		return '▄' // Lower half block
+	} else if density > 0.375 {
+		return '▃' // Lower 3/8 block
+	} else if density > 0.25 {
+		return '▂' // Lower 1/4 block
+	} else if density > 0.125 {
+		return '▁' // Lower 1/8 block
+	}
+	return ' '
+}
+
+func densityToASCII(density float64) rune {
+	// Original ASCII art characters
+	if density > 1.0 {
+		return '@'
+	} else if density > 0.8 {
+		return '#'
+	} else if density > 0.6 {
+		return '%'
+	} else if density > 0.4 {
+		return 'o'
+	} else if density > 0.3 {
+		return '='
+	} else if density > 0.2 {
+		return '+'
+	} else if density > 0.15 {
+		return '-'
+	} else if density > 0.1 {
+		return '.'
+	} else if density > 0.05 {
+		return '`'
+	}
+	return ' '
+}
+
+// ShadeLevels is how many shades countries can be ranked into
+const ShadeLevels = 4
+
+// ShadeGlyph is the land character of a country shaded at level (1 to
+// ShadeLevels)
+func ShadeGlyph(level int, charset Charset) rune {
+	glyphs := []rune{'░', '▒', '▓', '█'}
+	if charset == CharsetASCII {
+		glyphs = []rune{'.', ':', '%', '#'}
+	}
+	return glyphs[min(max(level, 1), ShadeLevels)-1]
+}
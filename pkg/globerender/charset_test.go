@@ -0,0 +1,90 @@
+package globerender
+
+import "testing"
+
+func TestDensityToChar(t *testing.T) {
+	tests := []struct {
+		density float64
+		ascii   rune
+		blocks  rune
+		braille rune
+	}{
+		{0, ' ', ' ', ' '},
+		{0.08, '`', ' ', ' '},
+		{0.12, '.', ' ', '⠀'},
+		{0.13, '.', '▁', '⠀'},
+		{0.18, '-', '▁', '⠁'},
+		{0.25, '+', '▁', '⠂'},
+		{0.35, '=', '▂', '⠄'},
+		{0.45, 'o', '▃', '⡀'},
+		{0.55, 'o', '▄', '⣀'},
+		{0.65, '%', '░', '⣤'},
+		{0.75, '%', '░', '⣦'},
+		{0.8, '%', '▒', '⣦'},
+		{0.85, '#', '▒', '⣶'},
+		{0.95, '#', '▓', '⣾'},
+		{1.0, '#', '▓', '⣾'},
+		{1.2, '@', '█', '⣿'},
+	}
+	for _, tt := range tests {
+		for _, c := range []struct {
+			charset Charset
+			want    rune
+		}{{CharsetASCII, tt.ascii}, {CharsetBlocks, tt.blocks}, {CharsetBraille, tt.braille}} {
+			if got := DensityToChar(tt.density, c.charset); got != c.want {
+				t.Errorf("DensityToChar(%v, %d) = %q, want %q", tt.density, c.charset, got, c.want)
+			}
+		}
+	}
+}
+
+// TestDensityToCharMonotonic checks that denser land never gets a glyph
+// lower in its charset's scale
+func TestDensityToCharMonotonic(t *testing.T) {
+	scales := map[Charset]string{
+		CharsetASCII:   " `.-+=o%#@",
+		CharsetBlocks:  " ▁▂▃▄░▒▓█",
+		CharsetBraille: " ⠀⠁⠂⠄⡀⣀⣤⣦⣶⣾⣿",
+	}
+	for charset, scale := range scales {
+		rank := make(map[rune]int)
+		for i, r := range []rune(scale) {
+			rank[r] = i
+		}
+		last := 0
+		for d := 0.0; d <= 1.3; d += 0.01 {
+			r := DensityToChar(d, charset)
+			level, ok := rank[r]
+			if !ok {
+				t.Fatalf("DensityToChar(%v, %d) = %q, not in the scale %q", d, charset, r, scale)
+			}
+			if level < last {
+				t.Errorf("DensityToChar(%v, %d) = %q, lighter than at a lower density", d, charset, r)
+			}
+			last = level
+		}
+		if last != len([]rune(scale))-1 {
+			t.Errorf("charset %d never reaches its densest glyph", charset)
+		}
+	}
+}
+
+func TestShadeGlyph(t *testing.T) {
+	tests := []struct {
+		level   int
+		charset Charset
+		want    rune
+	}{
+		{1, CharsetBlocks, '░'},
+		{4, CharsetBraille, '█'},
+		{2, CharsetASCII, ':'},
+		{0, CharsetASCII, '.'},   // Clamped up to 1
+		{9, CharsetBlocks, '█'},  // Clamped down to ShadeLevels
+		{-3, CharsetBlocks, '░'}, // Clamped up to 1
+	}
+	for _, tt := range tests {
+		if got := ShadeGlyph(tt.level, tt.charset); got != tt.want {
+			t.Errorf("ShadeGlyph(%d, %d) = %q, want %q", tt.level, tt.charset, got, tt.want)
+		}
+	}
+}
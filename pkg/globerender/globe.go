@@ -0,0 +1,627 @@
+// Package globerender draws the rotating ASCII globe: land shaded by
+// density and lighting, attack markers and arcs, and countries shaded by a
+// level. It works on plain runes and RGB colors, without a terminal, so TUIs,
+// web backends and image exporters can share it.
+package globerender
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Globe is a sphere of a given size in terminal cells, with the camera and
+// lighting settings that persist between frames
+type Globe struct {
+	Radius      float64
+	Width       int
+	Height      int
+	EarthMap    []string
+	CountryMap  []string
+	MapWidth    int
+	MapHeight   int
+	AspectRatio float64
+	Charset     Charset
+	Lighting    bool
+	LightLon    float64
+	LightLat    float64
+	LightFollow bool
+	Zoom        float64
+	NudgeX      float64
+	NudgeY      float64
+	Projection  Projection
+	SubCell     bool // Place markers and arcs on Braille dots instead of whole cells
+	Supersample bool // Average 2x2 samples per cell instead of bleeding light into neighbours
+}
+
+// Kind tells what a rendered cell holds so it can be colored without
+// guessing from the rune (Braille markers look like Braille land)
+type Kind uint8
+
+const (
+	KindLand Kind = iota // Land, the globe's outline or an empty cell
+	KindArc
+	KindMarker
+	KindCrowd // A marker in a cell shared by CrowdSize or more attackers
+	KindShade // Shaded country land; KindShade+level-1 for each shade level
+)
+
+// Marker reports whether the cell holds an attack marker, crowded or not
+func (k Kind) Marker() bool {
+	return k == KindMarker || k == KindCrowd
+}
+
+// Attackers sharing a cell from CrowdSize on are drawn as a crowd, denser
+// again from CrowdLarge on
+const (
+	CrowdSize  = 3
+	CrowdLarge = 10
+)
+
+// CrowdGlyph is the plain marker of a cell count attackers share
+func CrowdGlyph(count int) rune {
+	if count >= CrowdLarge {
+		return '●'
+	}
+	return '✱'
+}
+
+// brailleDotBits maps a dot's [row][column] within a 2x4 Braille cell to its
+// bit in the U+2800 block
+var brailleDotBits = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// IsBraille reports whether r is in the Braille block
+func IsBraille(r rune) bool {
+	return r >= 0x2800 && r <= 0x28FF
+}
+
+// New sizes a globe to fit width x height cells, where a cell is
+// aspectRatio times as tall as it is wide
+func New(width, height int, aspectRatio float64, charset Charset) *Globe {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	globeWidth := width
+	effectiveHeight := float64(height) * aspectRatio
+	radius := math.Min(float64(globeWidth)/2.5, effectiveHeight/2.5)
+
+	if radius < 1.0 {
+		radius = 1.0
+	}
+
+	earthMap := EarthBitmap()
+	return &Globe{
+		Radius:      radius,
+		Width:       globeWidth,
+		Height:      height,
+		EarthMap:    earthMap,
+		CountryMap:  CountryBitmap(),
+		MapWidth:    len(earthMap[0]),
+		MapHeight:   len(earthMap),
+		AspectRatio: aspectRatio,
+		Charset:     charset,
+		Lighting:    false,
+		LightLon:    0,
+		LightLat:    0,
+		LightFollow: false,
+		Zoom:        1.0,
+		NudgeX:      0,
+		NudgeY:      0,
+		SubCell:     true,
+	}
+}
+
+// ZoomAt changes the zoom while keeping the point of the globe under cell
+// x, y where it is, so zooming moves towards that point rather than the
+// globe's center
+func (g *Globe) ZoomAt(zoom float64, x, y int) {
+	if g.Zoom <= 0 {
+		g.Zoom = 1.0
+	}
+	px := float64(x - g.Width/2)
+	py := float64(y - g.Height/2)
+	scale := zoom / g.Zoom
+	g.Zoom = zoom
+	g.NudgeX = px - (px-g.NudgeX)*scale
+	g.NudgeY = py - (py-g.NudgeY)*scale
+	g.clampNudge()
+}
+
+// Pan moves the view by dx, dy of the globe's radius, so a step covers the
+// same stretch of the surface at any zoom. Positive dy moves the globe down.
+func (g *Globe) Pan(dx, dy float64) {
+	s := g.Scale()
+	g.NudgeX += dx * s
+	g.NudgeY += dy * s / g.AspectRatio
+	g.clampNudge()
+}
+
+// Rescale keeps the view on the same part of the globe when Scale changes
+// from oldScale, as it does when the terminal is resized
+func (g *Globe) Rescale(oldScale float64) {
+	if oldScale <= 0 {
+		return
+	}
+	g.NudgeX *= g.Scale() / oldScale
+	g.NudgeY *= g.Scale() / oldScale
+	g.clampNudge()
+}
+
+// clampNudge lets the globe's edge reach the middle of the view but no
+// further, so panning and zooming out never lose the globe
+func (g *Globe) clampNudge() {
+	s := g.Scale()
+	w, h := g.Projection.extent()
+	g.NudgeX = max(-w*s, min(w*s, g.NudgeX))
+	g.NudgeY = max(-h*s/g.AspectRatio, min(h*s/g.AspectRatio, g.NudgeY))
+}
+
+func (g *Globe) sampleEarthAt(lat, lon float64) rune {
+	latNorm := (lat + 90) / 180
+	lonNorm := (lon + 180) / 360
+
+	y := int(latNorm * float64(g.MapHeight-1))
+	x := int(lonNorm * float64(g.MapWidth-1))
+
+	if y < 0 {
+		y = 0
+	}
+	if y >= g.MapHeight {
+		y = g.MapHeight - 1
+	}
+	if x < 0 {
+		x = 0
+	}
+	if x >= g.MapWidth {
+		x = g.MapWidth - 1
+	}
+
+	return rune(g.EarthMap[y][x])
+}
+
+// supersampleOffsets are the sample points within a cell, in cells from its
+// center, when Supersample is on
+var supersampleOffsets = [4][2]float64{{-0.25, -0.25}, {0.25, -0.25}, {-0.25, 0.25}, {0.25, 0.25}}
+
+// landSample looks up the land at dx, dy from the globe's center, in units
+// of cell width, returning its lit density and the light on it. ok is false
+// off the globe and over water.
+func (g *Globe) landSample(dx, dy, scale, rotation float64) (lat, lon, density, light float64, ok bool) {
+	lat, lon, view, ok := g.inverse(dx/scale, dy/scale, rotation)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+
+	earthChar := g.sampleEarthAt(lat, lon)
+	if earthChar == ' ' {
+		return 0, 0, 0, 0, false
+	}
+	baseDensity := 1.0
+	switch earthChar {
+	case '#':
+		baseDensity = 1.0
+	case '.':
+		baseDensity = 0.6
+	default:
+		baseDensity = 0.8
+	}
+
+	// Apply lighting
+	light = g.calculateLighting(lat, lon, view)
+	return lat, lon, baseDensity * light, light, true
+}
+
+// Project returns the cell a point on the globe is drawn in; visible is
+// false on the far side or off screen
+func (g *Globe) Project(lat, lon, rotation float64) (int, int, bool) {
+	fx, fy, visible := g.screenPoint(lat, lon, rotation)
+	if !visible {
+		return 0, 0, false
+	}
+	screenX, screenY := int(math.Floor(fx)), int(math.Floor(fy))
+
+	if screenX < 0 || screenX >= g.Width || screenY < 0 || screenY >= g.Height {
+		return 0, 0, false
+	}
+
+	return screenX, screenY, true
+}
+
+// ProjectSubCell projects like Project but also returns which Braille
+// dot column (0-1) and row (0-3) inside the cell the point falls on, so
+// positions move in quarter/half cell steps as the globe turns
+func (g *Globe) ProjectSubCell(lat, lon, rotation float64) (int, int, int, int, bool) {
+	fx, fy, visible := g.screenPoint(lat, lon, rotation)
+	if !visible {
+		return 0, 0, 0, 0, false
+	}
+
+	cellX, cellY := math.Floor(fx), math.Floor(fy)
+	screenX, screenY := int(cellX), int(cellY)
+	if screenX < 0 || screenX >= g.Width || screenY < 0 || screenY >= g.Height {
+		return 0, 0, 0, 0, false
+	}
+
+	dotX := min(int((fx-cellX)*2), 1)
+	dotY := min(int((fy-cellY)*4), 3)
+	return screenX, screenY, dotX, dotY, true
+}
+
+// screenPoint is where a point on the globe falls on screen, in cells with
+// fractions, after zoom and nudge
+func (g *Globe) screenPoint(lat, lon, rotation float64) (float64, float64, bool) {
+	x, y, visible := g.forward(lat, lon, g.turn(rotation))
+	if !visible {
+		return 0, 0, false
+	}
+	s := g.Scale()
+	return x*s + g.NudgeX + float64(g.Width/2), -y*s/g.AspectRatio + g.NudgeY + float64(g.Height/2), true
+}
+
+// UseSubCell reports whether markers and arcs are drawn on Braille dots
+func (g *Globe) UseSubCell(protocolGlyphs bool) bool {
+	return g.SubCell && g.Charset == CharsetBraille && !protocolGlyphs
+}
+
+// MarkerCell returns the cell and rune used for an attack marker, placed on
+// Braille dots when subCell is set
+func (g *Globe) MarkerCell(lat, lon, rotation float64, subCell bool) (int, int, rune, bool) {
+	if !subCell {
+		x, y, visible := g.Project(lat, lon, rotation)
+		return x, y, '*', visible
+	}
+
+	x, y, dotX, dotY, visible := g.ProjectSubCell(lat, lon, rotation)
+	if !visible {
+		return 0, 0, 0, false
+	}
+	// Two stacked dots are still visible as a marker but leave room to move
+	dotY = min(dotY, 2)
+	return x, y, 0x2800 + brailleDotBits[dotY][dotX] + brailleDotBits[dotY+1][dotX], true
+}
+
+func (g *Globe) calculateLighting(lat, lon, rotation float64) float64 {
+	if !g.Lighting {
+		return 1.0
+	}
+
+	// Calculate light vector
+	var lightLon, lightLat float64
+	if g.LightFollow {
+		// Light rotates opposite to globe
+		lightLon = -rotation * 180 / math.Pi
+		lightLat = 23.5 // Approximate Earth's axial tilt
+	} else {
+		lightLon = g.LightLon
+		lightLat = g.LightLat
+	}
+
+	// Convert both point and light to 3D vectors
+	adjustedLon := -lon + 90
+	adjustedLon = math.Mod(adjustedLon+180, 360) - 180
+	latRad := lat * math.Pi / 180
+	lonRad := (adjustedLon + rotation*180/math.Pi) * math.Pi / 180
+
+	// Surface normal at this point
+	nx := math.Cos(latRad) * math.Cos(lonRad)
+	ny := math.Sin(latRad)
+	nz := math.Cos(latRad) * math.Sin(lonRad)
+
+	// Light direction
+	lightLatRad := lightLat * math.Pi / 180
+	lightLonRad := lightLon * math.Pi / 180
+	lx := math.Cos(lightLatRad) * math.Cos(lightLonRad)
+	ly := math.Sin(lightLatRad)
+	lz := math.Cos(lightLatRad) * math.Sin(lightLonRad)
+
+	// Dot product for diffuse lighting (Lambertian)
+	dotProduct := nx*lx + ny*ly + nz*lz
+	intensity := math.Max(0.2, dotProduct) // Minimum ambient light 0.2
+
+	return intensity
+}
+
+// Marker is an attack location drawn on the globe
+type Marker struct {
+	Lat, Lon float64
+	Glyph    rune // Drawn instead of the default '*' (or Braille dots) when set
+	Rank     int  // Bigger markers win a shared cell; ranked markers take a whole cell
+}
+
+// Arc is an attack's path from its source to the honeypot
+type Arc struct {
+	SrcLat, SrcLon float64
+	DstLat, DstLon float64
+	Fade           float64 // 1 when new, down to 0 when it expires
+}
+
+// Raster draws one frame: land, then arcs (arcStyle "curved" or "straight",
+// "off" for none), then markers. protocolGlyphs says markers carry their
+// own glyphs, which keeps them off Braille dots. shades maps country codes
+// to their shade level (1 to ShadeLevels); nil leaves land unshaded.
+func (g *Globe) Raster(rotation float64, markers []Marker, arcs []Arc, arcStyle string, protocolGlyphs bool, shades map[string]int) ([][]rune, [][]Kind) {
+	if g.Width <= 0 || g.Height <= 0 {
+		return [][]rune{[]rune{' '}}, [][]Kind{[]Kind{KindLand}}
+	}
+
+	screen := make([][]rune, g.Height)
+	kinds := make([][]Kind, g.Height)
+	for i := range screen {
+		screen[i] = make([]rune, g.Width)
+		kinds[i] = make([]Kind, g.Width)
+		for j := range screen[i] {
+			screen[i][j] = ' '
+		}
+	}
+
+	// Land is rasterized in horizontal bands, one per worker. Anti-aliasing
+	// spreads a land cell's light into its neighbours, which may belong to
+	// another band, so the first pass records only each cell's own light and
+	// the second gathers it from the neighbours once every band is done.
+	// Supersampled cells are smoothed within themselves and gather nothing.
+	light := make([][]float64, g.Height+2) // Light on the land in cell x,y at [y+1][x+1]; 0 off land
+	land := make([][]float64, g.Height)    // Each cell's own land density
+	outline := make([][]bool, g.Height)
+	shade := make([][]int, g.Height)
+	for i := range light {
+		light[i] = make([]float64, g.Width+2)
+	}
+	for i := range land {
+		land[i] = make([]float64, g.Width)
+		outline[i] = make([]bool, g.Width)
+		shade[i] = make([]int, g.Width)
+	}
+
+	centerX, centerY := g.Width/2, g.Height/2
+	scale := g.Scale()
+	rotation = g.turn(rotation)
+
+	parallelRows(g.Height, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := 0; x < g.Width; x++ {
+				dx := float64(x-centerX) - g.NudgeX
+				dy := (float64(y-centerY) - g.NudgeY) * g.AspectRatio
+				outline[y][x] = g.onOutline(dx/scale, dy/scale)
+
+				if g.Supersample {
+					// Average four samples a quarter cell from the center,
+					// each with the light inland cells gather from their
+					// neighbours so the two qualities are equally bright. The
+					// cell takes the country of the first sample on land.
+					var sum float64
+					hit := false
+					for _, offset := range supersampleOffsets {
+						lat, lon, density, lightFactor, ok := g.landSample(dx+offset[0], dy+offset[1]*g.AspectRatio, scale, rotation)
+						if !ok {
+							continue
+						}
+						if !hit && shades != nil {
+							shade[y][x] = shades[g.CountryAt(lat, lon)]
+						}
+						hit = true
+						sum += density + 9*0.05*lightFactor
+					}
+					land[y][x] = sum / float64(len(supersampleOffsets))
+					continue
+				}
+
+				lat, lon, density, lightFactor, ok := g.landSample(dx, dy, scale, rotation)
+				if !ok {
+					continue
+				}
+				land[y][x] = density
+				light[y+1][x+1] = lightFactor
+
+				if shades != nil {
+					shade[y][x] = shades[g.CountryAt(lat, lon)]
+				}
+			}
+		}
+	})
+
+	// Convert density to characters; lit land of shaded countries takes its
+	// level's glyph instead
+	parallelRows(g.Height, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			above, row, below := light[y], light[y+1], light[y+2]
+			for x := 0; x < g.Width; x++ {
+				// Anti-aliasing: a share of the light on each neighbour,
+				// added in the order the cells are scanned
+				d := 0.05*above[x] + 0.05*above[x+1] + 0.05*above[x+2] + 0.05*row[x]
+				d += land[y][x]
+				d += 0.05 * row[x+1]
+				if outline[y][x] {
+					d += 0.2
+				}
+				d += 0.05 * row[x+2]
+				d += 0.05 * below[x]
+				d += 0.05 * below[x+1]
+				d += 0.05 * below[x+2]
+
+				screen[y][x] = DensityToChar(d, g.Charset)
+				if level := shade[y][x]; level > 0 && screen[y][x] != ' ' {
+					screen[y][x] = ShadeGlyph(level, g.Charset)
+					kinds[y][x] = KindShade + Kind(level-1)
+				}
+			}
+		}
+	})
+
+	// Arcs and markers are overlaid after the land so it cannot erase them.
+	// This holds for every charset: arcs cross land as well as ocean, where
+	// the original renderer drew them first and the land wiped them out.
+	if arcStyle != "off" && len(arcs) > 0 {
+		for _, arc := range arcs {
+			g.renderArc(arc, rotation, screen, kinds, arcStyle, protocolGlyphs)
+		}
+	}
+
+	// Every attacker in a cell is counted, so a city centroid many
+	// addresses geolocate to stands out from a lone attacker
+	type markerCell struct {
+		rank  int  // Rank of the marker drawn
+		plain bool // It has no glyph of its own
+		count int
+	}
+	cells := make(map[int]*markerCell) // By y*Width+x
+	for _, m := range markers {
+		screenX, screenY, marker, visible := g.MarkerCell(m.Lat, m.Lon, rotation, g.UseSubCell(protocolGlyphs) && m.Rank == 0)
+		if !visible {
+			continue
+		}
+		if m.Glyph != 0 {
+			marker = m.Glyph
+		}
+		existing := screen[screenY][screenX]
+		cell := cells[screenY*g.Width+screenX]
+		if cell == nil {
+			cell = &markerCell{}
+			cells[screenY*g.Width+screenX] = cell
+		}
+		cell.count++
+		if kinds[screenY][screenX] == KindMarker {
+			if cell.rank > m.Rank {
+				// The bigger marker wins a shared cell
+				continue
+			}
+			if IsBraille(marker) && IsBraille(existing) {
+				// Two attackers in one cell: keep both sets of dots
+				marker |= existing
+			}
+		}
+		screen[screenY][screenX] = marker
+		kinds[screenY][screenX] = KindMarker
+		cell.rank, cell.plain = m.Rank, m.Glyph == 0
+	}
+	for i, cell := range cells {
+		if cell.count < CrowdSize {
+			continue
+		}
+		x, y := i%g.Width, i/g.Width
+		kinds[y][x] = KindCrowd
+		if cell.plain {
+			screen[y][x] = CrowdGlyph(cell.count)
+		}
+	}
+
+	return screen, kinds
+}
+
+func (g *Globe) renderArc(arc Arc, rotation float64, screen [][]rune, kinds [][]Kind, arcStyle string, protocolGlyphs bool) {
+	fadeFactor := arc.Fade
+	if fadeFactor < 0 {
+		return
+	}
+
+	subCell := g.UseSubCell(protocolGlyphs)
+	steps := 30
+	if subCell {
+		// Enough samples to keep the finer dot trail continuous
+		steps = 90
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+
+		var lat, lon float64
+		if arcStyle == "curved" {
+			// Bezier curve with control points for arc
+			midLat := (arc.SrcLat + arc.DstLat) / 2
+			midLon := (arc.SrcLon + arc.DstLon) / 2
+			heightFactor := 20.0 // Arc height
+
+			cp1Lat := arc.SrcLat + (midLat-arc.SrcLat)*0.5 + heightFactor
+			cp1Lon := arc.SrcLon + (midLon-arc.SrcLon)*0.5
+
+			cp2Lat := midLat + (arc.DstLat-midLat)*0.5 + heightFactor
+			cp2Lon := midLon + (arc.DstLon-midLon)*0.5
+
+			lat = bezierPoint(t, arc.SrcLat, cp1Lat, cp2Lat, arc.DstLat)
+			lon = bezierPoint(t, arc.SrcLon, cp1Lon, cp2Lon, arc.DstLon)
+		} else {
+			// Straight line (great circle approximation)
+			lat = arc.SrcLat + t*(arc.DstLat-arc.SrcLat)
+			lon = arc.SrcLon + t*(arc.DstLon-arc.SrcLon)
+		}
+
+		// Trail fade: newer parts brighter
+		segmentFade := fadeFactor * (0.3 + 0.7*t)
+		if segmentFade <= 0.3 {
+			continue
+		}
+
+		if subCell {
+			screenX, screenY, dotX, dotY, visible := g.ProjectSubCell(lat, lon, rotation)
+			if !visible {
+				continue
+			}
+			dot := 0x2800 + brailleDotBits[dotY][dotX]
+			if kinds[screenY][screenX] == KindArc {
+				dot |= screen[screenY][screenX]
+			}
+			screen[screenY][screenX] = dot
+			kinds[screenY][screenX] = KindArc
+			continue
+		}
+
+		screenX, screenY, visible := g.Project(lat, lon, rotation)
+		if visible && screenX >= 0 && screenX < g.Width && screenY >= 0 && screenY < g.Height {
+			screen[screenY][screenX] = '·'
+			kinds[screenY][screenX] = KindArc
+		}
+	}
+}
+
+// CountryAt returns the country code of the bitmap cell sampleEarthAt reads
+// for the same point, or "" over open water
+func (g *Globe) CountryAt(lat, lon float64) string {
+	if len(g.CountryMap) != g.MapHeight {
+		return ""
+	}
+	y := min(max(int((lat+90)/180*float64(g.MapHeight-1)), 0), g.MapHeight-1)
+	x := min(max(int((lon+180)/360*float64(g.MapWidth-1)), 0), g.MapWidth-1)
+	code := g.CountryMap[y][2*x : 2*x+2]
+	if code == "  " {
+		return ""
+	}
+	return code
+}
+
+// Bezier curve calculation for curved arcs
+func bezierPoint(t float64, p0, p1, p2, p3 float64) float64 {
+	u := 1 - t
+	return u*u*u*p0 + 3*u*u*t*p1 + 3*u*t*t*p2 + t*t*t*p3
+}
+
+// minBandRows keeps bands big enough that a small frame is not split into
+// more goroutines than it has work for
+const minBandRows = 8
+
+// parallelRows calls fn on horizontal bands [y0, y1) covering height rows,
+// one band per worker in a pool sized to GOMAXPROCS, and waits for them all
+func parallelRows(height int, fn func(y0, y1 int)) {
+	workers := min(runtime.GOMAXPROCS(0), height/minBandRows)
+	if workers <= 1 {
+		fn(0, height)
+		return
+	}
+
+	var wg sync.WaitGroup
+	band := (height + workers - 1) / workers
+	for y0 := 0; y0 < height; y0 += band {
+		wg.Add(1)
+		go func(y0, y1 int) {
+			defer wg.Done()
+			fn(y0, y1)
+		}(y0, min(y0+band, height))
+	}
+	wg.Wait()
+}
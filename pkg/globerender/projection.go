@@ -0,0 +1,229 @@
+package globerender
+
+import "math"
+
+// Projection is how the globe's surface is laid out on screen
+type Projection int
+
+const (
+	Orthographic    Projection = iota // The turning sphere, one hemisphere at a time
+	Equirectangular                   // A flat world map with the whole earth in view
+	Mollweide                         // An equal-area ellipse of the whole earth
+	Hemispheres                       // The near and far sides of the sphere side by side
+)
+
+// ProjectionNames are the projections' names, indexed by Projection
+var ProjectionNames = []string{"orthographic", "flat", "mollweide", "hemispheres"}
+
+func (p Projection) String() string {
+	if p < 0 || int(p) >= len(ProjectionNames) {
+		return "unknown"
+	}
+	return ProjectionNames[p]
+}
+
+// ParseProjection looks a projection up by name
+func ParseProjection(name string) (Projection, bool) {
+	for i, n := range ProjectionNames {
+		if n == name {
+			return Projection(i), true
+		}
+	}
+	return Orthographic, false
+}
+
+// Flat reports whether the projection is a map of the whole earth. Flat
+// maps stay centered on the prime meridian rather than turning.
+func (p Projection) Flat() bool {
+	return p == Equirectangular || p == Mollweide
+}
+
+// hemisphereOffset is how far each of the two hemispheres' centers is from
+// the middle of the view, in globe radii, leaving a gap between them
+const hemisphereOffset = 1.08
+
+// extent is the half width and half height of the projection's outline, in
+// globe radii: the sphere's disc, a 2:1 rectangle or ellipse, or two discs
+func (p Projection) extent() (float64, float64) {
+	switch {
+	case p.Flat():
+		return 2, 1
+	case p == Hemispheres:
+		return hemisphereOffset + 1, 1
+	}
+	return 1, 1
+}
+
+// flatFill is the share of the view a flat map may take up, leaving the
+// same kind of margin the sphere has
+const flatFill = 0.95
+
+// Scale is how many cells wide a globe radius is at the current zoom. For
+// the other projections it is half the height that lets all of them fit the
+// view.
+func (g *Globe) Scale() float64 {
+	if g.Projection == Orthographic {
+		return g.Radius * g.Zoom
+	}
+	w, h := g.Projection.extent()
+	fit := math.Min(float64(g.Width)*flatFill/(2*w), float64(g.Height)*g.AspectRatio*flatFill/(2*h))
+	return math.Max(fit, 1.0) * g.Zoom
+}
+
+// turn is the rotation a frame is drawn with; flat maps ignore it
+func (g *Globe) turn(rotation float64) float64 {
+	if g.Projection.Flat() {
+		return 0
+	}
+	return rotation
+}
+
+// forward places lat, lon on the projection's plane in globe radii, with x
+// to the east and y to the north. visible is false on the far side of the
+// sphere.
+func (g *Globe) forward(lat, lon, rotation float64) (x, y float64, visible bool) {
+	switch g.Projection {
+	case Hemispheres:
+		// The far side is seen from behind, as if the globe had turned half
+		// way round
+		if x, y, front := orthographicForward(lat, lon, rotation); front {
+			return x - hemisphereOffset, y, true
+		}
+		x, y, _ = orthographicForward(lat, lon, rotation+math.Pi)
+		return x + hemisphereOffset, y, true
+	case Equirectangular:
+		lat = math.Max(-90, math.Min(90, lat))
+		return wrapLon(lon) / 90, lat / 90, true
+	case Mollweide:
+		lat = math.Max(-90, math.Min(90, lat))
+		theta := mollweideTheta(lat * math.Pi / 180)
+		return 2 / math.Pi * wrapLon(lon) * math.Pi / 180 * math.Cos(theta), math.Sin(theta), true
+	}
+	return orthographicForward(lat, lon, rotation)
+}
+
+func orthographicForward(lat, lon, rotation float64) (x, y float64, visible bool) {
+	adjustedLon := -lon + 90
+	adjustedLon = math.Mod(adjustedLon+180, 360) - 180
+	latRad := lat * math.Pi / 180
+	lonRad := (adjustedLon + rotation*180/math.Pi) * math.Pi / 180
+
+	x = math.Cos(latRad) * math.Cos(lonRad)
+	y = math.Sin(latRad)
+	z := math.Cos(latRad) * math.Sin(lonRad)
+	return x, y, z >= 0
+}
+
+// inverse finds the point of the earth at nx, ny on the projection's plane,
+// in globe radii with ny growing downwards. lat comes back with the sign
+// sampleEarthAt and CountryAt index the bitmaps by, north negative. view is
+// the rotation the point is seen with, which lighting depends on.
+func (g *Globe) inverse(nx, ny, rotation float64) (lat, lon, view float64, ok bool) {
+	switch g.Projection {
+	case Equirectangular:
+		if math.Abs(nx) > 2 || math.Abs(ny) > 1 {
+			return 0, 0, 0, false
+		}
+		return ny * 90, nx * 90, rotation, true
+	case Mollweide:
+		if nx*nx/4+ny*ny > 1 {
+			return 0, 0, 0, false
+		}
+		theta := math.Asin(ny)
+		cos := math.Cos(theta)
+		if cos < 1e-9 {
+			return math.Copysign(90, ny), 0, rotation, true
+		}
+		lat = math.Asin((2*theta+math.Sin(2*theta))/math.Pi) * 180 / math.Pi
+		lon = math.Pi * nx / (2 * cos) * 180 / math.Pi
+		if math.Abs(lon) > 180 {
+			return 0, 0, 0, false
+		}
+		return lat, lon, rotation, true
+	case Hemispheres:
+		if nx < 0 {
+			nx += hemisphereOffset
+		} else {
+			nx -= hemisphereOffset
+			rotation += math.Pi
+		}
+	}
+
+	lat, lon, ok = orthographicInverse(nx, ny, rotation)
+	return lat, lon, rotation, ok
+}
+
+func orthographicInverse(nx, ny, rotation float64) (lat, lon float64, ok bool) {
+	nzSquared := 1 - nx*nx - ny*ny
+	if nzSquared < 0 {
+		return 0, 0, false
+	}
+	nz := math.Sqrt(nzSquared)
+
+	lat = math.Asin(ny) * 180 / math.Pi
+	lon = wrapLon(math.Atan2(nx, nz)*180/math.Pi + rotation*180/math.Pi)
+	return lat, lon, true
+}
+
+// onOutline reports whether nx, ny (as for inverse) is on the edge of the
+// projection, within half a cell of it, for the outline drawn around it
+func (g *Globe) onOutline(nx, ny float64) bool {
+	cell := 0.5 / g.Scale()
+	switch g.Projection {
+	case Equirectangular:
+		// Rows are AspectRatio cells apart, so the top and bottom edges
+		// need a band that tall to land on one
+		rows := cell * g.AspectRatio
+		ax, ay := math.Abs(nx), math.Abs(ny)
+		return (math.Abs(ax-2) < cell && ay < 1+rows) || (math.Abs(ay-1) < rows && ax < 2+cell)
+	case Mollweide:
+		return math.Abs(math.Sqrt(nx*nx/4+ny*ny)-1) < cell
+	case Hemispheres:
+		nx = math.Abs(nx) - hemisphereOffset
+	}
+	return math.Abs(math.Sqrt(nx*nx+ny*ny)-1) < cell
+}
+
+// FocusNudge is the nudge that brings lat, lon to the middle of the view,
+// once the sphere has turned to face lon. With two hemispheres that is the
+// middle of the near one.
+func (g *Globe) FocusNudge(lat, lon float64) (float64, float64) {
+	s := g.Scale()
+	switch g.Projection {
+	case Orthographic:
+		return 0, math.Sin(lat*math.Pi/180) * s / g.AspectRatio
+	case Hemispheres:
+		return hemisphereOffset * s, math.Sin(lat*math.Pi/180) * s / g.AspectRatio
+	}
+	x, y, _ := g.forward(lat, lon, 0)
+	return -x * s, y * s / g.AspectRatio
+}
+
+// mollweideTheta solves 2θ + sin 2θ = π sin φ for the auxiliary angle of
+// the Mollweide projection by Newton's method
+func mollweideTheta(phi float64) float64 {
+	if math.Abs(phi) >= math.Pi/2-1e-9 {
+		return math.Copysign(math.Pi/2, phi)
+	}
+	theta := phi
+	target := math.Pi * math.Sin(phi)
+	for range 10 {
+		f := 2*theta + math.Sin(2*theta) - target
+		theta -= f / (2 + 2*math.Cos(2*theta))
+		if math.Abs(f) < 1e-9 {
+			break
+		}
+	}
+	return theta
+}
+
+// wrapLon brings a longitude into -180 to 180
+func wrapLon(lon float64) float64 {
+	for lon < -180 {
+		lon += 360
+	}
+	for lon > 180 {
+		lon -= 360
+	}
+	return lon
+}
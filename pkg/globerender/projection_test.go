@@ -0,0 +1,174 @@
+package globerender
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseProjection(t *testing.T) {
+	for i, name := range ProjectionNames {
+		p, ok := ParseProjection(name)
+		if !ok || p != Projection(i) {
+			t.Errorf("ParseProjection(%q) = %v, %v, want %v", name, p, ok, Projection(i))
+		}
+		if p.String() != name {
+			t.Errorf("%v.String() = %q, want %q", p, p.String(), name)
+		}
+	}
+	if _, ok := ParseProjection("mercator"); ok {
+		t.Error("ParseProjection(mercator) succeeded")
+	}
+	if s := Projection(len(ProjectionNames)).String(); s != "unknown" {
+		t.Errorf("out of range String() = %q, want unknown", s)
+	}
+}
+
+// TestProjectionRoundTrip checks that inverse undoes forward for every
+// projection. inverse takes y growing downwards and returns latitudes north
+// negative, the way the bitmaps are indexed.
+func TestProjectionRoundTrip(t *testing.T) {
+	for p := range Projection(len(ProjectionNames)) {
+		g := New(80, 40, 2, CharsetASCII)
+		g.Projection = p
+		for _, rotation := range []float64{0, 1, math.Pi, 4.5} {
+			for lat := -80.0; lat <= 80; lat += 20 {
+				for lon := -170.0; lon <= 170; lon += 17 {
+					x, y, visible := g.forward(lat, lon, rotation)
+					if !visible {
+						continue
+					}
+					gotLat, gotLon, _, ok := g.inverse(x, -y, rotation)
+					if !ok {
+						t.Errorf("%v rotation %v: inverse of (%v, %v) failed", p, rotation, lat, lon)
+						continue
+					}
+					dLon := math.Abs(wrapLon(gotLon - lon))
+					if math.Abs(-gotLat-lat) > 1e-6 || dLon > 1e-6 {
+						t.Errorf("%v rotation %v: (%v, %v) came back as (%v, %v)", p, rotation, lat, lon, -gotLat, gotLon)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestOrthographicVisibility(t *testing.T) {
+	tests := []struct {
+		lat, lon, rotation float64
+		visible            bool
+	}{
+		{0, 0, 0, true},
+		{45, 60, 0, true},
+		{0, 180, 0, false},
+		{0, -120, 0, false},
+		{0, 90, math.Pi / 2, true},
+		{0, -90, math.Pi / 2, false},
+		{90, 0, 0, true}, // The pole is on the rim
+	}
+	for _, tt := range tests {
+		if _, _, visible := orthographicForward(tt.lat, tt.lon, tt.rotation); visible != tt.visible {
+			t.Errorf("orthographicForward(%v, %v, %v) visible = %v, want %v", tt.lat, tt.lon, tt.rotation, visible, tt.visible)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	g := New(80, 40, 2, CharsetASCII)
+	cx, cy := g.Width/2, g.Height/2
+
+	// The point facing the viewer is in the middle, whichever way the
+	// globe has turned
+	for _, lon := range []float64{0, 45, -100} {
+		x, y, ok := g.screenPoint(0, lon, lon*math.Pi/180)
+		if !ok || math.Abs(x-float64(cx)) > 1e-9 || math.Abs(y-float64(cy)) > 1e-9 {
+			t.Errorf("lon %v facing the viewer is at %v, %v, %v, want %d, %d", lon, x, y, ok, cx, cy)
+		}
+	}
+
+	// North is up, east is right
+	_, northY, _ := g.Project(30, 0, 0)
+	_, southY, _ := g.Project(-30, 0, 0)
+	eastX, _, _ := g.Project(0, 30, 0)
+	westX, _, _ := g.Project(0, -30, 0)
+	if northY >= cy || southY <= cy || eastX <= cx || westX >= cx {
+		t.Errorf("north %d south %d east %d west %d around %d, %d", northY, southY, eastX, westX, cx, cy)
+	}
+
+	if _, _, ok := g.Project(0, 180, 0); ok {
+		t.Error("the far side is visible")
+	}
+
+	// The nudge moves the picture, zoom spreads it out
+	g.NudgeX, g.NudgeY = 5, -3
+	if x, y, _ := g.Project(0, 0, 0); x != cx+5 || y != cy-3 {
+		t.Errorf("nudged center = %d, %d, want %d, %d", x, y, cx+5, cy-3)
+	}
+	g.NudgeX, g.NudgeY = 0, 0
+	before, _, _ := g.Project(0, 20, 0)
+	g.Zoom = 2
+	after, _, _ := g.Project(0, 20, 0)
+	if after-cx <= before-cx {
+		t.Errorf("zooming in moved lon 20 from column %d to %d", before, after)
+	}
+}
+
+func TestProjectFlat(t *testing.T) {
+	g := New(100, 30, 2, CharsetASCII)
+	g.Projection = Equirectangular
+	x0, y0, _ := g.Project(0, 0, 0)
+	x1, y1, ok := g.Project(0, 0, 2)
+	if !ok || x0 != x1 || y0 != y1 {
+		t.Errorf("a flat map turned: %d, %d then %d, %d", x0, y0, x1, y1)
+	}
+
+	// The whole earth is in view, with the antimeridian at the edges
+	west, _, okWest := g.Project(0, -179, 0)
+	east, _, okEast := g.Project(0, 179, 0)
+	if !okWest || !okEast || west > 5 || east < g.Width-6 {
+		t.Errorf("antimeridian at columns %d (%v) and %d (%v) of %d", west, okWest, east, okEast, g.Width)
+	}
+}
+
+func TestProjectSubCell(t *testing.T) {
+	g := New(80, 40, 2, CharsetBraille)
+	x, y, ok := g.Project(10, 20, 0.3)
+	sx, sy, dotX, dotY, subOK := g.ProjectSubCell(10, 20, 0.3)
+	if ok != subOK || x != sx || y != sy {
+		t.Errorf("ProjectSubCell cell %d, %d, %v, want Project's %d, %d, %v", sx, sy, subOK, x, y, ok)
+	}
+	if dotX < 0 || dotX > 1 || dotY < 0 || dotY > 3 {
+		t.Errorf("dot %d, %d outside the 2x4 Braille cell", dotX, dotY)
+	}
+
+	_, _, r, _ := g.MarkerCell(10, 20, 0.3, true)
+	if !IsBraille(r) || r == 0x2800 {
+		t.Errorf("sub-cell marker %q is not a Braille dot pattern", r)
+	}
+	if _, _, r, _ := g.MarkerCell(10, 20, 0.3, false); r != '*' {
+		t.Errorf("whole-cell marker = %q, want *", r)
+	}
+}
+
+func TestMollweideTheta(t *testing.T) {
+	for _, lat := range []float64{-89.9, -60, -10, 0, 33, 75, 89.9} {
+		phi := lat * math.Pi / 180
+		theta := mollweideTheta(phi)
+		if diff := 2*theta + math.Sin(2*theta) - math.Pi*math.Sin(phi); math.Abs(diff) > 1e-6 {
+			t.Errorf("mollweideTheta(%v°) = %v, off by %v", lat, theta, diff)
+		}
+	}
+	if theta := mollweideTheta(math.Pi / 2); theta != math.Pi/2 {
+		t.Errorf("mollweideTheta at the pole = %v, want π/2", theta)
+	}
+}
+
+func TestWrapLon(t *testing.T) {
+	tests := []struct{ lon, want float64 }{
+		{0, 0}, {180, 180}, {-180, -180}, {190, -170}, {-190, 170}, {725, 5},
+	}
+	for _, tt := range tests {
+		if got := wrapLon(tt.lon); got != tt.want {
+			t.Errorf("wrapLon(%v) = %v, want %v", tt.lon, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package globerender
+
+// RGB is a 24-bit color
+type RGB struct {
+	R, G, B uint8
+}
+
+// Palette colors the kinds of cell a frame holds
+type Palette struct {
+	Land   RGB
+	Arc    RGB
+	Marker RGB
+	Glyph  RGB // Markers drawn with their own glyph
+	Crowd  RGB // Markers of cells several attackers share
+	Shades [ShadeLevels]RGB
+}
+
+// DefaultPalette is green land with red attacks and orange arcs, shading
+// countries from green towards red
+var DefaultPalette = Palette{
+	Land:   RGB{0, 128, 0},
+	Arc:    RGB{255, 150, 0},
+	Marker: RGB{255, 0, 0},
+	Glyph:  RGB{255, 100, 100},
+	Crowd:  RGB{255, 160, 160},
+	Shades: [ShadeLevels]RGB{{64, 96, 0}, {128, 64, 0}, {191, 32, 0}, {255, 0, 0}},
+}
+
+// Cell is one rendered character with its color
+type Cell struct {
+	Rune  rune
+	Kind  Kind
+	Color RGB
+	Bold  bool // Markers are bold
+}
+
+// Options are everything about a frame besides its size, rotation and
+// markers. The zero value draws a plain ASCII globe with no arcs.
+type Options struct {
+	AspectRatio    float64 // Cell height over width, 2 when zero
+	Charset        Charset
+	Projection     Projection
+	Zoom           float64 // 1 when zero
+	NudgeX, NudgeY float64 // Offset of the globe's center in cells
+	Lighting       bool    // Shade land by a light at LightLat/LightLon
+	LightLat       float64
+	LightLon       float64
+	LightFollow    bool // The light stays fixed while the globe turns under it
+	SubCell        bool // Place markers and arcs on Braille dots with the Braille charset
+	Supersample    bool // Average 2x2 samples per cell for smoother coastlines
+	ProtocolGlyphs bool // Markers carry their own glyphs
+	Arcs           []Arc
+	ArcStyle       string         // "curved", "straight" or "off" (the default)
+	Shades         map[string]int // Shade level by country code
+	Palette        *Palette       // DefaultPalette when nil
+}
+
+// Render draws a width x height globe turned by rotation radians. Empty
+// cells hold a space.
+func Render(width, height int, rotation float64, markers []Marker, opts Options) [][]Cell {
+	if opts.AspectRatio == 0 {
+		opts.AspectRatio = 2
+	}
+	globe := New(width, height, opts.AspectRatio, opts.Charset)
+	if opts.Zoom != 0 {
+		globe.Zoom = opts.Zoom
+	}
+	globe.NudgeX, globe.NudgeY = opts.NudgeX, opts.NudgeY
+	globe.Projection = opts.Projection
+	globe.Lighting, globe.LightLat, globe.LightLon, globe.LightFollow = opts.Lighting, opts.LightLat, opts.LightLon, opts.LightFollow
+	globe.SubCell = opts.SubCell
+	globe.Supersample = opts.Supersample
+	arcStyle := opts.ArcStyle
+	if arcStyle == "" {
+		arcStyle = "off"
+	}
+	palette := opts.Palette
+	if palette == nil {
+		palette = &DefaultPalette
+	}
+
+	runes, kinds := globe.Raster(rotation, markers, opts.Arcs, arcStyle, opts.ProtocolGlyphs, opts.Shades)
+	cells := make([][]Cell, len(runes))
+	for y, row := range runes {
+		cells[y] = make([]Cell, len(row))
+		for x, r := range row {
+			cell := Cell{Rune: r, Kind: kinds[y][x], Color: palette.Land}
+			switch {
+			case cell.Kind == KindCrowd:
+				cell.Color, cell.Bold = palette.Crowd, true
+			case cell.Kind == KindMarker && opts.ProtocolGlyphs && r != '*':
+				cell.Color, cell.Bold = palette.Glyph, true
+			case cell.Kind == KindMarker:
+				cell.Color, cell.Bold = palette.Marker, true
+			case cell.Kind == KindArc:
+				cell.Color = palette.Arc
+			case cell.Kind >= KindShade:
+				cell.Color = palette.Shades[min(int(cell.Kind-KindShade), ShadeLevels-1)]
+			}
+			cells[y][x] = cell
+		}
+	}
+	return cells
+}
@@ -0,0 +1,197 @@
+package globerender
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden frames in testdata")
+
+// goldenMarkers are attack sources spread over several continents, with a
+// crowd of three in one city
+var goldenMarkers = []Marker{
+	{Lat: 39.9, Lon: 116.4},  // Beijing
+	{Lat: 55.8, Lon: 37.6},   // Moscow
+	{Lat: -23.5, Lon: -46.6}, // São Paulo
+	{Lat: 52.5, Lon: 13.4},   // Berlin
+	{Lat: 52.5, Lon: 13.4},
+	{Lat: 52.5, Lon: 13.4},
+	{Lat: 40.7, Lon: -74.0, Glyph: 'S', Rank: 1}, // New York
+	{Lat: 6.5, Lon: 3.4},                         // Lagos
+}
+
+// goldenArcs run from some of the markers to a honeypot in Kansas City
+var goldenArcs = []Arc{
+	{SrcLat: 55.8, SrcLon: 37.6, DstLat: 39.1, DstLon: -94.6, Fade: 1},
+	{SrcLat: -23.5, SrcLon: -46.6, DstLat: 39.1, DstLon: -94.6, Fade: 0.5},
+	{SrcLat: 52.5, SrcLon: 13.4, DstLat: 39.1, DstLon: -94.6, Fade: 1},
+}
+
+var goldenFrames = []struct {
+	name     string
+	width    int
+	height   int
+	rotation float64
+	opts     Options
+}{
+	{"ascii", 80, 24, 1.2, Options{ArcStyle: "curved", Arcs: goldenArcs}},
+	{"blocks-lit", 80, 24, 1.2, Options{Charset: CharsetBlocks, Lighting: true, LightLon: -30, LightLat: 20, ArcStyle: "straight", Arcs: goldenArcs}},
+	{"braille-subcell", 80, 24, 1.2, Options{Charset: CharsetBraille, SubCell: true, ArcStyle: "curved", Arcs: goldenArcs}},
+	{"glyphs-zoomed", 60, 20, 0.4, Options{Charset: CharsetBraille, Zoom: 1.8, NudgeX: -6, NudgeY: 3, ProtocolGlyphs: true}},
+	{"flat-shaded", 100, 30, 0, Options{Charset: CharsetBlocks, Projection: Equirectangular, Shades: map[string]int{"US": 4, "CN": 3, "RU": 2, "BR": 1}}},
+	{"mollweide-supersampled", 100, 30, 0, Options{Projection: Mollweide, Supersample: true, ArcStyle: "curved", Arcs: goldenArcs}},
+	{"hemispheres", 100, 30, 2.5, Options{Charset: CharsetBlocks, Projection: Hemispheres}},
+}
+
+// colorCodes names the palette colors in golden frames. Markers are bold,
+// which tells them from land shaded in the same color.
+var colorCodes = map[bool]map[RGB]byte{
+	false: {
+		DefaultPalette.Land:      'l',
+		DefaultPalette.Arc:       'a',
+		DefaultPalette.Shades[0]: '1',
+		DefaultPalette.Shades[1]: '2',
+		DefaultPalette.Shades[2]: '3',
+		DefaultPalette.Shades[3]: '4',
+	},
+	true: {
+		DefaultPalette.Marker: 'm',
+		DefaultPalette.Glyph:  'g',
+		DefaultPalette.Crowd:  'c',
+	},
+}
+
+// showFrame draws cells on a simulated terminal and reads the screen back:
+// the characters, then a line of color codes for every row
+func showFrame(t *testing.T, cells [][]Cell, width, height int) string {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(width, height)
+	for y, row := range cells {
+		for x, cell := range row {
+			style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(int32(cell.Color.R), int32(cell.Color.G), int32(cell.Color.B))).Bold(cell.Bold)
+			screen.SetContent(x, y, cell.Rune, nil, style)
+		}
+	}
+	screen.Show()
+
+	contents, w, h := screen.GetContents()
+	var text, colors strings.Builder
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			cell := contents[y*w+x]
+			r := ' '
+			if len(cell.Runes) > 0 {
+				r = cell.Runes[0]
+			}
+			text.WriteRune(r)
+
+			code := byte(' ')
+			if r != ' ' {
+				fg, _, attrs := cell.Style.Decompose()
+				cr, cg, cb := fg.RGB()
+				var ok bool
+				bold := attrs&tcell.AttrBold != 0
+				if code, ok = colorCodes[bold][RGB{uint8(cr), uint8(cg), uint8(cb)}]; !ok {
+					code = '?'
+				}
+			}
+			colors.WriteByte(code)
+		}
+		text.WriteByte('\n')
+		colors.WriteByte('\n')
+	}
+	return text.String() + "\n" + colors.String()
+}
+
+// TestRenderGolden renders fixed frames and compares what a terminal shows
+// with testdata/<name>.golden. Run go test -update to accept changes.
+func TestRenderGolden(t *testing.T) {
+	for _, tt := range goldenFrames {
+		t.Run(tt.name, func(t *testing.T) {
+			cells := Render(tt.width, tt.height, tt.rotation, goldenMarkers, tt.opts)
+			if len(cells) != tt.height || len(cells[0]) != tt.width {
+				t.Fatalf("frame is %dx%d, want %dx%d", len(cells[0]), len(cells), tt.width, tt.height)
+			}
+			got := showFrame(t, cells, tt.width, tt.height)
+
+			path := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if got != string(want) {
+				gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+				for i := range min(len(gotLines), len(wantLines)) {
+					if gotLines[i] != wantLines[i] {
+						t.Errorf("line %d differs from %s:\n got: %q\nwant: %q", i+1, path, gotLines[i], wantLines[i])
+						break
+					}
+				}
+				t.Errorf("frame differs from %s; run go test -update if the change is intended", path)
+			}
+		})
+	}
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	opts := Options{Charset: CharsetBraille, Lighting: true, ArcStyle: "curved", Arcs: goldenArcs}
+	first := Render(120, 40, 0.7, goldenMarkers, opts)
+	for range 3 {
+		again := Render(120, 40, 0.7, goldenMarkers, opts)
+		for y := range first {
+			for x := range first[y] {
+				if first[y][x] != again[y][x] {
+					t.Fatalf("cell %d, %d changed between renders: %+v then %+v", x, y, first[y][x], again[y][x])
+				}
+			}
+		}
+	}
+}
+
+func TestRenderKinds(t *testing.T) {
+	cells := Render(100, 30, 0, goldenMarkers, Options{Projection: Equirectangular, ProtocolGlyphs: true, ArcStyle: "curved", Arcs: goldenArcs})
+	counts := make(map[Kind]int)
+	var glyph bool
+	for _, row := range cells {
+		for _, cell := range row {
+			counts[cell.Kind]++
+			if cell.Rune == 'S' {
+				glyph = cell.Kind == KindMarker && cell.Color == DefaultPalette.Glyph && cell.Bold
+			}
+		}
+	}
+	if counts[KindArc] == 0 {
+		t.Error("no arc cells")
+	}
+	if counts[KindCrowd] != 1 {
+		t.Errorf("%d crowd cells, want 1 for the three markers in Berlin", counts[KindCrowd])
+	}
+	if !glyph {
+		t.Error("the ranked marker's glyph is not drawn as a bold glyph marker")
+	}
+
+	// With arcs off nothing is drawn as an arc
+	cells = Render(80, 24, 1.2, goldenMarkers, Options{Arcs: goldenArcs})
+	for _, row := range cells {
+		for _, cell := range row {
+			if cell.Kind == KindArc {
+				t.Fatal("arc drawn with the default arc style off")
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package mhn reads the SecKC MHN (Modern Honey Network) API: recent
+// honeypot events, geocoding of attacking addresses and hourly attack
+// counts. Parse turns an event from any of the MHN feeds into the fields a
+// display needs.
+package mhn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public SecKC MHN API
+const DefaultBaseURL = "https://mhn.h-i-r.net/seckcapi"
+
+// Event is one honeypot event as the events API returns it
+type Event struct {
+	Event     json.RawMessage `json:"event"` // Decoded by Parse
+	Timestamp float64         `json:"timestamp"`
+	CachedAt  string          `json:"cached_at"`
+	ID        json.RawMessage `json:"id,omitempty"` // Server's event ID, string or number, when it sends one
+}
+
+// Key identifies the event for spotting duplicates: the server's ID when it
+// sends one, otherwise a hash of the timestamp and the event's content.
+// Two events in the same second from the same address have different keys
+// unless every field matches.
+func (e Event) Key() string {
+	if id := strings.Trim(string(e.ID), `"`); id != "" && id != "null" {
+		return "id:" + id
+	}
+	raw := []byte(e.Event)
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) == nil {
+		raw = compact.Bytes()
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%.6f|", e.Timestamp)
+	hash.Write(raw)
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// EventsResponse is the body of /feeds/events/recent
+type EventsResponse struct {
+	Events        []Event `json:"events"`
+	Count         int     `json:"count"`
+	Authenticated bool    `json:"authenticated"`
+	ServerTime    float64 `json:"server_time"`
+}
+
+// GeocodeResponse is the body of /geocode/<ip>, a MaxMind city record
+type GeocodeResponse struct {
+	City struct {
+		Names map[string]string `json:"names"`
+	} `json:"city"`
+	Country struct {
+		ISOCode string            `json:"iso_code"`
+		Names   map[string]string `json:"names"`
+	} `json:"country"`
+	Location struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"location"`
+}
+
+// HourlyStats is one channel's attack counts by hour ("0" to "23") of a day
+type HourlyStats struct {
+	Date    string         `json:"date"`
+	Hourly  map[string]int `json:"hourly"`
+	Channel string         `json:"channel"`
+}
+
+type StatsResponse []HourlyStats
+
+// Client makes plain, anonymous requests to an MHN API
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for the API at baseURL with a 10 second timeout
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Events returns up to limit events newer than since (a Unix time), or the
+// latest ones when since is 0
+func (c *Client) Events(since float64, limit int) ([]Event, error) {
+	url := fmt.Sprintf("%s/feeds/events/recent?limit=%d", c.BaseURL, limit)
+	if since > 0 {
+		url = fmt.Sprintf("%s/feeds/events/recent?since=%.1f&limit=%d", c.BaseURL, since, limit)
+	}
+	var resp EventsResponse
+	if err := c.get(url, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get events: %v", err)
+	}
+	return resp.Events, nil
+}
+
+// Geocode locates an IP address
+func (c *Client) Geocode(ip string) (GeocodeResponse, error) {
+	var resp GeocodeResponse
+	err := c.get(fmt.Sprintf("%s/geocode/%s", c.BaseURL, ip), &resp)
+	return resp, err
+}
+
+// Stats returns the hourly attack counts of a day
+func (c *Client) Stats(day time.Time) (StatsResponse, error) {
+	var resp StatsResponse
+	err := c.get(fmt.Sprintf("%s/stats/attacks?date=%s", c.BaseURL, day.Format("20060102")), &resp)
+	return resp, err
+}
+
+func (c *Client) get(url string, v interface{}) error {
+	resp, err := c.HTTP.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
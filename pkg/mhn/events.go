@@ -0,0 +1,546 @@
+package mhn
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultChannel parses events whose feed is neither named nor
+// recognized; its fields (src_ip, username/password, protocol) are the ones
+// most feeds share
+const DefaultChannel = "cowrie.sessions"
+
+// EventFields is what the dashboard takes from a honeypot event, whichever
+// feed it came from
+type EventFields struct {
+	Channel  string
+	SrcIP    string
+	Username string
+	Password string
+	Protocol string
+	Sensor   string         // Reporting sensor, empty when the feed does not say
+	DestPort int            // 0 when the sensor did not report it
+	Session  *SessionDetail // Cowrie session detail, nil when there is none
+	Feed     *FeedDetail    // What dionaea, p0f or suricata add, nil for other feeds
+}
+
+// Service is the protocol the event names or, when it names none, the one
+// usually found on its destination port
+func (f EventFields) Service() string {
+	if f.Protocol != "" {
+		return f.Protocol
+	}
+	service, _ := InferProtocol(f.DestPort)
+	return service
+}
+
+// HoneypotEvent is one feed's event decoded into its own typed fields
+type HoneypotEvent interface {
+	Fields() EventFields
+}
+
+// EventParser decodes the JSON of one channel's events
+type EventParser func(data []byte) (HoneypotEvent, error)
+
+// parsers decodes the events of each MHN channel. Feeds that do not name the
+// channel are recognized by their fields, see eventChannel.
+var parsers = map[string]EventParser{
+	"cowrie.sessions":     parseCowrieEvent,
+	"kippo.sessions":      parseCowrieEvent,
+	"dionaea.connections": parseDionaeaEvent,
+	"dionaea.capture":     parseDionaeaCaptureEvent,
+	"p0f.events":          parseP0fEvent,
+	"suricata.events":     parseSuricataEvent,
+}
+
+// RegisterParser adds or replaces the parser of a channel. Register parsers
+// before events arrive; the registry is not locked.
+func RegisterParser(channel string, parser EventParser) {
+	parsers[channel] = parser
+}
+
+// PortField is a port sent either as a number or as a string. Anything
+// else, or a number outside 1-65535, reads as 0.
+type PortField int
+
+func (p *PortField) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if json.Unmarshal(data, &value) != nil {
+		return nil
+	}
+	switch value := value.(type) {
+	case float64:
+		if value > 0 && value < 65536 {
+			*p = PortField(value)
+		}
+	case string:
+		if port, err := strconv.Atoi(value); err == nil && port > 0 && port < 65536 {
+			*p = PortField(port)
+		}
+	}
+	return nil
+}
+
+// Source holds the sensor and port fields any feed may carry. Parsers of
+// other feeds embed it.
+type Source struct {
+	Channel         string    `json:"channel"`
+	SensorName      string    `json:"sensor"`
+	Hostname        string    `json:"hostname"`
+	Honeypot        string    `json:"honeypot"`
+	DestPort        PortField `json:"dest_port"`
+	DstPort         PortField `json:"dst_port"`
+	DestinationPort PortField `json:"destination_port"`
+}
+
+// Sensor names the sensor that reported the event, or "" when the feed does
+// not say
+func (s Source) Sensor() string {
+	return cmp.Or(s.SensorName, s.Hostname, s.Honeypot)
+}
+
+// Port returns the honeypot port the event was aimed at, or 0
+func (s Source) Port() int {
+	return int(cmp.Or(s.DestPort, s.DstPort, s.DestinationPort))
+}
+
+// CowrieEvent is a Cowrie (or Kippo) session as MHN publishes it, also the
+// shape of the generic credential events other feeds send
+type CowrieEvent struct {
+	Source
+	SrcIP           string   `json:"src_ip"`
+	PeerIP          string   `json:"peerIP"`
+	LoggedIn        []string `json:"loggedin"` // Username and password of the successful login
+	Username        string   `json:"username"`
+	Password        string   `json:"password"`
+	Protocol        string   `json:"protocol"`
+	Session         string   `json:"session"`
+	StartTime       string   `json:"startTime"`
+	EndTime         string   `json:"endTime"`
+	Version         string   `json:"version"`
+	Commands        []string `json:"commands"`
+	UnknownCommands []string `json:"unknownCommands"`
+	URLs            []string `json:"urls"`
+	Hashes          []string `json:"hashes"`
+}
+
+func parseCowrieEvent(data []byte) (HoneypotEvent, error) {
+	var event CowrieEvent
+	return &event, Decode(data, &event)
+}
+
+func (e *CowrieEvent) Fields() EventFields {
+	fields := EventFields{
+		SrcIP:    cmp.Or(e.SrcIP, e.PeerIP),
+		Protocol: e.Protocol,
+		Sensor:   e.Sensor(),
+		DestPort: e.Port(),
+	}
+	if len(e.LoggedIn) >= 2 {
+		fields.Username, fields.Password = e.LoggedIn[0], e.LoggedIn[1]
+	}
+	fields.Username = cmp.Or(fields.Username, e.Username)
+	fields.Password = cmp.Or(fields.Password, e.Password)
+
+	detail := &SessionDetail{
+		ID:              e.Session,
+		Start:           e.StartTime,
+		End:             e.EndTime,
+		Version:         e.Version,
+		Commands:        nonEmpty(e.Commands),
+		UnknownCommands: nonEmpty(e.UnknownCommands),
+		URLs:            nonEmpty(e.URLs),
+		Hashes:          nonEmpty(e.Hashes),
+	}
+	if detail.ID != "" || detail.Interactive() {
+		fields.Session = detail
+	}
+	return fields
+}
+
+// DionaeaEvent is a dionaea.connections event, a connection to one of
+// dionaea's emulated services
+type DionaeaEvent struct {
+	Source
+	RemoteHost string    `json:"remote_host"`
+	LocalPort  PortField `json:"local_port"`
+	Transport  string    `json:"connection_transport"`
+	Service    string    `json:"connection_protocol"` // Dionaea's handler, e.g. "smbd"
+}
+
+// dionaeaServices renames dionaea's protocol handlers to the protocol names
+// the other feeds use
+var dionaeaServices = map[string]string{
+	"smbd": "smb", "httpd": "http", "ftpd": "ftp", "tftpd": "tftp",
+	"mssqld": "mssql", "mysqld": "mysql", "sipsession": "sip", "epmapper": "msrpc",
+}
+
+func parseDionaeaEvent(data []byte) (HoneypotEvent, error) {
+	var event DionaeaEvent
+	return &event, Decode(data, &event)
+}
+
+func (e *DionaeaEvent) Fields() EventFields {
+	port := cmp.Or(int(e.LocalPort), e.Port())
+	protocol := strings.ToLower(e.Service)
+	if service, ok := dionaeaServices[protocol]; ok {
+		protocol = service
+	} else if _, known := ServicePorts[port]; protocol == "" && port > 0 && !known {
+		// Well known ports are left for the dashboard to infer, which marks
+		// the protocol as a guess
+		protocol = GuessProtocol(port, e.Transport)
+	}
+	return EventFields{SrcIP: e.RemoteHost, Protocol: protocol, Sensor: e.Sensor(), DestPort: port,
+		Feed: &FeedDetail{Honeypot: "dionaea"}}
+}
+
+// DionaeaCaptureEvent is a dionaea.capture event, a malware binary dionaea
+// downloaded or was sent by an attacker
+type DionaeaCaptureEvent struct {
+	Source
+	SAddr  string    `json:"saddr"`
+	DPort  PortField `json:"dport"`
+	URL    string    `json:"url"`
+	MD5    string    `json:"md5"`
+	SHA512 string    `json:"sha512"`
+}
+
+func parseDionaeaCaptureEvent(data []byte) (HoneypotEvent, error) {
+	var event DionaeaCaptureEvent
+	return &event, Decode(data, &event)
+}
+
+func (e *DionaeaCaptureEvent) Fields() EventFields {
+	port := cmp.Or(int(e.DPort), e.Port())
+	return EventFields{SrcIP: e.SAddr, Sensor: e.Sensor(), DestPort: port,
+		Feed: &FeedDetail{Honeypot: "dionaea", URL: e.URL, MD5: e.MD5, SHA512: e.SHA512}}
+}
+
+// P0fEvent is a p0f.events passive fingerprint of a connecting client
+type P0fEvent struct {
+	Source
+	ClientIP   string    `json:"client_ip"`
+	ServerPort PortField `json:"server_port"`
+	OS         string    `json:"os"`
+	Dist       string    `json:"dist"` // Network distance in hops
+	Link       string    `json:"link"` // Link type guessed from the MTU, e.g. "Ethernet or modem"
+}
+
+func parseP0fEvent(data []byte) (HoneypotEvent, error) {
+	var event P0fEvent
+	return &event, Decode(data, &event)
+}
+
+func (e *P0fEvent) Fields() EventFields {
+	port := cmp.Or(int(e.ServerPort), e.Port())
+	fields := EventFields{SrcIP: e.ClientIP, Sensor: e.Sensor(), DestPort: port,
+		Feed: &FeedDetail{Honeypot: "p0f", OS: e.OS, Distance: e.Dist, Link: e.Link}}
+	if _, known := ServicePorts[port]; port > 0 && !known {
+		fields.Protocol = GuessProtocol(port, "tcp")
+	}
+	return fields
+}
+
+// SuricataEvent is a suricata.events IDS alert in Suricata's EVE format
+type SuricataEvent struct {
+	Source
+	SrcIP    string `json:"src_ip"`
+	Proto    string `json:"proto"`     // Transport, e.g. "TCP"
+	AppProto string `json:"app_proto"` // Application protocol Suricata detected
+	Alert    struct {
+		Signature   string `json:"signature"`
+		SignatureID int    `json:"signature_id"`
+		Severity    int    `json:"severity"`
+		Category    string `json:"category"`
+	} `json:"alert"`
+}
+
+func parseSuricataEvent(data []byte) (HoneypotEvent, error) {
+	var event SuricataEvent
+	return &event, Decode(data, &event)
+}
+
+func (e *SuricataEvent) Fields() EventFields {
+	port := e.Port()
+	protocol := strings.ToLower(e.AppProto)
+	if protocol == "failed" {
+		protocol = ""
+	}
+	if _, known := ServicePorts[port]; protocol == "" && port > 0 && !known {
+		protocol = GuessProtocol(port, e.Proto)
+	}
+	return EventFields{SrcIP: e.SrcIP, Protocol: protocol, Sensor: e.Sensor(), DestPort: port,
+		Feed: &FeedDetail{
+			Honeypot:    "suricata",
+			Signature:   e.Alert.Signature,
+			SignatureID: e.Alert.SignatureID,
+			Severity:    e.Alert.Severity,
+			Category:    e.Alert.Category,
+		}}
+}
+
+// Decode unmarshals an event into its typed struct. Feeds are loose about
+// types, so a field of the wrong type is left empty instead of failing the
+// whole event.
+func Decode(data []byte, event HoneypotEvent) error {
+	err := json.Unmarshal(data, event)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return nil
+	}
+	return err
+}
+
+// eventChannel names the channel of an event: the one it carries when a
+// parser is registered for it, otherwise the one its fields point to
+func eventChannel(data []byte) string {
+	var probe struct {
+		Channel    string          `json:"channel"`
+		RemoteHost string          `json:"remote_host"`
+		SAddr      string          `json:"saddr"`
+		ClientIP   string          `json:"client_ip"`
+		Alert      json.RawMessage `json:"alert"`
+	}
+	json.Unmarshal(data, &probe)
+	if _, ok := parsers[probe.Channel]; ok {
+		return probe.Channel
+	}
+	switch {
+	case probe.RemoteHost != "":
+		return "dionaea.connections"
+	case probe.SAddr != "":
+		return "dionaea.capture"
+	case probe.ClientIP != "":
+		return "p0f.events"
+	case len(probe.Alert) > 0 && string(probe.Alert) != "null":
+		return "suricata.events"
+	}
+	return DefaultChannel
+}
+
+// Parse decodes an event from any MHN feed. ok is false when
+// the event is not JSON or has no source IP. Events without credentials
+// show as a "connection" over their protocol.
+func Parse(data []byte) (fields EventFields, ok bool) {
+	channel := eventChannel(data)
+	event, err := parsers[channel](data)
+	if err != nil {
+		return fields, false
+	}
+	fields = event.Fields()
+	fields.Channel = channel
+	if fields.SrcIP == "" {
+		return fields, false
+	}
+
+	if service := fields.Service(); fields.Username == "" && fields.Password == "" && service != "" {
+		fields.Username, fields.Password = "connection", service
+	}
+	fields.Username = cmp.Or(fields.Username, "unknown")
+	fields.Password = cmp.Or(fields.Password, "unknown")
+	return fields, true
+}
+
+// nonEmpty drops the empty strings a list decodes to from values of the
+// wrong type
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// CowrieLogEvent is one line of a Cowrie json.log. Unlike the MHN feed,
+// which publishes whole sessions, every line is a single step of a session.
+type CowrieLogEvent struct {
+	Source
+	EventID   string `json:"eventid"`
+	Session   string `json:"session"`
+	Timestamp string `json:"timestamp"`
+	SrcIP     string `json:"src_ip"`
+	Protocol  string `json:"protocol"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Input     string `json:"input"`   // Command line of command events
+	URL       string `json:"url"`     // Source of file downloads
+	SHASum    string `json:"shasum"`  // Hash of downloaded or uploaded files
+	Version   string `json:"version"` // SSH client version
+}
+
+// SessionDetail is what a Cowrie session event records beyond the login:
+// the shell commands run, URLs fetched and hashes of downloaded files
+type SessionDetail struct {
+	ID              string
+	Start           string
+	End             string
+	Version         string // SSH client version string
+	Commands        []string
+	UnknownCommands []string // Commands the honeypot did not emulate
+	URLs            []string
+	Hashes          []string
+}
+
+// Duration is the session length once its end event has arrived
+func (s *SessionDetail) Duration() (time.Duration, bool) {
+	if s == nil || s.Start == "" || s.End == "" {
+		return 0, false
+	}
+	start, err := time.Parse(time.RFC3339Nano, s.Start)
+	if err != nil {
+		return 0, false
+	}
+	end, err := time.Parse(time.RFC3339Nano, s.End)
+	if err != nil || end.Before(start) {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
+
+// Badge summarizes the session for its dashboard row, e.g. "[2m5s 3 cmds] "
+func (s *SessionDetail) Badge() string {
+	if s == nil {
+		return ""
+	}
+	var parts []string
+	if d, ok := s.Duration(); ok {
+		parts = append(parts, d.Round(time.Second).String())
+	}
+	if n := len(s.Commands) + len(s.UnknownCommands); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d cmds", n))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(parts, " ") + "] "
+}
+
+// Merge returns a copy of s updated with a later event for the same session.
+// Rows share their detail with frame snapshots, so it is never changed in place.
+func (s *SessionDetail) Merge(update *SessionDetail) *SessionDetail {
+	merged := *s
+	if update.Start != "" {
+		merged.Start = update.Start
+	}
+	if update.End != "" {
+		merged.End = update.End
+	}
+	if update.Version != "" {
+		merged.Version = update.Version
+	}
+	// End events repeat the whole session, so the longer list is the newer one
+	longer := func(old, latest []string) []string {
+		if len(latest) >= len(old) {
+			return latest
+		}
+		return old
+	}
+	merged.Commands = longer(s.Commands, update.Commands)
+	merged.UnknownCommands = longer(s.UnknownCommands, update.UnknownCommands)
+	merged.URLs = longer(s.URLs, update.URLs)
+	merged.Hashes = longer(s.Hashes, update.Hashes)
+	return &merged
+}
+
+// Interactive reports whether the attacker did anything after logging in
+func (s *SessionDetail) Interactive() bool {
+	return s != nil && len(s.Commands)+len(s.UnknownCommands)+len(s.URLs)+len(s.Hashes) > 0
+}
+
+// DetailLines lays out the commands, URLs and hashes for the detail panel
+func (s *SessionDetail) DetailLines() []string {
+	var lines []string
+	section := func(title string, items []string, prefix string) {
+		if len(items) == 0 {
+			return
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d)", title, len(items)))
+		for _, item := range items {
+			lines = append(lines, prefix+SanitizeText(item))
+		}
+	}
+	section("COMMANDS", s.Commands, "$ ")
+	section("UNKNOWN COMMANDS", s.UnknownCommands, "$ ")
+	section("URLS", s.URLs, "  ")
+	section("HASHES", s.Hashes, "  ")
+	return lines
+}
+
+// FeedDetail is what the honeypots other than Cowrie report beyond the
+// shared fields: dionaea's captured malware, p0f's fingerprint of the
+// attacker's OS and the signature of a suricata alert
+type FeedDetail struct {
+	Honeypot    string `json:"honeypot"` // "dionaea", "p0f" or "suricata"
+	URL         string `json:"url,omitempty"`
+	MD5         string `json:"md5,omitempty"`
+	SHA512      string `json:"sha512,omitempty"`
+	OS          string `json:"os,omitempty"`
+	Distance    string `json:"distance,omitempty"` // Network hops to the attacker
+	Link        string `json:"link,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	SignatureID int    `json:"signature_id,omitempty"`
+	Severity    int    `json:"severity,omitempty"` // 1 is the most severe
+	Category    string `json:"category,omitempty"`
+}
+
+// Kind is what the event is, going by what the feed reported
+func (f *FeedDetail) Kind() string {
+	switch {
+	case f.MD5 != "" || f.SHA512 != "":
+		return "malware capture"
+	case f.OS != "":
+		return "OS fingerprint"
+	case f.Signature != "":
+		return "IDS alert"
+	}
+	return "connection"
+}
+
+// DetailLines pairs labels with the values the feed reported, skipping the
+// ones it left out
+func (f *FeedDetail) DetailLines() [][2]string {
+	var lines [][2]string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, [2]string{label, SanitizeText(value)})
+		}
+	}
+	add("OS", f.OS)
+	if f.Distance != "" {
+		add("Distance", f.Distance+" hops")
+	}
+	add("Link", f.Link)
+	add("Signature", f.Signature)
+	if f.SignatureID != 0 {
+		add("SID", strconv.Itoa(f.SignatureID))
+	}
+	if f.Severity != 0 {
+		add("Severity", strconv.Itoa(f.Severity))
+	}
+	add("Category", f.Category)
+	add("MD5", f.MD5)
+	add("SHA512", f.SHA512)
+	add("URL", f.URL)
+	return lines
+}
+
+// SanitizeText replaces control characters in attacker supplied text so it
+// cannot move the cursor or change colors when drawn
+func SanitizeText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
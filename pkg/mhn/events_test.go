@@ -0,0 +1,221 @@
+package mhn
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestParseFeeds(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		channel  string
+		srcIP    string
+		username string
+		password string
+		protocol string
+		sensor   string
+		port     int
+		honeypot string // Feed detail's honeypot, "" when there is no feed detail
+	}{
+		{
+			name:    "cowrie login",
+			event:   `{"channel":"cowrie.sessions","peerIP":"203.0.113.5","loggedin":["root","hunter2"],"username":"admin","password":"admin","protocol":"ssh","session":"a1b2","sensor":"hp-1","dest_port":22}`,
+			channel: "cowrie.sessions", srcIP: "203.0.113.5", username: "root", password: "hunter2", protocol: "ssh", sensor: "hp-1", port: 22,
+		},
+		{
+			name:    "cowrie credentials without login",
+			event:   `{"src_ip":"198.51.100.7","username":"pi","password":"raspberry","protocol":"telnet","hostname":"hp-2"}`,
+			channel: "cowrie.sessions", srcIP: "198.51.100.7", username: "pi", password: "raspberry", protocol: "telnet", sensor: "hp-2",
+		},
+		{
+			name:    "kippo",
+			event:   `{"channel":"kippo.sessions","peerIP":"198.51.100.8","username":"root","password":"toor","protocol":"ssh"}`,
+			channel: "kippo.sessions", srcIP: "198.51.100.8", username: "root", password: "toor", protocol: "ssh",
+		},
+		{
+			name:    "dionaea handler",
+			event:   `{"remote_host":"192.0.2.10","local_port":445,"connection_transport":"tcp","connection_protocol":"smbd","honeypot":"dionaea-1"}`,
+			channel: "dionaea.connections", srcIP: "192.0.2.10", username: "connection", password: "smb", protocol: "smb", sensor: "dionaea-1", port: 445, honeypot: "dionaea",
+		},
+		{
+			name:    "dionaea well known port left to inference",
+			event:   `{"remote_host":"192.0.2.11","local_port":"3306","connection_transport":"tcp"}`,
+			channel: "dionaea.connections", srcIP: "192.0.2.11", username: "connection", password: "mysql", port: 3306, honeypot: "dionaea",
+		},
+		{
+			name:    "dionaea unknown port",
+			event:   `{"remote_host":"192.0.2.12","local_port":40000,"connection_transport":"udp"}`,
+			channel: "dionaea.connections", srcIP: "192.0.2.12", username: "connection", password: "udp/40000", protocol: "udp/40000", port: 40000, honeypot: "dionaea",
+		},
+		{
+			name:    "dionaea capture",
+			event:   `{"saddr":"192.0.2.13","dport":"445","url":"http://192.0.2.13/x.exe","md5":"d41d8cd98f00b204e9800998ecf8427e"}`,
+			channel: "dionaea.capture", srcIP: "192.0.2.13", username: "connection", password: "smb", port: 445, honeypot: "dionaea",
+		},
+		{
+			name:    "p0f",
+			event:   `{"client_ip":"192.0.2.14","server_port":22,"os":"Linux 3.x","dist":"12"}`,
+			channel: "p0f.events", srcIP: "192.0.2.14", username: "connection", password: "ssh", port: 22, honeypot: "p0f",
+		},
+		{
+			name:    "p0f unknown port",
+			event:   `{"client_ip":"192.0.2.15","server_port":4444}`,
+			channel: "p0f.events", srcIP: "192.0.2.15", username: "connection", password: "tcp/4444", protocol: "tcp/4444", port: 4444, honeypot: "p0f",
+		},
+		{
+			name:    "suricata app protocol",
+			event:   `{"src_ip":"192.0.2.16","dest_port":80,"proto":"TCP","app_proto":"HTTP","alert":{"signature":"ET SCAN","signature_id":2001219,"severity":2}}`,
+			channel: "suricata.events", srcIP: "192.0.2.16", username: "connection", password: "http", protocol: "http", port: 80, honeypot: "suricata",
+		},
+		{
+			name:    "suricata failed detection",
+			event:   `{"channel":"suricata.events","src_ip":"192.0.2.17","dest_port":50000,"proto":"UDP","app_proto":"failed","alert":{}}`,
+			channel: "suricata.events", srcIP: "192.0.2.17", username: "connection", password: "udp/50000", protocol: "udp/50000", port: 50000, honeypot: "suricata",
+		},
+		{
+			name:    "unknown channel falls back to the default",
+			event:   `{"channel":"glastopf.events","src_ip":"192.0.2.18"}`,
+			channel: DefaultChannel, srcIP: "192.0.2.18", username: "unknown", password: "unknown",
+		},
+		{
+			name:    "fields of the wrong type are left empty",
+			event:   `{"src_ip":"192.0.2.19","username":42,"password":"x","dest_port":true,"commands":"ls"}`,
+			channel: DefaultChannel, srcIP: "192.0.2.19", username: "unknown", password: "x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, ok := Parse([]byte(tt.event))
+			if !ok {
+				t.Fatalf("Parse(%s) failed", tt.event)
+			}
+			if fields.Channel != tt.channel {
+				t.Errorf("Channel = %q, want %q", fields.Channel, tt.channel)
+			}
+			if fields.SrcIP != tt.srcIP {
+				t.Errorf("SrcIP = %q, want %q", fields.SrcIP, tt.srcIP)
+			}
+			if fields.Username != tt.username || fields.Password != tt.password {
+				t.Errorf("credentials = %q/%q, want %q/%q", fields.Username, fields.Password, tt.username, tt.password)
+			}
+			if fields.Protocol != tt.protocol {
+				t.Errorf("Protocol = %q, want %q", fields.Protocol, tt.protocol)
+			}
+			if fields.Sensor != tt.sensor {
+				t.Errorf("Sensor = %q, want %q", fields.Sensor, tt.sensor)
+			}
+			if fields.DestPort != tt.port {
+				t.Errorf("DestPort = %d, want %d", fields.DestPort, tt.port)
+			}
+			honeypot := ""
+			if fields.Feed != nil {
+				honeypot = fields.Feed.Honeypot
+			}
+			if honeypot != tt.honeypot {
+				t.Errorf("Feed.Honeypot = %q, want %q", honeypot, tt.honeypot)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+	}{
+		{"not JSON", `src_ip=192.0.2.1`},
+		{"no source address", `{"username":"root","password":"root","protocol":"ssh"}`},
+		{"dionaea without remote host", `{"channel":"dionaea.connections","local_port":445}`},
+		{"array", `[{"src_ip":"192.0.2.1"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fields, ok := Parse([]byte(tt.event)); ok {
+				t.Errorf("Parse(%s) = %+v, want failure", tt.event, fields)
+			}
+		})
+	}
+}
+
+func TestParseCowrieSession(t *testing.T) {
+	event := `{"src_ip":"203.0.113.9","session":"s1","startTime":"2024-05-01T10:00:00Z","endTime":"2024-05-01T10:01:30Z",
+		"commands":["uname -a","",7],"urls":["http://203.0.113.9/bot.sh"],"hashes":["abc123"]}`
+	fields, ok := Parse([]byte(event))
+	if !ok {
+		t.Fatal("Parse failed")
+	}
+	if fields.Session == nil {
+		t.Fatal("Session is nil")
+	}
+	if fields.Session.ID != "s1" {
+		t.Errorf("Session.ID = %q, want s1", fields.Session.ID)
+	}
+	// The empty command and the one of the wrong type are dropped
+	if !slices.Equal(fields.Session.Commands, []string{"uname -a"}) {
+		t.Errorf("Session.Commands = %q, want [uname -a]", fields.Session.Commands)
+	}
+	if !slices.Equal(fields.Session.URLs, []string{"http://203.0.113.9/bot.sh"}) {
+		t.Errorf("Session.URLs = %q", fields.Session.URLs)
+	}
+	if d, ok := fields.Session.Duration(); !ok || d.Seconds() != 90 {
+		t.Errorf("Session.Duration() = %v, %v, want 1m30s", d, ok)
+	}
+
+	fields, _ = Parse([]byte(`{"src_ip":"203.0.113.9","username":"root","password":"root"}`))
+	if fields.Session != nil {
+		t.Errorf("Session = %+v for an event without session fields, want nil", fields.Session)
+	}
+}
+
+func TestPortField(t *testing.T) {
+	tests := []struct {
+		json string
+		want PortField
+	}{
+		{`22`, 22},
+		{`"8080"`, 8080},
+		{`65535`, 65535},
+		{`0`, 0},
+		{`65536`, 0},
+		{`-1`, 0},
+		{`"ssh"`, 0},
+		{`true`, 0},
+		{`null`, 0},
+	}
+	for _, tt := range tests {
+		var port PortField
+		if err := json.Unmarshal([]byte(tt.json), &port); err != nil {
+			t.Errorf("Unmarshal(%s) error: %v", tt.json, err)
+		}
+		if port != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.json, port, tt.want)
+		}
+	}
+}
+
+type testEvent struct {
+	Addr string `json:"addr"`
+}
+
+func (e *testEvent) Fields() EventFields {
+	return EventFields{SrcIP: e.Addr, Protocol: "test"}
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser("test.events", func(data []byte) (HoneypotEvent, error) {
+		var event testEvent
+		return &event, Decode(data, &event)
+	})
+	defer delete(parsers, "test.events")
+
+	fields, ok := Parse([]byte(`{"channel":"test.events","addr":"192.0.2.20"}`))
+	if !ok {
+		t.Fatal("Parse failed")
+	}
+	if fields.Channel != "test.events" || fields.SrcIP != "192.0.2.20" || fields.Password != "test" {
+		t.Errorf("Parse = %+v, want the registered parser's fields", fields)
+	}
+}
@@ -0,0 +1,68 @@
+package mhn
+
+import (
+	"sync"
+	"time"
+)
+
+// maxMerged bounds the event keys a Merger keeps to spot duplicates
+const maxMerged = 20000
+
+// Merger remembers recent events by their key so one seen through several
+// API endpoints, or polled twice, is shown once
+type Merger struct {
+	mutex sync.Mutex
+	seen  map[string]bool
+	order []string // Keys oldest first
+}
+
+func NewMerger() *Merger {
+	return &Merger{seen: make(map[string]bool)}
+}
+
+// Fresh reports whether no endpoint has delivered the event with this key
+// (see Event.Key) before
+func (m *Merger) Fresh(key string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.seen[key] {
+		return false
+	}
+	m.seen[key] = true
+	m.order = append(m.order, key)
+	if len(m.order) > maxMerged {
+		delete(m.seen, m.order[0])
+		m.order = m.order[1:]
+	}
+	return true
+}
+
+// ParsedEvent is an event Parse recognized, with the time the server gave it
+type ParsedEvent struct {
+	EventFields
+	Time time.Time // On the server's clock
+}
+
+// ParseEvents decodes polled, streamed or backfilled events. It skips
+// events from before after, events no feed parser recognizes and, when
+// merger is set, events it has already seen from any endpoint. Events
+// sharing a timestamp are told apart by their ID or content, so a burst
+// within one second is kept whole. It also returns the newest timestamp
+// among events, the next call's after.
+func ParseEvents(events []Event, after float64, merger *Merger) ([]ParsedEvent, float64) {
+	var parsed []ParsedEvent
+	newest := after
+	for _, event := range events {
+		if event.Timestamp < after {
+			continue
+		}
+		newest = max(newest, event.Timestamp)
+
+		fields, ok := Parse(event.Event)
+		if !ok || (merger != nil && !merger.Fresh(event.Key())) {
+			continue
+		}
+		parsed = append(parsed, ParsedEvent{EventFields: fields, Time: time.Unix(0, int64(event.Timestamp*1e9))})
+	}
+	return parsed, newest
+}
@@ -0,0 +1,161 @@
+package mhn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventKey(t *testing.T) {
+	login := json.RawMessage(`{"src_ip":"192.0.2.1","username":"root","password":"root"}`)
+	tests := []struct {
+		name string
+		a, b Event
+		same bool
+	}{
+		{
+			name: "string ID",
+			a:    Event{ID: json.RawMessage(`"e-17"`), Timestamp: 1, Event: login},
+			b:    Event{ID: json.RawMessage(`"e-17"`), Timestamp: 2, Event: json.RawMessage(`{}`)},
+			same: true,
+		},
+		{
+			name: "numeric ID matches the same ID as a string",
+			a:    Event{ID: json.RawMessage(`17`), Event: login},
+			b:    Event{ID: json.RawMessage(`"17"`), Event: login},
+			same: true,
+		},
+		{
+			name: "different IDs with the same content",
+			a:    Event{ID: json.RawMessage(`1`), Timestamp: 1, Event: login},
+			b:    Event{ID: json.RawMessage(`2`), Timestamp: 1, Event: login},
+		},
+		{
+			name: "content hash ignores JSON whitespace",
+			a:    Event{Timestamp: 1700000000.5, Event: login},
+			b:    Event{Timestamp: 1700000000.5, Event: json.RawMessage("{\"src_ip\": \"192.0.2.1\",\n \"username\": \"root\", \"password\": \"root\"}")},
+			same: true,
+		},
+		{
+			name: "null ID falls back to the content hash",
+			a:    Event{ID: json.RawMessage(`null`), Timestamp: 1, Event: login},
+			b:    Event{Timestamp: 1, Event: login},
+			same: true,
+		},
+		{
+			name: "same second, different content",
+			a:    Event{Timestamp: 1700000000, Event: login},
+			b:    Event{Timestamp: 1700000000, Event: json.RawMessage(`{"src_ip":"192.0.2.1","username":"root","password":"admin"}`)},
+		},
+		{
+			name: "same content, different time",
+			a:    Event{Timestamp: 1700000000, Event: login},
+			b:    Event{Timestamp: 1700000000.25, Event: login},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := tt.a.Key() == tt.b.Key(); same != tt.same {
+				t.Errorf("keys %q and %q: same = %v, want %v", tt.a.Key(), tt.b.Key(), same, tt.same)
+			}
+		})
+	}
+
+	if key := (Event{ID: json.RawMessage(`"abc"`)}).Key(); key != "id:abc" {
+		t.Errorf("Key() = %q, want id:abc", key)
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	event := func(ts float64, body string) Event {
+		return Event{Timestamp: ts, Event: json.RawMessage(body)}
+	}
+	root := `{"src_ip":"192.0.2.1","username":"root","password":"root"}`
+	admin := `{"src_ip":"192.0.2.1","username":"root","password":"admin"}`
+
+	tests := []struct {
+		name       string
+		events     []Event
+		after      float64
+		wantPass   []string // Passwords of the kept events, in order
+		wantNewest float64
+	}{
+		{
+			name:       "two events in the same second are both kept",
+			events:     []Event{event(100, root), event(100, admin)},
+			wantPass:   []string{"root", "admin"},
+			wantNewest: 100,
+		},
+		{
+			name:       "an exact duplicate is dropped",
+			events:     []Event{event(100, root), event(100, root)},
+			wantPass:   []string{"root"},
+			wantNewest: 100,
+		},
+		{
+			name:       "events with distinct server IDs are kept despite equal content",
+			events:     []Event{{ID: json.RawMessage(`1`), Timestamp: 100, Event: json.RawMessage(root)}, {ID: json.RawMessage(`2`), Timestamp: 100, Event: json.RawMessage(root)}},
+			wantPass:   []string{"root", "root"},
+			wantNewest: 100,
+		},
+		{
+			name:       "a repeated server ID is dropped despite other content",
+			events:     []Event{{ID: json.RawMessage(`"x"`), Timestamp: 100, Event: json.RawMessage(root)}, {ID: json.RawMessage(`"x"`), Timestamp: 101, Event: json.RawMessage(admin)}},
+			wantPass:   []string{"root"},
+			wantNewest: 101,
+		},
+		{
+			name:       "events before the cursor are skipped",
+			events:     []Event{event(99, root), event(100, admin), event(101, root)},
+			after:      100,
+			wantPass:   []string{"admin", "root"},
+			wantNewest: 101,
+		},
+		{
+			name:       "the cursor does not move back",
+			events:     []Event{event(50, root)},
+			after:      100,
+			wantNewest: 100,
+		},
+		{
+			name:       "unrecognized events still move the cursor",
+			events:     []Event{event(100, root), event(120, `{"username":"nobody"}`)},
+			wantPass:   []string{"root"},
+			wantNewest: 120,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, newest := ParseEvents(tt.events, tt.after, NewMerger())
+			if newest != tt.wantNewest {
+				t.Errorf("newest = %v, want %v", newest, tt.wantNewest)
+			}
+			if len(parsed) != len(tt.wantPass) {
+				t.Fatalf("kept %d events, want %d", len(parsed), len(tt.wantPass))
+			}
+			for i, event := range parsed {
+				if event.Password != tt.wantPass[i] {
+					t.Errorf("event %d password = %q, want %q", i, event.Password, tt.wantPass[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEventsAcrossEndpoints(t *testing.T) {
+	merger := NewMerger()
+	events := []Event{{Timestamp: 1700000000.5, Event: json.RawMessage(`{"src_ip":"192.0.2.1","username":"a","password":"b"}`)}}
+
+	first, _ := ParseEvents(events, 0, merger)
+	again, _ := ParseEvents(events, 0, merger)
+	if len(first) != 1 || len(again) != 0 {
+		t.Errorf("kept %d then %d events, want 1 then 0", len(first), len(again))
+	}
+	if want := int64(1700000000500); first[0].Time.UnixMilli() != want {
+		t.Errorf("Time = %v, want %d ms", first[0].Time, want)
+	}
+
+	// Without a merger nothing is deduplicated
+	if unmerged, _ := ParseEvents(append(events, events...), 0, nil); len(unmerged) != 2 {
+		t.Errorf("kept %d events without a merger, want 2", len(unmerged))
+	}
+}
@@ -0,0 +1,52 @@
+package mhn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServicePorts guesses the protocol of a connection from its destination
+// port when the sensor did not identify the service
+var ServicePorts = map[int]string{
+	21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 53: "dns", 69: "tftp",
+	80: "http", 102: "s7", 110: "pop3", 123: "ntp", 135: "msrpc", 139: "smb",
+	143: "imap", 161: "snmp", 389: "ldap", 443: "https", 445: "smb",
+	465: "smtp", 502: "modbus", 587: "smtp", 623: "ipmi", 631: "ipp",
+	873: "rsync", 993: "imap", 995: "pop3", 1080: "socks", 1433: "mssql",
+	1521: "oracle", 1723: "pptp", 1883: "mqtt", 1900: "upnp", 2222: "ssh",
+	2323: "telnet", 2375: "docker", 3306: "mysql", 3389: "rdp", 5060: "sip",
+	5432: "postgres", 5555: "adb", 5683: "coap", 5900: "vnc", 5985: "winrm",
+	6379: "redis", 7547: "tr-069", 8080: "http", 8291: "winbox",
+	8443: "https", 9200: "elasticsearch", 11211: "memcached",
+	20000: "dnp3", 27017: "mongodb", 47808: "bacnet",
+}
+
+// InferProtocol names the service usually found on a destination port, for
+// events whose sensor did not say which service was attacked
+func InferProtocol(port int) (string, bool) {
+	service, ok := ServicePorts[port]
+	return service, ok
+}
+
+// GuessProtocol names the service on a destination port, falling back to
+// transport/port, e.g. "tcp/8291"
+func GuessProtocol(port int, transport string) string {
+	if service, ok := ServicePorts[port]; ok {
+		return service
+	}
+	if transport == "" {
+		transport = "tcp"
+	}
+	return fmt.Sprintf("%s/%d", strings.ToLower(transport), port)
+}
+
+// ServicePort is the lowest port ServicePorts names protocol on, or 0
+func ServicePort(protocol string) int {
+	port := 0
+	for p, service := range ServicePorts {
+		if service == strings.ToLower(protocol) && (port == 0 || p < port) {
+			port = p
+		}
+	}
+	return port
+}
@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAttempts caps the attempts a CredentialStats keeps inside its
+// window, so a storm cannot grow it without bound
+const DefaultMaxAttempts = 50000
+
+type credAttempt struct {
+	Pair string
+	Time time.Time
+}
+
+// CredentialStats counts attempts per username:password pair over a
+// sliding window
+type CredentialStats struct {
+	attempts    []credAttempt // attempts[head:] are inside the window, oldest first
+	head        int
+	window      time.Duration
+	maxAttempts int // Oldest attempts are dropped beyond this many
+	now         func() time.Time
+	mutex       sync.RWMutex
+}
+
+// Histogram bins for attempts per credential pair (upper bounds, inclusive)
+var (
+	HistogramBins   = []int{1, 3, 7, 15, 31, math.MaxInt32}
+	HistogramLabels = []string{"1", "2-3", "4-7", "8-15", "16-31", "32+"}
+)
+
+func NewCredentialStats(window time.Duration) *CredentialStats {
+	return &CredentialStats{
+		attempts:    make([]credAttempt, 0),
+		window:      window,
+		maxAttempts: DefaultMaxAttempts,
+		now:         time.Now,
+	}
+}
+
+// Window is how far back attempts are counted
+func (cs *CredentialStats) Window() time.Duration {
+	return cs.window
+}
+
+// SetMaxAttempts caps the number of attempts kept inside the window
+func (cs *CredentialStats) SetMaxAttempts(n int) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.maxAttempts = n
+}
+
+// Shed drops the oldest half of the recorded attempts
+func (cs *CredentialStats) Shed() {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.dropLocked((len(cs.attempts) - cs.head) / 2)
+}
+
+func (cs *CredentialStats) Record(username, password string, t time.Time) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	cs.attempts = append(cs.attempts, credAttempt{Pair: username + ":" + password, Time: t})
+	cs.pruneLocked(t)
+}
+
+func (cs *CredentialStats) pruneLocked(now time.Time) {
+	cutoff := now.Add(-cs.window)
+	idx := cs.head
+	if len(cs.attempts)-idx > cs.maxAttempts {
+		idx = len(cs.attempts) - cs.maxAttempts
+	}
+	for idx < len(cs.attempts) && cs.attempts[idx].Time.Before(cutoff) {
+		idx++
+	}
+	cs.dropLocked(idx - cs.head)
+}
+
+// dropLocked forgets the n oldest attempts by moving the head past them.
+// The live attempts are copied down only once the dropped ones outnumber
+// them, so pruning costs amortized O(1) per attempt however large the
+// window.
+func (cs *CredentialStats) dropLocked(n int) {
+	if n <= 0 {
+		return
+	}
+	cs.head += n
+	live := len(cs.attempts) - cs.head
+	if cs.head < live {
+		return
+	}
+	copy(cs.attempts, cs.attempts[cs.head:])
+	clear(cs.attempts[live:]) // Let the dropped pairs' strings be collected
+	cs.attempts = cs.attempts[:live]
+	cs.head = 0
+}
+
+// PairCounts returns the number of attempts per credential pair within the window
+func (cs *CredentialStats) PairCounts() map[string]int {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	cs.pruneLocked(cs.now())
+	counts := make(map[string]int)
+	for _, a := range cs.attempts[cs.head:] {
+		counts[a.Pair]++
+	}
+	return counts
+}
+
+// Histogram buckets per-pair attempt counts into HistogramBins and returns
+// the bucket sizes along with the sorted per-pair counts
+func (cs *CredentialStats) Histogram() ([]int, []int) {
+	counts := cs.PairCounts()
+	bins := make([]int, len(HistogramBins))
+	sorted := make([]int, 0, len(counts))
+	for _, c := range counts {
+		sorted = append(sorted, c)
+		for i, upper := range HistogramBins {
+			if c <= upper {
+				bins[i]++
+				break
+			}
+		}
+	}
+	sort.Ints(sorted)
+	return bins, sorted
+}
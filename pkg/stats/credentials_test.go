@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCredentialStatsWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cs := NewCredentialStats(time.Hour)
+	cs.now = func() time.Time { return now }
+
+	cs.Record("root", "root", now.Add(-2*time.Hour))
+	cs.Record("root", "root", now.Add(-30*time.Minute))
+	cs.Record("root", "root", now.Add(-time.Minute))
+	cs.Record("admin", "admin", now)
+
+	counts := cs.PairCounts()
+	if len(counts) != 2 || counts["root:root"] != 2 || counts["admin:admin"] != 1 {
+		t.Errorf("PairCounts() = %v, want root:root 2 and admin:admin 1", counts)
+	}
+
+	// The window slides with the clock
+	now = now.Add(45 * time.Minute)
+	if counts := cs.PairCounts(); counts["root:root"] != 1 {
+		t.Errorf("45 minutes on root:root = %d, want 1", counts["root:root"])
+	}
+}
+
+func TestCredentialStatsMaxAttempts(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cs := NewCredentialStats(time.Hour)
+	cs.now = func() time.Time { return now }
+	cs.SetMaxAttempts(3)
+	for _, password := range []string{"a", "b", "c", "d", "e"} {
+		cs.Record("root", password, now)
+	}
+	counts := cs.PairCounts()
+	if len(counts) != 3 || counts["root:a"] != 0 || counts["root:e"] != 1 {
+		t.Errorf("PairCounts() = %v, want the newest three attempts", counts)
+	}
+
+	cs.Shed()
+	if counts := cs.PairCounts(); len(counts) != 2 || counts["root:c"] != 0 {
+		t.Errorf("PairCounts() after Shed = %v, want the newest two", counts)
+	}
+}
+
+// TestCredentialStatsSliding records far more attempts than the window holds
+// and checks that the dropped ones are reclaimed rather than piling up
+// behind the head
+func TestCredentialStatsSliding(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	now := start
+	cs := NewCredentialStats(time.Minute)
+	cs.now = func() time.Time { return now }
+	for i := range 10000 {
+		now = start.Add(time.Duration(i) * time.Second)
+		cs.Record("root", string(rune('a'+i%26)), now)
+		if live := len(cs.attempts) - cs.head; live > 61 || cs.head > live {
+			t.Fatalf("after %d attempts: %d live behind a head of %d", i+1, live, cs.head)
+		}
+	}
+
+	counts := cs.PairCounts()
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total != 61 {
+		t.Errorf("%d attempts in the last minute, want 61", total)
+	}
+	if cs.attempts[cs.head].Time != now.Add(-time.Minute) {
+		t.Errorf("oldest attempt at %v, want %v", cs.attempts[cs.head].Time, now.Add(-time.Minute))
+	}
+}
+
+func TestCredentialStatsHistogram(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cs := NewCredentialStats(time.Hour)
+	cs.now = func() time.Time { return now }
+	for pair, n := range map[string]int{"a": 1, "b": 1, "c": 3, "d": 4, "e": 40} {
+		for range n {
+			cs.Record(pair, "x", now)
+		}
+	}
+
+	bins, sorted := cs.Histogram()
+	if want := []int{2, 1, 1, 0, 0, 1}; !slices.Equal(bins, want) {
+		t.Errorf("bins = %v, want %v", bins, want)
+	}
+	if want := []int{1, 1, 3, 4, 40}; !slices.Equal(sorted, want) {
+		t.Errorf("sorted = %v, want %v", sorted, want)
+	}
+	if len(HistogramBins) != len(HistogramLabels) {
+		t.Errorf("%d bins but %d labels", len(HistogramBins), len(HistogramLabels))
+	}
+}
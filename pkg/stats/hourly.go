@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// localHours is how long hourly buckets are kept
+const localHours = 48
+
+// Aggregator buckets the events this client sees, backfill included, by
+// hour and protocol. The hourly bar graph and sparkline fall back to it when
+// the stats API is unreachable or the feed is a replay or a local log, and it
+// tops up the stats API's stale count for the current hour.
+type Aggregator struct {
+	mutex sync.RWMutex
+	hours map[time.Time]*hourBucket // Keyed by the start of the local hour
+	now   func() time.Time
+}
+
+type hourBucket struct {
+	total     int
+	protocols map[string]int
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{hours: make(map[time.Time]*hourBucket), now: time.Now}
+}
+
+// hourStart truncates t to the start of its hour in local time
+func hourStart(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+}
+
+// Record counts an event in its hour, dropping buckets past localHours
+func (sa *Aggregator) Record(t time.Time, protocol string) {
+	hour := hourStart(t)
+	sa.mutex.Lock()
+	defer sa.mutex.Unlock()
+	bucket := sa.hours[hour]
+	if bucket == nil {
+		bucket = &hourBucket{protocols: make(map[string]int)}
+		sa.hours[hour] = bucket
+		cutoff := hourStart(sa.now()).Add(-localHours * time.Hour)
+		maps.DeleteFunc(sa.hours, func(h time.Time, _ *hourBucket) bool { return h.Before(cutoff) })
+	}
+	bucket.total++
+	bucket.protocols[protocol]++
+}
+
+// Hourly returns the event count and per-protocol counts of the hour ago
+// hours before now's
+func (sa *Aggregator) Hourly(now time.Time, ago int) (int, map[string]int) {
+	sa.mutex.RLock()
+	defer sa.mutex.RUnlock()
+	bucket := sa.hours[hourStart(now).Add(-time.Duration(ago)*time.Hour)]
+	if bucket == nil {
+		return 0, nil
+	}
+	return bucket.total, maps.Clone(bucket.protocols)
+}
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	now := time.Date(2024, 5, 1, 14, 30, 0, 0, time.Local)
+	sa := NewAggregator()
+	sa.now = func() time.Time { return now }
+
+	sa.Record(now, "ssh")
+	sa.Record(now.Add(-25*time.Minute), "ssh")
+	sa.Record(now.Add(-31*time.Minute), "telnet") // 13:59, the hour before
+	sa.Record(now.Add(-3*time.Hour), "smb")
+
+	tests := []struct {
+		ago       int
+		total     int
+		protocols map[string]int
+	}{
+		{0, 2, map[string]int{"ssh": 2}},
+		{1, 1, map[string]int{"telnet": 1}},
+		{2, 0, nil},
+		{3, 1, map[string]int{"smb": 1}},
+	}
+	for _, tt := range tests {
+		total, protocols := sa.Hourly(now, tt.ago)
+		if total != tt.total || !maps.Equal(protocols, tt.protocols) {
+			t.Errorf("Hourly(%d) = %d %v, want %d %v", tt.ago, total, protocols, tt.total, tt.protocols)
+		}
+	}
+
+	// The counts returned are a copy
+	_, protocols := sa.Hourly(now, 0)
+	protocols["ssh"] = 99
+	if _, again := sa.Hourly(now, 0); again["ssh"] != 2 {
+		t.Error("changing Hourly's map changed the aggregator")
+	}
+}
+
+func TestAggregatorDropsOldHours(t *testing.T) {
+	now := time.Date(2024, 5, 3, 12, 0, 0, 0, time.Local)
+	sa := NewAggregator()
+	sa.now = func() time.Time { return now }
+
+	sa.Record(now.Add(-(localHours-1)*time.Hour), "ssh")
+	sa.Record(now.Add(-(localHours+1)*time.Hour), "ssh") // Too old to keep
+	if total, _ := sa.Hourly(now, localHours-1); total != 1 {
+		t.Fatalf("hour %d ago total = %d, want 1", localHours-1, total)
+	}
+	if total, _ := sa.Hourly(now, localHours+1); total != 0 {
+		t.Errorf("hour %d ago total = %d, want 0", localHours+1, total)
+	}
+
+	// Opening a new hour drops those that have since passed localHours
+	now = now.Add(2 * time.Hour)
+	sa.Record(now, "ssh")
+	if total, _ := sa.Hourly(now, localHours+1); total != 0 {
+		t.Errorf("hour %d ago still counted %d events", localHours+1, total)
+	}
+	if total, _ := sa.Hourly(now, 0); total != 1 {
+		t.Errorf("current hour total = %d, want 1", total)
+	}
+}
@@ -0,0 +1,102 @@
+// Package stats keeps the rolling windows behind the live statistics: the
+// events per minute gauge, credential pair attempts and hourly event counts.
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	rateSlots      = 120 // Seconds of per-second counts: this minute and the one before
+	rateSparkCells = 12  // Sparkline cells, 5 seconds each
+)
+
+// RateGauge counts live events per second for the events per minute gauge
+// in the status line, its trend arrow and its 60 second sparkline
+type RateGauge struct {
+	mutex  sync.Mutex
+	counts [rateSlots]int
+	stamps [rateSlots]int64 // Unix second each slot is counting
+	last   time.Time        // Newest live event
+}
+
+// RateReading is the gauge at one moment
+type RateReading struct {
+	PerMinute int       // Events in the last 60 seconds
+	Previous  int       // Events in the 60 seconds before that
+	Spark     []int     // Events per 5 seconds over the last minute, oldest first
+	Last      time.Time // Newest live event, zero before the first
+}
+
+// Record counts a live event
+func (rg *RateGauge) Record(t time.Time) {
+	sec := t.Unix()
+	i := sec % rateSlots
+	rg.mutex.Lock()
+	defer rg.mutex.Unlock()
+	if rg.stamps[i] != sec {
+		rg.stamps[i], rg.counts[i] = sec, 0
+	}
+	rg.counts[i]++
+	if t.After(rg.last) {
+		rg.last = t
+	}
+}
+
+// Reading sums the sliding windows ending at now
+func (rg *RateGauge) Reading(now time.Time) RateReading {
+	reading := RateReading{Spark: make([]int, rateSparkCells)}
+	sec := now.Unix()
+	rg.mutex.Lock()
+	defer rg.mutex.Unlock()
+	reading.Last = rg.last
+	for ago := int64(0); ago < rateSlots; ago++ {
+		i := (sec - ago) % rateSlots
+		if rg.stamps[i] != sec-ago {
+			continue
+		}
+		if ago < rateSlots/2 {
+			reading.PerMinute += rg.counts[i]
+			reading.Spark[rateSparkCells-1-int(ago)*rateSparkCells/(rateSlots/2)] += rg.counts[i]
+		} else {
+			reading.Previous += rg.counts[i]
+		}
+	}
+	return reading
+}
+
+// Trend compares the last minute with the one before: 1 when busier, -1 when
+// quieter and 0 when within 10% (or two events) of it
+func (r RateReading) Trend() int {
+	diff, steady := r.PerMinute-r.Previous, max(2, r.Previous/10)
+	if diff >= -steady && diff <= steady {
+		return 0
+	}
+	if diff > 0 {
+		return 1
+	}
+	return -1
+}
+
+// Arrow is the trend as ▲, ▶ or ▼
+func (r RateReading) Arrow() string {
+	return map[int]string{1: "▲", 0: "▶", -1: "▼"}[r.Trend()]
+}
+
+// Age says how long ago the newest event arrived, e.g. "3s", "4m" or "2h",
+// or "never" before the first
+func (r RateReading) Age(now time.Time) string {
+	if r.Last.IsZero() {
+		return "never"
+	}
+	age := max(now.Sub(r.Last), 0)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	}
+	return fmt.Sprintf("%dh", int(age.Hours()))
+}
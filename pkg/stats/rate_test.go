@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRateGauge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var rg RateGauge
+
+	// Five events this minute and three in the minute before
+	for _, ago := range []int{0, 0, 4, 30, 59, 60, 61, 119} {
+		rg.Record(now.Add(-time.Duration(ago) * time.Second))
+	}
+	r := rg.Reading(now)
+	if r.PerMinute != 5 || r.Previous != 3 {
+		t.Errorf("PerMinute, Previous = %d, %d, want 5, 3", r.PerMinute, r.Previous)
+	}
+	if want := []int{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 3}; !slices.Equal(r.Spark, want) {
+		t.Errorf("Spark = %v, want %v", r.Spark, want)
+	}
+	if !r.Last.Equal(now) {
+		t.Errorf("Last = %v, want %v", r.Last, now)
+	}
+
+	// A minute on this minute becomes the one before, and the slots of the
+	// oldest are reused
+	later := now.Add(time.Minute)
+	rg.Record(later)
+	if r := rg.Reading(later); r.PerMinute != 1 || r.Previous != 5 {
+		t.Errorf("a minute on PerMinute, Previous = %d, %d, want 1, 5", r.PerMinute, r.Previous)
+	}
+	if r := rg.Reading(later.Add(10 * time.Minute)); r.PerMinute != 0 || r.Previous != 0 {
+		t.Errorf("after a quiet spell PerMinute, Previous = %d, %d, want 0, 0", r.PerMinute, r.Previous)
+	}
+}
+
+func TestRateReadingTrend(t *testing.T) {
+	tests := []struct {
+		perMinute, previous int
+		want                int
+		arrow               string
+	}{
+		{0, 0, 0, "▶"},
+		{2, 0, 0, "▶"}, // Within two events
+		{3, 0, 1, "▲"},
+		{105, 100, 0, "▶"}, // Within 10%
+		{111, 100, 1, "▲"},
+		{89, 100, -1, "▼"},
+	}
+	for _, tt := range tests {
+		r := RateReading{PerMinute: tt.perMinute, Previous: tt.previous}
+		if got := r.Trend(); got != tt.want || r.Arrow() != tt.arrow {
+			t.Errorf("%d after %d: Trend() = %d %s, want %d %s", tt.perMinute, tt.previous, got, r.Arrow(), tt.want, tt.arrow)
+		}
+	}
+}
+
+func TestRateReadingAge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		last time.Time
+		want string
+	}{
+		{time.Time{}, "never"},
+		{now.Add(-3 * time.Second), "3s"},
+		{now.Add(-4*time.Minute - 10*time.Second), "4m"},
+		{now.Add(-2*time.Hour - 59*time.Minute), "2h"},
+		{now.Add(time.Second), "0s"}, // Clock skew puts it in the future
+	}
+	for _, tt := range tests {
+		if got := (RateReading{Last: tt.last}).Age(now); got != tt.want {
+			t.Errorf("Age(%v) = %q, want %q", now.Sub(tt.last), got, tt.want)
+		}
+	}
+}
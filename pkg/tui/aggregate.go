@@ -0,0 +1,336 @@
+package tui
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+	"SecKC-MHN-Globe/pkg/stats"
+)
+
+// WithTags fills in each row's triage tag
+func (cl ConnectionList) WithTags() ConnectionList {
+	if GlobalTags == nil || len(cl) == 0 {
+		return cl
+	}
+	GlobalTags.mutex.RLock()
+	defer GlobalTags.mutex.RUnlock()
+	for i := range cl {
+		cl[i].Tag = GlobalTags.tags[cl[i].IP].Name
+	}
+	return cl
+}
+
+// FilterTag keeps the rows matching one of tagFilters
+func (cl ConnectionList) FilterTag(filter string) ConnectionList {
+	if filter == "" {
+		return cl
+	}
+	var rows ConnectionList
+	for _, conn := range cl {
+		switch filter {
+		case "tagged":
+			if conn.Tag == "" {
+				continue
+			}
+		case "untagged":
+			if conn.Tag != "" {
+				continue
+			}
+		default:
+			if conn.Tag != filter {
+				continue
+			}
+		}
+		rows = append(rows, conn)
+	}
+	return rows
+}
+
+// without drops the rows that appear in pinned
+func (cl ConnectionList) without(pinned ConnectionList) ConnectionList {
+	if len(pinned) == 0 {
+		return cl
+	}
+	var rows ConnectionList
+	for _, conn := range cl {
+		if !slices.ContainsFunc(pinned, func(pin api.Connection) bool { return sameRow(pin, conn) }) {
+			rows = append(rows, conn)
+		}
+	}
+	return rows
+}
+
+// Collapse folds each run of consecutive rows from one IP into the run's
+// newest row, counting the run in Attempts and listing its credentials in
+// Tried, so a brute-force storm takes one row per attacker instead of the
+// whole dashboard. A run with an alert keeps it highlighted.
+func (cl ConnectionList) Collapse() ConnectionList {
+	var rows ConnectionList
+	for _, conn := range cl {
+		creds := conn.Username + ":" + conn.Password
+		if n := len(rows); n > 0 && rows[n-1].IP == conn.IP {
+			run := rows[n-1]
+			conn.Attempts, conn.Tried = run.Attempts+1, run.Tried
+			if !slices.Contains(conn.Tried, creds) {
+				conn.Tried = append(conn.Tried, creds)
+			}
+			if conn.Alert == "" {
+				conn.Alert = run.Alert
+			}
+			rows[n-1] = conn
+			continue
+		}
+		conn.Attempts, conn.Tried = 1, []string{creds}
+		rows = append(rows, conn)
+	}
+	return rows
+}
+
+// Search keeps the rows with re matching the IP, credentials, location,
+// organization or reverse DNS
+func (cl ConnectionList) Search(re *regexp.Regexp) ConnectionList {
+	var rows ConnectionList
+	for _, conn := range cl {
+		for _, field := range []string{conn.IP, conn.Username, conn.Password, conn.City, conn.Country, conn.Org, conn.RDNS} {
+			if re.MatchString(field) {
+				rows = append(rows, conn)
+				break
+			}
+		}
+	}
+	return rows
+}
+
+// FilterRows keeps the rows matching f
+func (cl ConnectionList) FilterRows(f RowFilter) ConnectionList {
+	if !f.Active() {
+		return cl
+	}
+	var rows ConnectionList
+	for _, conn := range cl {
+		if f.Match(conn) {
+			rows = append(rows, conn)
+		}
+	}
+	return rows
+}
+
+// StatEntry is a named count used by the top-N panels and the panel API
+type StatEntry struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// IPStat is one row of the top attacking IPs panel
+type IPStat struct {
+	IP           string `json:"ip"`
+	Count        int    `json:"count"`
+	SessionCount int    `json:"session_count"` // Hits since startup, not just the rows on screen
+	Country      string `json:"country,omitempty"`
+	ASN          string `json:"asn,omitempty"`
+	Org          string `json:"org,omitempty"`
+}
+
+// PortStat is one row of the top destination ports panel
+type PortStat struct {
+	Port    int    `json:"port"`
+	Service string `json:"service,omitempty"`
+	Count   int    `json:"count"`
+}
+
+// CredentialSummary is the data behind the credential histogram panel
+type CredentialSummary struct {
+	Window    string      `json:"window"`
+	Pairs     int         `json:"pairs"`
+	Attempts  int         `json:"attempts"`
+	Histogram []StatEntry `json:"histogram"`
+	P50       int         `json:"p50"`
+	P90       int         `json:"p90"`
+	P99       int         `json:"p99"`
+	Shape     string      `json:"shape"`
+	TopPairs  []StatEntry `json:"top_pairs"`
+}
+
+// HourStat is one bar of the rolling 24 hour graph (Offset 23 is the current hour)
+type HourStat struct {
+	Offset    int            `json:"offset"`
+	Count     int            `json:"count"`
+	Protocols map[string]int `json:"protocols,omitempty"` // Events this client saw, by protocol
+}
+
+// ConnectionList is a copy of the dashboard rows, oldest first, that can be
+// aggregated without holding the dashboard lock
+type ConnectionList []api.Connection
+
+// WithSessionHits fills in each row's session hit count
+func (cl ConnectionList) WithSessionHits() ConnectionList {
+	if GlobalOffenders == nil || len(cl) == 0 {
+		return cl
+	}
+	ips := make([]string, len(cl))
+	for i, conn := range cl {
+		ips[i] = conn.IP
+	}
+	hits := GlobalOffenders.Hits(ips)
+	for i := range cl {
+		cl[i].Hits = hits[cl[i].IP]
+	}
+	return cl
+}
+
+// InteractiveSessions returns the rows whose session ran commands, fetched
+// URLs or dropped files, newest first
+func (cl ConnectionList) InteractiveSessions() ConnectionList {
+	var sessions ConnectionList
+	for i := len(cl) - 1; i >= 0; i-- {
+		if cl[i].Session.Interactive() {
+			sessions = append(sessions, cl[i])
+		}
+	}
+	return sessions
+}
+
+// countBy tallies connections by the key returned from keyFn, skipping empty keys
+func (cl ConnectionList) countBy(keyFn func(api.Connection) string) map[string]int {
+	counts := make(map[string]int)
+	for _, conn := range cl {
+		if key := keyFn(conn); key != "" {
+			counts[key]++
+		}
+	}
+	return counts
+}
+
+// topN sorts counts descending (ties by name) and returns at most n entries
+func topN(counts map[string]int, n int) []StatEntry {
+	entries := make([]StatEntry, 0, len(counts))
+	for name, count := range counts {
+		entries = append(entries, StatEntry{Name: name, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+func (cl ConnectionList) TopCountries(n int) []StatEntry {
+	return topN(cl.countBy(func(c api.Connection) string { return c.Country }), n)
+}
+
+func (cl ConnectionList) TopASNs(n int) []StatEntry {
+	return topN(cl.countBy(func(c api.Connection) string { return c.ASN }), n)
+}
+
+// TopCredentials ranks the username:password pairs tried, skipping events
+// that carried neither
+func (cl ConnectionList) TopCredentials(n int) []StatEntry {
+	return topN(cl.countBy(func(c api.Connection) string {
+		if c.Username == "" && c.Password == "" {
+			return ""
+		}
+		return c.Username + ":" + c.Password
+	}), n)
+}
+
+func (cl ConnectionList) ProtocolBreakdown() []StatEntry {
+	return topN(cl.countBy(func(c api.Connection) string {
+		if c.Protocol == "" {
+			return "unknown"
+		}
+		return strings.ToLower(c.Protocol)
+	}), 0)
+}
+
+func (cl ConnectionList) TopIPs(n int) []IPStat {
+	counts := cl.countBy(func(c api.Connection) string { return c.IP })
+
+	// First connection seen for each IP supplies the enrichment details
+	details := make(map[string]api.Connection)
+	for _, conn := range cl {
+		if _, exists := details[conn.IP]; !exists {
+			details[conn.IP] = conn
+		}
+	}
+
+	var stats []IPStat
+	for _, entry := range topN(counts, n) {
+		conn := details[entry.Name]
+		stats = append(stats, IPStat{
+			IP:           entry.Name,
+			Count:        entry.Count,
+			SessionCount: conn.Hits,
+			Country:      conn.Country,
+			ASN:          conn.ASN,
+			Org:          conn.Org,
+		})
+	}
+	return stats
+}
+
+// TopPorts ranks destination ports, naming each by the protocol most of its
+// events reported or, failing that, its well known service
+func (cl ConnectionList) TopPorts(n int) []PortStat {
+	counts := cl.countBy(func(c api.Connection) string {
+		if c.Port == 0 {
+			return ""
+		}
+		return strconv.Itoa(c.Port)
+	})
+
+	protocols := make(map[string]map[string]int)
+	for _, conn := range cl {
+		if conn.Port == 0 || conn.Protocol == "" {
+			continue
+		}
+		key := strconv.Itoa(conn.Port)
+		if protocols[key] == nil {
+			protocols[key] = make(map[string]int)
+		}
+		protocols[key][strings.ToLower(conn.Protocol)]++
+	}
+
+	var stats []PortStat
+	for _, entry := range topN(counts, n) {
+		port, _ := strconv.Atoi(entry.Name)
+		service := mhn.ServicePorts[port]
+		if top := topN(protocols[entry.Name], 1); len(top) > 0 {
+			service = top[0].Name
+		}
+		stats = append(stats, PortStat{Port: port, Service: service, Count: entry.Count})
+	}
+	return stats
+}
+
+// credentialSummary sums up the pairs cs counted for /api/credentials
+func credentialSummary(cs *stats.CredentialStats, topPairs int) CredentialSummary {
+	counts := cs.PairCounts()
+	bins, sorted := cs.Histogram()
+
+	summary := CredentialSummary{
+		Window:   cs.Window().String(),
+		Pairs:    len(sorted),
+		P50:      percentile(sorted, 50),
+		P90:      percentile(sorted, 90),
+		P99:      percentile(sorted, 99),
+		TopPairs: topN(counts, topPairs),
+	}
+	for _, c := range sorted {
+		summary.Attempts += c
+	}
+	summary.Shape = credentialShape(sorted, summary.Attempts)
+	for i, label := range stats.HistogramLabels {
+		summary.Histogram = append(summary.Histogram, StatEntry{Name: label, Count: bins[i]})
+	}
+	return summary
+}
@@ -0,0 +1,499 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	maxAlertLog       = 200
+	alertFlashTime    = 10 * time.Second
+	alertWebhookQueue = 100
+)
+
+// AlertRule matches enriched events and fires actions. Every non-empty match
+// list must contain the event's value; list entries may use * and ? wildcards.
+// With Rate set the rule only fires once that many matching events from the
+// same source IP arrive within Window.
+type AlertRule struct {
+	Name     string   `toml:"name"`
+	Country  []string `toml:"country"`  // Country name, e.g. "China"
+	ASN      []string `toml:"asn"`      // "AS4134" or "4134"
+	Protocol []string `toml:"protocol"` // ssh, telnet, http, ...
+	Username []string `toml:"username"`
+	IP       []string `toml:"ip"` // Addresses or CIDRs
+	Rate     int      `toml:"rate"`
+	Window   string   `toml:"window"`   // Rate window (default 1m)
+	Cooldown string   `toml:"cooldown"` // Minimum time between firings per source IP (default 1m)
+	Actions  []string `toml:"actions"`  // highlight, flash, banner, webhook (default highlight + flash)
+	Severity string   `toml:"severity"` // info, warning or critical (default warning)
+	Webhook  string   `toml:"webhook"`
+	Format   string   `toml:"format"` // slack, discord or generic (default generic)
+
+	window   time.Duration
+	cooldown time.Duration
+	networks []*net.IPNet
+}
+
+type alertRulesFile struct {
+	Rules []AlertRule `toml:"rule"`
+}
+
+// Alert is one rule firing, shown in the alerts panel and posted to webhooks
+type Alert struct {
+	Rule     string    `json:"rule"`
+	Time     time.Time `json:"time"`
+	IP       string    `json:"src_ip"`
+	Country  string    `json:"country,omitempty"`
+	City     string    `json:"city,omitempty"`
+	ASN      string    `json:"asn,omitempty"`
+	Org      string    `json:"org,omitempty"`
+	Protocol string    `json:"protocol,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Password string    `json:"password,omitempty"`
+	Count    int       `json:"count,omitempty"` // Matching events in the window for rate rules
+	Severity string    `json:"severity"`
+}
+
+// Summary is the one line description used in the panel and chat webhooks
+func (a Alert) Summary() string {
+	where := a.Country
+	if where == "" {
+		where = "unknown location"
+	}
+	s := fmt.Sprintf("[%s] %s from %s (%s)", a.Rule, a.Protocol, a.IP, where)
+	if a.Count > 0 {
+		s += fmt.Sprintf(" x%d", a.Count)
+	}
+	return s
+}
+
+type alertDelivery struct {
+	url    string
+	format string
+	alert  Alert
+}
+
+// AlertEngine evaluates rules against every new connection
+type AlertEngine struct {
+	path       string // Rules file, empty when alerting is off
+	rules      []AlertRule
+	hits       map[string][]time.Time // rule|ip -> matching event times within the window
+	lastFired  map[string]time.Time   // rule|ip -> last time the rule fired
+	flash      map[string]time.Time   // IP -> flash expiry
+	log        []Alert
+	deliveries chan alertDelivery
+	httpClient *http.Client
+	started    bool // Whether the webhook worker is running
+	mutex      sync.RWMutex
+}
+
+// LoadAlertRules reads [[rule]] tables from a TOML file and validates them
+func LoadAlertRules(path string) ([]AlertRule, error) {
+	var file alertRulesFile
+	meta, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown key %s", undecoded[0])
+	}
+
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+
+		rule.window, err = parseRuleDuration(rule.Window, time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: window: %v", rule.Name, err)
+		}
+		rule.cooldown, err = parseRuleDuration(rule.Cooldown, time.Minute)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: cooldown: %v", rule.Name, err)
+		}
+		if rule.Rate < 0 {
+			return nil, fmt.Errorf("rule %s: rate must not be negative", rule.Name)
+		}
+
+		for _, entry := range rule.IP {
+			if !strings.Contains(entry, "/") {
+				ip := net.ParseIP(entry)
+				if ip == nil {
+					return nil, fmt.Errorf("rule %s: invalid IP %q", rule.Name, entry)
+				}
+				bits := 128
+				if ip.To4() != nil {
+					bits = 32
+				}
+				entry = fmt.Sprintf("%s/%d", entry, bits)
+			}
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid CIDR %q", rule.Name, entry)
+			}
+			rule.networks = append(rule.networks, network)
+		}
+
+		switch rule.Severity {
+		case "":
+			rule.Severity = "warning"
+		case "info", "warning", "critical":
+		default:
+			return nil, fmt.Errorf("rule %s: unknown severity %q (want info, warning or critical)", rule.Name, rule.Severity)
+		}
+
+		if len(rule.Actions) == 0 {
+			rule.Actions = []string{"highlight", "flash"}
+			if rule.Severity == "critical" {
+				rule.Actions = append(rule.Actions, "banner")
+			}
+			if rule.Webhook != "" {
+				rule.Actions = append(rule.Actions, "webhook")
+			}
+		}
+		for _, action := range rule.Actions {
+			switch action {
+			case "highlight", "flash", "banner":
+			case "webhook":
+				if rule.Webhook == "" {
+					return nil, fmt.Errorf("rule %s: webhook action needs a webhook URL", rule.Name)
+				}
+			default:
+				return nil, fmt.Errorf("rule %s: unknown action %q (want highlight, flash, banner or webhook)", rule.Name, action)
+			}
+		}
+
+		switch rule.Format {
+		case "":
+			rule.Format = "generic"
+		case "slack", "discord", "generic":
+		default:
+			return nil, fmt.Errorf("rule %s: unknown format %q (want slack, discord or generic)", rule.Name, rule.Format)
+		}
+	}
+
+	return file.Rules, nil
+}
+
+func parseRuleDuration(s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
+
+func NewAlertEngine(path string, rules []AlertRule) *AlertEngine {
+	return &AlertEngine{
+		path:       path,
+		rules:      rules,
+		hits:       make(map[string][]time.Time),
+		lastFired:  make(map[string]time.Time),
+		flash:      make(map[string]time.Time),
+		deliveries: make(chan alertDelivery, alertWebhookQueue),
+		httpClient: api.NewHTTPClient(10 * time.Second),
+	}
+}
+
+// Reload reads the rules from path, which may name a different file than
+// before or be empty to turn alerting off. The current rules are kept if
+// the file is invalid.
+func (ae *AlertEngine) Reload(path string) error {
+	var rules []AlertRule
+	if path != "" {
+		var err error
+		if rules, err = LoadAlertRules(path); err != nil {
+			return err
+		}
+	}
+	ae.mutex.Lock()
+	if path != ae.path {
+		debugLog("Alerts: Rules file is now %q", path)
+	}
+	ae.path = path
+	ae.rules = rules
+	ae.hits = make(map[string][]time.Time)
+	ae.lastFired = make(map[string]time.Time)
+	ae.mutex.Unlock()
+	if path != "" {
+		ae.Start()
+	}
+	return nil
+}
+
+// Path returns the rules file, or "" when alerting is off
+func (ae *AlertEngine) Path() string {
+	ae.mutex.RLock()
+	defer ae.mutex.RUnlock()
+	return ae.path
+}
+
+// Start runs the webhook delivery worker, once
+func (ae *AlertEngine) Start() {
+	ae.mutex.Lock()
+	started := ae.started
+	ae.started = true
+	ae.mutex.Unlock()
+	if started {
+		return
+	}
+	api.Workers.Go("alert-webhook", func(stop <-chan struct{}) error {
+		for {
+			select {
+			case <-stop:
+				return nil
+			case d := <-ae.deliveries:
+				if err := ae.post(d); err != nil {
+					debugLog("Alerts: Webhook for %s failed: %v", d.alert.Rule, err)
+				}
+			}
+		}
+	})
+}
+
+// Evaluate runs every rule against an enriched connection. It returns the
+// name of the first firing rule that highlights rows, or "".
+func (ae *AlertEngine) Evaluate(conn api.Connection) string {
+	ae.mutex.Lock()
+	defer ae.mutex.Unlock()
+
+	highlight := ""
+	for i := range ae.rules {
+		rule := &ae.rules[i]
+		if !rule.matches(conn) {
+			continue
+		}
+
+		key := rule.Name + "|" + conn.IP
+		count := 0
+		if rule.Rate > 0 {
+			cutoff := conn.Time.Add(-rule.window)
+			recent := ae.hits[key][:0]
+			for _, t := range ae.hits[key] {
+				if t.After(cutoff) {
+					recent = append(recent, t)
+				}
+			}
+			recent = append(recent, conn.Time)
+			ae.hits[key] = recent
+			count = len(recent)
+			if count < rule.Rate {
+				continue
+			}
+		}
+
+		if last, ok := ae.lastFired[key]; ok && conn.Time.Sub(last) < rule.cooldown {
+			// Still part of the same incident: keep the row highlighted
+			if highlight == "" && rule.hasAction("highlight") {
+				highlight = rule.Name
+			}
+			continue
+		}
+		ae.lastFired[key] = conn.Time
+
+		alert := Alert{
+			Rule:     rule.Name,
+			Time:     conn.Time,
+			IP:       conn.IP,
+			Country:  conn.Country,
+			City:     conn.City,
+			ASN:      conn.ASN,
+			Org:      conn.Org,
+			Protocol: conn.Protocol,
+			Username: conn.Username,
+			Password: conn.Password,
+			Count:    count,
+			Severity: rule.Severity,
+		}
+		ae.log = append(ae.log, alert)
+		if len(ae.log) > maxAlertLog {
+			ae.log = ae.log[len(ae.log)-maxAlertLog:]
+		}
+		debugLog("Alerts: %s", alert.Summary())
+		globalToasts.Post("Alert: "+alert.Summary(), true)
+
+		if highlight == "" && rule.hasAction("highlight") {
+			highlight = rule.Name
+		}
+		if rule.hasAction("flash") {
+			ae.flash[conn.IP] = conn.Time.Add(alertFlashTime)
+		}
+		if rule.hasAction("banner") && GlobalBanner != nil {
+			GlobalBanner.Post(rule.Severity, alert.Summary(), conn.Time)
+		}
+		if rule.hasAction("webhook") {
+			select {
+			case ae.deliveries <- alertDelivery{url: rule.Webhook, format: rule.Format, alert: alert}:
+			default:
+				debugLog("Alerts: Webhook queue full, dropping %s", alert.Summary())
+			}
+		}
+	}
+
+	ae.prune(conn.Time)
+	return highlight
+}
+
+// prune drops rate windows and flashes that can no longer matter so the maps
+// do not grow with every attacker ever seen
+func (ae *AlertEngine) prune(now time.Time) {
+	if len(ae.hits)+len(ae.lastFired)+len(ae.flash) < 4096 {
+		return
+	}
+	maxAge := time.Duration(0)
+	for _, rule := range ae.rules {
+		if rule.window > maxAge {
+			maxAge = rule.window
+		}
+		if rule.cooldown > maxAge {
+			maxAge = rule.cooldown
+		}
+	}
+	for key, times := range ae.hits {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) > maxAge {
+			delete(ae.hits, key)
+		}
+	}
+	for key, t := range ae.lastFired {
+		if now.Sub(t) > maxAge {
+			delete(ae.lastFired, key)
+		}
+	}
+	for ip, until := range ae.flash {
+		if now.After(until) {
+			delete(ae.flash, ip)
+		}
+	}
+}
+
+// Recent returns up to n alerts, newest first
+func (ae *AlertEngine) Recent(n int) []Alert {
+	ae.mutex.RLock()
+	defer ae.mutex.RUnlock()
+	if n <= 0 || n > len(ae.log) {
+		n = len(ae.log)
+	}
+	recent := make([]Alert, 0, n)
+	for i := len(ae.log) - 1; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, ae.log[i])
+	}
+	return recent
+}
+
+// Flashing returns the IPs whose globe markers should currently flash
+func (ae *AlertEngine) Flashing(now time.Time) map[string]bool {
+	ae.mutex.RLock()
+	defer ae.mutex.RUnlock()
+	flashing := make(map[string]bool)
+	for ip, until := range ae.flash {
+		if now.Before(until) {
+			flashing[ip] = true
+		}
+	}
+	return flashing
+}
+
+func (rule *AlertRule) hasAction(action string) bool {
+	for _, a := range rule.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule *AlertRule) matches(conn api.Connection) bool {
+	if !matchAnyPattern(rule.Country, conn.Country) ||
+		!matchAnyPattern(rule.Protocol, conn.Protocol) ||
+		!matchAnyPattern(rule.Username, conn.Username) {
+		return false
+	}
+	if len(rule.ASN) > 0 {
+		asn := strings.TrimPrefix(strings.ToUpper(conn.ASN), "AS")
+		found := false
+		for _, want := range rule.ASN {
+			if strings.TrimPrefix(strings.ToUpper(want), "AS") == asn && asn != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(rule.networks) > 0 {
+		ip := net.ParseIP(conn.IP)
+		found := false
+		for _, network := range rule.networks {
+			if ip != nil && network.Contains(ip) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAnyPattern reports whether value matches one of the case-insensitive
+// glob patterns; an empty pattern list matches everything
+func matchAnyPattern(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	value = strings.ToLower(value)
+	for _, pattern := range patterns {
+		if ok, err := filepath.Match(strings.ToLower(pattern), value); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (ae *AlertEngine) post(d alertDelivery) error {
+	var payload interface{}
+	switch d.format {
+	case "slack":
+		payload = map[string]string{"text": d.alert.Summary()}
+	case "discord":
+		payload = map[string]string{"content": d.alert.Summary()}
+	default:
+		payload = d.alert
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := ae.httpClient.Post(d.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
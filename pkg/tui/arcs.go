@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AttackArc struct {
+	SrcIP     string
+	SrcLat    float64
+	SrcLon    float64
+	DstLat    float64
+	DstLon    float64
+	Protocol  string
+	CreatedAt time.Time
+	TTL       time.Duration // How long the arc persists
+}
+
+type ArcManager struct {
+	arcs      []AttackArc
+	arcStyle  string     // "curved", "straight", "off"
+	trailMS   int        // Trail persistence in milliseconds
+	maxArcs   int        // Oldest arcs are dropped beyond this many
+	honeypots []Honeypot // Arc destinations; the first is the default
+	mutex     sync.RWMutex
+}
+
+func NewArcManager(arcStyle string, trailMS int) *ArcManager {
+	honeypots, _ := ParseHoneypots(DefaultHoneypots)
+	return &ArcManager{
+		arcs:      make([]AttackArc, 0),
+		arcStyle:  arcStyle,
+		trailMS:   trailMS,
+		maxArcs:   DefaultMaxArcs,
+		honeypots: honeypots,
+	}
+}
+
+// Honeypot is a sensor location arcs are drawn to and the globe marks
+type Honeypot struct {
+	Name string
+	Lat  float64
+	Lon  float64
+}
+
+// DefaultHoneypots is the SecKC honeypot in Kansas City
+const DefaultHoneypots = "SecKC,39.0997,-94.5786"
+
+// ParseHoneypots reads a ";" separated list of "name,lat,lon" honeypots
+func ParseHoneypots(spec string) ([]Honeypot, error) {
+	var honeypots []Honeypot
+	for _, item := range strings.Split(spec, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		parts := strings.Split(item, ",")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("want name,lat,lon, got %q", item)
+		}
+		honeypot := Honeypot{Name: strings.TrimSpace(parts[0])}
+		for i, value := range []*float64{&honeypot.Lat, &honeypot.Lon} {
+			v, err := strconv.ParseFloat(strings.TrimSpace(parts[i+1]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", parts[i+1])
+			}
+			*value = v
+		}
+		switch {
+		case honeypot.Name == "":
+			return nil, fmt.Errorf("honeypot needs a name")
+		case honeypot.Lat < -90 || honeypot.Lat > 90:
+			return nil, fmt.Errorf("latitude must be between -90 and 90")
+		case honeypot.Lon < -180 || honeypot.Lon > 180:
+			return nil, fmt.Errorf("longitude must be between -180 and 180")
+		}
+		honeypots = append(honeypots, honeypot)
+	}
+	if len(honeypots) == 0 {
+		return nil, fmt.Errorf("need at least one honeypot")
+	}
+	return honeypots, nil
+}
+
+// SetHoneypots replaces the arc destinations; arcs already drawn keep theirs
+func (am *ArcManager) SetHoneypots(honeypots []Honeypot) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.honeypots = honeypots
+}
+
+func (am *ArcManager) Honeypots() []Honeypot {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+	return slices.Clone(am.honeypots)
+}
+
+// destination is the honeypot named like the API endpoint that reported an
+// event, or the first one. Callers hold the lock.
+func (am *ArcManager) destination(origin string) Honeypot {
+	if origin != "" {
+		for _, honeypot := range am.honeypots {
+			if strings.EqualFold(honeypot.Name, origin) {
+				return honeypot
+			}
+		}
+	}
+	return am.honeypots[0]
+}
+
+// SetMaxArcs caps the number of live arcs
+func (am *ArcManager) SetMaxArcs(n int) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.maxArcs = n
+}
+
+// Shed drops the oldest half of the live arcs
+func (am *ArcManager) Shed() {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.arcs = append([]AttackArc(nil), am.arcs[len(am.arcs)/2:]...)
+}
+
+// AddArc draws an arc from an attack to the honeypot of the API endpoint
+// (origin) that reported it
+func (am *ArcManager) AddArc(srcIP string, srcLat, srcLon float64, protocol, origin string) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	dst := am.destination(origin)
+	arc := AttackArc{
+		SrcIP:     srcIP,
+		SrcLat:    srcLat,
+		SrcLon:    srcLon,
+		DstLat:    dst.Lat,
+		DstLon:    dst.Lon,
+		Protocol:  protocol,
+		CreatedAt: time.Now(),
+		TTL:       time.Duration(am.trailMS) * time.Millisecond,
+	}
+	am.arcs = append(am.arcs, arc)
+	if len(am.arcs) > am.maxArcs {
+		am.arcs = append([]AttackArc(nil), am.arcs[len(am.arcs)-am.maxArcs:]...)
+	}
+}
+
+func (am *ArcManager) CleanupExpired() {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	now := time.Now()
+	validArcs := make([]AttackArc, 0)
+	for _, arc := range am.arcs {
+		if now.Sub(arc.CreatedAt) < arc.TTL {
+			validArcs = append(validArcs, arc)
+		}
+	}
+	am.arcs = validArcs
+}
+
+func (am *ArcManager) GetActiveArcs() []AttackArc {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+
+	arcsCopy := make([]AttackArc, len(am.arcs))
+	copy(arcsCopy, am.arcs)
+	return arcsCopy
+}
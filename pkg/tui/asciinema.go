@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"github.com/gdamore/tcell/v2"
+)
+
+// RecordFormats are the --record-format choices: the cast, the events
+// file written beside it, or both
+var RecordFormats = []string{"both", "cast", "events"}
+
+type AsciinemaRecorder struct {
+	enabled   bool // Casting frames
+	file      *os.File
+	events    *os.File // Raw events beside the cast, nil when not recorded
+	eventsMu  sync.Mutex
+	paths     []string // Files being written
+	startTime time.Time
+	width     int
+	height    int
+	prevFrame [][]RecordedCell // Last recorded frame for diff-based updates
+	lastStyle tcell.Style      // SGR state the player is currently in
+}
+
+// recordedEvent is one line of the events file: an event and when it
+// arrived, in seconds from the start of the cast
+type recordedEvent struct {
+	T float64 `json:"t"`
+	api.EnrichedEvent
+}
+
+// eventsPath names the events file recorded beside a cast, demo.cast
+// becoming demo.events.ndjson
+func eventsPath(castPath string) string {
+	return strings.TrimSuffix(castPath, filepath.Ext(castPath)) + ".events.ndjson"
+}
+
+// RecordedCell is a single screen cell captured for recording
+type RecordedCell struct {
+	Rune  rune
+	Style tcell.Style
+}
+
+// NewAsciinemaRecorder starts recording to path in one of recordFormats: an
+// asciinema cast, an NDJSON file of the events that arrive, or both, with
+// the events timed from the start of the cast so the two replay in sync
+func NewAsciinemaRecorder(path, format string, width, height int) (*AsciinemaRecorder, error) {
+	if path == "" {
+		return &AsciinemaRecorder{enabled: false}, nil
+	}
+
+	recorder := &AsciinemaRecorder{
+		startTime: time.Now(),
+		width:     width,
+		height:    height,
+	}
+	if format == "both" || format == "events" {
+		events, err := os.Create(eventsPath(path))
+		if err != nil {
+			return nil, err
+		}
+		recorder.events = events
+		recorder.paths = append(recorder.paths, events.Name())
+	}
+	if format == "events" {
+		return recorder, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		recorder.Close()
+		return nil, err
+	}
+	recorder.enabled = true
+	recorder.file = file
+	recorder.paths = append([]string{path}, recorder.paths...)
+
+	// Write asciinema v2 header
+	header := map[string]interface{}{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": time.Now().Unix(),
+		"env": map[string]string{
+			"TERM":  "xterm-256color",
+			"SHELL": "/bin/bash",
+		},
+	}
+	headerJSON, _ := json.Marshal(header)
+	file.Write(headerJSON)
+	file.Write([]byte("\n"))
+
+	return recorder, nil
+}
+
+// RecordFrame writes the ANSI escape sequences needed to turn the previously
+// recorded frame into this one, so casts replay with positioning and color
+func (ar *AsciinemaRecorder) RecordFrame(screen [][]RecordedCell) {
+	if !ar.enabled {
+		return
+	}
+
+	var sb strings.Builder
+
+	// Full redraw on the first frame or when the terminal size changed
+	fullRedraw := len(ar.prevFrame) != len(screen)
+	if !fullRedraw {
+		for y := range screen {
+			if len(ar.prevFrame[y]) != len(screen[y]) {
+				fullRedraw = true
+				break
+			}
+		}
+	}
+	if fullRedraw {
+		ar.lastStyle = tcell.StyleDefault
+		sb.WriteString("\x1b[0m\x1b[2J\x1b[H")
+	}
+
+	for y, row := range screen {
+		cursorX := -1 // Column the player cursor sits at on this row, -1 if unknown
+		for x, cell := range row {
+			if !fullRedraw && ar.prevFrame[y][x] == cell {
+				continue
+			}
+			if cursorX != x {
+				fmt.Fprintf(&sb, "\x1b[%d;%dH", y+1, x+1)
+			}
+			if cell.Style != ar.lastStyle {
+				sb.WriteString(styleToSGR(cell.Style))
+				ar.lastStyle = cell.Style
+			}
+			r := cell.Rune
+			if r == 0 {
+				r = ' '
+			}
+			sb.WriteRune(r)
+			cursorX = x + 1
+		}
+	}
+
+	ar.prevFrame = screen
+
+	if sb.Len() == 0 {
+		return
+	}
+
+	timestamp := time.Since(ar.startTime).Seconds()
+	event := []interface{}{timestamp, "o", sb.String()}
+	eventJSON, _ := json.Marshal(event)
+	ar.file.Write(eventJSON)
+	ar.file.Write([]byte("\n"))
+}
+
+// styleToSGR converts a tcell style into a full SGR escape sequence
+func styleToSGR(style tcell.Style) string {
+	fg, bg, attr := style.Decompose()
+
+	codes := []string{"0"}
+	if attr&tcell.AttrBold != 0 {
+		codes = append(codes, "1")
+	}
+	if attr&tcell.AttrDim != 0 {
+		codes = append(codes, "2")
+	}
+	if attr&tcell.AttrItalic != 0 {
+		codes = append(codes, "3")
+	}
+	if attr&tcell.AttrUnderline != 0 {
+		codes = append(codes, "4")
+	}
+	if attr&tcell.AttrBlink != 0 {
+		codes = append(codes, "5")
+	}
+	if attr&tcell.AttrReverse != 0 {
+		codes = append(codes, "7")
+	}
+	if attr&tcell.AttrStrikeThrough != 0 {
+		codes = append(codes, "9")
+	}
+
+	if fg != tcell.ColorDefault && fg.Valid() {
+		r, g, b := fg.RGB()
+		codes = append(codes, fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+	}
+	if bg != tcell.ColorDefault && bg.Valid() {
+		r, g, b := bg.RGB()
+		codes = append(codes, fmt.Sprintf("48;2;%d;%d;%d", r, g, b))
+	}
+
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// Active reports whether the recorder is writing anything
+func (ar *AsciinemaRecorder) Active() bool {
+	ar.eventsMu.Lock()
+	defer ar.eventsMu.Unlock()
+	return ar.enabled || ar.events != nil
+}
+
+// Paths lists the files being written, the cast first
+func (ar *AsciinemaRecorder) Paths() []string {
+	return ar.paths
+}
+
+// RecordEvent appends an event to the events file, if one is recorded
+func (ar *AsciinemaRecorder) RecordEvent(event api.EnrichedEvent) {
+	ar.eventsMu.Lock()
+	defer ar.eventsMu.Unlock()
+	if ar.events == nil {
+		return
+	}
+	line, err := json.Marshal(recordedEvent{T: time.Since(ar.startTime).Seconds(), EnrichedEvent: event})
+	if err != nil {
+		return
+	}
+	if _, err := ar.events.Write(append(line, '\n')); err != nil {
+		debugLog("Recording: events file: %v", err)
+		ar.events.Close()
+		ar.events = nil
+	}
+}
+
+func (ar *AsciinemaRecorder) Close() {
+	if ar.enabled && ar.file != nil {
+		ar.file.Close()
+	}
+	ar.eventsMu.Lock()
+	if ar.events != nil {
+		ar.events.Close()
+		ar.events = nil
+	}
+	ar.eventsMu.Unlock()
+}
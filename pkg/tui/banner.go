@@ -0,0 +1,139 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const maxBannerItems = 20
+
+// bannerSeverities lists the severity levels, lowest first
+var bannerSeverities = []string{"info", "warning", "critical"}
+
+// BannerItem is one alert or incident pinned to the banner lane
+type BannerItem struct {
+	Severity string
+	Text     string
+	Time     time.Time // Latest occurrence
+	Count    int       // Occurrences since it was first posted
+}
+
+// BannerLane holds alerts and incidents that stay on screen above the
+// dashboard until the operator acknowledges them
+type BannerLane struct {
+	items []BannerItem
+	mutex sync.RWMutex
+}
+
+func NewBannerLane() *BannerLane {
+	return &BannerLane{}
+}
+
+// Post pins text to the lane, counting repeats of an item already there
+func (bl *BannerLane) Post(severity, text string, t time.Time) {
+	bl.mutex.Lock()
+	defer bl.mutex.Unlock()
+	for i := range bl.items {
+		if bl.items[i].Text == text {
+			bl.items[i].Count++
+			bl.items[i].Time = t
+			if IndexOf(bannerSeverities, severity) > IndexOf(bannerSeverities, bl.items[i].Severity) {
+				bl.items[i].Severity = severity
+			}
+			return
+		}
+	}
+	bl.items = append(bl.items, BannerItem{Severity: severity, Text: text, Time: t, Count: 1})
+	if len(bl.items) > maxBannerItems {
+		bl.items = bl.items[len(bl.items)-maxBannerItems:]
+	}
+}
+
+// Pending returns the unacknowledged items, most severe and then newest first
+func (bl *BannerLane) Pending() []BannerItem {
+	bl.mutex.RLock()
+	items := append([]BannerItem(nil), bl.items...)
+	bl.mutex.RUnlock()
+	sort.SliceStable(items, func(i, j int) bool {
+		si, sj := IndexOf(bannerSeverities, items[i].Severity), IndexOf(bannerSeverities, items[j].Severity)
+		if si != sj {
+			return si > sj
+		}
+		return items[i].Time.After(items[j].Time)
+	})
+	return items
+}
+
+// Acknowledge clears the lane
+func (bl *BannerLane) Acknowledge() {
+	bl.mutex.Lock()
+	defer bl.mutex.Unlock()
+	bl.items = nil
+}
+
+// PostBanner pins an incident to the banner lane when it is enabled
+func PostBanner(severity, format string, args ...interface{}) {
+	if GlobalBanner != nil {
+		GlobalBanner.Post(severity, fmt.Sprintf(format, args...), time.Now())
+	}
+}
+
+// renderBanner draws the lane above the dashboard. Critical items blink and
+// text too long for the lane scrolls past.
+func (tui *TUI) renderBanner(snap *FrameSnapshot) {
+	if !snap.View.ShowBanner {
+		return
+	}
+	startX := snap.Globe.Width + 3
+	width := snap.Width - startX
+	if width <= 0 {
+		return
+	}
+
+	style := tcell.StyleDefault.Foreground(snap.Theme.Separator).Background(snap.Theme.Background)
+	text := "No active alerts"
+	if len(snap.Banner) > 0 {
+		item := snap.Banner[0]
+		color := snap.Theme.Stats
+		switch item.Severity {
+		case "critical":
+			color = snap.Theme.Attack
+		case "warning":
+			color = snap.Theme.Dashboard
+		}
+		style = tcell.StyleDefault.Foreground(color).Background(snap.Theme.Background).Bold(true).Reverse(true)
+		if item.Severity == "critical" && snap.Taken.UnixMilli()/500%2 == 0 {
+			style = style.Reverse(false)
+		}
+
+		text = fmt.Sprintf("%s %s %s", strings.ToUpper(item.Severity), item.Time.Format("15:04:05"), item.Text)
+		if item.Count > 1 {
+			text += fmt.Sprintf(" (x%d)", item.Count)
+		}
+		if more := len(snap.Banner) - 1; more > 0 {
+			text += fmt.Sprintf(" [+%d more]", more)
+		}
+		text += " · Bksp:Ack"
+
+		// Scroll text that does not fit, with a gap before it comes round again
+		if textWidth(text) > width {
+			loop := append([]rune(text), []rune("   ")...)
+			offset := int(snap.Taken.UnixMilli()/150) % len(loop)
+			text = string(append(loop[offset:], loop[:offset]...))
+		}
+	}
+
+	tui.drawText(startX, 0, padCells(clipCells(text, width, ""), width), style)
+}
+
+// AcknowledgeBanner clears the banner lane
+func (tui *TUI) AcknowledgeBanner() {
+	if GlobalBanner != nil {
+		GlobalBanner.Acknowledge()
+	}
+}
@@ -0,0 +1,235 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// followEase is how long the follow camera takes to turn to a new attack
+const followEase = time.Second
+
+// CameraTurn swings the globe round to face a longitude and holds it there;
+// afterwards the spin carries on from the held angle instead of jumping back
+// to where the clock says it should be. Callers provide the locking.
+type CameraTurn struct {
+	start  time.Time
+	until  time.Time
+	ease   time.Duration
+	from   float64 // Rotation when the turn started, NaN until the next Rotation call
+	target float64 // Rotation that faces the target longitude
+	offset float64 // Added to the clock-driven spin
+}
+
+// Face starts a turn towards lon that takes ease and lasts until hold has passed
+func (c *CameraTurn) Face(lon float64, now time.Time, ease, hold time.Duration) {
+	c.start = now
+	c.until = now.Add(hold)
+	c.ease = ease
+	c.from = math.NaN()
+	c.target = lon * math.Pi / 180
+}
+
+// Turning reports whether a turn is still easing in or holding
+func (c *CameraTurn) Turning(now time.Time) bool {
+	return now.Before(c.until)
+}
+
+// Rotation replaces the clock-driven spin during a turn
+func (c *CameraTurn) Rotation(now time.Time, spin float64) float64 {
+	if !c.Turning(now) {
+		return spin + c.offset
+	}
+	if math.IsNaN(c.from) {
+		c.from = spin + c.offset
+	}
+	// Turn the short way round to the target
+	delta := math.Remainder(c.target-c.from, 2*math.Pi)
+	rot := c.from + delta*smoothstep(float64(now.Sub(c.start))/float64(c.ease))
+	c.offset = rot - spin
+	return rot
+}
+
+func smoothstep(t float64) float64 {
+	t = math.Max(0, math.Min(1, t))
+	return t * t * (3 - 2*t)
+}
+
+// FollowCamera turns the globe to face each new attack's source in turn.
+// Attacks that arrive mid-turn are skipped; the next turn picks up whatever
+// is newest once the current one has finished.
+type FollowCamera struct {
+	turn     CameraTurn
+	lastIP   string
+	lastTime time.Time
+	mutex    sync.Mutex
+}
+
+// Tick starts a turn towards the newest geolocated attack
+func (fc *FollowCamera) Tick(tui *TUI, now time.Time) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	if fc.turn.Turning(now) || GlobalGeoIP == nil {
+		return
+	}
+	conn, ok := tui.dashboard.Latest()
+	if !ok || (conn.IP == fc.lastIP && conn.Time.Equal(fc.lastTime)) {
+		return
+	}
+	fc.lastIP, fc.lastTime = conn.IP, conn.Time
+	if loc := GlobalGeoIP.LookupIP(conn.IP); loc.Valid {
+		// Hold for as long again so each source is on screen for a moment
+		fc.turn.Face(loc.Longitude, now, followEase, 2*followEase)
+		if tui.frameRate != nil {
+			tui.frameRate.Touch()
+		}
+	}
+}
+
+func (fc *FollowCamera) Rotation(now time.Time, spin float64) float64 {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.turn.Rotation(now, spin)
+}
+
+// holdUntilReleased keeps a camera turn in place until Release is called
+const holdUntilReleased = time.Duration(math.MaxInt64)
+
+// Release ends a turn; the spin carries on from the held angle
+func (c *CameraTurn) Release(now time.Time) {
+	if c.Turning(now) {
+		c.until = now
+	}
+}
+
+// DefaultViewPresets frame the regions bound to keys 1-9, as
+// "name,lat,lon,zoom" so each can be replaced from the config file
+var DefaultViewPresets = [9]string{
+	"North America,40,-100,2.0",
+	"South America,-15,-60,2.0",
+	"Europe,50,15,2.6",
+	"Africa,5,20,2.0",
+	"Middle East,28,45,2.6",
+	"Russia,60,90,2.0",
+	"East Asia,35,115,2.2",
+	"South Asia,20,80,2.6",
+	"Oceania,-25,135,2.0",
+}
+
+// ViewPreset frames one region of the globe
+type ViewPreset struct {
+	Name string
+	Lat  float64
+	Lon  float64
+	Zoom float64
+}
+
+// ParseViewPreset reads a "name,lat,lon,zoom" preset
+func ParseViewPreset(spec string) (ViewPreset, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 4 {
+		return ViewPreset{}, fmt.Errorf("want name,lat,lon,zoom, got %q", spec)
+	}
+	preset := ViewPreset{Name: strings.TrimSpace(parts[0])}
+	values := []*float64{&preset.Lat, &preset.Lon, &preset.Zoom}
+	for i, value := range values {
+		v, err := strconv.ParseFloat(strings.TrimSpace(parts[i+1]), 64)
+		if err != nil {
+			return ViewPreset{}, fmt.Errorf("invalid number %q", parts[i+1])
+		}
+		*value = v
+	}
+	switch {
+	case preset.Name == "":
+		return ViewPreset{}, fmt.Errorf("preset needs a name")
+	case preset.Lat < -90 || preset.Lat > 90:
+		return ViewPreset{}, fmt.Errorf("latitude must be between -90 and 90")
+	case preset.Lon < -180 || preset.Lon > 180:
+		return ViewPreset{}, fmt.Errorf("longitude must be between -180 and 180")
+	case preset.Zoom < minZoom || preset.Zoom > maxZoom:
+		return ViewPreset{}, fmt.Errorf("zoom must be between %.1f and %.1f", minZoom, maxZoom)
+	}
+	return preset, nil
+}
+
+// ViewPresets turns the globe to a preset region on keys 1-9 and holds it
+// there until 0 releases the view
+type ViewPresets struct {
+	presets [9]ViewPreset
+	turn    CameraTurn
+	mutex   sync.Mutex
+}
+
+func NewViewPresets(presets [9]ViewPreset) *ViewPresets {
+	return &ViewPresets{presets: presets}
+}
+
+// ApplyViewPreset frames preset n (1-9), or for 0 resets zoom and nudge and
+// lets the globe spin again
+func (tui *TUI) ApplyViewPreset(n int) {
+	vp := tui.presets
+	if vp == nil || n < 0 || n > len(vp.presets) {
+		return
+	}
+	if n > 0 {
+		tui.FaceView(vp.presets[n-1])
+		return
+	}
+	vp.mutex.Lock()
+	vp.turn.Release(time.Now())
+	vp.mutex.Unlock()
+	tui.mutex.Lock()
+	tui.globe.Zoom = 1.0
+	tui.globe.NudgeX, tui.globe.NudgeY = 0, 0
+	tui.mutex.Unlock()
+	tui.setViewPreset("")
+}
+
+// FaceView turns the globe to frame a region and holds it there, like the
+// presets on 1-9
+func (tui *TUI) FaceView(preset ViewPreset) {
+	vp := tui.presets
+	if vp == nil {
+		return
+	}
+	vp.mutex.Lock()
+	vp.turn.Face(preset.Lon, time.Now(), followEase, holdUntilReleased)
+	vp.mutex.Unlock()
+	tui.mutex.Lock()
+	g := tui.globe
+	g.Zoom = preset.Zoom
+	g.NudgeX, g.NudgeY = g.FocusNudge(preset.Lat, preset.Lon)
+	tui.mutex.Unlock()
+	tui.setViewPreset(preset.Name)
+}
+
+// setViewPreset names the region holding the view in the status line, ""
+// once it is released
+func (tui *TUI) setViewPreset(name string) {
+	tui.state.mutex.Lock()
+	tui.state.viewPreset = name
+	tui.state.mutex.Unlock()
+	if tui.frameRate != nil {
+		tui.frameRate.Touch()
+	}
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+func (vp *ViewPresets) Rotation(now time.Time, spin float64) float64 {
+	vp.mutex.Lock()
+	defer vp.mutex.Unlock()
+	return vp.turn.Rotation(now, spin)
+}
+
+// ToggleFollow switches the follow-attack camera on or off
+func (tui *TUI) ToggleFollow() {
+	tui.state.mutex.Lock()
+	tui.state.following = !tui.state.following
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
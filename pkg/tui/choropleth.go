@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"maps"
+	"math"
+	"strings"
+	"sync"
+
+	"SecKC-MHN-Globe/pkg/globerender"
+	"github.com/gdamore/tcell/v2"
+)
+
+// choroplethLevels is how many shades the choropleth ranks countries into
+const choroplethLevels = globerender.ShadeLevels
+
+// CountryTally counts the session's events, backfill included, by country
+// code for the choropleth
+type CountryTally struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+// Record counts an event from country; anything but a two letter code is
+// skipped so odd feed values cannot grow the map
+func (ct *CountryTally) Record(country string) {
+	if len(country) != 2 {
+		return
+	}
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	if ct.counts == nil {
+		ct.counts = make(map[string]int)
+	}
+	ct.counts[strings.ToUpper(country)]++
+}
+
+func (ct *CountryTally) Counts() map[string]int {
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	return maps.Clone(ct.counts)
+}
+
+// choroplethShades ranks countries into levels 1 to choroplethLevels on a log
+// scale, so one very noisy country does not wash out the rest. It also
+// returns the highest count, for the legend.
+func choroplethShades(counts map[string]int) (map[string]int, int) {
+	maxCount := 0
+	for _, n := range counts {
+		maxCount = max(maxCount, n)
+	}
+	shades := make(map[string]int, len(counts))
+	for code, n := range counts {
+		if n <= 0 {
+			continue
+		}
+		level := choroplethLevels
+		if maxCount > 1 {
+			level = 1 + int(float64(choroplethLevels-1)*math.Log(float64(n))/math.Log(float64(maxCount)))
+		}
+		shades[code] = level
+	}
+	return shades, maxCount
+}
+
+// choroplethColor blends the theme's land color towards its attack color as
+// the level rises
+func choroplethColor(level int) tcell.Color {
+	theme := ActiveTheme()
+	r1, g1, b1 := theme.Globe.RGB()
+	r2, g2, b2 := theme.Attack.RGB()
+	if r1 < 0 || r2 < 0 {
+		return theme.Attack
+	}
+	f := float64(level) / choroplethLevels
+	mix := func(a, b int32) int32 { return a + int32(float64(b-a)*f) }
+	return tcell.NewRGBColor(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
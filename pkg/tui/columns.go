@@ -0,0 +1,421 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"github.com/mattn/go-runewidth"
+)
+
+// DashboardColumn is one field of a dashboard row
+type DashboardColumn struct {
+	Name   string
+	Header string
+	Width  int  // Cells; long values push the rest of the row right unless Fixed
+	Fixed  bool // Clip values to Width, marking the cut with »
+	Live   bool // Values change with the clock, so rows are redrawn every second
+	Value  func(conn api.Connection) string
+}
+
+// dashboardColumns are the columns a layout can use. The last column of a
+// layout is not padded, so org, which takes whatever is left, goes last.
+var dashboardColumns = []DashboardColumn{
+	{Name: "ip", Header: "IP", Width: 15, Value: func(conn api.Connection) string { return conn.IP }},
+	{Name: "country", Header: "[CC]", Width: 4, Value: countryColumn},
+	{Name: "city", Header: "City", Width: 12, Value: func(conn api.Connection) string {
+		if conn.City == "" {
+			return "Unknown"
+		}
+		return conn.City
+	}},
+	{Name: "proto", Header: "Prot", Width: 4, Value: protocolColumn},
+	{Name: "port", Header: "Port", Width: 5, Value: func(conn api.Connection) string {
+		if conn.Port == 0 {
+			return "-"
+		}
+		return strconv.Itoa(conn.Port)
+	}},
+	{Name: "creds", Header: "User:Pass", Width: 10, Value: credsColumn},
+	timeColumn("local"),
+	{Name: "datetime", Header: "Date/Time", Width: 14, Value: func(conn api.Connection) string { return conn.Time.Format("01-02 15:04:05") }},
+	{Name: "age", Header: "Age", Width: 7, Live: true, Value: func(conn api.Connection) string { return ageColumn(conn.Time) }},
+	{Name: "tag", Header: "Tag", Width: 3, Value: func(conn api.Connection) string { return tagColumn(conn.Tag) }},
+	{Name: "origin", Header: "Source", Width: 8, Fixed: true, Value: func(conn api.Connection) string { return conn.Origin }},
+	{Name: "org", Header: "ASN / Org / rDNS", Value: orgColumn},
+}
+
+// TimeFormats are the ways the time column can show when an event happened,
+// in the order z cycles them: local HH:MM:SS, how long ago, or a UTC ISO
+// 8601 timestamp
+var TimeFormats = []string{"local", "relative", "utc"}
+
+// timeColumn is the time column in one of timeFormats
+func timeColumn(format string) DashboardColumn {
+	switch format {
+	case "relative":
+		return DashboardColumn{Name: "time", Header: "Age", Width: 4, Live: true, Value: func(conn api.Connection) string {
+			return api.ShortDuration(max(time.Since(conn.Time), 0))
+		}}
+	case "utc":
+		return DashboardColumn{Name: "time", Header: "Time (UTC)", Width: 20, Value: func(conn api.Connection) string {
+			return conn.Time.UTC().Format("2006-01-02T15:04:05Z")
+		}}
+	}
+	return DashboardColumn{Name: "time", Header: "Time", Width: 8, Value: func(conn api.Connection) string { return conn.Time.Format("15:04:05") }}
+}
+
+// columnPresets are the layouts the U key cycles through, in order
+var columnPresets = []ColumnLayout{
+	{Name: "compact", Spec: "ip,country,proto,creds:18,time"},
+	{Name: "normal", Spec: "ip,country,city,proto,creds,time,tag,org"},
+	{Name: "wide", Spec: "ip,country,city:20,proto,creds:26,datetime,tag,org"},
+}
+
+// ColumnLayout is a named dashboard column spec: column names separated by
+// commas, each optionally followed by :width to fix its width
+type ColumnLayout struct {
+	Name    string
+	Spec    string
+	Columns []DashboardColumn
+}
+
+// ParseColumnLayout accepts a preset name or a column spec such as
+// "ip,country,city:16,creds:20,org"
+func ParseColumnLayout(spec string) (ColumnLayout, error) {
+	for _, preset := range columnPresets {
+		if spec == preset.Name {
+			spec = preset.Spec
+		}
+	}
+	layout := ColumnLayout{Name: "custom", Spec: spec}
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		name, width, sized := strings.Cut(strings.TrimSpace(item), ":")
+		i := slices.IndexFunc(dashboardColumns, func(col DashboardColumn) bool { return col.Name == name })
+		if i < 0 {
+			names := make([]string, len(dashboardColumns))
+			for j, col := range dashboardColumns {
+				names[j] = col.Name
+			}
+			return ColumnLayout{}, fmt.Errorf("unknown column %q (columns: %s)", name, strings.Join(names, ", "))
+		}
+		if seen[name] {
+			return ColumnLayout{}, fmt.Errorf("column %q listed twice", name)
+		}
+		seen[name] = true
+		col := dashboardColumns[i]
+		if sized {
+			n, err := strconv.Atoi(width)
+			if err != nil || n < 1 || n > 100 {
+				return ColumnLayout{}, fmt.Errorf("column %q: width must be 1-100", name)
+			}
+			col.Width, col.Fixed = n, true
+		}
+		layout.Columns = append(layout.Columns, col)
+	}
+	for _, preset := range columnPresets {
+		if layout.Spec == preset.Spec {
+			layout.Name = preset.Name
+		}
+	}
+	return layout, nil
+}
+
+// mustColumnLayout is for the built-in presets, which always parse
+func mustColumnLayout(spec string) ColumnLayout {
+	layout, err := ParseColumnLayout(spec)
+	if err != nil {
+		panic(err)
+	}
+	return layout
+}
+
+// line joins one value per column, each padded (and, for fixed columns,
+// clipped) to its width
+func (cl ColumnLayout) line(value func(col DashboardColumn) string) string {
+	parts := make([]string, len(cl.Columns))
+	for i, col := range cl.Columns {
+		text := value(col)
+		if col.Fixed {
+			text = clipCells(text, col.Width, "»")
+		}
+		if i < len(cl.Columns)-1 {
+			text = padCells(text, col.Width)
+		}
+		parts[i] = text
+	}
+	return strings.Join(parts, " ")
+}
+
+// Header is the column title line
+func (cl ColumnLayout) Header() string {
+	return cl.line(func(col DashboardColumn) string { return col.Header })
+}
+
+// Row formats one connection
+func (cl ColumnLayout) Row(conn api.Connection) string {
+	return cl.line(func(col DashboardColumn) string { return col.Value(conn) })
+}
+
+// WithTimeFormat returns the layout with its time column, if it has one, in
+// one of timeFormats. A width fixed in the spec is kept.
+func (cl ColumnLayout) WithTimeFormat(format string) ColumnLayout {
+	i := slices.IndexFunc(cl.Columns, func(col DashboardColumn) bool { return col.Name == "time" })
+	if i < 0 {
+		return cl
+	}
+	col := timeColumn(format)
+	if cl.Columns[i].Fixed {
+		col.Width, col.Fixed = cl.Columns[i].Width, true
+	}
+	cl.Columns = slices.Clone(cl.Columns)
+	cl.Columns[i] = col
+	return cl
+}
+
+// Live reports whether any column changes with the clock
+func (cl ColumnLayout) Live() bool {
+	return slices.ContainsFunc(cl.Columns, func(col DashboardColumn) bool { return col.Live })
+}
+
+// wrapIndent starts continuation lines in wrap mode past the first column
+func (cl ColumnLayout) wrapIndent() int {
+	return cl.Columns[0].Width + 1
+}
+
+// ageColumn is how long ago t was, e.g. "12s ago". API rows are on our clock
+// once corrected for the server's skew; one a little in the future shows 0s.
+func ageColumn(t time.Time) string {
+	return api.ShortDuration(max(time.Since(t), 0)) + " ago"
+}
+
+func countryColumn(conn api.Connection) string {
+	if parts := strings.Fields(conn.Country); len(parts) > 0 {
+		return "[" + clipCells(parts[0], 2, "") + "]"
+	}
+	return ""
+}
+
+// protocolColumn ends a protocol guessed from the port with a ?, e.g. "ssh?"
+func protocolColumn(conn api.Connection) string {
+	if conn.Inferred {
+		return clipCells(conn.Protocol, 3, "") + "?"
+	}
+	return clipCells(conn.Protocol, 4, "")
+}
+
+// credsColumn ends a collapsed row's credentials with its attempts, e.g.
+// "root:admin (12)"
+func credsColumn(conn api.Connection) string {
+	creds := conn.Username + ":" + conn.Password
+	if conn.Attempts > 1 {
+		return fmt.Sprintf("%s (%d)", creds, conn.Attempts)
+	}
+	return creds
+}
+
+// protocolLabel spells out that a protocol was guessed from the port
+func protocolLabel(conn api.Connection) string {
+	if conn.Inferred {
+		return fmt.Sprintf("%s (guessed from port %d)", conn.Protocol, conn.Port)
+	}
+	return conn.Protocol
+}
+
+// orgColumn shows ASN and Org, or rDNS when there is no Org, after any
+// repeat offender and session badges
+func orgColumn(conn api.Connection) string {
+	enrichInfo := "..."
+	if conn.Org != "" {
+		enrichInfo = fmt.Sprintf("%s %s", conn.ASN, conn.Org)
+	} else if conn.RDNS != "" {
+		enrichInfo = conn.RDNS
+	}
+	return offenderBadge(conn.Hits) + conn.Session.Badge() + enrichInfo
+}
+
+// ToggleFreeze freezes the feed, or thaws it and shows the rows held back
+func (tui *TUI) ToggleFreeze() string {
+	frozen, _ := tui.dashboard.Frozen()
+	held := tui.dashboard.SetFrozen(!frozen)
+	tui.MarkDashboardChanged()
+	tui.MarkGlobeChanged()
+	if frozen {
+		return fmt.Sprintf("Feed thawed: %d new", held)
+	}
+	return "Feed frozen"
+}
+
+// CycleTimeFormat steps the dashboard's time column through timeFormats
+func (tui *TUI) CycleTimeFormat() string {
+	tui.state.mutex.Lock()
+	format := TimeFormats[(slices.Index(TimeFormats, tui.state.timeFormat)+1)%len(TimeFormats)]
+	tui.state.timeFormat = format
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+	return format
+}
+
+// CycleColumnLayout switches the dashboard to the next layout
+func (tui *TUI) CycleColumnLayout() {
+	tui.state.mutex.Lock()
+	tui.state.columnLayout = cycleIndex(tui.state.columnLayout, 1, len(tui.state.columnLayouts))
+	name := tui.state.columnLayouts[tui.state.columnLayout].Name
+	tui.state.mutex.Unlock()
+	debugLog("Dashboard: %s columns", name)
+	tui.MarkDashboardChanged()
+}
+
+// SetColumnLayout selects layout, adding it to the cycle after the presets
+// when it is not one of them
+func (tui *TUI) SetColumnLayout(layout ColumnLayout) {
+	tui.state.mutex.Lock()
+	layouts := tui.state.columnLayouts
+	i := slices.IndexFunc(layouts, func(l ColumnLayout) bool { return l.Spec == layout.Spec })
+	if i < 0 {
+		layouts = append(layouts[:len(columnPresets):len(columnPresets)], layout)
+		i = len(layouts) - 1
+	}
+	tui.state.columnLayouts, tui.state.columnLayout = layouts, i
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+// renderConnectionLines formats dashboard rows, oldest first, keeping the
+// newest rows that fit. The first pins rows of conns are pinned: they come
+// first, under their own rule, whatever their age. rowConn maps each line to
+// its index in conns (-1 for the header, rules and blank lines). With wrap
+// set, long rows continue on indented lines of at most width cells instead
+// of running off the right edge.
+func renderConnectionLines(conns ConnectionList, pins int, layout ColumnLayout, height int, width int, wrap, expand bool) ([]string, []int) {
+	lines := make([]string, height)
+	rowConn := make([]int, height)
+	for i := range rowConn {
+		rowConn[i] = -1
+	}
+
+	// Single header line with all fields
+	headerLine := clipCells(layout.Header(), width, "")
+	lines[0] = headerLine
+	lines[1] = strings.Repeat("-", width)
+
+	startLine := 2
+	if pins > 0 {
+		for i := 0; i < pins; i++ {
+			for _, part := range connectionRowLines(conns[i], layout, width, wrap, expand) {
+				if startLine >= height-1 {
+					break
+				}
+				lines[startLine] = part
+				rowConn[startLine] = i
+				startLine++
+			}
+		}
+		if startLine < height {
+			lines[startLine] = strings.Repeat("-", width)
+			startLine++
+		}
+	}
+
+	// Work back from the newest row so the most recent attacks always show
+	available := height - startLine
+	var rows [][]string
+	var rowIdx []int
+	used := 0
+	for i := len(conns) - 1; i >= pins && used < available; i-- {
+		parts := connectionRowLines(conns[i], layout, width, wrap, expand)
+		if used+len(parts) > available {
+			if used > 0 {
+				break
+			}
+			parts = parts[:available]
+		}
+		rows = append(rows, parts)
+		rowIdx = append(rowIdx, i)
+		used += len(parts)
+	}
+
+	lineIdx := startLine
+	for r := len(rows) - 1; r >= 0; r-- {
+		for _, part := range rows[r] {
+			lines[lineIdx] = part
+			rowConn[lineIdx] = rowIdx[r]
+			lineIdx++
+		}
+	}
+
+	return lines, rowConn
+}
+
+// connectionRowLines formats one dashboard row: a single line, or with wrap
+// set, the row split into lines of at most width cells. With expand set, a
+// collapsed row is followed by the credentials it tried.
+func connectionRowLines(conn api.Connection, layout ColumnLayout, width int, wrap, expand bool) []string {
+	var lines []string
+	line := layout.Row(conn)
+	if wrap {
+		lines = wrapLine(line, width, layout.wrapIndent())
+	} else {
+		// Only truncate if line is significantly longer than width (allows some overflow)
+		if textWidth(line) > width+10 {
+			line = clipCells(line, width, "»") // Use » to indicate more text
+		}
+		lines = []string{line}
+	}
+	if expand {
+		lines = append(lines, triedLines(conn, width, layout.wrapIndent())...)
+	}
+	return lines
+}
+
+// wrapLine splits line into pieces of at most width cells, preferring to
+// break at spaces. Continuation pieces are indented and marked with ↳.
+func wrapLine(line string, width, indent int) []string {
+	if width < indent+10 {
+		indent = 0
+	}
+	var parts []string
+	prefix := ""
+	for line != "" {
+		limit := width - textWidth(prefix)
+		if limit < 1 {
+			limit = 1
+		}
+		if textWidth(line) <= limit {
+			parts = append(parts, prefix+line)
+			break
+		}
+
+		// Cut at the first character past the limit, or at the last space
+		// in the second half of the piece
+		cut, space, col := 0, -1, 0
+		for i, r := range line {
+			if r == ' ' && col > limit/2 {
+				space = i
+			}
+			col += runewidth.RuneWidth(r)
+			if col > limit {
+				cut = i
+				break
+			}
+		}
+		if space > 0 {
+			cut = space
+		}
+		if cut == 0 {
+			// A single character wider than the limit
+			_, cut = utf8.DecodeRuneInString(line)
+		}
+		parts = append(parts, prefix+strings.TrimRight(line[:cut], " "))
+		line = strings.TrimLeft(line[cut:], " ")
+		if indent > 0 {
+			prefix = strings.Repeat(" ", indent-2) + "↳ "
+		}
+	}
+	return parts
+}
@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"SecKC-MHN-Globe/pkg/globerender"
+)
+
+// Layer is one named stage of a frame. Layers are drawn in ascending Z, so
+// a higher layer covers a lower one wherever both draw.
+type Layer struct {
+	Name    string
+	Z       int
+	Visible bool
+	Dim     float64 // Fade towards the background, 0 (none) to 1
+	Draw    func(tui *TUI, frame *Frame)
+}
+
+// Frame is what the layers of one frame draw from
+type Frame struct {
+	Snap           *FrameSnapshot
+	Rotation       float64
+	ProtocolGlyphs bool
+	Layout         Layout
+	Globe          [][]rune // Rasterized globe, nil when it has not changed since the last frame
+	Kinds          [][]globerender.Kind
+}
+
+// Compositor holds the layers a frame is built from
+type Compositor struct {
+	layers []*Layer // Sorted by Z
+	mutex  sync.RWMutex
+}
+
+// Add registers a layer, replacing any layer of the same name
+func (c *Compositor) Add(layer *Layer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.layers = slices.DeleteFunc(c.layers, func(l *Layer) bool { return l.Name == layer.Name })
+	c.layers = append(c.layers, layer)
+	slices.SortStableFunc(c.layers, func(a, b *Layer) int { return a.Z - b.Z })
+}
+
+// Layer returns the named layer, or nil
+func (c *Compositor) Layer(name string) *Layer {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for _, layer := range c.layers {
+		if layer.Name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// Names lists the layers from bottom to top
+func (c *Compositor) Names() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	names := make([]string, len(c.layers))
+	for i, layer := range c.layers {
+		names[i] = layer.Name
+	}
+	return names
+}
+
+// Visible reports whether the named layer is drawn
+func (c *Compositor) Visible(name string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for _, layer := range c.layers {
+		if layer.Name == name {
+			return layer.Visible
+		}
+	}
+	return false
+}
+
+// SetVisible shows or hides the named layer
+func (c *Compositor) SetVisible(name string, visible bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, layer := range c.layers {
+		if layer.Name == name {
+			layer.Visible = visible
+		}
+	}
+}
+
+// SetDim sets how far the named layer fades towards the background
+func (c *Compositor) SetDim(name string, dim float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, layer := range c.layers {
+		if layer.Name == name {
+			layer.Dim = dim
+		}
+	}
+}
+
+// Compose draws every visible layer in Z order
+func (c *Compositor) Compose(tui *TUI, frame *Frame) {
+	c.mutex.RLock()
+	layers := slices.Clone(c.layers)
+	c.mutex.RUnlock()
+
+	buffer, _ := tui.screen.(*bufferedScreen)
+	for _, layer := range layers {
+		if !layer.Visible {
+			continue
+		}
+		if buffer != nil {
+			buffer.SetDim(layer.Dim)
+		}
+		layer.Draw(tui, frame)
+	}
+	if buffer != nil {
+		buffer.SetDim(0)
+	}
+}
+
+// NewCompositor returns the standard layers, bottom to top. Rain falls over
+// the land (unless it is masked) but under the data on the globe, and the
+// status line and help stay above every panel.
+func NewCompositor() *Compositor {
+	c := &Compositor{}
+	for _, layer := range []*Layer{
+		{Name: "earth", Z: 10, Visible: true, Draw: (*TUI).drawEarthLayer},
+		{Name: "graticule", Z: 20, Visible: false, Draw: (*TUI).drawGraticuleLayer},
+		{Name: "rain", Z: 25, Visible: true, Draw: (*TUI).drawRainLayer},
+		{Name: "heatmap", Z: 30, Visible: true, Draw: (*TUI).drawHeatmapLayer},
+		{Name: "footprints", Z: 35, Visible: true, Draw: (*TUI).drawFootprintsLayer},
+		{Name: "arcs", Z: 40, Visible: true, Draw: (*TUI).drawArcsLayer},
+		{Name: "markers", Z: 50, Visible: true, Draw: (*TUI).drawMarkersLayer},
+		{Name: "honeypots", Z: 55, Visible: true, Draw: (*TUI).drawHoneypotsLayer},
+		{Name: "dashboard", Z: 60, Visible: true, Draw: (*TUI).drawDashboardLayer},
+		{Name: "panels", Z: 70, Visible: true, Draw: (*TUI).drawPanelsLayer},
+		{Name: "status", Z: 80, Visible: true, Draw: (*TUI).drawStatusLayer},
+		{Name: "toasts", Z: 85, Visible: true, Draw: (*TUI).drawToastLayer},
+		{Name: "help", Z: 90, Visible: true, Draw: (*TUI).drawHelpLayer},
+		{Name: "palette", Z: 95, Visible: true, Draw: (*TUI).drawPaletteLayer},
+	} {
+		c.Add(layer)
+	}
+	return c
+}
+
+// ApplyLayerSpec applies a comma separated list of name=on, name=off or
+// name=<dim 0-1> settings
+func (c *Compositor) ApplyLayerSpec(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("%q: want name=on, name=off or name=<dim 0-1>", item)
+		}
+		name = strings.TrimSpace(name)
+		if c.Layer(name) == nil {
+			return fmt.Errorf("unknown layer %q (layers: %s)", name, strings.Join(c.Names(), ", "))
+		}
+		switch value = strings.TrimSpace(value); value {
+		case "on":
+			c.SetVisible(name, true)
+		case "off":
+			c.SetVisible(name, false)
+		default:
+			dim, err := strconv.ParseFloat(value, 64)
+			if err != nil || dim < 0 || dim > 1 {
+				return fmt.Errorf("%s: dim must be between 0 and 1, got %q", name, value)
+			}
+			c.SetVisible(name, true)
+			c.SetDim(name, dim)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package tui
+
+import (
+	"strings"
+
+	"SecKC-MHN-Globe/pkg/config"
+	"SecKC-MHN-Globe/pkg/globerender"
+)
+
+// Config is the config file, decoded for ReloadConfig
+type Config = config.Config
+
+// ConfigOption has the fields of config.Option, so the registry below can
+// list them positionally
+type ConfigOption config.Option
+
+// ConfigOptions is the registry of every supported config file option.
+// It must list every field of Config; config.Generate checks both directions.
+var ConfigOptions = []ConfigOption{
+	{"api", "base_url", "u", "URL[,URL...]", "Base URL for the SecKC API; several comma separated endpoints, each optionally label=URL, are polled together"},
+	{"api", "poll_interval", "p", "1s-300s", "API polling interval"},
+	{"api", "max_events", "e", "1-500", "Maximum events to fetch per API call"},
+	{"api", "backfill", "backfill", "0s-24h", "Load this much recent history from the API at startup (0s disables)"},
+	{"api", "stream", "stream", "auto|websocket|sse|off", "Receive events over a WebSocket or server-sent events stream when the API offers one, polling otherwise"},
+	{"api", "key", "api-key", "string", "API key or bearer token sent with events and geocode requests"},
+	{"api", "key_header", "api-key-header", "string", "Send the raw key in this header (e.g. X-API-Key) instead of Authorization: Bearer"},
+	{"http", "proxy", "proxy", "URL", "Proxy for all outbound HTTP requests: http://, https:// or socks5:// (empty uses HTTPS_PROXY/HTTP_PROXY)"},
+	{"http", "ca_cert", "ca-cert", "path", "PEM CA bundle trusted in addition to the system roots for outbound HTTPS"},
+	{"http", "tls_skip_verify", "tls-skip-verify", "true|false", "Do not verify HTTPS certificates (insecure, for testing only)"},
+
+	{"display", "theme", "theme", strings.Join(themeOrder, "|"), "Color theme"},
+	{"display", "charset", "charset", "ascii|blocks|braille", "Character set used to draw the globe"},
+	{"display", "projection", "projection", strings.Join(globerender.ProjectionNames, "|"), "The turning globe, a flat equirectangular or Mollweide map of the whole earth, or both hemispheres side by side (J cycles them)"},
+	{"display", "color_mode", "color-mode", "auto|" + strings.Join(ColorModeNames, "|"), "Colors the terminal can show; auto detects, smaller palettes get nearest-color themes"},
+	{"display", "unicode", "unicode", strings.Join(UnicodeModes, "|"), "Whether the terminal can show Braille and box drawing; off draws ASCII only"},
+	{"display", "rotation_period", "s", "10-300", "Globe rotation period in seconds"},
+	{"display", "refresh_rate", "r", "50-1000", "Globe refresh rate in milliseconds"},
+	{"display", "aspect_ratio", "a", "1.0-4.0", "Character aspect ratio (height/width)"},
+	{"display", "monochrome", "m", "true|false", "Force the monochrome theme"},
+	{"display", "protocol_glyphs", "protocol-glyphs", "true|false", "Show protocol glyphs"},
+	{"display", "dashboard_wrap", "wrap", "true|false", "Wrap long dashboard rows onto indented continuation lines instead of scrolling"},
+	{"display", "collapse", "collapse", strings.Join(CollapseModes, "|"), "Fold consecutive rows from one IP into one with an attempt count; expanded also lists the credentials tried (* cycles it)"},
+	{"display", "columns", "columns", "compact|normal|wide or a list of ip,country,city,proto,port,creds,time,datetime,age,tag,origin,org (name:width fixes a width)", "Dashboard columns; U cycles the presets and this layout"},
+	{"display", "repeat_threshold", "repeat-threshold", ">=2", "Session hits from one IP before it is marked as a repeat offender (x4 for the largest marker)"},
+	{"display", "timeline", "timeline", "true|false", "Show the session timeline bar under the globe (Home enters scrub mode)"},
+	{"display", "ticker", "ticker", strings.Join(TickerModes, "|"), "Attack ticker scrolling along the bottom row; auto runs it in the compact layout of small terminals (~ toggles it)"},
+	{"display", "time_format", "time-format", strings.Join(TimeFormats, "|"), "Dashboard time column: local HH:MM:SS, relative age or UTC ISO 8601 (z cycles it)"},
+	{"display", "legend", "legend", "true|false", "Start with the symbol legend open (B toggles it)"},
+	{"display", "honeypots", "honeypots", "name,lat,lon[;name,lat,lon...]", "Honeypots marked on the globe; arcs run to the one named like the reporting API endpoint, or the first"},
+	{"display", "kiosk", "kiosk", "true|false", "Attract mode for wall displays: cycle themes and panels and zoom into the busiest region"},
+	{"display", "kiosk_interval", "kiosk-interval", "5-600", "Seconds between kiosk panel changes (themes change every 2x, zooms every 3x)"},
+	{"display", "subcell", "subcell", "true|false", "Place markers and arcs on individual Braille dots (braille charset only)"},
+	{"display", "jitter", "jitter", "0-2", "Degrees markers are spread over, seeded by address, so attackers at one city centroid do not all share a cell"},
+	{"display", "layers", "layers", "name=on|off|<dim 0-1>,...", "Show, hide or dim the layers a frame is built from: " + strings.Join(NewCompositor().Names(), ", ")},
+	{"display", "quality", "quality", strings.Join(QualityNames, "|"), "Land anti-aliasing: normal bleeds light into neighbouring cells, high supersamples each cell 2x2"},
+	{"display", "mouse", "mouse", "true|false", "Zoom with the mouse wheel about the pointer (hold Shift to select text while it is on)"},
+	{"display", "active_fps", "active-fps", "1-60", "Render rate while events or keys are arriving"},
+	{"display", "idle_fps", "idle-fps", "0-active_fps", "Render rate when idle (0 disables idle throttling)"},
+	{"display", "idle_after", "idle-after", ">=1", "Seconds without events or keys before idling"},
+
+	{"effects", "arc_style", "arcs", "curved|straight|off", "Attack arc style"},
+	{"effects", "trail_ms", "trail-ms", "100-10000", "Arc trail persistence in milliseconds"},
+	{"effects", "footprints", "footprints", "0s-24h", "Leave a fading dot where attacks came from for this long after the last one (0s disables)"},
+	{"effects", "crt_enabled", "crt", "true|false", "Enable CRT scanline effect"},
+	{"effects", "glow_level", "glow", "0-3", "Phosphor glow radius in cells around bright characters (CRT only)"},
+	{"effects", "crt_curve", "crt-curve", "true|false", "Bow the picture like a curved CRT tube (CRT only)"},
+	{"effects", "rain_enabled", "rain", "true|false", "Enable Matrix rain effect"},
+	{"effects", "rain_density", "rain-density", "0-10", "Matrix rain density"},
+	{"effects", "rain_mask", "rain-mask", "true|false", "Only let rain fall over the ocean and background"},
+
+	{"lighting", "enabled", "lighting", "true|false", "Enable globe lighting/shading"},
+	{"lighting", "lon", "light-lon", "-180 to 180", "Light source longitude"},
+	{"lighting", "lat", "light-lat", "-90 to 90", "Light source latitude"},
+	{"lighting", "follow", "light-follow", "true|false", "Light rotates opposite to the globe"},
+
+	{"demo", "enabled", "demo-storm", "true|false", "Enable the demo storm generator"},
+	{"demo", "rate", "demo-rate", "1-1000", "Demo attacks per second"},
+	{"demo", "scenario", "demo-scenario", "path", "Scenario file of timed demo storm phases, e.g. \"quiet 60s, then 200/s from CN for 30s\" (starts the storm)"},
+	{"demo", "replay", "demo-replay", "builtin|path", "Replay geolocated events offline instead of polling the honeypot API (builtin uses the embedded sample)"},
+	{"demo", "offline", "offline", "true|false", "Make no API requests, geocoding, ipinfo.io or rDNS lookups; locations come from the embedded network table"},
+
+	{"recording", "file", "record", "path", "Record the session to an asciinema file"},
+	{"recording", "format", "record-format", "cast|events|both", "What to record: the cast, the raw events as <file>.events.ndjson timed from the cast start, or both"},
+	{"recording", "gif_file", "export-gif", "path", "Export the session as an animated GIF"},
+	{"recording", "gif_duration", "gif-duration", ">0", "Length of session to capture for GIF export"},
+	{"recording", "gif_frame_skip", "gif-frame-skip", ">=1", "Capture every Nth rendered frame for GIF export"},
+
+	{"web", "addr", "web-addr", "host:port", "Serve the panel data API on this address (empty disables)"},
+	{"web", "user", "web-user", "string", "HTTP basic auth username"},
+	{"web", "pass", "web-pass", "string", "HTTP basic auth password (required with user)"},
+	{"web", "token", "web-token", "string", "Required bearer token"},
+	{"web", "allow", "web-allow", "comma separated IPs/CIDRs", "Clients allowed to use the web server"},
+	{"web", "tls_cert", "web-tls-cert", "path", "TLS certificate file"},
+	{"web", "tls_key", "web-tls-key", "path", "TLS private key file"},
+	{"web", "tls_self_signed", "web-tls-self-signed", "true|false", "Serve TLS with a generated self-signed certificate"},
+
+	{"geoip", "asn_db", "asn-db", "path (.mmdb, .tsv, .tsv.gz)", "Local GeoLite2-ASN or iptoasn.com database for offline ASN/Org lookups"},
+	{"geoip", "asn_fallback", "asn-fallback", "true|false", "Query ipinfo.io for addresses the local database does not cover"},
+	{"geoip", "lang", "geo-lang", "en|de|es|fr|ja|pt-BR|ru|zh-CN|...", "Language of city and country names (English when the geocode response has no translation)"},
+
+	{"alerts", "rules", "alert-rules", "path", "TOML file of [[rule]] alert rules (see alerts.example.toml)"},
+	{"alerts", "banner", "banner", "true|false", "Reserve a row above the dashboard for alerts and incidents until acknowledged (Backspace)"},
+	{"alerts", "storm_rate", "storm-rate", ">=0", "Events per minute that raise the storm alarm: the dashboard header flashes and breaks the last minute down by source (0 disables)"},
+	{"alerts", "storm_clear", "storm-clear", ">=0", "Events per minute a storm must fall to before it clears (0 for half of storm_rate)"},
+	{"alerts", "storm_hold", "storm-hold", ">=0s", "How long the rate must stay at or below storm_clear before the storm clears"},
+	{"alerts", "storm_heatmap", "storm-heatmap", "true|false", "Shade countries by attacks while a storm lasts"},
+
+	{"findings", "window", "findings-window", ">=1m", "Window credential patterns are counted over; baseline usernames are those seen in the first one"},
+	{"findings", "usernames", "findings-usernames", ">=0", "Distinct usernames one address tries within the window that make a spray finding (0 disables)"},
+	{"findings", "new_user_rate", "findings-new-user-rate", ">=0", "Tries of a never seen username within the window that make a finding (0 disables)"},
+	{"findings", "hostnames", "findings-hostnames", "list", "Comma separated honeypot hostnames to flag in passwords, besides sensor names and --honeypots"},
+
+	{"malware", "virustotal_key", "vt-key", "string", "VirusTotal API key for looking up the hashes of downloaded and captured files"},
+	{"malware", "bazaar_key", "bazaar-key", "string", "MalwareBazaar Auth-Key, asked about hashes VirusTotal does not know"},
+	{"malware", "lookup_rate", "hash-lookup-rate", ">=1", "Hash lookups per minute (VirusTotal's public API allows 4)"},
+
+	{"coverage", "sensors", "sensors", "comma separated name:service/port ...", "Sensors and the services they run, for the protocol coverage panel"},
+	{"coverage", "window", "coverage-window", ">=1m", "A configured service with no events for this long is marked silent"},
+
+	{"triage", "tags_file", "tags-file", "path", "JSON file that keeps triage tags across restarts (empty keeps them in memory)"},
+
+	{"roles", "spectator", "spectator", "true|false", "Start locked: passers-by can only open panels, help and the command guide"},
+	{"roles", "pass", "operator-pass", "passphrase", "Passphrase that unlocks operator keys after Ctrl+O (empty: only a restart unlocks)"},
+
+	{"dns", "server", "dns-server", "host[:port]", "DNS server for reverse lookups (empty uses the system resolver)"},
+	{"dns", "timeout", "dns-timeout", "100ms-10s", "Reverse lookup timeout"},
+	{"dns", "workers", "dns-workers", "1-64", "Concurrent reverse lookups"},
+	{"dns", "negative_ttl", "dns-negative-ttl", ">=0", "How long to remember addresses with no PTR record"},
+
+	{"hpfeeds", "host", "hpfeeds-host", "host", "hpfeeds broker host (empty disables publishing)"},
+	{"hpfeeds", "port", "hpfeeds-port", "1-65535", "hpfeeds broker port"},
+	{"hpfeeds", "ident", "hpfeeds-ident", "string", "hpfeeds publisher ident"},
+	{"hpfeeds", "secret", "hpfeeds-secret", "string", "hpfeeds publisher secret"},
+	{"hpfeeds", "channel", "hpfeeds-channel", "string", "hpfeeds channel for enriched events"},
+
+	{"syslog", "forward", "syslog-forward", "udp|tcp|tls://host[:port]", "Forward every live event to this syslog collector (empty disables)"},
+	{"syslog", "format", "syslog-format", "rfc5424|cef", "Message format: RFC 5424 structured data or CEF"},
+	{"syslog", "ca", "syslog-ca", "path", "PEM CA bundle to verify a tls:// collector (empty uses the system roots)"},
+
+	{"elasticsearch", "url", "es-url", "URL", "Elasticsearch/OpenSearch URL to bulk-index events into (empty disables)"},
+	{"elasticsearch", "index", "es-index", "name", "Index name; %Y, %m, %d and %H are replaced from the event time"},
+	{"elasticsearch", "user", "es-user", "string", "Basic auth username"},
+	{"elasticsearch", "pass", "es-pass", "string", "Basic auth password"},
+	{"elasticsearch", "api_key", "es-api-key", "string", "API key (base64 id:key), used instead of basic auth"},
+	{"elasticsearch", "batch", "es-batch", "1-10000", "Events per bulk request"},
+	{"elasticsearch", "flush", "es-flush", "1s-5m", "Send a partial batch after this long"},
+
+	{"kafka", "brokers", "kafka-brokers", "host:port,...", "Kafka bootstrap brokers to publish enriched events to (empty disables)"},
+	{"kafka", "topic", "kafka-topic", "name", "Kafka topic; records are keyed by source IP"},
+	{"kafka", "user", "kafka-user", "string", "SASL/PLAIN username"},
+	{"kafka", "pass", "kafka-pass", "string", "SASL/PLAIN password"},
+	{"kafka", "tls", "kafka-tls", "true|false", "Connect to the brokers over TLS"},
+	{"nats", "url", "nats-url", "nats|tls://host[:port]", "NATS server to publish enriched events to (empty disables)"},
+	{"nats", "subject", "nats-subject", "subject", "NATS subject"},
+	{"nats", "user", "nats-user", "string", "NATS username"},
+	{"nats", "pass", "nats-pass", "string", "NATS password"},
+	{"nats", "token", "nats-token", "string", "NATS auth token, instead of a username"},
+	{"mqtt", "broker", "mqtt-broker", "mqtt|mqtts://host[:port]", "MQTT broker for the event source and sink"},
+	{"mqtt", "user", "mqtt-user", "string", "MQTT username"},
+	{"mqtt", "pass", "mqtt-pass", "string", "MQTT password"},
+	{"mqtt", "ca", "mqtt-ca", "path", "PEM CA bundle to verify an mqtts:// broker (empty uses the system roots)"},
+	{"mqtt", "client_id", "mqtt-client-id", "string", "Client id prefix; -src and -pub are appended"},
+	{"mqtt", "qos", "mqtt-qos", "0-1", "QoS for subscribing and publishing"},
+	{"mqtt", "subscribe", "mqtt-subscribe", "topic filter", "Read JSON honeypot events from this topic filter, e.g. honeypot/+/events (empty disables)"},
+	{"mqtt", "publish", "mqtt-publish", "topic", "Publish enriched events to this topic (empty disables)"},
+
+	{"zeek", "log", "zeek", "path", "Zeek conn.log (TSV or JSON) to show connections from (empty disables)"},
+	{"zeek", "follow", "zeek-follow", "true|false", "Keep reading connections as Zeek appends them, across log rotation"},
+
+	{"cowrie", "log", "cowrie-log", "path", "Follow this Cowrie json.log instead of polling the honeypot API (empty disables)"},
+
+	{"intel", "interval", "intel-interval", ">=10s", "How often to write the STIX feed and push to TAXII/MISP"},
+	{"intel", "window", "intel-window", ">=1m", "Addresses seen within this window are exported; indicators stay valid this long"},
+	{"intel", "min_sightings", "intel-min-sightings", ">=1", "Only export addresses seen at least this many times"},
+	{"intel", "file", "intel-file", "path", "Write the STIX 2.1 bundle to this file every interval (empty disables)"},
+	{"taxii", "url", "taxii-url", "URL", "TAXII 2.1 collection objects endpoint to push indicators to (empty disables)"},
+	{"taxii", "user", "taxii-user", "string", "TAXII basic auth username"},
+	{"taxii", "pass", "taxii-pass", "string", "TAXII basic auth password"},
+	{"misp", "url", "misp-url", "URL", "MISP instance to create events in (empty disables)"},
+	{"misp", "key", "misp-key", "string", "MISP automation key"},
+
+	{"limits", "max_arcs", "max-arcs", ">=1", "Maximum live attack arcs; oldest are dropped first"},
+	{"limits", "geo_cache_size", "geo-cache-size", ">=1", "Maximum cached geolocation lookups (LRU)"},
+	{"limits", "max_cred_attempts", "max-cred-attempts", ">=1", "Maximum credential attempts kept for the histogram"},
+	{"limits", "history_size", "history-size", ">=100", "Events kept for the timeline and scrub mode"},
+	{"limits", "mem_limit_mb", "mem-limit", ">=0", "Shed cached data when RSS exceeds this many MB (0 disables)"},
+
+	{"presets", "1", "preset-1", "name,lat,lon,zoom", "Region framed by key 1 (zoom 0.5-3.0)"},
+	{"presets", "2", "preset-2", "name,lat,lon,zoom", "Region framed by key 2"},
+	{"presets", "3", "preset-3", "name,lat,lon,zoom", "Region framed by key 3"},
+	{"presets", "4", "preset-4", "name,lat,lon,zoom", "Region framed by key 4"},
+	{"presets", "5", "preset-5", "name,lat,lon,zoom", "Region framed by key 5"},
+	{"presets", "6", "preset-6", "name,lat,lon,zoom", "Region framed by key 6"},
+	{"presets", "7", "preset-7", "name,lat,lon,zoom", "Region framed by key 7"},
+	{"presets", "8", "preset-8", "name,lat,lon,zoom", "Region framed by key 8"},
+	{"presets", "9", "preset-9", "name,lat,lon,zoom", "Region framed by key 9"},
+
+	{"keys", "pause", "key-pause", "keys", "Pause/resume rotation (comma separated keys: a character, space, shift+space, comma, enter, esc, tab, backspace, delete, home, end, pgup, pgdn, up, down, left, right, f1-f64 or ctrl+a-ctrl+z; empty unbinds)"},
+	{"keys", "freeze", "key-freeze", "keys", "Freeze the feed, holding new rows back"},
+	{"keys", "speed_down", "key-speed-down", "keys", "Slow the spin"},
+	{"keys", "speed_up", "key-speed-up", "keys", "Speed up the spin"},
+	{"keys", "zoom_in", "key-zoom-in", "keys", "Zoom in"},
+	{"keys", "zoom_out", "key-zoom-out", "keys", "Zoom out"},
+	{"keys", "nudge_up", "key-nudge-up", "keys", "Nudge the view up"},
+	{"keys", "nudge_down", "key-nudge-down", "keys", "Nudge the view down"},
+	{"keys", "nudge_left", "key-nudge-left", "keys", "Nudge the view left"},
+	{"keys", "nudge_right", "key-nudge-right", "keys", "Nudge the view right"},
+	{"keys", "theme", "key-theme", "keys", "Cycle themes"},
+	{"keys", "arcs", "key-arcs", "keys", "Toggle attack arcs"},
+	{"keys", "lighting", "key-lighting", "keys", "Toggle lighting"},
+	{"keys", "rain", "key-rain", "keys", "Toggle Matrix rain"},
+	{"keys", "info", "key-info", "keys", "Toggle the attack info panel"},
+	{"keys", "stats", "key-stats", "keys", "Toggle the stats panel"},
+	{"keys", "top_ips", "key-top-ips", "keys", "Toggle the top IPs panel"},
+	{"keys", "ports", "key-ports", "keys", "Toggle the top ports panel"},
+	{"keys", "creds", "key-creds", "keys", "Toggle the credential histogram"},
+	{"keys", "diagnostics", "key-diagnostics", "keys", "Toggle the diagnostics panel"},
+	{"keys", "legend", "key-legend", "keys", "Toggle the symbol legend"},
+	{"keys", "alerts", "key-alerts", "keys", "Toggle the alerts log"},
+	{"keys", "findings", "key-findings", "keys", "Toggle the credential findings"},
+	{"keys", "hashes", "key-hashes", "keys", "Toggle the malware hashes"},
+	{"keys", "urls", "key-urls", "keys", "Toggle the attacker URLs"},
+	{"keys", "ticker", "key-ticker", "keys", "Toggle the attack ticker"},
+	{"keys", "coverage", "key-coverage", "keys", "Toggle the protocol coverage matrix"},
+	{"keys", "triage", "key-triage", "keys", "Triage mode, or pin the selected row while triaging"},
+	{"keys", "tag_filter", "key-tag-filter", "keys", "Filter the dashboard by tag"},
+	{"keys", "session", "key-session", "keys", "Open the session detail panel"},
+	{"keys", "scrub", "key-scrub", "keys", "Scrub the timeline"},
+	{"keys", "live", "key-live", "keys", "Return to live rows and time"},
+	{"keys", "stats_view", "key-stats-view", "keys", "Cycle the stats chart range"},
+	{"keys", "countries", "key-countries", "keys", "Shade countries by attacks"},
+	{"keys", "follow", "key-follow", "keys", "Toggle the follow-attack camera"},
+	{"keys", "reset_view", "key-reset-view", "keys", "Reset the view"},
+	{"keys", "acknowledge", "key-acknowledge", "keys", "Acknowledge banner alerts"},
+	{"keys", "scroll_left", "key-scroll-left", "keys", "Scroll the dashboard left"},
+	{"keys", "scroll_right", "key-scroll-right", "keys", "Scroll the dashboard right"},
+	{"keys", "scroll_home", "key-scroll-home", "keys", "Reset the dashboard scroll"},
+	{"keys", "rotate_left", "key-rotate-left", "keys", "Step the rotation back"},
+	{"keys", "rotate_right", "key-rotate-right", "keys", "Step the rotation forward"},
+	{"keys", "page_up", "key-page-up", "keys", "Scroll back through history"},
+	{"keys", "page_down", "key-page-down", "keys", "Scroll forward through history"},
+	{"keys", "search", "key-search", "keys", "Search the session's history"},
+	{"keys", "search_prev", "key-search-prev", "keys", "Select the previous search match"},
+	{"keys", "search_next", "key-search-next", "keys", "Select the next search match"},
+	{"keys", "wrap", "key-wrap", "keys", "Toggle dashboard row wrap"},
+	{"keys", "collapse", "key-collapse", "keys", "Cycle collapsing rows from one IP"},
+	{"keys", "columns", "key-columns", "keys", "Cycle dashboard column layouts"},
+	{"keys", "time_format", "key-time-format", "keys", "Cycle the time column format"},
+	{"keys", "screenshot", "key-screenshot", "keys", "Save a screenshot"},
+	{"keys", "report", "key-report", "keys", "Export session statistics"},
+	{"keys", "settings", "key-settings", "keys", "Open the settings menu"},
+	{"keys", "palette", "key-palette", "keys", "Open the : command palette"},
+	{"keys", "commands", "key-commands", "keys", "Toggle the command guide"},
+	{"keys", "help", "key-help", "keys", "Toggle the help panel"},
+	{"keys", "quit", "key-quit", "keys", "Quit"},
+
+	{"debug", "log_file", "d", "path", "Debug log filename"},
+	{"debug", "pprof_addr", "pprof-addr", "host:port", "Serve net/http/pprof profiles on this address, loopback unless web auth is set (empty disables)"},
+}
+
+// APIEndpoint is one API base URL and the label its events are tagged with
+type APIEndpoint struct {
+	Label string
+	URL   string
+}
@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"github.com/gdamore/tcell/v2"
+)
+
+const DefaultCoverageWindow = time.Hour
+
+// SensorSpec is one configured honeypot sensor and the services it runs,
+// written as "name:ssh/22 telnet/23" (ports are optional and only shown)
+type SensorSpec struct {
+	Name     string
+	Services []string // Lower-case protocol names
+	Labels   []string // Column labels, "ssh/22" or just "ssh"
+}
+
+// ParseSensorSpecs reads a comma separated list of sensor specs
+func ParseSensorSpecs(list string) ([]SensorSpec, error) {
+	var specs []SensorSpec
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, services, found := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" || strings.TrimSpace(services) == "" {
+			return nil, fmt.Errorf("sensor %q: want name:service/port ...", entry)
+		}
+		spec := SensorSpec{Name: name}
+		for _, service := range strings.Fields(services) {
+			protocol, port, hasPort := strings.Cut(service, "/")
+			if protocol == "" {
+				return nil, fmt.Errorf("sensor %s: empty service in %q", name, service)
+			}
+			if hasPort {
+				if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+					return nil, fmt.Errorf("sensor %s: invalid port %q", name, port)
+				}
+			}
+			spec.Services = append(spec.Services, strings.ToLower(protocol))
+			spec.Labels = append(spec.Labels, strings.ToLower(service))
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Coverage cell states
+const (
+	coverageNone       = iota // Not configured, nothing recent
+	coverageLive              // Configured and reporting
+	coverageSilent            // Configured but nothing within the window
+	coverageUnexpected        // Reporting but not configured
+)
+
+// CoverageMatrix is a sensors x protocols snapshot for the coverage panel
+type CoverageMatrix struct {
+	Sensors   []string
+	Protocols []string
+	Labels    []string // Column labels, with ports where configured
+	Cells     [][]int
+	Window    time.Duration
+}
+
+// CoverageTracker remembers when each sensor last reported each protocol, to
+// compare against what the sensors are configured to run
+type CoverageTracker struct {
+	sensors []SensorSpec
+	window  time.Duration
+	seen    map[string]map[string]time.Time // sensor -> protocol -> last event
+	mutex   sync.RWMutex
+}
+
+func NewCoverageTracker(sensors []SensorSpec, window time.Duration) *CoverageTracker {
+	return &CoverageTracker{
+		sensors: sensors,
+		window:  window,
+		seen:    make(map[string]map[string]time.Time),
+	}
+}
+
+// Record notes an event; events without a sensor name count for "default"
+func (ct *CoverageTracker) Record(sensor, protocol string, t time.Time) {
+	if protocol == "" {
+		return
+	}
+	if sensor == "" {
+		sensor = "default"
+	}
+	protocol = strings.ToLower(protocol)
+
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+	protocols := ct.seen[sensor]
+	if protocols == nil {
+		protocols = make(map[string]time.Time)
+		ct.seen[sensor] = protocols
+	}
+	if t.After(protocols[protocol]) {
+		protocols[protocol] = t
+	}
+}
+
+// Matrix lists configured sensors first, then any unconfigured sensor seen
+// within the window; columns are the configured services followed by any
+// other protocol seen recently
+func (ct *CoverageTracker) Matrix(now time.Time) CoverageMatrix {
+	ct.mutex.RLock()
+	defer ct.mutex.RUnlock()
+
+	m := CoverageMatrix{Window: ct.window}
+	recent := func(sensor, protocol string) bool {
+		t, ok := ct.seen[sensor][protocol]
+		return ok && now.Sub(t) <= ct.window
+	}
+
+	column := make(map[string]int)
+	addColumn := func(protocol, label string) {
+		if _, exists := column[protocol]; !exists {
+			column[protocol] = len(m.Protocols)
+			m.Protocols = append(m.Protocols, protocol)
+			m.Labels = append(m.Labels, label)
+		}
+	}
+	configured := make(map[string]map[string]bool)
+	for _, spec := range ct.sensors {
+		m.Sensors = append(m.Sensors, spec.Name)
+		configured[spec.Name] = make(map[string]bool)
+		for i, protocol := range spec.Services {
+			configured[spec.Name][protocol] = true
+			addColumn(protocol, spec.Labels[i])
+		}
+	}
+
+	var extraSensors, extraProtocols []string
+	addedSensor := make(map[string]bool)
+	addedProtocol := make(map[string]bool)
+	for sensor, protocols := range ct.seen {
+		for protocol := range protocols {
+			if !recent(sensor, protocol) {
+				continue
+			}
+			if configured[sensor] == nil && !addedSensor[sensor] {
+				addedSensor[sensor] = true
+				extraSensors = append(extraSensors, sensor)
+			}
+			if _, exists := column[protocol]; !exists && !addedProtocol[protocol] {
+				addedProtocol[protocol] = true
+				extraProtocols = append(extraProtocols, protocol)
+			}
+		}
+	}
+	sort.Strings(extraSensors)
+	sort.Strings(extraProtocols)
+	m.Sensors = append(m.Sensors, extraSensors...)
+	for _, protocol := range extraProtocols {
+		addColumn(protocol, protocol)
+	}
+
+	for _, sensor := range m.Sensors {
+		row := make([]int, len(m.Protocols))
+		for i, protocol := range m.Protocols {
+			live := recent(sensor, protocol)
+			switch {
+			case configured[sensor][protocol] && live:
+				row[i] = coverageLive
+			case configured[sensor][protocol]:
+				row[i] = coverageSilent
+			case live:
+				row[i] = coverageUnexpected
+			}
+		}
+		m.Cells = append(m.Cells, row)
+	}
+	return m
+}
+
+func (tui *TUI) renderCoveragePanel(snap *FrameSnapshot) {
+	if !snap.View.ShowCoverage || snap.Coverage == nil {
+		return
+	}
+	m := snap.Coverage
+
+	const sensorWidth = 16
+	colWidths := make([]int, len(m.Labels))
+	for i, label := range m.Labels {
+		colWidths[i] = max(textWidth(label), 3)
+	}
+	innerWidth := sensorWidth
+	for _, w := range colWidths {
+		innerWidth += w + 1
+	}
+	innerWidth = max(innerWidth, 50)
+
+	const title = "PROTOCOL COVERAGE"
+	row := func(text string) string {
+		return "║ " + fitCells(text, innerWidth) + " ║"
+	}
+	lines := []string{
+		"╔" + strings.Repeat("═", innerWidth+2) + "╗",
+		row(fmt.Sprintf("%*s", (innerWidth+len(title))/2, title)),
+		"╠" + strings.Repeat("═", innerWidth+2) + "╣",
+	}
+	var header strings.Builder
+	header.WriteString(fmt.Sprintf("%-*s", sensorWidth, "Sensor"))
+	for i, label := range m.Labels {
+		header.WriteString(fmt.Sprintf("%-*s ", colWidths[i], label))
+	}
+	lines = append(lines, row(header.String()))
+
+	firstRow := len(lines)
+	maxRows := max(snap.Height-10, 1)
+	switch {
+	case len(m.Sensors) == 0:
+		lines = append(lines, row("No events yet (configure sensors with --sensors)"))
+	default:
+		for i, sensor := range m.Sensors {
+			if i >= maxRows {
+				break
+			}
+			lines = append(lines, row(truncateMarker(sensor, sensorWidth-1)))
+		}
+	}
+	lines = append(lines, row(""))
+	lines = append(lines, row(fmt.Sprintf("● live  ○ silent for %s  + not configured", formatWindow(m.Window))))
+	lines = append(lines, row("Press F to close"))
+	lines = append(lines, "╚"+strings.Repeat("═", innerWidth+2)+"╝")
+
+	startY := (snap.Height - len(lines)) / 2
+	startX := (snap.Width - (innerWidth + 4)) / 2
+
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background).Bold(true)
+	liveStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusOk).Background(snap.Theme.Background).Bold(true)
+	silentStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusError).Background(snap.Theme.Background).Bold(true).Reverse(true)
+	extraStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range lines {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+
+	// Cell markers go on top of the sensor rows
+	for r := 0; r < len(m.Sensors) && r < maxRows; r++ {
+		y := startY + firstRow + r
+		if y < 0 || y >= snap.Height {
+			continue
+		}
+		x := startX + 2 + sensorWidth
+		for c, state := range m.Cells[r] {
+			switch state {
+			case coverageLive:
+				tui.screen.SetContent(x, y, '●', nil, liveStyle)
+			case coverageSilent:
+				tui.screen.SetContent(x, y, '○', nil, silentStyle)
+			case coverageUnexpected:
+				tui.screen.SetContent(x, y, '+', nil, extraStyle)
+			default:
+				tui.screen.SetContent(x, y, '·', nil, panelStyle)
+			}
+			x += colWidths[c] + 1
+		}
+	}
+}
+
+// formatWindow prints a duration without trailing zero units ("1h", "90m")
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// noteSensor counts a row for the coverage panel and the hostnames the
+// findings ignore
+func noteSensor(row api.Connection, t time.Time) {
+	if GlobalCoverage != nil {
+		GlobalCoverage.Record(row.Sensor, row.Service(), t)
+	}
+	if GlobalFindings != nil {
+		GlobalFindings.NoteHostname(row.Sensor)
+	}
+}
@@ -0,0 +1,198 @@
+package tui
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+const maxCowrieSessions = 10000 // Open sessions tracked before the oldest are forgotten
+
+// cowrieSession collects one Cowrie session's events until it closes
+type cowrieSession struct {
+	ip       string
+	protocol string
+	port     int
+	sensor   string
+	username string // First login, which the session's row shows
+	password string
+	detail   api.SessionDetail
+	shown    bool // A dashboard row carries the session
+	seen     time.Time
+}
+
+// CowrieSource turns the events of a Cowrie json.log into dashboard rows,
+// for a honeypot host without an MHN server. Every login attempt is a row;
+// the first one carries the session, so the commands, downloads and
+// duration that follow update it in place. Sessions that end without a
+// login show as a plain connection.
+type CowrieSource struct {
+	dashboard *Dashboard
+	sessions  map[string]*cowrieSession
+}
+
+func NewCowrieSource(dashboard *Dashboard) *CowrieSource {
+	return &CowrieSource{dashboard: dashboard, sessions: make(map[string]*cowrieSession)}
+}
+
+// Start loads the events of the last backfill window from path as history
+// and then follows the log, across Cowrie's daily rotation
+func (cs *CowrieSource) Start(path string, backfill time.Duration) error {
+	ft, err := OpenFileTailer(path, backfill == 0)
+	if err != nil {
+		return err
+	}
+	since := time.Now().Add(-backfill)
+	loaded := 0
+	for {
+		line, ok := ft.ReadLine()
+		if !ok {
+			break
+		}
+		if cs.Handle(line, since) {
+			loaded++
+		}
+	}
+	if backfill > 0 {
+		debugLog("Cowrie: Loaded %d events from the last %v of %s", loaded, backfill, path)
+	}
+
+	api.Workers.Go("cowrie-tail", func(stop <-chan struct{}) error {
+		tailLoop(ft, stop, func(line string) {
+			cs.Handle(line, time.Time{})
+		})
+		ft.Close()
+		return nil
+	})
+	return nil
+}
+
+// Handle applies one log line. With a zero history time events are live;
+// otherwise those before it are skipped and the rest are added as history.
+// It reports whether the line was a Cowrie event that was used.
+func (cs *CowrieSource) Handle(line string, history time.Time) bool {
+	var event mhn.CowrieLogEvent
+	if json.Unmarshal([]byte(line), &event) != nil {
+		return false
+	}
+	key := event.Session
+	if !strings.HasPrefix(event.EventID, "cowrie.") || key == "" {
+		return false
+	}
+	when, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+	if err != nil {
+		when = time.Now()
+	}
+	live := history.IsZero()
+	if !live && when.Before(history) {
+		return false
+	}
+
+	session := cs.sessions[key]
+	if session == nil {
+		if len(cs.sessions) >= maxCowrieSessions {
+			cs.forgetOldest()
+		}
+		session = &cowrieSession{
+			ip:       event.SrcIP,
+			protocol: event.Protocol,
+			port:     event.Port(),
+			sensor:   event.SensorName,
+			detail:   api.SessionDetail{ID: key},
+		}
+		if session.protocol == "" {
+			session.protocol = "ssh"
+		}
+		cs.sessions[key] = session
+	}
+	session.seen = when
+
+	switch event.EventID {
+	case "cowrie.session.connect":
+		session.detail.Start = event.Timestamp
+		if GlobalCoverage != nil {
+			GlobalCoverage.Record(session.sensor, session.protocol, when)
+		}
+		if GlobalFindings != nil {
+			GlobalFindings.NoteHostname(session.sensor)
+		}
+	case "cowrie.client.version":
+		session.detail.Version = strings.Trim(event.Version, `b'"`)
+	case "cowrie.login.failed", "cowrie.login.success":
+		username, password := event.Username, event.Password
+		if session.shown {
+			cs.add(session, when, live, username, password, nil)
+		} else {
+			session.shown = true
+			session.username, session.password = username, password
+			cs.add(session, when, live, username, password, cs.snapshot(session))
+		}
+	case "cowrie.command.input":
+		session.detail.Commands = append(session.detail.Commands, event.Input)
+		cs.update(session, when, live)
+	case "cowrie.command.failed":
+		session.detail.UnknownCommands = append(session.detail.UnknownCommands, event.Input)
+		cs.update(session, when, live)
+	case "cowrie.session.file_download", "cowrie.session.file_upload":
+		if event.URL != "" {
+			session.detail.URLs = append(session.detail.URLs, event.URL)
+		}
+		if event.SHASum != "" {
+			session.detail.Hashes = append(session.detail.Hashes, event.SHASum)
+		}
+		cs.update(session, when, live)
+	case "cowrie.session.closed":
+		session.detail.End = event.Timestamp
+		if !session.shown {
+			session.shown = true
+			cs.add(session, when, live, "connection", session.protocol, cs.snapshot(session))
+		} else {
+			cs.update(session, when, live)
+		}
+		delete(cs.sessions, key)
+	}
+	return true
+}
+
+// update refreshes the session's row; the dashboard merges it by session id
+func (cs *CowrieSource) update(session *cowrieSession, when time.Time, live bool) {
+	if session.shown {
+		cs.add(session, when, live, session.username, session.password, cs.snapshot(session))
+	}
+}
+
+func (cs *CowrieSource) add(session *cowrieSession, when time.Time, live bool, username, password string, detail *api.SessionDetail) {
+	if live {
+		cs.dashboard.AddSession(session.ip, username, password, session.protocol, session.port, detail)
+	} else {
+		cs.dashboard.Backfill(when, session.ip, username, password, session.protocol, session.port, detail)
+	}
+}
+
+// snapshot copies the session so later appends do not reach into rows
+func (cs *CowrieSource) snapshot(session *cowrieSession) *api.SessionDetail {
+	detail := session.detail
+	detail.Commands = slices.Clone(detail.Commands)
+	detail.UnknownCommands = slices.Clone(detail.UnknownCommands)
+	detail.URLs = slices.Clone(detail.URLs)
+	detail.Hashes = slices.Clone(detail.Hashes)
+	return &detail
+}
+
+// forgetOldest drops the least recently active tenth of the open sessions,
+// whose close events were lost
+func (cs *CowrieSource) forgetOldest() {
+	keys := make([]string, 0, len(cs.sessions))
+	for key := range cs.sessions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return cs.sessions[keys[i]].seen.Before(cs.sessions[keys[j]].seen) })
+	for _, key := range keys[:len(keys)/10+1] {
+		delete(cs.sessions, key)
+	}
+}
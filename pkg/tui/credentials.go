@@ -0,0 +1,21 @@
+package tui
+
+import "math"
+
+// Attempts per credential pair are counted over a sliding window by
+// stats.CredentialStats; the panel and /api/credentials summarize them
+
+// percentile returns the p-th percentile (0-100) of an ascending slice
+func percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// CRTEffect post-processes every frame on its way to the terminal: alternate
+// rows are dimmed by the theme's ScanlineShade, bright cells excite a
+// phosphor that glows into the cells around them and fades after they go
+// dark, and the picture can be bowed like a curved tube. It works on a copy
+// of the composed frame, so the effects never build up in the frame buffer.
+type CRTEffect struct {
+	enabled   bool
+	glowLevel int  // Glow radius in cells, 0-3; 0 turns the phosphor off
+	curvature bool // Barrel distortion towards the corners
+	width     int
+	height    int
+	phosphor  []float64     // Excitation of each cell, decaying towards 0
+	tint      []tcell.Color // Color each cell's phosphor glows in
+	mutex     sync.Mutex
+}
+
+// Tuning for the CRT effects
+const (
+	phosphorDecay     = 0.85 // Per Update, every 100ms
+	phosphorThreshold = 96   // Foreground luminance that excites the phosphor
+	glowStrength      = 0.45 // Background tint of a fully excited neighbour
+	barrelStrength    = 0.08 // How far the corners bow outwards
+)
+
+func NewCRTEffect(width, height int) *CRTEffect {
+	crt := &CRTEffect{}
+	crt.resize(width, height)
+	return crt
+}
+
+// Settings returns whether the effect is on, its glow level and whether the
+// picture is curved
+func (crt *CRTEffect) Settings() (enabled bool, glowLevel int, curvature bool) {
+	crt.mutex.Lock()
+	defer crt.mutex.Unlock()
+	return crt.enabled, crt.glowLevel, crt.curvature
+}
+
+// Set changes the effect's settings; the input and config watcher goroutines
+// call it while the renderer applies the effect
+func (crt *CRTEffect) Set(enabled bool, glowLevel int, curvature bool) {
+	crt.mutex.Lock()
+	crt.enabled, crt.glowLevel, crt.curvature = enabled, glowLevel, curvature
+	crt.mutex.Unlock()
+}
+
+// resize must be called with crt.mutex held
+func (crt *CRTEffect) resize(width, height int) {
+	if width == crt.width && height == crt.height && crt.phosphor != nil {
+		return
+	}
+	crt.width, crt.height = width, height
+	crt.phosphor = make([]float64, width*height)
+	crt.tint = make([]tcell.Color, width*height)
+}
+
+// Update decays the phosphor glow
+func (crt *CRTEffect) Update() {
+	crt.mutex.Lock()
+	defer crt.mutex.Unlock()
+	if !crt.enabled {
+		return
+	}
+	for i := range crt.phosphor {
+		crt.phosphor[i] *= phosphorDecay
+	}
+}
+
+// Apply returns the frame as the tube shows it, or cells unchanged when the
+// effect is off
+func (crt *CRTEffect) Apply(cells []bufferCell, width, height int) []bufferCell {
+	crt.mutex.Lock()
+	defer crt.mutex.Unlock()
+	if !crt.enabled {
+		return cells
+	}
+	crt.resize(width, height)
+	out := slices.Clone(cells)
+	theme := ActiveTheme()
+
+	if crt.glowLevel > 0 {
+		crt.excite(cells, theme)
+		crt.glow(out, theme)
+	}
+
+	// Scanlines: every other row loses some of its brightness
+	shade := theme.ScanlineShade
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x++ {
+			cell := &out[y*width+x]
+			fg, bg, _ := cell.style.Decompose()
+			cell.style = cell.style.Foreground(scaleColor(orDefault(fg, theme.Text), shade)).
+				Background(scaleColor(orDefault(bg, theme.Background), shade))
+		}
+	}
+
+	if crt.curvature {
+		out = barrelWarp(out, width, height)
+	}
+	return out
+}
+
+// excite charges the phosphor under every bright character
+func (crt *CRTEffect) excite(cells []bufferCell, theme *Theme) {
+	for i, cell := range cells {
+		if cell.mainc == ' ' {
+			continue
+		}
+		fg, _, _ := cell.style.Decompose()
+		fg = orDefault(fg, theme.Text)
+		if luminance(fg) >= phosphorThreshold {
+			crt.phosphor[i] = 1
+			crt.tint[i] = fg
+		}
+	}
+}
+
+// glow tints each cell's background with the brightest phosphor within the
+// glow radius, falling off with distance, so bright cells bloom and dark ones
+// keep an afterglow while their phosphor fades
+func (crt *CRTEffect) glow(out []bufferCell, theme *Theme) {
+	radius := crt.glowLevel
+	for y := 0; y < crt.height; y++ {
+		for x := 0; x < crt.width; x++ {
+			best, tint := 0.0, tcell.ColorDefault
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || nx >= crt.width || ny < 0 || ny >= crt.height {
+						continue
+					}
+					j := ny*crt.width + nx
+					level := crt.phosphor[j] / float64(1+max(dx, -dx, dy, -dy))
+					if level > best {
+						best, tint = level, crt.tint[j]
+					}
+				}
+			}
+			if best < 0.05 {
+				continue
+			}
+			cell := &out[y*crt.width+x]
+			_, bg, _ := cell.style.Decompose()
+			cell.style = cell.style.Background(mixColor(orDefault(bg, theme.Background), tint, best*glowStrength))
+		}
+	}
+}
+
+// barrelWarp bows the frame like a curved tube: each cell shows the cell a
+// little further from the center, and the corners fall off into the bezel
+func barrelWarp(cells []bufferCell, width, height int) []bufferCell {
+	out := make([]bufferCell, len(cells))
+	bezel := bufferCell{mainc: ' ', style: tcell.StyleDefault.Background(tcell.ColorBlack)}
+	for y := 0; y < height; y++ {
+		v := (float64(y)+0.5)/float64(height)*2 - 1
+		for x := 0; x < width; x++ {
+			u := (float64(x)+0.5)/float64(width)*2 - 1
+			bend := 1 + barrelStrength*(u*u+v*v)
+			sx := int((u*bend + 1) / 2 * float64(width))
+			sy := int((v*bend + 1) / 2 * float64(height))
+			if sx < 0 || sx >= width || sy < 0 || sy >= height {
+				out[y*width+x] = bezel
+				continue
+			}
+			out[y*width+x] = cells[sy*width+sx]
+		}
+	}
+	return out
+}
+
+// orDefault is c, or fallback for the terminal's default color
+func orDefault(c, fallback tcell.Color) tcell.Color {
+	if c.Valid() {
+		return c
+	}
+	return fallback
+}
+
+// scaleColor darkens c by factor; the terminal default stays as it is
+func scaleColor(c tcell.Color, factor float64) tcell.Color {
+	if !c.Valid() {
+		return c
+	}
+	r, g, b := c.RGB()
+	return tcell.NewRGBColor(int32(float64(r)*factor), int32(float64(g)*factor), int32(float64(b)*factor))
+}
+
+// mixColor moves c towards target by f, treating the terminal default as
+// black
+func mixColor(c, target tcell.Color, f float64) tcell.Color {
+	var r1, g1, b1, r2, g2, b2 int32
+	if c.Valid() {
+		r1, g1, b1 = c.RGB()
+	}
+	if target.Valid() {
+		r2, g2, b2 = target.RGB()
+	}
+	mix := func(a, b int32) int32 { return a + int32(float64(b-a)*min(f, 1)) }
+	return tcell.NewRGBColor(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
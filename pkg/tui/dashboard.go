@@ -0,0 +1,338 @@
+package tui
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+type Dashboard struct {
+	Connections []api.Connection
+	MaxLines    int
+	frozen      bool             // New rows are held back, see SetFrozen
+	held        []api.Connection // Newest rows held back while frozen, oldest first
+	heldCount   int              // Rows that arrived while frozen, including ones beyond MaxLines
+	mutex       sync.RWMutex
+}
+
+func NewDashboard(maxLines int) *Dashboard {
+	return &Dashboard{
+		Connections: make([]api.Connection, 0),
+		MaxLines:    maxLines,
+	}
+}
+
+func (d *Dashboard) AddConnection(ip, username, password, protocol string, port int) {
+	d.AddSession(ip, username, password, protocol, port, nil)
+}
+
+// AddSession adds a row carrying Cowrie session detail (nil when the event
+// has none). A later event for a session already on screen, such as its end
+// event, updates that row in place instead of adding a second one.
+func (d *Dashboard) AddSession(ip, username, password, protocol string, port int, detail *api.SessionDetail) {
+	d.addSession(time.Now(), true, api.Connection{IP: ip, Username: username, Password: password, Protocol: protocol, Port: port, Session: detail})
+}
+
+// AddRow adds a row decoded from a honeypot feed event (see
+// api.ParseAPIEvents), with what its kind of honeypot reports beyond the
+// shared fields, at time t. live is false for events from before startup,
+// as for Backfill.
+func (d *Dashboard) AddRow(t time.Time, live bool, row api.Connection) {
+	d.addSession(t, live, row)
+}
+
+// Backfill adds an event from before startup at its original time. It feeds
+// the panels, stats and timeline but draws no arcs, fires no alerts and is
+// not re-published over hpfeeds.
+func (d *Dashboard) Backfill(t time.Time, ip, username, password, protocol string, port int, detail *api.SessionDetail) {
+	d.addSession(t, false, api.Connection{IP: ip, Username: username, Password: password, Protocol: protocol, Port: port, Session: detail})
+}
+
+func (d *Dashboard) addSession(t time.Time, live bool, connection api.Connection) {
+	if d == nil {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if detail := connection.Session; detail != nil && detail.ID != "" {
+		if rows, i := d.findRow(detail.ID); i >= 0 {
+			d.updateRow(rows, i, connection.Username, connection.Password, detail)
+			return
+		}
+	}
+
+	// Name the service from the destination port when the sensor left the
+	// protocol out, so glyphs, filters and rules still have one to go on
+	if connection.Protocol == "" {
+		connection.Protocol, connection.Inferred = mhn.InferProtocol(connection.Port)
+	}
+
+	connection.Time = t
+	if connection.Session != nil {
+		connection.Key = connection.Session.ID
+	}
+
+	// Lookup geolocation for arc rendering (fast, cached)
+	if GlobalGeoIP != nil {
+		loc := GlobalGeoIP.LookupIP(connection.IP)
+		if loc.Valid {
+			connection.City = loc.City
+			connection.Country = loc.Country
+			connection.ASN = loc.ASN
+			connection.Org = loc.Org
+			connection.RDNS = loc.RDNS
+			// Add to arc manager if enabled
+			lat, lon := GlobalGeoIP.Plot(connection.IP, loc)
+			if GlobalArcManager != nil && live {
+				GlobalArcManager.AddArc(connection.IP, lat, lon, connection.Protocol, connection.Origin)
+			}
+			if GlobalFootprints != nil {
+				GlobalFootprints.Record(lat, lon, t)
+			}
+		}
+
+		// Re-publish the enriched event when acting as an enrichment node,
+		// hand it to the SIEM and event buses and the recording, and index
+		// it for Kibana.
+		// Backfilled events are only indexed, where their ids keep them from
+		// doubling up.
+		if GlobalHPFeedsPublisher != nil || GlobalSyslog != nil || GlobalElastic != nil || len(GlobalBuses) > 0 || GlobalTUI != nil {
+			event := api.EnrichedEvent{
+				SrcIP:     connection.IP,
+				Username:  connection.Username,
+				Password:  connection.Password,
+				Protocol:  connection.Protocol,
+				Timestamp: connection.Time.UTC().Format(time.RFC3339),
+				City:      loc.City,
+				Country:   loc.Country,
+				Latitude:  loc.Latitude,
+				Longitude: loc.Longitude,
+				ASN:       loc.ASN,
+				Org:       loc.Org,
+				RDNS:      loc.RDNS,
+				Origin:    connection.Origin,
+				DestPort:  connection.Port,
+				Inferred:  connection.Inferred,
+				Feed:      connection.Feed,
+			}
+			if GlobalHPFeedsPublisher != nil && live {
+				GlobalHPFeedsPublisher.Publish(event)
+			}
+			if GlobalSyslog != nil && live {
+				GlobalSyslog.Forward(event, connection.Time)
+			}
+			if GlobalElastic != nil {
+				GlobalElastic.Index(event, connection.Time)
+			}
+			if live {
+				for _, bus := range GlobalBuses {
+					bus.Publish(event)
+				}
+				if GlobalTUI != nil {
+					GlobalTUI.RecordEvent(event)
+				}
+			}
+		}
+	}
+
+	if GlobalOffenders != nil {
+		connection.Hits = GlobalOffenders.Record(connection.IP)
+	}
+
+	if GlobalAlertEngine != nil && live {
+		connection.Alert = GlobalAlertEngine.Evaluate(connection)
+	}
+	if GlobalFindings != nil && live {
+		if kind := GlobalFindings.Evaluate(connection); connection.Alert == "" {
+			connection.Alert = kind
+		}
+	}
+	if GlobalHashes != nil {
+		for _, hash := range connectionHashes(connection) {
+			GlobalHashes.Note(hash, connection.IP, t)
+		}
+	}
+	if globalURLs != nil {
+		for _, url := range connectionURLs(connection) {
+			globalURLs.Note(url, connection.IP, t)
+		}
+	}
+
+	if d.frozen {
+		d.held = append(d.held, connection)
+		d.heldCount++
+		if len(d.held) > d.MaxLines {
+			d.held = d.held[len(d.held)-d.MaxLines:]
+		}
+	} else {
+		d.Connections = append(d.Connections, connection)
+	}
+
+	if GlobalHistory != nil {
+		GlobalHistory.Record(connection)
+	}
+
+	if live {
+		globalRate.Record(connection.Time)
+	}
+	globalLocalStats.Record(connection.Time, connection.Protocol)
+	globalCountryTally.Record(connection.Country)
+
+	if GlobalCredStats != nil {
+		GlobalCredStats.Record(connection.Username, connection.Password, connection.Time)
+	}
+
+	if GlobalIntel != nil {
+		GlobalIntel.Record(connection)
+	}
+
+	if len(d.Connections) > d.MaxLines {
+		d.Connections = d.Connections[len(d.Connections)-d.MaxLines:]
+	}
+
+	if GlobalTUI != nil {
+		GlobalTUI.MarkDashboardChanged()
+		GlobalTUI.MarkStatsChanged()
+		if GlobalTUI.frameRate != nil {
+			GlobalTUI.frameRate.Touch()
+		}
+	}
+}
+
+// findRow returns the rows holding the row with the given key, the shown
+// ones or those held back while frozen, and its index there, or -1. Callers
+// hold the dashboard lock.
+func (d *Dashboard) findRow(key string) ([]api.Connection, int) {
+	for _, rows := range [][]api.Connection{d.held, d.Connections} {
+		for i := len(rows) - 1; i >= 0; i-- {
+			if rows[i].Key == key {
+				return rows, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// updateRow folds a follow-up session event into an existing row. The row is
+// not re-counted: geolocation, arcs, alerts and statistics ran when it was added.
+func (d *Dashboard) updateRow(rows []api.Connection, i int, username, password string, detail *api.SessionDetail) {
+	row := rows[i]
+	var known, knownURLs []string
+	if row.Session != nil {
+		known = row.Session.Hashes
+		knownURLs = row.Session.URLs
+		row.Session = row.Session.Merge(detail)
+	} else {
+		row.Session = detail
+	}
+	// End events repeat the session's hashes; only the new ones are noted
+	if GlobalHashes != nil {
+		for _, hash := range row.Session.Hashes {
+			if !slices.Contains(known, hash) {
+				GlobalHashes.Note(hash, row.IP, row.Time)
+			}
+		}
+	}
+	if globalURLs != nil {
+		for _, url := range row.Session.URLs {
+			if !slices.Contains(knownURLs, url) {
+				globalURLs.Note(url, row.IP, row.Time)
+			}
+		}
+	}
+	// Start events may arrive before the login is known
+	if (row.Username == "unknown" || row.Username == "connection") && username != "unknown" && username != "connection" {
+		row.Username = username
+		row.Password = password
+	}
+	rows[i] = row
+	// Scrub mode and history searches read the recorded copy
+	if GlobalHistory != nil {
+		GlobalHistory.Update(row)
+	}
+
+	if GlobalTUI != nil {
+		GlobalTUI.MarkDashboardChanged()
+	}
+}
+
+// mockGenerator invents the traffic shown when no feed could be reached
+var mockGenerator = NewDemoGenerator(time.Now().UnixNano())
+
+func (d *Dashboard) GenerateRandomConnection() {
+	mockGenerator.Next("", "").add(d, mockGenerator.rng)
+}
+
+// SetFrozen freezes or thaws the feed. While frozen, new rows are held back
+// so the rows being read stay put; arcs, alerts, statistics and forwarding
+// carry on. Thawing adds the held rows in the order they arrived. It
+// returns how many rows arrived while frozen.
+func (d *Dashboard) SetFrozen(frozen bool) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	count := d.heldCount
+	if d.frozen && !frozen {
+		d.Connections = append(d.Connections, d.held...)
+		if len(d.Connections) > d.MaxLines {
+			d.Connections = d.Connections[len(d.Connections)-d.MaxLines:]
+		}
+		d.held, d.heldCount = nil, 0
+	}
+	d.frozen = frozen
+	return count
+}
+
+// Frozen reports whether the feed is frozen and how many rows have arrived
+// since
+func (d *Dashboard) Frozen() (bool, int) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.frozen, d.heldCount
+}
+
+// Capacity returns how many rows the dashboard keeps
+func (d *Dashboard) Capacity() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.MaxLines
+}
+
+// Latest returns the newest dashboard row
+func (d *Dashboard) Latest() (api.Connection, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if len(d.Connections) == 0 {
+		return api.Connection{}, false
+	}
+	return d.Connections[len(d.Connections)-1], true
+}
+
+// List copies the current dashboard rows
+func (d *Dashboard) List() ConnectionList {
+	if d == nil {
+		return nil
+	}
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return append(ConnectionList(nil), d.Connections...)
+}
+
+// AddAPIRows adds rows from an API endpoint or the MQTT source, counting
+// each for the coverage panel. Live rows are stamped with the time they
+// arrive; backfilled ones keep their own.
+func (d *Dashboard) AddAPIRows(rows []api.Connection, live bool) {
+	for _, row := range rows {
+		t := row.Time
+		if live {
+			t = time.Now()
+		}
+		noteSensor(row, t)
+		d.AddRow(t, live, row)
+	}
+}
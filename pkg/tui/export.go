@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// exportFormats are the file formats :export writes
+var exportFormats = []string{"ndjson", "csv", "cef"}
+
+// ExportEvents writes rows to path as NDJSON, CSV with a header line, or
+// CEF records, oldest first
+func ExportEvents(rows ConnectionList, format, path string) error {
+	var buf bytes.Buffer
+	switch format {
+	case "ndjson":
+		enc := json.NewEncoder(&buf)
+		for _, conn := range rows {
+			if err := enc.Encode(conn.Enriched()); err != nil {
+				return err
+			}
+		}
+	case "csv":
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"timestamp", "src_ip", "username", "password", "protocol", "dest_port", "city", "country", "asn", "org", "rdns", "origin"})
+		for _, conn := range rows {
+			event := conn.Enriched()
+			port := ""
+			if event.DestPort != 0 {
+				port = strconv.Itoa(event.DestPort)
+			}
+			w.Write([]string{event.Timestamp, event.SrcIP, event.Username, event.Password, event.Protocol, port,
+				event.City, event.Country, event.ASN, event.Org, event.RDNS, event.Origin})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	case "cef":
+		for _, conn := range rows {
+			buf.WriteString(api.FormatCEF(conn.Enriched()))
+			buf.WriteByte('\n')
+		}
+	default:
+		return fmt.Errorf("unknown format %q (formats: %s)", format, strings.Join(exportFormats, ", "))
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// rowFilterFields are the row fields :filter can match, in completion order
+var rowFilterFields = []string{"country", "proto", "port", "ip", "asn", "org", "city", "origin"}
+
+// rowField returns a row's value for one of rowFilterFields
+func rowField(conn api.Connection, field string) string {
+	switch field {
+	case "country":
+		return conn.Country
+	case "proto":
+		return conn.Protocol
+	case "port":
+		if conn.Port == 0 {
+			return ""
+		}
+		return strconv.Itoa(conn.Port)
+	case "ip":
+		return conn.IP
+	case "asn":
+		return conn.ASN
+	case "org":
+		return conn.Org
+	case "city":
+		return conn.City
+	case "origin":
+		return conn.Origin
+	}
+	return ""
+}
+
+// RowFilter keeps the dashboard rows whose field matches a value, ignoring
+// case: an address or CIDR block for ip, a substring for org and city, and
+// the whole value for the rest. The zero RowFilter keeps every row.
+type RowFilter struct {
+	Field   string
+	Value   string
+	network *net.IPNet
+}
+
+func parseRowFilter(field, value string) (RowFilter, error) {
+	if !slices.Contains(rowFilterFields, field) {
+		return RowFilter{}, fmt.Errorf("unknown field %q (fields: %s)", field, strings.Join(rowFilterFields, ", "))
+	}
+	if value == "" {
+		return RowFilter{}, fmt.Errorf("filter %s needs a value", field)
+	}
+	filter := RowFilter{Field: field, Value: value}
+	if field == "ip" && strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return RowFilter{}, fmt.Errorf("invalid CIDR block %q", value)
+		}
+		filter.network = network
+	}
+	return filter, nil
+}
+
+func (f RowFilter) Active() bool {
+	return f.Field != ""
+}
+
+func (f RowFilter) String() string {
+	return f.Field + "=" + f.Value
+}
+
+func (f RowFilter) Match(conn api.Connection) bool {
+	value := rowField(conn, f.Field)
+	switch {
+	case f.network != nil:
+		ip := net.ParseIP(value)
+		return ip != nil && f.network.Contains(ip)
+	case f.Field == "org" || f.Field == "city":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(f.Value))
+	default:
+		return strings.EqualFold(value, f.Value)
+	}
+}
+
+// SetRowFilter shows only the rows matching f on the dashboard, drawn from
+// the whole session history rather than the rows on screen
+func (tui *TUI) SetRowFilter(f RowFilter) {
+	tui.state.mutex.Lock()
+	tui.state.rowFilter = f
+	// The selected row may be filtered out; the next arrow picks the newest
+	tui.state.triageIP, tui.state.triageTime = "", time.Time{}
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
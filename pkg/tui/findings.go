@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+const (
+	maxFindings                = 200
+	maxKnownUsernames          = 50000
+	DefaultFindingsWindow      = 10 * time.Minute
+	DefaultFindingsUsernames   = 10 // Distinct usernames from one address that make a spray
+	DefaultFindingsNewUserRate = 20 // Attempts with a newly seen username that make a finding
+	minPasswordHostname        = 4  // Shortest hostname passwords are checked for
+)
+
+// The kinds of finding
+const (
+	findingSpray    = "username-spray"
+	findingNewUser  = "new-username"
+	findingHostname = "hostname-password"
+)
+
+// Finding is one unusual credential pattern, shown in the findings panel
+type Finding struct {
+	Kind     string    `json:"kind"`
+	Time     time.Time `json:"time"`
+	IP       string    `json:"src_ip"`
+	Country  string    `json:"country,omitempty"`
+	Protocol string    `json:"protocol,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Password string    `json:"password,omitempty"`
+	Detail   string    `json:"detail"`
+}
+
+// CredentialAnalyzer flags unusual credential activity: one address
+// cycling through many usernames, a username never seen before suddenly
+// tried at a high rate, and passwords containing a honeypot's hostname,
+// which an attacker can only have learned from the honeypot itself.
+// Usernames seen during the first window are the baseline, not new.
+type CredentialAnalyzer struct {
+	window    time.Duration
+	spray     int // Distinct usernames per address within the window, 0 disables
+	newRate   int // Attempts with a new username within the window, 0 disables
+	baseline  time.Time
+	hostnames map[string]bool                 // Lower case sensor and honeypot names
+	usernames map[string]map[string]time.Time // IP -> username -> last tried
+	firstSeen map[string]time.Time            // Username -> first tried
+	newHits   map[string][]time.Time          // New username -> attempts within the window
+	reported  map[string]time.Time            // kind|subject -> last logged
+	log       []Finding
+	mutex     sync.Mutex
+}
+
+func NewCredentialAnalyzer(window time.Duration, spray, newRate int, hostnames []string) *CredentialAnalyzer {
+	ca := &CredentialAnalyzer{
+		window:    window,
+		spray:     spray,
+		newRate:   newRate,
+		baseline:  time.Now().Add(window),
+		hostnames: make(map[string]bool),
+		usernames: make(map[string]map[string]time.Time),
+		firstSeen: make(map[string]time.Time),
+		newHits:   make(map[string][]time.Time),
+		reported:  make(map[string]time.Time),
+	}
+	for _, name := range hostnames {
+		ca.NoteHostname(name)
+	}
+	return ca
+}
+
+// Thresholds returns the window and the spray and new username rates
+func (ca *CredentialAnalyzer) Thresholds() (time.Duration, int, int) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	return ca.window, ca.spray, ca.newRate
+}
+
+// SetThresholds changes the window and the spray and new username rates
+func (ca *CredentialAnalyzer) SetThresholds(window time.Duration, spray, newRate int) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	ca.window, ca.spray, ca.newRate = window, spray, newRate
+}
+
+// NoteHostname adds a honeypot name passwords are checked for, along with
+// its first label when it is a fully qualified name
+func (ca *CredentialAnalyzer) NoteHostname(name string) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < minPasswordHostname {
+		return
+	}
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	ca.hostnames[name] = true
+	if label, _, ok := strings.Cut(name, "."); ok && len(label) >= minPasswordHostname {
+		ca.hostnames[label] = true
+	}
+}
+
+// Evaluate checks a live event's credentials. It returns the kind of the
+// first finding the event is part of, for highlighting its row, or "".
+func (ca *CredentialAnalyzer) Evaluate(conn api.Connection) string {
+	if conn.Username == "" && conn.Password == "" {
+		return ""
+	}
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	now := conn.Time
+	var kinds []string
+
+	if conn.Username != "" && ca.spray > 0 {
+		tried := ca.usernames[conn.IP]
+		if tried == nil {
+			tried = make(map[string]time.Time)
+			ca.usernames[conn.IP] = tried
+		}
+		tried[conn.Username] = now
+		maps.DeleteFunc(tried, func(_ string, t time.Time) bool { return now.Sub(t) > ca.window })
+		if len(tried) >= ca.spray {
+			kinds = append(kinds, findingSpray)
+			ca.report(conn, findingSpray, conn.IP, fmt.Sprintf("%d usernames in %s", len(tried), formatWindow(ca.window)))
+		}
+	}
+
+	if conn.Username != "" && ca.newRate > 0 {
+		first, known := ca.firstSeen[conn.Username]
+		if !known && len(ca.firstSeen) < maxKnownUsernames {
+			first, known = now, true
+			ca.firstSeen[conn.Username] = now
+		}
+		if known && first.After(ca.baseline) && now.Sub(first) <= ca.window {
+			hits := append(ca.newHits[conn.Username], now)
+			ca.newHits[conn.Username] = hits
+			if len(hits) >= ca.newRate {
+				kinds = append(kinds, findingNewUser)
+				ca.report(conn, findingNewUser, conn.Username, fmt.Sprintf("new username, %d tries since %s", len(hits), first.Format("15:04:05")))
+			}
+		}
+	}
+
+	if host := ca.passwordHostname(conn.Password); host != "" {
+		kinds = append(kinds, findingHostname)
+		ca.report(conn, findingHostname, conn.IP+"|"+host, fmt.Sprintf("password contains hostname %q", host))
+	}
+
+	ca.prune(now)
+	if len(kinds) == 0 {
+		return ""
+	}
+	return kinds[0]
+}
+
+// passwordHostname returns the honeypot name the password contains, or ""
+func (ca *CredentialAnalyzer) passwordHostname(password string) string {
+	password = strings.ToLower(password)
+	for name := range ca.hostnames {
+		if strings.Contains(password, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// report logs a finding unless the same one was logged within the window,
+// so an ongoing spray is one entry rather than one per attempt
+func (ca *CredentialAnalyzer) report(conn api.Connection, kind, subject, detail string) {
+	key := kind + "|" + subject
+	if last, ok := ca.reported[key]; ok && conn.Time.Sub(last) < ca.window {
+		return
+	}
+	ca.reported[key] = conn.Time
+
+	finding := Finding{
+		Kind:     kind,
+		Time:     conn.Time,
+		IP:       conn.IP,
+		Country:  conn.Country,
+		Protocol: conn.Protocol,
+		Username: conn.Username,
+		Password: conn.Password,
+		Detail:   detail,
+	}
+	ca.log = append(ca.log, finding)
+	if len(ca.log) > maxFindings {
+		ca.log = ca.log[len(ca.log)-maxFindings:]
+	}
+	debugLog("Findings: %s from %s: %s", kind, conn.IP, detail)
+	globalToasts.Post(fmt.Sprintf("Finding: %s from %s", kind, conn.IP), true)
+}
+
+// prune forgets usernames, attempts and reports that have left the window
+// so the maps do not grow with every attacker ever seen
+func (ca *CredentialAnalyzer) prune(now time.Time) {
+	if len(ca.usernames)+len(ca.newHits)+len(ca.reported) < 4096 {
+		return
+	}
+	stale := func(t time.Time) bool { return now.Sub(t) > ca.window }
+	for ip, tried := range ca.usernames {
+		maps.DeleteFunc(tried, func(_ string, t time.Time) bool { return stale(t) })
+		if len(tried) == 0 {
+			delete(ca.usernames, ip)
+		}
+	}
+	for username, hits := range ca.newHits {
+		if stale(ca.firstSeen[username]) || len(hits) == 0 {
+			delete(ca.newHits, username)
+		}
+	}
+	maps.DeleteFunc(ca.reported, func(_ string, t time.Time) bool { return stale(t) })
+}
+
+// Recent returns up to n findings, newest first
+func (ca *CredentialAnalyzer) Recent(n int) []Finding {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	if n <= 0 || n > len(ca.log) {
+		n = len(ca.log)
+	}
+	recent := make([]Finding, 0, n)
+	for i := len(ca.log) - 1; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, ca.log[i])
+	}
+	return recent
+}
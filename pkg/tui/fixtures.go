@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"slices"
+	"sort"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// geoFixture places a block of addresses at its network's main city
+type geoFixture struct {
+	Country string
+	City    string
+	Lat     float64
+	Lon     float64
+	ASN     string
+	Org     string
+	Prefix  string
+}
+
+// geoFixtures add cloud, hosting and scanner blocks that are common in
+// honeypot logs to the demo storm's networks, so --offline can place more
+// real traffic
+var geoFixtures = []geoFixture{
+	{"CN", "Nanjing", 32.06, 118.78, "AS4134", "Chinanet", "61.177.0.0/16"},
+	{"CN", "Zhenjiang", 32.19, 119.42, "AS4134", "Chinanet", "222.186.0.0/16"},
+	{"CN", "Hangzhou", 30.27, 120.15, "AS4134", "Chinanet", "183.136.0.0/16"},
+	{"CN", "Nanjing", 32.06, 118.78, "AS4837", "China Unicom", "112.85.0.0/16"},
+	{"CN", "Guangzhou", 23.13, 113.26, "AS45090", "Tencent Cloud", "119.29.0.0/16"},
+	{"TW", "Taipei", 25.03, 121.56, "AS3462", "Chunghwa Telecom", "114.32.0.0/12"},
+	{"KR", "Seoul", 37.57, 126.98, "AS4766", "Korea Telecom", "175.192.0.0/10"},
+	{"VN", "Hanoi", 21.03, 105.85, "AS45899", "VNPT", "14.160.0.0/11"},
+	{"US", "Ashburn", 39.04, -77.49, "AS14618", "Amazon", "54.80.0.0/12"},
+	{"US", "Council Bluffs", 41.26, -95.86, "AS396982", "Google Cloud", "35.184.0.0/13"},
+	{"US", "San Francisco", 37.77, -122.42, "AS13335", "Cloudflare", "104.16.0.0/13"},
+	{"US", "New York", 40.71, -74.01, "AS14061", "DigitalOcean", "159.203.0.0/16"},
+	{"US", "Ann Arbor", 42.28, -83.74, "AS398324", "Censys", "167.94.138.0/24"},
+	{"DE", "Nuremberg", 49.45, 11.08, "AS24940", "Hetzner Online", "78.46.0.0/15"},
+	{"FI", "Helsinki", 60.17, 24.94, "AS24940", "Hetzner Online", "95.216.0.0/16"},
+	{"FR", "Paris", 48.86, 2.35, "AS12876", "Scaleway", "51.15.0.0/16"},
+	{"FR", "Paris", 48.86, 2.35, "AS12876", "Scaleway", "62.210.0.0/16"},
+	{"FR", "Roubaix", 50.69, 3.17, "AS16276", "OVH", "137.74.0.0/16"},
+	{"NL", "Amsterdam", 52.37, 4.90, "AS202425", "IP Volume", "80.82.64.0/20"},
+	{"CH", "Zurich", 47.37, 8.54, "AS51852", "Private Layer", "179.43.128.0/18"},
+}
+
+// fixtureJitter spreads the addresses of a block this many degrees around
+// its city, so a busy network is a cluster rather than one marker
+const fixtureJitter = 0.6
+
+type fixtureBlock struct {
+	network *net.IPNet
+	fixture geoFixture
+}
+
+// GeoFixtures locates addresses from the embedded network table, without
+// any network access
+type GeoFixtures struct {
+	blocks []fixtureBlock // Most specific first
+}
+
+// NewGeoFixtures builds the table from the demo storm's networks and
+// geoFixtures
+func NewGeoFixtures() *GeoFixtures {
+	fixtures := slices.Clone(geoFixtures)
+	for _, src := range demoSources {
+		fixtures = append(fixtures, geoFixture{src.Country, src.City, src.Lat, src.Lon, src.ASN, src.Org, src.Prefix})
+	}
+	f := &GeoFixtures{}
+	for _, fixture := range fixtures {
+		_, network, err := net.ParseCIDR(fixture.Prefix)
+		if err != nil {
+			panic(fmt.Sprintf("geo fixture %s: %v", fixture.Prefix, err))
+		}
+		f.blocks = append(f.blocks, fixtureBlock{network, fixture})
+	}
+	sort.SliceStable(f.blocks, func(i, j int) bool {
+		a, _ := f.blocks[i].network.Mask.Size()
+		b, _ := f.blocks[j].network.Mask.Size()
+		return a > b
+	})
+	return f
+}
+
+// Lookup places ipStr in the most specific block containing it. An address
+// always lands on the same spot near the block's city.
+func (f *GeoFixtures) Lookup(ipStr string) (api.LocationInfo, bool) {
+	ip := net.ParseIP(ipStr)
+	if f == nil || ip == nil {
+		return api.LocationInfo{}, false
+	}
+	for _, block := range f.blocks {
+		if !block.network.Contains(ip) {
+			continue
+		}
+		h := fnv.New32a()
+		h.Write([]byte(ipStr))
+		sum := h.Sum32()
+		fx := block.fixture
+		return api.LocationInfo{
+			City:      fx.City,
+			Country:   fx.Country,
+			Latitude:  fx.Lat + (float64(sum&0xffff)/0xffff-0.5)*fixtureJitter,
+			Longitude: fx.Lon + (float64(sum>>16)/0xffff-0.5)*fixtureJitter,
+			ASN:       fx.ASN,
+			Org:       fx.Org,
+			Valid:     true,
+		}, true
+	}
+	return api.LocationInfo{}, false
+}
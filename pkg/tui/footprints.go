@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Footprint is a dim dot left where attacks came from. It outlives the
+// source's marker and arcs, fading out over the footprint time after the
+// last attack from there.
+type Footprint struct {
+	Lat  float64
+	Lon  float64
+	Hits int
+	Last time.Time
+	Fade float64 // 1 just after the last hit, falling to 0 at the footprint time (Active only)
+}
+
+// Tuning for the footprints layer
+const (
+	DefaultFootprintTime = 10 * time.Minute
+	footprintGrid        = 0.5  // Degrees; sources closer than this share a footprint
+	maxFootprints        = 5000 // The stalest footprints make way beyond this
+	footprintBusy        = 20   // Hits at which a footprint is drawn at full strength
+)
+
+// FootprintTracker keeps a footprint at each place attacks came from for
+// ttl after the last of them, so the session's geography builds up on the
+// globe long after the markers have aged out
+type FootprintTracker struct {
+	mutex sync.Mutex
+	ttl   time.Duration
+	cells map[[2]int]*Footprint
+}
+
+func NewFootprintTracker(ttl time.Duration) *FootprintTracker {
+	return &FootprintTracker{ttl: ttl, cells: make(map[[2]int]*Footprint)}
+}
+
+// Record leaves a footprint at lat, lon for an attack at t. Attacks older
+// than the footprint time, such as most of a backfill, leave none.
+func (ft *FootprintTracker) Record(lat, lon float64, t time.Time) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	now := time.Now()
+	if ft.ttl <= 0 || now.Sub(t) >= ft.ttl {
+		return
+	}
+	key := [2]int{int(math.Floor(lat / footprintGrid)), int(math.Floor(lon / footprintGrid))}
+	if fp, ok := ft.cells[key]; ok {
+		fp.Hits++
+		if t.After(fp.Last) {
+			fp.Last = t
+		}
+		return
+	}
+	if len(ft.cells) >= maxFootprints {
+		ft.evict(now)
+	}
+	// The first attack's position keeps the dot under the markers
+	ft.cells[key] = &Footprint{Lat: lat, Lon: lon, Hits: 1, Last: t}
+}
+
+// evict drops the expired footprints, or failing that the stalest one.
+// Callers hold the lock.
+func (ft *FootprintTracker) evict(now time.Time) {
+	var stalest [2]int
+	var oldest time.Time
+	for key, fp := range ft.cells {
+		if now.Sub(fp.Last) >= ft.ttl {
+			delete(ft.cells, key)
+		} else if oldest.IsZero() || fp.Last.Before(oldest) {
+			stalest, oldest = key, fp.Last
+		}
+	}
+	if len(ft.cells) >= maxFootprints {
+		delete(ft.cells, stalest)
+	}
+}
+
+// Active returns the footprints still fading at now, dropping the rest
+func (ft *FootprintTracker) Active(now time.Time) []Footprint {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	footprints := make([]Footprint, 0, len(ft.cells))
+	for key, fp := range ft.cells {
+		age := now.Sub(fp.Last)
+		if age >= ft.ttl {
+			delete(ft.cells, key)
+			continue
+		}
+		active := *fp
+		active.Fade = 1 - max(age, 0).Seconds()/ft.ttl.Seconds()
+		footprints = append(footprints, active)
+	}
+	return footprints
+}
+
+func (ft *FootprintTracker) TTL() time.Duration {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	return ft.ttl
+}
+
+// SetTTL changes the footprint time; 0 clears the footprints and stops
+// recording them
+func (ft *FootprintTracker) SetTTL(ttl time.Duration) {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	ft.ttl = ttl
+	if ttl <= 0 {
+		clear(ft.cells)
+	}
+}
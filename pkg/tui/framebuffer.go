@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// bufferCell is one cell of an off-screen frame
+type bufferCell struct {
+	mainc rune
+	combc []rune
+	style tcell.Style
+}
+
+func (c bufferCell) equal(other bufferCell) bool {
+	return c.mainc == other.mainc && c.style == other.style && slices.Equal(c.combc, other.combc)
+}
+
+// bufferedScreen collects a frame's layers (globe, arcs, rain, dashboard,
+// panels) in an off-screen back buffer instead of drawing them straight onto
+// the terminal. Show diffs the finished frame against the last one shown and
+// sends only the changed cells in a single batch, so several layers changing
+// in one frame can never be seen half drawn.
+type bufferedScreen struct {
+	tcell.Screen
+	width, height int
+	back, front   []bufferCell
+	fill          bufferCell // What Clear leaves in every cell
+	full          bool       // Send every cell at the next Show
+	dim           float64    // Fade applied to cells as they are drawn, 0-1
+	post          func(cells []bufferCell, width, height int) []bufferCell
+	mutex         sync.Mutex
+}
+
+func newBufferedScreen(screen tcell.Screen) *bufferedScreen {
+	bs := &bufferedScreen{Screen: screen, fill: bufferCell{mainc: ' '}}
+	bs.resize()
+	return bs
+}
+
+// resize matches the buffers to the terminal, keeping nothing of the old
+// frame; it must be called with bs.mutex held
+func (bs *bufferedScreen) resize() {
+	width, height := bs.Screen.Size()
+	if width == bs.width && height == bs.height && bs.back != nil {
+		return
+	}
+	bs.width, bs.height = width, height
+	bs.back = make([]bufferCell, width*height)
+	bs.front = make([]bufferCell, width*height)
+	for i := range bs.back {
+		bs.back[i] = bs.fill
+	}
+	bs.full = true
+}
+
+func (bs *bufferedScreen) SetContent(x, y int, mainc rune, combc []rune, style tcell.Style) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	if x < 0 || y < 0 || x >= bs.width || y >= bs.height {
+		return
+	}
+	if bs.dim > 0 {
+		style = dimStyle(style, bs.dim)
+	}
+	bs.back[y*bs.width+x] = bufferCell{mainc: mainc, combc: slices.Clone(combc), style: style}
+}
+
+// SetPostProcess has every frame pass through fn on its way to the
+// terminal, leaving the frame buffer itself untouched
+func (bs *bufferedScreen) SetPostProcess(fn func(cells []bufferCell, width, height int) []bufferCell) {
+	bs.mutex.Lock()
+	bs.post = fn
+	bs.full = true
+	bs.mutex.Unlock()
+}
+
+// SetDim fades everything drawn from now on towards its background by
+// factor, 0 for none and 1 for invisible text
+func (bs *bufferedScreen) SetDim(factor float64) {
+	bs.mutex.Lock()
+	bs.dim = min(max(factor, 0), 1)
+	bs.mutex.Unlock()
+}
+
+// dimStyle blends a style's foreground towards its background, or the
+// theme's when the cell has none
+func dimStyle(style tcell.Style, factor float64) tcell.Style {
+	fg, bg, _ := style.Decompose()
+	theme := ActiveTheme()
+	if !fg.Valid() {
+		fg = theme.Text
+	}
+	if !bg.Valid() {
+		bg = theme.Background
+	}
+	if !fg.Valid() {
+		return style
+	}
+	fr, fgr, fb := fg.RGB()
+	var br, bgr, bb int32 // Black under a terminal default background
+	if bg.Valid() {
+		br, bgr, bb = bg.RGB()
+	}
+	blend := func(from, to int32) int32 {
+		return from + int32(float64(to-from)*factor)
+	}
+	return style.Foreground(tcell.NewRGBColor(blend(fr, br), blend(fgr, bgr), blend(fb, bb)))
+}
+
+func (bs *bufferedScreen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	if x < 0 || y < 0 || x >= bs.width || y >= bs.height {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	cell := bs.back[y*bs.width+x]
+	return cell.mainc, slices.Clone(cell.combc), cell.style, max(runewidth.RuneWidth(cell.mainc), 1)
+}
+
+func (bs *bufferedScreen) SetStyle(style tcell.Style) {
+	bs.mutex.Lock()
+	bs.fill.style = style
+	bs.mutex.Unlock()
+	bs.Screen.SetStyle(style)
+}
+
+func (bs *bufferedScreen) Clear() {
+	bs.Fill(' ', bs.fill.style)
+}
+
+func (bs *bufferedScreen) Fill(r rune, style tcell.Style) {
+	bs.mutex.Lock()
+	defer bs.mutex.Unlock()
+	bs.resize()
+	for i := range bs.back {
+		bs.back[i] = bufferCell{mainc: r, style: style}
+	}
+}
+
+// Show sends the cells that changed since the last frame to the terminal
+func (bs *bufferedScreen) Show() {
+	bs.mutex.Lock()
+	bs.flush()
+	bs.mutex.Unlock()
+	bs.Screen.Show()
+}
+
+// Sync repaints the whole terminal from the last frame
+func (bs *bufferedScreen) Sync() {
+	bs.mutex.Lock()
+	bs.full = true
+	bs.flush()
+	bs.mutex.Unlock()
+	bs.Screen.Sync()
+}
+
+// flush must be called with bs.mutex held
+func (bs *bufferedScreen) flush() {
+	if width, height := bs.Screen.Size(); width != bs.width || height != bs.height {
+		// The terminal changed size mid-frame; the next frame redraws it
+		bs.resize()
+		return
+	}
+	frame := bs.back
+	if bs.post != nil {
+		frame = bs.post(frame, bs.width, bs.height)
+	}
+	for i, cell := range frame {
+		if !bs.full && cell.equal(bs.front[i]) {
+			continue
+		}
+		bs.Screen.SetContent(i%bs.width, i/bs.width, cell.mainc, cell.combc, cell.style)
+		bs.front[i] = cell
+	}
+	bs.full = false
+}
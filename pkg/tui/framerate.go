@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type FrameRateController struct {
+	activeFPS    int
+	idleFPS      int           // 0 disables idle throttling
+	idleAfter    time.Duration // Inactivity period before dropping to idle FPS
+	lastActivity time.Time
+	mutex        sync.RWMutex
+}
+
+func NewFrameRateController(activeFPS, idleFPS int, idleAfter time.Duration) *FrameRateController {
+	return &FrameRateController{
+		activeFPS:    activeFPS,
+		idleFPS:      idleFPS,
+		idleAfter:    idleAfter,
+		lastActivity: time.Now(),
+	}
+}
+
+// Touch records user input or a new event, ramping back up to the active rate
+func (fr *FrameRateController) Touch() {
+	fr.mutex.Lock()
+	fr.lastActivity = time.Now()
+	fr.mutex.Unlock()
+}
+
+func (fr *FrameRateController) IsIdle() bool {
+	fr.mutex.RLock()
+	defer fr.mutex.RUnlock()
+	return fr.idleFPS > 0 && time.Since(fr.lastActivity) >= fr.idleAfter
+}
+
+func (fr *FrameRateController) CurrentFPS() int {
+	if fr.IsIdle() {
+		return fr.idleFPS
+	}
+	return fr.activeFPS
+}
+
+// FrameInterval returns how long the main loop should wait between frames
+func (fr *FrameRateController) FrameInterval() time.Duration {
+	return time.Second / time.Duration(fr.CurrentFPS())
+}
+
+// StatusText returns the frame rate indicator shown in the status line
+func (fr *FrameRateController) StatusText() string {
+	if fr.IsIdle() {
+		return fmt.Sprintf("[IDLE %dfps]", fr.idleFPS)
+	}
+	return fmt.Sprintf("[%dfps]", fr.activeFPS)
+}
@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	gifCellWidth  = 7  // basicfont.Face7x13 advance
+	gifCellHeight = 13 // basicfont.Face7x13 line height
+	gifBaseline   = 11 // basicfont.Face7x13 ascent
+)
+
+type gifFrame struct {
+	cells [][]RecordedCell
+	at    time.Time
+}
+
+type GIFExporter struct {
+	enabled   bool
+	path      string
+	duration  time.Duration // Stop capturing and write the GIF after this long
+	frameSkip int           // Capture every Nth rendered frame
+	frameNum  int
+	startTime time.Time
+	frames    []gifFrame
+	mutex     sync.Mutex
+	writing   sync.WaitGroup // The write started when the duration ran out
+}
+
+func NewGIFExporter(path string, duration time.Duration, frameSkip int) *GIFExporter {
+	if path == "" {
+		return &GIFExporter{enabled: false}
+	}
+	if frameSkip < 1 {
+		frameSkip = 1
+	}
+	return &GIFExporter{
+		enabled:   true,
+		path:      path,
+		duration:  duration,
+		frameSkip: frameSkip,
+		startTime: time.Now(),
+		frames:    make([]gifFrame, 0),
+	}
+}
+
+// Active reports whether frames are still being captured
+func (ge *GIFExporter) Active() bool {
+	ge.mutex.Lock()
+	defer ge.mutex.Unlock()
+	return ge.enabled
+}
+
+// CaptureFrame stores the cell grid. Once the duration has passed it stops
+// capturing and writes the GIF in the background.
+func (ge *GIFExporter) CaptureFrame(screen [][]RecordedCell) {
+	ge.mutex.Lock()
+	defer ge.mutex.Unlock()
+	if !ge.enabled {
+		return
+	}
+
+	if time.Since(ge.startTime) > ge.duration {
+		frames := ge.stopLocked()
+		ge.writing.Add(1)
+		go func() {
+			defer ge.writing.Done()
+			if err := ge.write(frames); err != nil {
+				debugLog("GIF export: Failed: %v", err)
+				globalToasts.Post("GIF export failed: "+err.Error(), true)
+				return
+			}
+			postToast("GIF export finished: %s", ge.path)
+		}()
+		return
+	}
+
+	ge.frameNum++
+	if (ge.frameNum-1)%ge.frameSkip != 0 {
+		return
+	}
+	ge.frames = append(ge.frames, gifFrame{cells: screen, at: time.Now()})
+}
+
+// stopLocked ends capturing and hands over the frames captured so far
+func (ge *GIFExporter) stopLocked() []gifFrame {
+	frames := ge.frames
+	ge.enabled, ge.frames = false, nil
+	return frames
+}
+
+// Close writes the frames captured so far when the duration has not run out
+// yet, and otherwise waits for the GIF already being written. It is safe to
+// call more than once.
+func (ge *GIFExporter) Close() error {
+	ge.mutex.Lock()
+	active := ge.enabled
+	frames := ge.stopLocked()
+	ge.mutex.Unlock()
+
+	ge.writing.Wait()
+	if !active {
+		return nil
+	}
+	return ge.write(frames)
+}
+
+// write rasterizes frames and writes them to the animated GIF
+func (ge *GIFExporter) write(frames []gifFrame) error {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	anim := &gif.GIF{}
+	for i, frame := range frames {
+		anim.Image = append(anim.Image, rasterizeFrame(frame.cells))
+
+		// Frame delay in 1/100s taken from when the next frame was captured
+		delay := 10
+		if i+1 < len(frames) {
+			delay = int(frames[i+1].at.Sub(frame.at) / (10 * time.Millisecond))
+		}
+		if delay < 2 {
+			delay = 2
+		}
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	file, err := os.Create(ge.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	debugLog("GIF export: Writing %d frames to %s", len(frames), ge.path)
+	return gif.EncodeAll(file, anim)
+}
+
+// rasterizeFrame draws a cell grid with the embedded 7x13 bitmap font
+func rasterizeFrame(cells [][]RecordedCell) *image.Paletted {
+	height := len(cells)
+	width := 0
+	if height > 0 {
+		width = len(cells[0])
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width*gifCellWidth, height*gifCellHeight))
+	drawer := &font.Drawer{Dst: rgba, Face: basicfont.Face7x13}
+	defaultBG := tcellToRGBA(ActiveTheme().Background, color.RGBA{0, 0, 0, 255})
+
+	for y, row := range cells {
+		for x, cell := range row {
+			fg, bg, _ := cell.Style.Decompose()
+			fgColor := tcellToRGBA(fg, color.RGBA{255, 255, 255, 255})
+			bgColor := tcellToRGBA(bg, defaultBG)
+
+			cellRect := image.Rect(x*gifCellWidth, y*gifCellHeight, (x+1)*gifCellWidth, (y+1)*gifCellHeight)
+			draw.Draw(rgba, cellRect, image.NewUniform(bgColor), image.Point{}, draw.Src)
+
+			r := cell.Rune
+			if r == 0 || r == ' ' {
+				continue
+			}
+			if drawBlockGlyph(rgba, cellRect, r, fgColor) {
+				continue
+			}
+			drawer.Src = image.NewUniform(fgColor)
+			drawer.Dot = fixed.P(cellRect.Min.X, cellRect.Min.Y+gifBaseline)
+			drawer.DrawString(string(r))
+		}
+	}
+
+	paletted := image.NewPaletted(rgba.Bounds(), palette.Plan9)
+	draw.Draw(paletted, rgba.Bounds(), rgba, image.Point{}, draw.Src)
+	return paletted
+}
+
+func tcellToRGBA(c tcell.Color, fallback color.RGBA) color.RGBA {
+	if c == tcell.ColorDefault || !c.Valid() {
+		return fallback
+	}
+	r, g, b := c.RGB()
+	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+}
+
+// drawBlockGlyph procedurally draws Braille, block, shade and box-drawing
+// characters that the bitmap font does not cover
+func drawBlockGlyph(img *image.RGBA, cell image.Rectangle, r rune, c color.RGBA) bool {
+	fill := func(x0, y0, x1, y1 int) {
+		rect := image.Rect(cell.Min.X+x0, cell.Min.Y+y0, cell.Min.X+x1, cell.Min.Y+y1)
+		draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	w, h := cell.Dx(), cell.Dy()
+
+	switch {
+	case r >= 0x2800 && r <= 0x28FF:
+		// Braille: bits 0-2 and 6 are the left column, 3-5 and 7 the right
+		bits := int(r - 0x2800)
+		dots := []struct{ bit, col, row int }{
+			{0, 0, 0}, {1, 0, 1}, {2, 0, 2}, {6, 0, 3},
+			{3, 1, 0}, {4, 1, 1}, {5, 1, 2}, {7, 1, 3},
+		}
+		for _, d := range dots {
+			if bits&(1<<d.bit) != 0 {
+				x := 1 + d.col*3
+				y := 1 + d.row*3
+				fill(x, y, x+2, y+2)
+			}
+		}
+		return true
+	case r == '█':
+		fill(0, 0, w, h)
+		return true
+	case r == '▀':
+		fill(0, 0, w, h/2)
+		return true
+	case r >= '▁' && r <= '▇':
+		eighths := int(r-'▁') + 1
+		fill(0, h-h*eighths/8, w, h)
+		return true
+	case r == '░' || r == '▒' || r == '▓':
+		// Shades as ordered dither patterns of increasing density
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				on := false
+				switch r {
+				case '░':
+					on = x%2 == 0 && y%2 == 0
+				case '▒':
+					on = (x+y)%2 == 0
+				case '▓':
+					on = !(x%2 == 0 && y%2 == 0)
+				}
+				if on {
+					img.Set(cell.Min.X+x, cell.Min.Y+y, c)
+				}
+			}
+		}
+		return true
+	}
+
+	// Box drawing: which of left/right/up/down arms the character has
+	arms := map[rune][4]bool{
+		'─': {true, true, false, false}, '═': {true, true, false, false},
+		'│': {false, false, true, true}, '║': {false, false, true, true},
+		'┌': {false, true, false, true}, '╔': {false, true, false, true},
+		'┐': {true, false, false, true}, '╗': {true, false, false, true},
+		'└': {false, true, true, false}, '╚': {false, true, true, false},
+		'┘': {true, false, true, false}, '╝': {true, false, true, false},
+		'├': {false, true, true, true}, '╠': {false, true, true, true},
+		'┤': {true, false, true, true}, '╣': {true, false, true, true},
+		'┬': {true, true, false, true}, '╦': {true, true, false, true},
+		'┴': {true, true, true, false}, '╩': {true, true, true, false},
+		'┼': {true, true, true, true}, '╬': {true, true, true, true},
+	}
+	a, ok := arms[r]
+	if !ok {
+		return false
+	}
+	mx, my := w/2, h/2
+	if a[0] {
+		fill(0, my, mx+1, my+1)
+	}
+	if a[1] {
+		fill(mx, my, w, my+1)
+	}
+	if a[2] {
+		fill(mx, 0, mx+1, my+1)
+	}
+	if a[3] {
+		fill(mx, my, mx+1, h)
+	}
+	return true
+}
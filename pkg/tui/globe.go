@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/globerender"
+)
+
+// The globe itself is drawn by pkg/globerender; the TUI adds colors, panels
+// and effects around it
+type (
+	Charset = globerender.Charset
+	Globe   = globerender.Globe
+)
+
+const (
+	CharsetASCII   = globerender.CharsetASCII
+	CharsetBlocks  = globerender.CharsetBlocks
+	CharsetBraille = globerender.CharsetBraille
+)
+
+// approxMarker marks an attacker placed at its country's centroid because
+// its city is not known
+const approxMarker = '~'
+
+// globeMarkers places a marker for every located attacker. Repeat offenders
+// get a whole-cell marker that grows with their hits, and attackers located
+// only by country a ~; with protocolGlyphs every marker shows its protocol
+// instead.
+func globeMarkers(locations map[string]api.LocationInfo, levels map[string]int, protocolGlyphs bool) []globerender.Marker {
+	markers := make([]globerender.Marker, 0, len(locations))
+	for ip, loc := range locations {
+		if !loc.Valid {
+			continue
+		}
+		marker := globerender.Marker{Lat: loc.Latitude, Lon: loc.Longitude, Rank: levels[ip]}
+		if protocolGlyphs {
+			if protocol := getProtocolForIP(ip); protocol != "" {
+				marker.Glyph = getProtocolGlyph(protocol)
+			}
+		} else if marker.Rank > 0 {
+			marker.Glyph = offenderMarker(marker.Rank)
+		} else if loc.Approx {
+			marker.Glyph = approxMarker
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// globeArcs fades each arc by how much of its lifetime has passed
+func globeArcs(arcs []AttackArc, now time.Time) []globerender.Arc {
+	fading := make([]globerender.Arc, len(arcs))
+	for i, arc := range arcs {
+		fading[i] = globerender.Arc{
+			SrcLat: arc.SrcLat, SrcLon: arc.SrcLon,
+			DstLat: arc.DstLat, DstLon: arc.DstLon,
+			Fade: 1 - float64(now.Sub(arc.CreatedAt).Milliseconds())/float64(arc.TTL.Milliseconds()),
+		}
+	}
+	return fading
+}
+
+// getProtocolGlyph marks a service, or a family of them such as mail or
+// databases, on the globe
+func getProtocolGlyph(protocol string) rune {
+	switch strings.ToLower(protocol) {
+	case "ssh":
+		return '#'
+	case "telnet":
+		return '~'
+	case "smtp", "pop3", "imap":
+		return '@'
+	case "http", "https":
+		return ':'
+	case "ftp", "tftp":
+		return '%'
+	case "smb", "msrpc":
+		return '$'
+	case "rdp", "vnc", "winrm":
+		return '&'
+	case "mysql", "mssql", "postgres", "oracle", "mongodb", "redis", "elasticsearch", "memcached":
+		return '='
+	case "modbus", "s7", "dnp3", "bacnet", "mqtt", "coap", "upnp", "tr-069", "adb":
+		return '^'
+	default:
+		return '!'
+	}
+}
+
+func getProtocolForIP(ip string) string {
+	// Look up protocol from global dashboard
+	if GlobalTUI != nil && GlobalTUI.dashboard != nil {
+		GlobalTUI.dashboard.mutex.RLock()
+		defer GlobalTUI.dashboard.mutex.RUnlock()
+		for _, conn := range GlobalTUI.dashboard.Connections {
+			if conn.IP == ip {
+				return conn.Protocol
+			}
+		}
+	}
+	return ""
+}
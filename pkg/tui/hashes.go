@@ -0,0 +1,367 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+const (
+	maxMalwareHashes      = 5000
+	DefaultHashLookupRate = 4 // Lookups per minute, what VirusTotal's public API allows
+	hashLookupBackoff     = 5 * time.Minute
+	hashLookupTimeout     = 10 * time.Second
+	virusTotalURL         = "https://www.virustotal.com/api/v3/files/"
+	malwareBazaarURL      = "https://mb-api.abuse.ch/api/v1/"
+)
+
+// The states of a hash's lookup
+const (
+	hashPending = "pending"
+	hashFound   = "found"
+	hashUnknown = "unknown" // Neither service has seen the file
+	hashFailed  = "error"
+)
+
+// errHashRateLimited is a lookup service answering 429 Too Many Requests
+var errHashRateLimited = errors.New("rate limited")
+
+// MalwareHash is a file hash seen in this session's events: a Cowrie
+// download or upload, or a binary dionaea captured, with what VirusTotal
+// or MalwareBazaar know of it once it has been looked up
+type MalwareHash struct {
+	Hash       string    `json:"hash"`
+	Count      int       `json:"count"` // Events carrying it
+	FirstSeen  time.Time `json:"first_seen"`
+	LastIP     string    `json:"last_ip"`
+	Status     string    `json:"status,omitempty"` // Empty while lookups are off
+	Detections int       `json:"detections,omitempty"`
+	Engines    int       `json:"engines,omitempty"` // VirusTotal engines that scanned it
+	Name       string    `json:"name,omitempty"`    // Threat label or MalwareBazaar signature
+	FileType   string    `json:"file_type,omitempty"`
+	Source     string    `json:"source,omitempty"` // Service the verdict came from
+}
+
+// Verdict sums the lookup up for the panels, e.g. "Mirai (VT 41/63)"
+func (mh MalwareHash) Verdict() string {
+	switch mh.Status {
+	case "":
+		return "-"
+	case hashPending:
+		return "looking up..."
+	case hashUnknown:
+		return "not known"
+	case hashFailed:
+		return "lookup failed"
+	}
+	verdict := mh.Name
+	if verdict == "" {
+		verdict = "no family name"
+	}
+	if mh.Engines > 0 {
+		return fmt.Sprintf("%s (VT %d/%d)", verdict, mh.Detections, mh.Engines)
+	}
+	return verdict + " (MalwareBazaar)"
+}
+
+// HashLookup keeps the hashes seen this session and looks each one up once
+// on VirusTotal, then MalwareBazaar when VirusTotal does not know it, no
+// faster than the configured rate. A service answering 429 pauses lookups
+// for a while, with the hash going back to the front of the queue.
+type HashLookup struct {
+	vtKey     string
+	bazaarKey string
+	offline   bool
+	interval  time.Duration // Between lookups
+	client    *http.Client
+	hashes    map[string]*MalwareHash
+	order     []string  // First seen, oldest first
+	pending   []string  // Queued for lookup, oldest first
+	resume    time.Time // No lookups before this after a 429
+	mutex     sync.Mutex
+}
+
+// NewHashLookup looks hashes up with whichever keys are set; offline keeps
+// the hashes but never looks them up
+func NewHashLookup(vtKey, bazaarKey string, perMinute int, offline bool) *HashLookup {
+	return &HashLookup{
+		vtKey:     vtKey,
+		bazaarKey: bazaarKey,
+		offline:   offline,
+		interval:  time.Minute / time.Duration(perMinute),
+		client:    api.NewHTTPClient(hashLookupTimeout),
+		hashes:    make(map[string]*MalwareHash),
+	}
+}
+
+// SetKeys changes the VirusTotal and MalwareBazaar API keys; an empty key
+// leaves that service out
+func (hl *HashLookup) SetKeys(vtKey, bazaarKey string) {
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	hl.vtKey, hl.bazaarKey = vtKey, bazaarKey
+	if hl.enabled() {
+		// Hashes seen while lookups were off are looked up now
+		for _, hash := range hl.order {
+			if record := hl.hashes[hash]; record.Status == "" {
+				record.Status = hashPending
+				hl.pending = append(hl.pending, hash)
+			}
+		}
+	}
+}
+
+// SetRate changes how many lookups a minute are made
+func (hl *HashLookup) SetRate(perMinute int) {
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	hl.interval = time.Minute / time.Duration(perMinute)
+}
+
+func (hl *HashLookup) enabled() bool {
+	return !hl.offline && (hl.vtKey != "" || hl.bazaarKey != "")
+}
+
+// Services names the configured lookup services, for the panel
+func (hl *HashLookup) Services() []string {
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	var services []string
+	if hl.offline {
+		return nil
+	}
+	if hl.vtKey != "" {
+		services = append(services, "VirusTotal")
+	}
+	if hl.bazaarKey != "" {
+		services = append(services, "MalwareBazaar")
+	}
+	return services
+}
+
+// connectionHashes lists the file hashes a row carries: its Cowrie session's
+// downloads and uploads, and the MD5 of dionaea's capture, which unlike its
+// SHA-512 both services accept
+func connectionHashes(conn api.Connection) []string {
+	var hashes []string
+	if conn.Session != nil {
+		hashes = append(hashes, conn.Session.Hashes...)
+	}
+	if conn.Feed != nil && conn.Feed.MD5 != "" {
+		hashes = append(hashes, conn.Feed.MD5)
+	}
+	return hashes
+}
+
+// Note records a hash seen in an event from ip, queueing it for lookup the
+// first time it turns up
+func (hl *HashLookup) Note(hash, ip string, t time.Time) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if hash == "" {
+		return
+	}
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	if record := hl.hashes[hash]; record != nil {
+		record.Count++
+		record.LastIP = ip
+		return
+	}
+	if len(hl.order) >= maxMalwareHashes {
+		oldest := hl.order[0]
+		hl.order = hl.order[1:]
+		hl.pending = slices.DeleteFunc(hl.pending, func(h string) bool { return h == oldest })
+		delete(hl.hashes, oldest)
+	}
+	record := &MalwareHash{Hash: hash, Count: 1, FirstSeen: t, LastIP: ip}
+	if hl.enabled() {
+		record.Status = hashPending
+		hl.pending = append(hl.pending, hash)
+	}
+	hl.hashes[hash] = record
+	hl.order = append(hl.order, hash)
+}
+
+// Start looks up queued hashes on a supervised goroutine, one per interval
+func (hl *HashLookup) Start() {
+	api.Workers.Go("hash-lookup", func(stop <-chan struct{}) error {
+		for {
+			hl.mutex.Lock()
+			interval := hl.interval
+			hl.mutex.Unlock()
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(interval):
+			}
+			if hash, vtKey, bazaarKey, ok := hl.next(time.Now()); ok {
+				hl.lookup(hash, vtKey, bazaarKey)
+			}
+		}
+	})
+}
+
+// next takes the oldest queued hash, unless lookups are off or paused
+func (hl *HashLookup) next(now time.Time) (string, string, string, bool) {
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	if !hl.enabled() || len(hl.pending) == 0 || now.Before(hl.resume) {
+		return "", "", "", false
+	}
+	hash := hl.pending[0]
+	hl.pending = hl.pending[1:]
+	return hash, hl.vtKey, hl.bazaarKey, true
+}
+
+func (hl *HashLookup) lookup(hash, vtKey, bazaarKey string) {
+	var result MalwareHash
+	var found bool
+	var err error
+	if vtKey != "" {
+		found, err = hl.lookupVirusTotal(hash, vtKey, &result)
+	}
+	if !found && err == nil && bazaarKey != "" {
+		found, err = hl.lookupBazaar(hash, bazaarKey, &result)
+	}
+
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	record := hl.hashes[hash]
+	if record == nil {
+		return
+	}
+	switch {
+	case errors.Is(err, errHashRateLimited):
+		debugLog("Hash Lookup: Rate limited, pausing for %s", hashLookupBackoff)
+		hl.resume = time.Now().Add(hashLookupBackoff)
+		hl.pending = append([]string{hash}, hl.pending...)
+		return
+	case err != nil:
+		debugLog("Hash Lookup: Failed for %s: %v", hash, err)
+		record.Status = hashFailed
+	case !found:
+		debugLog("Hash Lookup: %s is not known", hash)
+		record.Status = hashUnknown
+	default:
+		debugLog("Hash Lookup: %s is %s", hash, result.Verdict())
+		record.Status = hashFound
+		record.Detections, record.Engines = result.Detections, result.Engines
+		record.Name, record.FileType, record.Source = result.Name, result.FileType, result.Source
+	}
+	if GlobalTUI != nil {
+		GlobalTUI.MarkDashboardChanged()
+	}
+}
+
+// lookupVirusTotal fills in the engines flagging the file and its threat
+// label from VirusTotal's file report
+func (hl *HashLookup) lookupVirusTotal(hash, key string, result *MalwareHash) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, virusTotalURL+url.PathEscape(hash), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("x-apikey", key)
+	resp, err := hl.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	case http.StatusTooManyRequests:
+		return false, errHashRateLimited
+	default:
+		return false, fmt.Errorf("VirusTotal: HTTP %d", resp.StatusCode)
+	}
+
+	var report struct {
+		Data struct {
+			Attributes struct {
+				Stats          map[string]int `json:"last_analysis_stats"`
+				Classification struct {
+					Label string `json:"suggested_threat_label"`
+				} `json:"popular_threat_classification"`
+				Type string `json:"type_description"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return false, fmt.Errorf("VirusTotal: %w", err)
+	}
+	attributes := report.Data.Attributes
+	result.Detections = attributes.Stats["malicious"]
+	for _, verdict := range []string{"malicious", "suspicious", "undetected", "harmless"} {
+		result.Engines += attributes.Stats[verdict]
+	}
+	result.Name, result.FileType, result.Source = attributes.Classification.Label, attributes.Type, "virustotal"
+	return true, nil
+}
+
+// lookupBazaar fills in the malware family MalwareBazaar files the sample
+// under
+func (hl *HashLookup) lookupBazaar(hash, key string, result *MalwareHash) (bool, error) {
+	form := url.Values{"query": {"get_info"}, "hash": {hash}}
+	req, err := http.NewRequest(http.MethodPost, malwareBazaarURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Auth-Key", key)
+	resp, err := hl.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return false, errHashRateLimited
+	default:
+		return false, fmt.Errorf("MalwareBazaar: HTTP %d", resp.StatusCode)
+	}
+
+	var info struct {
+		Status string `json:"query_status"`
+		Data   []struct {
+			Signature string   `json:"signature"`
+			FileType  string   `json:"file_type"`
+			Tags      []string `json:"tags"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return false, fmt.Errorf("MalwareBazaar: %w", err)
+	}
+	switch {
+	case info.Status == "hash_not_found" || info.Status == "no_results":
+		return false, nil
+	case info.Status != "ok" || len(info.Data) == 0:
+		return false, fmt.Errorf("MalwareBazaar: %s", info.Status)
+	}
+	sample := info.Data[0]
+	result.Name, result.FileType, result.Source = sample.Signature, sample.FileType, "malwarebazaar"
+	if result.Name == "" && len(sample.Tags) > 0 {
+		result.Name = sample.Tags[0]
+	}
+	return true, nil
+}
+
+// All returns every hash seen this session, newest first
+func (hl *HashLookup) All() []MalwareHash {
+	hl.mutex.Lock()
+	defer hl.mutex.Unlock()
+	all := make([]MalwareHash, 0, len(hl.order))
+	for i := len(hl.order) - 1; i >= 0; i-- {
+		all = append(all, *hl.hashes[hl.order[i]])
+	}
+	return all
+}
@@ -0,0 +1,326 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"github.com/gdamore/tcell/v2"
+)
+
+const DefaultHistorySize = 20000
+
+// EventHistory keeps every dashboard row of the session (up to a cap) so the
+// timeline can show volume and scrub mode can rebuild past frames
+type EventHistory struct {
+	rows     []api.Connection
+	maxRows  int
+	recorded int // Rows ever recorded, so a scrolled view can hold its place
+	mutex    sync.RWMutex
+}
+
+func NewEventHistory(maxRows int) *EventHistory {
+	return &EventHistory{maxRows: maxRows}
+}
+
+// Record appends a new row, dropping the oldest beyond the cap
+func (h *EventHistory) Record(conn api.Connection) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.rows = append(h.rows, conn)
+	h.recorded++
+	if len(h.rows) > h.maxRows {
+		h.rows = append([]api.Connection(nil), h.rows[len(h.rows)-h.maxRows:]...)
+	}
+}
+
+// Update replaces the newest recorded row of row's session, after the
+// dashboard has merged a follow-up event into it
+func (h *EventHistory) Update(row api.Connection) {
+	if row.Session == nil || row.Session.ID == "" {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i := len(h.rows) - 1; i >= 0; i-- {
+		if session := h.rows[i].Session; session != nil && session.ID == row.Session.ID {
+			h.rows[i] = row
+			return
+		}
+	}
+}
+
+// Shed drops the oldest half of the history
+func (h *EventHistory) Shed() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.rows = append([]api.Connection(nil), h.rows[len(h.rows)/2:]...)
+}
+
+// Len is the number of remembered rows
+func (h *EventHistory) Len() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.rows)
+}
+
+// Recorded counts every row recorded this session, including any dropped
+func (h *EventHistory) Recorded() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.recorded
+}
+
+// Page returns n rows, oldest first, ending back rows before the newest.
+// back is clamped so the page stays full, and the clamped value returned.
+func (h *EventHistory) Page(back, n int) (ConnectionList, int) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	back = max(min(back, len(h.rows)-n), 0)
+	end := len(h.rows) - back
+	start := max(end-n, 0)
+	return append(ConnectionList(nil), h.rows[start:end]...), back
+}
+
+// Matching returns the newest n rows that f keeps, oldest first
+func (h *EventHistory) Matching(f RowFilter, n int) ConnectionList {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	var rows ConnectionList
+	for i := len(h.rows) - 1; i >= 0 && len(rows) < n; i-- {
+		if f.Match(h.rows[i]) {
+			rows = append(rows, h.rows[i])
+		}
+	}
+	slices.Reverse(rows)
+	return rows
+}
+
+// Since returns the rows that arrived after t, oldest first
+func (h *EventHistory) Since(t time.Time) ConnectionList {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	start := sort.Search(len(h.rows), func(i int) bool { return h.rows[i].Time.After(t) })
+	return append(ConnectionList(nil), h.rows[start:]...)
+}
+
+// Values returns the distinct values of a row field, most common first
+func (h *EventHistory) Values(field string, n int) []string {
+	h.mutex.RLock()
+	counts := make(map[string]int)
+	for _, conn := range h.rows {
+		if value := rowField(conn, field); value != "" {
+			counts[value]++
+		}
+	}
+	h.mutex.RUnlock()
+	values := slices.Collect(maps.Keys(counts))
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	return values[:min(n, len(values))]
+}
+
+// Start returns the time of the oldest remembered event
+func (h *EventHistory) Start() (time.Time, bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if len(h.rows) == 0 {
+		return time.Time{}, false
+	}
+	return h.rows[0].Time, true
+}
+
+// Volume counts events in each of bins equal slices of [start, end]
+func (h *EventHistory) Volume(start, end time.Time, bins int) []int {
+	counts := make([]int, bins)
+	span := end.Sub(start)
+	if bins == 0 || span <= 0 {
+		return counts
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for _, row := range h.rows {
+		if row.Time.Before(start) || row.Time.After(end) {
+			continue
+		}
+		bin := int(float64(row.Time.Sub(start)) / float64(span) * float64(bins))
+		counts[min(bin, bins-1)]++
+	}
+	return counts
+}
+
+// At returns the last n rows that had arrived by t, oldest first, which is
+// what the dashboard showed at that moment
+func (h *EventHistory) At(t time.Time, n int) ConnectionList {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	end := sort.Search(len(h.rows), func(i int) bool { return h.rows[i].Time.After(t) })
+	start := max(end-n, 0)
+	return append(ConnectionList(nil), h.rows[start:end]...)
+}
+
+// timelineLabelWidth is the room left of the timeline bar for LIVE or the
+// scrub cursor's time
+const timelineLabelWidth = 11
+
+// timelineBins is how many bins the timeline beside a globe globeWidth
+// cells wide splits the history into
+func timelineBins(globeWidth int) int {
+	return max(globeWidth-timelineLabelWidth, 10)
+}
+
+// scrubArcs rebuilds the arcs that were still fading at t, shifting their
+// creation time so the renderer draws them at the age they had then
+func scrubArcs(conns ConnectionList, locations map[string]api.LocationInfo, t, now time.Time) []AttackArc {
+	if GlobalArcManager == nil {
+		return nil
+	}
+	GlobalArcManager.mutex.RLock()
+	defer GlobalArcManager.mutex.RUnlock()
+	ttl := time.Duration(GlobalArcManager.trailMS) * time.Millisecond
+
+	var arcs []AttackArc
+	for _, conn := range conns {
+		loc, ok := locations[conn.IP]
+		if !ok || t.Sub(conn.Time) > ttl {
+			continue
+		}
+		dst := GlobalArcManager.destination(conn.Origin)
+		arcs = append(arcs, AttackArc{
+			SrcIP:     conn.IP,
+			SrcLat:    loc.Latitude,
+			SrcLon:    loc.Longitude,
+			DstLat:    dst.Lat,
+			DstLon:    dst.Lon,
+			Protocol:  conn.Protocol,
+			CreatedAt: conn.Time.Add(now.Sub(t)),
+			TTL:       ttl,
+		})
+	}
+	return arcs
+}
+
+func (tui *TUI) renderTimeline(snap *FrameSnapshot) {
+	if !snap.View.ShowTimeline {
+		return
+	}
+	y := snap.Height - 2
+	if y < 0 {
+		return
+	}
+
+	labelStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusOk).Background(snap.Theme.Background).Bold(true)
+	barStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background)
+	cursorStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true).Reverse(true)
+
+	label := "LIVE"
+	if snap.View.Scrubbing {
+		label = "@" + snap.ScrubTime.Format("15:04:05")
+		labelStyle = tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+	}
+	tui.drawText(0, y, fmt.Sprintf("%-*s", timelineLabelWidth, label), labelStyle)
+
+	maxCount := 0
+	for _, count := range snap.Timeline {
+		maxCount = max(maxCount, count)
+	}
+	sparkChars := []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	for i, count := range snap.Timeline {
+		x := timelineLabelWidth + i
+		if x >= snap.Globe.Width || x >= snap.Width {
+			break
+		}
+		char := sparkChars[0]
+		if count > 0 {
+			char = sparkChars[1+count*(len(sparkChars)-2)/max(maxCount, 1)]
+		}
+		style := barStyle
+		if snap.View.Scrubbing && i == snap.TimelineCursor {
+			style = cursorStyle
+			if char == ' ' {
+				char = '│'
+			}
+		}
+		tui.screen.SetContent(x, y, char, nil, style)
+	}
+}
+
+// ToggleScrub enters scrub mode at the newest moment, or returns to live
+func (tui *TUI) ToggleScrub(enter bool) {
+	bins := timelineBins(tui.Globe().Width)
+	tui.state.mutex.Lock()
+	if enter && !tui.state.scrubbing {
+		tui.state.scrubbing = true
+		tui.state.scrubEnd = time.Now()
+		tui.state.scrubCursor = bins - 1
+	} else if !enter {
+		tui.state.scrubbing = false
+	}
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+// handleScrubKey moves the scrub cursor one bin, or ten with PgUp/PgDn
+func (tui *TUI) handleScrubKey(key tcell.Key) {
+	step := 0
+	switch key {
+	case tcell.KeyLeft:
+		step = -1
+	case tcell.KeyRight:
+		step = 1
+	case tcell.KeyPgUp:
+		step = -10
+	case tcell.KeyPgDn:
+		step = 10
+	}
+	bins := timelineBins(tui.Globe().Width)
+	tui.state.mutex.Lock()
+	tui.state.scrubCursor = min(max(tui.state.scrubCursor+step, 0), bins-1)
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+// ScrollDashboardRows moves the dashboard back (positive) or forward through
+// history. The position is kept relative to the rows recorded since, so the
+// page holds still while new events arrive; reaching 0 returns to live.
+func (tui *TUI) ScrollDashboardRows(rows int) {
+	if GlobalHistory == nil {
+		return
+	}
+	recorded := GlobalHistory.Recorded()
+	limit := max(GlobalHistory.Len()-tui.dashboard.Capacity(), 0)
+	tui.state.mutex.Lock()
+	back := tui.state.dashboardBack
+	if back > 0 {
+		back += recorded - tui.state.scrollMark
+	}
+	tui.state.dashboardBack = min(max(back+rows, 0), limit)
+	tui.state.scrollMark = recorded
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+// dashboardPage is how far PgUp/PgDn scroll: one screen of unpinned rows,
+// less one so a row stays for context
+func (tui *TUI) dashboardPage() int {
+	tui.mutex.RLock()
+	defer tui.mutex.RUnlock()
+	unpinned := 0
+	for _, i := range tui.dashVisible {
+		if i >= tui.dashPins {
+			unpinned++
+		}
+	}
+	return max(unpinned-1, 1)
+}
@@ -0,0 +1,324 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// KeyAction is an interactive command that can be bound to keys in the
+// [keys] config section, or with --key-<name> (underscores as dashes)
+type KeyAction struct {
+	Name     string
+	Defaults string // Comma separated key names, as written in the config
+	Help     string
+}
+
+// KeyActions lists every bindable action. Region presets stay on 1-9,
+// triage tags on 0-3 while triaging, and Ctrl+C and Ctrl+O are reserved.
+// Open panels keep the arrows, PgUp/PgDn and Esc whatever they are bound to.
+var KeyActions = []KeyAction{
+	{"pause", "space", "Pause/resume rotation"},
+	{"freeze", "shift+space,ctrl+s", "Freeze the feed, holding new rows back"},
+	{"speed_down", "[", "Slow the spin"},
+	{"speed_up", "]", "Speed up the spin"},
+	{"zoom_in", "+,=", "Zoom in"},
+	{"zoom_out", "-,_", "Zoom out"},
+	{"nudge_up", "up", "Nudge the view up"},
+	{"nudge_down", "down", "Nudge the view down"},
+	{"nudge_left", "left", "Nudge the view left"},
+	{"nudge_right", "right", "Nudge the view right"},
+	{"theme", "t,T", "Cycle themes"},
+	{"arcs", "g,G", "Toggle attack arcs"},
+	{"lighting", "l,L", "Toggle lighting"},
+	{"rain", "r,R", "Toggle Matrix rain"},
+	{"info", "i,I", "Toggle the attack info panel"},
+	{"stats", "s,S", "Toggle the stats panel"},
+	{"top_ips", "p,P", "Toggle the top IPs panel"},
+	{"ports", "@", "Toggle the top ports panel"},
+	{"creds", "k,K", "Toggle the credential histogram"},
+	{"diagnostics", "d,D", "Toggle the diagnostics panel"},
+	{"legend", "b,B", "Toggle the symbol legend"},
+	{"alerts", "a,A", "Toggle the alerts log"},
+	{"findings", "!", "Toggle the credential findings"},
+	{"hashes", "Z", "Toggle the malware hashes"},
+	{"urls", "&", "Toggle the attacker URLs"},
+	{"ticker", "~", "Toggle the attack ticker"},
+	{"coverage", "f,F", "Toggle the protocol coverage matrix"},
+	{"triage", "y,Y", "Triage mode, or pin the selected row while triaging"},
+	{"tag_filter", "tab", "Filter the dashboard by tag"},
+	{"session", "enter", "Open the session detail panel"},
+	{"scrub", "home", "Scrub the timeline"},
+	{"live", "end", "Return to live rows and time"},
+	{"stats_view", "#", "Cycle the stats chart range"},
+	{"countries", "%", "Shade countries by attacks"},
+	{"follow", "v,V", "Toggle the follow-attack camera"},
+	{"projection", "j,J", "Cycle the map projection"},
+	{"reset_view", "0", "Reset the view"},
+	{"acknowledge", "backspace,delete", "Acknowledge banner alerts"},
+	{"scroll_left", "comma", "Scroll the dashboard left"},
+	{"scroll_right", ".", "Scroll the dashboard right"},
+	{"scroll_home", "h,H", "Reset the dashboard scroll"},
+	{"rotate_left", "<", "Step the rotation back"},
+	{"rotate_right", ">", "Step the rotation forward"},
+	{"page_up", "pgup", "Scroll back through history"},
+	{"page_down", "pgdn", "Scroll forward through history"},
+	{"search", "/", "Search the session's history"},
+	{"search_prev", "n", "Select the previous search match"},
+	{"search_next", "N", "Select the next search match"},
+	{"wrap", "w,W", "Toggle dashboard row wrap"},
+	{"collapse", "*", "Cycle collapsing rows from one IP"},
+	{"columns", "u,U", "Cycle dashboard column layouts"},
+	{"time_format", "z", "Cycle the time column format"},
+	{"screenshot", "o,O,f12", "Save a screenshot"},
+	{"report", "e,E", "Export session statistics"},
+	{"settings", "m,M", "Open the settings menu"},
+	{"palette", ":", "Open the : command palette"},
+	{"commands", "c,C", "Toggle the command guide"},
+	{"help", "?", "Toggle the help panel"},
+	{"quit", "q,Q,x,X,esc", "Quit"},
+}
+
+// keyHelpRow is one line of the help panel and one entry of the command
+// guide, covering one action or a pair of opposites
+type keyHelpRow struct {
+	actions []string
+	text    string // Help panel description
+	guide   string // Command guide name
+}
+
+var keyHelpRows = []keyHelpRow{
+	{[]string{"pause"}, "Pause/Resume rotation", "Pause"},
+	{[]string{"freeze"}, "Freeze feed (+N new held)", "Freeze"},
+	{[]string{"speed_down", "speed_up"}, "Decrease/Increase spin", "Speed"},
+	{[]string{"zoom_in", "zoom_out"}, "Zoom in/out", "Zoom"},
+	{[]string{"nudge_up", "nudge_down", "nudge_left", "nudge_right"}, "Nudge view angle", "Nudge"},
+	{[]string{"theme"}, "Cycle themes", "Theme"},
+	{[]string{"arcs"}, "Toggle attack arcs", "Arcs"},
+	{[]string{"lighting"}, "Toggle lighting", "Light"},
+	{[]string{"rain"}, "Toggle Matrix rain", "Rain"},
+	{[]string{"info"}, "Toggle attack info panel", "Info"},
+	{[]string{"stats"}, "Toggle stats panel", "Stats"},
+	{[]string{"top_ips"}, "Toggle top IPs panel", "TopIPs"},
+	{[]string{"ports"}, "Toggle top ports panel", "Ports"},
+	{[]string{"creds"}, "Toggle credential histogram", "Creds"},
+	{[]string{"diagnostics"}, "Toggle diagnostics panel", "Diag"},
+	{[]string{"legend"}, "Toggle symbol legend", "Legend"},
+	{[]string{"alerts"}, "Toggle alerts log", "Alerts"},
+	{[]string{"findings"}, "Toggle credential findings", "Findings"},
+	{[]string{"hashes"}, "Toggle malware hashes", "Hashes"},
+	{[]string{"urls"}, "Toggle attacker URLs", "URLs"},
+	{[]string{"ticker"}, "Toggle attack ticker", "Ticker"},
+	{[]string{"coverage"}, "Toggle protocol coverage", "Coverage"},
+	{[]string{"triage"}, "Triage: tag/pin rows (Esc)", "Triage"},
+	{[]string{"tag_filter"}, "Filter dashboard by tag", "TagFilter"},
+	{[]string{"session"}, "Session detail (commands)", "Session"},
+	{[]string{"scrub", "live"}, "Scrub timeline (←/→) / live", "Scrub"},
+	{[]string{"stats_view"}, "Stats 24h/day/7d/30d (←/→)", "StatsView"},
+	{[]string{"countries"}, "Shade countries by attacks", "Countries"},
+	{[]string{"follow"}, "Follow-attack camera", "Follow"},
+	{[]string{"projection"}, "Globe, flat map, Mollweide, hemispheres", "Projection"},
+	{[]string{"reset_view"}, "Reset view (1-9: region presets)", "Reset"},
+	{[]string{"acknowledge"}, "Acknowledge banner alerts", "Ack"},
+	{[]string{"scroll_left", "scroll_right"}, "Scroll dashboard left/right", "Scroll"},
+	{[]string{"rotate_left", "rotate_right"}, "Rotate globe step (paused)", "Rotate"},
+	{[]string{"scroll_home"}, "Reset dashboard scroll", "Home"},
+	{[]string{"page_up", "page_down"}, "Scroll back through history", "History"},
+	{[]string{"search"}, "Search history (Esc clears)", "Search"},
+	{[]string{"search_prev", "search_next"}, "Previous/next search match", "Match"},
+	{[]string{"wrap"}, "Toggle dashboard row wrap", "Wrap"},
+	{[]string{"collapse"}, "Collapse runs from one IP", "Collapse"},
+	{[]string{"columns"}, "Cycle dashboard columns", "Columns"},
+	{[]string{"time_format"}, "Time: local/age/UTC", "Time"},
+	{[]string{"screenshot"}, "Save screenshot (txt + svg)", "Shot"},
+	{[]string{"report"}, "Export stats (json/csv/md)", "Report"},
+	{[]string{"settings"}, "Settings menu", "Menu"},
+	{[]string{"palette"}, "Commands (:theme, :filter...)", "Cmd"},
+	{[]string{"commands"}, "Toggle command guide", "Guide"},
+	{[]string{"help"}, "Toggle this help panel", "Help"},
+	{[]string{"quit"}, "Exit", "Quit"},
+}
+
+// keyCombo is a key as tcell reports it: a rune, or a special key
+type keyCombo struct {
+	key   tcell.Key
+	rune  rune
+	shift bool // Only for Shift+Space, which most terminals send as a plain space
+}
+
+// keyDisplayNames are the short names the help panel shows for special keys
+var keyDisplayNames = map[tcell.Key]string{
+	tcell.KeyBackspace:  "Bksp",
+	tcell.KeyBackspace2: "Bksp",
+	tcell.KeyDelete:     "Del",
+	tcell.KeyUp:         "↑",
+	tcell.KeyDown:       "↓",
+	tcell.KeyLeft:       "←",
+	tcell.KeyRight:      "→",
+}
+
+func (kc keyCombo) String() string {
+	switch {
+	case kc.key != tcell.KeyRune:
+		if name, ok := keyDisplayNames[kc.key]; ok {
+			return name
+		}
+		return strings.Replace(tcell.KeyNames[kc.key], "Ctrl-", "Ctrl+", 1)
+	case kc.rune == ' ' && kc.shift:
+		return "Shift+Space"
+	case kc.rune == ' ':
+		return "Space"
+	}
+	return string(kc.rune)
+}
+
+// parseKeyName turns a key name from the config into the keys it stands
+// for: a single character, space, shift+space, comma, or a special key such
+// as enter, esc, tab, backspace (either code terminals send), delete, home,
+// end, pgup, pgdn, up, down, left, right, f1-f64 or ctrl+a-ctrl+z
+func parseKeyName(name string) ([]keyCombo, error) {
+	if r := []rune(name); len(r) == 1 {
+		return []keyCombo{{key: tcell.KeyRune, rune: r[0]}}, nil
+	}
+	lower := strings.ReplaceAll(strings.ToLower(name), "+", "-")
+	switch lower {
+	case "space":
+		return []keyCombo{{key: tcell.KeyRune, rune: ' '}}, nil
+	case "shift-space":
+		return []keyCombo{{key: tcell.KeyRune, rune: ' ', shift: true}}, nil
+	case "comma":
+		return []keyCombo{{key: tcell.KeyRune, rune: ','}}, nil
+	case "backspace", "bksp":
+		return []keyCombo{{key: tcell.KeyBackspace}, {key: tcell.KeyBackspace2}}, nil
+	case "escape":
+		lower = "esc"
+	case "return":
+		lower = "enter"
+	case "del":
+		lower = "delete"
+	case "pageup":
+		lower = "pgup"
+	case "pagedown":
+		lower = "pgdn"
+	case "ctrl-c", "ctrl-o":
+		return nil, fmt.Errorf("%s is reserved", name)
+	}
+	for key, keyName := range tcell.KeyNames {
+		if strings.ToLower(keyName) == lower {
+			return []keyCombo{{key: key}}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key %q", name)
+}
+
+// KeyBindingError is a bad binding, naming the action it was given for
+type KeyBindingError struct {
+	Action string
+	Err    error
+}
+
+func (e *KeyBindingError) Error() string {
+	return fmt.Sprintf("keys.%s: %v", e.Action, e.Err)
+}
+
+// KeyMap resolves key presses to action names
+type KeyMap struct {
+	actions map[keyCombo]string
+	keys    map[string][]keyCombo // Keys of each action, in the order given
+}
+
+// NewKeyMap binds every action to its keys from bindings, or to its
+// defaults when bindings has no entry for it. An empty entry unbinds the
+// action. It fails on unknown key names and on a key bound to two actions
+// or to a region preset.
+func NewKeyMap(bindings map[string]string) (*KeyMap, error) {
+	km := &KeyMap{
+		actions: make(map[keyCombo]string),
+		keys:    make(map[string][]keyCombo),
+	}
+	for _, action := range KeyActions {
+		spec, ok := bindings[action.Name]
+		if !ok {
+			spec = action.Defaults
+		}
+		for _, name := range strings.Split(spec, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			combos, err := parseKeyName(name)
+			if err != nil {
+				return nil, &KeyBindingError{action.Name, err}
+			}
+			for _, combo := range combos {
+				if combo.key == tcell.KeyRune && combo.rune >= '1' && combo.rune <= '9' {
+					return nil, &KeyBindingError{action.Name, fmt.Errorf("key %s is reserved for region preset %s", combo, combo)}
+				}
+				if other, taken := km.actions[combo]; taken {
+					return nil, &KeyBindingError{action.Name, fmt.Errorf("key %s is already bound to %s", combo, other)}
+				}
+				km.actions[combo] = action.Name
+				km.keys[action.Name] = append(km.keys[action.Name], combo)
+			}
+		}
+	}
+	return km, nil
+}
+
+// defaultKeyMap binds every action to its default keys
+func defaultKeyMap() *KeyMap {
+	km, err := NewKeyMap(nil)
+	if err != nil {
+		panic(err)
+	}
+	return km
+}
+
+// Action names the action ev is bound to, or returns "" when it is unbound.
+// A shifted key falls back to the action of the key itself.
+func (km *KeyMap) Action(ev *tcell.EventKey) string {
+	combo := keyCombo{key: ev.Key()}
+	if ev.Key() == tcell.KeyRune {
+		combo.rune = ev.Rune()
+	}
+	if ev.Modifiers()&tcell.ModShift != 0 {
+		if action, ok := km.actions[keyCombo{key: combo.key, rune: combo.rune, shift: true}]; ok {
+			return action
+		}
+	}
+	return km.actions[combo]
+}
+
+// labels lists the keys of action for display, showing a letter bound in
+// both cases once, in upper case, and backspace once
+func (km *KeyMap) labels(action string) []string {
+	var labels []string
+	for _, combo := range km.keys[action] {
+		label := combo.String()
+		if combo.key == tcell.KeyRune && unicode.IsLower(combo.rune) && slices.Contains(km.keys[action], keyCombo{key: tcell.KeyRune, rune: unicode.ToUpper(combo.rune)}) {
+			continue
+		}
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// Label is the keys of a help row, e.g. "Q/X/Esc" for one action, or the
+// first key of each action of a pair such as "[/]". It is empty when none
+// of the actions has a key.
+func (km *KeyMap) Label(actions ...string) string {
+	if len(actions) == 1 {
+		return strings.Join(km.labels(actions[0]), "/")
+	}
+	var firsts []string
+	for _, action := range actions {
+		if labels := km.labels(action); len(labels) > 0 {
+			firsts = append(firsts, labels[0])
+		}
+	}
+	return strings.Join(firsts, "/")
+}
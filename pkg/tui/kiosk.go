@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/globerender"
+)
+
+const (
+	DefaultKioskInterval = 30 // Seconds between kiosk panel changes
+	kioskFocusZoom       = 1.8
+	kioskEase            = 2 * time.Second
+)
+
+// kioskPanels is the order kiosk mode opens panels in; "" is a rest step
+// with only the globe and dashboard on screen, and the legend explains the
+// globe to passers-by
+var kioskPanels = []string{"stats", "", "topips", "legend", "ports", "", "creds", "legend"}
+
+// KioskDirector drives the view for unattended wall displays: it cycles
+// themes, opens and closes panels on a schedule, and periodically swings
+// the globe round and zooms into the busiest region
+type KioskDirector struct {
+	interval   time.Duration
+	nextPanel  time.Time
+	nextTheme  time.Time
+	nextFocus  time.Time
+	panelStep  int
+	focusStart time.Time
+	focusUntil time.Time
+	focusLat   float64
+	focusLon   float64
+	turn       CameraTurn
+	baseZoom   float64 // Zoom to return to when the focus ends
+	zoom       float64 // Zoom Tick last set, to notice the user zooming
+	scale      float64 // How far Tick last zoomed in from baseZoom
+	mutex      sync.Mutex
+}
+
+func NewKioskDirector(interval time.Duration, now time.Time) *KioskDirector {
+	return &KioskDirector{
+		interval:  interval,
+		nextPanel: now.Add(interval),
+		nextTheme: now.Add(2 * interval),
+		nextFocus: now.Add(interval * 3 / 2),
+		baseZoom:  1.0,
+	}
+}
+
+// Tick advances the schedule and eases zoom towards the current focus
+func (k *KioskDirector) Tick(tui *TUI, now time.Time) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if !now.Before(k.nextPanel) {
+		k.panelStep = (k.panelStep + 1) % len(kioskPanels)
+		tui.showKioskPanel(kioskPanels[k.panelStep])
+		k.nextPanel = now.Add(k.interval)
+	}
+
+	if !now.Before(k.nextTheme) {
+		tui.state.mutex.RLock()
+		next := (tui.state.currentTheme + 1) % len(themeOrder)
+		tui.state.mutex.RUnlock()
+		tui.SetTheme(themeOrder[next])
+		k.nextTheme = now.Add(2 * k.interval)
+	}
+
+	if !now.Before(k.nextFocus) {
+		if lat, lon, ok := busiestRegion(tui.dashboard.List()); ok {
+			k.focusStart = now
+			k.focusUntil = now.Add(max(k.interval/2, 5*time.Second))
+			k.focusLat, k.focusLon = lat, lon
+			k.turn.Face(lon, now, kioskEase, k.focusUntil.Sub(now))
+			tui.mutex.RLock()
+			k.baseZoom, k.zoom, k.scale = tui.globe.Zoom, tui.globe.Zoom, 1
+			tui.mutex.RUnlock()
+		}
+		k.nextFocus = now.Add(3 * k.interval)
+	}
+
+	// Ease in while focusing, ease back out over the same time afterwards
+	var p float64
+	if now.Before(k.focusUntil) {
+		p = smoothstep(float64(now.Sub(k.focusStart)) / float64(kioskEase))
+	} else if !k.focusUntil.IsZero() {
+		p = 1 - smoothstep(float64(now.Sub(k.focusUntil))/float64(kioskEase))
+		if now.Sub(k.focusUntil) >= kioskEase {
+			k.focusUntil = time.Time{}
+		}
+	} else {
+		return
+	}
+	tui.mutex.Lock()
+	g := tui.globe
+	if g.Zoom != k.zoom {
+		// The user zoomed during the focus: ease out to their zoom, not
+		// the one the focus started from
+		k.baseZoom = g.Zoom / k.scale
+	}
+	k.scale = 1 + (kioskFocusZoom-1)*p
+	g.Zoom = k.baseZoom * k.scale
+	k.zoom = g.Zoom
+	nudgeX, nudgeY := g.FocusNudge(k.focusLat, k.focusLon)
+	if g.Projection != globerender.Orthographic {
+		g.NudgeX = nudgeX * p
+	}
+	g.NudgeY = nudgeY * p
+	tui.mutex.Unlock()
+	if tui.frameRate != nil {
+		tui.frameRate.Touch() // Keep the camera move smooth
+	}
+	tui.MarkGlobeChanged()
+}
+
+// Rotation replaces the clock-driven spin while a region is in focus, and
+// afterwards keeps spinning from wherever the focus left the globe
+func (k *KioskDirector) Rotation(now time.Time, spin float64) float64 {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return k.turn.Rotation(now, spin)
+}
+
+// showKioskPanel opens one panel and closes the others kiosk mode cycles
+func (tui *TUI) showKioskPanel(name string) {
+	tui.state.mutex.Lock()
+	tui.state.showStats = name == "stats"
+	tui.state.showTopIPs = name == "topips"
+	tui.state.showPorts = name == "ports"
+	tui.state.showCredHist = name == "creds"
+	tui.state.showLegend = name == "legend"
+	tui.state.showCommands = false
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+	tui.MarkStatsChanged()
+}
+
+// busiestRegion returns the mean position of the rows in the 30° square
+// holding the most geolocated rows
+func busiestRegion(conns ConnectionList) (float64, float64, bool) {
+	if GlobalGeoIP == nil {
+		return 0, 0, false
+	}
+	type region struct {
+		count    int
+		lat, lon float64
+	}
+	regions := make(map[[2]int]*region)
+	var best *region
+	for _, conn := range conns {
+		loc := GlobalGeoIP.LookupIP(conn.IP)
+		if !loc.Valid {
+			continue
+		}
+		key := [2]int{int(math.Floor(loc.Latitude / 30)), int(math.Floor(loc.Longitude / 30))}
+		r := regions[key]
+		if r == nil {
+			r = &region{}
+			regions[key] = r
+		}
+		r.count++
+		r.lat += loc.Latitude
+		r.lon += loc.Longitude
+		if best == nil || r.count > best.count {
+			best = r
+		}
+	}
+	if best == nil {
+		return 0, 0, false
+	}
+	return best.lat / float64(best.count), best.lon / float64(best.count), true
+}
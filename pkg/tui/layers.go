@@ -0,0 +1,445 @@
+package tui
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"SecKC-MHN-Globe/pkg/effects"
+	"SecKC-MHN-Globe/pkg/globerender"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// drawToastLayer stacks the toasts down from the top right corner, newest
+// at the bottom, each fading into the background as it runs out
+func (tui *TUI) drawToastLayer(frame *Frame) {
+	snap := frame.Snap
+	y := 0
+	if snap.View.ShowBanner {
+		y = 1
+	}
+	for _, toast := range snap.Toasts {
+		if y >= frame.Snap.Height {
+			break
+		}
+		color := frame.Snap.Theme.StatusOk
+		if toast.Bad {
+			color = frame.Snap.Theme.StatusError
+		}
+		color = mixColor(orDefault(color, tcell.ColorWhite), orDefault(frame.Snap.Theme.Background, tcell.ColorBlack), toast.Fade(snap.Taken))
+		text := " " + clipCells(toast.Text, max(frame.Snap.Width/2, 10), "…") + " "
+		tui.drawText(frame.Snap.Width-textWidth(text)-1, y, text, tcell.StyleDefault.Foreground(color).Bold(true).Reverse(true))
+		y++
+	}
+}
+
+func (tui *TUI) drawText(x, y int, text string, style tcell.Style) {
+	// Bounds check
+	// Clip to the screen itself, which a resize may have changed since the
+	// frame's snapshot was taken
+	width, height := tui.screen.Size()
+	if y < 0 || y >= height || x >= width {
+		return
+	}
+
+	// Advance by cell width: wide characters take two cells and combining
+	// marks ride on the character before them
+	col, last := x, -1
+	for _, r := range text {
+		w := runewidth.RuneWidth(r)
+		if w == 0 {
+			if last >= 0 {
+				mainc, combc, _, _ := tui.screen.GetContent(last, y)
+				tui.screen.SetContent(last, y, mainc, append(combc, r), style)
+			}
+			continue
+		}
+		last = -1
+		if col >= 0 && col < width {
+			tui.screen.SetContent(col, y, r, nil, style)
+			last = col
+		}
+		col += w
+	}
+}
+
+// rasterGlobe rasterizes the globe and clears its area for the globe
+// layers, or returns nil when nothing on it has changed since the last
+// frame. Hidden arc, marker and heatmap layers are left out of the raster so
+// the land shows through where they would be.
+func (tui *TUI) rasterGlobe(snap *FrameSnapshot, rotation float64, protocolGlyphs bool) ([][]rune, [][]globerender.Kind) {
+	tui.mutex.RLock()
+	changed := tui.globeChanged
+	tui.mutex.RUnlock()
+
+	if !changed {
+		return nil, nil
+	}
+
+	var markers []globerender.Marker
+	if tui.layers.Visible("markers") {
+		markers = globeMarkers(snap.Locations, snap.Levels, protocolGlyphs)
+	}
+	arcStyle := snap.ArcStyle
+	if !tui.layers.Visible("arcs") {
+		arcStyle = "off"
+	}
+	shades := snap.Shades
+	if !tui.layers.Visible("heatmap") {
+		shades = nil
+	}
+	// Charset, lighting and the rest of the view change under tui.mutex
+	tui.mutex.RLock()
+	globeScreen, cellKinds := snap.Globe.Raster(rotation, markers, globeArcs(snap.Arcs, time.Now()), arcStyle, protocolGlyphs, shades)
+	tui.mutex.RUnlock()
+
+	// Clear globe area with bounds checking
+	for y := 0; y < snap.Globe.Height && y < snap.Height; y++ {
+		for x := 0; x < snap.Globe.Width && x < snap.Width; x++ {
+			tui.screen.SetContent(x, y, ' ', nil, tcell.StyleDefault)
+		}
+	}
+
+	return globeScreen, cellKinds
+}
+
+// drawGlobeCells draws the globe cells of one kind for a globe layer, with
+// the style style returns for each
+func (tui *TUI) drawGlobeCells(frame *Frame, match func(kind globerender.Kind) bool, style func(x, y int, char rune, kind globerender.Kind) tcell.Style) {
+	for y := 0; y < len(frame.Globe) && y < frame.Snap.Height && y < frame.Snap.Globe.Height; y++ {
+		for x := 0; x < len(frame.Globe[y]) && x < frame.Snap.Globe.Width && x < frame.Snap.Width; x++ {
+			char, kind := frame.Globe[y][x], frame.Kinds[y][x]
+			if char == ' ' || !match(kind) {
+				continue
+			}
+			tui.screen.SetContent(x, y, char, nil, style(x, y, char, kind))
+		}
+	}
+}
+
+// Rainbow and Skittles modes: colorful globe characters
+var rainbowColors = []tcell.Color{
+	tcell.NewRGBColor(255, 0, 0),   // Red
+	tcell.NewRGBColor(255, 127, 0), // Orange
+	tcell.NewRGBColor(255, 255, 0), // Yellow
+	tcell.NewRGBColor(0, 255, 0),   // Green
+	tcell.NewRGBColor(0, 0, 255),   // Blue
+	tcell.NewRGBColor(75, 0, 130),  // Indigo
+	tcell.NewRGBColor(148, 0, 211), // Violet
+}
+
+// drawRainLayer draws each trail from its head up: the head flares towards
+// white as it enters a cell and cools as it moves on, and the trail fades
+// out towards its tail. Masked rain skips every cell the globe drew on.
+func (tui *TUI) drawRainLayer(frame *Frame) {
+	if frame.Globe == nil || tui.rain == nil || !tui.rain.Enabled() {
+		return
+	}
+	glyphs := effects.RainGlyphs
+	if !tui.caps.Unicode {
+		glyphs = effects.RainASCIIGlyphs
+	}
+	white := tcell.NewRGBColor(255, 255, 255)
+	masked := tui.rain.Masked()
+	tui.rain.Each(func(col effects.RainColumn) {
+		if col.X < 0 || col.X >= frame.Snap.Globe.Width || col.X >= frame.Snap.Width {
+			return
+		}
+		head := int(math.Floor(col.Y))
+		heat := 1 - (col.Y - float64(head))
+		for i := 0; i < col.Length; i++ {
+			y := head - i
+			if y < 0 || y >= frame.Snap.Globe.Height || y >= frame.Snap.Height || y >= len(col.Glyphs) {
+				continue
+			}
+			if masked && y < len(frame.Globe) && col.X < len(frame.Globe[y]) && frame.Globe[y][col.X] != ' ' {
+				continue
+			}
+			fade := col.Intensity * (1 - float64(i)/float64(col.Length))
+			style := tcell.StyleDefault.Foreground(scaleColor(frame.Snap.Theme.RainEffect, fade))
+			if i == 0 {
+				style = tcell.StyleDefault.Foreground(mixColor(frame.Snap.Theme.RainEffect, white, 0.8*heat)).Bold(true)
+			}
+			glyph := glyphs[col.Glyphs[y]%len(glyphs)]
+			tui.screen.SetContent(col.X, y, glyph, nil, style)
+		}
+	})
+}
+
+func (tui *TUI) drawEarthLayer(frame *Frame) {
+	if frame.Globe == nil {
+		return
+	}
+	landStyle := tcell.StyleDefault.Foreground(frame.Snap.Theme.Globe)
+	rainbowMode := frame.Snap.Theme.Name == "rainbow"
+	skittlesMode := frame.Snap.Theme.Name == "skittles"
+	tui.drawGlobeCells(frame,
+		func(kind globerender.Kind) bool { return kind == globerender.KindLand },
+		func(x, y int, char rune, kind globerender.Kind) tcell.Style {
+			if rainbowMode {
+				// Rainbow mode: solid rainbow pattern (diagonal stripes)
+				return tcell.StyleDefault.Foreground(rainbowColors[(x+y)%len(rainbowColors)])
+			} else if skittlesMode {
+				// Skittles mode: randomized rainbow colors for each character
+				// Use position as seed for pseudo-random but consistent colors per position
+				return tcell.StyleDefault.Foreground(rainbowColors[((x*73)+(y*37))%len(rainbowColors)])
+			}
+			return landStyle
+		})
+}
+
+// drawGraticuleLayer dots meridians and parallels every 30° onto the open
+// ocean, leaving land, arcs and markers alone
+func (tui *TUI) drawGraticuleLayer(frame *Frame) {
+	if frame.Globe == nil {
+		return
+	}
+	style := tcell.StyleDefault.Foreground(frame.Snap.Theme.Separator)
+	plot := func(lat, lon float64) {
+		x, y, visible := frame.Snap.Globe.Project(lat, lon, frame.Rotation)
+		if visible && y >= 0 && y < len(frame.Globe) && y < frame.Snap.Height && x >= 0 && x < len(frame.Globe[y]) && x < frame.Snap.Width && frame.Globe[y][x] == ' ' {
+			tui.screen.SetContent(x, y, '·', nil, style)
+		}
+	}
+	for lat := -60.0; lat <= 60; lat += 30 {
+		for lon := -180.0; lon < 180; lon += 2 {
+			plot(lat, lon)
+		}
+	}
+	for lon := -180.0; lon < 180; lon += 30 {
+		for lat := -88.0; lat <= 88; lat += 2 {
+			plot(lat, lon)
+		}
+	}
+}
+
+func (tui *TUI) drawHeatmapLayer(frame *Frame) {
+	if frame.Globe == nil {
+		return
+	}
+	tui.drawGlobeCells(frame,
+		func(kind globerender.Kind) bool { return kind >= globerender.KindShade },
+		func(x, y int, char rune, kind globerender.Kind) tcell.Style {
+			return tcell.StyleDefault.Foreground(choroplethColor(int(kind-globerender.KindShade) + 1))
+		})
+}
+
+// drawFootprintsLayer dots the places attacks came from, dimming each
+// towards the background as it goes quiet. Busier places start brighter.
+// Arcs and markers are drawn over them.
+func (tui *TUI) drawFootprintsLayer(frame *Frame) {
+	if frame.Globe == nil || len(frame.Snap.Footprints) == 0 {
+		return
+	}
+	glyph := '·'
+	if !tui.caps.Unicode {
+		glyph = '.'
+	}
+	for _, fp := range frame.Snap.Footprints {
+		x, y, visible := frame.Snap.Globe.Project(fp.Lat, fp.Lon, frame.Rotation)
+		if !visible || y < 0 || y >= len(frame.Globe) || y >= frame.Snap.Height || x < 0 || x >= len(frame.Globe[y]) || x >= frame.Snap.Width {
+			continue
+		}
+		busy := math.Min(1, math.Log1p(float64(fp.Hits))/math.Log1p(footprintBusy))
+		color := mixColor(frame.Snap.Theme.Background, frame.Snap.Theme.Attack, fp.Fade*(0.3+0.4*busy))
+		tui.screen.SetContent(x, y, glyph, nil, tcell.StyleDefault.Foreground(color))
+	}
+}
+
+func (tui *TUI) drawArcsLayer(frame *Frame) {
+	if frame.Globe == nil {
+		return
+	}
+	arcStyle := tcell.StyleDefault.Foreground(frame.Snap.Theme.ArcTrail)
+	tui.drawGlobeCells(frame,
+		func(kind globerender.Kind) bool { return kind == globerender.KindArc },
+		func(x, y int, char rune, kind globerender.Kind) tcell.Style { return arcStyle })
+}
+
+// crowdColor is the attack color brightened towards white, for marker
+// cells several attackers share
+func crowdColor() tcell.Color {
+	return mixColor(ActiveTheme().Attack, tcell.ColorWhite, 0.45)
+}
+
+func (tui *TUI) drawMarkersLayer(frame *Frame) {
+	if frame.Globe == nil {
+		return
+	}
+	attackStyle := tcell.StyleDefault.Foreground(frame.Snap.Theme.Attack).Bold(true)
+	glyphStyle := tcell.StyleDefault.Foreground(frame.Snap.Theme.AttackGlyph).Bold(true)
+	crowdStyle := tcell.StyleDefault.Foreground(crowdColor()).Bold(true)
+	tui.drawGlobeCells(frame,
+		func(kind globerender.Kind) bool { return kind.Marker() },
+		func(x, y int, char rune, kind globerender.Kind) tcell.Style {
+			if kind == globerender.KindCrowd {
+				return crowdStyle
+			}
+			if frame.ProtocolGlyphs && char != '*' {
+				return glyphStyle
+			}
+			return attackStyle
+		})
+
+	// Markers of alerting IPs blink in reverse video for a few seconds
+	snap := frame.Snap
+	if len(snap.Flashing) > 0 && snap.Taken.UnixMilli()/250%2 == 0 {
+		flashStyle := attackStyle.Reverse(true)
+		for ip := range snap.Flashing {
+			loc, ok := snap.Locations[ip]
+			if !ok {
+				continue
+			}
+			subCell := frame.Snap.Globe.UseSubCell(frame.ProtocolGlyphs) && snap.Levels[ip] == 0
+			x, y, _, visible := frame.Snap.Globe.MarkerCell(loc.Latitude, loc.Longitude, frame.Rotation, subCell)
+			if visible && y < len(frame.Globe) && x < len(frame.Globe[y]) && x < frame.Snap.Width && y < frame.Snap.Height {
+				tui.screen.SetContent(x, y, frame.Globe[y][x], nil, flashStyle)
+			}
+		}
+	}
+}
+
+// drawHoneypotsLayer marks each honeypot the arcs run to with a ring and
+// its name, over the markers of attacks from close by
+func (tui *TUI) drawHoneypotsLayer(frame *Frame) {
+	if frame.Globe == nil {
+		return
+	}
+	glyph := honeypotGlyph(tui.caps.Unicode)
+	style := tcell.StyleDefault.Foreground(frame.Snap.Theme.Stats).Bold(true)
+	for _, honeypot := range frame.Snap.Honeypots {
+		x, y, visible := frame.Snap.Globe.Project(honeypot.Lat, honeypot.Lon, frame.Rotation)
+		if !visible || y < 0 || y >= len(frame.Globe) || y >= frame.Snap.Height || x < 0 || x >= frame.Snap.Globe.Width || x >= frame.Snap.Width {
+			continue
+		}
+		tui.screen.SetContent(x, y, glyph, nil, style)
+		for i, r := range []rune(honeypot.Name) {
+			if x+2+i >= frame.Snap.Globe.Width || x+2+i >= frame.Snap.Width {
+				break
+			}
+			tui.screen.SetContent(x+2+i, y, r, nil, style)
+		}
+	}
+}
+
+// honeypotGlyph is the honeypot marker, a ring where the terminal has one
+func honeypotGlyph(unicode bool) rune {
+	if unicode {
+		return '◉'
+	}
+	return 'O'
+}
+
+func (tui *TUI) drawDashboardLayer(frame *Frame) {
+	switch frame.Layout {
+	case LayoutCompact:
+		if !marqueeShown(frame.Snap.View.TickerMode, frame.Layout) {
+			tui.renderTicker(frame.Snap)
+		}
+		return
+	case LayoutMinimal:
+		tui.renderMinimal(frame.Snap)
+		return
+	}
+	tui.renderDashboard(frame.Snap)
+	tui.renderStats(frame.Snap)
+	tui.renderTimeline(frame.Snap)
+	tui.renderBanner(frame.Snap)
+}
+
+func (tui *TUI) drawPanelsLayer(frame *Frame) {
+	if frame.Layout == LayoutMinimal {
+		return
+	}
+	snap := frame.Snap
+	tui.renderLegendPanel(snap, frame.ProtocolGlyphs)
+	tui.renderInfoPanel(snap)
+	tui.renderStatsPanel(snap)
+	tui.renderTopIPsPanel(snap)
+	tui.renderPortsPanel(snap)
+	tui.renderCredHistPanel(snap)
+	tui.renderDiagnosticsPanel(snap)
+	tui.renderAlertsPanel(snap)
+	tui.renderFindingsPanel(snap)
+	tui.renderHashesPanel(snap)
+	tui.renderURLsPanel(snap)
+	tui.renderCoveragePanel(snap)
+	tui.renderSessionPanel(snap)
+	tui.renderSettingsPanel(snap)
+}
+
+// drawStatusLayer draws the ticker marquee or the command guide on the
+// bottom row. The smaller layouts need the row for their attacks instead.
+func (tui *TUI) drawStatusLayer(frame *Frame) {
+	switch {
+	case marqueeShown(frame.Snap.View.TickerMode, frame.Layout):
+		tui.renderMarquee(frame.Snap)
+	case frame.Layout == LayoutFull:
+		tui.renderCommandGuide(frame.Snap)
+	}
+}
+
+func (tui *TUI) drawHelpLayer(frame *Frame) {
+	tui.renderHelpPanel(frame.Snap)
+}
+
+// drawPaletteLayer draws the : prompt over the command guide on the bottom
+// row, with the usage of the command being typed on the right and the
+// completions for the word being typed on the row above
+func (tui *TUI) drawPaletteLayer(frame *Frame) {
+	p := frame.Snap.Palette
+	if !p.Open || frame.Snap.Height < 2 {
+		return
+	}
+	blankStyle := tcell.StyleDefault.Background(frame.Snap.Theme.Background)
+	promptStyle := blankStyle.Foreground(frame.Snap.Theme.Dashboard).Bold(true)
+	dimStyle := blankStyle.Foreground(frame.Snap.Theme.Separator)
+	y := frame.Snap.Height - 1
+	for x := 0; x < frame.Snap.Width; x++ {
+		tui.screen.SetContent(x, y, ' ', nil, blankStyle)
+	}
+
+	// Long lines scroll so the cursor stays on screen
+	prompt := ":" + p.Entry
+	for textWidth(prompt) > frame.Snap.Width-1 {
+		_, size := utf8.DecodeRuneInString(prompt)
+		prompt = prompt[size:]
+	}
+	tui.drawText(0, y, prompt, promptStyle)
+	cursorX := textWidth(prompt)
+	tui.drawText(cursorX, y, " ", promptStyle.Reverse(true))
+
+	usage := "Tab completes, ↑/↓ history, Enter runs, Esc closes"
+	if words := strings.Fields(p.Entry); len(words) > 0 {
+		usage = ""
+		if cmd, ok := findPaletteCommand(words[0]); ok {
+			usage = cmd.Name + " " + cmd.Usage + " - " + cmd.Help
+		}
+	}
+	if usage != "" && cursorX+2+textWidth(usage) < frame.Snap.Width {
+		tui.drawText(frame.Snap.Width-textWidth(usage)-1, y, usage, dimStyle)
+	}
+
+	if len(p.Hints) == 0 {
+		return
+	}
+	y--
+	for x := 0; x < frame.Snap.Width; x++ {
+		tui.screen.SetContent(x, y, ' ', nil, blankStyle)
+	}
+	x := 1
+	for i, hint := range p.Hints {
+		if x+textWidth(hint)+2 > frame.Snap.Width {
+			tui.drawText(x, y, "…", dimStyle)
+			break
+		}
+		style := promptStyle
+		if i == p.Hint {
+			style = style.Reverse(true)
+		}
+		tui.drawText(x, y, hint, style)
+		x += textWidth(hint) + 2
+	}
+}
@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/globerender"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Layout is how much of the interface the terminal has room for
+type Layout int
+
+const (
+	LayoutFull    Layout = iota // Globe, dashboard, statistics and panels
+	LayoutCompact               // The globe alone, with a one-line attack ticker under it
+	LayoutMinimal               // A few lines of statistics and the newest attacks
+)
+
+var layoutNames = []string{"full", "compact", "minimal"}
+
+func (l Layout) String() string {
+	return layoutNames[l]
+}
+
+// The smallest terminal each layout is used in. Going back up to a bigger
+// layout takes layoutHysteresis more columns and rows, so dragging a window
+// edge back and forth over a threshold does not flip layouts on every event.
+const (
+	fullMinWidth, fullMinHeight       = 60, 20
+	compactMinWidth, compactMinHeight = 24, 8
+	layoutHysteresisX                 = 4
+	layoutHysteresisY                 = 2
+)
+
+// chooseLayout picks the layout for a width x height terminal, given the
+// one in use
+func chooseLayout(current Layout, width, height int) Layout {
+	fits := func(layout Layout, minWidth, minHeight int) bool {
+		if layout < current {
+			minWidth += layoutHysteresisX
+			minHeight += layoutHysteresisY
+		}
+		return width >= minWidth && height >= minHeight
+	}
+	switch {
+	case fits(LayoutFull, fullMinWidth, fullMinHeight):
+		return LayoutFull
+	case fits(LayoutCompact, compactMinWidth, compactMinHeight):
+		return LayoutCompact
+	}
+	return LayoutMinimal
+}
+
+// Layout is the layout the terminal was last sized for
+func (tui *TUI) Layout() Layout {
+	tui.mutex.RLock()
+	defer tui.mutex.RUnlock()
+	return tui.layout
+}
+
+// Size is the terminal size as of the last resize. Frames draw from the
+// copy in their snapshot instead.
+func (tui *TUI) Size() (int, int) {
+	tui.mutex.RLock()
+	defer tui.mutex.RUnlock()
+	return tui.width, tui.height
+}
+
+// Globe is a copy of the globe's view settings, for reading them away from
+// the locks that guard changes
+func (tui *TUI) Globe() globerender.Globe {
+	tui.mutex.RLock()
+	defer tui.mutex.RUnlock()
+	return *tui.globe
+}
+
+// tickerEntry is how an attack reads on the ticker
+func tickerEntry(conn api.Connection) string {
+	entry := conn.IP
+	if conn.Country != "" {
+		entry += " [" + conn.Country + "]"
+	}
+	if conn.Username != "" && conn.Username != "unknown" && conn.Username != "connection" {
+		entry += " " + conn.Username + ":" + conn.Password
+	}
+	return entry + " " + protocolColumn(conn)
+}
+
+// tickerText lists the dashboard's attacks newest first, as far as fits in
+// width cells
+func tickerText(conns ConnectionList, width int) string {
+	var sb strings.Builder
+	for i := len(conns) - 1; i >= 0 && textWidth(sb.String()) < width; i-- {
+		if sb.Len() > 0 {
+			sb.WriteString(" · ")
+		}
+		sb.WriteString(tickerEntry(conns[i]))
+	}
+	return sb.String()
+}
+
+// renderTicker draws the compact layout's attack ticker on the bottom row
+func (tui *TUI) renderTicker(snap *FrameSnapshot) {
+	tui.mutex.RLock()
+	changed := tui.dashChanged
+	tui.mutex.RUnlock()
+	if !changed || snap.Height < 1 {
+		return
+	}
+
+	y := snap.Height - 1
+	text := tickerText(snap.Connections, snap.Width)
+	if text == "" {
+		text = "Waiting for attacks..."
+	}
+	tui.drawText(0, y, padCells(clipCells(text, snap.Width, "…"), snap.Width), tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background))
+
+	tui.mutex.Lock()
+	tui.dashChanged = false
+	tui.mutex.Unlock()
+}
+
+// renderMinimal fills a terminal too small for the globe with the status
+// line's health indicators, the session totals and the newest attacks.
+// The totals come from the choropleth tally, so events that could not be
+// placed in a country are left out.
+func (tui *TUI) renderMinimal(snap *FrameSnapshot) {
+	tui.mutex.RLock()
+	changed := tui.dashChanged
+	tui.mutex.RUnlock()
+	if !changed {
+		return
+	}
+
+	blankStyle := tcell.StyleDefault.Background(snap.Theme.Background)
+	for y := 0; y < snap.Height; y++ {
+		for x := 0; x < snap.Width; x++ {
+			tui.screen.SetContent(x, y, ' ', nil, blankStyle)
+		}
+	}
+
+	headerStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Bold(true)
+	rowStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard)
+	tui.drawText(0, 0, clipCells("SecKC MHN Globe", snap.Width, ""), headerStyle)
+
+	x := 0
+	for _, item := range tui.statusItems(snap.Taken, snap.Rate, snap.Theme, snap.Globe.Charset) {
+		text := item.text
+		if x == 0 {
+			text = strings.TrimPrefix(text, " ")
+		}
+		if x+textWidth(text) > snap.Width {
+			break
+		}
+		tui.drawText(x, 1, text, item.style)
+		x += textWidth(text)
+	}
+
+	counts := globalCountryTally.Counts()
+	events := 0
+	for _, n := range counts {
+		events += n
+	}
+	totals := fmt.Sprintf("%d events from %d countries", events, len(counts))
+	tui.drawText(0, 2, clipCells(totals, snap.Width, "…"), rowStyle)
+
+	for y, i := 3, len(snap.Connections)-1; y < snap.Height && i >= 0; y, i = y+1, i-1 {
+		tui.drawText(0, y, clipCells(tickerEntry(snap.Connections[i]), snap.Width, "…"), rowStyle)
+	}
+
+	tui.mutex.Lock()
+	tui.dashChanged = false
+	tui.mutex.Unlock()
+}
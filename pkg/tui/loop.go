@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// LoopOptions are the main loop's settings
+type LoopOptions struct {
+	Refresh        time.Duration // Between globe redraws
+	RotationPeriod time.Duration // One turn of the globe
+	Kiosk          time.Duration // Between kiosk panel changes, 0 outside kiosk mode
+	ProtocolGlyphs bool
+	Mock           bool // Make up connections, with no live source to show
+}
+
+// Run draws frames and runs the periodic jobs until quit fires
+func (tui *TUI) Run(quit <-chan bool, opts LoopOptions) {
+	startTime := time.Now()
+
+	follow := &FollowCamera{}
+
+	// Kiosk mode runs the show without user input
+	var kiosk *KioskDirector
+	if opts.Kiosk > 0 {
+		kiosk = NewKioskDirector(opts.Kiosk, startTime)
+		tui.showKioskPanel("")
+	}
+	lastConnectionTime := time.Now()
+	lastGlobeUpdate := time.Now()
+	lastStatsUpdate := time.Now()
+	lastArcCleanup := time.Now()
+	lastRainUpdate := time.Now()
+	lastCRTUpdate := time.Now()
+	lastFrame := time.Now()
+	wasIdle := false
+	lastRole := ""
+	lastAuth := ""
+	lastStatus := ""
+	lastToasts := ""
+	lastStormFlash := int64(-1)
+
+	nextMockInterval := time.Duration(200+rand.Intn(4800)) * time.Millisecond
+
+	fetchStats := func() {
+		if tui.stats.offline {
+			return
+		}
+		api.Workers.Go("stats-fetch", func(stop <-chan struct{}) error {
+			if err := tui.stats.FetchData(); err != nil {
+				debugLog("Stats: Fetch failed: %v", err)
+			} else {
+				tui.MarkStatsChanged()
+			}
+			return nil
+		})
+	}
+
+	// Fetch initial stats
+	fetchStats()
+
+	// Main loop
+	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
+		now := time.Now()
+
+		// Update globe rotation
+		if now.Sub(lastGlobeUpdate) >= opts.Refresh {
+			tui.MarkGlobeChanged()
+			lastGlobeUpdate = now
+		}
+
+		// Generate mock data if needed
+		if opts.Mock && now.Sub(lastConnectionTime) >= nextMockInterval {
+			tui.dashboard.GenerateRandomConnection()
+			lastConnectionTime = now
+			nextMockInterval = time.Duration(200+rand.Intn(4800)) * time.Millisecond
+		}
+
+		// Update stats
+		if now.Sub(lastStatsUpdate) >= 300*time.Second {
+			fetchStats()
+			lastStatsUpdate = now
+		}
+
+		// Cleanup expired arcs
+		if GlobalArcManager != nil && now.Sub(lastArcCleanup) >= 100*time.Millisecond {
+			GlobalArcManager.CleanupExpired()
+			lastArcCleanup = now
+		}
+
+		// Update rain effect
+		if tui.rain != nil && tui.rain.Enabled() && now.Sub(lastRainUpdate) >= 50*time.Millisecond {
+			tui.rain.Update()
+			lastRainUpdate = now
+			tui.MarkGlobeChanged()
+		}
+
+		// Update CRT effect
+		if tui.crt != nil && now.Sub(lastCRTUpdate) >= 100*time.Millisecond {
+			tui.crt.Update()
+			lastCRTUpdate = now
+		}
+
+		rotation := tui.state.AdvanceRotation(now.Sub(lastFrame), opts.RotationPeriod)
+		lastFrame = now
+
+		if kiosk != nil {
+			kiosk.Tick(tui, now)
+			rotation = kiosk.Rotation(now, rotation)
+		}
+		tui.state.mutex.RLock()
+		following := tui.state.following
+		tui.state.mutex.RUnlock()
+		if following {
+			follow.Tick(tui, now)
+		}
+		rotation = follow.Rotation(now, rotation)
+		rotation = tui.presets.Rotation(now, rotation)
+
+		// Redraw the status line when switching between idle and active
+		idle := tui.frameRate.IsIdle()
+		if idle != wasIdle {
+			debugLog("Frame rate: idle=%v fps=%d", idle, tui.frameRate.CurrentFPS())
+			tui.MarkDashboardChanged()
+			wasIdle = idle
+		}
+
+		// Redraw the status line when the spectator indicator changes, such
+		// as when a wrong passphrase notice runs out
+		if tui.roles != nil {
+			if role := tui.roles.Status(now); role != lastRole {
+				tui.MarkDashboardChanged()
+				lastRole = role
+			}
+		}
+
+		// Redraw it too when an API endpoint accepts or refuses the key or
+		// goes down, since that also stops the events that would trigger a redraw
+		if auth := fmt.Sprint(apiStatusBadges()); auth != lastAuth {
+			tui.MarkDashboardChanged()
+			lastAuth = auth
+		}
+
+		// Redraw what toasts covered as they come and go
+		if toasts := fmt.Sprint(globalToasts.Active(now)); toasts != lastToasts {
+			tui.MarkGlobeChanged()
+			tui.MarkDashboardChanged()
+			tui.MarkStatsChanged()
+			lastToasts = toasts
+		}
+
+		// The rate gauge and the event age move with the clock, not just
+		// with new events
+		if status := tui.statusText(now); status != lastStatus {
+			tui.MarkDashboardChanged()
+			lastStatus = status
+		}
+
+		// Raise and clear the storm alarm, and flash its header while it lasts
+		if GlobalStorm != nil {
+			tui.updateStorm(now)
+			if GlobalStorm.State().Active {
+				if flash := now.UnixMilli() / stormFlashInterval.Milliseconds(); flash != lastStormFlash {
+					tui.MarkDashboardChanged()
+					lastStormFlash = flash
+				}
+			}
+		}
+
+		tui.Render(rotation, opts.ProtocolGlyphs)
+
+		time.Sleep(tui.frameRate.FrameInterval())
+	}
+}
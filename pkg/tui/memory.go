@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/stats"
+)
+
+// Default caps for structures that grow with attack volume
+const (
+	DefaultMaxArcs         = 2000
+	DefaultMaxCredAttempts = stats.DefaultMaxAttempts
+)
+
+// MemoryWatchdog samples the process RSS and sheds cached data when it
+// exceeds the configured limit, so long-running kiosks stay bounded
+type MemoryWatchdog struct {
+	limit    uint64 // Bytes; 0 disables the watchdog
+	interval time.Duration
+	lastRSS  uint64
+	sheds    int
+	mutex    sync.RWMutex
+}
+
+func NewMemoryWatchdog(limitMB int) *MemoryWatchdog {
+	return &MemoryWatchdog{
+		limit:    uint64(limitMB) * 1024 * 1024,
+		interval: 10 * time.Second,
+	}
+}
+
+// Start checks memory usage on every interval until the process exits
+func (mw *MemoryWatchdog) Start() {
+	if mw.limit == 0 {
+		return
+	}
+	api.Workers.Go("mem-watchdog", func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(mw.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return nil
+			case <-ticker.C:
+				mw.Check()
+			}
+		}
+	})
+}
+
+// Check samples RSS once and sheds load if it is over the limit
+func (mw *MemoryWatchdog) Check() {
+	rss := currentRSS()
+
+	mw.mutex.Lock()
+	mw.lastRSS = rss
+	over := mw.limit > 0 && rss > mw.limit
+	if over {
+		mw.sheds++
+	}
+	mw.mutex.Unlock()
+
+	if !over {
+		return
+	}
+	debugLog("Memory: RSS %d MB exceeds limit %d MB, shedding load", rss>>20, mw.limit>>20)
+	PostBanner("warning", "Memory %d MB over the %d MB limit, shedding cached data", rss>>20, mw.limit>>20)
+	shedLoad()
+	debugLog("Memory: RSS %d MB after shedding", currentRSS()>>20)
+}
+
+// Stats returns the last sampled RSS in bytes and how many times load was shed
+func (mw *MemoryWatchdog) Stats() (uint64, int) {
+	mw.mutex.RLock()
+	defer mw.mutex.RUnlock()
+	return mw.lastRSS, mw.sheds
+}
+
+// currentRSS reads the resident set size from /proc, falling back to the Go
+// runtime's view of memory obtained from the OS on other platforms
+func currentRSS() uint64 {
+	if data, err := os.ReadFile("/proc/self/statm"); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Sys
+}
+
+// shedLoad halves every bounded cache and returns freed memory to the OS
+func shedLoad() {
+	if GlobalArcManager != nil {
+		GlobalArcManager.Shed()
+	}
+	if GlobalGeoIP != nil {
+		GlobalGeoIP.Shed()
+	}
+	if GlobalCredStats != nil {
+		GlobalCredStats.Shed()
+	}
+	if GlobalOffenders != nil {
+		GlobalOffenders.Shed()
+	}
+	if GlobalHistory != nil {
+		GlobalHistory.Shed()
+	}
+	debug.FreeOSMemory()
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	DefaultRepeatThreshold = 5
+	maxTrackedOffenders    = 50000
+)
+
+// OffenderTracker counts hits per source IP for the whole session, unlike
+// the dashboard which only remembers the rows on screen
+type OffenderTracker struct {
+	hits      map[string]int
+	threshold int // Hits at which an IP counts as a repeat offender
+	mutex     sync.RWMutex
+}
+
+func NewOffenderTracker(threshold int) *OffenderTracker {
+	return &OffenderTracker{
+		hits:      make(map[string]int),
+		threshold: threshold,
+	}
+}
+
+// Record counts one hit and returns the IP's session total
+func (ot *OffenderTracker) Record(ip string) int {
+	ot.mutex.Lock()
+	defer ot.mutex.Unlock()
+	if _, exists := ot.hits[ip]; !exists && len(ot.hits) >= maxTrackedOffenders {
+		ot.dropOneTimers()
+	}
+	ot.hits[ip]++
+	return ot.hits[ip]
+}
+
+// Hits returns the session totals for the given IPs
+func (ot *OffenderTracker) Hits(ips []string) map[string]int {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+	hits := make(map[string]int, len(ips))
+	for _, ip := range ips {
+		hits[ip] = ot.hits[ip]
+	}
+	return hits
+}
+
+// Level grades a hit count: 0 for a normal marker, 1 for a repeat offender
+// and 2 for an IP with four times the threshold
+func (ot *OffenderTracker) Level(hits int) int {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+	switch {
+	case hits >= ot.threshold*4:
+		return 2
+	case hits >= ot.threshold:
+		return 1
+	}
+	return 0
+}
+
+func (ot *OffenderTracker) Threshold() int {
+	ot.mutex.RLock()
+	defer ot.mutex.RUnlock()
+	return ot.threshold
+}
+
+func (ot *OffenderTracker) SetThreshold(threshold int) {
+	ot.mutex.Lock()
+	defer ot.mutex.Unlock()
+	ot.threshold = threshold
+}
+
+// Shed forgets IPs that have only been seen once
+func (ot *OffenderTracker) Shed() {
+	ot.mutex.Lock()
+	defer ot.mutex.Unlock()
+	ot.dropOneTimers()
+}
+
+func (ot *OffenderTracker) dropOneTimers() {
+	for ip, n := range ot.hits {
+		if n <= 1 {
+			delete(ot.hits, ip)
+		}
+	}
+}
+
+// offenderBadge is the dashboard badge for a repeat offender, "" otherwise
+func offenderBadge(hits int) string {
+	if GlobalOffenders == nil || GlobalOffenders.Level(hits) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("×%d ", hits)
+}
+
+// offenderMarker is the globe marker for a repeat offender level
+func offenderMarker(level int) rune {
+	switch level {
+	case 2:
+		return '█'
+	case 1:
+		return '✸'
+	}
+	return '*'
+}
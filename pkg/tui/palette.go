@@ -0,0 +1,592 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"SecKC-MHN-Globe/pkg/globerender"
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxPaletteHistory caps the commands Up and Down can recall
+const maxPaletteHistory = 50
+
+// PaletteCommand is a command typed at the : prompt, for operations that do
+// not deserve a key of their own
+type PaletteCommand struct {
+	Name     string
+	Usage    string // Arguments, shown while the command is typed
+	Help     string
+	Complete func(tui *TUI, args []string) []string        // Candidates for the last of args, which is being typed
+	Run      func(tui *TUI, args []string) (string, error) // Returns the message to toast
+}
+
+var paletteCommands = []PaletteCommand{
+	{"theme", "<name>", "Switch the color theme", completeTheme, (*TUI).runThemeCommand},
+	{"charset", "<name>", "Switch the globe charset", completeCharset, (*TUI).runCharsetCommand},
+	{"arcs", "<style>", "Set the attack arc style", completeArcs, (*TUI).runArcsCommand},
+	{"layer", "<name> on|off|<dim 0-1>", "Show, hide or dim a layer", (*TUI).completeLayer, (*TUI).runLayerCommand},
+	{"filter", "<field> <value> | off", "Show only rows matching a field", (*TUI).completeFilter, (*TUI).runFilterCommand},
+	{"goto", "<lat>,<lon> [zoom] | <region>", "Turn the globe to a place and hold it", (*TUI).completeGoto, (*TUI).runGotoCommand},
+	{"zoom", "<0.5-3.0>", "Set the globe zoom", nil, (*TUI).runZoomCommand},
+	{"projection", "<name>", "Show the globe, a flat map, a Mollweide map or both hemispheres", completeProjection, (*TUI).runProjectionCommand},
+	{"record", "<file.cast> | stop", "Start or stop an asciinema recording", completeRecord, (*TUI).runRecordCommand},
+	{"export", "[last <duration>] ndjson|csv|cef [file] | stats [base] | urls [file]", "Write the session history, statistics or attacker URLs to files", completeExport, (*TUI).runExportCommand},
+	{"wordlist", "[all|<protocol>] [base]", "Write the credentials tried as hydra/hashcat wordlists", completeWordlist, (*TUI).runWordlistCommand},
+}
+
+// findPaletteCommand looks a command up by name or by a prefix only it has
+func findPaletteCommand(name string) (PaletteCommand, bool) {
+	var found []PaletteCommand
+	for _, cmd := range paletteCommands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+		if strings.HasPrefix(cmd.Name, name) {
+			found = append(found, cmd)
+		}
+	}
+	if len(found) != 1 {
+		return PaletteCommand{}, false
+	}
+	return found[0], true
+}
+
+func completeTheme(_ *TUI, args []string) []string {
+	if len(args) == 1 {
+		return themeOrder
+	}
+	return nil
+}
+
+func completeCharset(_ *TUI, args []string) []string {
+	if len(args) == 1 {
+		return CharsetNames
+	}
+	return nil
+}
+
+func completeProjection(_ *TUI, args []string) []string {
+	if len(args) == 1 {
+		return globerender.ProjectionNames
+	}
+	return nil
+}
+
+func completeArcs(_ *TUI, args []string) []string {
+	if len(args) == 1 {
+		return ArcStyles
+	}
+	return nil
+}
+
+func (tui *TUI) completeLayer(args []string) []string {
+	switch len(args) {
+	case 1:
+		return tui.layers.Names()
+	case 2:
+		return []string{"on", "off"}
+	}
+	return nil
+}
+
+func (tui *TUI) completeFilter(args []string) []string {
+	switch {
+	case len(args) == 1:
+		return append(slices.Clone(rowFilterFields), "off")
+	case len(args) == 2 && GlobalHistory != nil && slices.Contains(rowFilterFields, args[0]):
+		return GlobalHistory.Values(args[0], 30)
+	}
+	return nil
+}
+
+// presetSlug is a preset name as one word for :goto, e.g. north-america
+func presetSlug(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
+
+func (tui *TUI) completeGoto(args []string) []string {
+	if len(args) != 1 || tui.presets == nil {
+		return nil
+	}
+	var slugs []string
+	for _, preset := range tui.presets.presets {
+		slugs = append(slugs, presetSlug(preset.Name))
+	}
+	return slugs
+}
+
+func completeRecord(_ *TUI, args []string) []string {
+	if len(args) == 1 {
+		return []string{"stop"}
+	}
+	return nil
+}
+
+func completeExport(_ *TUI, args []string) []string {
+	switch {
+	case len(args) == 1:
+		return append([]string{"last", "stats", "urls"}, exportFormats...)
+	case args[0] == "last" && len(args) == 2:
+		return []string{"15m", "1h", "6h", "24h"}
+	case args[0] == "last" && len(args) == 3:
+		return exportFormats
+	}
+	return nil
+}
+
+func completeWordlist(_ *TUI, args []string) []string {
+	if len(args) != 1 || GlobalHistory == nil {
+		return nil
+	}
+	protocols := []string{"all"}
+	for _, proto := range GlobalHistory.Values("proto", 30) {
+		if proto = strings.ToLower(proto); !slices.Contains(protocols, proto) {
+			protocols = append(protocols, proto)
+		}
+	}
+	return protocols
+}
+
+// oneArg returns the single argument of a command that takes one
+func oneArg(args []string, usage string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: %s", usage)
+	}
+	return args[0], nil
+}
+
+func (tui *TUI) runThemeCommand(args []string) (string, error) {
+	name, err := oneArg(args, "theme <name>")
+	if err != nil {
+		return "", err
+	}
+	if _, ok := Themes[name]; !ok {
+		return "", fmt.Errorf("unknown theme %q", name)
+	}
+	tui.SetTheme(name)
+	return "Theme: " + name, nil
+}
+
+func (tui *TUI) runCharsetCommand(args []string) (string, error) {
+	name, err := oneArg(args, "charset <name>")
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(CharsetNames, name) {
+		return "", fmt.Errorf("unknown charset %q (charsets: %s)", name, strings.Join(CharsetNames, ", "))
+	}
+	if name != "ascii" && !tui.caps.Unicode {
+		return "", fmt.Errorf("%s needs a Unicode terminal", name)
+	}
+	tui.SetCharset(ParseCharset(name))
+	return "Charset: " + name, nil
+}
+
+func (tui *TUI) runProjectionCommand(args []string) (string, error) {
+	name, err := oneArg(args, "projection <name>")
+	if err != nil {
+		return "", err
+	}
+	projection, ok := globerender.ParseProjection(name)
+	if !ok {
+		return "", fmt.Errorf("unknown projection %q (projections: %s)", name, strings.Join(globerender.ProjectionNames, ", "))
+	}
+	tui.SetProjection(projection)
+	return "Projection: " + name, nil
+}
+
+func (tui *TUI) runArcsCommand(args []string) (string, error) {
+	style, err := oneArg(args, "arcs <style>")
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(ArcStyles, style) {
+		return "", fmt.Errorf("unknown arc style %q (styles: %s)", style, strings.Join(ArcStyles, ", "))
+	}
+	tui.SetArcStyle(style)
+	return "Arcs: " + style, nil
+}
+
+func (tui *TUI) runLayerCommand(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("usage: layer <name> on|off|<dim 0-1>")
+	}
+	if err := tui.SetLayers(args[0] + "=" + args[1]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Layer %s: %s", args[0], args[1]), nil
+}
+
+func (tui *TUI) runFilterCommand(args []string) (string, error) {
+	if len(args) == 0 || (len(args) == 1 && args[0] == "off") {
+		tui.SetRowFilter(RowFilter{})
+		return "Filter cleared", nil
+	}
+	filter, err := parseRowFilter(args[0], strings.Join(args[1:], " "))
+	if err != nil {
+		return "", err
+	}
+	tui.SetRowFilter(filter)
+	return "Filter: " + filter.String(), nil
+}
+
+// runGotoCommand takes a latitude and longitude with an optional zoom,
+// separated by commas or spaces, or the name of one of the region presets
+func (tui *TUI) runGotoCommand(args []string) (string, error) {
+	text := strings.Join(args, " ")
+	if text == "" {
+		return "", fmt.Errorf("usage: goto <lat>,<lon> [zoom] | <region>")
+	}
+	if tui.presets != nil {
+		for _, preset := range tui.presets.presets {
+			if presetSlug(preset.Name) == presetSlug(text) {
+				tui.FaceView(preset)
+				return "Go to " + preset.Name, nil
+			}
+		}
+	}
+	coords := strings.Fields(strings.ReplaceAll(text, ",", " "))
+	if len(coords) == 2 {
+		coords = append(coords, "2.0")
+	}
+	if len(coords) != 3 {
+		return "", fmt.Errorf("unknown place %q", text)
+	}
+	preset, err := ParseViewPreset("goto," + strings.Join(coords, ","))
+	if err != nil {
+		return "", err
+	}
+	preset.Name = fmt.Sprintf("%.1f,%.1f", preset.Lat, preset.Lon)
+	tui.FaceView(preset)
+	return "Go to " + preset.Name, nil
+}
+
+func (tui *TUI) runZoomCommand(args []string) (string, error) {
+	arg, err := oneArg(args, "zoom <0.5-3.0>")
+	if err != nil {
+		return "", err
+	}
+	zoom, err := strconv.ParseFloat(arg, 64)
+	if err != nil || zoom < minZoom || zoom > maxZoom {
+		return "", fmt.Errorf("zoom must be between %.1f and %.1f", minZoom, maxZoom)
+	}
+	return fmt.Sprintf("Zoom: %.1fx", tui.ZoomCenter(zoom)), nil
+}
+
+func (tui *TUI) runRecordCommand(args []string) (string, error) {
+	path, err := oneArg(args, "record <file.cast> | stop")
+	if err != nil {
+		return "", err
+	}
+	if path == "stop" {
+		tui.StartRecording("")
+		return "Recording stopped", nil
+	}
+	paths, err := tui.StartRecording(path)
+	if err != nil {
+		return "", err
+	}
+	return "Recording started: " + strings.Join(paths, ", "), nil
+}
+
+// runExportCommand writes the history rows the dashboard filter keeps,
+// from the last duration given or the whole session, to the named file or
+// a timestamped one in the working directory. "stats" writes the session
+// report instead, and "urls" the attacker URLs as CSV.
+func (tui *TUI) runExportCommand(args []string) (string, error) {
+	usage := fmt.Errorf("usage: export [last <duration>] ndjson|csv|cef [file] | stats [base] | urls [file]")
+	if len(args) > 0 && args[0] == "urls" {
+		if len(args) > 2 {
+			return "", usage
+		}
+		path := "seckc-globe-urls-" + time.Now().Format("20060102-150405") + ".csv"
+		if len(args) == 2 {
+			path = args[1]
+		}
+		urls := globalURLs.All()
+		if err := ExportURLs(urls, path); err != nil {
+			return "", err
+		}
+		debugLog("Export: %d URLs to %s", len(urls), path)
+		return fmt.Sprintf("Exported %d URLs to %s", len(urls), path), nil
+	}
+	if GlobalHistory == nil {
+		return "", fmt.Errorf("no session history")
+	}
+	if len(args) > 0 && args[0] == "stats" {
+		if len(args) > 2 {
+			return "", usage
+		}
+		base := sessionReportBase()
+		if len(args) == 2 {
+			base = strings.TrimSuffix(args[1], filepath.Ext(args[1]))
+		}
+		events, err := tui.ExportSessionReport(base)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Exported statistics of %d events to %s.{json,csv,md}", events, base), nil
+	}
+	rows, _ := GlobalHistory.Page(0, GlobalHistory.Len())
+	if len(args) > 0 && args[0] == "last" {
+		if len(args) < 2 {
+			return "", usage
+		}
+		window, err := time.ParseDuration(args[1])
+		if err != nil || window <= 0 {
+			return "", fmt.Errorf("invalid duration %q", args[1])
+		}
+		rows = GlobalHistory.Since(time.Now().Add(-window))
+		args = args[2:]
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return "", usage
+	}
+	format := args[0]
+	path := "seckc-globe-" + time.Now().Format("20060102-150405") + "." + format
+	if len(args) == 2 {
+		path = args[1]
+	}
+	tui.state.mutex.RLock()
+	rows = rows.FilterRows(tui.state.rowFilter)
+	tui.state.mutex.RUnlock()
+	if err := ExportEvents(rows, format, path); err != nil {
+		return "", err
+	}
+	debugLog("Export: %d events to %s", len(rows), path)
+	return fmt.Sprintf("Exported %d events to %s", len(rows), path), nil
+}
+
+// runWordlistCommand writes the credentials of the session history, of
+// every protocol or just one, to wordlist files named after base or
+// seckc-globe-wordlist[-<protocol>] in the working directory. The names do
+// not change, so running it again refreshes the lists.
+func (tui *TUI) runWordlistCommand(args []string) (string, error) {
+	if GlobalHistory == nil {
+		return "", fmt.Errorf("no session history")
+	}
+	if len(args) > 2 {
+		return "", fmt.Errorf("usage: wordlist [all|<protocol>] [base]")
+	}
+	protocol := ""
+	if len(args) > 0 && !strings.EqualFold(args[0], "all") {
+		protocol = strings.ToLower(args[0])
+	}
+	base := "seckc-globe-wordlist"
+	if protocol != "" {
+		base += "-" + protocol
+	}
+	if len(args) == 2 {
+		base = args[1]
+	}
+	rows, _ := GlobalHistory.Page(0, GlobalHistory.Len())
+	lists := BuildWordlists(rows, protocol)
+	if len(lists.Combos) == 0 {
+		return "", fmt.Errorf("no %scredentials seen yet", strings.TrimPrefix(protocol+" ", " "))
+	}
+	if _, err := WriteWordlists(lists, base); err != nil {
+		return "", err
+	}
+	debugLog("Wordlist: %d users, %d passwords, %d pairs to %s-*", len(lists.Users), len(lists.Passwords), len(lists.Combos), base)
+	return fmt.Sprintf("Wrote %d users, %d passwords, %d pairs to %s-*", len(lists.Users), len(lists.Passwords), len(lists.Combos), base), nil
+}
+
+// CommandPalette is the : prompt: the line being typed, completions for
+// its last word and the lines entered before
+type CommandPalette struct {
+	open    bool
+	entry   string
+	hints   []string // Completions for the last word of entry
+	hint    int      // Hint Tab last filled in, -1 until Tab cycles
+	history []string
+	recall  int // Line Up/Down recalled, len(history) for a new one
+	mutex   sync.Mutex
+}
+
+// PaletteView is the prompt as one frame draws it
+type PaletteView struct {
+	Open  bool
+	Entry string
+	Hints []string
+	Hint  int
+}
+
+func NewCommandPalette() *CommandPalette {
+	return &CommandPalette{hint: -1}
+}
+
+func (p *CommandPalette) View() PaletteView {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return PaletteView{Open: p.open, Entry: p.entry, Hints: p.hints, Hint: p.hint}
+}
+
+func (p *CommandPalette) Close() {
+	p.mutex.Lock()
+	p.open = false
+	p.mutex.Unlock()
+}
+
+// paletteHints returns the completions for the last word of a line: command
+// names for the first word, then whatever the command offers
+func (tui *TUI) paletteHints(entry string) []string {
+	words := strings.Fields(entry)
+	if len(words) == 0 || strings.HasSuffix(entry, " ") {
+		words = append(words, "")
+	}
+	var candidates []string
+	if len(words) == 1 {
+		for _, cmd := range paletteCommands {
+			candidates = append(candidates, cmd.Name)
+		}
+	} else if cmd, ok := findPaletteCommand(words[0]); ok && cmd.Complete != nil {
+		candidates = cmd.Complete(tui, words[1:])
+	}
+	word := strings.ToLower(words[len(words)-1])
+	var hints []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(strings.ToLower(candidate), word) {
+			hints = append(hints, candidate)
+		}
+	}
+	return hints
+}
+
+// commonPrefix returns the longest prefix all of words share
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// complete fills in the word being typed: the only hint followed by a space,
+// the hints' common prefix, or with nothing more in common each hint in turn
+func (p *CommandPalette) complete(back bool) {
+	if len(p.hints) == 0 {
+		return
+	}
+	stem := p.entry[:strings.LastIndex(p.entry, " ")+1]
+	word := p.entry[len(stem):]
+	if p.hint < 0 {
+		if len(p.hints) == 1 {
+			p.entry = stem + p.hints[0] + " "
+			return
+		}
+		if prefix := commonPrefix(p.hints); len(prefix) > len(word) {
+			p.entry = stem + prefix
+			return
+		}
+	}
+	switch {
+	case p.hint < 0 && back:
+		p.hint = len(p.hints) - 1
+	case back:
+		p.hint = cycleIndex(p.hint, -1, len(p.hints))
+	default:
+		p.hint = cycleIndex(p.hint, 1, len(p.hints))
+	}
+	p.entry = stem + p.hints[p.hint]
+}
+
+// OpenPalette starts typing a command at the : prompt
+func (tui *TUI) OpenPalette() {
+	p := tui.palette
+	p.mutex.Lock()
+	p.open = true
+	p.entry = ""
+	p.hint = -1
+	p.hints = tui.paletteHints("")
+	p.recall = len(p.history)
+	p.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+// handlePaletteKey edits the : prompt and reports whether ev was used up.
+// Tab completes, Up and Down recall earlier lines and Enter runs the line.
+func (tui *TUI) handlePaletteKey(ev *tcell.EventKey) bool {
+	p := tui.palette
+	p.mutex.Lock()
+	if !p.open {
+		p.mutex.Unlock()
+		return false
+	}
+	line := ""
+	cycling := false
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		p.open = false
+		line = strings.TrimSpace(p.entry)
+		if line != "" && (len(p.history) == 0 || p.history[len(p.history)-1] != line) {
+			p.history = append(p.history, line)
+			if len(p.history) > maxPaletteHistory {
+				p.history = p.history[1:]
+			}
+		}
+	case tcell.KeyEscape:
+		p.open = false
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if p.entry == "" {
+			p.open = false
+		}
+		_, size := utf8.DecodeLastRuneInString(p.entry)
+		p.entry = p.entry[:len(p.entry)-size]
+	case tcell.KeyTab, tcell.KeyBacktab:
+		p.complete(ev.Key() == tcell.KeyBacktab)
+		cycling = p.hint >= 0
+	case tcell.KeyUp, tcell.KeyDown:
+		if ev.Key() == tcell.KeyUp {
+			p.recall = max(p.recall-1, 0)
+		} else {
+			p.recall = min(p.recall+1, len(p.history))
+		}
+		p.entry = ""
+		if p.recall < len(p.history) {
+			p.entry = p.history[p.recall]
+		}
+	case tcell.KeyRune:
+		p.entry += string(ev.Rune())
+	}
+	// Hints hold still while Tab steps through them
+	if !cycling {
+		p.hint = -1
+		p.hints = tui.paletteHints(p.entry)
+	}
+	p.mutex.Unlock()
+
+	if line != "" {
+		tui.runPaletteCommand(line)
+	}
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+	return true
+}
+
+// runPaletteCommand runs one line from the : prompt and toasts the outcome
+func (tui *TUI) runPaletteCommand(line string) {
+	words := strings.Fields(line)
+	cmd, ok := findPaletteCommand(words[0])
+	if !ok {
+		globalToasts.Post("Unknown command: "+words[0], true)
+		return
+	}
+	message, err := cmd.Run(tui, words[1:])
+	if err != nil {
+		debugLog("Palette: %s: %v", line, err)
+		globalToasts.Post(cmd.Name+": "+err.Error(), true)
+		return
+	}
+	debugLog("Palette: %s", line)
+	globalToasts.Post(message, false)
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"net/http"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// RegisterPanelAPI exposes the data behind each TUI panel as JSON so external
+// dashboards can reuse the aggregation instead of re-implementing it
+func RegisterPanelAPI(ws *api.WebServer) {
+	ws.HandleFunc("GET /api/panels", func(w http.ResponseWriter, r *http.Request) {
+		conns := panelConnections()
+		api.WriteJSON(w, map[string]interface{}{
+			"top_countries": conns.TopCountries(api.QueryLimit(r, 5)),
+			"top_asns":      conns.TopASNs(api.QueryLimit(r, 5)),
+			"top_ips":       conns.TopIPs(api.QueryLimit(r, 10)),
+			"top_ports":     conns.TopPorts(api.QueryLimit(r, 8)),
+			"protocols":     conns.ProtocolBreakdown(),
+			"credentials":   panelCredentials(r),
+			"hourly":        panelHourly(),
+		})
+	})
+	ws.HandleFunc("GET /api/panels/top-countries", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelConnections().TopCountries(api.QueryLimit(r, 5)))
+	})
+	ws.HandleFunc("GET /api/panels/top-asns", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelConnections().TopASNs(api.QueryLimit(r, 5)))
+	})
+	ws.HandleFunc("GET /api/panels/top-ips", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelConnections().TopIPs(api.QueryLimit(r, 10)))
+	})
+	ws.HandleFunc("GET /api/panels/top-ports", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelConnections().TopPorts(api.QueryLimit(r, 8)))
+	})
+	ws.HandleFunc("GET /api/panels/protocols", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelConnections().ProtocolBreakdown())
+	})
+	ws.HandleFunc("GET /api/panels/credentials", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelCredentials(r))
+	})
+	ws.HandleFunc("GET /api/panels/hourly", func(w http.ResponseWriter, r *http.Request) {
+		api.WriteJSON(w, panelHourly())
+	})
+	ws.HandleFunc("GET /api/intel/stix", func(w http.ResponseWriter, r *http.Request) {
+		if GlobalIntel == nil {
+			api.WriteJSON(w, api.StixBundle(nil, api.DefaultIntelWindow, time.Now()))
+			return
+		}
+		api.WriteJSON(w, api.StixBundle(GlobalIntel.Sources(time.Time{}), GlobalIntel.Window(), time.Now()))
+	})
+	ws.HandleFunc("GET /api/alerts", func(w http.ResponseWriter, r *http.Request) {
+		alerts := []Alert{}
+		if GlobalAlertEngine != nil {
+			alerts = GlobalAlertEngine.Recent(api.QueryLimit(r, 50))
+		}
+		api.WriteJSON(w, alerts)
+	})
+	ws.HandleFunc("GET /api/findings", func(w http.ResponseWriter, r *http.Request) {
+		findings := []Finding{}
+		if GlobalFindings != nil {
+			findings = GlobalFindings.Recent(api.QueryLimit(r, 50))
+		}
+		api.WriteJSON(w, findings)
+	})
+	ws.HandleFunc("GET /api/hashes", func(w http.ResponseWriter, r *http.Request) {
+		hashes := []MalwareHash{}
+		if GlobalHashes != nil {
+			hashes = GlobalHashes.All()
+		}
+		api.WriteJSON(w, hashes)
+	})
+	ws.HandleFunc("GET /api/urls", func(w http.ResponseWriter, r *http.Request) {
+		urls := []AttackerURL{}
+		if globalURLs != nil {
+			urls = globalURLs.All()
+		}
+		api.WriteJSON(w, urls)
+	})
+}
+
+func panelConnections() ConnectionList {
+	if GlobalTUI == nil {
+		return nil
+	}
+	return GlobalTUI.dashboard.List().WithSessionHits().WithTags()
+}
+
+func panelCredentials(r *http.Request) interface{} {
+	if GlobalCredStats == nil {
+		return nil
+	}
+	return credentialSummary(GlobalCredStats, api.QueryLimit(r, 10))
+}
+
+func panelHourly() []HourStat {
+	if GlobalTUI == nil || GlobalTUI.stats == nil {
+		return nil
+	}
+	return GlobalTUI.stats.HourlySeries()
+}
@@ -0,0 +1,1352 @@
+package tui
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/globerender"
+	"SecKC-MHN-Globe/pkg/stats"
+	"github.com/gdamore/tcell/v2"
+)
+
+func (tui *TUI) renderDashboard(snap *FrameSnapshot) {
+	tui.mutex.RLock()
+	changed := tui.dashChanged
+	// Ages go stale as the clock runs, whether or not events arrive
+	if snap.View.Columns.Live() && time.Since(tui.dashDrawn) >= time.Second {
+		changed = true
+	}
+	tui.mutex.RUnlock()
+
+	if !changed {
+		return
+	}
+
+	dashboardHeight := snap.Height - 4
+
+	// The banner lane takes the top row when enabled
+	top := 0
+	if snap.View.ShowBanner {
+		top = 1
+	}
+
+	// Dynamic dashboard width: use remaining space after globe
+	dashboardWidth := snap.Width - snap.Globe.Width - 3 // 3 for separator and padding
+	if dashboardWidth < 50 {
+		dashboardWidth = 50
+	}
+	// No maximum limit - use all available space
+
+	separatorX := snap.Globe.Width + 1
+	startX := separatorX + 2
+
+	// Wrap to what is actually on screen; the dashboard may extend past the
+	// right edge on narrow terminals
+	wrap := snap.View.DashboardWrap
+	lineWidth := dashboardWidth
+	if wrap {
+		lineWidth = max(min(dashboardWidth, snap.Width-startX)-2, 20)
+	}
+	conns := snap.Rows
+	dashLines, rowConn := renderConnectionLines(conns, snap.Pins, snap.View.Columns, dashboardHeight-top, lineWidth, wrap, snap.View.Collapse == "expanded")
+	var visible []int
+	for y, i := range rowConn {
+		if i >= 0 && (y == 0 || rowConn[y-1] != i) {
+			visible = append(visible, i)
+		}
+	}
+	tui.mutex.Lock()
+	tui.dashRows, tui.dashVisible, tui.dashPins = conns, visible, snap.Pins
+	tui.mutex.Unlock()
+
+	for y := top; y < dashboardHeight; y++ {
+		tui.screen.SetContent(separatorX, y, ' ', nil, tcell.StyleDefault)
+		for x := 0; x < dashboardWidth && startX+x < snap.Width; x++ {
+			tui.screen.SetContent(startX+x, y, ' ', nil, tcell.StyleDefault)
+		}
+	}
+
+	for y := 0; y < snap.Height; y++ {
+		tui.screen.SetContent(separatorX, y, '|', nil,
+			tcell.StyleDefault.Foreground(snap.Theme.Separator))
+	}
+
+	// While scrolled back, the separator doubles as a scrollbar over history
+	if page := tui.dashboard.Capacity(); snap.ScrollBack > 0 && snap.HistoryLen > page {
+		track := dashboardHeight - top
+		thumb := max(track*page/snap.HistoryLen, 1)
+		thumbTop := top + (track-thumb)*(snap.HistoryLen-page-snap.ScrollBack)/(snap.HistoryLen-page)
+		for y := thumbTop; y < thumbTop+thumb; y++ {
+			tui.screen.SetContent(separatorX, y, '█', nil, tcell.StyleDefault.Foreground(snap.Theme.Dashboard))
+		}
+	}
+
+	headerStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Bold(true)
+	stormStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+	connectionStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats)
+	alertRowStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Bold(true).Reverse(true)
+	selectedRowStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Bold(true).Reverse(true)
+	pinnedRowStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard)
+
+	scrollOffset := snap.View.DashboardScroll
+	if wrap {
+		// Nothing runs off the edge in wrap mode, so there is nothing to scroll
+		scrollOffset = 0
+	}
+
+	for y, line := range dashLines {
+		if y >= dashboardHeight-top {
+			break
+		}
+		screenY := y + top
+
+		// Apply horizontal scroll - slice the line based on scroll offset
+		lineWidth := textWidth(line)
+		visibleLine := ""
+		scrollIndicatorLeft := ""
+		scrollIndicatorRight := ""
+
+		if lineWidth > 0 {
+			// Add left scroll indicator if scrolled right
+			if scrollOffset > 0 {
+				scrollIndicatorLeft = "◀"
+			}
+
+			// Calculate visible portion in cells
+			startCol := min(scrollOffset, lineWidth-1)
+			visibleWidth := dashboardWidth - 2 // -2 for scroll indicators
+			visibleLine = sliceCells(line, startCol, visibleWidth)
+
+			// Add right scroll indicator if there's more content
+			if startCol+visibleWidth < lineWidth {
+				scrollIndicatorRight = "▶"
+			}
+		}
+
+		line = scrollIndicatorLeft + visibleLine + scrollIndicatorRight
+
+		style := connectionStyle
+		if y <= 1 && snap.Storm.Active {
+			// A storm flashes the header and breaks itself down in place
+			// of the rule under the column names
+			style = stormStyle
+			if snap.Taken.UnixMilli()/stormFlashInterval.Milliseconds()%2 == 0 {
+				style = style.Reverse(true)
+			}
+			if y == 1 {
+				width := max(min(dashboardWidth, snap.Width-startX), 0)
+				line = padCells(clipCells(stormHeader(snap), width, ""), width)
+			}
+		} else if y <= 1 {
+			style = headerStyle
+		} else if i := rowConn[y]; i >= 0 && snap.View.Triaging && conns[i].IP == snap.View.TriageIP && conns[i].Time.Equal(snap.View.TriageTime) {
+			style = selectedRowStyle
+		} else if i >= 0 && snap.View.SearchRe != nil && sameRow(conns[i], snap.SearchRow) {
+			style = selectedRowStyle
+		} else if i >= 0 && conns[i].Alert != "" {
+			style = alertRowStyle
+		} else if i >= 0 && i < snap.Pins {
+			style = pinnedRowStyle
+		}
+
+		if startX < snap.Width {
+			tui.drawText(startX, screenY, line, style)
+
+			// Highlight what the search matched
+			if re := snap.View.SearchRe; re != nil && rowConn[y] >= 0 {
+				matchStyle := style.Bold(true).Underline(true)
+				if style != selectedRowStyle && style != alertRowStyle {
+					matchStyle = matchStyle.Foreground(snap.Theme.Attack)
+				}
+				for _, m := range re.FindAllStringIndex(line, -1) {
+					tui.drawText(startX+textWidth(line[:m[0]]), screenY, line[m[0]:m[1]], matchStyle)
+				}
+			}
+		}
+	}
+
+	tui.renderStatusBar(snap, dashboardHeight, startX, dashboardWidth)
+
+	tui.mutex.Lock()
+	tui.dashChanged = false
+	tui.dashDrawn = time.Now()
+	tui.mutex.Unlock()
+}
+
+// statusItem is one indicator of the status bar
+type statusItem struct {
+	text  string
+	style tcell.Style
+}
+
+// statusItems lists the health indicators on the left of the status bar:
+// frame rate, events per minute, age of the newest event, geocode cache hit
+// ratio, theme and charset, and whether the session is being recorded
+func (tui *TUI) statusItems(now time.Time, rate RateReading, theme *Theme, charset Charset) []statusItem {
+	okStyle := tcell.StyleDefault.Foreground(theme.StatusOk).Bold(true)
+	errorStyle := tcell.StyleDefault.Foreground(theme.StatusError).Bold(true)
+	dimStyle := tcell.StyleDefault.Foreground(theme.Separator)
+	statsStyle := tcell.StyleDefault.Foreground(theme.Stats)
+
+	var items []statusItem
+	if tui.frameRate != nil {
+		style := okStyle
+		if tui.frameRate.IsIdle() {
+			style = dimStyle
+		}
+		items = append(items, statusItem{tui.frameRate.StatusText(), style})
+	}
+
+	// Events per minute, the arrow colored by the trend
+	arrowStyle := dimStyle.Bold(true)
+	switch rate.Trend() {
+	case 1:
+		arrowStyle = arrowStyle.Foreground(theme.Attack)
+	case -1:
+		arrowStyle = arrowStyle.Foreground(theme.StatusOk)
+	}
+	items = append(items,
+		statusItem{fmt.Sprintf(" %d/min", rate.PerMinute), statsStyle},
+		statusItem{rate.Arrow() + " ", arrowStyle},
+		statusItem{sparkline(rate.Spark), statsStyle})
+
+	// A feed that has gone quiet for over five minutes is worth a look
+	ageStyle := statsStyle
+	if rate.Last.IsZero() || now.Sub(rate.Last) > 5*time.Minute {
+		ageStyle = errorStyle
+	}
+	items = append(items, statusItem{" last " + rate.Age(now), ageStyle})
+
+	if GlobalGeoIP != nil {
+		if ratio := GlobalGeoIP.HitRatio(); ratio >= 0 {
+			items = append(items, statusItem{fmt.Sprintf(" geo %d%%", int(ratio*100)), statsStyle})
+		}
+	}
+
+	items = append(items, statusItem{" " + theme.Name + "/" + CharsetNames[charset], dimStyle})
+
+	if tui.Recording() {
+		items = append(items, statusItem{" ● REC", errorStyle})
+	}
+	return items
+}
+
+// statusText is the status bar's left side as plain text, so the main loop
+// can redraw it when an indicator changes without new events
+func (tui *TUI) statusText(now time.Time) string {
+	var sb strings.Builder
+	for _, item := range tui.statusItems(now, globalRate.Reading(now), ActiveTheme(), tui.Globe().Charset) {
+		sb.WriteString(item.text)
+	}
+	return sb.String()
+}
+
+// renderStatusBar draws the line under the dashboard: health indicators on
+// the left, the stats view title in the middle when there is room, and the
+// camera modes and API endpoint badges on the right
+func (tui *TUI) renderStatusBar(snap *FrameSnapshot, y, startX, width int) {
+	if y < 0 || y >= snap.Height {
+		return
+	}
+
+	// Clear the line first
+	blankStyle := tcell.StyleDefault.Background(snap.Theme.Background)
+	for x := startX; x < startX+width && x < snap.Width; x++ {
+		tui.screen.SetContent(x, y, ' ', nil, blankStyle)
+	}
+
+	statusOkStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusOk).Bold(true)
+	statusErrorStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusError).Bold(true)
+
+	leftX := startX
+	for _, item := range tui.statusItems(snap.Taken, snap.Rate, snap.Theme, snap.Globe.Charset) {
+		tui.drawText(leftX, y, item.text, item.style)
+		leftX += textWidth(item.text)
+	}
+	leftX++
+
+	// Camera mode indicators on the right
+	var modes []string
+	if snap.View.ViewPreset != "" {
+		modes = append(modes, "["+strings.ToUpper(snap.View.ViewPreset)+"]")
+	}
+	if snap.View.Following {
+		modes = append(modes, "[FOLLOW]")
+	}
+	if snap.View.SearchPrompt {
+		modes = append(modes, "[/"+clipCells(snap.View.SearchEntry, 20, "")+"_]")
+	} else if snap.View.SearchQuery != "" {
+		modes = append(modes, fmt.Sprintf("[/%s %d/%d]", clipCells(snap.View.SearchQuery, 12, "…"), snap.View.SearchCursor+1, len(snap.View.SearchMatches)))
+	} else if snap.ScrollBack > 0 {
+		modes = append(modes, fmt.Sprintf("[-%d ROWS]", snap.ScrollBack))
+	}
+	if snap.View.TagFilter != "" {
+		modes = append(modes, "["+strings.ToUpper(snap.View.TagFilter)+"]")
+	}
+	if snap.View.RowFilter.Active() {
+		modes = append(modes, "["+clipCells(strings.ToUpper(snap.View.RowFilter.String()), 24, "…")+"]")
+	}
+	if snap.View.Triaging {
+		modes = append(modes, "[TRIAGE]")
+	}
+	if snap.Frozen {
+		modes = append(modes, fmt.Sprintf("[FROZEN +%d new]", snap.Held))
+	}
+	if snap.Role != "" {
+		modes = append(modes, snap.Role)
+	}
+	statusX := startX + width
+	if len(modes) > 0 {
+		text := strings.Join(modes, " ")
+		statusX -= textWidth(text)
+		tui.drawText(statusX, y, text, statusOkStyle)
+	}
+	// API endpoint badges go left of the modes. A refused key or an
+	// unreachable endpoint stops its feed, so those use the error color.
+	for i := len(snap.APIStatus) - 1; i >= 0; i-- {
+		badge := snap.APIStatus[i]
+		style := statusOkStyle
+		if badge.Bad {
+			style = statusErrorStyle
+		}
+		text := badge.Text
+		if statusX < startX+width {
+			text += " "
+		}
+		statusX -= textWidth(text)
+		tui.drawText(statusX, y, text, style)
+	}
+
+	// The title only shows in the gap between both sides
+	headerText := statsViewTitle(snap.View.StatsView, snap.View.StatsDay)
+	headerStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Bold(true)
+	if headerX := startX + (width-textWidth(headerText))/2; headerX >= leftX && headerX+textWidth(headerText) < statusX {
+		tui.drawText(headerX, y, headerText, headerStyle)
+	}
+}
+
+func (tui *TUI) renderStats(snap *FrameSnapshot) {
+	tui.mutex.RLock()
+	changed := tui.statsChanged
+	lastLeft := tui.statsLeft
+	tui.mutex.RUnlock()
+
+	if !changed {
+		return
+	}
+
+	spark := tui.stats.RenderSparkline()
+	statsLines := tui.stats.RenderBarGraph(24)
+	if view := snap.View.StatsView; view != statsViews[0] {
+		values := tui.stats.ViewSeries(view, snap.View.StatsDay)
+		spark = sparkline(values)
+		statsLines = renderBars(values, statsBarWidths[view])
+	}
+
+	// Views differ in width, so clear whatever the last one drew
+	clearStyle := tcell.StyleDefault.Background(snap.Theme.Background).Foreground(snap.Theme.Stats)
+	if lastLeft > 0 {
+		for y := snap.Height - 4; y < snap.Height; y++ {
+			for x := lastLeft; x < snap.Width-7; x++ {
+				tui.screen.SetContent(x, y, ' ', nil, clearStyle)
+			}
+		}
+	}
+
+	// Render sparkline first
+	left := snap.Width
+	if len(spark) > 0 {
+		sparkY := snap.Height - 4
+		sparkX := snap.Width - textWidth(spark) - 7
+		if sparkX > 0 && sparkY > 0 {
+			sparkStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats)
+			tui.drawText(sparkX, sparkY, spark, sparkStyle)
+			left = sparkX
+		}
+	}
+
+	if len(statsLines) == 0 || len(statsLines[0]) == 0 {
+		tui.mutex.Lock()
+		tui.statsLeft = left
+		tui.statsChanged = false
+		tui.mutex.Unlock()
+		return
+	}
+
+	chartWidth := len(statsLines[0])
+	startX := snap.Width - chartWidth - 7
+	if startX < 0 {
+		startX = 0
+	}
+	left = min(left, startX)
+
+	statsStartY := snap.Height - 3
+
+	for y := statsStartY; y < statsStartY+3 && y < snap.Height; y++ {
+		for x := startX; x < startX+chartWidth && x < snap.Width; x++ {
+			tui.screen.SetContent(x, y, ' ', nil, clearStyle)
+		}
+	}
+
+	textStyle := tcell.StyleDefault.Background(snap.Theme.Background).Foreground(snap.Theme.Stats)
+
+	for i, line := range statsLines {
+		y := statsStartY + i
+		if y >= snap.Height {
+			break
+		}
+		tui.drawText(startX, y, line, textStyle)
+	}
+
+	tui.mutex.Lock()
+	tui.statsLeft = left
+	tui.statsChanged = false
+	tui.mutex.Unlock()
+}
+
+func (tui *TUI) renderInfoPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowInfo || len(snap.Connections) == 0 {
+		return
+	}
+
+	// Most recent connection
+	conn := snap.Connections[len(snap.Connections)-1]
+
+	infoText := []string{
+		"╔═══════════════ ATTACK DETAILS ═══════════════╗",
+		fmt.Sprintf("║ IP:         %-32s ║", conn.IP),
+		"║ City:       " + padCells(truncateString(conn.City, 32), 32) + " ║",
+		"║ Country:    " + padCells(truncateString(conn.Country, 32), 32) + " ║",
+		"║ ASN:        " + padCells(truncateString(conn.ASN, 32), 32) + " ║",
+		"║ Org:        " + padCells(truncateString(conn.Org, 32), 32) + " ║",
+		"║ rDNS:       " + padCells(truncateString(conn.RDNS, 32), 32) + " ║",
+		"║ Protocol:   " + padCells(truncateString(protocolLabel(conn), 32), 32) + " ║",
+		"║ User:Pass:  " + padCells(truncateString(conn.Username+":"+conn.Password, 32), 32) + " ║",
+		fmt.Sprintf("║ Time:       %-32s ║", conn.Time.Format("2006-01-02 15:04:05")),
+	}
+	if feed := conn.Feed; feed != nil {
+		honeypot := feedIcon(feed.Honeypot, tui.caps.Unicode) + " " + feed.Honeypot + " (" + feed.Kind() + ")"
+		infoText = append(infoText, "║ Honeypot:   "+padCells(truncateString(honeypot, 32), 32)+" ║")
+		for _, line := range feed.DetailLines() {
+			infoText = append(infoText, "║ "+padCells(line[0]+":", 12)+padCells(truncateString(line[1], 32), 32)+" ║")
+		}
+	}
+	for _, hash := range connectionHashes(conn) {
+		if record, ok := snap.hashRecord(hash); ok && record.Status != "" {
+			infoText = append(infoText, "║ Malware:    "+padCells(truncateString(record.Verdict(), 32), 32)+" ║")
+		}
+	}
+	infoText = append(infoText,
+		"╠═══════════════════════════════════════════════╣",
+		"║ Press I to close                              ║",
+		"╚═══════════════════════════════════════════════╝",
+	)
+
+	startY := (snap.Height - len(infoText)) / 2
+	startX := (snap.Width - len(infoText[0])) / 2
+
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range infoText {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// feedIcon marks the kind of honeypot behind an event in the detail panel:
+// dionaea's malware, p0f's fingerprints and suricata's alerts
+func feedIcon(honeypot string, unicode bool) string {
+	var icon, ascii string
+	switch honeypot {
+	case "dionaea":
+		icon, ascii = "☣", "x"
+	case "p0f":
+		icon, ascii = "◈", "o"
+	case "suricata":
+		icon, ascii = "⚠", "!"
+	default:
+		icon, ascii = "•", "*"
+	}
+	if unicode {
+		return icon
+	}
+	return ascii
+}
+
+func truncateString(s string, maxLen int) string {
+	if s == "" {
+		return "N/A"
+	}
+	return clipCells(s, maxLen, "...")
+}
+
+func (tui *TUI) renderStatsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowStats {
+		return
+	}
+
+	// Aggregate top 5 countries and ASNs from dashboard connections
+	topCountries := snap.Connections.TopCountries(5)
+	topASNs := snap.Connections.TopASNs(5)
+
+	statsText := []string{
+		"╔═══════ TOP ATTACKERS ═══════╗",
+		"║ TOP COUNTRIES               ║",
+	}
+
+	for i, entry := range topCountries {
+		line := fmt.Sprintf("║ %d. %s %4d ║", i+1, padCells(truncateString(entry.Name, 18), 18), entry.Count)
+		statsText = append(statsText, line)
+	}
+
+	statsText = append(statsText, "║                             ║")
+	statsText = append(statsText, "║ TOP ASNs                    ║")
+
+	for i, entry := range topASNs {
+		line := fmt.Sprintf("║ %d. %s %4d ║", i+1, padCells(truncateString(entry.Name, 18), 18), entry.Count)
+		statsText = append(statsText, line)
+	}
+
+	statsText = append(statsText, "╠═════════════════════════════╣")
+	statsText = append(statsText, "║ Press S to close            ║")
+	statsText = append(statsText, "╚═════════════════════════════╝")
+
+	startY := 2
+	startX := snap.Width - 33
+
+	statsStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background)
+
+	for i, line := range statsText {
+		y := startY + i
+		if y >= 0 && y < snap.Height && startX >= 0 {
+			tui.drawText(startX, y, line, statsStyle)
+		}
+	}
+}
+
+// portsBarWidth is the length of the longest bar in the top ports panel
+const portsBarWidth = 7
+
+func (tui *TUI) renderPortsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowPorts {
+		return
+	}
+
+	entries := snap.Connections.TopPorts(8)
+
+	portsText := []string{
+		"╔═════════ TOP PORTS ═════════╗",
+		fmt.Sprintf("║ %5s %-7s %-*s %5s ║", "Port", "Service", portsBarWidth, "", "Hits"),
+	}
+
+	for _, entry := range entries {
+		bar := max(entry.Count*portsBarWidth/entries[0].Count, 1)
+		service := entry.Service
+		if service == "" {
+			service = "?"
+		}
+		line := fmt.Sprintf("║ %5d %s %s%s %5d ║", entry.Port, padCells(clipCells(service, 7, ""), 7),
+			strings.Repeat("█", bar), strings.Repeat(" ", portsBarWidth-bar), entry.Count)
+		portsText = append(portsText, line)
+	}
+	if len(entries) == 0 {
+		portsText = append(portsText, "║ No destination ports yet    ║")
+	}
+
+	portsText = append(portsText, "╠═════════════════════════════╣")
+	portsText = append(portsText, "║ Press @ to close            ║")
+	portsText = append(portsText, "╚═════════════════════════════╝")
+
+	// Stack under the top attackers panel when both are open
+	startY := 2
+	if snap.View.ShowStats {
+		startY += 7 + len(snap.Connections.TopCountries(5)) + len(snap.Connections.TopASNs(5))
+	}
+	startX := snap.Width - 33
+
+	statsStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background)
+
+	for i, line := range portsText {
+		y := startY + i
+		if y >= 0 && y < snap.Height && startX >= 0 {
+			tui.drawText(startX, y, line, statsStyle)
+		}
+	}
+}
+
+func (tui *TUI) renderTopIPsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowTopIPs {
+		return
+	}
+
+	// Aggregate top 10 IP addresses from dashboard connections
+	entries := snap.Connections.TopIPs(10)
+
+	// Build panel
+	ipsText := []string{
+		"╔═══════════════════════════════════════════════╗",
+		"║          TOP ATTACKING IP ADDRESSES           ║",
+		"╠═══════════════════════════════════════════════╣",
+		fmt.Sprintf("║ %-3s %-15s %-5s %-6s %-12s ║", "#", "IP", "Now", "Total", "Org"),
+	}
+
+	for i, entry := range entries {
+		org := "Unknown"
+		if entry.Org != "" {
+			org = truncateString(entry.Org, 12)
+		}
+		line := fmt.Sprintf("║ %2d. %-15s x%-4d %-6d %s ║", i+1, entry.IP, entry.Count, entry.SessionCount, padCells(org, 12))
+		ipsText = append(ipsText, line)
+	}
+
+	// Padding
+	for len(ipsText) < 16 {
+		ipsText = append(ipsText, "║                                               ║")
+	}
+
+	ipsText = append(ipsText, "╠═══════════════════════════════════════════════╣")
+	ipsText = append(ipsText, "║ Press P to close                              ║")
+	ipsText = append(ipsText, "╚═══════════════════════════════════════════════╝")
+
+	startY := (snap.Height - len(ipsText)) / 2
+	startX := (snap.Width - len(ipsText[0])) / 2
+
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range ipsText {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// legendSwatch is one glyph drawn in its on-globe color followed by a label
+type legendSwatch struct {
+	glyph string
+	style tcell.Style
+	label string
+}
+
+// densityRamp lists the land characters of a charset from sparse to dense
+func densityRamp(charset Charset) string {
+	var ramp []rune
+	for d := 0.06; d <= 1.1; d += 0.04 {
+		r := globerender.DensityToChar(d, charset)
+		if r != ' ' && r != '⠀' && (len(ramp) == 0 || ramp[len(ramp)-1] != r) {
+			ramp = append(ramp, r)
+		}
+	}
+	return string(ramp)
+}
+
+// renderLegendPanel explains the on-globe symbols in the globe's top-left
+// corner, which the sphere never covers
+func (tui *TUI) renderLegendPanel(snap *FrameSnapshot, protocolGlyphs bool) {
+	if !snap.View.ShowLegend {
+		return
+	}
+
+	attackStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+	glyphStyle := tcell.StyleDefault.Foreground(snap.Theme.AttackGlyph).Background(snap.Theme.Background).Bold(true)
+	arcStyle := tcell.StyleDefault.Foreground(snap.Theme.ArcTrail).Background(snap.Theme.Background)
+	landStyle := tcell.StyleDefault.Foreground(snap.Theme.Globe).Background(snap.Theme.Background)
+	okStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusOk).Background(snap.Theme.Background).Bold(true)
+	errStyle := tcell.StyleDefault.Foreground(snap.Theme.StatusError).Background(snap.Theme.Background).Bold(true)
+	textStyle := tcell.StyleDefault.Foreground(snap.Theme.Text).Background(snap.Theme.Background)
+	borderStyle := tcell.StyleDefault.Foreground(snap.Theme.Separator).Background(snap.Theme.Background)
+
+	markerGlyph, arcGlyph := "*", "·"
+	if snap.Globe.UseSubCell(protocolGlyphs) {
+		markerGlyph, arcGlyph = "⠃", "⠁"
+	}
+	honeypotStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background).Bold(true)
+	crowdStyle := tcell.StyleDefault.Foreground(crowdColor()).Background(snap.Theme.Background).Bold(true)
+	rows := [][]legendSwatch{
+		{{string(honeypotGlyph(tui.caps.Unicode)), honeypotStyle, "Honeypot"}},
+		{{markerGlyph, attackStyle, "Attack origin"}},
+		{{"✸", attackStyle, "Repeat"}, {"█", attackStyle, "Heavy repeat"}},
+		{{string(globerender.CrowdGlyph(globerender.CrowdSize)), crowdStyle, fmt.Sprintf("%d+ here", globerender.CrowdSize)},
+			{string(globerender.CrowdGlyph(globerender.CrowdLarge)), crowdStyle, fmt.Sprintf("%d+ here", globerender.CrowdLarge)}},
+		{{arcGlyph, arcStyle, "Arc to honeypot, fades"}},
+	}
+	if GlobalFootprints != nil && GlobalFootprints.TTL() > 0 && tui.layers.Visible("footprints") {
+		footprintStyle := tcell.StyleDefault.Foreground(mixColor(snap.Theme.Background, snap.Theme.Attack, 0.5)).Background(snap.Theme.Background)
+		rows = append(rows, []legendSwatch{{"·", footprintStyle, "Recent origin, fades"}})
+	}
+	if protocolGlyphs {
+		rows = append(rows,
+			[]legendSwatch{{"#", glyphStyle, "SSH"}, {"~", glyphStyle, "Telnet"}, {"@", glyphStyle, "Mail"}},
+			[]legendSwatch{{":", glyphStyle, "HTTP"}, {"%", glyphStyle, "FTP"}, {"$", glyphStyle, "SMB"}},
+			[]legendSwatch{{"&", glyphStyle, "RDP/VNC"}, {"=", glyphStyle, "Database"}},
+			[]legendSwatch{{"^", glyphStyle, "IoT/ICS"}, {"!", glyphStyle, "Other"}},
+		)
+	} else {
+		// Protocol glyphs replace the ~ of approximate markers, Telnet's own
+		rows = slices.Insert(rows, 2, []legendSwatch{{string(approxMarker), attackStyle, "Country only, approx."}})
+	}
+	if snap.Shades != nil {
+		var scale []legendSwatch
+		for level := 1; level <= choroplethLevels; level++ {
+			style := tcell.StyleDefault.Foreground(choroplethColor(level)).Background(snap.Theme.Background)
+			scale = append(scale, legendSwatch{string(globerender.ShadeGlyph(level, snap.Globe.Charset)), style, ""})
+		}
+		scale[len(scale)-1].label = fmt.Sprintf("1 → %d", snap.ShadeMax)
+		rows = append(rows, scale, []legendSwatch{{"", textStyle, "countries: few → most"}})
+	}
+	rows = append(rows,
+		[]legendSwatch{{"+", okStyle, "Feed OK"}, {"-", errStyle, "Feed down"}},
+		[]legendSwatch{{densityRamp(snap.Globe.Charset), landStyle, ""}},
+		[]legendSwatch{{"", textStyle, "land: sparse → dense"}},
+	)
+
+	const innerWidth = 26
+	startX, startY := 0, 0
+	tui.drawText(startX, startY, "┌─ LEGEND "+strings.Repeat("─", innerWidth-9)+"┐", borderStyle)
+	for i, row := range rows {
+		y := startY + 1 + i
+		tui.drawText(startX, y, "│"+strings.Repeat(" ", innerWidth)+"│", borderStyle)
+		x := startX + 2
+		for _, swatch := range row {
+			tui.drawText(x, y, swatch.glyph, swatch.style)
+			x += len([]rune(swatch.glyph))
+			if swatch.label != "" {
+				if swatch.glyph != "" {
+					x++
+				}
+				tui.drawText(x, y, swatch.label, textStyle)
+				x += len([]rune(swatch.label)) + 2
+			}
+		}
+	}
+	y := startY + 1 + len(rows)
+	tui.drawText(startX, y, "│ "+fmt.Sprintf("%-*s", innerWidth-1, "Press B to close")+"│", borderStyle)
+	tui.drawText(startX, y+1, "└"+strings.Repeat("─", innerWidth)+"┘", borderStyle)
+}
+
+func (tui *TUI) renderCredHistPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowCredHist || snap.CredBins == nil {
+		return
+	}
+
+	bins, sorted := snap.CredBins, snap.CredSorted
+
+	total := 0
+	for _, c := range sorted {
+		total += c
+	}
+
+	maxBin := 0
+	for _, b := range bins {
+		if b > maxBin {
+			maxBin = b
+		}
+	}
+
+	p50 := percentile(sorted, 50)
+	p90 := percentile(sorted, 90)
+	p99 := percentile(sorted, 99)
+
+	verdict := credentialShape(sorted, total)
+
+	barWidth := 20
+	histText := []string{
+		"╔═══════════════════════════════════════════╗",
+		"║      ATTEMPTS PER CREDENTIAL PAIR         ║",
+		fmt.Sprintf("║ %-41s ║", fmt.Sprintf("Window: %s  Pairs: %d  Tries: %d", GlobalCredStats.Window(), len(sorted), total)),
+		"╠═══════════════════════════════════════════╣",
+	}
+
+	for i, label := range stats.HistogramLabels {
+		bar := ""
+		if maxBin > 0 {
+			bar = strings.Repeat("█", bins[i]*barWidth/maxBin)
+			if bins[i] > 0 && bar == "" {
+				bar = "▏"
+			}
+		}
+		// Mark which bins hold the percentile values
+		marker := ""
+		if len(sorted) > 0 {
+			lower := 1
+			if i > 0 {
+				lower = stats.HistogramBins[i-1] + 1
+			}
+			for _, pv := range []struct {
+				name string
+				val  int
+			}{{"50", p50}, {"90", p90}, {"99", p99}} {
+				if pv.val >= lower && pv.val <= stats.HistogramBins[i] {
+					marker += "p" + pv.name
+				}
+			}
+		}
+		line := fmt.Sprintf("║ %5s │%-20s│%5d %-8s║", label, bar, bins[i], truncateMarker(marker, 8))
+		histText = append(histText, line)
+	}
+
+	histText = append(histText, "╠═══════════════════════════════════════════╣")
+	histText = append(histText, fmt.Sprintf("║ %-41s ║", fmt.Sprintf("p50: %d  p90: %d  p99: %d", p50, p90, p99)))
+	histText = append(histText, fmt.Sprintf("║ Shape: %-34s ║", verdict))
+	histText = append(histText, "║ Press K to close                          ║")
+	histText = append(histText, "╚═══════════════════════════════════════════╝")
+
+	startY := (snap.Height - len(histText)) / 2
+	startX := (snap.Width - len([]rune(histText[0]))) / 2
+
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range histText {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// renderDiagnosticsPanel shows the supervised background workers and memory use
+func (tui *TUI) renderDiagnosticsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowDiagnostics {
+		return
+	}
+
+	diagText := []string{
+		"╔═════════════════════════════════════════════╗",
+		"║             BACKGROUND WORKERS              ║",
+		"╠═════════════════════════════════════════════╣",
+		"║ Worker         State    Rst  Age/Last error ║",
+	}
+
+	for _, w := range api.Workers.Status() {
+		detail := api.ShortDuration(snap.Taken.Sub(w.Since))
+		if w.LastError != "" {
+			detail += " " + w.LastError
+		}
+		line := fmt.Sprintf("║ %-14s %-8s %3d  %-14s ║", truncateMarker(w.Name, 14), w.State, w.Restarts, truncateMarker(detail, 14))
+		diagText = append(diagText, line)
+	}
+
+	rss := currentRSS()
+	sheds := 0
+	if GlobalMemWatchdog != nil {
+		_, sheds = GlobalMemWatchdog.Stats()
+	}
+	cacheSize, cacheMax := 0, 0
+	if GlobalGeoIP != nil {
+		cacheSize, cacheMax = GlobalGeoIP.GetCacheStats()
+	}
+
+	diagText = append(diagText, "╠═════════════════════════════════════════════╣")
+	diagText = append(diagText, fmt.Sprintf("║ %-43s ║", fmt.Sprintf("Goroutines: %d  RSS: %d MB  Sheds: %d", runtime.NumGoroutine(), rss>>20, sheds)))
+	diagText = append(diagText, fmt.Sprintf("║ %-43s ║", fmt.Sprintf("Geo cache: %d/%d", cacheSize, cacheMax)))
+	for _, client := range api.Clients {
+		if skew, known := client.Skew(); known && skew.Abs() >= api.ClockSkewLogged {
+			line := "Clock skew: server " + api.FormatSkew(skew)
+			if len(api.Clients) > 1 {
+				line = client.Label() + ": clock " + api.FormatSkew(skew)
+			}
+			diagText = append(diagText, fmt.Sprintf("║ %-43s ║", truncateMarker(line, 43)))
+		}
+		if transport := client.Streaming(); transport != "" {
+			line := "Events: streaming (" + transport + ")"
+			if len(api.Clients) > 1 {
+				line = client.Label() + ": streaming (" + transport + ")"
+			}
+			diagText = append(diagText, fmt.Sprintf("║ %-43s ║", truncateMarker(line, 43)))
+		}
+	}
+	for _, ts := range []*api.TransferStats{api.EventsTransfer, api.StatsTransfer} {
+		c := ts.Counts()
+		line := fmt.Sprintf("%-6s %5d req %5d 304 %7s/%s", c.Name, c.Requests, c.NotModified, api.FormatBytes(c.WireBytes), api.FormatBytes(c.BodyBytes))
+		diagText = append(diagText, fmt.Sprintf("║ %-43s ║", truncateMarker(line, 43)))
+	}
+	diagText = append(diagText, fmt.Sprintf("║ %-43s ║", "Terminal: "+tui.caps.String()))
+	diagText = append(diagText, fmt.Sprintf("║ %-43s ║", truncateMarker("Version: "+api.BuildVersion(), 43)))
+	diagText = append(diagText, "║ Press D to close                            ║")
+	diagText = append(diagText, "╚═════════════════════════════════════════════╝")
+
+	startY := (snap.Height - len(diagText)) / 2
+	startX := (snap.Width - len([]rune(diagText[0]))) / 2
+
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Stats).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range diagText {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+func (tui *TUI) renderAlertsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowAlerts {
+		return
+	}
+
+	alertText := []string{
+		"╔══════════════════════════════════════════════════════════╗",
+		"║                        ALERTS LOG                        ║",
+		"╠══════════════════════════════════════════════════════════╣",
+	}
+
+	if GlobalAlertEngine == nil || GlobalAlertEngine.Path() == "" {
+		alertText = append(alertText, "║ No rules loaded (start with --alert-rules <file>)        ║")
+	} else if len(snap.Alerts) == 0 {
+		alertText = append(alertText, "║ No alerts yet                                            ║")
+	} else {
+		alertText = append(alertText, "║ Time     Rule         Source IP       Prot Country       ║")
+		maxRows := snap.Height - 8
+		for i, alert := range snap.Alerts {
+			if i >= maxRows || i >= 15 {
+				break
+			}
+			line := fmt.Sprintf("║ %-8s %s %-15s %s %s ║",
+				alert.Time.Format("15:04:05"),
+				padCells(truncateMarker(alert.Rule, 12), 12),
+				alert.IP,
+				padCells(truncateMarker(alert.Protocol, 4), 4),
+				padCells(truncateMarker(alert.Country, 13), 13))
+			alertText = append(alertText, line)
+		}
+	}
+
+	alertText = append(alertText, "║ Press A to close                                         ║")
+	alertText = append(alertText, "╚══════════════════════════════════════════════════════════╝")
+
+	startY := (snap.Height - len(alertText)) / 2
+	startX := (snap.Width - len([]rune(alertText[0]))) / 2
+
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range alertText {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// renderFindingsPanel lists the credential findings, newest first
+func (tui *TUI) renderFindingsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowFindings {
+		return
+	}
+
+	const innerWidth = 70
+	row := func(text string) string {
+		return "║ " + fitCells(text, innerWidth-2) + " ║"
+	}
+	border := func(left, right string) string {
+		return left + strings.Repeat("═", innerWidth) + right
+	}
+
+	lines := []string{border("╔", "╗"), row("CREDENTIAL FINDINGS"), border("╠", "╣")}
+	switch {
+	case GlobalFindings == nil:
+		lines = append(lines, row("Credential analysis is off"))
+	case len(snap.Findings) == 0:
+		lines = append(lines, row("No unusual credential activity yet"))
+	default:
+		lines = append(lines, row(fmt.Sprintf("%-8s %-17s %-15s %s", "Time", "Finding", "Source IP", "Detail")))
+		for i, finding := range snap.Findings {
+			if i >= snap.Height-8 || i >= 15 {
+				break
+			}
+			lines = append(lines, row(fmt.Sprintf("%-8s %-17s %-15s %s",
+				finding.Time.Format("15:04:05"), finding.Kind, finding.IP, finding.Detail)))
+		}
+	}
+	lines = append(lines, row("Press ! to close"), border("╚", "╝"))
+
+	startY := (snap.Height - len(lines)) / 2
+	startX := (snap.Width - textWidth(lines[0])) / 2
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+	for i, line := range lines {
+		if y := startY + i; y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// hashRecord finds a hash among the snapshot's malware hashes
+func (snap *FrameSnapshot) hashRecord(hash string) (MalwareHash, bool) {
+	hash = strings.ToLower(hash)
+	for _, record := range snap.Hashes {
+		if record.Hash == hash {
+			return record, true
+		}
+	}
+	return MalwareHash{}, false
+}
+
+// renderHashesPanel lists the file hashes seen this session, newest first,
+// with what VirusTotal or MalwareBazaar know of them
+func (tui *TUI) renderHashesPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowHashes {
+		return
+	}
+
+	const innerWidth = 70
+	row := func(text string) string {
+		return "║ " + fitCells(text, innerWidth-2) + " ║"
+	}
+	border := func(left, right string) string {
+		return left + strings.Repeat("═", innerWidth) + right
+	}
+
+	lines := []string{border("╔", "╗"), row(fmt.Sprintf("MALWARE HASHES  (%d unique this session)", len(snap.Hashes))), border("╠", "╣")}
+	if len(snap.Hashes) == 0 {
+		lines = append(lines, row("No downloads or captured binaries yet"))
+	} else {
+		lines = append(lines, row(fmt.Sprintf("%-16s %5s %-8s %s", "Hash", "Seen", "First", "Verdict")))
+		for i, record := range snap.Hashes {
+			if i >= snap.Height-9 || i >= 15 {
+				break
+			}
+			lines = append(lines, row(fmt.Sprintf("%-16s %5d %-8s %s",
+				clipCells(record.Hash, 16, "…"), record.Count, record.FirstSeen.Format("15:04:05"), record.Verdict())))
+		}
+	}
+	services := "off (needs --vt-key or --bazaar-key, and no --offline)"
+	if GlobalHashes != nil {
+		if names := GlobalHashes.Services(); len(names) > 0 {
+			services = strings.Join(names, ", ")
+		}
+	}
+	lines = append(lines, border("╠", "╣"), row("Lookups: "+services), row("Press Z to close"), border("╚", "╝"))
+
+	startY := (snap.Height - len(lines)) / 2
+	startX := (snap.Width - textWidth(lines[0])) / 2
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+	for i, line := range lines {
+		if y := startY + i; y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// renderURLsPanel lists the URLs attackers fetched this session, defanged,
+// newest first. It scrolls with the arrows once there are more than fit.
+func (tui *TUI) renderURLsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowURLs {
+		return
+	}
+
+	const innerWidth = 70
+	row := func(text string) string {
+		return "║ " + fitCells(text, innerWidth-2) + " ║"
+	}
+	border := func(left, right string) string {
+		return left + strings.Repeat("═", innerWidth) + right
+	}
+
+	lines := []string{border("╔", "╗"), row(fmt.Sprintf("ATTACKER URLS  (%d unique this session)", len(snap.URLs))), border("╠", "╣")}
+	if len(snap.URLs) == 0 {
+		lines = append(lines, row("No URLs seen yet"))
+	} else {
+		visible := max(min(snap.Height-10, 15), 1)
+		first := max(min(snap.View.URLScroll, len(snap.URLs)-visible), 0)
+		last := min(first+visible, len(snap.URLs))
+		lines = append(lines, row(fmt.Sprintf("%5s %-8s %s", "Seen", "First", "URL (defanged)")))
+		for _, record := range snap.URLs[first:last] {
+			lines = append(lines, row(fmt.Sprintf("%5d %-8s %s",
+				record.Count, record.FirstSeen.Format("15:04:05"), clipCells(record.Defanged, innerWidth-17, "…"))))
+		}
+		if len(snap.URLs) > visible {
+			lines = append(lines, row(fmt.Sprintf("Showing %d-%d of %d", first+1, last, len(snap.URLs))))
+		}
+	}
+	lines = append(lines, border("╠", "╣"), row("↑/↓ scroll · :export urls [file] · Press & to close"), border("╚", "╝"))
+
+	startY := (snap.Height - len(lines)) / 2
+	startX := (snap.Width - textWidth(lines[0])) / 2
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Attack).Background(snap.Theme.Background).Bold(true)
+	for i, line := range lines {
+		if y := startY + i; y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// handleURLsKey scrolls the attacker URLs panel. The panel also clamps the
+// scroll when drawn, to keep the last page full.
+func (tui *TUI) handleURLsKey(key tcell.Key) {
+	tui.state.mutex.Lock()
+	switch key {
+	case tcell.KeyUp:
+		tui.state.urlScroll = max(tui.state.urlScroll-1, 0)
+	case tcell.KeyDown:
+		tui.state.urlScroll++
+	case tcell.KeyPgUp:
+		tui.state.urlScroll = max(tui.state.urlScroll-10, 0)
+	case tcell.KeyPgDn:
+		tui.state.urlScroll += 10
+	}
+	if globalURLs != nil {
+		tui.state.urlScroll = min(tui.state.urlScroll, max(globalURLs.Len()-1, 0))
+	}
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+// ToggleURLsPanel opens the attacker URLs panel at the newest URL
+func (tui *TUI) ToggleURLsPanel() {
+	tui.state.mutex.Lock()
+	tui.state.showURLs = !tui.state.showURLs
+	tui.state.urlScroll = 0
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+func (tui *TUI) renderSessionPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowSession {
+		return
+	}
+
+	const innerWidth = 70
+	row := func(text string) string {
+		return "║ " + fitCells(text, innerWidth-2) + " ║"
+	}
+	border := func(left, right string) string {
+		return left + strings.Repeat("═", innerWidth) + right
+	}
+
+	sessions := snap.Connections.InteractiveSessions()
+	lines := []string{border("╔", "╗")}
+
+	if len(sessions) == 0 {
+		lines = append(lines,
+			row("SESSION DETAIL"),
+			border("╠", "╣"),
+			row("No sessions with shell interaction on screen yet"),
+		)
+	} else {
+		index := min(snap.View.SessionCursor, len(sessions)-1)
+		conn := sessions[index]
+		detail := conn.Session
+
+		lines = append(lines,
+			row(fmt.Sprintf("SESSION DETAIL  (%d of %d, newest first)", index+1, len(sessions))),
+			border("╠", "╣"),
+			row(fmt.Sprintf("Source:   %s  %s  %s", conn.IP, conn.Country, protocolLabel(conn))),
+			row(fmt.Sprintf("Session:  %s", detail.ID)),
+			row(fmt.Sprintf("Started:  %s  Ended: %s", orDash(detail.Start), orDash(detail.End))),
+			row(fmt.Sprintf("Login:    %s:%s  Client: %s", conn.Username, conn.Password, orDash(detail.Version))),
+			border("╠", "╣"),
+		)
+
+		body := detail.DetailLines()
+		var detections []string
+		for _, hash := range detail.Hashes {
+			if record, ok := snap.hashRecord(hash); ok && record.Status != "" {
+				detections = append(detections, "  "+clipCells(hash, 16, "…")+"  "+record.Verdict())
+			}
+		}
+		if len(detections) > 0 {
+			body = append(body, "", fmt.Sprintf("DETECTIONS (%d)", len(detections)))
+			body = append(body, detections...)
+		}
+		maxBody := max(snap.Height-len(lines)-4, 3)
+		scroll := min(snap.View.SessionScroll, max(len(body)-maxBody, 0))
+		end := min(scroll+maxBody, len(body))
+		for _, line := range body[scroll:end] {
+			lines = append(lines, row(line))
+		}
+		if len(body) > maxBody {
+			lines = append(lines, row(fmt.Sprintf("-- lines %d-%d of %d --", scroll+1, end, len(body))))
+		}
+	}
+
+	lines = append(lines,
+		border("╠", "╣"),
+		row("↑/↓ Scroll  ←/→ Session  Enter/Esc Close"),
+		border("╚", "╝"),
+	)
+
+	startY := max((snap.Height-len(lines))/2, 0)
+	startX := max((snap.Width-innerWidth-2)/2, 0)
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range lines {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// handleSessionKey scrolls the session detail panel and steps between sessions
+func (tui *TUI) handleSessionKey(key tcell.Key) {
+	sessions := tui.dashboard.List().InteractiveSessions()
+
+	tui.state.mutex.Lock()
+	switch key {
+	case tcell.KeyUp:
+		tui.state.sessionScroll = max(tui.state.sessionScroll-1, 0)
+	case tcell.KeyDown:
+		tui.state.sessionScroll++
+	case tcell.KeyPgUp:
+		tui.state.sessionScroll = max(tui.state.sessionScroll-10, 0)
+	case tcell.KeyPgDn:
+		tui.state.sessionScroll += 10
+	case tcell.KeyLeft:
+		tui.state.sessionCursor = max(tui.state.sessionCursor-1, 0)
+		tui.state.sessionScroll = 0
+	case tcell.KeyRight:
+		tui.state.sessionCursor++
+		tui.state.sessionScroll = 0
+	}
+	// Stop at the last session and the end of its lines, so a held key
+	// cannot run either past what the panel can show
+	tui.state.sessionCursor = min(tui.state.sessionCursor, max(len(sessions)-1, 0))
+	lines := 0
+	if len(sessions) > 0 {
+		detail := sessions[tui.state.sessionCursor].Session
+		// Detections add a heading and one line per hash below the commands
+		lines = len(detail.DetailLines()) + len(detail.Hashes) + 2
+	}
+	tui.state.sessionScroll = min(tui.state.sessionScroll, max(lines-1, 0))
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+// ToggleSessionPanel opens the session detail panel on the newest session
+func (tui *TUI) ToggleSessionPanel() {
+	tui.state.mutex.Lock()
+	tui.state.showSession = !tui.state.showSession
+	tui.state.sessionCursor = 0
+	tui.state.sessionScroll = 0
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// credentialShape classifies the attack: many pairs with few tries each is a
+// spray, a small number of pairs with many tries is targeted brute force
+func credentialShape(sorted []int, total int) string {
+	if len(sorted) == 0 {
+		return "Not enough data"
+	}
+	if percentile(sorted, 90) <= 2 && len(sorted) >= 10 {
+		return "Credential spray"
+	}
+	if sorted[len(sorted)-1] >= 10 && sorted[len(sorted)-1]*2 >= total {
+		return "Targeted brute force"
+	}
+	return "Mixed activity"
+}
+
+func truncateMarker(s string, maxLen int) string {
+	return clipCells(s, maxLen, "")
+}
+
+// helpLines builds the help panel from the active key bindings, leaving
+// out actions that have no key
+func (tui *TUI) helpLines() []string {
+	type row struct{ keys, text string }
+	var rows []row
+	for _, hr := range keyHelpRows {
+		if label := tui.keys.Label(hr.actions...); label != "" {
+			rows = append(rows, row{label, hr.text})
+		}
+	}
+	rows = append(rows, row{"1-9", "Region view presets"}, row{"Ctrl+O", "Operator unlock / relock"}, row{"Ctrl+C", "Exit"})
+
+	keysWidth, textWidthMax := 0, 0
+	for _, r := range rows {
+		keysWidth = max(keysWidth, textWidth(r.keys))
+		textWidthMax = max(textWidthMax, textWidth(r.text))
+	}
+	inner := keysWidth + 3 + textWidthMax + 2
+	title := "KEYBOARD CONTROLS"
+	pad := (inner - len(title)) / 2
+	lines := []string{
+		"╔" + strings.Repeat("═", inner) + "╗",
+		"║" + strings.Repeat(" ", pad) + title + strings.Repeat(" ", inner-pad-len(title)) + "║",
+		"╠" + strings.Repeat("═", inner) + "╣",
+	}
+	for _, r := range rows {
+		lines = append(lines, "║ "+padCells(padCells(r.keys, keysWidth)+" - "+r.text, inner-2)+" ║")
+	}
+	return append(lines, "╚"+strings.Repeat("═", inner)+"╝")
+}
+
+// commandGuide is the one line key summary, e.g. "T:Theme L:Light ..."
+func (tui *TUI) commandGuide() string {
+	var parts []string
+	for _, hr := range keyHelpRows {
+		if label := tui.keys.Label(hr.actions...); label != "" {
+			parts = append(parts, label+":"+hr.guide)
+		}
+		if hr.actions[0] == "reset_view" {
+			parts = append(parts, "1-9:Region")
+		}
+	}
+	return strings.Join(append(parts, "^O:Operator"), " ")
+}
+
+func (tui *TUI) renderHelpPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowHelp {
+		return
+	}
+
+	helpText := tui.helpLines()
+	startY := (snap.Height - len(helpText)) / 2
+	startX := (snap.Width - textWidth(helpText[0])) / 2
+
+	helpStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background)
+
+	for i, line := range helpText {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, helpStyle)
+		}
+	}
+}
+
+func (tui *TUI) renderCommandGuide(snap *FrameSnapshot) {
+	y := snap.Height - 1
+	if y < 0 || y >= snap.Height {
+		return
+	}
+
+	// Always clear the bottom line first
+	blankStyle := tcell.StyleDefault.Background(snap.Theme.Background)
+	for x := 0; x < snap.Width; x++ {
+		tui.screen.SetContent(x, y, ' ', nil, blankStyle)
+	}
+
+	if !snap.View.ShowCommands {
+		return
+	}
+
+	// Command guide at bottom of screen
+	guideLines := []string{tui.commandGuide()}
+
+	guideStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background).Bold(true)
+
+	// Center the guide text
+	text := guideLines[0]
+	text = clipCells(text, snap.Width, "")
+	startX := (snap.Width - textWidth(text)) / 2
+	if startX < 0 {
+		startX = 0
+	}
+	tui.drawText(startX, y, text, guideStyle)
+}
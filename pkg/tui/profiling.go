@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"testing"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/globerender"
+)
+
+// StartPprof serves the net/http/pprof handlers on their own listener, kept
+// off the web dashboard so profiles are never exposed to its viewers, behind
+// the web server's access policy. The command line handler is left out since
+// the flags carry API keys and passwords.
+func StartPprof(addr string, policy *api.AccessPolicy) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	server := &http.Server{Addr: addr, Handler: policy.Wrap("pprof", mux), ReadHeaderTimeout: 10 * time.Second}
+
+	api.Workers.Go("pprof", func(stop <-chan struct{}) error {
+		go func() {
+			<-stop
+			server.Close()
+		}()
+		debugLog("pprof: Listening on %s", addr)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+}
+
+// benchSizes are the terminal sizes --bench-render times, from a laptop
+// window up to a wall display
+var benchSizes = [][2]int{{80, 24}, {120, 40}, {200, 60}, {320, 90}}
+
+// RunRenderBenchmark times a globe frame at each bench size and charset, with
+// lighting, curved arcs and a busy set of markers, and prints one line per
+// case in the format of go test -bench
+func RunRenderBenchmark(out io.Writer, aspect float64) {
+	rng := rand.New(rand.NewSource(1))
+	markers := make([]globerender.Marker, 200)
+	for i := range markers {
+		markers[i] = globerender.Marker{Lat: rng.Float64()*140 - 70, Lon: rng.Float64()*360 - 180, Glyph: '*'}
+	}
+	arcs := make([]globerender.Arc, 50)
+	for i := range arcs {
+		arcs[i] = globerender.Arc{SrcLat: markers[i].Lat, SrcLon: markers[i].Lon, DstLat: 39.1, DstLon: -94.6}
+	}
+
+	procs := runtime.GOMAXPROCS(0)
+	fmt.Fprintf(out, "goos: %s\ngoarch: %s\n", runtime.GOOS, runtime.GOARCH)
+	for _, size := range benchSizes {
+		for charset, name := range CharsetNames {
+			globe := globerender.New(size[0], size[1], aspect, Charset(charset))
+			globe.Lighting = true
+			result := testing.Benchmark(func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					globe.Raster(float64(i)*0.01, markers, arcs, "curved", false, nil)
+				}
+			})
+			fmt.Fprintf(out, "BenchmarkRender/%dx%d/%s-%d\t%s\t%s\n", size[0], size[1], name, procs, result.String(), result.MemString())
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"SecKC-MHN-Globe/pkg/stats"
+)
+
+// Live events are counted per second by a stats.RateGauge for the events
+// per minute gauge in the status line, its trend arrow and its sparkline
+type RateReading = stats.RateReading
+
+// sparkline draws values as block heights scaled to the largest
+func sparkline(values []int) string {
+	sparkChars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	maxVal := slices.Max(values)
+	var sb strings.Builder
+	for _, v := range values {
+		idx := 0
+		if maxVal > 0 {
+			idx = v * (len(sparkChars) - 1) / maxVal
+		}
+		sb.WriteRune(sparkChars[idx])
+	}
+	return sb.String()
+}
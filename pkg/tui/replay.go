@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+// builtinDemoEvents is a sample capture shipped in the binary for
+// --demo-replay builtin: about 20 minutes of honeypot traffic from addresses
+// in the RFC 5737 documentation ranges, already geolocated
+//
+//go:embed demo-events.ndjson
+var builtinDemoEvents []byte
+
+// maxReplayGap shortens quiet stretches of a capture during replay
+const maxReplayGap = 10 * time.Second
+
+// DemoReplay plays back enriched events, one JSON object per line in the
+// format the hpfeeds publisher sends. Locations come from the events, so a
+// replay needs no network access.
+type DemoReplay struct {
+	events []api.EnrichedEvent
+	times  []time.Time
+}
+
+// LoadDemoReplay reads a capture from a file, or the embedded sample when
+// source is "builtin"
+func LoadDemoReplay(source string) (*DemoReplay, error) {
+	data := builtinDemoEvents
+	if source != "builtin" {
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, err
+		}
+	}
+
+	dr := &DemoReplay{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var event api.EnrichedEvent
+		if err := json.Unmarshal(text, &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", source, line, err)
+		}
+		when, err := time.Parse(time.RFC3339, event.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: bad timestamp %q", source, line, event.Timestamp)
+		}
+		if event.SrcIP == "" {
+			continue
+		}
+		dr.events = append(dr.events, event)
+		dr.times = append(dr.times, when)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	if len(dr.events) < 2 {
+		return nil, fmt.Errorf("%s: need at least 2 events", source)
+	}
+	if !sort.SliceIsSorted(dr.times, func(i, j int) bool { return dr.times[i].Before(dr.times[j]) }) {
+		return nil, fmt.Errorf("%s: events are not in time order", source)
+	}
+	return dr, nil
+}
+
+// Len is the number of events in the capture
+func (dr *DemoReplay) Len() int {
+	return len(dr.events)
+}
+
+// Start backfills the first half of the capture so the display opens fully
+// populated, then plays the rest at its recorded pace and loops
+func (dr *DemoReplay) Start(dashboard *Dashboard) {
+	half := len(dr.events) / 2
+	shift := time.Since(dr.times[half])
+	for i := 0; i < half; i++ {
+		dr.add(dashboard, i, dr.times[i].Add(shift), false)
+	}
+	debugLog("Replay: Backfilled %d of %d events", half, len(dr.events))
+
+	api.Workers.Go("demo-replay", func(stop <-chan struct{}) error {
+		for i := half; ; i = (i + 1) % len(dr.events) {
+			gap := time.Second // Pause before the capture starts over
+			if i > 0 {
+				gap = min(dr.times[i].Sub(dr.times[i-1]), maxReplayGap)
+			}
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(gap):
+			}
+			dr.add(dashboard, i, time.Now(), true)
+		}
+	})
+}
+
+func (dr *DemoReplay) add(dashboard *Dashboard, i int, when time.Time, live bool) {
+	event := dr.events[i]
+	if GlobalGeoIP != nil && (event.Latitude != 0 || event.Longitude != 0) {
+		GlobalGeoIP.Seed(event.SrcIP, api.LocationInfo{
+			City:      event.City,
+			Country:   event.Country,
+			Latitude:  event.Latitude,
+			Longitude: event.Longitude,
+			ASN:       event.ASN,
+			Org:       event.Org,
+			RDNS:      event.RDNS,
+			Valid:     true,
+		})
+	}
+	if GlobalCoverage != nil {
+		GlobalCoverage.Record("demo", event.Protocol, when)
+	}
+	// Older captures have no ports; assume the service's usual one
+	port := event.DestPort
+	if port == 0 {
+		port = mhn.ServicePort(event.Protocol)
+	}
+	// A protocol guessed when the capture was made is guessed again, so it
+	// stays marked as a guess
+	protocol := event.Protocol
+	if event.Inferred {
+		protocol = ""
+	}
+	if live {
+		dashboard.AddConnection(event.SrcIP, event.Username, event.Password, protocol, port)
+	} else {
+		dashboard.Backfill(when, event.SrcIP, event.Username, event.Password, protocol, port, nil)
+	}
+}
@@ -0,0 +1,188 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sessionReportTop is how many rows each ranking of a session report keeps
+const sessionReportTop = 20
+
+// SessionReport is the session's aggregates as E and :export stats write
+// them, for after-action notes
+type SessionReport struct {
+	Generated   time.Time   `json:"generated"`
+	Since       time.Time   `json:"since"`
+	Events      int         `json:"events"`
+	TopIPs      []IPStat    `json:"top_ips"`
+	Countries   []StatEntry `json:"top_countries"`
+	ASNs        []StatEntry `json:"top_asns"`
+	Credentials []StatEntry `json:"top_credentials"`
+	Ports       []PortStat  `json:"top_ports"`
+	Protocols   []StatEntry `json:"protocols"`
+	Hourly      []HourStat  `json:"hourly"`
+}
+
+// BuildSessionReport aggregates the session history rows, oldest first, and
+// the rolling 24 hour graph into a report
+func BuildSessionReport(rows ConnectionList, hourly []HourStat, now time.Time) SessionReport {
+	report := SessionReport{
+		Generated:   now,
+		Events:      len(rows),
+		TopIPs:      rows.WithSessionHits().TopIPs(sessionReportTop),
+		Countries:   rows.TopCountries(sessionReportTop),
+		ASNs:        rows.TopASNs(sessionReportTop),
+		Credentials: rows.TopCredentials(sessionReportTop),
+		Ports:       rows.TopPorts(sessionReportTop),
+		Protocols:   rows.ProtocolBreakdown(),
+		Hourly:      hourly,
+	}
+	if len(rows) > 0 {
+		report.Since = rows[0].Time
+	}
+	return report
+}
+
+// sessionReportBase is the timestamped name reports get in the working
+// directory when none is given
+func sessionReportBase() string {
+	return "seckc-globe-report-" + time.Now().Format("20060102-150405")
+}
+
+// hourStart is the local time the graph bar at offset began
+func (r SessionReport) hourStart(offset int) time.Time {
+	return r.Generated.Truncate(time.Hour).Add(time.Duration(offset-23) * time.Hour)
+}
+
+// WriteSessionReport writes the report to base.json, base.csv and base.md
+// and returns the paths written
+func WriteSessionReport(r SessionReport, base string) ([]string, error) {
+	files := []struct {
+		ext    string
+		render func(SessionReport) ([]byte, error)
+	}{
+		{".json", func(r SessionReport) ([]byte, error) { return json.MarshalIndent(r, "", "  ") }},
+		{".csv", sessionReportCSV},
+		{".md", func(r SessionReport) ([]byte, error) { return []byte(sessionReportMarkdown(r)), nil }},
+	}
+	var paths []string
+	for _, f := range files {
+		data, err := f.render(r)
+		if err != nil {
+			return paths, err
+		}
+		if err := os.WriteFile(base+f.ext, data, 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, base+f.ext)
+	}
+	return paths, nil
+}
+
+// sessionReportCSV lays every ranking out as section,rank,name,count,detail
+// rows so one sheet holds the whole report
+func sessionReportCSV(r SessionReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"section", "rank", "name", "count", "detail"})
+	entries := func(section string, stats []StatEntry) {
+		for i, e := range stats {
+			w.Write([]string{section, strconv.Itoa(i + 1), e.Name, strconv.Itoa(e.Count), ""})
+		}
+	}
+	for i, ip := range r.TopIPs {
+		w.Write([]string{"ip", strconv.Itoa(i + 1), ip.IP, strconv.Itoa(ip.Count),
+			strings.TrimSpace(strings.Join([]string{ip.Country, ip.ASN, ip.Org}, " "))})
+	}
+	entries("country", r.Countries)
+	entries("asn", r.ASNs)
+	entries("credential", r.Credentials)
+	for i, p := range r.Ports {
+		w.Write([]string{"port", strconv.Itoa(i + 1), strconv.Itoa(p.Port), strconv.Itoa(p.Count), p.Service})
+	}
+	entries("protocol", r.Protocols)
+	for _, h := range r.Hourly {
+		w.Write([]string{"hour", strconv.Itoa(h.Offset + 1), r.hourStart(h.Offset).Format("2006-01-02 15:04"), strconv.Itoa(h.Count), ""})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// sessionReportMarkdown renders the report as headed tables
+func sessionReportMarkdown(r SessionReport) string {
+	var sb strings.Builder
+	sb.WriteString("# SecKC MHN Globe session report\n\n")
+	fmt.Fprintf(&sb, "Generated %s", r.Generated.Format("2006-01-02 15:04:05 MST"))
+	if !r.Since.IsZero() {
+		fmt.Fprintf(&sb, " from %d events since %s", r.Events, r.Since.Format("2006-01-02 15:04:05"))
+	} else {
+		sb.WriteString(" before any events arrived")
+	}
+	sb.WriteString(".\n")
+
+	table := func(title string, header []string, rows [][]string) {
+		fmt.Fprintf(&sb, "\n## %s\n\n", title)
+		if len(rows) == 0 {
+			sb.WriteString("None seen.\n")
+			return
+		}
+		sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		sb.WriteString(strings.Repeat("|---", len(header)) + "|\n")
+		for _, row := range rows {
+			for i := range row {
+				row[i] = markdownCell(row[i])
+			}
+			sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	}
+	entries := func(title, name string, stats []StatEntry) {
+		var rows [][]string
+		for i, e := range stats {
+			rows = append(rows, []string{strconv.Itoa(i + 1), e.Name, strconv.Itoa(e.Count)})
+		}
+		table(title, []string{"#", name, "Events"}, rows)
+	}
+
+	var rows [][]string
+	for i, ip := range r.TopIPs {
+		rows = append(rows, []string{strconv.Itoa(i + 1), ip.IP, strconv.Itoa(ip.Count), ip.Country, ip.ASN, ip.Org})
+	}
+	table("Top attacking IPs", []string{"#", "IP", "Events", "Country", "ASN", "Org"}, rows)
+	entries("Top countries", "Country", r.Countries)
+	entries("Top ASNs", "ASN", r.ASNs)
+	entries("Top credentials", "Username:password", r.Credentials)
+	rows = nil
+	for i, p := range r.Ports {
+		rows = append(rows, []string{strconv.Itoa(i + 1), strconv.Itoa(p.Port), p.Service, strconv.Itoa(p.Count)})
+	}
+	table("Top ports", []string{"#", "Port", "Service", "Events"}, rows)
+	entries("Protocols", "Protocol", r.Protocols)
+
+	rows = nil
+	counts := make([]int, len(r.Hourly))
+	for i, h := range r.Hourly {
+		counts[i] = h.Count
+		rows = append(rows, []string{r.hourStart(h.Offset).Format("Jan 2 15:00"), strconv.Itoa(h.Count)})
+	}
+	title := "Last 24 hours"
+	if len(counts) > 0 {
+		title += " " + sparkline(counts)
+	}
+	table(title, []string{"Hour", "Events"}, rows)
+	return sb.String()
+}
+
+// markdownCell keeps a value from breaking out of its table cell
+func markdownCell(value string) string {
+	value = strings.NewReplacer("|", "\\|", "\n", " ", "\r", " ").Replace(value)
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// SaveScreenshot writes the captured screen as plain text and as an SVG that
+// preserves theme colors, returning the base filename used. Names carry
+// milliseconds so shots taken in the same second do not overwrite each other
+func SaveScreenshot(cells [][]RecordedCell, dir string) (string, error) {
+	base := filepath.Join(dir, "seckc-globe-"+time.Now().Format("20060102-150405.000"))
+
+	var text strings.Builder
+	for _, row := range cells {
+		line := make([]rune, len(row))
+		for x, cell := range row {
+			line[x] = cell.Rune
+			if line[x] == 0 {
+				line[x] = ' '
+			}
+		}
+		text.WriteString(strings.TrimRight(string(line), " "))
+		text.WriteString("\n")
+	}
+	if err := os.WriteFile(base+".txt", []byte(text.String()), 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(base+".svg", []byte(renderSVG(cells)), 0644); err != nil {
+		return "", err
+	}
+
+	return base, nil
+}
+
+func renderSVG(cells [][]RecordedCell) string {
+	const cellW, cellH = 9, 18
+	height := len(cells)
+	width := 0
+	if height > 0 {
+		width = len(cells[0])
+	}
+
+	theme := ActiveTheme()
+	defaultBG := tcellToRGBA(theme.Background, color.RGBA{0, 0, 0, 255})
+	defaultFG := tcellToRGBA(theme.Text, color.RGBA{255, 255, 255, 255})
+	hex := func(c color.RGBA) string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width*cellW, height*cellH, width*cellW, height*cellH)
+	fmt.Fprintf(&sb, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(defaultBG))
+	sb.WriteString(`<g font-family="Menlo, Consolas, 'DejaVu Sans Mono', monospace" font-size="15" xml:space="preserve">` + "\n")
+
+	for y, row := range cells {
+		// Backgrounds that differ from the theme background, merged into runs
+		for x := 0; x < len(row); {
+			_, bg, _ := row[x].Style.Decompose()
+			bgColor := tcellToRGBA(bg, defaultBG)
+			run := 1
+			for x+run < len(row) {
+				_, nextBG, _ := row[x+run].Style.Decompose()
+				if tcellToRGBA(nextBG, defaultBG) != bgColor {
+					break
+				}
+				run++
+			}
+			if bgColor != defaultBG {
+				fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x*cellW, y*cellH, run*cellW, cellH, hex(bgColor))
+			}
+			x += run
+		}
+
+		// Foreground text, one element per cell so columns stay aligned
+		for x, cell := range row {
+			if cell.Rune == 0 || cell.Rune == ' ' {
+				continue
+			}
+			fg, _, attr := cell.Style.Decompose()
+			weight := ""
+			if attr&tcell.AttrBold != 0 {
+				weight = ` font-weight="bold"`
+			}
+			var escaped strings.Builder
+			xml.EscapeText(&escaped, []byte(string(cell.Rune)))
+			fmt.Fprintf(&sb, `<text x="%d" y="%d" fill="%s"%s>%s</text>`+"\n",
+				x*cellW, y*cellH+cellH-4, hex(tcellToRGBA(fg, defaultFG)), weight, escaped.String())
+		}
+	}
+
+	sb.WriteString("</g>\n</svg>\n")
+	return sb.String()
+}
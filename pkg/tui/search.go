@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// compileSearch treats a query as a case-insensitive regular expression, or
+// as plain text when it is not a valid one
+func compileSearch(query string) *regexp.Regexp {
+	if re, err := regexp.Compile("(?i)" + query); err == nil {
+		return re
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+}
+
+// OpenSearch starts typing a query at the / prompt
+func (tui *TUI) OpenSearch() {
+	tui.state.mutex.Lock()
+	tui.state.searchPrompt = true
+	tui.state.searchEntry = ""
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+// handleSearchKey edits the / prompt and reports whether ev was used up.
+// Enter searches, or repeats the last search when nothing was typed, so
+// rows that arrived since are found too.
+func (tui *TUI) handleSearchKey(ev *tcell.EventKey) bool {
+	tui.state.mutex.Lock()
+	if !tui.state.searchPrompt {
+		tui.state.mutex.Unlock()
+		return false
+	}
+	entry := tui.state.searchEntry
+	switch ev.Key() {
+	case tcell.KeyEnter:
+		tui.state.searchPrompt = false
+		if entry == "" {
+			entry = tui.state.searchQuery
+		}
+	case tcell.KeyEscape:
+		tui.state.searchPrompt = false
+		entry = ""
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		_, size := utf8.DecodeLastRuneInString(entry)
+		tui.state.searchEntry = entry[:len(entry)-size]
+	case tcell.KeyRune:
+		tui.state.searchEntry += string(ev.Rune())
+	}
+	submitted := !tui.state.searchPrompt && entry != ""
+	tui.state.mutex.Unlock()
+
+	if submitted {
+		tui.runSearch(entry)
+	}
+	tui.MarkDashboardChanged()
+	return true
+}
+
+// runSearch finds query in every row of the session history and selects
+// the newest match
+func (tui *TUI) runSearch(query string) {
+	rows := tui.dashboard.List()
+	if GlobalHistory != nil {
+		rows, _ = GlobalHistory.Page(0, GlobalHistory.Len())
+	}
+	re := compileSearch(query)
+	matches := rows.Search(re)
+	debugLog("Search: %q matched %d of %d rows", query, len(matches), len(rows))
+
+	tui.state.mutex.Lock()
+	tui.state.searchQuery, tui.state.searchRe = query, re
+	tui.state.searchMatches = matches
+	tui.state.searchCursor = len(matches) - 1
+	tui.state.mutex.Unlock()
+}
+
+// StepSearch moves the selection to an older (negative) or newer match.
+// Single steps wrap around like n/N in less; longer ones stop at the ends.
+func (tui *TUI) StepSearch(step int) {
+	tui.state.mutex.Lock()
+	if n := len(tui.state.searchMatches); n > 0 {
+		if step == 1 || step == -1 {
+			tui.state.searchCursor = cycleIndex(tui.state.searchCursor, step, n)
+		} else {
+			tui.state.searchCursor = min(max(tui.state.searchCursor+step, 0), n-1)
+		}
+	}
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+// ClearSearch returns the dashboard to every row
+func (tui *TUI) ClearSearch() {
+	tui.state.mutex.Lock()
+	tui.state.searchQuery, tui.state.searchRe = "", nil
+	tui.state.searchMatches = nil
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
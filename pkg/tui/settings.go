@@ -0,0 +1,1136 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/globerender"
+	"github.com/BurntSushi/toml"
+	"github.com/gdamore/tcell/v2"
+)
+
+var CharsetNames = []string{"ascii", "blocks", "braille"}
+
+var QualityNames = []string{"normal", "high"}
+
+var ArcStyles = []string{"curved", "straight", "off"}
+
+func ParseCharset(name string) Charset {
+	switch name {
+	case "braille":
+		return CharsetBraille
+	case "blocks":
+		return CharsetBlocks
+	default:
+		return CharsetASCII
+	}
+}
+
+type settingItem struct {
+	label  string
+	value  func(tui *TUI) string
+	adjust func(tui *TUI, dir int)
+}
+
+// cycleIndex steps through n options, wrapping in both directions
+func cycleIndex(current, dir, n int) int {
+	return ((current+dir)%n + n) % n
+}
+
+// IndexOf returns the position of value in list, or -1 when it is missing
+func IndexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return -1
+}
+
+var settingsItems = []settingItem{
+	{
+		label: "Theme",
+		value: func(tui *TUI) string { return ActiveTheme().Name },
+		adjust: func(tui *TUI, dir int) {
+			tui.SetTheme(themeOrder[cycleIndex(IndexOf(themeOrder, ActiveTheme().Name), dir, len(themeOrder))])
+		},
+	},
+	{
+		label: "Charset",
+		value: func(tui *TUI) string { return CharsetNames[tui.Globe().Charset] },
+		adjust: func(tui *TUI, dir int) {
+			tui.SetCharset(Charset(cycleIndex(int(tui.Globe().Charset), dir, len(CharsetNames))))
+		},
+	},
+	{
+		label: "Graticule",
+		value: func(tui *TUI) string { return onOff(tui.layers.Visible("graticule")) },
+		adjust: func(tui *TUI, dir int) {
+			tui.SetLayers("graticule=" + onOff(!tui.layers.Visible("graticule")))
+		},
+	},
+	{
+		label: "Projection",
+		value: func(tui *TUI) string { return tui.Globe().Projection.String() },
+		adjust: func(tui *TUI, dir int) {
+			tui.SetProjection(globerender.Projection(cycleIndex(int(tui.Globe().Projection), dir, len(globerender.ProjectionNames))))
+		},
+	},
+	{
+		label: "Quality",
+		value: func(tui *TUI) string {
+			if tui.Globe().Supersample {
+				return "high"
+			}
+			return "normal"
+		},
+		adjust: func(tui *TUI, dir int) {
+			tui.SetQuality(!tui.Globe().Supersample)
+		},
+	},
+	{
+		label: "Arc style",
+		value: func(tui *TUI) string { return currentArcStyle() },
+		adjust: func(tui *TUI, dir int) {
+			tui.SetArcStyle(ArcStyles[cycleIndex(IndexOf(ArcStyles, currentArcStyle()), dir, len(ArcStyles))])
+		},
+	},
+	{
+		label: "Trail (ms)",
+		value: func(tui *TUI) string { return fmt.Sprintf("%d", currentTrailMS()) },
+		adjust: func(tui *TUI, dir int) {
+			SetTrailMS(currentTrailMS() + dir*100)
+		},
+	},
+	{
+		label: "Lighting",
+		value: func(tui *TUI) string { return onOff(tui.Lighting()) },
+		adjust: func(tui *TUI, dir int) {
+			tui.SetLighting(!tui.Lighting())
+		},
+	},
+	{
+		label: "CRT",
+		value: func(tui *TUI) string {
+			enabled, _, _ := tui.crt.Settings()
+			return onOff(enabled)
+		},
+		adjust: func(tui *TUI, dir int) {
+			enabled, glow, curve := tui.crt.Settings()
+			tui.crt.Set(!enabled, glow, curve)
+		},
+	},
+	{
+		label: "Glow",
+		value: func(tui *TUI) string {
+			_, glow, _ := tui.crt.Settings()
+			return fmt.Sprintf("%d", glow)
+		},
+		adjust: func(tui *TUI, dir int) {
+			enabled, glow, curve := tui.crt.Settings()
+			tui.crt.Set(enabled, cycleIndex(glow, dir, 4), curve)
+		},
+	},
+	{
+		label: "Curvature",
+		value: func(tui *TUI) string {
+			_, _, curve := tui.crt.Settings()
+			return onOff(curve)
+		},
+		adjust: func(tui *TUI, dir int) {
+			enabled, glow, curve := tui.crt.Settings()
+			tui.crt.Set(enabled, glow, !curve)
+		},
+	},
+	{
+		label: "Rain density",
+		value: func(tui *TUI) string {
+			if !tui.rain.Enabled() {
+				return "off"
+			}
+			return fmt.Sprintf("%d", tui.rain.Density())
+		},
+		adjust: func(tui *TUI, dir int) {
+			density := tui.rain.Density()
+			if !tui.rain.Enabled() {
+				density = 0
+			}
+			tui.SetRainDensity(density + dir)
+		},
+	},
+	{
+		label: "Rain mask",
+		value: func(tui *TUI) string {
+			if tui.rain.Masked() {
+				return "ocean only"
+			}
+			return "everywhere"
+		},
+		adjust: func(tui *TUI, dir int) {
+			tui.rain.SetMasked(!tui.rain.Masked())
+			tui.MarkGlobeChanged()
+		},
+	},
+	{
+		label: "Dashboard wrap",
+		value: func(tui *TUI) string {
+			tui.state.mutex.RLock()
+			defer tui.state.mutex.RUnlock()
+			return onOff(tui.state.dashboardWrap)
+		},
+		adjust: func(tui *TUI, dir int) {
+			tui.ToggleDashboardWrap()
+		},
+	},
+	{
+		label: "Poll interval",
+		value: func(tui *TUI) string {
+			if api.PrimaryClient == nil {
+				return "n/a"
+			}
+			return api.PrimaryClient.PollInterval().String()
+		},
+		adjust: func(tui *TUI, dir int) {
+			if api.PrimaryClient == nil {
+				return
+			}
+			interval := api.PrimaryClient.PollInterval() + time.Duration(dir)*time.Second
+			if interval >= time.Second && interval <= 300*time.Second {
+				for _, client := range api.Clients {
+					client.SetPollInterval(interval)
+				}
+			}
+		},
+	},
+}
+
+// CycleCollapse steps the dashboard through collapseModes
+func (tui *TUI) CycleCollapse() string {
+	tui.state.mutex.Lock()
+	mode := CollapseModes[(slices.Index(CollapseModes, tui.state.collapse)+1)%len(CollapseModes)]
+	tui.state.collapse = mode
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+	return mode
+}
+
+// ToggleDashboardWrap switches between wrapping long rows and scrolling them
+func (tui *TUI) ToggleDashboardWrap() {
+	tui.state.mutex.Lock()
+	tui.state.dashboardWrap = !tui.state.dashboardWrap
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func currentArcStyle() string {
+	if GlobalArcManager == nil {
+		return "off"
+	}
+	GlobalArcManager.mutex.RLock()
+	defer GlobalArcManager.mutex.RUnlock()
+	return GlobalArcManager.arcStyle
+}
+
+func currentTrailMS() int {
+	if GlobalArcManager == nil {
+		return 0
+	}
+	GlobalArcManager.mutex.RLock()
+	defer GlobalArcManager.mutex.RUnlock()
+	return GlobalArcManager.trailMS
+}
+
+func SetTrailMS(trailMS int) {
+	if GlobalArcManager == nil || trailMS < 100 || trailMS > 10000 {
+		return
+	}
+	GlobalArcManager.mutex.Lock()
+	GlobalArcManager.trailMS = trailMS
+	GlobalArcManager.mutex.Unlock()
+}
+
+func (tui *TUI) SetTheme(name string) {
+	theme, exists := Themes[name]
+	if !exists {
+		return
+	}
+	tui.state.mutex.Lock()
+	tui.state.currentTheme = IndexOf(themeOrder, name)
+	SetActiveTheme(theme)
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+	tui.MarkStatsChanged()
+}
+
+func (tui *TUI) SetCharset(charset Charset) {
+	if !tui.caps.Unicode {
+		charset = CharsetASCII
+	}
+	tui.mutex.Lock()
+	tui.globe.Charset = charset
+	tui.mutex.Unlock()
+	tui.MarkGlobeChanged()
+}
+
+// SetLayers applies a --layers spec and redraws the whole screen, since a
+// hidden layer leaves nothing behind to cover what it drew
+func (tui *TUI) SetLayers(spec string) error {
+	if err := tui.layers.ApplyLayerSpec(spec); err != nil {
+		return err
+	}
+	tui.screen.Clear()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+	tui.MarkStatsChanged()
+	return nil
+}
+
+// SetProjection lays the globe out as a sphere, one of the flat maps or two
+// hemispheres. The nudge is dropped since it means a different place in
+// each; zoom is kept.
+func (tui *TUI) SetProjection(projection globerender.Projection) {
+	tui.mutex.Lock()
+	if tui.globe.Projection != projection {
+		tui.globe.Projection = projection
+		tui.globe.NudgeX, tui.globe.NudgeY = 0, 0
+	}
+	tui.mutex.Unlock()
+	tui.screen.Clear()
+	tui.MarkGlobeChanged()
+}
+
+// Lighting reports whether land is shaded by the sun
+func (tui *TUI) Lighting() bool {
+	tui.mutex.RLock()
+	defer tui.mutex.RUnlock()
+	return tui.globe.Lighting
+}
+
+// SetLighting turns day/night shading of the land on or off
+func (tui *TUI) SetLighting(on bool) {
+	tui.mutex.Lock()
+	tui.globe.Lighting = on
+	tui.mutex.Unlock()
+	tui.MarkGlobeChanged()
+}
+
+// SetQuality switches land anti-aliasing between the neighbour bleed and 2x2
+// supersampling
+func (tui *TUI) SetQuality(high bool) {
+	tui.mutex.Lock()
+	tui.globe.Supersample = high
+	tui.mutex.Unlock()
+	tui.MarkGlobeChanged()
+}
+
+func (tui *TUI) SetArcStyle(style string) {
+	if GlobalArcManager == nil {
+		return
+	}
+	tui.state.mutex.Lock()
+	tui.state.showArcs = style != "off"
+	if style != "off" {
+		tui.state.savedArcStyle = style
+	}
+	tui.state.mutex.Unlock()
+
+	GlobalArcManager.mutex.Lock()
+	GlobalArcManager.arcStyle = style
+	GlobalArcManager.mutex.Unlock()
+	tui.MarkGlobeChanged()
+}
+
+// SetRainDensity rebuilds the rain columns; a density of 0 disables the rain
+func (tui *TUI) SetRainDensity(density int) {
+	if density < 0 || density > 10 {
+		return
+	}
+	tui.rain.SetDensity(density)
+	tui.rain.SetEnabled(density > 0)
+	tui.MarkGlobeChanged()
+}
+
+func (tui *TUI) ToggleSettings() {
+	tui.state.mutex.Lock()
+	tui.state.showSettings = !tui.state.showSettings
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+func (tui *TUI) handleSettingsKey(key tcell.Key) {
+	tui.state.mutex.Lock()
+	cursor := tui.state.settingsCursor
+	switch key {
+	case tcell.KeyUp:
+		cursor = cycleIndex(cursor, -1, len(settingsItems))
+	case tcell.KeyDown:
+		cursor = cycleIndex(cursor, 1, len(settingsItems))
+	}
+	tui.state.settingsCursor = cursor
+	tui.state.mutex.Unlock()
+
+	switch key {
+	case tcell.KeyLeft:
+		settingsItems[cursor].adjust(tui, -1)
+	case tcell.KeyRight:
+		settingsItems[cursor].adjust(tui, 1)
+	}
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+func (tui *TUI) renderSettingsPanel(snap *FrameSnapshot) {
+	if !snap.View.ShowSettings {
+		return
+	}
+
+	lines := []string{
+		"╔═══════════════════════════════════════╗",
+		"║              SETTINGS                 ║",
+		"╠═══════════════════════════════════════╣",
+	}
+	for i, item := range settingsItems {
+		marker := " "
+		if i == snap.View.SettingsCursor {
+			marker = "▶"
+		}
+		lines = append(lines, fmt.Sprintf("║ %s %-14s ◀ %-16s ▶ ║", marker, item.label, truncateMarker(item.value(tui), 16)))
+	}
+	lines = append(lines,
+		"╠═══════════════════════════════════════╣",
+		"║ ↑/↓ Select  ←/→ Change  M/Esc Close   ║",
+		"╚═══════════════════════════════════════╝",
+	)
+
+	startY := (snap.Height - len(lines)) / 2
+	startX := (snap.Width - len([]rune(lines[0]))) / 2
+	panelStyle := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background).Bold(true)
+
+	for i, line := range lines {
+		y := startY + i
+		if y >= 0 && y < snap.Height {
+			tui.drawText(startX, y, line, panelStyle)
+		}
+	}
+}
+
+// ReloadConfig re-reads the TOML config and applies every setting that can
+// change at runtime. Only keys present in the file are applied.
+func (tui *TUI) ReloadConfig(path string) error {
+	var config Config
+	meta, err := toml.DecodeFile(path, &config)
+	if err != nil {
+		return err
+	}
+
+	if meta.IsDefined("display", "theme") {
+		tui.SetTheme(config.Display.Theme)
+	}
+	if meta.IsDefined("display", "charset") {
+		tui.SetCharset(ParseCharset(config.Display.Charset))
+	}
+	if meta.IsDefined("display", "projection") {
+		projection, ok := globerender.ParseProjection(config.Display.Projection)
+		if !ok {
+			return fmt.Errorf("display.projection: unknown projection %q", config.Display.Projection)
+		}
+		tui.SetProjection(projection)
+	}
+	if meta.IsDefined("display", "dashboard_wrap") {
+		tui.state.mutex.Lock()
+		tui.state.dashboardWrap = config.Display.DashboardWrap
+		tui.state.mutex.Unlock()
+	}
+	if meta.IsDefined("display", "collapse") {
+		if !slices.Contains(CollapseModes, config.Display.Collapse) {
+			return fmt.Errorf("display.collapse: unknown collapse mode %q", config.Display.Collapse)
+		}
+		tui.state.mutex.Lock()
+		tui.state.collapse = config.Display.Collapse
+		tui.state.mutex.Unlock()
+		tui.MarkDashboardChanged()
+	}
+	if meta.IsDefined("display", "columns") {
+		layout, err := ParseColumnLayout(string(config.Display.Columns))
+		if err != nil {
+			return fmt.Errorf("display.columns: %v", err)
+		}
+		tui.SetColumnLayout(layout)
+	}
+	if meta.IsDefined("display", "repeat_threshold") && GlobalOffenders != nil {
+		if config.Display.RepeatThreshold < 2 {
+			return fmt.Errorf("display.repeat_threshold: must be at least 2")
+		}
+		GlobalOffenders.SetThreshold(config.Display.RepeatThreshold)
+	}
+	if meta.IsDefined("display", "timeline") {
+		tui.state.mutex.Lock()
+		tui.state.showTimeline = config.Display.Timeline
+		tui.state.mutex.Unlock()
+	}
+	if meta.IsDefined("display", "ticker") {
+		if !slices.Contains(TickerModes, config.Display.Ticker) {
+			return fmt.Errorf("display.ticker: unknown ticker mode %q", config.Display.Ticker)
+		}
+		tui.state.mutex.Lock()
+		tui.state.tickerMode = config.Display.Ticker
+		tui.state.mutex.Unlock()
+		tui.MarkDashboardChanged()
+	}
+	if meta.IsDefined("display", "time_format") {
+		if !slices.Contains(TimeFormats, config.Display.TimeFormat) {
+			return fmt.Errorf("display.time_format: unknown time format %q", config.Display.TimeFormat)
+		}
+		tui.state.mutex.Lock()
+		tui.state.timeFormat = config.Display.TimeFormat
+		tui.state.mutex.Unlock()
+		tui.MarkDashboardChanged()
+	}
+	if meta.IsDefined("display", "legend") {
+		tui.state.mutex.Lock()
+		tui.state.showLegend = config.Display.Legend
+		tui.state.mutex.Unlock()
+	}
+	if meta.IsDefined("display", "honeypots") && GlobalArcManager != nil {
+		honeypots, err := ParseHoneypots(config.Display.Honeypots)
+		if err != nil {
+			return fmt.Errorf("display.honeypots: %v", err)
+		}
+		GlobalArcManager.SetHoneypots(honeypots)
+		for _, honeypot := range honeypots {
+			GlobalFindings.NoteHostname(honeypot.Name)
+		}
+	}
+	if meta.IsDefined("display", "subcell") {
+		tui.mutex.Lock()
+		tui.globe.SubCell = config.Display.SubCell
+		tui.mutex.Unlock()
+	}
+	if meta.IsDefined("alerts", "storm_rate") || meta.IsDefined("alerts", "storm_clear") || meta.IsDefined("alerts", "storm_hold") {
+		rate, clear, hold := GlobalStorm.Thresholds()
+		if meta.IsDefined("alerts", "storm_rate") {
+			rate = config.Alerts.StormRate
+		}
+		if meta.IsDefined("alerts", "storm_clear") {
+			clear = config.Alerts.StormClear
+		}
+		if meta.IsDefined("alerts", "storm_hold") {
+			var err error
+			if hold, err = time.ParseDuration(config.Alerts.StormHold); err != nil || hold < 0 {
+				return fmt.Errorf("alerts.storm_hold: invalid duration %q", config.Alerts.StormHold)
+			}
+		}
+		if rate < 0 || clear < 0 || (rate > 0 && clear >= rate) {
+			return fmt.Errorf("alerts.storm_clear: must be below storm_rate")
+		}
+		GlobalStorm.SetThresholds(rate, clear, hold)
+	}
+	if meta.IsDefined("findings", "window") || meta.IsDefined("findings", "usernames") || meta.IsDefined("findings", "new_user_rate") {
+		window, spray, newRate := GlobalFindings.Thresholds()
+		if meta.IsDefined("findings", "window") {
+			var err error
+			if window, err = time.ParseDuration(config.Findings.Window); err != nil || window < time.Minute {
+				return fmt.Errorf("findings.window: invalid duration %q (at least 1m)", config.Findings.Window)
+			}
+		}
+		if meta.IsDefined("findings", "usernames") {
+			spray = config.Findings.Usernames
+		}
+		if meta.IsDefined("findings", "new_user_rate") {
+			newRate = config.Findings.NewUserRate
+		}
+		if spray < 0 || newRate < 0 {
+			return fmt.Errorf("findings: usernames and new_user_rate must not be negative")
+		}
+		GlobalFindings.SetThresholds(window, spray, newRate)
+	}
+	if meta.IsDefined("findings", "hostnames") {
+		for _, name := range strings.Split(config.Findings.Hostnames, ",") {
+			GlobalFindings.NoteHostname(name)
+		}
+	}
+	if meta.IsDefined("malware", "lookup_rate") {
+		if config.Malware.LookupRate < 1 {
+			return fmt.Errorf("malware.lookup_rate: must be at least 1")
+		}
+		GlobalHashes.SetRate(config.Malware.LookupRate)
+	}
+	if meta.IsDefined("malware", "virustotal_key") || meta.IsDefined("malware", "bazaar_key") {
+		GlobalHashes.SetKeys(config.Malware.VirusTotalKey, config.Malware.BazaarKey)
+	}
+	if meta.IsDefined("alerts", "storm_heatmap") {
+		GlobalStorm.SetHeatmap(config.Alerts.StormHeatmap)
+		tui.MarkGlobeChanged()
+	}
+	if meta.IsDefined("display", "jitter") && GlobalGeoIP != nil {
+		if config.Display.Jitter < 0 || config.Display.Jitter > 2 {
+			return fmt.Errorf("display.jitter: must be between 0 and 2")
+		}
+		GlobalGeoIP.SetJitter(config.Display.Jitter)
+	}
+	if meta.IsDefined("display", "layers") {
+		if err := tui.SetLayers(config.Display.Layers); err != nil {
+			return fmt.Errorf("display.layers: %v", err)
+		}
+	}
+	if meta.IsDefined("display", "quality") {
+		if IndexOf(QualityNames, config.Display.Quality) < 0 {
+			return fmt.Errorf("display.quality: unknown quality %q", config.Display.Quality)
+		}
+		tui.SetQuality(config.Display.Quality == "high")
+	}
+	if meta.IsDefined("display", "mouse") {
+		tui.SetMouse(config.Display.Mouse)
+	}
+	if meta.IsDefined("effects", "arc_style") {
+		tui.SetArcStyle(config.Effects.ArcStyle)
+	}
+	if meta.IsDefined("effects", "trail_ms") {
+		SetTrailMS(config.Effects.TrailMS)
+	}
+	if meta.IsDefined("effects", "footprints") && GlobalFootprints != nil {
+		ttl, err := time.ParseDuration(config.Effects.Footprints)
+		if err != nil || ttl < 0 || ttl > 24*time.Hour {
+			return fmt.Errorf("effects.footprints: invalid duration %q", config.Effects.Footprints)
+		}
+		GlobalFootprints.SetTTL(ttl)
+	}
+	if meta.IsDefined("effects", "rain_enabled") || meta.IsDefined("effects", "rain_density") {
+		density := tui.rain.Density()
+		if meta.IsDefined("effects", "rain_density") {
+			density = config.Effects.RainDensity
+		}
+		if meta.IsDefined("effects", "rain_enabled") && !config.Effects.RainEnabled {
+			density = 0
+		}
+		tui.SetRainDensity(density)
+	}
+	if meta.IsDefined("effects", "rain_mask") {
+		tui.rain.SetMasked(config.Effects.RainMask)
+		tui.MarkGlobeChanged()
+	}
+	crtEnabled, glowLevel, crtCurve := tui.crt.Settings()
+	if meta.IsDefined("effects", "crt_enabled") {
+		crtEnabled = config.Effects.CRTEnabled
+	}
+	if meta.IsDefined("effects", "glow_level") {
+		if config.Effects.GlowLevel < 0 || config.Effects.GlowLevel > 3 {
+			return fmt.Errorf("effects.glow_level: must be between 0 and 3")
+		}
+		glowLevel = config.Effects.GlowLevel
+	}
+	if meta.IsDefined("effects", "crt_curve") {
+		crtCurve = config.Effects.CRTCurve
+	}
+	tui.crt.Set(crtEnabled, glowLevel, crtCurve)
+	tui.mutex.Lock()
+	if meta.IsDefined("lighting", "enabled") {
+		tui.globe.Lighting = config.Lighting.Enabled
+	}
+	if meta.IsDefined("lighting", "lon") {
+		tui.globe.LightLon = config.Lighting.Lon
+	}
+	if meta.IsDefined("lighting", "lat") {
+		tui.globe.LightLat = config.Lighting.Lat
+	}
+	if meta.IsDefined("lighting", "follow") {
+		tui.globe.LightFollow = config.Lighting.Follow
+	}
+	tui.mutex.Unlock()
+	rulesPath := GlobalAlertEngine.Path()
+	if meta.IsDefined("alerts", "rules") {
+		rulesPath = config.Alerts.Rules
+	}
+	if err := GlobalAlertEngine.Reload(rulesPath); err != nil {
+		return fmt.Errorf("alert rules: %v", err)
+	}
+	if meta.IsDefined("api", "poll_interval") && api.PrimaryClient != nil {
+		interval, err := time.ParseDuration(config.API.PollInterval)
+		if err != nil || interval < time.Second || interval > 300*time.Second {
+			return fmt.Errorf("api.poll_interval: invalid duration %q", config.API.PollInterval)
+		}
+		for _, client := range api.Clients {
+			client.SetPollInterval(interval)
+		}
+	}
+
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+	tui.MarkStatsChanged()
+	return nil
+}
+
+// WatchConfig reloads the config on SIGHUP or when the file's modification
+// time changes
+func (tui *TUI) WatchConfig(path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	lastMod := time.Time{}
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	api.Workers.Go("config-watcher", func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			reload := false
+			select {
+			case <-stop:
+				signal.Stop(hup)
+				return nil
+			case <-hup:
+				debugLog("Config: SIGHUP received")
+				reload = true
+			case <-ticker.C:
+				if info, err := os.Stat(path); err == nil && info.ModTime() != lastMod {
+					lastMod = info.ModTime()
+					debugLog("Config: %s changed on disk", path)
+					reload = true
+				}
+			}
+			if reload {
+				if err := tui.ReloadConfig(path); err != nil {
+					debugLog("Config: Reload failed: %v", err)
+					globalToasts.Post("Config reload failed: "+err.Error(), true)
+				} else {
+					debugLog("Config: Reloaded %s", path)
+					postToast("Config reloaded")
+				}
+			}
+		}
+	})
+}
+
+func (tui *TUI) PollEvents(aspectRatio float64) chan bool {
+	quit := make(chan bool, 1)
+	api.Workers.Go("input", func(stop <-chan struct{}) error {
+		// PollEvent does not watch stop, so wake it when shutdown begins
+		go func() {
+			<-stop
+			tui.screen.PostEvent(tcell.NewEventInterrupt(nil))
+		}()
+
+		for {
+			ev := tui.screen.PollEvent()
+			if ev == nil {
+				// Screen was finalized during shutdown
+				return nil
+			}
+			switch ev := ev.(type) {
+			case *tcell.EventKey:
+				if tui.frameRate != nil {
+					tui.frameRate.Touch()
+				}
+				if tui.filterSpectatorKey(ev) || tui.handleSearchKey(ev) || tui.handlePaletteKey(ev) {
+					continue
+				}
+				if ev.Key() == tcell.KeyCtrlC {
+					quit <- true
+					return nil
+				}
+				if ev.Key() == tcell.KeyEscape && tui.closePanel() {
+					continue
+				}
+				if r := ev.Rune(); ev.Key() == tcell.KeyRune {
+					if tui.state.triaging && r >= '0' && r <= '3' {
+						tui.TagSelected(int(r - '0'))
+						continue
+					}
+					if r >= '1' && r <= '9' {
+						tui.ApplyViewPreset(int(r - '0'))
+						continue
+					}
+				}
+				if tui.handlePanelKey(ev.Key()) {
+					continue
+				}
+				if tui.handleAction(tui.keys.Action(ev)) {
+					quit <- true
+					return nil
+				}
+			case *tcell.EventMouse:
+				tui.handleMouse(ev)
+			case *tcell.EventResize:
+				tui.HandleResize(aspectRatio)
+			case *tcell.EventInterrupt:
+				select {
+				case <-stop:
+					return nil
+				default:
+				}
+			}
+		}
+	})
+	return quit
+}
+
+// SetMouse turns mouse reporting on for wheel zoom, or off so the terminal
+// selects text as usual
+func (tui *TUI) SetMouse(on bool) {
+	if on {
+		tui.screen.EnableMouse(tcell.MouseButtonEvents)
+	} else {
+		tui.screen.DisableMouse()
+	}
+}
+
+// handleMouse zooms about the pointer when the wheel turns over the globe
+func (tui *TUI) handleMouse(ev *tcell.EventMouse) {
+	if tui.roles != nil && tui.roles.Locked() {
+		return
+	}
+	x, y := ev.Position()
+	globe := tui.Globe()
+	if x >= globe.Width || y >= globe.Height {
+		return
+	}
+	var zoom float64
+	switch {
+	case ev.Buttons()&tcell.WheelUp != 0:
+		zoom = tui.ZoomAt(globe.Zoom+zoomStep, x, y)
+	case ev.Buttons()&tcell.WheelDown != 0:
+		zoom = tui.ZoomAt(globe.Zoom-zoomStep, x, y)
+	default:
+		return
+	}
+	if tui.frameRate != nil {
+		tui.frameRate.Touch()
+	}
+	postToast("Zoom: %.1fx", zoom)
+}
+
+// closePanel closes the innermost open panel or mode for Esc, and reports
+// whether there was one; otherwise Esc does whatever it is bound to
+func (tui *TUI) closePanel() bool {
+	switch {
+	case tui.state.showSettings:
+		tui.ToggleSettings()
+	case tui.state.showSession:
+		tui.ToggleSessionPanel()
+	case tui.state.showURLs:
+		tui.ToggleURLsPanel()
+	case tui.state.triaging:
+		tui.ToggleTriage()
+	case tui.state.searchQuery != "":
+		tui.ClearSearch()
+	case tui.state.scrubbing:
+		tui.ToggleScrub(false)
+	default:
+		return false
+	}
+	return true
+}
+
+// handlePanelKey gives the arrows and PgUp/PgDn to the open panel or mode
+// that moves with them, and reports whether one took the key
+func (tui *TUI) handlePanelKey(key tcell.Key) bool {
+	horizontal := key == tcell.KeyLeft || key == tcell.KeyRight
+	switch key {
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight:
+		switch {
+		case tui.state.showSettings:
+			tui.handleSettingsKey(key)
+		case tui.state.showSession:
+			tui.handleSessionKey(key)
+		case tui.state.showURLs && !horizontal:
+			tui.handleURLsKey(key)
+		case tui.state.scrubbing && horizontal:
+			tui.handleScrubKey(key)
+		case tui.state.statsView != statsViews[0] && horizontal:
+			if key == tcell.KeyLeft {
+				tui.StepStatsDay(-1)
+			} else {
+				tui.StepStatsDay(1)
+			}
+		case tui.state.triaging && !horizontal:
+			// Older rows are higher up the dashboard
+			if key == tcell.KeyUp {
+				tui.MoveTriageCursor(1)
+			} else {
+				tui.MoveTriageCursor(-1)
+			}
+		default:
+			return false
+		}
+		return true
+	case tcell.KeyPgUp, tcell.KeyPgDn:
+		switch {
+		case tui.state.showSession:
+			tui.handleSessionKey(key)
+		case tui.state.showURLs:
+			tui.handleURLsKey(key)
+		case tui.state.scrubbing:
+			tui.handleScrubKey(key)
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// handleAction runs a bound action, and reports whether it was quit
+func (tui *TUI) handleAction(action string) bool {
+	switch action {
+	case "quit":
+		return true
+	case "screenshot":
+		tui.TakeScreenshot()
+	case "report":
+		tui.TakeSessionReport()
+	case "settings":
+		tui.ToggleSettings()
+	case "palette":
+		tui.OpenPalette()
+	case "pause":
+		tui.state.mutex.Lock()
+		tui.state.paused = !tui.state.paused
+		paused := tui.state.paused
+		tui.state.mutex.Unlock()
+		globalToasts.Post(map[bool]string{true: "Paused", false: "Resumed"}[paused], false)
+	case "freeze":
+		postToast("%s", tui.ToggleFreeze())
+	case "speed_down":
+		tui.state.mutex.Lock()
+		tui.state.spinSpeed = math.Max(0.1, tui.state.spinSpeed-0.1)
+		speed := tui.state.spinSpeed
+		tui.state.mutex.Unlock()
+		postToast("Spin speed: %.1fx", speed)
+	case "speed_up":
+		tui.state.mutex.Lock()
+		tui.state.spinSpeed = math.Min(5.0, tui.state.spinSpeed+0.1)
+		speed := tui.state.spinSpeed
+		tui.state.mutex.Unlock()
+		postToast("Spin speed: %.1fx", speed)
+	case "zoom_in":
+		postToast("Zoom: %.1fx", tui.ZoomCenter(tui.Globe().Zoom+zoomStep))
+	case "zoom_out":
+		postToast("Zoom: %.1fx", tui.ZoomCenter(tui.Globe().Zoom-zoomStep))
+	case "nudge_up":
+		tui.Pan(0, -nudgeStep)
+	case "nudge_down":
+		tui.Pan(0, nudgeStep)
+	case "nudge_left":
+		tui.Pan(-nudgeStep, 0)
+	case "nudge_right":
+		tui.Pan(nudgeStep, 0)
+	case "theme":
+		// Cycle themes
+		tui.state.mutex.RLock()
+		next := (tui.state.currentTheme + 1) % len(themeOrder)
+		tui.state.mutex.RUnlock()
+		tui.SetTheme(themeOrder[next])
+		postToast("Theme: %s", themeOrder[next])
+	case "commands":
+		tui.state.mutex.Lock()
+		tui.state.showCommands = !tui.state.showCommands
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+	case "arcs":
+		tui.state.mutex.Lock()
+		tui.state.showArcs = !tui.state.showArcs
+		tui.state.mutex.Unlock()
+		if GlobalArcManager != nil {
+			GlobalArcManager.mutex.Lock()
+			if tui.state.showArcs {
+				// Restore saved style or default to curved
+				if tui.state.savedArcStyle == "" || tui.state.savedArcStyle == "off" {
+					GlobalArcManager.arcStyle = "curved"
+					tui.state.savedArcStyle = "curved"
+				} else {
+					GlobalArcManager.arcStyle = tui.state.savedArcStyle
+				}
+			} else {
+				// Save current style and turn off
+				tui.state.savedArcStyle = GlobalArcManager.arcStyle
+				GlobalArcManager.arcStyle = "off"
+			}
+			GlobalArcManager.mutex.Unlock()
+		}
+		postToast("Arcs: %s", onOff(tui.state.showArcs))
+	case "reset_view":
+		tui.ApplyViewPreset(0)
+	case "follow":
+		tui.ToggleFollow()
+	case "projection":
+		next := globerender.Projection(cycleIndex(int(tui.Globe().Projection), 1, len(globerender.ProjectionNames)))
+		tui.SetProjection(next)
+		postToast("Projection: %s", next)
+	case "lighting":
+		lighting := !tui.Lighting()
+		tui.SetLighting(lighting)
+		postToast("Lighting: %s", onOff(lighting))
+	case "rain":
+		if tui.rain != nil {
+			tui.rain.SetEnabled(!tui.rain.Enabled())
+			tui.MarkGlobeChanged()
+			postToast("Rain: %s", onOff(tui.rain.Enabled()))
+		}
+	case "help":
+		tui.state.mutex.Lock()
+		tui.state.showHelp = !tui.state.showHelp
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+	case "info":
+		tui.state.mutex.Lock()
+		tui.state.showInfo = !tui.state.showInfo
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+	case "stats":
+		tui.state.mutex.Lock()
+		tui.state.showStats = !tui.state.showStats
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+		tui.MarkStatsChanged()
+	case "top_ips":
+		tui.state.mutex.Lock()
+		tui.state.showTopIPs = !tui.state.showTopIPs
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "ports":
+		tui.state.mutex.Lock()
+		tui.state.showPorts = !tui.state.showPorts
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "creds":
+		tui.state.mutex.Lock()
+		tui.state.showCredHist = !tui.state.showCredHist
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "diagnostics":
+		tui.state.mutex.Lock()
+		tui.state.showDiagnostics = !tui.state.showDiagnostics
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "legend":
+		tui.state.mutex.Lock()
+		tui.state.showLegend = !tui.state.showLegend
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+	case "alerts":
+		tui.state.mutex.Lock()
+		tui.state.showAlerts = !tui.state.showAlerts
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "findings":
+		tui.state.mutex.Lock()
+		tui.state.showFindings = !tui.state.showFindings
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "hashes":
+		tui.state.mutex.Lock()
+		tui.state.showHashes = !tui.state.showHashes
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "urls":
+		tui.ToggleURLsPanel()
+	case "ticker":
+		postToast("Ticker: %s", tui.ToggleTicker())
+	case "time_format":
+		postToast("Time: %s", tui.CycleTimeFormat())
+	case "collapse":
+		postToast("Collapse: %s", tui.CycleCollapse())
+	case "stats_view":
+		tui.CycleStatsView()
+	case "countries":
+		tui.state.mutex.Lock()
+		tui.state.choropleth = !tui.state.choropleth
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+	case "coverage":
+		tui.state.mutex.Lock()
+		tui.state.showCoverage = !tui.state.showCoverage
+		tui.state.mutex.Unlock()
+		tui.MarkGlobeChanged()
+		tui.MarkDashboardChanged()
+	case "triage":
+		if tui.state.triaging {
+			tui.TogglePin()
+		} else {
+			tui.ToggleTriage()
+		}
+	case "search":
+		tui.OpenSearch()
+	case "search_prev":
+		tui.StepSearch(-1)
+	case "search_next":
+		tui.StepSearch(1)
+	case "rotate_left":
+		tui.StepRotation(-1)
+	case "rotate_right":
+		tui.StepRotation(1)
+	case "scroll_left":
+		tui.state.mutex.Lock()
+		tui.state.dashboardScroll -= 5
+		if tui.state.dashboardScroll < 0 {
+			tui.state.dashboardScroll = 0
+		}
+		tui.state.mutex.Unlock()
+		tui.MarkDashboardChanged()
+	case "scroll_right":
+		tui.state.mutex.Lock()
+		tui.state.dashboardScroll += 5
+		tui.state.mutex.Unlock()
+		tui.MarkDashboardChanged()
+	case "columns":
+		tui.CycleColumnLayout()
+	case "wrap":
+		tui.ToggleDashboardWrap()
+	case "scroll_home":
+		// Reset scroll to home position
+		tui.state.mutex.Lock()
+		tui.state.dashboardScroll = 0
+		tui.state.mutex.Unlock()
+		tui.MarkDashboardChanged()
+	case "acknowledge":
+		tui.AcknowledgeBanner()
+	case "session":
+		tui.ToggleSessionPanel()
+	case "tag_filter":
+		tui.CycleTagFilter()
+	case "scrub":
+		tui.ToggleScrub(true)
+	case "live":
+		tui.ToggleScrub(false)
+		tui.ScrollDashboardRows(-math.MaxInt32)
+	case "page_up":
+		if tui.state.searchQuery != "" {
+			tui.StepSearch(-tui.dashboardPage())
+		} else {
+			tui.ScrollDashboardRows(tui.dashboardPage())
+		}
+	case "page_down":
+		if tui.state.searchQuery != "" {
+			tui.StepSearch(tui.dashboardPage())
+		} else {
+			tui.ScrollDashboardRows(-tui.dashboardPage())
+		}
+	}
+	return false
+}
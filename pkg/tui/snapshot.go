@@ -0,0 +1,362 @@
+package tui
+
+import (
+	"regexp"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/globerender"
+)
+
+// ViewState is a copy of the TUI toggles that affect what a frame draws
+type ViewState struct {
+	ShowInfo        bool
+	ShowStats       bool
+	ShowTopIPs      bool
+	ShowPorts       bool
+	ShowCredHist    bool
+	ShowDiagnostics bool
+	ShowLegend      bool
+	ShowAlerts      bool
+	ShowFindings    bool
+	ShowHashes      bool
+	ShowURLs        bool
+	ShowSession     bool
+	ShowBanner      bool
+	ShowCoverage    bool
+	ShowTimeline    bool
+	TickerMode      string
+	Scrubbing       bool
+	ScrubEnd        time.Time // Right edge of the timeline, frozen on entering scrub mode
+	ScrubCursor     int
+	SessionCursor   int
+	SessionScroll   int
+	URLScroll       int
+	ShowCommands    bool
+	ShowSettings    bool
+	ShowHelp        bool
+	SettingsCursor  int
+	DashboardScroll int
+	DashboardWrap   bool
+	Collapse        string
+	Following       bool
+	ViewPreset      string
+	Triaging        bool
+	TriageIP        string
+	TriageTime      time.Time
+	TagFilter       string
+	RowFilter       RowFilter
+	DashboardBack   int
+	ScrollMark      int
+	Pinned          []api.Connection
+	SearchPrompt    bool
+	SearchEntry     string
+	SearchQuery     string
+	SearchRe        *regexp.Regexp
+	SearchMatches   ConnectionList // Shared: a new search replaces it, never edits it
+	SearchCursor    int
+	Columns         ColumnLayout
+	StatsView       string
+	StatsDay        time.Time
+	Choropleth      bool
+}
+
+func (s *TUIState) View() ViewState {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return ViewState{
+		ShowInfo:        s.showInfo,
+		ShowStats:       s.showStats,
+		ShowTopIPs:      s.showTopIPs,
+		ShowPorts:       s.showPorts,
+		ShowCredHist:    s.showCredHist,
+		ShowDiagnostics: s.showDiagnostics,
+		ShowLegend:      s.showLegend,
+		ShowAlerts:      s.showAlerts,
+		ShowFindings:    s.showFindings,
+		ShowHashes:      s.showHashes,
+		ShowURLs:        s.showURLs,
+		ShowSession:     s.showSession,
+		ShowBanner:      s.showBanner,
+		ShowCoverage:    s.showCoverage,
+		ShowTimeline:    s.showTimeline,
+		TickerMode:      s.tickerMode,
+		Scrubbing:       s.scrubbing,
+		ScrubEnd:        s.scrubEnd,
+		ScrubCursor:     s.scrubCursor,
+		SessionCursor:   s.sessionCursor,
+		SessionScroll:   s.sessionScroll,
+		URLScroll:       s.urlScroll,
+		ShowCommands:    s.showCommands,
+		ShowSettings:    s.showSettings,
+		ShowHelp:        s.showHelp,
+		SettingsCursor:  s.settingsCursor,
+		DashboardScroll: s.dashboardScroll,
+		DashboardWrap:   s.dashboardWrap,
+		Collapse:        s.collapse,
+		Following:       s.following,
+		ViewPreset:      s.viewPreset,
+		Triaging:        s.triaging,
+		TriageIP:        s.triageIP,
+		TriageTime:      s.triageTime,
+		TagFilter:       s.tagFilter,
+		RowFilter:       s.rowFilter,
+		DashboardBack:   s.dashboardBack,
+		ScrollMark:      s.scrollMark,
+		Pinned:          append([]api.Connection(nil), s.pinned...),
+		SearchPrompt:    s.searchPrompt,
+		SearchEntry:     s.searchEntry,
+		SearchQuery:     s.searchQuery,
+		SearchRe:        s.searchRe,
+		SearchMatches:   s.searchMatches,
+		SearchCursor:    s.searchCursor,
+		Columns:         s.columnLayouts[s.columnLayout].WithTimeFormat(s.timeFormat),
+		StatsView:       s.statsView,
+		StatsDay:        s.statsDay,
+		Choropleth:      s.choropleth,
+	}
+}
+
+// FrameSnapshot is an immutable copy of everything one frame draws. It is
+// assembled once per tick so the globe markers, arcs, dashboard rows and
+// panels always describe the same set of events.
+type FrameSnapshot struct {
+	Taken       time.Time
+	Width       int // Terminal size, so a resize mid-frame cannot tear it
+	Height      int
+	Globe       globerender.Globe // Copy of the view's globe: its size, zoom, charset and projection
+	Theme       *Theme            // Theme the whole frame is drawn in
+	Connections ConnectionList
+	Locations   map[string]api.LocationInfo // Marker position for each IP in Connections (globe frames only)
+	Arcs        []AttackArc                 // Only arcs whose source row is in Connections (globe frames only)
+	ArcStyle    string
+	View        ViewState
+	CredBins    []int // Credential histogram, only filled while the panel is open
+	CredSorted  []int
+	Alerts      []Alert         // Newest first, only filled while the panel is open
+	Findings    []Finding       // Newest first, only filled while the panel is open
+	Hashes      []MalwareHash   // Newest first, only filled while the hashes, info or session panel is open
+	URLs        []AttackerURL   // Newest first, only filled while the panel is open
+	Flashing    map[string]bool // IPs whose markers flash for a recent alert (globe frames only)
+	Banner      []BannerItem    // Unacknowledged banner items, most severe first
+	Coverage    *CoverageMatrix // Only filled while the coverage panel is open
+	Role        string          // Spectator lock indicator, empty without --spectator
+	APIStatus   []StatusBadge   // API endpoint indicators, empty for an anonymous lone endpoint
+	Rate        RateReading     // Live events per minute for the status line gauge
+	Storm       StormState      // Storm alarm, inactive without --storm-rate
+	Rows        ConnectionList  // Dashboard rows: pinned first, then live or scrolled back
+	Pins        int             // Leading entries of Rows that are pinned
+	Frozen      bool            // The feed is frozen, see Dashboard.SetFrozen
+	Held        int             // Rows that arrived while frozen
+	ScrollBack  int             // Rows the dashboard is scrolled back, 0 when live
+	HistoryLen  int             // Rows the dashboard can scroll through
+	SearchRow   api.Connection  // Selected search match
+	Levels      map[string]int  // Repeat offender level per marker IP (globe frames only)
+	Shades      map[string]int  // Choropleth level per country code, nil when off
+	ShadeMax    int             // Events from the most active country
+	Footprints  []Footprint     // Places attacks came from within the footprint time (globe frames only)
+	Honeypots   []Honeypot      // Arc destinations marked on the globe (globe frames only)
+
+	Timeline       []int     // Events per timeline bin
+	TimelineCursor int       // Bin under the scrub cursor
+	ScrubTime      time.Time // Moment the frame shows while scrubbing
+
+	Toasts  []Toast     // Notifications on screen, oldest first
+	Palette PaletteView // The : prompt, when open
+}
+
+// StatusBadge is a status line indicator; Bad ones use the error color
+type StatusBadge struct {
+	Text string
+	Bad  bool
+}
+
+// apiStatusBadges returns the indicators of the API endpoints
+func apiStatusBadges() []StatusBadge {
+	var badges []StatusBadge
+	for _, client := range api.Clients {
+		if text, bad := client.StatusBadge(len(api.Clients) > 1); text != "" {
+			badges = append(badges, StatusBadge{text, bad})
+		}
+	}
+	return badges
+}
+
+// TakeSnapshot copies the shared render data, holding each lock only long
+// enough to copy, then derives markers and arcs from that single copy
+func (tui *TUI) TakeSnapshot() *FrameSnapshot {
+	snap := &FrameSnapshot{
+		Taken:     time.Now(),
+		Locations: make(map[string]api.LocationInfo),
+		ArcStyle:  "off",
+		View:      tui.state.View(),
+	}
+	tui.mutex.RLock()
+	snap.Width, snap.Height = tui.width, tui.height
+	snap.Globe = *tui.globe
+	tui.mutex.RUnlock()
+	snap.Theme = ActiveTheme()
+
+	if snap.View.ShowCredHist && GlobalCredStats != nil {
+		snap.CredBins, snap.CredSorted = GlobalCredStats.Histogram()
+	}
+
+	if snap.View.ShowAlerts && GlobalAlertEngine != nil {
+		snap.Alerts = GlobalAlertEngine.Recent(maxAlertLog)
+	}
+
+	if snap.View.ShowFindings && GlobalFindings != nil {
+		snap.Findings = GlobalFindings.Recent(maxFindings)
+	}
+
+	if (snap.View.ShowHashes || snap.View.ShowInfo || snap.View.ShowSession) && GlobalHashes != nil {
+		snap.Hashes = GlobalHashes.All()
+	}
+
+	if snap.View.ShowURLs && globalURLs != nil {
+		snap.URLs = globalURLs.All()
+	}
+
+	if snap.View.ShowBanner && GlobalBanner != nil {
+		snap.Banner = GlobalBanner.Pending()
+	}
+
+	if tui.roles != nil {
+		snap.Role = tui.roles.Status(snap.Taken)
+	}
+
+	snap.APIStatus = apiStatusBadges()
+	snap.Rate = globalRate.Reading(snap.Taken)
+	if GlobalStorm != nil {
+		snap.Storm = GlobalStorm.State()
+		if snap.Storm.Active && GlobalHistory != nil {
+			snap.Storm.Sources = stormBreakdown(GlobalHistory.Since(snap.Taken.Add(-stormSourceWindow)))
+		}
+	}
+	snap.Toasts = globalToasts.Active(snap.Taken)
+	snap.Palette = tui.palette.View()
+
+	if snap.View.ShowCoverage && GlobalCoverage != nil {
+		coverage := GlobalCoverage.Matrix(snap.Taken)
+		snap.Coverage = &coverage
+	}
+
+	if GlobalHistory != nil && (snap.View.ShowTimeline || snap.View.Scrubbing) {
+		end := snap.Taken
+		if snap.View.Scrubbing {
+			end = snap.View.ScrubEnd
+		}
+		if start, ok := GlobalHistory.Start(); ok {
+			bins := timelineBins(snap.Globe.Width)
+			snap.Timeline = GlobalHistory.Volume(start, end, bins)
+			snap.TimelineCursor = min(snap.View.ScrubCursor, bins-1)
+			snap.ScrubTime = start.Add(end.Sub(start) * time.Duration(snap.TimelineCursor+1) / time.Duration(bins))
+		}
+	}
+
+	// Scrub mode rebuilds the rows from history instead of the live dashboard
+	if snap.View.Scrubbing && GlobalHistory != nil {
+		snap.Connections = GlobalHistory.At(snap.ScrubTime, tui.dashboard.Capacity()).WithSessionHits().WithTags()
+	} else {
+		snap.Connections = tui.dashboard.List().WithSessionHits().WithTags()
+	}
+
+	// The dashboard lists pinned rows, then the live rows or, while scrolled
+	// up, a page of history. A search replaces both with the page of matches
+	// around the selected one, and a :filter with the newest matching rows.
+	rows := snap.Connections
+	if matches := snap.View.SearchMatches; snap.View.SearchQuery != "" && !snap.View.Scrubbing {
+		page := tui.dashboard.Capacity()
+		end := min(max(snap.View.SearchCursor+page/2+1, page), len(matches))
+		rows = append(ConnectionList(nil), matches[max(end-page, 0):end]...).WithSessionHits().WithTags()
+		snap.ScrollBack, snap.HistoryLen = len(matches)-end, len(matches)
+		if len(matches) > 0 {
+			snap.SearchRow = matches[snap.View.SearchCursor]
+		}
+	} else if filter := snap.View.RowFilter; filter.Active() && GlobalHistory != nil && !snap.View.Scrubbing {
+		rows = GlobalHistory.Matching(filter, tui.dashboard.Capacity()).WithSessionHits().WithTags()
+	} else if GlobalHistory != nil {
+		snap.HistoryLen = GlobalHistory.Len()
+		if !snap.View.Scrubbing && snap.View.DashboardBack > 0 {
+			back := snap.View.DashboardBack + GlobalHistory.Recorded() - snap.View.ScrollMark
+			rows, snap.ScrollBack = GlobalHistory.Page(back, tui.dashboard.Capacity())
+			rows = rows.WithSessionHits().WithTags()
+		}
+	}
+	pinned := ConnectionList(snap.View.Pinned).WithSessionHits().WithTags()
+	rows = rows.FilterTag(snap.View.TagFilter).FilterRows(snap.View.RowFilter).without(pinned)
+	// Search matches stay apart so the selected one can be told from its neighbors
+	if snap.View.Collapse != "off" && snap.View.SearchQuery == "" {
+		rows = rows.Collapse()
+	}
+	snap.Rows = append(pinned, rows...)
+	snap.Pins = len(pinned)
+	snap.Frozen, snap.Held = tui.dashboard.Frozen()
+
+	// Scrub mode shades the countries of the rows as they were, and a storm
+	// may shade them whether or not the operator has
+	if snap.View.Choropleth || (snap.Storm.Active && snap.Storm.Heatmap) {
+		counts := globalCountryTally.Counts()
+		if snap.View.Scrubbing {
+			counts = snap.Connections.countBy(func(c api.Connection) string { return c.Country })
+		}
+		snap.Shades, snap.ShadeMax = choroplethShades(counts)
+	}
+
+	// Markers and arcs are only needed when the globe is redrawn this frame
+	tui.mutex.RLock()
+	globeChanged := tui.globeChanged
+	tui.mutex.RUnlock()
+	if !globeChanged {
+		return snap
+	}
+
+	if GlobalGeoIP != nil {
+		for _, conn := range snap.Connections {
+			if _, exists := snap.Locations[conn.IP]; !exists {
+				loc := GlobalGeoIP.LookupIP(conn.IP)
+				if loc.Valid {
+					loc.Latitude, loc.Longitude = GlobalGeoIP.Plot(conn.IP, loc)
+					snap.Locations[conn.IP] = loc
+				}
+			}
+		}
+	}
+
+	if GlobalAlertEngine != nil && !snap.View.Scrubbing {
+		snap.Flashing = GlobalAlertEngine.Flashing(snap.Taken)
+	}
+
+	if GlobalFootprints != nil && !snap.View.Scrubbing {
+		snap.Footprints = GlobalFootprints.Active(snap.Taken)
+	}
+
+	if GlobalOffenders != nil {
+		snap.Levels = make(map[string]int)
+		for _, conn := range snap.Connections {
+			if _, visible := snap.Locations[conn.IP]; visible {
+				if level := GlobalOffenders.Level(conn.Hits); level > 0 {
+					snap.Levels[conn.IP] = level
+				}
+			}
+		}
+	}
+
+	if GlobalArcManager != nil {
+		GlobalArcManager.mutex.RLock()
+		snap.ArcStyle = GlobalArcManager.arcStyle
+		GlobalArcManager.mutex.RUnlock()
+		snap.Honeypots = GlobalArcManager.Honeypots()
+		if snap.View.Scrubbing {
+			snap.Arcs = scrubArcs(snap.Connections, snap.Locations, snap.ScrubTime, snap.Taken)
+			return snap
+		}
+		for _, arc := range GlobalArcManager.GetActiveArcs() {
+			if _, visible := snap.Locations[arc.SrcIP]; visible {
+				snap.Arcs = append(snap.Arcs, arc)
+			}
+		}
+	}
+
+	return snap
+}
@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"crypto/subtle"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// spectatorActions are the actions a locked display still answers: panels,
+// help, the command guide, dashboard scrolling and search. Anything that
+// quits, pauses, moves the camera, changes settings, tags rows or writes
+// files needs the operator.
+var spectatorActions = []string{
+	"info", "stats", "top_ips", "ports", "creds", "diagnostics", "legend", "alerts", "findings", "hashes", "urls", "coverage", "ticker",
+	"commands", "help", "scroll_left", "scroll_right", "scroll_home", "wrap", "collapse", "columns", "time_format",
+	"search", "search_prev", "search_next", "stats_view", "countries",
+	"session", "page_up", "page_down", "live",
+}
+
+// unlockFailedShow is how long a wrong passphrase shows in the status line
+const unlockFailedShow = 3 * time.Second
+
+// RoleLock keeps a display in spectator mode until the operator types the
+// passphrase after Ctrl+O. Without a passphrase the lock only lifts on
+// restart.
+type RoleLock struct {
+	pass      string
+	locked    bool
+	prompting bool
+	entry     []rune
+	failedAt  time.Time
+	mutex     sync.Mutex
+}
+
+func NewRoleLock(pass string) *RoleLock {
+	return &RoleLock{pass: pass, locked: true}
+}
+
+// Locked reports whether only spectator keys are allowed
+func (rl *RoleLock) Locked() bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return rl.locked
+}
+
+// Prompting reports whether keys are going to the passphrase prompt
+func (rl *RoleLock) Prompting() bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	return rl.prompting
+}
+
+// Toggle opens the passphrase prompt on a locked display, or hands an
+// unlocked display back to spectators
+func (rl *RoleLock) Toggle() {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if !rl.locked {
+		rl.locked = true
+		debugLog("Spectator: display locked")
+		return
+	}
+	if rl.pass == "" {
+		return
+	}
+	rl.prompting = true
+	rl.entry = rl.entry[:0]
+}
+
+// Type adds a character to the passphrase being entered
+func (rl *RoleLock) Type(r rune) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if len(rl.entry) < 256 {
+		rl.entry = append(rl.entry, r)
+	}
+}
+
+// Erase removes the last character typed
+func (rl *RoleLock) Erase() {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if len(rl.entry) > 0 {
+		rl.entry = rl.entry[:len(rl.entry)-1]
+	}
+}
+
+// Cancel closes the prompt without trying the entry
+func (rl *RoleLock) Cancel() {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.prompting = false
+	rl.entry = rl.entry[:0]
+}
+
+// Submit unlocks the display if the entry matches the passphrase
+func (rl *RoleLock) Submit(now time.Time) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if subtle.ConstantTimeCompare([]byte(string(rl.entry)), []byte(rl.pass)) == 1 {
+		rl.locked = false
+		rl.failedAt = time.Time{}
+		debugLog("Spectator: operator unlocked the display")
+	} else {
+		rl.failedAt = now
+		debugLog("Spectator: wrong passphrase")
+	}
+	rl.prompting = false
+	rl.entry = rl.entry[:0]
+}
+
+// Status is the status line indicator for the current role
+func (rl *RoleLock) Status(now time.Time) string {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	switch {
+	case rl.prompting:
+		return "[PASS: " + strings.Repeat("*", len(rl.entry)) + "_]"
+	case !rl.locked:
+		return "[OPERATOR]"
+	case now.Sub(rl.failedAt) < unlockFailedShow:
+		return "[DENIED]"
+	}
+	return "[SPECTATOR]"
+}
+
+// filterSpectatorKey runs the unlock prompt and reports whether ev was used
+// up, either by the prompt or because spectators may not press it
+func (tui *TUI) filterSpectatorKey(ev *tcell.EventKey) bool {
+	rl := tui.roles
+	if rl == nil {
+		return false
+	}
+	if ev.Key() == tcell.KeyCtrlO {
+		tui.palette.Close()
+		rl.Toggle()
+		tui.MarkDashboardChanged()
+		return true
+	}
+	if rl.Prompting() {
+		switch ev.Key() {
+		case tcell.KeyEnter:
+			rl.Submit(time.Now())
+		case tcell.KeyEscape:
+			rl.Cancel()
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			rl.Erase()
+		case tcell.KeyRune:
+			rl.Type(ev.Rune())
+		}
+		tui.MarkDashboardChanged()
+		return true
+	}
+	if !rl.Locked() || tui.state.searchPrompt {
+		return false
+	}
+
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		// Only to close the session panel or a search
+		if tui.state.showSession || tui.state.searchQuery != "" {
+			return false
+		}
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight, tcell.KeyPgUp, tcell.KeyPgDn:
+		// Only to scroll the session panel or step the stats history
+		horizontal := ev.Key() == tcell.KeyLeft || ev.Key() == tcell.KeyRight
+		if tui.state.showSession || (horizontal && tui.state.statsView != statsViews[0]) {
+			return false
+		}
+	}
+	return !slices.Contains(spectatorActions, tui.keys.Action(ev))
+}
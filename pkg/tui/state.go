@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"math"
+	"regexp"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+type TUIState struct {
+	paused          bool
+	spinSpeed       float64
+	rotation        float64 // Globe rotation in radians, advanced each frame while not paused
+	showHelp        bool
+	showGrid        bool
+	showArcs        bool
+	showInfo        bool // Show detailed info panel
+	showStats       bool // Show top attackers stats
+	showTopIPs      bool // Show top IP addresses panel
+	showPorts       bool // Show top destination ports panel
+	showCredHist    bool // Show credential pair histogram panel
+	showDiagnostics bool // Show background worker diagnostics panel
+	showLegend      bool // Show symbol legend overlay
+	showAlerts      bool // Show alerts log panel
+	showFindings    bool // Show credential findings panel
+	showHashes      bool // Show malware hashes panel
+	showURLs        bool // Show attacker URLs panel
+	showSession     bool // Show Cowrie session detail panel
+	showBanner      bool // Reserve the banner lane above the dashboard
+	showCoverage    bool // Show sensor protocol coverage panel
+	showTimeline    bool // Show the session timeline bar
+	scrubbing       bool // Scrub mode: draw history as of the timeline cursor
+	scrubEnd        time.Time
+	scrubCursor     int    // Timeline bin under the scrub cursor
+	sessionCursor   int    // Selected interactive session, 0 is the newest
+	sessionScroll   int    // First visible line of the session detail body
+	urlScroll       int    // First visible row of the attacker URLs panel
+	showCommands    bool   // Show command guide
+	tickerMode      string // One of tickerModes
+	timeFormat      string // One of timeFormats
+	showSettings    bool   // Show settings menu overlay
+	settingsCursor  int    // Selected row in the settings menu
+	savedArcStyle   string // Remember the arc style when toggling
+	currentTheme    int
+	dashboardScroll int    // Horizontal scroll offset for dashboard
+	dashboardWrap   bool   // Wrap long dashboard rows instead of scrolling
+	collapse        string // One of collapseModes
+	following       bool   // Follow-attack camera turns to each new attack
+	viewPreset      string // Name of the region preset holding the view, if any
+	triaging        bool   // Triage mode: arrows select a dashboard row to tag
+	triageIP        string // Selected row, by source IP and event time
+	triageTime      time.Time
+	tagFilter       string // One of tagFilters
+	rowFilter       RowFilter
+	dashboardBack   int // Rows the dashboard is scrolled back into history
+	scrollMark      int // History rows recorded when dashboardBack was set
+	pinned          []api.Connection
+	searchPrompt    bool           // Keys go to the / prompt
+	searchEntry     string         // Query being typed at the prompt
+	searchQuery     string         // Last query searched, "" when not searching
+	searchRe        *regexp.Regexp // searchQuery compiled, for highlighting
+	searchMatches   ConnectionList // History rows matching searchQuery, oldest first
+	searchCursor    int            // Selected index in searchMatches
+	columnLayouts   []ColumnLayout // Presets, then any custom layout from --columns
+	columnLayout    int            // Index into columnLayouts
+	statsView       string         // One of statsViews
+	statsDay        time.Time      // Last day shown by the history stats views
+	choropleth      bool           // Shade countries by attack count
+	mutex           sync.RWMutex
+}
+
+func NewTUIState() *TUIState {
+	return &TUIState{
+		paused:       false,
+		spinSpeed:    1.0,
+		showHelp:     false,
+		showGrid:     false,
+		showArcs:     true,
+		currentTheme: 0,
+		columnLayouts: []ColumnLayout{
+			mustColumnLayout("compact"), mustColumnLayout("normal"), mustColumnLayout("wide"),
+		},
+		columnLayout: 1, // normal
+		statsView:    statsViews[0],
+		tickerMode:   "auto",
+		timeFormat:   "local",
+		collapse:     "off",
+	}
+}
+
+// rotationStep is how far < and > turn the globe
+const rotationStep = 5 * math.Pi / 180
+
+// AdvanceRotation spins the globe on by dt at the current speed, so pausing
+// and speed changes carry on from the current angle instead of jumping
+func (s *TUIState) AdvanceRotation(dt time.Duration, period time.Duration) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.paused {
+		s.rotation -= dt.Seconds() / period.Seconds() * 2 * math.Pi * s.spinSpeed
+		s.rotation = math.Mod(s.rotation, 2*math.Pi)
+	}
+	return s.rotation
+}
+
+// StepRotation turns the globe by hand; steps > 0 follow the spin direction
+func (tui *TUI) StepRotation(steps int) {
+	tui.state.mutex.Lock()
+	tui.state.rotation -= float64(steps) * rotationStep
+	tui.state.mutex.Unlock()
+	tui.MarkGlobeChanged()
+}
+
+// Zoom limits, and the steps the keys and mouse wheel take
+const (
+	minZoom   = 0.5
+	maxZoom   = 3.0
+	zoomStep  = 0.1
+	nudgeStep = 0.1 // In globe radii, so the arrows cover the same ground at any zoom
+)
+
+// ZoomAt zooms to zoom, clamped to minZoom-maxZoom, keeping the point of
+// the globe under cell x, y in place
+func (tui *TUI) ZoomAt(zoom float64, x, y int) float64 {
+	zoom = max(minZoom, min(maxZoom, zoom))
+	tui.mutex.Lock()
+	tui.globe.ZoomAt(zoom, x, y)
+	tui.mutex.Unlock()
+	tui.MarkGlobeChanged()
+	return zoom
+}
+
+// Pan moves the view by dx, dy of the globe's radius
+func (tui *TUI) Pan(dx, dy float64) {
+	tui.mutex.Lock()
+	tui.globe.Pan(dx, dy)
+	tui.mutex.Unlock()
+	tui.MarkGlobeChanged()
+}
+
+// ZoomCenter zooms about the middle of the view
+func (tui *TUI) ZoomCenter(zoom float64) float64 {
+	tui.mutex.RLock()
+	x, y := tui.globe.Width/2, tui.globe.Height/2
+	tui.mutex.RUnlock()
+	return tui.ZoomAt(zoom, x, y)
+}
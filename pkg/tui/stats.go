@@ -0,0 +1,307 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+type StatsManager struct {
+	httpClient    *http.Client
+	todayData     api.StatsResponse
+	yesterdayData api.StatsResponse
+	lastFetch     time.Time
+	mutex         sync.RWMutex
+	todayURL      string
+	yesterdayURL  string
+	days          map[string]statsDayCache // History views' days, by YYYYMMDD
+	fetching      map[string]bool          // Days being fetched
+	offline       bool                     // Replays and local logs never call the stats API
+}
+
+// FetchDays fetches the stats of days not cached yet. Finished days never
+// change, so they are fetched once; today is refetched after five minutes
+func (s *StatsManager) FetchDays(days []time.Time) error {
+	today := dayStart(time.Now())
+	var errs []error
+	for _, day := range days {
+		key := day.Format("20060102")
+		s.mutex.Lock()
+		cached, ok := s.days[key]
+		if s.fetching[key] || ok && (day.Before(today) || time.Since(cached.fetched) < 5*time.Minute) {
+			s.mutex.Unlock()
+			continue
+		}
+		s.fetching[key] = true
+		s.mutex.Unlock()
+
+		data, err := s.fetchFromURL(statsURL(day), key)
+
+		s.mutex.Lock()
+		delete(s.fetching, key)
+		if err == nil {
+			s.days[key] = statsDayCache{data: data, fetched: time.Now()}
+		}
+		s.mutex.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DayHourly returns the 24 hourly counts of a day, from the stats API or,
+// where it is higher, the events this client saw
+func (s *StatsManager) DayHourly(day time.Time) []int {
+	s.mutex.RLock()
+	cached := s.days[day.Format("20060102")]
+	s.mutex.RUnlock()
+
+	hours := make([]int, 24)
+	for h := range hours {
+		if len(cached.data) > 0 {
+			hours[h] = cached.data[0].Hourly[strconv.Itoa(h)]
+		}
+		at := time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, time.Local)
+		if local, _ := globalLocalStats.Hourly(at, 0); local > hours[h] {
+			hours[h] = local
+		}
+	}
+	return hours
+}
+
+// ViewSeries returns the bars of a history view: hourly counts for "day",
+// daily totals for "7d" and "30d"
+func (s *StatsManager) ViewSeries(view string, day time.Time) []int {
+	if view == "day" {
+		return s.DayHourly(day)
+	}
+	days := statsDays(view, day)
+	totals := make([]int, len(days))
+	for i, d := range days {
+		for _, n := range s.DayHourly(d) {
+			totals[i] += n
+		}
+	}
+	return totals
+}
+
+func NewStatsManager() *StatsManager {
+	return &StatsManager{
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: api.NewPollingTransport(api.HTTPTransport, api.StatsTransfer),
+		},
+		days:     make(map[string]statsDayCache),
+		fetching: make(map[string]bool),
+	}
+}
+
+func (s *StatsManager) updateURLs() {
+	now := time.Now()
+	s.todayURL = statsURL(now)
+	s.yesterdayURL = statsURL(now.AddDate(0, 0, -1))
+}
+
+func (s *StatsManager) fetchFromURL(url, label string) (api.StatsResponse, error) {
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats api.StatsResponse
+	if err := json.Unmarshal(body, &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (s *StatsManager) FetchData() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.updateURLs()
+
+	if time.Since(s.lastFetch) < 5*time.Minute && len(s.todayData) > 0 {
+		return nil
+	}
+
+	todayData, _ := s.fetchFromURL(s.todayURL, "Today")
+	s.todayData = todayData
+
+	yesterdayData, _ := s.fetchFromURL(s.yesterdayURL, "Yesterday")
+	s.yesterdayData = yesterdayData
+
+	if len(s.todayData) > 0 || len(s.yesterdayData) > 0 {
+		s.lastFetch = time.Now()
+		return nil
+	}
+
+	return fmt.Errorf("no data available")
+}
+
+func (s *StatsManager) GetHourlyData() map[string]int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rollingData := make(map[string]int)
+	now := time.Now()
+	currentHour := now.Hour()
+
+	for i := 0; i < 24; i++ {
+		targetHour := (currentHour - i + 24) % 24
+		targetHourStr := fmt.Sprintf("%d", targetHour)
+
+		var count int
+		if i <= currentHour && len(s.todayData) > 0 {
+			count, _ = s.todayData[0].Hourly[targetHourStr]
+		} else if len(s.yesterdayData) > 0 {
+			count, _ = s.yesterdayData[0].Hourly[targetHourStr]
+		}
+
+		// Locally seen events fill hours the stats API did not answer for
+		// and the current hour, which the API only reports every 5 minutes
+		if local, _ := globalLocalStats.Hourly(now, i); local > count {
+			count = local
+		}
+
+		rollingKey := fmt.Sprintf("%d", 23-i)
+		rollingData[rollingKey] = count
+	}
+
+	return rollingData
+}
+
+func (s *StatsManager) RenderBarGraph(width int) []string {
+	hourlyData := s.GetHourlyData()
+	values := make([]int, 24)
+	for pos := range values {
+		values[pos] = hourlyData[fmt.Sprintf("%d", pos)]
+	}
+	return renderBars(values, 1)
+}
+
+// renderBars draws values as a 3-line bar chart labelled with its maximum,
+// each bar barWidth columns wide
+func renderBars(values []int, barWidth int) []string {
+	maxVal := 0
+	for _, count := range values {
+		if count > maxVal {
+			maxVal = count
+		}
+	}
+
+	if maxVal == 0 {
+		return []string{"", "", ""}
+	}
+
+	lines := make([]string, 3)
+	maxValStr := fmt.Sprintf("%d", maxVal)
+	labelWidth := len(maxValStr) + 1
+
+	for lineIdx := 0; lineIdx < 3; lineIdx++ {
+		var line string
+
+		if lineIdx == 0 {
+			line = fmt.Sprintf("%*s ", labelWidth-1, maxValStr)
+		} else if lineIdx == 2 {
+			line = fmt.Sprintf("%*s ", labelWidth-1, "0")
+		} else {
+			line = fmt.Sprintf("%*s ", labelWidth-1, "")
+		}
+
+		for _, count := range values {
+			normalizedHeight := float64(count) / float64(maxVal) * 3.0
+			lineHeight := 3 - lineIdx
+
+			var barChar rune
+			if normalizedHeight >= float64(lineHeight) {
+				barChar = '#'
+			} else if normalizedHeight >= float64(lineHeight-1) {
+				remainder := normalizedHeight - float64(lineHeight-1)
+				if remainder >= 0.66 {
+					barChar = '#'
+				} else if remainder >= 0.33 {
+					barChar = '='
+				} else if remainder > 0 {
+					barChar = '_'
+				} else {
+					barChar = ' '
+				}
+			} else {
+				barChar = ' '
+			}
+
+			line += strings.Repeat(string(barChar), barWidth)
+		}
+		lines[lineIdx] = line
+	}
+
+	return lines
+}
+
+func (s *StatsManager) RenderSparkline() string {
+	hourlyData := s.GetHourlyData()
+
+	if len(hourlyData) == 0 {
+		return ""
+	}
+
+	maxVal := 0
+	for _, count := range hourlyData {
+		if count > maxVal {
+			maxVal = count
+		}
+	}
+
+	if maxVal == 0 {
+		return strings.Repeat("▁", 24)
+	}
+
+	sparkChars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	var sparkline strings.Builder
+
+	for pos := 0; pos < 24; pos++ {
+		posStr := fmt.Sprintf("%d", pos)
+		count, exists := hourlyData[posStr]
+		if !exists {
+			count = 0
+		}
+
+		normalized := float64(count) / float64(maxVal)
+		charIdx := int(normalized * float64(len(sparkChars)-1))
+		sparkline.WriteRune(sparkChars[charIdx])
+	}
+
+	return sparkline.String()
+}
+
+func (s *StatsManager) HourlySeries() []HourStat {
+	hourlyData := s.GetHourlyData()
+	now := time.Now()
+	series := make([]HourStat, 24)
+	for pos := 0; pos < 24; pos++ {
+		_, protocols := globalLocalStats.Hourly(now, 23-pos)
+		series[pos] = HourStat{Offset: pos, Count: hourlyData[fmt.Sprintf("%d", pos)], Protocols: protocols}
+	}
+	return series
+}
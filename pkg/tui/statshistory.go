@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// statsViews are the stats chart views # cycles through: the rolling last 24
+// hours, one day by the hour, and the 7 or 30 days ending at a day
+var statsViews = []string{"24h", "day", "7d", "30d"}
+
+// statsHistoryDays is how far back ← steps the history views
+const statsHistoryDays = 365
+
+// statsBarWidths widens the bars of views with few of them
+var statsBarWidths = map[string]int{"day": 1, "7d": 3, "30d": 1}
+
+// statsDayCache holds one day's stats API answer
+type statsDayCache struct {
+	data    api.StatsResponse
+	fetched time.Time
+}
+
+// statsURL is the stats API address of one day's hourly counts
+func statsURL(day time.Time) string {
+	return fmt.Sprintf("https://mhn.h-i-r.net/seckcapi/stats/attacks?date=%s", day.Format("20060102"))
+}
+
+// dayStart truncates t to local midnight
+func dayStart(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// statsDays returns the days a history view covers ending at day, oldest first
+func statsDays(view string, day time.Time) []time.Time {
+	n := 1
+	switch view {
+	case "7d":
+		n = 7
+	case "30d":
+		n = 30
+	}
+	days := make([]time.Time, n)
+	for i := range days {
+		days[i] = dayStart(day).AddDate(0, 0, i-n+1)
+	}
+	return days
+}
+
+// statsViewTitle is the status line title of a stats view
+func statsViewTitle(view string, day time.Time) string {
+	switch view {
+	case "day":
+		return "[ HOURLY STATS " + day.Format("2006-01-02") + " ]"
+	case "7d", "30d":
+		days := statsDays(view, day)
+		return "[ DAILY STATS " + days[0].Format("01-02") + ".." + day.Format("01-02") + " ]"
+	}
+	return "[ HOURLY ATTACK STATS ]"
+}
+
+// CycleStatsView moves the stats chart to the next view, starting history
+// views at today
+func (tui *TUI) CycleStatsView() {
+	tui.state.mutex.Lock()
+	view := statsViews[(slices.Index(statsViews, tui.state.statsView)+1)%len(statsViews)]
+	tui.state.statsView = view
+	if tui.state.statsDay.IsZero() {
+		tui.state.statsDay = dayStart(time.Now())
+	}
+	day := tui.state.statsDay
+	tui.state.mutex.Unlock()
+
+	tui.fetchStatsHistory(view, day)
+	tui.MarkStatsChanged()
+	tui.MarkDashboardChanged()
+}
+
+// StepStatsDay moves the day a history view ends at, never past today nor
+// more than statsHistoryDays back
+func (tui *TUI) StepStatsDay(delta int) {
+	today := dayStart(time.Now())
+	tui.state.mutex.Lock()
+	day := tui.state.statsDay.AddDate(0, 0, delta)
+	if day.After(today) {
+		day = today
+	}
+	if oldest := today.AddDate(0, 0, -statsHistoryDays); day.Before(oldest) {
+		day = oldest
+	}
+	tui.state.statsDay = day
+	view := tui.state.statsView
+	tui.state.mutex.Unlock()
+
+	tui.fetchStatsHistory(view, day)
+	tui.MarkStatsChanged()
+	tui.MarkDashboardChanged()
+}
+
+// fetchStatsHistory fetches the days a history view shows in the background
+func (tui *TUI) fetchStatsHistory(view string, day time.Time) {
+	if view == statsViews[0] || tui.stats.offline {
+		return
+	}
+	api.Workers.Go("stats-history", func(stop <-chan struct{}) error {
+		if err := tui.stats.FetchDays(statsDays(view, day)); err != nil {
+			debugLog("Stats: History fetch failed: %v", err)
+		}
+		tui.MarkStatsChanged()
+		return nil
+	})
+}
@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultStormRate   = 300              // Events per minute that start a storm
+	DefaultStormHold   = 30 * time.Second // How long the rate must stay down before a storm clears
+	stormSourceWindow  = time.Minute      // Events the storm banner breaks down by source
+	stormBreakdownTop  = 3
+	stormFlashInterval = 500 * time.Millisecond
+)
+
+// StormDetector watches the events per minute gauge and raises a storm
+// when it reaches the trigger rate. A storm only clears once the rate has
+// stayed at or below the lower clear rate for the hold time, so a rate
+// hovering around the trigger does not make the alarm flap.
+type StormDetector struct {
+	trigger int // Events per minute that start a storm, 0 disables
+	clear   int // Events per minute a storm must fall to, 0 for half the trigger
+	hold    time.Duration
+	heatmap bool // Shade countries by attacks while a storm lasts
+	active  bool
+	start   time.Time
+	peak    int
+	calm    time.Time // When the rate fell to the clear rate, zero while above it
+	mutex   sync.Mutex
+}
+
+// StormState is the detector as one frame sees it
+type StormState struct {
+	Active  bool
+	Start   time.Time
+	Peak    int
+	Heatmap bool
+	Sources string // Where the last minute's events came from, filled in per frame
+}
+
+func NewStormDetector(trigger, clear int, hold time.Duration, heatmap bool) *StormDetector {
+	return &StormDetector{trigger: trigger, clear: clear, hold: hold, heatmap: heatmap}
+}
+
+// Thresholds returns the trigger and clear rates and the hold time
+func (sd *StormDetector) Thresholds() (int, int, time.Duration) {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	return sd.trigger, sd.clear, sd.hold
+}
+
+// SetThresholds changes the rates and hold time, ending any storm when
+// detection is turned off
+func (sd *StormDetector) SetThresholds(trigger, clear int, hold time.Duration) {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	sd.trigger, sd.clear, sd.hold = trigger, clear, hold
+	if trigger == 0 {
+		sd.active = false
+	}
+}
+
+// SetHeatmap sets whether a storm shades countries by attacks
+func (sd *StormDetector) SetHeatmap(heatmap bool) {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	sd.heatmap = heatmap
+}
+
+// clearRate is the rate a storm must fall to before it can clear
+func (sd *StormDetector) clearRate() int {
+	if sd.clear > 0 {
+		return sd.clear
+	}
+	return sd.trigger / 2
+}
+
+// Update feeds the detector the events in the last minute. It returns 1
+// when a storm starts, -1 when one clears and 0 otherwise.
+func (sd *StormDetector) Update(now time.Time, perMinute int) int {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	if sd.trigger == 0 {
+		return 0
+	}
+	if !sd.active {
+		if perMinute < sd.trigger {
+			return 0
+		}
+		sd.active, sd.start, sd.peak, sd.calm = true, now, perMinute, time.Time{}
+		return 1
+	}
+
+	sd.peak = max(sd.peak, perMinute)
+	if perMinute > sd.clearRate() {
+		sd.calm = time.Time{}
+		return 0
+	}
+	if sd.calm.IsZero() {
+		sd.calm = now
+	}
+	if now.Sub(sd.calm) < sd.hold {
+		return 0
+	}
+	sd.active = false
+	return -1
+}
+
+func (sd *StormDetector) State() StormState {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	return StormState{Active: sd.active, Start: sd.start, Peak: sd.peak, Heatmap: sd.heatmap}
+}
+
+// updateStorm feeds the detector the live rate, announcing a storm as it
+// starts and ends
+func (tui *TUI) updateStorm(now time.Time) {
+	rate := globalRate.Reading(now)
+	switch GlobalStorm.Update(now, rate.PerMinute) {
+	case 1:
+		sources := ""
+		if GlobalHistory != nil {
+			sources = stormBreakdown(GlobalHistory.Since(now.Add(-stormSourceWindow)))
+		}
+		text := fmt.Sprintf("Attack storm: %d/min", rate.PerMinute)
+		if sources != "" {
+			text += " · " + sources
+		}
+		debugLog("Storm: %s", text)
+		globalToasts.Post(text, true)
+		PostBanner("critical", "%s", text)
+	case -1:
+		state := GlobalStorm.State()
+		debugLog("Storm: Cleared after %s, peak %d/min", now.Sub(state.Start).Round(time.Second), state.Peak)
+		postToast("Storm over after %s (peak %d/min)", now.Sub(state.Start).Round(time.Second), state.Peak)
+	default:
+		return
+	}
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+}
+
+// stormBreakdown describes where the last minute's events came from, as
+// the share of the busiest countries and protocols, e.g.
+// "CN 42% RU 18% US 9% · ssh 61% telnet 30%"
+func stormBreakdown(rows ConnectionList) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	shares := func(stats []StatEntry) string {
+		parts := make([]string, 0, len(stats))
+		for _, e := range stats[:min(len(stats), stormBreakdownTop)] {
+			parts = append(parts, fmt.Sprintf("%s %d%%", e.Name, e.Count*100/len(rows)))
+		}
+		return strings.Join(parts, " ")
+	}
+	return strings.TrimSuffix(shares(rows.TopCountries(stormBreakdownTop))+" · "+shares(rows.ProtocolBreakdown()), " · ")
+}
+
+// stormHeader is the line that replaces the dashboard header's rule while
+// a storm lasts
+func stormHeader(snap *FrameSnapshot) string {
+	text := fmt.Sprintf("STORM %d/min (peak %d) since %s", snap.Rate.PerMinute, snap.Storm.Peak, snap.Storm.Start.Format("15:04:05"))
+	if snap.Storm.Sources != "" {
+		text += " · " + snap.Storm.Sources
+	}
+	return text
+}
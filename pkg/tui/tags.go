@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// tagNames are the verdicts an operator can put on a source IP, in the order
+// of the 1-3 keys in triage mode
+var tagNames = []string{"investigated", "false positive", "escalated"}
+
+// tagFilters are the dashboard filters Tab cycles through; "" shows every row
+var tagFilters = append([]string{"", "tagged", "untagged"}, tagNames...)
+
+// tagColumn abbreviates a tag for the dashboard's Tag column
+func tagColumn(tag string) string {
+	switch tag {
+	case "investigated":
+		return "INV"
+	case "false positive":
+		return "FP"
+	case "escalated":
+		return "ESC"
+	}
+	return ""
+}
+
+// Tag is an operator's triage verdict on a source IP
+type Tag struct {
+	Name string    `json:"tag"`
+	Time time.Time `json:"tagged_at"`
+}
+
+// TagStore keeps tags by source IP, so every row from an address shares its
+// verdict. With a path, tags are written to a JSON file after each change and
+// loaded again at startup.
+type TagStore struct {
+	path  string
+	tags  map[string]Tag
+	mutex sync.RWMutex
+}
+
+func NewTagStore(path string) (*TagStore, error) {
+	ts := &TagStore{path: path, tags: make(map[string]Tag)}
+	if path == "" {
+		return ts, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &ts.tags); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ts, nil
+}
+
+// Set tags ip, or clears its tag when name is empty
+func (ts *TagStore) Set(ip, name string) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if name == "" {
+		delete(ts.tags, ip)
+	} else {
+		ts.tags[ip] = Tag{Name: name, Time: time.Now()}
+	}
+	return ts.save()
+}
+
+// Len is the number of tagged addresses
+func (ts *TagStore) Len() int {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return len(ts.tags)
+}
+
+// save writes through a temporary file so a crash mid-write cannot leave a
+// truncated tags file; the caller holds ts.mutex
+func (ts *TagStore) save() error {
+	if ts.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ts.tags, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ts.path)
+}
+
+// ToggleTriage enters or leaves triage mode; entering selects the newest row
+func (tui *TUI) ToggleTriage() {
+	tui.state.mutex.Lock()
+	tui.state.triaging = !tui.state.triaging
+	tui.state.triageIP, tui.state.triageTime = "", time.Time{}
+	triaging := tui.state.triaging
+	tui.state.mutex.Unlock()
+	if triaging {
+		tui.MoveTriageCursor(0)
+	}
+	tui.MarkDashboardChanged()
+}
+
+// MoveTriageCursor steps the selection up (positive) or down the rows the
+// dashboard last drew, pinned rows included. The selection follows its row
+// as new events arrive; once the row has scrolled away it restarts at the
+// newest.
+func (tui *TUI) MoveTriageCursor(step int) {
+	tui.state.mutex.RLock()
+	ip, when := tui.state.triageIP, tui.state.triageTime
+	tui.state.mutex.RUnlock()
+	tui.mutex.RLock()
+	rows, visible := tui.dashRows, tui.dashVisible
+	tui.mutex.RUnlock()
+
+	pos := -1
+	for p, i := range visible {
+		if rows[i].IP == ip && rows[i].Time.Equal(when) {
+			pos = p
+			break
+		}
+	}
+	if pos < 0 {
+		pos = len(visible) - 1
+	} else {
+		pos = min(max(pos-step, 0), len(visible)-1)
+	}
+
+	tui.state.mutex.Lock()
+	if pos >= 0 {
+		tui.state.triageIP, tui.state.triageTime = rows[visible[pos]].IP, rows[visible[pos]].Time
+	} else {
+		tui.state.triageIP, tui.state.triageTime = "", time.Time{}
+	}
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+// TagSelected puts tagNames[n-1] on the selected row's source IP, or clears
+// its tag when n is 0
+func (tui *TUI) TagSelected(n int) {
+	tui.state.mutex.RLock()
+	ip := tui.state.triageIP
+	tui.state.mutex.RUnlock()
+	if ip == "" || GlobalTags == nil || n > len(tagNames) {
+		return
+	}
+	name := ""
+	if n > 0 {
+		name = tagNames[n-1]
+	}
+	if err := GlobalTags.Set(ip, name); err != nil {
+		debugLog("Tags: %v", err)
+		PostBanner("warning", "Could not save tags: %v", err)
+	}
+	tui.MarkDashboardChanged()
+}
+
+// maxPinnedRows caps the rows held at the top of the dashboard; pinning
+// another drops the oldest pin
+const maxPinnedRows = 5
+
+// sameRow reports whether a and b are the same dashboard event
+func sameRow(a, b api.Connection) bool {
+	return a.IP == b.IP && a.Time.Equal(b.Time)
+}
+
+// CollapseModes are the --collapse settings, in the order * cycles them. on
+// folds runs of rows from one IP into one; expanded also lists the
+// credentials each run tried under it.
+var CollapseModes = []string{"off", "on", "expanded"}
+
+// maxTriedShown caps the credentials listed under an expanded row
+const maxTriedShown = 20
+
+// triedLines lists the credentials a collapsed row tried, newest last, on
+// ↳ lines indented by indent cells
+func triedLines(conn api.Connection, width, indent int) []string {
+	if len(conn.Tried) < 2 {
+		return nil
+	}
+	tried := conn.Tried
+	text := "tried " + strings.Join(tried[max(len(tried)-maxTriedShown, 0):], ", ")
+	if len(tried) > maxTriedShown {
+		text += fmt.Sprintf(" (+%d earlier)", len(tried)-maxTriedShown)
+	}
+	if width < indent+20 {
+		indent = 2
+	}
+	prefix := strings.Repeat(" ", indent-2) + "↳ "
+	lines := wrapLine(text, width-indent, 0)
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+	return lines
+}
+
+// TogglePin holds the selected row at the top of the dashboard, or releases
+// it if it is already pinned
+func (tui *TUI) TogglePin() {
+	tui.state.mutex.RLock()
+	ip, when := tui.state.triageIP, tui.state.triageTime
+	tui.state.mutex.RUnlock()
+	selected := api.Connection{IP: ip, Time: when}
+	tui.mutex.RLock()
+	i := slices.IndexFunc(tui.dashRows, func(conn api.Connection) bool { return sameRow(conn, selected) })
+	var row api.Connection
+	if i >= 0 {
+		row = tui.dashRows[i]
+	}
+	tui.mutex.RUnlock()
+	if i < 0 {
+		return
+	}
+
+	tui.state.mutex.Lock()
+	if p := slices.IndexFunc(tui.state.pinned, func(pin api.Connection) bool { return sameRow(pin, row) }); p >= 0 {
+		tui.state.pinned = slices.Delete(tui.state.pinned, p, p+1)
+	} else {
+		tui.state.pinned = append(tui.state.pinned, row)
+		if len(tui.state.pinned) > maxPinnedRows {
+			tui.state.pinned = tui.state.pinned[1:]
+		}
+	}
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
+
+// CycleTagFilter steps the dashboard through tagFilters
+func (tui *TUI) CycleTagFilter() {
+	tui.state.mutex.Lock()
+	tui.state.tagFilter = tagFilters[cycleIndex(IndexOf(tagFilters, tui.state.tagFilter), 1, len(tagFilters))]
+	// The selected row may be filtered out; the next arrow picks the newest
+	tui.state.triageIP, tui.state.triageTime = "", time.Time{}
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+}
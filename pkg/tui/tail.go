@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// FileTailer follows a growing log file line by line, like tail -F: when
+// the file is renamed away and replaced, or truncated, it finishes the old
+// file and carries on at the start of the new one
+type FileTailer struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	info    os.FileInfo
+	offset  int64
+	partial string
+}
+
+// OpenFileTailer opens path at the start, or at the end when skipExisting
+// is set. A missing file is not an error; it is picked up once it appears.
+func OpenFileTailer(path string, skipExisting bool) (*FileTailer, error) {
+	ft := &FileTailer{path: path}
+	if err := ft.open(); err != nil {
+		if os.IsNotExist(err) {
+			return ft, nil
+		}
+		return nil, err
+	}
+	if skipExisting {
+		offset, err := ft.file.Seek(0, io.SeekEnd)
+		if err != nil {
+			ft.Close()
+			return nil, err
+		}
+		ft.offset = offset
+		ft.reader.Reset(ft.file)
+	}
+	return ft, nil
+}
+
+func (ft *FileTailer) open() error {
+	file, err := os.Open(ft.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	ft.file, ft.info, ft.offset, ft.partial = file, info, 0, ""
+	ft.reader = bufio.NewReaderSize(file, 64*1024)
+	return nil
+}
+
+// ReadLine returns the next complete line without its newline; ok is false
+// when the writer has not finished another line yet
+func (ft *FileTailer) ReadLine() (line string, ok bool) {
+	if ft.file == nil {
+		return "", false
+	}
+	chunk, err := ft.reader.ReadString('\n')
+	ft.offset += int64(len(chunk))
+	if err != nil {
+		ft.partial += chunk
+		return "", false
+	}
+	line = strings.TrimRight(ft.partial+chunk, "\r\n")
+	ft.partial = ""
+	return line, true
+}
+
+// Poll checks for rotation once the current file is drained, and reports
+// whether there may be more lines to read
+func (ft *FileTailer) Poll() bool {
+	info, err := os.Stat(ft.path)
+	if err != nil {
+		return false // Rotated away and not recreated yet
+	}
+	if ft.file == nil || !os.SameFile(info, ft.info) {
+		if ft.file != nil {
+			debugLog("Tail: %s was rotated", ft.path)
+			ft.file.Close()
+			ft.file = nil
+		}
+		return ft.open() == nil
+	}
+	if info.Size() < ft.offset {
+		debugLog("Tail: %s was truncated", ft.path)
+		if _, err := ft.file.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		ft.offset, ft.partial = 0, ""
+		ft.reader.Reset(ft.file)
+		return true
+	}
+	return info.Size() > ft.offset
+}
+
+func (ft *FileTailer) Close() {
+	if ft.file != nil {
+		ft.file.Close()
+		ft.file = nil
+	}
+}
+
+// tailLoop hands every line of ft to fn until shutdown, polling for more
+// every half second
+func tailLoop(ft *FileTailer, stop <-chan struct{}, fn func(line string)) {
+	for {
+		for {
+			line, ok := ft.ReadLine()
+			if !ok {
+				break
+			}
+			fn(line)
+		}
+		if ft.Poll() {
+			continue
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"math/bits"
+	"os"
+	"sync"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Color depths, least capable first; colorModeNames is indexed by them
+const (
+	colorMono = iota
+	color16
+	color256
+	colorTrue
+)
+
+var ColorModeNames = []string{"mono", "16", "256", "truecolor"}
+
+var UnicodeModes = []string{"auto", "on", "off"}
+
+// TermCaps is what the terminal can show once any override flags are applied
+type TermCaps struct {
+	Colors  int  // colorMono, color16, color256 or colorTrue
+	Unicode bool // false when the locale cannot encode Braille or box drawing
+}
+
+func (tc TermCaps) String() string {
+	text := map[int]string{colorMono: "monochrome", color16: "16 colors", color256: "256 colors", colorTrue: "true color"}[tc.Colors]
+	if tc.Unicode {
+		return text + ", Unicode"
+	}
+	return text + ", ASCII"
+}
+
+// detectTermCaps reads the color count from terminfo (which tcell already
+// upgrades for COLORTERM=truecolor) and honors NO_COLOR; Unicode support is
+// whether the locale's encoding can represent a Braille cell. A mode other
+// than "auto" replaces the detected value.
+func detectTermCaps(screen tcell.Screen, colorMode, unicodeMode string) TermCaps {
+	var caps TermCaps
+	switch n := screen.Colors(); {
+	case os.Getenv("NO_COLOR") != "" || n < 8:
+		caps.Colors = colorMono
+	case n >= 1<<24:
+		caps.Colors = colorTrue
+	case n >= 256:
+		caps.Colors = color256
+	default:
+		caps.Colors = color16
+	}
+	if depth := IndexOf(ColorModeNames, colorMode); depth >= 0 {
+		caps.Colors = depth
+	}
+
+	caps.Unicode = screen.CanDisplay('⣿', false)
+	switch unicodeMode {
+	case "on":
+		caps.Unicode = true
+	case "off":
+		caps.Unicode = false
+	}
+	return caps
+}
+
+// asciiFallbacks stands in for the non-ASCII glyphs the UI draws when the
+// terminal cannot encode them; anything else becomes '?'
+var asciiFallbacks = map[rune]rune{
+	'─': '-', '═': '=', '│': '|', '║': '|',
+	'┌': '+', '┐': '+', '└': '+', '┘': '+', '├': '+', '┤': '+', '┬': '+', '┴': '+', '┼': '+',
+	'╔': '+', '╗': '+', '╚': '+', '╝': '+', '╠': '+', '╣': '+', '╦': '+', '╩': '+', '╬': '+',
+	'▁': '_', '▂': '_', '▃': '=', '▄': '=', '▅': '=', '▆': '#', '▇': '#', '█': '#', '▀': '"', '▏': '|',
+	'░': '.', '▒': ':', '▓': '%',
+	'←': '<', '→': '>', '↑': '^', '↓': 'v', '◀': '<', '▶': '>', '↳': '>', '»': '>',
+	'●': 'o', '○': '.', '✸': '*', '·': '.', '×': 'x', '°': 'o', '▲': '^', '▼': 'v',
+}
+
+func asciiFallback(r rune) rune {
+	if r <= unicode.MaxASCII {
+		return r
+	}
+	if r >= 0x2800 && r <= 0x28FF {
+		// Braille: more raised dots, denser character
+		switch bits.OnesCount(uint(r - 0x2800)) {
+		case 0:
+			return ' '
+		case 1, 2:
+			return '.'
+		case 3, 4:
+			return ':'
+		default:
+			return '#'
+		}
+	}
+	if fallback, ok := asciiFallbacks[r]; ok {
+		return fallback
+	}
+	return '?'
+}
+
+// degradedScreen rewrites every cell on its way to the terminal so themes
+// written for true color stay legible on smaller palettes, and the UI stays
+// readable when the locale is not UTF-8
+type degradedScreen struct {
+	tcell.Screen
+	caps    TermCaps
+	palette []tcell.Color
+	cache   map[tcell.Color]tcell.Color
+	mutex   sync.Mutex
+}
+
+// newDegradedScreen returns screen unchanged when the terminal can show
+// everything
+func newDegradedScreen(screen tcell.Screen, caps TermCaps) tcell.Screen {
+	if caps.Colors == colorTrue && caps.Unicode {
+		return screen
+	}
+	size := 256
+	if caps.Colors == color16 {
+		size = 16
+	}
+	palette := make([]tcell.Color, size)
+	for i := range palette {
+		palette[i] = tcell.PaletteColor(i)
+	}
+	return &degradedScreen{
+		Screen:  screen,
+		caps:    caps,
+		palette: palette,
+		cache:   make(map[tcell.Color]tcell.Color),
+	}
+}
+
+func (ds *degradedScreen) SetContent(x, y int, mainc rune, combc []rune, style tcell.Style) {
+	if !ds.caps.Unicode {
+		mainc = asciiFallback(mainc)
+		combc = nil
+	}
+	ds.Screen.SetContent(x, y, mainc, combc, ds.degrade(style))
+}
+
+func (ds *degradedScreen) SetStyle(style tcell.Style) {
+	ds.Screen.SetStyle(ds.degrade(style))
+}
+
+func (ds *degradedScreen) degrade(style tcell.Style) tcell.Style {
+	fg, bg, attrs := style.Decompose()
+	switch ds.caps.Colors {
+	case colorTrue:
+		return style
+	case colorMono:
+		// Keep highlighted rows visible: a background lighter than the text
+		// becomes reverse video
+		mono := tcell.StyleDefault.Attributes(attrs)
+		if bg.Valid() && luminance(bg) > luminance(fg) {
+			mono = mono.Reverse(attrs&tcell.AttrReverse == 0)
+		}
+		return mono
+	}
+
+	ds.mutex.Lock()
+	nearFg, nearBg := ds.nearest(fg), ds.nearest(bg)
+	ds.mutex.Unlock()
+	// Two distinct colors that land on the same palette entry would hide
+	// the text, so pick whichever of black or white reads on the background
+	if fg.Valid() && bg.Valid() && fg != bg && nearFg == nearBg {
+		if luminance(nearBg) > 128 {
+			nearFg = tcell.ColorBlack
+		} else {
+			nearFg = tcell.ColorWhite
+		}
+	}
+	return style.Foreground(nearFg).Background(nearBg)
+}
+
+// nearest must be called with ds.mutex held
+func (ds *degradedScreen) nearest(c tcell.Color) tcell.Color {
+	if !c.Valid() || (!c.IsRGB() && int(c-tcell.ColorValid) < len(ds.palette)) {
+		return c
+	}
+	if mapped, ok := ds.cache[c]; ok {
+		return mapped
+	}
+	mapped := tcell.FindColor(c, ds.palette)
+	ds.cache[c] = mapped
+	return mapped
+}
+
+// luminance is perceived brightness from 0 to 255; the terminal default
+// counts as dark
+func luminance(c tcell.Color) float64 {
+	if !c.Valid() {
+		return 0
+	}
+	r, g, b := c.RGB()
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Text is laid out in terminal cells, not bytes or runes: "São Paulo" is ten
+// bytes but nine cells, and a CJK character takes two cells. These helpers
+// measure with go-runewidth, the same tables tcell uses to place characters.
+
+// textWidth is the number of cells s takes on screen
+func textWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// padCells pads s with spaces to width cells; longer strings are unchanged
+func padCells(s string, width int) string {
+	return runewidth.FillRight(s, width)
+}
+
+// clipCells cuts s to at most width cells, ending with tail when it had to
+// cut; a wide character is never split
+func clipCells(s string, width int, tail string) string {
+	return runewidth.Truncate(s, width, tail)
+}
+
+// fitCells clips s to width cells, marking a cut with », and pads it to
+// exactly width cells
+func fitCells(s string, width int) string {
+	return padCells(clipCells(s, width, "»"), width)
+}
+
+// sliceCells returns the part of s that falls within width cells starting
+// at cell column from; a wide character crossing either edge is left out
+func sliceCells(s string, from, width int) string {
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		w := runewidth.RuneWidth(r)
+		if col >= from && col+w <= from+width {
+			b.WriteRune(r)
+		}
+		col += w
+		if col >= from+width {
+			break
+		}
+	}
+	return b.String()
+}
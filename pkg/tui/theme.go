@@ -0,0 +1,281 @@
+package tui
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+type Theme struct {
+	Name          string
+	Background    tcell.Color
+	Text          tcell.Color
+	Globe         tcell.Color
+	GlobeShaded   tcell.Color
+	Attack        tcell.Color
+	AttackGlyph   tcell.Color
+	Dashboard     tcell.Color
+	Stats         tcell.Color
+	Separator     tcell.Color
+	StatusOk      tcell.Color
+	StatusError   tcell.Color
+	ArcTrail      tcell.Color
+	RainEffect    tcell.Color
+	ScanlineShade float64 // 0.0-1.0 dimming factor for CRT scanlines
+}
+
+var Themes = map[string]*Theme{
+	"default": {
+		Name:          "default",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.ColorWhite,
+		Globe:         tcell.ColorGreen,
+		GlobeShaded:   tcell.NewRGBColor(0, 100, 0),
+		Attack:        tcell.ColorRed,
+		AttackGlyph:   tcell.NewRGBColor(255, 100, 100),
+		Dashboard:     tcell.ColorYellow,
+		Stats:         tcell.ColorAqua,
+		Separator:     tcell.ColorGray,
+		StatusOk:      tcell.ColorGreen,
+		StatusError:   tcell.ColorRed,
+		ArcTrail:      tcell.NewRGBColor(255, 150, 0),
+		RainEffect:    tcell.ColorGreen,
+		ScanlineShade: 0.7,
+	},
+	"matrix": {
+		Name:          "matrix",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(0, 255, 65),
+		Globe:         tcell.NewRGBColor(0, 255, 65),
+		GlobeShaded:   tcell.NewRGBColor(0, 150, 40),
+		Attack:        tcell.NewRGBColor(0, 255, 100),
+		AttackGlyph:   tcell.NewRGBColor(100, 255, 100),
+		Dashboard:     tcell.NewRGBColor(0, 200, 50),
+		Stats:         tcell.NewRGBColor(0, 180, 45),
+		Separator:     tcell.NewRGBColor(0, 100, 25),
+		StatusOk:      tcell.NewRGBColor(0, 255, 65),
+		StatusError:   tcell.NewRGBColor(0, 150, 40),
+		ArcTrail:      tcell.NewRGBColor(0, 255, 100),
+		RainEffect:    tcell.NewRGBColor(0, 255, 65),
+		ScanlineShade: 0.6,
+	},
+	"amber": {
+		Name:          "amber",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(255, 176, 0),
+		Globe:         tcell.NewRGBColor(255, 176, 0),
+		GlobeShaded:   tcell.NewRGBColor(180, 120, 0),
+		Attack:        tcell.NewRGBColor(255, 200, 50),
+		AttackGlyph:   tcell.NewRGBColor(255, 220, 100),
+		Dashboard:     tcell.NewRGBColor(255, 160, 0),
+		Stats:         tcell.NewRGBColor(220, 140, 0),
+		Separator:     tcell.NewRGBColor(120, 80, 0),
+		StatusOk:      tcell.NewRGBColor(255, 176, 0),
+		StatusError:   tcell.NewRGBColor(180, 100, 0),
+		ArcTrail:      tcell.NewRGBColor(255, 200, 80),
+		RainEffect:    tcell.NewRGBColor(255, 176, 0),
+		ScanlineShade: 0.65,
+	},
+	"solarized": {
+		Name:          "solarized",
+		Background:    tcell.NewRGBColor(0, 43, 54),
+		Text:          tcell.NewRGBColor(131, 148, 150),
+		Globe:         tcell.NewRGBColor(42, 161, 152),
+		GlobeShaded:   tcell.NewRGBColor(30, 110, 105),
+		Attack:        tcell.NewRGBColor(220, 50, 47),
+		AttackGlyph:   tcell.NewRGBColor(255, 100, 97),
+		Dashboard:     tcell.NewRGBColor(181, 137, 0),
+		Stats:         tcell.NewRGBColor(38, 139, 210),
+		Separator:     tcell.NewRGBColor(88, 110, 117),
+		StatusOk:      tcell.NewRGBColor(133, 153, 0),
+		StatusError:   tcell.NewRGBColor(220, 50, 47),
+		ArcTrail:      tcell.NewRGBColor(203, 75, 22),
+		RainEffect:    tcell.NewRGBColor(42, 161, 152),
+		ScanlineShade: 0.75,
+	},
+	"nord": {
+		Name:          "nord",
+		Background:    tcell.NewRGBColor(46, 52, 64),
+		Text:          tcell.NewRGBColor(216, 222, 233),
+		Globe:         tcell.NewRGBColor(136, 192, 208),
+		GlobeShaded:   tcell.NewRGBColor(94, 129, 172),
+		Attack:        tcell.NewRGBColor(191, 97, 106),
+		AttackGlyph:   tcell.NewRGBColor(235, 147, 156),
+		Dashboard:     tcell.NewRGBColor(235, 203, 139),
+		Stats:         tcell.NewRGBColor(129, 161, 193),
+		Separator:     tcell.NewRGBColor(76, 86, 106),
+		StatusOk:      tcell.NewRGBColor(163, 190, 140),
+		StatusError:   tcell.NewRGBColor(191, 97, 106),
+		ArcTrail:      tcell.NewRGBColor(208, 135, 112),
+		RainEffect:    tcell.NewRGBColor(136, 192, 208),
+		ScanlineShade: 0.8,
+	},
+	"dracula": {
+		Name:          "dracula",
+		Background:    tcell.NewRGBColor(40, 42, 54),
+		Text:          tcell.NewRGBColor(248, 248, 242),
+		Globe:         tcell.NewRGBColor(80, 250, 123),
+		GlobeShaded:   tcell.NewRGBColor(50, 150, 80),
+		Attack:        tcell.NewRGBColor(255, 85, 85),
+		AttackGlyph:   tcell.NewRGBColor(255, 121, 198),
+		Dashboard:     tcell.NewRGBColor(241, 250, 140),
+		Stats:         tcell.NewRGBColor(139, 233, 253),
+		Separator:     tcell.NewRGBColor(98, 114, 164),
+		StatusOk:      tcell.NewRGBColor(80, 250, 123),
+		StatusError:   tcell.NewRGBColor(255, 85, 85),
+		ArcTrail:      tcell.NewRGBColor(255, 184, 108),
+		RainEffect:    tcell.NewRGBColor(189, 147, 249),
+		ScanlineShade: 0.7,
+	},
+	"mono": {
+		Name:          "mono",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.ColorWhite,
+		Globe:         tcell.ColorWhite,
+		GlobeShaded:   tcell.ColorGray,
+		Attack:        tcell.ColorWhite,
+		AttackGlyph:   tcell.ColorWhite,
+		Dashboard:     tcell.ColorWhite,
+		Stats:         tcell.ColorWhite,
+		Separator:     tcell.ColorWhite,
+		StatusOk:      tcell.ColorWhite,
+		StatusError:   tcell.ColorWhite,
+		ArcTrail:      tcell.ColorWhite,
+		RainEffect:    tcell.ColorWhite,
+		ScanlineShade: 0.5,
+	},
+	"rainbow": {
+		Name:          "rainbow",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(255, 255, 255),
+		Globe:         tcell.NewRGBColor(255, 0, 0), // Base color (will be rainbow pattern)
+		GlobeShaded:   tcell.NewRGBColor(128, 0, 0),
+		Attack:        tcell.NewRGBColor(255, 255, 255),
+		AttackGlyph:   tcell.NewRGBColor(255, 255, 100),
+		Dashboard:     tcell.NewRGBColor(138, 43, 226),
+		Stats:         tcell.NewRGBColor(0, 191, 255),
+		Separator:     tcell.NewRGBColor(128, 128, 128),
+		StatusOk:      tcell.NewRGBColor(0, 255, 0),
+		StatusError:   tcell.NewRGBColor(255, 0, 0),
+		ArcTrail:      tcell.NewRGBColor(255, 165, 0),
+		RainEffect:    tcell.NewRGBColor(0, 255, 255),
+		ScanlineShade: 0.7,
+	},
+	"skittles": {
+		Name:          "skittles",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(255, 255, 255),
+		Globe:         tcell.NewRGBColor(255, 0, 0), // Base color (will be randomized per character)
+		GlobeShaded:   tcell.NewRGBColor(128, 0, 0),
+		Attack:        tcell.NewRGBColor(255, 255, 0),
+		AttackGlyph:   tcell.NewRGBColor(255, 200, 0),
+		Dashboard:     tcell.NewRGBColor(138, 43, 226),
+		Stats:         tcell.NewRGBColor(0, 191, 255),
+		Separator:     tcell.NewRGBColor(128, 128, 128),
+		StatusOk:      tcell.NewRGBColor(0, 255, 0),
+		StatusError:   tcell.NewRGBColor(255, 0, 0),
+		ArcTrail:      tcell.NewRGBColor(255, 165, 0),
+		RainEffect:    tcell.NewRGBColor(0, 255, 255),
+		ScanlineShade: 0.7,
+	},
+
+	// Colorblind-safe themes use the Okabe-Ito palette. Land, attacks and arcs
+	// differ in both hue along the axis each condition preserves and in
+	// brightness, so they stay apart even where hue is lost.
+	"deuteranopia": {
+		Name:          "deuteranopia",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(240, 240, 240),
+		Globe:         tcell.NewRGBColor(0, 114, 178), // Blue
+		GlobeShaded:   tcell.NewRGBColor(0, 70, 110),
+		Attack:        tcell.NewRGBColor(240, 228, 66), // Yellow
+		AttackGlyph:   tcell.NewRGBColor(255, 245, 150),
+		Dashboard:     tcell.NewRGBColor(230, 159, 0),  // Orange
+		Stats:         tcell.NewRGBColor(86, 180, 233), // Sky blue
+		Separator:     tcell.NewRGBColor(120, 120, 120),
+		StatusOk:      tcell.NewRGBColor(86, 180, 233),
+		StatusError:   tcell.NewRGBColor(230, 159, 0),
+		ArcTrail:      tcell.NewRGBColor(230, 159, 0),
+		RainEffect:    tcell.NewRGBColor(0, 114, 178),
+		ScanlineShade: 0.7,
+	},
+	"protanopia": {
+		Name:          "protanopia",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(240, 240, 240),
+		Globe:         tcell.NewRGBColor(86, 180, 233), // Sky blue
+		GlobeShaded:   tcell.NewRGBColor(40, 100, 140),
+		Attack:        tcell.NewRGBColor(240, 228, 66), // Yellow (reds look dark to protanopes)
+		AttackGlyph:   tcell.NewRGBColor(255, 245, 150),
+		Dashboard:     tcell.NewRGBColor(240, 228, 66),
+		Stats:         tcell.NewRGBColor(86, 180, 233),
+		Separator:     tcell.NewRGBColor(120, 120, 120),
+		StatusOk:      tcell.NewRGBColor(86, 180, 233),
+		StatusError:   tcell.NewRGBColor(240, 228, 66),
+		ArcTrail:      tcell.NewRGBColor(240, 240, 240), // White
+		RainEffect:    tcell.NewRGBColor(0, 114, 178),
+		ScanlineShade: 0.7,
+	},
+	"tritanopia": {
+		Name:          "tritanopia",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(240, 240, 240),
+		Globe:         tcell.NewRGBColor(0, 158, 158), // Teal
+		GlobeShaded:   tcell.NewRGBColor(0, 95, 95),
+		Attack:        tcell.NewRGBColor(230, 60, 60), // Red (blue/yellow is the confused axis)
+		AttackGlyph:   tcell.NewRGBColor(255, 120, 120),
+		Dashboard:     tcell.NewRGBColor(240, 240, 240),
+		Stats:         tcell.NewRGBColor(0, 200, 200),
+		Separator:     tcell.NewRGBColor(120, 120, 120),
+		StatusOk:      tcell.NewRGBColor(0, 200, 200),
+		StatusError:   tcell.NewRGBColor(230, 60, 60),
+		ArcTrail:      tcell.NewRGBColor(204, 121, 167), // Reddish purple
+		RainEffect:    tcell.NewRGBColor(0, 158, 158),
+		ScanlineShade: 0.7,
+	},
+	// High contrast keeps every foreground at least 7:1 against the black
+	// background (WCAG AAA for text); the dimmest color is shaded land at 9:1
+	"high-contrast": {
+		Name:          "high-contrast",
+		Background:    tcell.ColorBlack,
+		Text:          tcell.NewRGBColor(255, 255, 255), // 21:1
+		Globe:         tcell.NewRGBColor(255, 255, 255), // 21:1
+		GlobeShaded:   tcell.NewRGBColor(170, 170, 170), // 9.0:1
+		Attack:        tcell.NewRGBColor(255, 255, 0),   // 19.6:1
+		AttackGlyph:   tcell.NewRGBColor(255, 255, 0),
+		Dashboard:     tcell.NewRGBColor(255, 255, 255),
+		Stats:         tcell.NewRGBColor(0, 255, 255), // 16.7:1
+		Separator:     tcell.NewRGBColor(255, 255, 255),
+		StatusOk:      tcell.NewRGBColor(0, 255, 0),     // 15.3:1
+		StatusError:   tcell.NewRGBColor(255, 110, 110), // 7.6:1
+		ArcTrail:      tcell.NewRGBColor(0, 255, 255),
+		RainEffect:    tcell.NewRGBColor(170, 170, 170),
+		ScanlineShade: 0.9,
+	},
+}
+
+// The theme in use is swapped by SetTheme from the input goroutine while
+// frames render, so it is only reached through activeTheme and
+// setActiveTheme. Frames draw in the copy taken with their snapshot.
+var (
+	themeMutex   sync.RWMutex
+	currentTheme *Theme
+)
+
+// ActiveTheme is the theme in use
+func ActiveTheme() *Theme {
+	themeMutex.RLock()
+	defer themeMutex.RUnlock()
+	return currentTheme
+}
+
+// SetActiveTheme switches the theme in use
+func SetActiveTheme(theme *Theme) {
+	themeMutex.Lock()
+	defer themeMutex.Unlock()
+	currentTheme = theme
+}
+
+// themeOrder is the cycle order used by the T key and the settings menu
+var themeOrder = []string{"default", "matrix", "amber", "solarized", "nord", "dracula", "mono", "rainbow", "skittles",
+	"deuteranopia", "protanopia", "tritanopia", "high-contrast"}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	tickerSpeed     = 12 // Cells a second the marquee moves left
+	tickerSeparator = " · "
+	tickerReplay    = 8 // Newest attacks played again when the marquee runs dry
+)
+
+// TickerModes are the --ticker settings. auto runs the marquee in the
+// compact layout only.
+var TickerModes = []string{"auto", "on", "off"}
+
+// marqueeShown reports whether the marquee runs along the bottom row. The
+// minimal layout lists the newest attacks instead.
+func marqueeShown(mode string, layout Layout) bool {
+	switch mode {
+	case "on":
+		return layout != LayoutMinimal
+	case "auto":
+		return layout == LayoutCompact
+	}
+	return false
+}
+
+type marqueeItem struct {
+	text string
+	at   int // Tape cell of the first character
+}
+
+// Marquee is a tape of attacks moving right to left along the bottom row,
+// tickerSpeed cells a second. Attacks join the tape at the right edge of
+// the screen, or behind the last one still waiting to come on.
+type Marquee struct {
+	items  []marqueeItem
+	tail   int       // Tape cell after the last item and its separator
+	newest time.Time // Newest attack seen, so each joins the tape once
+	start  time.Time
+	mutex  sync.Mutex
+}
+
+func NewMarquee() *Marquee {
+	return &Marquee{start: time.Now()}
+}
+
+// Advance brings the tape up to now on a screen width cells wide and
+// returns its items and how far it has moved. Items that have gone off the
+// left edge are dropped and attacks newer than any seen join the tape, the
+// newest first if more than three screens are waiting. Once the tape runs
+// dry the newest tickerReplay attacks go round again.
+func (m *Marquee) Advance(conns ConnectionList, now time.Time, width int) ([]marqueeItem, int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	scroll := int(now.Sub(m.start).Seconds() * tickerSpeed)
+	m.items = slices.DeleteFunc(m.items, func(item marqueeItem) bool {
+		return item.at+textWidth(item.text) < scroll
+	})
+
+	var fresh ConnectionList
+	room := 3*width - max(m.tail-scroll, 0)
+	for i := len(conns) - 1; i >= 0 && conns[i].Time.After(m.newest); i-- {
+		if room > 0 {
+			fresh = append(fresh, conns[i])
+			room -= textWidth(tickerEntry(conns[i]) + tickerSeparator)
+		}
+	}
+	for _, conn := range conns {
+		if conn.Time.After(m.newest) {
+			m.newest = conn.Time
+		}
+	}
+	if len(fresh) == 0 && len(m.items) == 0 {
+		for i := len(conns) - 1; i >= max(len(conns)-tickerReplay, 0); i-- {
+			fresh = append(fresh, conns[i])
+		}
+	}
+
+	// fresh runs newest first; the oldest goes on the tape first
+	for i := len(fresh) - 1; i >= 0; i-- {
+		text := tickerEntry(fresh[i])
+		at := max(m.tail, scroll+width)
+		m.items = append(m.items, marqueeItem{text: text, at: at})
+		m.tail = at + textWidth(text+tickerSeparator)
+	}
+	return slices.Clone(m.items), scroll
+}
+
+// renderMarquee draws the attack ticker marquee on the bottom row. It runs
+// every frame, since the tape moves with the clock.
+func (tui *TUI) renderMarquee(snap *FrameSnapshot) {
+	y := snap.Height - 1
+	if y < 0 || tui.marquee == nil {
+		return
+	}
+	style := tcell.StyleDefault.Foreground(snap.Theme.Dashboard).Background(snap.Theme.Background)
+	for x := 0; x < snap.Width; x++ {
+		tui.screen.SetContent(x, y, ' ', nil, style)
+	}
+	items, scroll := tui.marquee.Advance(snap.Connections, snap.Taken, snap.Width)
+	if len(items) == 0 {
+		tui.drawText(0, y, clipCells("Waiting for attacks...", snap.Width, ""), style)
+		return
+	}
+	for _, item := range items {
+		x := item.at - scroll
+		if x >= snap.Width {
+			break
+		}
+		tui.drawText(x, y, item.text+tickerSeparator, style)
+	}
+}
+
+// ToggleTicker turns the marquee off where it runs and on where it does not
+func (tui *TUI) ToggleTicker() string {
+	layout := tui.Layout()
+	tui.state.mutex.Lock()
+	if marqueeShown(tui.state.tickerMode, layout) {
+		tui.state.tickerMode = "off"
+	} else {
+		tui.state.tickerMode = "on"
+	}
+	mode := tui.state.tickerMode
+	tui.state.mutex.Unlock()
+	tui.MarkDashboardChanged()
+	return mode
+}
@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+const (
+	maxToasts     = 4
+	toastDuration = 3 * time.Second
+	toastFade     = time.Second // The last part of toastDuration, fading out
+)
+
+// Toast is a short message shown in the top right corner for a few seconds
+type Toast struct {
+	Text  string
+	Bad   bool      // Errors and alerts use the error color
+	Shown time.Time // When it was last posted
+}
+
+// ToastQueue holds the toasts on screen, oldest first
+type ToastQueue struct {
+	toasts []Toast
+	mutex  sync.Mutex
+}
+
+var globalToasts = &ToastQueue{}
+
+// Post shows text as a toast. Posting the text of a toast still on screen
+// restarts its timer instead of stacking a copy.
+func (tq *ToastQueue) Post(text string, bad bool) {
+	tq.mutex.Lock()
+	defer tq.mutex.Unlock()
+	now := time.Now()
+	for i := range tq.toasts {
+		if tq.toasts[i].Text == text {
+			tq.toasts = append(tq.toasts[:i], tq.toasts[i+1:]...)
+			break
+		}
+	}
+	tq.toasts = append(tq.toasts, Toast{Text: text, Bad: bad, Shown: now})
+	if len(tq.toasts) > maxToasts {
+		tq.toasts = tq.toasts[len(tq.toasts)-maxToasts:]
+	}
+}
+
+// Active drops expired toasts and returns the rest, oldest first
+func (tq *ToastQueue) Active(now time.Time) []Toast {
+	tq.mutex.Lock()
+	defer tq.mutex.Unlock()
+	live := tq.toasts[:0]
+	for _, toast := range tq.toasts {
+		if now.Sub(toast.Shown) < toastDuration {
+			live = append(live, toast)
+		}
+	}
+	tq.toasts = live
+	return append([]Toast(nil), live...)
+}
+
+// postToast shows a formatted toast
+func postToast(format string, args ...interface{}) {
+	globalToasts.Post(fmt.Sprintf(format, args...), false)
+}
+
+// ToastAPIStatus tells the operator when client's endpoint goes down or
+// comes back
+func ToastAPIStatus(client *api.APIClient) func(failed bool) {
+	return func(failed bool) {
+		name := "API"
+		if len(api.Clients) > 1 {
+			name = "API " + client.Label()
+		}
+		if failed {
+			globalToasts.Post(name+" unreachable", true)
+		} else {
+			postToast("%s reconnected", name)
+		}
+	}
+}
+
+// Fade is how far the toast has faded at now, from 0 (solid) to 1 (gone)
+func (t Toast) Fade(now time.Time) float64 {
+	left := toastDuration - now.Sub(t.Shown)
+	if left >= toastFade {
+		return 0
+	}
+	return 1 - max(float64(left), 0)/float64(toastFade)
+}
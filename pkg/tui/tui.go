@@ -0,0 +1,509 @@
+// Package tui is the terminal front end of the enhanced globe: the tcell
+// screen, the layered globe and dashboard, the panels, menus and key
+// bindings, and recordings of what is on screen. Events reach it from
+// package api.
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/effects"
+	"SecKC-MHN-Globe/pkg/globerender"
+	"SecKC-MHN-Globe/pkg/stats"
+	"github.com/gdamore/tcell/v2"
+)
+
+var GlobalGeoIP *api.GeoIPManager
+
+var GlobalTUI *TUI
+
+var GlobalArcManager *ArcManager
+
+var GlobalCredStats *stats.CredentialStats
+
+var GlobalHPFeedsPublisher *api.HPFeedsPublisher
+
+var GlobalSyslog *api.SyslogForwarder
+
+var GlobalElastic *api.ElasticSink
+
+var GlobalBuses []*api.BusPublisher
+
+var GlobalMemWatchdog *MemoryWatchdog
+
+var GlobalAlertEngine *AlertEngine
+
+var GlobalOffenders *OffenderTracker
+
+var GlobalHistory *EventHistory
+
+var globalRate = &stats.RateGauge{}
+
+var globalLocalStats = stats.NewAggregator() // Hourly counts the stats graphs fall back to without the stats API
+
+var globalCountryTally = &CountryTally{}
+
+var GlobalFootprints *FootprintTracker
+
+var GlobalBanner *BannerLane
+
+var GlobalStorm *StormDetector
+
+var GlobalFindings *CredentialAnalyzer
+
+var GlobalHashes *HashLookup
+
+var globalURLs = NewURLTracker()
+
+var GlobalCoverage *CoverageTracker
+
+var GlobalTags *TagStore
+
+var GlobalIntel *api.IntelExporter
+
+type TUI struct {
+	screen       tcell.Screen
+	width        int
+	height       int
+	globe        *Globe
+	dashboard    *Dashboard
+	stats        *StatsManager
+	state        *TUIState
+	rain         *effects.Rain
+	crt          *CRTEffect
+	recorder     *AsciinemaRecorder
+	recordFormat string     // One of recordFormats, for recordings started with :record
+	recordMutex  sync.Mutex // Guards recorder, which :record can replace
+	gifExporter  *GIFExporter
+	frameRate    *FrameRateController
+	presets      *ViewPresets
+	caps         TermCaps
+	dashRows     ConnectionList // Dashboard rows as last drawn, for triage selection
+	dashVisible  []int          // Indexes into dashRows on screen, top to bottom
+	dashPins     int            // Leading dashRows that are pinned
+	roles        *RoleLock      // Spectator lock, nil unless --spectator
+	layers       *Compositor
+	keys         *KeyMap
+	palette      *CommandPalette
+	layout       Layout // Chosen from the terminal size on each resize
+	marquee      *Marquee
+	globeChanged bool
+	dashChanged  bool
+	dashDrawn    time.Time // When the dashboard was last drawn, to refresh ages
+	statsChanged bool
+	statsLeft    int // Left edge of the stats chart as last drawn
+	mutex        sync.RWMutex
+}
+
+func NewTUI(aspectRatio float64, charset Charset, recordPath, recordFormat, colorMode, unicodeMode string) (*TUI, error) {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := screen.Init(); err != nil {
+		return nil, err
+	}
+
+	// Degrade themes and glyphs to what the terminal can actually show
+	caps := detectTermCaps(screen, colorMode, unicodeMode)
+	debugLog("Terminal: %s (%d colors reported, charset %s)", caps, screen.Colors(), screen.CharacterSet())
+	screen = newBufferedScreen(newDegradedScreen(screen, caps))
+	if !caps.Unicode && charset != CharsetASCII {
+		debugLog("Terminal: %s charset needs Unicode, falling back to ascii", CharsetNames[charset])
+		charset = CharsetASCII
+	}
+
+	theme := ActiveTheme()
+	screen.SetStyle(tcell.StyleDefault.Background(theme.Background).Foreground(theme.Text))
+	screen.Clear()
+
+	width, height := screen.Size()
+
+	recorder, err := NewAsciinemaRecorder(recordPath, recordFormat, width, height)
+	if err != nil {
+		debugLog("Failed to initialize recorder: %v", err)
+		recorder = &AsciinemaRecorder{enabled: false}
+	}
+
+	tui := &TUI{
+		screen:       screen,
+		width:        width,
+		height:       height,
+		state:        NewTUIState(),
+		rain:         effects.NewRain(width, height, 5),
+		crt:          NewCRTEffect(width, height),
+		recorder:     recorder,
+		recordFormat: recordFormat,
+		caps:         caps,
+		layers:       NewCompositor(),
+		keys:         defaultKeyMap(),
+		palette:      NewCommandPalette(),
+		layout:       chooseLayout(LayoutFull, width, height),
+		marquee:      NewMarquee(),
+		globeChanged: true,
+		dashChanged:  true,
+		statsChanged: true,
+	}
+
+	// CRT effects are applied to each frame as it is sent to the terminal
+	if buffer, ok := screen.(*bufferedScreen); ok {
+		buffer.SetPostProcess(tui.crt.Apply)
+	}
+
+	// Dynamic dashboard width: 50% of terminal, minimum 45, maximum 80
+	dashboardWidth := width / 2
+	if dashboardWidth < 45 {
+		dashboardWidth = 45
+	}
+	if dashboardWidth > 80 {
+		dashboardWidth = 80
+	}
+
+	globeWidth := width - dashboardWidth - 3
+	if globeWidth < 10 {
+		globeWidth = 10
+	}
+
+	tui.globe = globerender.New(globeWidth, height, aspectRatio, charset)
+	tui.dashboard = NewDashboard(height - 4)
+	tui.stats = NewStatsManager()
+
+	return tui, nil
+}
+
+// Dashboard is the dashboard sources add rows to
+func (tui *TUI) Dashboard() *Dashboard {
+	return tui.dashboard
+}
+
+// Options are the startup settings NewTUI does not take
+type Options struct {
+	Offline     bool // No stats API to fetch the graphs from
+	GIF         *GIFExporter
+	Presets     *ViewPresets
+	Keys        *KeyMap
+	FrameRate   *FrameRateController
+	Roles       *RoleLock // Spectator lock, nil unless --spectator
+	SubCell     bool
+	Supersample bool
+	Projection  globerender.Projection
+
+	Lighting    bool
+	LightLon    float64
+	LightLat    float64
+	LightFollow bool
+	CRT         bool
+	Glow        int
+	CRTCurve    bool
+	RainMask    bool
+
+	DashboardWrap bool
+	Collapse      string // One of collapseModes
+	ShowTimeline  bool
+	TickerMode    string // One of tickerModes
+	TimeFormat    string // One of timeFormats
+	ShowLegend    bool
+	ShowBanner    bool
+}
+
+// Configure applies opts, before events and keys start arriving
+func (tui *TUI) Configure(opts Options) {
+	tui.stats.offline = opts.Offline
+	tui.gifExporter = opts.GIF
+	tui.presets = opts.Presets
+	tui.keys = opts.Keys
+	tui.frameRate = opts.FrameRate
+	tui.roles = opts.Roles
+	if tui.recorder.Active() {
+		postToast("Recording started: %s", strings.Join(tui.recorder.Paths(), ", "))
+	}
+	if tui.gifExporter.Active() {
+		postToast("GIF export started: %s", tui.gifExporter.path)
+	}
+
+	tui.mutex.Lock()
+	tui.globe.SubCell = opts.SubCell
+	tui.globe.Supersample = opts.Supersample
+	tui.globe.Projection = opts.Projection
+	if opts.Lighting {
+		tui.globe.Lighting = true
+		tui.globe.LightLon = opts.LightLon
+		tui.globe.LightLat = opts.LightLat
+		tui.globe.LightFollow = opts.LightFollow
+	}
+	tui.mutex.Unlock()
+	tui.crt.Set(opts.CRT, opts.Glow, opts.CRTCurve)
+	tui.rain.SetMasked(opts.RainMask)
+
+	tui.state.mutex.Lock()
+	tui.state.dashboardWrap = opts.DashboardWrap
+	tui.state.collapse = opts.Collapse
+	tui.state.showTimeline = opts.ShowTimeline
+	tui.state.tickerMode = opts.TickerMode
+	tui.state.timeFormat = opts.TimeFormat
+	tui.state.showLegend = opts.ShowLegend
+	tui.state.showBanner = opts.ShowBanner
+	tui.state.mutex.Unlock()
+}
+
+func (tui *TUI) Close() {
+	tui.recordMutex.Lock()
+	if tui.recorder != nil {
+		tui.recorder.Close()
+	}
+	tui.recordMutex.Unlock()
+	if tui.gifExporter != nil {
+		if err := tui.gifExporter.Close(); err != nil {
+			debugLog("GIF export: Failed: %v", err)
+		}
+	}
+	if tui.screen != nil {
+		tui.screen.Fini()
+	}
+}
+
+func (tui *TUI) HandleResize(aspectRatio float64) {
+	// Get new size first (no lock needed for screen operations)
+	newWidth, newHeight := tui.screen.Size()
+
+	tui.mutex.Lock()
+	tui.width = newWidth
+	tui.height = newHeight
+	layout := chooseLayout(tui.layout, newWidth, newHeight)
+	if layout != tui.layout {
+		debugLog("Layout: %s at %dx%d", layout, newWidth, newHeight)
+	}
+	tui.layout = layout
+	tui.mutex.Unlock()
+
+	// Too small for the globe: the minimal layout keeps the globe and the
+	// dashboard as they were for when the terminal grows again
+	if layout == LayoutMinimal {
+		tui.screen.Clear()
+		tui.MarkDashboardChanged()
+		tui.screen.Show()
+		return
+	}
+
+	// Calculate globe width - globe gets more space to expand
+	// Globe takes 60% of width, dashboard gets 40%
+	globeWidth := (newWidth * 60) / 100
+	if globeWidth < 60 {
+		globeWidth = 60
+	}
+	if globeWidth > 200 {
+		globeWidth = 200
+	}
+	globeHeight := newHeight
+	// The compact layout gives the globe everything but the ticker row
+	if layout == LayoutCompact {
+		globeWidth, globeHeight = newWidth, newHeight-1
+	}
+
+	// Preserve and recreate globe
+	tui.mutex.Lock()
+	if tui.globe != nil {
+		charset := tui.globe.Charset
+		lighting := tui.globe.Lighting
+		lightLon := tui.globe.LightLon
+		lightLat := tui.globe.LightLat
+		lightFollow := tui.globe.LightFollow
+		zoom := tui.globe.Zoom
+		nudgeX := tui.globe.NudgeX
+		nudgeY := tui.globe.NudgeY
+		projection := tui.globe.Projection
+		scale := tui.globe.Scale()
+		subCell := tui.globe.SubCell
+		supersample := tui.globe.Supersample
+
+		tui.globe = globerender.New(globeWidth, globeHeight, aspectRatio, charset)
+		tui.globe.SubCell = subCell
+		tui.globe.Supersample = supersample
+		tui.globe.Lighting = lighting
+		tui.globe.LightLon = lightLon
+		tui.globe.LightLat = lightLat
+		tui.globe.LightFollow = lightFollow
+		tui.globe.Zoom = zoom
+		tui.globe.NudgeX = nudgeX
+		tui.globe.NudgeY = nudgeY
+		tui.globe.Projection = projection
+		tui.globe.Rescale(scale)
+	}
+
+	// Restart the rain at the new size
+	if tui.rain != nil {
+		tui.rain.Resize(newWidth, newHeight)
+	}
+
+	// The CRT effect resizes its phosphor with the next frame
+	tui.mutex.Unlock()
+
+	// Update dashboard, unless it is hidden: the rows it would drop for a
+	// shorter terminal are kept for when the full layout comes back
+	if tui.dashboard != nil && layout == LayoutFull {
+		tui.dashboard.mutex.Lock()
+		newMaxLines := newHeight - 4
+		if newMaxLines < 1 {
+			newMaxLines = 1
+		}
+		tui.dashboard.MaxLines = newMaxLines
+		if len(tui.dashboard.Connections) > newMaxLines {
+			tui.dashboard.Connections = tui.dashboard.Connections[len(tui.dashboard.Connections)-newMaxLines:]
+		}
+		tui.dashboard.mutex.Unlock()
+	}
+
+	// Clear and mark for redraw
+	tui.screen.Clear()
+	tui.MarkGlobeChanged()
+	tui.MarkDashboardChanged()
+	tui.MarkStatsChanged()
+	tui.screen.Show()
+}
+
+func (tui *TUI) MarkGlobeChanged() {
+	tui.mutex.Lock()
+	tui.globeChanged = true
+	tui.mutex.Unlock()
+}
+
+func (tui *TUI) MarkDashboardChanged() {
+	tui.mutex.Lock()
+	tui.dashChanged = true
+	tui.mutex.Unlock()
+}
+
+func (tui *TUI) MarkStatsChanged() {
+	tui.mutex.Lock()
+	tui.statsChanged = true
+	tui.mutex.Unlock()
+}
+
+func (tui *TUI) Render(rotation float64, protocolGlyphs bool) {
+	// Every panel draws from the same snapshot so a frame never mixes data
+	// from before and after an event arrives
+	snap := tui.TakeSnapshot()
+
+	frame := &Frame{Snap: snap, Rotation: rotation, ProtocolGlyphs: protocolGlyphs, Layout: tui.Layout()}
+	if frame.Layout != LayoutMinimal {
+		frame.Globe, frame.Kinds = tui.rasterGlobe(snap, rotation, protocolGlyphs)
+	}
+	tui.layers.Compose(tui, frame)
+	if frame.Globe != nil {
+		tui.mutex.Lock()
+		tui.globeChanged = false
+		tui.mutex.Unlock()
+	}
+	tui.screen.Show()
+
+	// Record frame if recording or GIF export is enabled
+	tui.recordMutex.Lock()
+	defer tui.recordMutex.Unlock()
+	recording := tui.recorder != nil && tui.recorder.enabled
+	exporting := tui.gifExporter != nil && tui.gifExporter.Active()
+	if recording || exporting {
+		screen := tui.captureScreen(snap.Width, snap.Height)
+		if recording {
+			tui.recorder.RecordFrame(screen)
+		}
+		if exporting {
+			tui.gifExporter.CaptureFrame(screen)
+		}
+	}
+}
+
+// Recording reports whether a cast, its events or a GIF are being captured
+func (tui *TUI) Recording() bool {
+	tui.recordMutex.Lock()
+	defer tui.recordMutex.Unlock()
+	return (tui.recorder != nil && tui.recorder.Active()) || (tui.gifExporter != nil && tui.gifExporter.Active())
+}
+
+// StartRecording records the screen and events to path from the next frame
+// in the --record-format, ending any recording in progress; an empty path
+// just ends it. It returns the files being written.
+func (tui *TUI) StartRecording(path string) ([]string, error) {
+	width, height := tui.Size()
+	recorder, err := NewAsciinemaRecorder(path, tui.recordFormat, width, height)
+	if err != nil {
+		return nil, err
+	}
+	tui.recordMutex.Lock()
+	defer tui.recordMutex.Unlock()
+	if tui.recorder != nil {
+		tui.recorder.Close()
+	}
+	tui.recorder = recorder
+	return recorder.Paths(), nil
+}
+
+// RecordEvent adds a live event to the recording's events file, if any
+func (tui *TUI) RecordEvent(event api.EnrichedEvent) {
+	tui.recordMutex.Lock()
+	recorder := tui.recorder
+	tui.recordMutex.Unlock()
+	if recorder != nil {
+		recorder.RecordEvent(event)
+	}
+}
+
+// TakeScreenshot saves the current screen as text and SVG in the working directory
+func (tui *TUI) TakeScreenshot() {
+	base, err := SaveScreenshot(tui.captureScreen(tui.Size()), ".")
+	if err != nil {
+		debugLog("Screenshot: Failed: %v", err)
+		globalToasts.Post("Screenshot failed: "+err.Error(), true)
+		return
+	}
+	debugLog("Screenshot: Saved %s.txt and %s.svg", base, base)
+	postToast("Screenshot saved: %s.txt", base)
+}
+
+// TakeSessionReport saves the session's aggregates in the working directory
+func (tui *TUI) TakeSessionReport() {
+	base := sessionReportBase()
+	if _, err := tui.ExportSessionReport(base); err != nil {
+		debugLog("Session report: Failed: %v", err)
+		globalToasts.Post("Report failed: "+err.Error(), true)
+		return
+	}
+	postToast("Report saved: %s.md", base)
+}
+
+// ExportSessionReport writes the session's aggregates as JSON, CSV and
+// Markdown to base.json, base.csv and base.md and returns how many events
+// they cover
+func (tui *TUI) ExportSessionReport(base string) (int, error) {
+	if GlobalHistory == nil {
+		return 0, fmt.Errorf("no session history")
+	}
+	rows, _ := GlobalHistory.Page(0, GlobalHistory.Len())
+	var hourly []HourStat
+	if tui.stats != nil {
+		hourly = tui.stats.HourlySeries()
+	}
+	paths, err := WriteSessionReport(BuildSessionReport(rows, hourly, time.Now()), base)
+	if err != nil {
+		return 0, err
+	}
+	debugLog("Session report: %d events to %s", len(rows), strings.Join(paths, ", "))
+	return len(rows), nil
+}
+
+// captureScreen extracts the top left width x height of the screen with styles
+func (tui *TUI) captureScreen(width, height int) [][]RecordedCell {
+	screen := make([][]RecordedCell, height)
+	for y := 0; y < height; y++ {
+		screen[y] = make([]RecordedCell, width)
+		for x := 0; x < width; x++ {
+			mainc, _, style, _ := tui.screen.GetContent(x, y)
+			screen[y][x] = RecordedCell{Rune: mainc, Style: style}
+		}
+	}
+	return screen
+}
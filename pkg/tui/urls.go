@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+const maxAttackerURLs = 5000
+
+// AttackerURL is a URL attackers fetched or pointed the honeypot at, from
+// Cowrie sessions and dionaea captures
+type AttackerURL struct {
+	URL       string    `json:"url"`
+	Defanged  string    `json:"defanged"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	LastIP    string    `json:"last_ip"`
+}
+
+// URLTracker collects the URLs seen this session, once each with a count
+type URLTracker struct {
+	urls  map[string]*AttackerURL
+	order []string // First seen, oldest first
+	mutex sync.Mutex
+}
+
+func NewURLTracker() *URLTracker {
+	return &URLTracker{urls: make(map[string]*AttackerURL)}
+}
+
+// connectionURLs lists the URLs a row carries
+func connectionURLs(conn api.Connection) []string {
+	var urls []string
+	if conn.Session != nil {
+		urls = append(urls, conn.Session.URLs...)
+	}
+	if conn.Feed != nil && conn.Feed.URL != "" {
+		urls = append(urls, conn.Feed.URL)
+	}
+	return urls
+}
+
+// Note records a URL seen in an event from ip
+func (ut *URLTracker) Note(raw, ip string, t time.Time) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	ut.mutex.Lock()
+	defer ut.mutex.Unlock()
+	if seen := ut.urls[raw]; seen != nil {
+		seen.Count++
+		seen.LastIP = ip
+		if t.After(seen.LastSeen) {
+			seen.LastSeen = t
+		}
+		return
+	}
+	if len(ut.order) >= maxAttackerURLs {
+		delete(ut.urls, ut.order[0])
+		ut.order = ut.order[1:]
+	}
+	ut.urls[raw] = &AttackerURL{URL: raw, Defanged: defangURL(raw), Count: 1, FirstSeen: t, LastSeen: t, LastIP: ip}
+	ut.order = append(ut.order, raw)
+}
+
+// Len is how many unique URLs have been seen
+func (ut *URLTracker) Len() int {
+	ut.mutex.Lock()
+	defer ut.mutex.Unlock()
+	return len(ut.order)
+}
+
+// All returns every URL seen this session, newest first
+func (ut *URLTracker) All() []AttackerURL {
+	ut.mutex.Lock()
+	defer ut.mutex.Unlock()
+	all := make([]AttackerURL, 0, len(ut.order))
+	for i := len(ut.order) - 1; i >= 0; i-- {
+		all = append(all, *ut.urls[ut.order[i]])
+	}
+	return all
+}
+
+// defangURL makes a URL safe to show and paste: hxxp:// for http://, fxp://
+// for ftp:// and [.] for the dots of the host, so no terminal or chat client
+// turns it into a link. Control characters are blanked as for any attacker
+// supplied text.
+func defangURL(raw string) string {
+	raw = mhn.SanitizeText(raw)
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		scheme, rest = "", raw
+	}
+	switch strings.ToLower(scheme) {
+	case "http":
+		scheme = "hxxp"
+	case "https":
+		scheme = "hxxps"
+	case "ftp":
+		scheme = "fxp"
+	}
+	host, path, hasPath := strings.Cut(rest, "/")
+	host = strings.ReplaceAll(host, ".", "[.]")
+	if hasPath {
+		host += "/" + path
+	}
+	if scheme == "" {
+		return host
+	}
+	return scheme + "://" + host
+}
+
+// ExportURLs writes the URLs as CSV with a header line, newest first, each
+// both as it was seen and defanged
+func ExportURLs(urls []AttackerURL, path string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"url", "defanged", "count", "first_seen", "last_seen", "last_ip"})
+	for _, u := range urls {
+		w.Write([]string{u.URL, u.Defanged, strconv.Itoa(u.Count),
+			u.FirstSeen.UTC().Format(time.RFC3339), u.LastSeen.UTC().Format(time.RFC3339), u.LastIP})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"SecKC-MHN-Globe/pkg/api"
+)
+
+// Wordlists are the usernames, passwords and username:password pairs
+// attackers tried, each with how often, most tried first
+type Wordlists struct {
+	Users     []StatEntry
+	Passwords []StatEntry
+	Combos    []StatEntry
+}
+
+// BuildWordlists tallies the credentials of the rows whose protocol is
+// protocol, ignoring case, or of every row when it is empty
+func BuildWordlists(rows ConnectionList, protocol string) Wordlists {
+	if protocol != "" {
+		var kept ConnectionList
+		for _, conn := range rows {
+			if strings.EqualFold(conn.Protocol, protocol) {
+				kept = append(kept, conn)
+			}
+		}
+		rows = kept
+	}
+	return Wordlists{
+		Users:     topN(rows.countBy(func(c api.Connection) string { return c.Username }), 0),
+		Passwords: topN(rows.countBy(func(c api.Connection) string { return c.Password }), 0),
+		Combos:    rows.TopCredentials(0),
+	}
+}
+
+// hashcatWord writes a word the way hashcat reads one per line, hex encoded
+// as $HEX[...] when it holds control characters or could be mistaken for
+// that encoding
+func hashcatWord(word string) string {
+	if strings.HasPrefix(word, "$HEX[") || strings.ContainsFunc(word, unicode.IsControl) || !utf8.ValidString(word) {
+		return "$HEX[" + hex.EncodeToString([]byte(word)) + "]"
+	}
+	return word
+}
+
+// hydraCombo is a pair as a hydra -C login:pass line. hydra has no
+// escaping, so pairs holding control characters are left out.
+func hydraCombo(pair string) (string, bool) {
+	return pair, !strings.ContainsFunc(pair, unicode.IsControl)
+}
+
+// WriteWordlists writes base-users.txt and base-passwords.txt (hashcat and
+// hydra -L/-P), base-combos.txt (hydra -C) and base-counts.csv, replacing
+// any earlier ones, and returns the paths written
+func WriteWordlists(w Wordlists, base string) ([]string, error) {
+	lines := func(entries []StatEntry, format func(string) (string, bool)) []byte {
+		var buf bytes.Buffer
+		for _, e := range entries {
+			if line, ok := format(e.Name); ok {
+				buf.WriteString(line)
+				buf.WriteByte('\n')
+			}
+		}
+		return buf.Bytes()
+	}
+	word := func(name string) (string, bool) { return hashcatWord(name), true }
+
+	var counts bytes.Buffer
+	cw := csv.NewWriter(&counts)
+	cw.Write([]string{"kind", "value", "count"})
+	for _, list := range []struct {
+		kind    string
+		entries []StatEntry
+	}{{"user", w.Users}, {"password", w.Passwords}, {"combo", w.Combos}} {
+		for _, e := range list.entries {
+			cw.Write([]string{list.kind, e.Name, strconv.Itoa(e.Count)})
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		suffix string
+		data   []byte
+	}{
+		{"-users.txt", lines(w.Users, word)},
+		{"-passwords.txt", lines(w.Passwords, word)},
+		{"-combos.txt", lines(w.Combos, hydraCombo)},
+		{"-counts.csv", counts.Bytes()},
+	}
+	var paths []string
+	for _, f := range files {
+		if err := os.WriteFile(base+f.suffix, f.data, 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, base+f.suffix)
+	}
+	return paths, nil
+}
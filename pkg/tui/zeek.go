@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"SecKC-MHN-Globe/pkg/api"
+	"SecKC-MHN-Globe/pkg/mhn"
+)
+
+// ZeekConn is the part of a conn.log record the globe shows
+type ZeekConn struct {
+	Time     time.Time
+	SrcIP    string
+	Protocol string
+	Port     int
+}
+
+// ZeekParser reads conn.log records in Zeek's TSV format, following its
+// #separator and #fields headers, or in JSON (LogAscii::use_json)
+type ZeekParser struct {
+	separator string
+	fields    map[string]int
+}
+
+func NewZeekParser() *ZeekParser {
+	return &ZeekParser{separator: "\t"}
+}
+
+// Parse returns the record on line; ok is false for headers, blank lines,
+// records without an originator, and originators that are private,
+// loopback or link-local and so cannot be placed on the globe
+func (zp *ZeekParser) Parse(line string) (conn ZeekConn, ok bool) {
+	if line == "" {
+		return conn, false
+	}
+	if strings.HasPrefix(line, "#") {
+		zp.header(line)
+		return conn, false
+	}
+
+	var ts, srcIP, port, transport, service string
+	if line[0] == '{' {
+		var record map[string]interface{}
+		if json.Unmarshal([]byte(line), &record) != nil {
+			return conn, false
+		}
+		get := func(key string) string {
+			switch value := record[key].(type) {
+			case string:
+				return value
+			case float64:
+				return strconv.FormatFloat(value, 'f', -1, 64)
+			}
+			return ""
+		}
+		ts, srcIP, port, transport, service = get("ts"), get("id.orig_h"), get("id.resp_p"), get("proto"), get("service")
+	} else {
+		if zp.fields == nil {
+			return conn, false
+		}
+		values := strings.Split(line, zp.separator)
+		get := func(key string) string {
+			if i, ok := zp.fields[key]; ok && i < len(values) && values[i] != "-" && values[i] != "(empty)" {
+				return values[i]
+			}
+			return ""
+		}
+		ts, srcIP, port, transport, service = get("ts"), get("id.orig_h"), get("id.resp_p"), get("proto"), get("service")
+	}
+
+	ip := net.ParseIP(srcIP)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+		return conn, false
+	}
+	conn.SrcIP = srcIP
+	// ts is either epoch seconds or, with JSON timestamps, ISO 8601
+	if seconds, err := strconv.ParseFloat(ts, 64); err == nil {
+		conn.Time = time.Unix(0, int64(seconds*1e9))
+	} else if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		conn.Time = t
+	} else {
+		conn.Time = time.Now()
+	}
+	conn.Port, _ = strconv.Atoi(port)
+	if service != "" {
+		// Zeek lists every analyzer that matched, e.g. "ssl,http"
+		service, _, _ = strings.Cut(service, ",")
+		conn.Protocol = strings.ToLower(service)
+	} else {
+		conn.Protocol = mhn.GuessProtocol(conn.Port, transport)
+	}
+	return conn, true
+}
+
+func (zp *ZeekParser) header(line string) {
+	if sep, ok := strings.CutPrefix(line, "#separator "); ok {
+		if unquoted, err := strconv.Unquote(`"` + sep + `"`); err == nil {
+			zp.separator = unquoted
+		}
+		return
+	}
+	if fields, ok := strings.CutPrefix(line, "#fields"); ok {
+		zp.fields = make(map[string]int)
+		for i, name := range strings.Split(strings.TrimPrefix(fields, zp.separator), zp.separator) {
+			zp.fields[name] = i
+		}
+	}
+}
+
+// StartZeekInput loads conn.log into the session history and, when
+// following, keeps adding the connections Zeek appends. Only the latest
+// maxBackfillEvents existing records are loaded, so a long log does not
+// flood the geolocation lookups.
+func StartZeekInput(path string, follow bool, dashboard *Dashboard) error {
+	ft, err := OpenFileTailer(path, false)
+	if err != nil {
+		return err
+	}
+	parser := NewZeekParser()
+
+	var existing []ZeekConn
+	for {
+		line, ok := ft.ReadLine()
+		if !ok {
+			break
+		}
+		if conn, ok := parser.Parse(line); ok {
+			existing = append(existing, conn)
+			if len(existing) > 2*api.MaxBackfillEvents {
+				existing = append(existing[:0], existing[len(existing)-api.MaxBackfillEvents:]...)
+			}
+		}
+	}
+	if len(existing) > api.MaxBackfillEvents {
+		existing = existing[len(existing)-api.MaxBackfillEvents:]
+	}
+	for _, conn := range existing {
+		addZeekConn(dashboard, conn, false)
+	}
+	debugLog("Zeek: Loaded %d connections from %s", len(existing), path)
+
+	if !follow {
+		ft.Close()
+		return nil
+	}
+	api.Workers.Go("zeek-tail", func(stop <-chan struct{}) error {
+		tailLoop(ft, stop, func(line string) {
+			if conn, ok := parser.Parse(line); ok {
+				addZeekConn(dashboard, conn, true)
+			}
+		})
+		return nil
+	})
+	return nil
+}
+
+func addZeekConn(dashboard *Dashboard, conn ZeekConn, live bool) {
+	if GlobalCoverage != nil {
+		GlobalCoverage.Record("zeek", conn.Protocol, conn.Time)
+	}
+	// Connections carry no credentials; show them the way the API shows
+	// credential-less events
+	if live {
+		dashboard.AddConnection(conn.SrcIP, "connection", conn.Protocol, conn.Protocol, conn.Port)
+	} else {
+		dashboard.Backfill(conn.Time, conn.SrcIP, "connection", conn.Protocol, conn.Protocol, conn.Port, nil)
+	}
+}
@@ -2,14 +2,40 @@ package main
 
 // Creates an ASCII bitmap from an equirectangle projection PNG of Earth to help render a globe.
 // Original Projection borrowed from https://github.com/arscan/encom-globe
+//
+// With -countries it instead creates the country bitmap used by the globe's
+// choropleth mode: every land cell (and the water next to it, so coasts line
+// up with the hand-tuned land bitmap) gets the ISO 3166 code of the nearest
+// reference point in the CSV. Large or oddly shaped countries list several
+// points, so the regions follow their borders roughly rather than exactly.
 
 import (
+	"encoding/csv"
+	"flag"
 	"fmt"
+	"image"
 	"image/png"
+	"math"
 	"os"
+	"strconv"
 )
 
+// Target ASCII dimensions (reasonable for terminal display)
+const (
+	targetWidth  = 120
+	targetHeight = 60
+)
+
+// countryPoint is one reference point of a country
+type countryPoint struct {
+	code     string
+	lat, lon float64
+}
+
 func main() {
+	countriesFile := flag.String("countries", "", "CSV of code,lat,lon reference points; print the country bitmap instead")
+	flag.Parse()
+
 	// Open the PNG file
 	file, err := os.Open("equirectangle_projection.png")
 	if err != nil {
@@ -25,39 +51,115 @@ func main() {
 		return
 	}
 
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-	
-	// Target ASCII dimensions (reasonable for terminal display)
-	targetWidth := 120
-	targetHeight := 60
+	land := landCells(img)
 
-	// Scale factors
-	scaleX := float64(width) / float64(targetWidth)
-	scaleY := float64(height) / float64(targetHeight)
+	if *countriesFile != "" {
+		points, err := loadCountryPoints(*countriesFile)
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", *countriesFile, err)
+			return
+		}
+		printCountryBitmap(land, points)
+		return
+	}
 
 	// Convert to ASCII
-	fmt.Println("func getEarthBitmap() []string {")
+	fmt.Println("func EarthBitmap() []string {")
 	fmt.Println("\treturn []string{")
 
 	for y := 0; y < targetHeight; y++ {
 		fmt.Print("\t\t\"")
 		for x := 0; x < targetWidth; x++ {
+			if land[y][x] {
+				// dark areas are land: "#"
+				fmt.Print("#")
+			} else {
+				// light areas are water: " "
+				fmt.Print(" ")
+			}
+		}
+		fmt.Println("\",")
+	}
+
+	fmt.Println("\t}")
+	fmt.Println("}")
+}
+
+// landCells samples the projection down to the target grid
+func landCells(img image.Image) [][]bool {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	// Scale factors
+	scaleX := float64(width) / float64(targetWidth)
+	scaleY := float64(height) / float64(targetHeight)
+
+	land := make([][]bool, targetHeight)
+	for y := range land {
+		land[y] = make([]bool, targetWidth)
+		for x := range land[y] {
 			imgX := int(float64(x) * scaleX)
 			imgY := int(float64(y) * scaleY)
 
 			// Get pixel color
 			r, g, b, _ := img.At(imgX, imgY).RGBA()
-			
+
 			// Source image is B/W so keep it simple
-			if ((r + g + b) / 3 > 128){
-				// light areas are water: " "
-				fmt.Print(" ")
-			} else {
-				// dark areas are land: "#"
-				fmt.Print("#")
+			land[y][x] = (r+g+b)/3 <= 128
+		}
+	}
+	return land
+}
+
+func loadCountryPoints(path string) ([]countryPoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var points []countryPoint
+	for i, record := range records {
+		if len(record) != 3 || len(record[0]) != 2 {
+			return nil, fmt.Errorf("line %d: want code,lat,lon", i+1)
+		}
+		lat, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		lon, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		points = append(points, countryPoint{record[0], lat, lon})
+	}
+	return points, nil
+}
+
+// printCountryBitmap prints two characters per cell, the country code or
+// two spaces for open water
+func printCountryBitmap(land [][]bool, points []countryPoint) {
+	fmt.Println("func CountryBitmap() []string {")
+	fmt.Println("\treturn []string{")
+
+	for y := 0; y < targetHeight; y++ {
+		fmt.Print("\t\t\"")
+		for x := 0; x < targetWidth; x++ {
+			if !nearLand(land, x, y) {
+				fmt.Print("  ")
+				continue
 			}
+			lat := 90 - (float64(y)+0.5)*180/targetHeight
+			lon := -180 + (float64(x)+0.5)*360/targetWidth
+			fmt.Print(nearestCountry(points, lat, lon))
 		}
 		fmt.Println("\",")
 	}
@@ -65,3 +167,34 @@ func main() {
 	fmt.Println("\t}")
 	fmt.Println("}")
 }
+
+func nearLand(land [][]bool, x, y int) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			ny, nx := y+dy, (x+dx+targetWidth)%targetWidth
+			if ny >= 0 && ny < targetHeight && land[ny][nx] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func nearestCountry(points []countryPoint, lat, lon float64) string {
+	best, bestDist := "  ", math.Inf(1)
+	for _, p := range points {
+		if d := greatCircle(lat, lon, p.lat, p.lon); d < bestDist {
+			best, bestDist = p.code, d
+		}
+	}
+	return best
+}
+
+// greatCircle returns the angle between two points in radians
+func greatCircle(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * math.Asin(math.Sqrt(a))
+}